@@ -0,0 +1,113 @@
+package tcclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, wrapped, for requests rejected by a
+// CircuitBreaker without being sent.  Requests failing with this error are
+// not retried.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreaker stops requests to a host after a run of consecutive
+// failures, so that a degraded deployment is not further loaded by retries
+// from many clients.  Failures are network errors and HTTP 5xx and 429
+// responses.
+//
+// Once FailureThreshold consecutive requests to a host have failed, the
+// circuit for that host is open: requests fail immediately with
+// ErrCircuitOpen, for OpenDuration.  After that, a single request is sent as
+// a probe; if it succeeds the circuit closes, and if it fails the circuit
+// stays open for another OpenDuration.
+//
+// A CircuitBreaker is safe for concurrent use, and is intended to be shared
+// between all the clients of a process, by adding its Intercept method to
+// each client's Interceptors:
+//
+//	breaker := tcclient.NewCircuitBreaker(5, 30*time.Second)
+//	queue.Interceptors = append(queue.Interceptors, breaker.Intercept)
+type CircuitBreaker struct {
+	// Number of consecutive failures after which the circuit opens; values
+	// less than one are treated as one
+	FailureThreshold int
+	// Time for which an open circuit rejects requests before probing
+	OpenDuration time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+	// for testing
+	now func() time.Time
+}
+
+// the state of the circuit for a single host
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with the given settings.
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		OpenDuration:     openDuration,
+	}
+}
+
+func (breaker *CircuitBreaker) clock() time.Time {
+	if breaker.now != nil {
+		return breaker.now()
+	}
+	return time.Now()
+}
+
+// Intercept is an Interceptor applying the circuit breaker to a request.
+func (breaker *CircuitBreaker) Intercept(req *http.Request, next RequestHandler) (*http.Response, error) {
+	host := req.URL.Host
+
+	breaker.mu.Lock()
+	if breaker.hosts == nil {
+		breaker.hosts = map[string]*circuit{}
+	}
+	c, ok := breaker.hosts[host]
+	if !ok {
+		c = &circuit{}
+		breaker.hosts[host] = c
+	}
+	threshold := max(breaker.FailureThreshold, 1)
+	probe := false
+	if c.failures >= threshold {
+		if c.probing || breaker.clock().Before(c.openUntil) {
+			failures := c.failures
+			breaker.mu.Unlock()
+			return nil, fmt.Errorf("%w for %s after %d consecutive failures", ErrCircuitOpen, host, failures)
+		}
+		c.probing = true
+		probe = true
+	}
+	breaker.mu.Unlock()
+
+	resp, err := next(req)
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	if probe {
+		c.probing = false
+	}
+	switch {
+	case req.Context().Err() != nil:
+		// an abandoned request says nothing about the host
+	case err != nil || resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests:
+		c.failures++
+		if c.failures >= threshold {
+			c.openUntil = breaker.clock().Add(breaker.OpenDuration)
+		}
+	default:
+		c.failures = 0
+	}
+	return resp, err
+}
@@ -0,0 +1,102 @@
+package tcclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerOpensAndCloses(t *testing.T) {
+	var requests int32
+	var healthy atomic.Bool
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+
+	now := time.Now()
+	breaker := NewCircuitBreaker(3, time.Minute)
+	breaker.now = func() time.Time { return now }
+	client := Client{
+		RootURL:      s.URL,
+		Interceptors: []Interceptor{breaker.Intercept},
+		RetryPolicy:  &RetryPolicy{MaxAttempts: 1},
+	}
+
+	for i := 0; i < 3; i++ {
+		_, _, err := client.APICall(nil, "GET", "/ping", nil, nil)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// the circuit is now open, and requests are not sent
+	_, _, err := client.APICall(nil, "GET", "/ping", nil, nil)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// after the open duration, a failing probe re-opens the circuit
+	now = now.Add(time.Minute)
+	_, _, err = client.APICall(nil, "GET", "/ping", nil, nil)
+	require.False(t, errors.Is(err, ErrCircuitOpen))
+	require.Equal(t, int32(4), atomic.LoadInt32(&requests))
+	_, _, err = client.APICall(nil, "GET", "/ping", nil, nil)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	// and a successful probe closes it
+	healthy.Store(true)
+	now = now.Add(time.Minute)
+	_, _, err = client.APICall(nil, "GET", "/ping", nil, nil)
+	require.NoError(t, err)
+	_, _, err = client.APICall(nil, "GET", "/ping", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, int32(6), atomic.LoadInt32(&requests))
+}
+
+func TestCircuitBreakerNotRetried(t *testing.T) {
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	client := Client{
+		RootURL:      s.URL,
+		Interceptors: []Interceptor{NewCircuitBreaker(2, time.Hour).Intercept},
+		RetryPolicy:  &RetryPolicy{MaxAttempts: 10, InitialInterval: time.Millisecond},
+	}
+	_, cs, err := client.APICall(nil, "GET", "/ping", nil, nil)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, 3, cs.Attempts)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestCircuitBreakerPerHost(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Hour)
+	fail := func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}
+	ok := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	}
+	a, _ := http.NewRequest("GET", "https://a.example.com/", nil)
+	b, _ := http.NewRequest("GET", "https://b.example.com/", nil)
+
+	_, err := breaker.Intercept(a, fail)
+	require.False(t, errors.Is(err, ErrCircuitOpen))
+	_, err = breaker.Intercept(a, ok)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	_, err = breaker.Intercept(b, ok)
+	require.NoError(t, err)
+}
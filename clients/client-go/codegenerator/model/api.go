@@ -50,6 +50,10 @@ func (api *API) postPopulate(apiDef *APIDefinition) {
 		"New":        true,
 		"NewFromEnv": true,
 	}
+	// the generated interface and mock, and the hand-written fakes
+	for _, name := range []string{api.Name() + "API", "Mock" + api.Name(), "Fake" + api.Name(), "NewFake" + api.Name()} {
+		api.apiDef.members[name] = true
+	}
 
 	// make sure each entry defined for this API has a unique generated method name
 	methods := map[string]bool{}
@@ -118,6 +122,7 @@ func (api *API) generateAPICode(apiName string) string {
 
 	content += `
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/url"
@@ -224,6 +229,76 @@ func NewFromEnv() *` + api.Name() + ` {
 	for _, entry := range api.Entries {
 		content += entry.generateAPICode(apiName)
 	}
+	content += api.generateInterface()
+	return content
+}
+
+// Generate an interface implemented by the client type, covering each of the
+// API methods, so that consumers can substitute a mock
+func (api *API) generateInterface() string {
+	content := "// " + api.Name() + "API is the interface implemented by *" + api.Name() + ", with a method for\n"
+	content += "// each API method.  Code that accepts " + api.Name() + "API rather than *" + api.Name() + " can be\n"
+	content += "// tested with Mock" + api.Name() + ".\n"
+	content += "type " + api.Name() + "API interface {\n"
+	for _, entry := range api.Entries {
+		params, _, responseType := entry.signature()
+		content += "\t" + entry.MethodName + "(" + params + ") " + responseType + "\n"
+	}
+	content += "}\n"
+	content += "\n"
+	content += "var _ " + api.Name() + "API = (*" + api.Name() + ")(nil)\n"
+	return content
+}
+
+// Generate a mock implementation of the interface generated by
+// generateInterface
+func (api *API) generateMockCode() string {
+	mockName := "Mock" + api.Name()
+	content := `
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that ` + "`${GOPATH}/bin` is in your `PATH`" + `:
+//
+// go install && go generate
+
+package ` + api.apiDef.PackageName + `
+
+import (
+	"net/url"
+	"time"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// ` + mockName + ` is a mock implementation of ` + api.Name() + `API, for use in tests.  Each method
+// records the call, then calls the function in the corresponding ` + "`<Method>Func`" + ` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type ` + mockName + ` struct {
+	tcclient.MockCalls
+`
+	for _, entry := range api.Entries {
+		params, _, responseType := entry.signature()
+		content += "\t" + entry.MethodName + "Func func(" + params + ") " + responseType + "\n"
+	}
+	content += "}\n\n"
+	content += "var _ " + api.Name() + "API = (*" + mockName + ")(nil)\n"
+
+	for _, entry := range api.Entries {
+		params, args, responseType := entry.signature()
+		notMocked := "&tcclient.NotMockedError{Method: \"" + api.Name() + "." + entry.MethodName + "\"}"
+		if entry.OutputURL != "" {
+			notMocked = "nil, " + notMocked
+		}
+		content += "\n"
+		content += "// " + entry.MethodName + " records the call and calls " + entry.MethodName + "Func\n"
+		content += "func (m *" + mockName + ") " + entry.MethodName + "(" + params + ") " + responseType + " {\n"
+		content += "\tm.Record(" + strings.Join(append([]string{`"` + entry.MethodName + `"`}, args...), ", ") + ")\n"
+		content += "\tif m." + entry.MethodName + "Func == nil {\n"
+		content += "\t\treturn " + notMocked + "\n"
+		content += "\t}\n"
+		content += "\treturn m." + entry.MethodName + "Func(" + strings.Join(args, ", ") + ")\n"
+		content += "}\n"
+	}
 	return content
 }
 
@@ -281,9 +356,34 @@ func (entry *APIEntry) generateAPICode(apiName string) string {
 	if strings.ToUpper(entry.Method) == "GET" {
 		content += entry.generateSignedURLMethod(apiName)
 	}
+	content += entry.generatePagesMethod(apiName)
 	return content
 }
 
+// Returns the name of the go struct member for the `continuationToken`
+// property of the entry's output, or "" if the entry is not paginated (takes
+// no `continuationToken` query parameter or does not return one)
+func (entry *APIEntry) continuationTokenMember() string {
+	hasQuery := false
+	for _, q := range entry.Query {
+		if q == "continuationToken" {
+			hasQuery = true
+		}
+	}
+	if !hasQuery || entry.OutputURL == "" {
+		return ""
+	}
+	output := entry.Parent.apiDef.schemas.SubSchema(entry.OutputURL)
+	if output.Properties == nil {
+		return ""
+	}
+	prop, exists := output.Properties.Properties["continuationToken"]
+	if !exists || prop.Type == nil || *prop.Type != "string" {
+		return ""
+	}
+	return output.Properties.MemberNames["continuationToken"]
+}
+
 func (entry *APIEntry) getInputParamsAndQueryStringCode() (inputParams, queryCode, queryExpr string) {
 	inputArgs := append([]string{}, entry.Args...)
 
@@ -306,6 +406,27 @@ func (entry *APIEntry) getInputParamsAndQueryStringCode() (inputParams, queryCod
 	return
 }
 
+// Returns the parameters, the arguments (for passing the parameters on to
+// another function), and the response type of the entry's generated method
+func (entry *APIEntry) signature() (params string, args []string, responseType string) {
+	params, _, _ = entry.getInputParamsAndQueryStringCode()
+	args = append(append([]string{}, entry.Args...), entry.Query...)
+	if entry.InputURL != "" {
+		p := "payload *" + entry.Parent.apiDef.schemas.SubSchema(entry.InputURL).TypeName
+		if params == "" {
+			params = p
+		} else {
+			params += ", " + p
+		}
+		args = append(args, "payload")
+	}
+	responseType = "error"
+	if entry.OutputURL != "" {
+		responseType = "(*" + entry.Parent.apiDef.schemas.SubSchema(entry.OutputURL).TypeName + ", error)"
+	}
+	return
+}
+
 func (entry *APIEntry) generateDirectMethod(apiName string) string {
 	comment := ""
 	if entry.Stability != "stable" {
@@ -322,22 +443,12 @@ func (entry *APIEntry) generateDirectMethod(apiName string) string {
 	comment += "//\n"
 	comment += fmt.Sprintf("// See %v#%v\n", entry.Parent.apiDef.DocRoot, entry.Name)
 
-	inputParams, queryCode, queryExpr := entry.getInputParamsAndQueryStringCode()
+	_, queryCode, queryExpr := entry.getInputParamsAndQueryStringCode()
+	inputParams, _, responseType := entry.signature()
 
 	apiArgsPayload := "nil"
 	if entry.InputURL != "" {
 		apiArgsPayload = "payload"
-		p := "payload *" + entry.Parent.apiDef.schemas.SubSchema(entry.InputURL).TypeName
-		if inputParams == "" {
-			inputParams = p
-		} else {
-			inputParams += ", " + p
-		}
-	}
-
-	responseType := "error"
-	if entry.OutputURL != "" {
-		responseType = "(*" + entry.Parent.apiDef.schemas.SubSchema(entry.OutputURL).TypeName + ", error)"
 	}
 
 	content := comment
@@ -387,6 +498,60 @@ func (entry *APIEntry) generateSignedURLMethod(apiName string) string {
 	return strings.Replace(content, ` + ""`, "", -1)
 }
 
+func (entry *APIEntry) generatePagesMethod(apiName string) string {
+	member := entry.continuationTokenMember()
+	if member == "" {
+		return ""
+	}
+	varName := entry.Parent.apiDef.ExampleVarName
+	outputType := entry.Parent.apiDef.schemas.SubSchema(entry.OutputURL).TypeName
+
+	comment := "// Returns an iterator over the pages of results of " + entry.MethodName + ", fetching\n"
+	comment += "// each page as it is needed by passing the `continuationToken` of the\n"
+	comment += "// previous page.  Iteration stops after the last page, on error, or when ctx\n"
+	comment += "// is done.\n"
+	comment += "//\n"
+	comment += fmt.Sprintf("// See %v for more details.\n", entry.MethodName)
+
+	// the iterator takes the same arguments as the method, except for the
+	// continuation token
+	params := []string{}
+	callArgs := []string{}
+	for _, arg := range entry.Args {
+		params = append(params, arg)
+		callArgs = append(callArgs, arg)
+	}
+	for _, q := range entry.Query {
+		if q != "continuationToken" {
+			params = append(params, q)
+		}
+		callArgs = append(callArgs, q)
+	}
+	inputParams := "ctx context.Context"
+	if len(params) > 0 {
+		inputParams += ", " + strings.Join(params, ", ") + " string"
+	}
+	if entry.InputURL != "" {
+		inputParams += ", payload *" + entry.Parent.apiDef.schemas.SubSchema(entry.InputURL).TypeName
+		callArgs = append(callArgs, "payload")
+	}
+
+	content := comment
+	content += "func (" + varName + " *" + entry.Parent.Name() + ") " + entry.MethodName + "Pages(" + inputParams + ") *tcclient.Pages[" + outputType + "] {\n"
+	content += "\treturn tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*" + outputType + ", string, error) {\n"
+	content += "\t\tc := *" + varName + "\n"
+	content += "\t\tc.Context = ctx\n"
+	content += "\t\tresponse, err := c." + entry.MethodName + "(" + strings.Join(callArgs, ", ") + ")\n"
+	content += "\t\tif err != nil {\n"
+	content += "\t\t\treturn nil, \"\", err\n"
+	content += "\t\t}\n"
+	content += "\t\treturn response, response." + member + ", nil\n"
+	content += "\t})\n"
+	content += "}\n"
+	content += "\n"
+	return content
+}
+
 func requiredScopesComment(scopes *ScopeExpressionTemplate) string {
 	if scopes.Type == "" {
 		return ""
@@ -248,6 +248,12 @@ func (apiDefs APIDefinitions) GenerateCode(goOutputDir string) {
 		sourceFile := filepath.Join(apiDefs[i].PackagePath, apiDefs[i].PackageName+".go")
 		FormatSourceAndSave(sourceFile, []byte(content))
 
+		if api, ok := apiDefs[i].Data.(*API); ok {
+			fmt.Printf("Generating mock for %s\n", job.Package)
+			mockSourceFile := filepath.Join(apiDefs[i].PackagePath, "mock.go")
+			FormatSourceAndSave(mockSourceFile, []byte(api.generateMockCode()))
+		}
+
 	}
 
 	amqpApiLinks := ""
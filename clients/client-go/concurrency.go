@@ -0,0 +1,93 @@
+package tcclient
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ConcurrencyLimiter limits the number of concurrent requests to each host,
+// so that a large number of goroutines, or a burst of retries, does not
+// overwhelm a service.  Requests beyond the limit wait for an earlier request
+// to finish, or for their context to be done.  A request is finished once
+// its response body has been read to the end or closed.
+//
+// A ConcurrencyLimiter is safe for concurrent use, and is intended to be
+// shared between all the clients of a process, by adding its Intercept
+// method to each client's Interceptors:
+//
+//	limiter := tcclient.NewConcurrencyLimiter(10)
+//	queue.Interceptors = append(queue.Interceptors, limiter.Intercept)
+type ConcurrencyLimiter struct {
+	// Maximum number of concurrent requests to each host; values less than
+	// one are treated as one
+	MaxPerHost int
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter allowing maxPerHost
+// concurrent requests to each host.
+func NewConcurrencyLimiter(maxPerHost int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		MaxPerHost: maxPerHost,
+	}
+}
+
+// Get the semaphore for the given host
+func (limiter *ConcurrencyLimiter) semaphore(host string) chan struct{} {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if limiter.hosts == nil {
+		limiter.hosts = map[string]chan struct{}{}
+	}
+	sem, ok := limiter.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, max(limiter.MaxPerHost, 1))
+		limiter.hosts[host] = sem
+	}
+	return sem
+}
+
+// Intercept is an Interceptor applying the concurrency limit to a request.
+func (limiter *ConcurrencyLimiter) Intercept(req *http.Request, next RequestHandler) (*http.Response, error) {
+	sem := limiter.semaphore(req.URL.Host)
+	select {
+	case sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	var once sync.Once
+	release := func() {
+		once.Do(func() { <-sem })
+	}
+
+	resp, err := next(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody releases a concurrency slot when the body is read to the end
+// or closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (body *releasingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if err != nil {
+		body.release()
+	}
+	return n, err
+}
+
+func (body *releasingBody) Close() error {
+	body.release()
+	return body.ReadCloser.Close()
+}
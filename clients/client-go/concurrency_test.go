@@ -0,0 +1,69 @@
+package tcclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	var current, highest int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			h := atomic.LoadInt32(&highest)
+			if n <= h || atomic.CompareAndSwapInt32(&highest, h, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+
+	client := Client{
+		RootURL:      s.URL,
+		Interceptors: []Interceptor{NewConcurrencyLimiter(2).Intercept},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := client.APICall(nil, "GET", "/ping", nil, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(2), atomic.LoadInt32(&highest))
+}
+
+func TestConcurrencyLimiterReleasesOnClose(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1)
+	ok := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	}
+	req, _ := http.NewRequest("GET", "https://a.example.com/", nil)
+
+	resp, err := limiter.Intercept(req, ok)
+	require.NoError(t, err)
+
+	// a second request waits for the first to finish
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Intercept(req.WithContext(ctx), ok)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, resp.Body.Close())
+	resp, err = limiter.Intercept(req, ok)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+}
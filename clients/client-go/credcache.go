@@ -0,0 +1,245 @@
+package tcclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+)
+
+// CachedCredentials are credentials stored in the local credential cache by
+// `taskcluster signin --cache`, for use by other tools.
+type CachedCredentials struct {
+	RootURL     string `json:"rootUrl"`
+	ClientID    string `json:"clientId"`
+	AccessToken string `json:"accessToken"`
+	Certificate string `json:"certificate,omitempty"`
+	// The scopes of the client, if known
+	Scopes []string `json:"scopes,omitempty"`
+	// The time at which the credentials expire, or the zero time if
+	// unknown
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// DefaultCredentialCacheFile returns the location of the local credential
+// cache: the file given by environment variable
+// TASKCLUSTER_CREDENTIAL_CACHE if set, and otherwise
+// taskcluster/credentials.json in the user's cache directory.
+func DefaultCredentialCacheFile() (string, error) {
+	if file := os.Getenv("TASKCLUSTER_CREDENTIAL_CACHE"); file != "" {
+		return file, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate credential cache: %w", err)
+	}
+	return filepath.Join(dir, "taskcluster", "credentials.json"), nil
+}
+
+// the credential cache file holds one set of credentials per deployment
+type credentialCache map[string]*CachedCredentials
+
+func readCredentialCache(file string) (credentialCache, error) {
+	cache := credentialCache{}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("invalid credential cache %s: %w", file, err)
+	}
+	return cache, nil
+}
+
+// SaveCachedCredentials stores credentials in the given credential cache
+// file, replacing any existing credentials for the same root URL.  The file
+// is only readable by the current user.
+func SaveCachedCredentials(file string, creds *CachedCredentials) error {
+	cache, err := readCredentialCache(file)
+	if err != nil {
+		return err
+	}
+	cache[tcurls.NormalizeRootURL(creds.RootURL)] = creds
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	// write a new file and rename it, so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".credentials-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// LoadCachedCredentials returns the credentials for the given root URL from
+// the given credential cache file, or nil if there are none.
+func LoadCachedCredentials(file, rootURL string) (*CachedCredentials, error) {
+	cache, err := readCredentialCache(file)
+	if err != nil {
+		return nil, err
+	}
+	return cache[tcurls.NormalizeRootURL(rootURL)], nil
+}
+
+// CachedCredentialsProvider provides credentials from the local credential
+// cache, renewing them before they expire.
+//
+// Rather than using cached permanent credentials directly, the provider
+// creates temporary credentials from them with the client's scopes, valid for
+// TemporaryDuration, and creates new ones when those are within RenewBefore of
+// expiring.  The cache file is read again whenever it changes, so signing in
+// again takes effect without restarting long-running tools.  Cached
+// credentials that are temporary, or whose scopes or expiry are not known,
+// are used as they are.
+//
+// A CachedCredentialsProvider is safe for concurrent use.  Its Intercept
+// method signs each request of a client with the current credentials:
+//
+//	provider := tcclient.NewCachedCredentialsProvider(rootURL)
+//	queue := tcqueue.New(nil, rootURL)
+//	queue.Interceptors = append(queue.Interceptors, provider.Intercept)
+type CachedCredentialsProvider struct {
+	// Root URL of the deployment to provide credentials for
+	RootURL string
+	// Credential cache file; if empty, DefaultCredentialCacheFile() is used
+	File string
+	// Lifetime of the temporary credentials created by the provider; if
+	// zero, an hour
+	TemporaryDuration time.Duration
+	// Time before the expiry of the temporary credentials at which they are
+	// renewed; if zero, five minutes
+	RenewBefore time.Duration
+
+	mu      sync.Mutex
+	modTime time.Time
+	cached  *CachedCredentials
+	current *Credentials
+	renewAt time.Time
+	// for testing
+	now func() time.Time
+}
+
+// NewCachedCredentialsProvider returns a CachedCredentialsProvider for the
+// given deployment, using the default credential cache file.
+func NewCachedCredentialsProvider(rootURL string) *CachedCredentialsProvider {
+	return &CachedCredentialsProvider{
+		RootURL: rootURL,
+	}
+}
+
+func (provider *CachedCredentialsProvider) clock() time.Time {
+	if provider.now != nil {
+		return provider.now()
+	}
+	return time.Now()
+}
+
+// Credentials returns credentials that are valid for at least RenewBefore,
+// renewing them or reading the credential cache again if necessary.  It
+// returns an error if the cache has no unexpired credentials for the
+// deployment.
+func (provider *CachedCredentialsProvider) Credentials() (*Credentials, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	file := provider.File
+	if file == "" {
+		var err error
+		if file, err = DefaultCredentialCacheFile(); err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if info != nil && !info.ModTime().Equal(provider.modTime) {
+		cached, err := LoadCachedCredentials(file, provider.RootURL)
+		if err != nil {
+			return nil, err
+		}
+		provider.modTime = info.ModTime()
+		provider.cached = cached
+		provider.current = nil
+	}
+
+	now := provider.clock()
+	if provider.current != nil && now.Before(provider.renewAt) {
+		return provider.current, nil
+	}
+	temporaryDuration := provider.TemporaryDuration
+	if temporaryDuration <= 0 {
+		temporaryDuration = time.Hour
+	}
+	renewBefore := provider.RenewBefore
+	if renewBefore <= 0 {
+		renewBefore = 5 * time.Minute
+	}
+	cached := provider.cached
+	if cached == nil {
+		return nil, fmt.Errorf("no credentials for %s in %s; run `taskcluster signin --cache`", provider.RootURL, file)
+	}
+	if !cached.Expires.IsZero() && !now.Before(cached.Expires) {
+		return nil, fmt.Errorf("credentials for %s in %s expired at %s; run `taskcluster signin --cache`", provider.RootURL, file, cached.Expires.Format(time.RFC3339))
+	}
+
+	creds := &Credentials{
+		ClientID:    cached.ClientID,
+		AccessToken: cached.AccessToken,
+		Certificate: cached.Certificate,
+	}
+	expires := cached.Expires
+	if cached.Certificate == "" && cached.Scopes != nil && !cached.Expires.IsZero() {
+		// temporary credentials cannot outlive the credentials they are
+		// created from
+		duration := min(temporaryDuration, cached.Expires.Sub(now))
+		creds, err = creds.CreateTemporaryCredentials(duration, cached.Scopes...)
+		if err != nil {
+			return nil, err
+		}
+		expires = now.Add(duration)
+	}
+	provider.current = creds
+	if expires.IsZero() {
+		provider.renewAt = now.Add(renewBefore)
+	} else {
+		provider.renewAt = expires.Add(-renewBefore)
+	}
+	return creds, nil
+}
+
+// Intercept is an Interceptor that signs each request with the provider's
+// current credentials, replacing any existing Authorization header.  Use it
+// with clients that have no credentials.
+func (provider *CachedCredentialsProvider) Intercept(req *http.Request, next RequestHandler) (*http.Response, error) {
+	creds, err := provider.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	if err := creds.SignRequest(req); err != nil {
+		return nil, err
+	}
+	return next(req)
+}
@@ -0,0 +1,121 @@
+package tcclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoadCachedCredentials(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "taskcluster", "credentials.json")
+
+	creds, err := LoadCachedCredentials(file, "https://tc.example.com")
+	require.NoError(t, err)
+	require.Nil(t, creds)
+
+	require.NoError(t, SaveCachedCredentials(file, &CachedCredentials{RootURL: "https://tc.example.com/", ClientID: "one", AccessToken: "a"}))
+	require.NoError(t, SaveCachedCredentials(file, &CachedCredentials{RootURL: "https://other.example.com", ClientID: "two", AccessToken: "b"}))
+	require.NoError(t, SaveCachedCredentials(file, &CachedCredentials{RootURL: "https://tc.example.com", ClientID: "three", AccessToken: "c"}))
+
+	creds, err = LoadCachedCredentials(file, "https://tc.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "three", creds.ClientID)
+	creds, err = LoadCachedCredentials(file, "https://other.example.com/")
+	require.NoError(t, err)
+	require.Equal(t, "two", creds.ClientID)
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestCachedCredentialsProviderRenews(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	now := time.Now()
+	require.NoError(t, SaveCachedCredentials(file, &CachedCredentials{
+		RootURL:     "https://tc.example.com",
+		ClientID:    "signin",
+		AccessToken: "secret",
+		Scopes:      []string{"queue:*"},
+		Expires:     now.Add(3 * time.Hour),
+	}))
+	provider := &CachedCredentialsProvider{RootURL: "https://tc.example.com", File: file}
+	provider.now = func() time.Time { return now }
+
+	creds, err := provider.Credentials()
+	require.NoError(t, err)
+	require.Equal(t, "signin", creds.ClientID)
+	require.NotEqual(t, "secret", creds.AccessToken)
+	var cert Certificate
+	require.NoError(t, json.Unmarshal([]byte(creds.Certificate), &cert))
+	require.Equal(t, []string{"queue:*"}, cert.Scopes)
+
+	// the same credentials are returned until shortly before they expire
+	now = now.Add(50 * time.Minute)
+	again, err := provider.Credentials()
+	require.NoError(t, err)
+	require.Same(t, creds, again)
+
+	now = now.Add(6 * time.Minute)
+	renewed, err := provider.Credentials()
+	require.NoError(t, err)
+	require.NotEqual(t, creds.Certificate, renewed.Certificate)
+
+	// and not after the cached credentials have expired
+	now = now.Add(3 * time.Hour)
+	_, err = provider.Credentials()
+	require.ErrorContains(t, err, "expired")
+}
+
+func TestCachedCredentialsProviderRereadsCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	provider := &CachedCredentialsProvider{RootURL: "https://tc.example.com", File: file}
+
+	_, err := provider.Credentials()
+	require.ErrorContains(t, err, "no credentials")
+
+	require.NoError(t, SaveCachedCredentials(file, &CachedCredentials{
+		RootURL:     "https://tc.example.com",
+		ClientID:    "first",
+		AccessToken: "secret",
+	}))
+	creds, err := provider.Credentials()
+	require.NoError(t, err)
+	// without scopes and expiry, the cached credentials are used directly
+	require.Equal(t, &Credentials{ClientID: "first", AccessToken: "secret"}, creds)
+
+	require.NoError(t, SaveCachedCredentials(file, &CachedCredentials{
+		RootURL:     "https://tc.example.com",
+		ClientID:    "second",
+		AccessToken: "secret",
+	}))
+	// make sure the modification time changes
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(file, later, later))
+	creds, err = provider.Credentials()
+	require.NoError(t, err)
+	require.Equal(t, "second", creds.ClientID)
+}
+
+func TestCachedCredentialsProviderIntercept(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, SaveCachedCredentials(file, &CachedCredentials{
+		RootURL:     "https://tc.example.com",
+		ClientID:    "signin",
+		AccessToken: "secret",
+	}))
+	provider := &CachedCredentialsProvider{RootURL: "https://tc.example.com", File: file}
+
+	req, err := http.NewRequest("GET", "https://tc.example.com/api/queue/v1/ping", nil)
+	require.NoError(t, err)
+	_, err = provider.Intercept(req, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})
+	require.NoError(t, err)
+	require.Contains(t, req.Header.Get("Authorization"), `Hawk id="signin"`)
+}
@@ -64,10 +64,20 @@ type Client struct {
 	// HTTPClient is a ReducedHTTPClient to be used for the http call instead of
 	// the DefaultHTTPClient.
 	HTTPClient ReducedHTTPClient
+	// Interceptors wrap each HTTP request made by this client, with the
+	// first being outermost; see Interceptor
+	Interceptors []Interceptor
 	// Context that aborts all requests with this client
 	Context context.Context
 	// HTTPBackoffClient holds the HTTP backoff settings for retries
 	HTTPBackoffClient *httpbackoff.Client
+	// RetryPolicy configures the retrying of failed requests.  If nil, the
+	// settings of HTTPBackoffClient are used if it is set, and otherwise
+	// DefaultRetryPolicy().
+	RetryPolicy *RetryPolicy
+	// RetryHooks are called before each retry of a failed request; see
+	// RetryHook
+	RetryHooks []RetryHook
 }
 
 // Certificate represents the certificate used in Temporary Credentials. See
@@ -0,0 +1,122 @@
+package tcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// APIError is an error response from a Taskcluster service.  It is available
+// from the error returned by any API method that fails with an HTTP error
+// status, using errors.As:
+//
+//	var apiErr *tcclient.APIError
+//	if errors.As(err, &apiErr) {
+//		log.Printf("%s: %s (request %s)", apiErr.Code, apiErr.Message, apiErr.RequestID)
+//	}
+//
+// The common error codes are available as values for use with errors.Is:
+//
+//	if errors.Is(err, tcclient.ErrResourceNotFound) {
+//		...
+//	}
+type APIError struct {
+	// The HTTP status code of the response, such as 404
+	StatusCode int
+	// The error code given by the service, such as "ResourceNotFound", or
+	// "" if the response body was not a Taskcluster error
+	Code string
+	// The error message given by the service, or the response body if it was
+	// not a Taskcluster error
+	Message string
+	// The ID the service gave the request, from the x-for-request-id header
+	RequestID string
+	// The trace ID of the request, from the x-for-trace-id header
+	TraceID string
+	// Information about the request, as seen by the service
+	RequestInfo *APIErrorRequestInfo
+	// Any other properties of the error response body
+	Details map[string]json.RawMessage
+}
+
+// APIErrorRequestInfo describes the request that resulted in an APIError,
+// as seen by the service
+type APIErrorRequestInfo struct {
+	// The name of the API method, such as "createTask"
+	Method string `json:"method"`
+	// The URL parameters of the request
+	Params map[string]string `json:"params"`
+	// The request payload, with any secrets removed
+	Payload json.RawMessage `json:"payload"`
+	// The time the error occurred, in RFC3339 format
+	Time string `json:"time"`
+}
+
+// Values for use with errors.Is, matching any APIError with the given error
+// code
+var (
+	ErrMalformedPayload        = &APIError{Code: "MalformedPayload"}
+	ErrInvalidRequestArguments = &APIError{Code: "InvalidRequestArguments"}
+	ErrInputValidationError    = &APIError{Code: "InputValidationError"}
+	ErrInputError              = &APIError{Code: "InputError"}
+	ErrAuthenticationFailed    = &APIError{Code: "AuthenticationFailed"}
+	ErrInsufficientScopes      = &APIError{Code: "InsufficientScopes"}
+	ErrResourceNotFound        = &APIError{Code: "ResourceNotFound"}
+	ErrRequestConflict         = &APIError{Code: "RequestConflict"}
+	ErrResourceExpired         = &APIError{Code: "ResourceExpired"}
+	ErrInputTooLarge           = &APIError{Code: "InputTooLarge"}
+	ErrInternalServerError     = &APIError{Code: "InternalServerError"}
+)
+
+func (err *APIError) Error() string {
+	if err.Code == "" {
+		return fmt.Sprintf("HTTP %d: %s", err.StatusCode, err.Message)
+	}
+	return fmt.Sprintf("%s (HTTP %d): %s", err.Code, err.StatusCode, err.Message)
+}
+
+// Is reports whether err matches target, which must be an *APIError.  Empty
+// fields of target match any value, so that target may be one of the Err*
+// values, or for example &APIError{StatusCode: 404}.
+func (err *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	return (t.Code == "" || t.Code == err.Code) &&
+		(t.StatusCode == 0 || t.StatusCode == err.StatusCode) &&
+		(t.RequestID == "" || t.RequestID == err.RequestID)
+}
+
+// Parse an error response from a service, which is normally a JSON object
+// with properties code, message and requestInfo
+func parseAPIError(resp *http.Response, body string) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    body,
+		RequestID:  resp.Header.Get("x-for-request-id"),
+		TraceID:    resp.Header.Get("x-for-trace-id"),
+	}
+	var properties map[string]json.RawMessage
+	if json.Unmarshal([]byte(body), &properties) != nil {
+		return apiErr
+	}
+	var code, message string
+	if json.Unmarshal(properties["code"], &code) != nil || json.Unmarshal(properties["message"], &message) != nil {
+		return apiErr
+	}
+	apiErr.Code, apiErr.Message = code, message
+	delete(properties, "code")
+	delete(properties, "message")
+	if requestInfo, ok := properties["requestInfo"]; ok {
+		info := new(APIErrorRequestInfo)
+		if json.Unmarshal(requestInfo, info) == nil {
+			apiErr.RequestInfo = info
+			delete(properties, "requestInfo")
+		}
+	}
+	if len(properties) > 0 {
+		apiErr.Details = properties
+	}
+	return apiErr
+}
@@ -0,0 +1,98 @@
+package tcclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+func errorServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-for-request-id", "req-123")
+		w.Header().Set("x-for-trace-id", "trace-456")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestAPIError(t *testing.T) {
+	s := errorServer(t, 404, `{
+		"code": "ResourceNotFound",
+		"message": "Task abc not found",
+		"requestInfo": {
+			"method": "task",
+			"params": {"taskId": "abc"},
+			"payload": {},
+			"time": "2024-01-01T00:00:00.000Z"
+		}
+	}`)
+	client := Client{RootURL: s.URL, RetryPolicy: quickRetryPolicy()}
+	_, _, err := client.APICall(nil, "GET", "/task/abc", nil, nil)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, 404, apiErr.StatusCode)
+	require.Equal(t, "ResourceNotFound", apiErr.Code)
+	require.Equal(t, "Task abc not found", apiErr.Message)
+	require.Equal(t, "req-123", apiErr.RequestID)
+	require.Equal(t, "trace-456", apiErr.TraceID)
+	require.Equal(t, "task", apiErr.RequestInfo.Method)
+	require.Equal(t, map[string]string{"taskId": "abc"}, apiErr.RequestInfo.Params)
+	require.Nil(t, apiErr.Details)
+	require.Equal(t, "ResourceNotFound (HTTP 404): Task abc not found", apiErr.Error())
+
+	require.True(t, errors.Is(err, ErrResourceNotFound))
+	require.True(t, errors.Is(err, &APIError{StatusCode: 404}))
+	require.False(t, errors.Is(err, ErrInsufficientScopes))
+	require.False(t, errors.Is(err, &APIError{Code: "ResourceNotFound", StatusCode: 410}))
+
+	// the root cause is still available
+	var badCode httpbackoff.BadHttpResponseCode
+	require.True(t, errors.As(err, &badCode))
+	require.Equal(t, 404, badCode.HttpResponseCode)
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	s := errorServer(t, 409, `{"code": "RequestConflict", "message": "conflict", "existing": {"taskId": "abc"}}`)
+	client := Client{RootURL: s.URL, RetryPolicy: quickRetryPolicy()}
+	_, _, err := client.APICall(nil, "PUT", "/task/abc", nil, nil)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.True(t, errors.Is(err, ErrRequestConflict))
+	require.Nil(t, apiErr.RequestInfo)
+	require.JSONEq(t, `{"taskId": "abc"}`, string(apiErr.Details["existing"]))
+}
+
+func TestAPIErrorNotTaskcluster(t *testing.T) {
+	s := errorServer(t, 403, `<html>Forbidden</html>`)
+	client := Client{RootURL: s.URL, RetryPolicy: quickRetryPolicy()}
+	_, _, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, 403, apiErr.StatusCode)
+	require.Equal(t, "", apiErr.Code)
+	require.Equal(t, "<html>Forbidden</html>", apiErr.Message)
+	require.Equal(t, "HTTP 403: <html>Forbidden</html>", apiErr.Error())
+	require.False(t, errors.Is(err, ErrInsufficientScopes))
+}
+
+func TestNoAPIErrorForNetworkFailure(t *testing.T) {
+	policy := quickRetryPolicy()
+	policy.MaxAttempts = 1
+	client := Client{RootURL: "http://127.0.0.1:1", RetryPolicy: policy}
+	_, _, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.False(t, errors.As(err, &apiErr))
+}
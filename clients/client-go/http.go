@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,8 +16,6 @@ import (
 	"reflect"
 	"time"
 
-	"github.com/cenkalti/backoff/v3"
-	"github.com/taskcluster/httpbackoff/v3"
 	tcurls "github.com/taskcluster/taskcluster-lib-urls"
 	hawk "github.com/tent/hawk-go"
 )
@@ -29,10 +28,6 @@ func init() {
 	}
 }
 
-var defaultBackoff httpbackoff.Client = httpbackoff.Client{
-	BackOffSettings: backoff.NewExponentialBackOff(),
-}
-
 // CallSummary provides information about the underlying http request and
 // response issued for a given API call.
 type CallSummary struct {
@@ -154,16 +149,15 @@ func (client *Client) Request(rawPayload []byte, method, route string, query url
 		if client.Context != nil {
 			callSummary.HTTPRequest = callSummary.HTTPRequest.WithContext(client.Context)
 		}
-		var resp *http.Response
-		if client.HTTPClient != nil {
-			resp, err = client.HTTPClient.Do(callSummary.HTTPRequest)
-		} else {
-			resp, err = defaultHTTPClient.Do(callSummary.HTTPRequest)
-		}
+		resp, err := client.do(callSummary.HTTPRequest)
 		// return cancelled error, if context was cancelled
 		if client.Context != nil && client.Context.Err() != nil {
 			return nil, nil, client.Context.Err()
 		}
+		// do not retry requests rejected by a circuit breaker
+		if errors.Is(err, ErrCircuitOpen) {
+			return nil, nil, err
+		}
 		// b, e := httputil.DumpResponse(resp, true)
 		// if e == nil {
 		// 	fmt.Println(string(b))
@@ -171,14 +165,28 @@ func (client *Client) Request(rawPayload []byte, method, route string, query url
 		return resp, err, nil
 	}
 
-	// Make HTTP API calls using an exponential backoff algorithm...
+	// Make HTTP API calls, retrying according to the client's retry policy...
 	var err error
-
-	backoffClient := client.HTTPBackoffClient
-	if backoffClient == nil {
-		backoffClient = &defaultBackoff
+	var onRetry func(attempt int, delay time.Duration, response *http.Response, err error)
+	if len(client.RetryHooks) > 0 {
+		onRetry = func(attempt int, delay time.Duration, response *http.Response, err error) {
+			event := &RetryEvent{
+				ServiceName: client.ServiceName,
+				Method:      method,
+				Route:       route,
+				Attempt:     attempt,
+				Delay:       delay,
+				Err:         err,
+			}
+			if response != nil {
+				event.StatusCode = response.StatusCode
+			}
+			for _, hook := range client.RetryHooks {
+				hook(event)
+			}
+		}
 	}
-	callSummary.HTTPResponse, callSummary.Attempts, err = backoffClient.Retry(httpCall)
+	callSummary.HTTPResponse, callSummary.Attempts, err = client.retryPolicy().retry(client.Context, httpCall, onRetry)
 
 	// read response into memory, so that we can return the body
 	if callSummary.HTTPResponse != nil {
@@ -215,12 +223,24 @@ func (c *Credentials) SignRequest(req *http.Request) (err error) {
 type APICallException struct {
 	CallSummary *CallSummary
 	RootCause   error
+	// The error response from the service, if the call failed with an HTTP
+	// error status
+	APIError *APIError
 }
 
 func (err *APICallException) Error() string {
 	return err.CallSummary.String() + "\n" + err.RootCause.Error()
 }
 
+// Unwrap returns the root cause and the API error, if any, so that either can
+// be found with errors.As or errors.Is
+func (err *APICallException) Unwrap() []error {
+	if err.APIError == nil {
+		return []error{err.RootCause}
+	}
+	return []error{err.RootCause, err.APIError}
+}
+
 // APICall is the generic REST API calling method which performs all REST API
 // calls for this library.  Each auto-generated REST API method simply is a
 // wrapper around this method, calling it with specific specific arguments.
@@ -254,12 +274,14 @@ func (client *Client) APICall(payload interface{}, method, route string, result
 		if callSummary.HTTPResponse != nil && callSummary.HTTPResponse.StatusCode < 400 {
 			err = nil
 		} else {
-			return result,
-				callSummary,
-				&APICallException{
-					CallSummary: callSummary,
-					RootCause:   err,
-				}
+			exception := &APICallException{
+				CallSummary: callSummary,
+				RootCause:   err,
+			}
+			if callSummary.HTTPResponse != nil {
+				exception.APIError = parseAPIError(callSummary.HTTPResponse, callSummary.HTTPResponseBody)
+			}
+			return result, callSummary, exception
 		}
 	}
 	// if result is passed in as nil, it means the API defines no response body
@@ -286,6 +308,40 @@ func (client *Client) APICall(payload interface{}, method, route string, result
 // for.  The full-URL form permits signing URLs that are not even on the given
 // RootURL.
 func (client *Client) SignedURL(route string, query url.Values, duration time.Duration) (u *url.URL, err error) {
+	return client.SignedURLWithOptions(route, query, SignedURLOptions{Duration: duration})
+}
+
+// SignedURLOptions configures a URL signed with SignedURLWithOptions.
+type SignedURLOptions struct {
+	// Duration is the amount of time that the signed URL should remain valid
+	// for.  It is ignored if Expires is set.
+	Duration time.Duration
+	// Expires is the time at which the signed URL stops being valid.
+	Expires time.Time
+	// AuthorizedScopes, if not nil, restricts the scopes available to the
+	// signed URL, in place of any AuthorizedScopes of the client's
+	// credentials.  The scopes must be satisfied by the client's scopes, and
+	// an empty, non-nil slice grants no scopes at all.
+	//
+	// See https://docs.taskcluster.net/docs/manual/design/apis/hawk/authorized-scopes
+	AuthorizedScopes []string
+}
+
+// SignedURLWithOptions creates a signed URL as SignedURL does, with the
+// expiry and authorized scopes given in options.  This allows handing out
+// links that carry only the scopes needed to fetch a single resource.
+func (client *Client) SignedURLWithOptions(route string, query url.Values, options SignedURLOptions) (u *url.URL, err error) {
+	duration := options.Duration
+	if !options.Expires.IsZero() {
+		duration = time.Until(options.Expires)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("signed URL for %v would already have expired", route)
+	}
+	if client.Credentials == nil {
+		return nil, fmt.Errorf("cannot sign URL for %v without credentials", route)
+	}
+
 	u, err = url.Parse(route)
 	if err != nil || u.Host == "" {
 		u, err = setURL(client, route, query)
@@ -303,7 +359,13 @@ func (client *Client) SignedURL(route string, query url.Values, duration time.Du
 	if err != nil {
 		return
 	}
-	reqAuth.Ext, err = getExtHeader(client.Credentials)
+	signingCreds := client.Credentials
+	if options.AuthorizedScopes != nil {
+		narrowed := *client.Credentials
+		narrowed.AuthorizedScopes = options.AuthorizedScopes
+		signingCreds = &narrowed
+	}
+	reqAuth.Ext, err = getExtHeader(signingCreds)
 	if err != nil {
 		return
 	}
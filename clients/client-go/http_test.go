@@ -13,6 +13,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -505,6 +506,89 @@ func TestSignedURL_PartialURL(t *testing.T) {
 	}
 }
 
+// decodeBewit returns the expiry and ext header of the bewit in a signed URL
+func decodeBewit(t *testing.T, u *url.URL) (expiry int64, ext ExtHeader) {
+	t.Helper()
+	bewit, err := base64.RawURLEncoding.DecodeString(u.Query().Get("bewit"))
+	require.NoError(t, err)
+	parts := strings.Split(string(bewit), "\\")
+	require.Len(t, parts, 4)
+	expiry, err = strconv.ParseInt(parts[1], 10, 64)
+	require.NoError(t, err)
+	if parts[3] != "" {
+		extJSON, err := base64.StdEncoding.DecodeString(parts[3])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(extJSON, &ext))
+	}
+	return
+}
+
+func TestSignedURLWithOptions(t *testing.T) {
+	client := Client{
+		Credentials: &Credentials{
+			ClientID:         "test-signin",
+			AccessToken:      "fake-key",
+			AuthorizedScopes: []string{"queue:get-artifact:*"},
+		},
+		RootURL:     "https://tc.example.com",
+		ServiceName: "queue",
+		APIVersion:  "v1",
+	}
+	expires := time.Now().Add(10 * time.Minute)
+
+	res, err := client.SignedURLWithOptions("task/abc/artifacts/private/x", nil, SignedURLOptions{
+		Expires:          expires,
+		AuthorizedScopes: []string{"queue:get-artifact:private/x"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "/api/queue/v1/task/abc/artifacts/private/x", res.Path)
+
+	expiry, ext := decodeBewit(t, res)
+	require.InDelta(t, expires.Unix(), expiry, 1)
+	require.NotNil(t, ext.AuthorizedScopes)
+	require.Equal(t, []string{"queue:get-artifact:private/x"}, *ext.AuthorizedScopes)
+	// the client's credentials are unchanged
+	require.Equal(t, []string{"queue:get-artifact:*"}, client.Credentials.AuthorizedScopes)
+}
+
+func TestSignedURLWithOptionsNoScopes(t *testing.T) {
+	client := Client{
+		Credentials: &Credentials{
+			ClientID:    "test-signin",
+			AccessToken: "fake-key",
+		},
+		RootURL:     "https://tc.example.com",
+		ServiceName: "queue",
+		APIVersion:  "v1",
+	}
+
+	res, err := client.SignedURLWithOptions("ping", nil, SignedURLOptions{
+		Duration:         time.Minute,
+		AuthorizedScopes: []string{},
+	})
+	require.NoError(t, err)
+	_, ext := decodeBewit(t, res)
+	require.NotNil(t, ext.AuthorizedScopes)
+	require.Empty(t, *ext.AuthorizedScopes)
+}
+
+func TestSignedURLWithOptionsExpired(t *testing.T) {
+	client := Client{
+		Credentials: &Credentials{
+			ClientID:    "test-signin",
+			AccessToken: "fake-key",
+		},
+		RootURL:     "https://tc.example.com",
+		ServiceName: "queue",
+		APIVersion:  "v1",
+	}
+
+	_, err := client.SignedURLWithOptions("ping", nil, SignedURLOptions{Expires: time.Now().Add(-time.Minute)})
+	require.Error(t, err)
+	_, err = client.SignedURLWithOptions("ping", nil, SignedURLOptions{})
+	require.Error(t, err)
+}
+
 func TestRetryFailure(t *testing.T) {
 	// This mock service just returns 500's
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
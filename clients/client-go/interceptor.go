@@ -0,0 +1,44 @@
+package tcclient
+
+import (
+	"net/http"
+)
+
+// RequestHandler sends an HTTP request and returns its response, in the same
+// way as http.Client.Do.
+type RequestHandler func(req *http.Request) (*http.Response, error)
+
+// Interceptor wraps the sending of each HTTP request made by a Client.  It
+// may modify the request before passing it to next, which sends it (via any
+// later interceptors), and may inspect or replace the response.  It may also
+// return a response or error without calling next at all.
+//
+// Interceptors are called for every attempt, including retries, after the
+// request has been signed, so an interceptor can add its own authentication
+// headers to a client with Authenticate set to false.  For example, to log
+// each request:
+//
+//	queue.Interceptors = append(queue.Interceptors, func(req *http.Request, next tcclient.RequestHandler) (*http.Response, error) {
+//		start := time.Now()
+//		resp, err := next(req)
+//		log.Printf("%s %s took %s", req.Method, req.URL, time.Since(start))
+//		return resp, err
+//	})
+type Interceptor func(req *http.Request, next RequestHandler) (*http.Response, error)
+
+// Send a request through the client's interceptors, the first of which is
+// outermost, and then its HTTP client
+func (client *Client) do(req *http.Request) (*http.Response, error) {
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	handler := RequestHandler(httpClient.Do)
+	for i := len(client.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := client.Interceptors[i], handler
+		handler = func(req *http.Request) (*http.Response, error) {
+			return interceptor(req, next)
+		}
+	}
+	return handler(req)
+}
@@ -0,0 +1,114 @@
+package tcclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptorsOrderAndHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		w.Header().Set("X-Server", "yes")
+		_, _ = w.Write([]byte(`{"value": "hello"}`))
+	}))
+	defer s.Close()
+
+	calls := []string{}
+	tag := func(name string) Interceptor {
+		return func(req *http.Request, next RequestHandler) (*http.Response, error) {
+			calls = append(calls, name+" before")
+			req.Header.Add("X-Chain", name)
+			resp, err := next(req)
+			calls = append(calls, name+" after "+resp.Header.Get("X-Server"))
+			return resp, err
+		}
+	}
+	client := Client{
+		RootURL:      s.URL,
+		Interceptors: []Interceptor{tag("outer"), tag("inner")},
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	_, _, err := client.APICall(nil, "GET", "/whatever", &result, nil)
+	require.NoError(t, err)
+	require.Equal(t, "hello", result.Value)
+	require.Equal(t, []string{"outer before", "inner before", "inner after yes", "outer after yes"}, calls)
+	require.Equal(t, []string{"outer", "inner"}, gotHeaders.Values("X-Chain"))
+}
+
+func TestInterceptorAfterSigning(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+
+	var authorization string
+	client := Client{
+		RootURL:      s.URL,
+		Authenticate: true,
+		Credentials:  &Credentials{ClientID: "tester", AccessToken: "no-secret"},
+		Interceptors: []Interceptor{
+			func(req *http.Request, next RequestHandler) (*http.Response, error) {
+				authorization = req.Header.Get("Authorization")
+				return next(req)
+			},
+		},
+	}
+	_, _, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.NoError(t, err)
+	require.Contains(t, authorization, `Hawk id="tester"`)
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	client := Client{
+		RootURL: "https://tc.example.com",
+		Interceptors: []Interceptor{
+			func(req *http.Request, next RequestHandler) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: 200,
+					Header:     http.Header{},
+					Body:       io.NopCloser(bytes.NewBufferString(`{"value": "cached"}`)),
+					Request:    req,
+				}, nil
+			},
+			func(req *http.Request, next RequestHandler) (*http.Response, error) {
+				t.Fatal("inner interceptor should not be called")
+				return nil, nil
+			},
+		},
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	_, _, err := client.APICall(nil, "GET", "/whatever", &result, nil)
+	require.NoError(t, err)
+	require.Equal(t, "cached", result.Value)
+}
+
+func TestInterceptorEachAttempt(t *testing.T) {
+	s, _ := statusServer(t, nil, 500, 500)
+	attempts := 0
+	client := Client{
+		RootURL:     s.URL,
+		RetryPolicy: quickRetryPolicy(),
+		Interceptors: []Interceptor{
+			func(req *http.Request, next RequestHandler) (*http.Response, error) {
+				attempts++
+				return next(req)
+			},
+		},
+	}
+	_, cs, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, cs.Attempts)
+	require.Equal(t, 3, attempts)
+}
@@ -62,6 +62,8 @@ func GetURL(httpBackoffClient *httpbackoff.Client, url string, writeSeeker io.Wr
 			Err:      err,
 		}
 	}
-	defer resp.Body.Close()
+	if resp != nil {
+		resp.Body.Close()
+	}
 	return
 }
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"errors"
+	"io"
+)
+
+// WriteOnceSeeker implements io.WriteSeeker for an io.Writer, supporting only
+// seeking to the beginning before anything has been written.  This allows
+// data to be streamed to an io.Writer by functions that rewind their
+// io.WriteSeeker before each attempt, provided that any retry happens before
+// data has been written.
+type WriteOnceSeeker struct {
+	Writer  io.Writer
+	written bool
+}
+
+func (w *WriteOnceSeeker) Write(b []byte) (int, error) {
+	if len(b) > 0 {
+		w.written = true
+	}
+	return w.Writer.Write(b)
+}
+
+func (w *WriteOnceSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart || offset != 0 || w.written {
+		return 0, errors.New("cannot rewind a download to an io.Writer")
+	}
+	return 0, nil
+}
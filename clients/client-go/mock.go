@@ -0,0 +1,89 @@
+package tcclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+// MockCall is a call made to a mock client, recorded by MockCalls
+type MockCall struct {
+	// The name of the method called, such as "CreateTask"
+	Method string
+	// The arguments the method was called with, in order
+	Args []interface{}
+}
+
+// MockCalls records the calls made to a mock client.  It is embedded in each
+// of the generated mock clients, such as tcqueue.MockQueue, and is safe for
+// concurrent use.
+type MockCalls struct {
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+// Record records a call to the given method, with the given arguments
+func (m *MockCalls) Record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, MockCall{Method: method, Args: args})
+}
+
+// Calls returns the calls recorded so far, in the order they were made
+func (m *MockCalls) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall{}, m.calls...)
+}
+
+// CallsTo returns the calls recorded so far to the given method, in the order
+// they were made
+func (m *MockCalls) CallsTo(method string) []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := []MockCall{}
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// NotMockedError is returned by a mock client method that has no function
+// set to implement it
+type NotMockedError struct {
+	// The name of the method, such as "Queue.CreateTask"
+	Method string
+}
+
+func (err *NotMockedError) Error() string {
+	return err.Method + " is not mocked"
+}
+
+// FakeAPIError returns an error like the one returned by a client method when
+// the service responds with the given HTTP status code and error code, such
+// as (404, "ResourceNotFound"), for use by mock and fake clients.
+func FakeAPIError(statusCode int, code, message string) *APICallException {
+	body, _ := json.Marshal(map[string]string{"code": code, "message": message})
+	resp := &http.Response{
+		StatusCode: statusCode,
+		Status:     strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+		Header:     http.Header{},
+	}
+	return &APICallException{
+		CallSummary: &CallSummary{
+			HTTPResponse:     resp,
+			HTTPResponseBody: string(body),
+			Attempts:         1,
+		},
+		RootCause: httpbackoff.BadHttpResponseCode{
+			HttpResponseCode: statusCode,
+			Message:          "(Permanent) HTTP response code " + strconv.Itoa(statusCode) + "\n" + string(body),
+		},
+		APIError: parseAPIError(resp, string(body)),
+	}
+}
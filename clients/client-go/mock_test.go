@@ -0,0 +1,51 @@
+package tcclient
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+func TestMockCalls(t *testing.T) {
+	var m MockCalls
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Record("Ping")
+		}()
+	}
+	wg.Wait()
+	m.Record("Task", "abc")
+
+	require.Len(t, m.Calls(), 11)
+	require.Len(t, m.CallsTo("Ping"), 10)
+	require.Equal(t, []MockCall{{Method: "Task", Args: []interface{}{"abc"}}}, m.CallsTo("Task"))
+	require.Empty(t, m.CallsTo("Status"))
+}
+
+func TestNotMockedError(t *testing.T) {
+	var err error = &NotMockedError{Method: "Queue.Ping"}
+	require.Equal(t, "Queue.Ping is not mocked", err.Error())
+}
+
+func TestFakeAPIError(t *testing.T) {
+	var err error = FakeAPIError(404, "ResourceNotFound", "no such task")
+
+	var apiErr *APICallException
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, 404, apiErr.CallSummary.HTTPResponse.StatusCode)
+	require.JSONEq(t, `{"code": "ResourceNotFound", "message": "no such task"}`, apiErr.CallSummary.HTTPResponseBody)
+	require.Equal(t, 404, apiErr.RootCause.(httpbackoff.BadHttpResponseCode).HttpResponseCode)
+	require.Contains(t, err.Error(), "no such task")
+}
+
+func TestFakeAPIErrorIs(t *testing.T) {
+	err := FakeAPIError(409, "RequestConflict", "conflict")
+	require.True(t, errors.Is(err, ErrRequestConflict))
+	require.Equal(t, "conflict", err.APIError.Message)
+}
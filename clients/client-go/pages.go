@@ -0,0 +1,89 @@
+package tcclient
+
+import (
+	"context"
+)
+
+// PageFetcher fetches the page of results following the given continuation
+// token (the first page, if it is empty), returning the page and the
+// continuation token for the next page, which is empty on the last page.
+type PageFetcher[T any] func(ctx context.Context, continuationToken string) (page *T, next string, err error)
+
+// Pages iterates over the pages of results of an API method that returns a
+// `continuationToken`, fetching each page as it is needed.  The generated
+// `<Method>Pages` methods return a Pages object for each such API method.
+// Use it as follows:
+//
+//	pages := queue.ListTaskGroupPages(ctx, taskGroupId, "")
+//	for pages.Next() {
+//		for _, task := range pages.Page().Tasks {
+//			// ...
+//		}
+//	}
+//	if err := pages.Err(); err != nil {
+//		// handle error...
+//	}
+type Pages[T any] struct {
+	ctx               context.Context
+	fetch             PageFetcher[T]
+	page              *T
+	continuationToken string
+	done              bool
+	err               error
+}
+
+// NewPages returns a Pages object that calls fetch to get each page.  Requests
+// are made with the given context, and iteration stops when it is done.
+func NewPages[T any](ctx context.Context, fetch PageFetcher[T]) *Pages[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Pages[T]{
+		ctx:   ctx,
+		fetch: fetch,
+	}
+}
+
+// Next fetches the next page, returning false if there are no more pages or
+// if an error occurred, in which case it is returned by Err.
+func (p *Pages[T]) Next() bool {
+	if p.done {
+		return false
+	}
+	if err := p.ctx.Err(); err != nil {
+		p.fail(err)
+		return false
+	}
+	page, next, err := p.fetch(p.ctx, p.continuationToken)
+	if err != nil {
+		p.fail(err)
+		return false
+	}
+	p.page = page
+	p.continuationToken = next
+	p.done = next == ""
+	return true
+}
+
+func (p *Pages[T]) fail(err error) {
+	p.page = nil
+	p.err = err
+	p.done = true
+}
+
+// Page returns the page fetched by the most recent call to Next.
+func (p *Pages[T]) Page() *T {
+	return p.page
+}
+
+// ContinuationToken returns the continuation token for the page following
+// the current page, allowing iteration to be resumed later.  It is empty
+// after the last page.
+func (p *Pages[T]) ContinuationToken() string {
+	return p.continuationToken
+}
+
+// Err returns the error that stopped iteration, if any.
+func (p *Pages[T]) Err() error {
+	return p.err
+}
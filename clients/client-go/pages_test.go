@@ -0,0 +1,110 @@
+package tcclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPage struct {
+	Items             []string `json:"items"`
+	ContinuationToken string   `json:"continuationToken"`
+}
+
+// Serve three pages of results, following the continuationToken query
+// parameter
+func pagesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"":   `{"items": ["a", "b"], "continuationToken": "p2"}`,
+		"p2": `{"items": ["c"], "continuationToken": "p3"}`,
+		"p3": `{"items": ["d"]}`,
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("continuationToken")]
+		if !ok {
+			w.WriteHeader(400)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(page))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func fetchTestPage(c *Client) PageFetcher[testPage] {
+	return func(ctx context.Context, continuationToken string) (*testPage, string, error) {
+		cd := *c
+		cd.Context = ctx
+		v := url.Values{}
+		if continuationToken != "" {
+			v.Add("continuationToken", continuationToken)
+		}
+		result, _, err := cd.APICall(nil, "GET", "/list", new(testPage), v)
+		if err != nil {
+			return nil, "", err
+		}
+		page := result.(*testPage)
+		return page, page.ContinuationToken, nil
+	}
+}
+
+func TestPages(t *testing.T) {
+	s := pagesServer(t)
+	c := &Client{RootURL: s.URL}
+
+	pages := NewPages(context.Background(), fetchTestPage(c))
+	items := []string{}
+	tokens := []string{}
+	for pages.Next() {
+		items = append(items, pages.Page().Items...)
+		tokens = append(tokens, pages.ContinuationToken())
+	}
+	require.NoError(t, pages.Err())
+	require.Equal(t, []string{"a", "b", "c", "d"}, items)
+	require.Equal(t, []string{"p2", "p3", ""}, tokens)
+
+	// once finished, iteration does not restart
+	require.False(t, pages.Next())
+}
+
+func TestPagesError(t *testing.T) {
+	fetchErr := errors.New("uhoh")
+	calls := 0
+	pages := NewPages(context.Background(), func(ctx context.Context, continuationToken string) (*testPage, string, error) {
+		calls++
+		if calls == 2 {
+			return nil, "", fetchErr
+		}
+		return &testPage{Items: []string{"a"}}, "more", nil
+	})
+
+	require.True(t, pages.Next())
+	require.False(t, pages.Next())
+	require.Nil(t, pages.Page())
+	require.Equal(t, fetchErr, pages.Err())
+
+	// no further calls are made after an error
+	require.False(t, pages.Next())
+	require.Equal(t, 2, calls)
+}
+
+func TestPagesContextCancelled(t *testing.T) {
+	s := pagesServer(t)
+	c := &Client{RootURL: s.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pages := NewPages(ctx, fetchTestPage(c))
+	require.True(t, pages.Next())
+	require.Equal(t, []string{"a", "b"}, pages.Page().Items)
+
+	cancel()
+	require.False(t, pages.Next())
+	require.Equal(t, context.Canceled, pages.Err())
+}
@@ -0,0 +1,213 @@
+package tcclient
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+// RetryPolicy configures how failed API requests are retried.  Requests are
+// retried after network errors, HTTP 5xx responses and HTTP 429 (Too Many
+// Requests) responses.  The delay before each retry grows exponentially from
+// InitialInterval, and is randomized so that many clients failing at once do
+// not all retry at the same moment.  If a 429 or 503 response has a
+// Retry-After header, the client waits at least as long as it specifies.
+//
+// Start from DefaultRetryPolicy() when only changing some settings.
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first; zero means no limit
+	// other than Budget
+	MaxAttempts int
+
+	// Delay before the first retry
+	InitialInterval time.Duration
+
+	// Factor by which the delay increases after each retry
+	Multiplier float64
+
+	// Maximum delay between attempts, other than as requested by
+	// Retry-After; zero means no maximum
+	MaxInterval time.Duration
+
+	// Jitter applied to each delay, between 0 and 1: a delay d is chosen
+	// randomly from the range [d * (1 - RandomizationFactor), d * (1 +
+	// RandomizationFactor)]
+	RandomizationFactor float64
+
+	// Total time allowed for all attempts and the delays between them; a
+	// retry is not made if waiting for it would exceed the budget.  Zero means
+	// no limit other than MaxAttempts.
+	Budget time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by clients without a
+// RetryPolicy or HTTPBackoffClient.
+func DefaultRetryPolicy() *RetryPolicy {
+	return retryPolicyFromBackOff(backoff.NewExponentialBackOff())
+}
+
+// Convert the settings of an exponential backoff, as used by
+// Client.HTTPBackoffClient, into a RetryPolicy
+func retryPolicyFromBackOff(b *backoff.ExponentialBackOff) *RetryPolicy {
+	return &RetryPolicy{
+		InitialInterval:     b.InitialInterval,
+		Multiplier:          b.Multiplier,
+		MaxInterval:         b.MaxInterval,
+		RandomizationFactor: b.RandomizationFactor,
+		Budget:              b.MaxElapsedTime,
+	}
+}
+
+// RetryEvent describes a failed attempt at an API request, which is about to
+// be retried.
+type RetryEvent struct {
+	// The service, HTTP method and route of the request
+	ServiceName string
+	Method      string
+	Route       string
+	// The number of the attempt that failed, starting at 1
+	Attempt int
+	// The time to wait before the next attempt
+	Delay time.Duration
+	// The error of the failed attempt
+	Err error
+	// The HTTP status code of the failed attempt, or zero if no response
+	// was received
+	StatusCode int
+}
+
+// RetryHook is called before each retry of an API request, allowing retries
+// to be logged or counted in metrics.  It is called synchronously, so should
+// return quickly.
+type RetryHook func(event *RetryEvent)
+
+// Get the retry policy for this client
+func (client *Client) retryPolicy() *RetryPolicy {
+	if client.RetryPolicy != nil {
+		return client.RetryPolicy
+	}
+	if client.HTTPBackoffClient != nil && client.HTTPBackoffClient.BackOffSettings != nil {
+		return retryPolicyFromBackOff(client.HTTPBackoffClient.BackOffSettings)
+	}
+	return DefaultRetryPolicy()
+}
+
+// Randomize the given delay according to RandomizationFactor
+func (policy *RetryPolicy) jitter(interval time.Duration) time.Duration {
+	delta := policy.RandomizationFactor * float64(interval)
+	low := float64(interval) - delta
+	return time.Duration(low + rand.Float64()*2*delta)
+}
+
+// Calculate the delay before the retry following one after the given delay
+func (policy *RetryPolicy) nextInterval(interval time.Duration) time.Duration {
+	if policy.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * policy.Multiplier)
+	}
+	if policy.MaxInterval > 0 && interval > policy.MaxInterval {
+		interval = policy.MaxInterval
+	}
+	return interval
+}
+
+// Parse a Retry-After header value, either a number of seconds or an HTTP
+// date, returning zero if it is missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// Make an error for an unsuccessful HTTP response, of the same form as
+// httpbackoff, so that callers can continue to check for
+// httpbackoff.BadHttpResponseCode
+func badResponse(kind string, response *http.Response) error {
+	// this leaves the body available to be read again
+	dump, _ := httputil.DumpResponse(response, true)
+	return httpbackoff.BadHttpResponseCode{
+		HttpResponseCode: response.StatusCode,
+		Message:          "(" + kind + ") HTTP response code " + strconv.Itoa(response.StatusCode) + "\n" + string(dump),
+	}
+}
+
+// Call httpCall, retrying according to the policy, and return the final
+// response, the number of attempts, and any error.  httpCall returns errors
+// that should be retried as tempError, and errors that should not as
+// permError.  Waiting between attempts stops early if ctx is done.  If
+// onRetry is not nil, it is called before waiting for each retry.
+func (policy *RetryPolicy) retry(ctx context.Context, httpCall func() (resp *http.Response, tempError error, permError error), onRetry func(attempt int, delay time.Duration, response *http.Response, err error)) (*http.Response, int, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	start := time.Now()
+	interval := policy.InitialInterval
+	attempts := 0
+	for {
+		response, tempError, permError := httpCall()
+		attempts++
+		if permError != nil {
+			return response, attempts, permError
+		}
+
+		var retryAfter time.Duration
+		if tempError == nil {
+			switch code := response.StatusCode; {
+			case code/100 == 2:
+				return response, attempts, nil
+			case code/100 == 5 || code == http.StatusTooManyRequests:
+				tempError = badResponse("Intermittent", response)
+				if code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable {
+					retryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+				}
+			default:
+				return response, attempts, badResponse("Permanent", response)
+			}
+		}
+
+		if policy.MaxAttempts > 0 && attempts >= policy.MaxAttempts {
+			return response, attempts, tempError
+		}
+		wait := policy.jitter(interval)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		if policy.Budget > 0 && time.Since(start)+wait > policy.Budget {
+			return response, attempts, tempError
+		}
+
+		log.Printf("Error: %s", tempError)
+		if onRetry != nil {
+			onRetry(attempts, wait, response, tempError)
+		}
+		if response != nil {
+			_ = response.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempts, ctx.Err()
+		}
+		interval = policy.nextInterval(interval)
+	}
+}
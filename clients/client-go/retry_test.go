@@ -0,0 +1,193 @@
+package tcclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+// Serve the given status codes in turn, with the given headers, followed by
+// 200 responses
+func statusServer(t *testing.T, header http.Header, codes ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1))
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		if call <= len(codes) {
+			w.WriteHeader(codes[call-1])
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(s.Close)
+	return s, &calls
+}
+
+func quickRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		InitialInterval: time.Millisecond,
+		Multiplier:      2,
+		MaxInterval:     10 * time.Millisecond,
+		Budget:          5 * time.Second,
+	}
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	s, calls := statusServer(t, nil, 500, 502, 503, 504)
+	policy := quickRetryPolicy()
+	policy.MaxAttempts = 3
+	client := Client{RootURL: s.URL, RetryPolicy: policy}
+
+	_, cs, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.Error(t, err)
+	require.Equal(t, 3, cs.Attempts)
+	require.Equal(t, int32(3), atomic.LoadInt32(calls))
+
+	rootCause := err.(*APICallException).RootCause
+	require.IsType(t, httpbackoff.BadHttpResponseCode{}, rootCause)
+	require.Equal(t, 503, rootCause.(httpbackoff.BadHttpResponseCode).HttpResponseCode)
+}
+
+func TestRetryPolicyTooManyRequests(t *testing.T) {
+	s, _ := statusServer(t, nil, 429, 429)
+	client := Client{RootURL: s.URL, RetryPolicy: quickRetryPolicy()}
+
+	_, cs, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, cs.Attempts)
+}
+
+func TestRetryPolicyPermanentError(t *testing.T) {
+	s, calls := statusServer(t, nil, 404)
+	client := Client{RootURL: s.URL, RetryPolicy: quickRetryPolicy()}
+
+	_, _, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestRetryPolicyRetryAfter(t *testing.T) {
+	s, _ := statusServer(t, http.Header{"Retry-After": []string{"1"}}, 503)
+	client := Client{RootURL: s.URL, RetryPolicy: quickRetryPolicy()}
+
+	start := time.Now()
+	_, cs, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, cs.Attempts)
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestRetryPolicyBudget(t *testing.T) {
+	// the Retry-After exceeds the budget, so no retry is made
+	s, calls := statusServer(t, http.Header{"Retry-After": []string{"60"}}, 429)
+	client := Client{RootURL: s.URL, RetryPolicy: quickRetryPolicy()}
+
+	start := time.Now()
+	_, _, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.Error(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(calls))
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRetryPolicyContext(t *testing.T) {
+	s, calls := statusServer(t, http.Header{"Retry-After": []string{"60"}}, 503)
+	policy := quickRetryPolicy()
+	policy.Budget = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	client := Client{RootURL: s.URL, RetryPolicy: policy, Context: ctx}
+
+	time.AfterFunc(100*time.Millisecond, cancel)
+	_, _, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestRetryPolicyFromHTTPBackoffClient(t *testing.T) {
+	client := Client{}
+	client.quickBackoff()
+	policy := client.retryPolicy()
+	require.Equal(t, 100*time.Millisecond, policy.Budget)
+	require.Equal(t, client.HTTPBackoffClient.BackOffSettings.InitialInterval, policy.InitialInterval)
+
+	client.RetryPolicy = quickRetryPolicy()
+	require.Equal(t, client.RetryPolicy, client.retryPolicy())
+}
+
+func TestRetryPolicyIntervals(t *testing.T) {
+	policy := &RetryPolicy{
+		Multiplier:          2,
+		MaxInterval:         3 * time.Second,
+		RandomizationFactor: 0.5,
+	}
+	require.Equal(t, 2*time.Second, policy.nextInterval(time.Second))
+	require.Equal(t, 3*time.Second, policy.nextInterval(2*time.Second))
+
+	for i := 0; i < 100; i++ {
+		d := policy.jitter(time.Second)
+		require.GreaterOrEqual(t, d, 500*time.Millisecond)
+		require.LessOrEqual(t, d, 1500*time.Millisecond)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	require.Equal(t, 90*time.Second, parseRetryAfter("Mon, 01 Jan 2024 00:01:30 GMT", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("Sun, 31 Dec 2023 23:00:00 GMT", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("-5", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestRetryHooks(t *testing.T) {
+	s, _ := statusServer(t, nil, 500, 503)
+	var events []RetryEvent
+	client := Client{
+		RootURL:     s.URL,
+		ServiceName: "queue",
+		APIVersion:  "v1",
+		RetryPolicy: quickRetryPolicy(),
+		RetryHooks: []RetryHook{
+			func(event *RetryEvent) {
+				events = append(events, *event)
+			},
+		},
+	}
+
+	_, cs, err := client.APICall(nil, "GET", "/task/abc", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, cs.Attempts)
+	require.Len(t, events, 2)
+	for i, event := range events {
+		require.Equal(t, "queue", event.ServiceName)
+		require.Equal(t, "GET", event.Method)
+		require.Equal(t, "/task/abc", event.Route)
+		require.Equal(t, i+1, event.Attempt)
+		require.Greater(t, event.Delay, time.Duration(0))
+		require.Error(t, event.Err)
+	}
+	require.Equal(t, 500, events[0].StatusCode)
+	require.Equal(t, 503, events[1].StatusCode)
+}
+
+func TestRetryHooksNotCalledWithoutRetry(t *testing.T) {
+	s, _ := statusServer(t, nil, 404)
+	called := false
+	client := Client{
+		RootURL:     s.URL,
+		RetryPolicy: quickRetryPolicy(),
+		RetryHooks:  []RetryHook{func(event *RetryEvent) { called = true }},
+	}
+
+	_, _, err := client.APICall(nil, "GET", "/whatever", nil, nil)
+	require.Error(t, err)
+	require.False(t, called)
+}
@@ -0,0 +1,351 @@
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that `${GOPATH}/bin` is in your `PATH`:
+//
+// go install && go generate
+
+package tcauth
+
+import (
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// MockAuth is a mock implementation of AuthAPI, for use in tests.  Each method
+// records the call, then calls the function in the corresponding `<Method>Func` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type MockAuth struct {
+	tcclient.MockCalls
+	PingFunc                func() error
+	LbheartbeatFunc         func() error
+	VersionFunc             func() error
+	ListClientsFunc         func(continuationToken, limit, prefix string) (*ListClientResponse, error)
+	ClientFunc              func(clientId string) (*GetClientResponse, error)
+	CreateClientFunc        func(clientId string, payload *CreateClientRequest) (*CreateClientResponse, error)
+	ResetAccessTokenFunc    func(clientId string) (*CreateClientResponse, error)
+	UpdateClientFunc        func(clientId string, payload *CreateClientRequest) (*GetClientResponse, error)
+	EnableClientFunc        func(clientId string) (*GetClientResponse, error)
+	DisableClientFunc       func(clientId string) (*GetClientResponse, error)
+	DeleteClientFunc        func(clientId string) error
+	ListRolesFunc           func() (*GetAllRolesNoPagination, error)
+	ListRoles2Func          func(continuationToken, limit string) (*GetAllRolesResponse, error)
+	ListRoleIdsFunc         func(continuationToken, limit string) (*GetRoleIdsResponse, error)
+	RoleFunc                func(roleId string) (*GetRoleResponse, error)
+	CreateRoleFunc          func(roleId string, payload *CreateRoleRequest) (*GetRoleResponse, error)
+	UpdateRoleFunc          func(roleId string, payload *CreateRoleRequest) (*GetRoleResponse, error)
+	DeleteRoleFunc          func(roleId string) error
+	ExpandScopesFunc        func(payload *SetOfScopes) (*SetOfScopes, error)
+	CurrentScopesFunc       func() (*SetOfScopes, error)
+	AwsS3CredentialsFunc    func(level, bucket, prefix, format string) (*AWSS3CredentialsResponse, error)
+	AzureAccountsFunc       func() (*AzureListAccountResponse, error)
+	AzureTablesFunc         func(account, continuationToken string) (*AzureListTableResponse, error)
+	AzureTableSASFunc       func(account, table, level string) (*AzureTableSharedAccessSignature, error)
+	AzureContainersFunc     func(account, continuationToken string) (*AzureListContainersResponse, error)
+	AzureContainerSASFunc   func(account, container, level string) (*AzureBlobSharedAccessSignature, error)
+	SentryDSNFunc           func(project string) (*SentryDSNResponse, error)
+	WebsocktunnelTokenFunc  func(wstAudience, wstClient string) (*WebsocktunnelTokenResponse, error)
+	GcpCredentialsFunc      func(projectId, serviceAccount string) (*GCPCredentialsResponse, error)
+	AuthenticateHawkFunc    func(payload *HawkSignatureAuthenticationRequest) (*HawkSignatureAuthenticationResponse, error)
+	TestAuthenticateFunc    func(payload *TestAuthenticateRequest) (*TestAuthenticateResponse, error)
+	TestAuthenticateGetFunc func() (*TestAuthenticateResponse, error)
+	HeartbeatFunc           func() error
+}
+
+var _ AuthAPI = (*MockAuth)(nil)
+
+// Ping records the call and calls PingFunc
+func (m *MockAuth) Ping() error {
+	m.Record("Ping")
+	if m.PingFunc == nil {
+		return &tcclient.NotMockedError{Method: "Auth.Ping"}
+	}
+	return m.PingFunc()
+}
+
+// Lbheartbeat records the call and calls LbheartbeatFunc
+func (m *MockAuth) Lbheartbeat() error {
+	m.Record("Lbheartbeat")
+	if m.LbheartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Auth.Lbheartbeat"}
+	}
+	return m.LbheartbeatFunc()
+}
+
+// Version records the call and calls VersionFunc
+func (m *MockAuth) Version() error {
+	m.Record("Version")
+	if m.VersionFunc == nil {
+		return &tcclient.NotMockedError{Method: "Auth.Version"}
+	}
+	return m.VersionFunc()
+}
+
+// ListClients records the call and calls ListClientsFunc
+func (m *MockAuth) ListClients(continuationToken, limit, prefix string) (*ListClientResponse, error) {
+	m.Record("ListClients", continuationToken, limit, prefix)
+	if m.ListClientsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.ListClients"}
+	}
+	return m.ListClientsFunc(continuationToken, limit, prefix)
+}
+
+// Client records the call and calls ClientFunc
+func (m *MockAuth) Client(clientId string) (*GetClientResponse, error) {
+	m.Record("Client", clientId)
+	if m.ClientFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.Client"}
+	}
+	return m.ClientFunc(clientId)
+}
+
+// CreateClient records the call and calls CreateClientFunc
+func (m *MockAuth) CreateClient(clientId string, payload *CreateClientRequest) (*CreateClientResponse, error) {
+	m.Record("CreateClient", clientId, payload)
+	if m.CreateClientFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.CreateClient"}
+	}
+	return m.CreateClientFunc(clientId, payload)
+}
+
+// ResetAccessToken records the call and calls ResetAccessTokenFunc
+func (m *MockAuth) ResetAccessToken(clientId string) (*CreateClientResponse, error) {
+	m.Record("ResetAccessToken", clientId)
+	if m.ResetAccessTokenFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.ResetAccessToken"}
+	}
+	return m.ResetAccessTokenFunc(clientId)
+}
+
+// UpdateClient records the call and calls UpdateClientFunc
+func (m *MockAuth) UpdateClient(clientId string, payload *CreateClientRequest) (*GetClientResponse, error) {
+	m.Record("UpdateClient", clientId, payload)
+	if m.UpdateClientFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.UpdateClient"}
+	}
+	return m.UpdateClientFunc(clientId, payload)
+}
+
+// EnableClient records the call and calls EnableClientFunc
+func (m *MockAuth) EnableClient(clientId string) (*GetClientResponse, error) {
+	m.Record("EnableClient", clientId)
+	if m.EnableClientFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.EnableClient"}
+	}
+	return m.EnableClientFunc(clientId)
+}
+
+// DisableClient records the call and calls DisableClientFunc
+func (m *MockAuth) DisableClient(clientId string) (*GetClientResponse, error) {
+	m.Record("DisableClient", clientId)
+	if m.DisableClientFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.DisableClient"}
+	}
+	return m.DisableClientFunc(clientId)
+}
+
+// DeleteClient records the call and calls DeleteClientFunc
+func (m *MockAuth) DeleteClient(clientId string) error {
+	m.Record("DeleteClient", clientId)
+	if m.DeleteClientFunc == nil {
+		return &tcclient.NotMockedError{Method: "Auth.DeleteClient"}
+	}
+	return m.DeleteClientFunc(clientId)
+}
+
+// ListRoles records the call and calls ListRolesFunc
+func (m *MockAuth) ListRoles() (*GetAllRolesNoPagination, error) {
+	m.Record("ListRoles")
+	if m.ListRolesFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.ListRoles"}
+	}
+	return m.ListRolesFunc()
+}
+
+// ListRoles2 records the call and calls ListRoles2Func
+func (m *MockAuth) ListRoles2(continuationToken, limit string) (*GetAllRolesResponse, error) {
+	m.Record("ListRoles2", continuationToken, limit)
+	if m.ListRoles2Func == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.ListRoles2"}
+	}
+	return m.ListRoles2Func(continuationToken, limit)
+}
+
+// ListRoleIds records the call and calls ListRoleIdsFunc
+func (m *MockAuth) ListRoleIds(continuationToken, limit string) (*GetRoleIdsResponse, error) {
+	m.Record("ListRoleIds", continuationToken, limit)
+	if m.ListRoleIdsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.ListRoleIds"}
+	}
+	return m.ListRoleIdsFunc(continuationToken, limit)
+}
+
+// Role records the call and calls RoleFunc
+func (m *MockAuth) Role(roleId string) (*GetRoleResponse, error) {
+	m.Record("Role", roleId)
+	if m.RoleFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.Role"}
+	}
+	return m.RoleFunc(roleId)
+}
+
+// CreateRole records the call and calls CreateRoleFunc
+func (m *MockAuth) CreateRole(roleId string, payload *CreateRoleRequest) (*GetRoleResponse, error) {
+	m.Record("CreateRole", roleId, payload)
+	if m.CreateRoleFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.CreateRole"}
+	}
+	return m.CreateRoleFunc(roleId, payload)
+}
+
+// UpdateRole records the call and calls UpdateRoleFunc
+func (m *MockAuth) UpdateRole(roleId string, payload *CreateRoleRequest) (*GetRoleResponse, error) {
+	m.Record("UpdateRole", roleId, payload)
+	if m.UpdateRoleFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.UpdateRole"}
+	}
+	return m.UpdateRoleFunc(roleId, payload)
+}
+
+// DeleteRole records the call and calls DeleteRoleFunc
+func (m *MockAuth) DeleteRole(roleId string) error {
+	m.Record("DeleteRole", roleId)
+	if m.DeleteRoleFunc == nil {
+		return &tcclient.NotMockedError{Method: "Auth.DeleteRole"}
+	}
+	return m.DeleteRoleFunc(roleId)
+}
+
+// ExpandScopes records the call and calls ExpandScopesFunc
+func (m *MockAuth) ExpandScopes(payload *SetOfScopes) (*SetOfScopes, error) {
+	m.Record("ExpandScopes", payload)
+	if m.ExpandScopesFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.ExpandScopes"}
+	}
+	return m.ExpandScopesFunc(payload)
+}
+
+// CurrentScopes records the call and calls CurrentScopesFunc
+func (m *MockAuth) CurrentScopes() (*SetOfScopes, error) {
+	m.Record("CurrentScopes")
+	if m.CurrentScopesFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.CurrentScopes"}
+	}
+	return m.CurrentScopesFunc()
+}
+
+// AwsS3Credentials records the call and calls AwsS3CredentialsFunc
+func (m *MockAuth) AwsS3Credentials(level, bucket, prefix, format string) (*AWSS3CredentialsResponse, error) {
+	m.Record("AwsS3Credentials", level, bucket, prefix, format)
+	if m.AwsS3CredentialsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.AwsS3Credentials"}
+	}
+	return m.AwsS3CredentialsFunc(level, bucket, prefix, format)
+}
+
+// AzureAccounts records the call and calls AzureAccountsFunc
+func (m *MockAuth) AzureAccounts() (*AzureListAccountResponse, error) {
+	m.Record("AzureAccounts")
+	if m.AzureAccountsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.AzureAccounts"}
+	}
+	return m.AzureAccountsFunc()
+}
+
+// AzureTables records the call and calls AzureTablesFunc
+func (m *MockAuth) AzureTables(account, continuationToken string) (*AzureListTableResponse, error) {
+	m.Record("AzureTables", account, continuationToken)
+	if m.AzureTablesFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.AzureTables"}
+	}
+	return m.AzureTablesFunc(account, continuationToken)
+}
+
+// AzureTableSAS records the call and calls AzureTableSASFunc
+func (m *MockAuth) AzureTableSAS(account, table, level string) (*AzureTableSharedAccessSignature, error) {
+	m.Record("AzureTableSAS", account, table, level)
+	if m.AzureTableSASFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.AzureTableSAS"}
+	}
+	return m.AzureTableSASFunc(account, table, level)
+}
+
+// AzureContainers records the call and calls AzureContainersFunc
+func (m *MockAuth) AzureContainers(account, continuationToken string) (*AzureListContainersResponse, error) {
+	m.Record("AzureContainers", account, continuationToken)
+	if m.AzureContainersFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.AzureContainers"}
+	}
+	return m.AzureContainersFunc(account, continuationToken)
+}
+
+// AzureContainerSAS records the call and calls AzureContainerSASFunc
+func (m *MockAuth) AzureContainerSAS(account, container, level string) (*AzureBlobSharedAccessSignature, error) {
+	m.Record("AzureContainerSAS", account, container, level)
+	if m.AzureContainerSASFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.AzureContainerSAS"}
+	}
+	return m.AzureContainerSASFunc(account, container, level)
+}
+
+// SentryDSN records the call and calls SentryDSNFunc
+func (m *MockAuth) SentryDSN(project string) (*SentryDSNResponse, error) {
+	m.Record("SentryDSN", project)
+	if m.SentryDSNFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.SentryDSN"}
+	}
+	return m.SentryDSNFunc(project)
+}
+
+// WebsocktunnelToken records the call and calls WebsocktunnelTokenFunc
+func (m *MockAuth) WebsocktunnelToken(wstAudience, wstClient string) (*WebsocktunnelTokenResponse, error) {
+	m.Record("WebsocktunnelToken", wstAudience, wstClient)
+	if m.WebsocktunnelTokenFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.WebsocktunnelToken"}
+	}
+	return m.WebsocktunnelTokenFunc(wstAudience, wstClient)
+}
+
+// GcpCredentials records the call and calls GcpCredentialsFunc
+func (m *MockAuth) GcpCredentials(projectId, serviceAccount string) (*GCPCredentialsResponse, error) {
+	m.Record("GcpCredentials", projectId, serviceAccount)
+	if m.GcpCredentialsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.GcpCredentials"}
+	}
+	return m.GcpCredentialsFunc(projectId, serviceAccount)
+}
+
+// AuthenticateHawk records the call and calls AuthenticateHawkFunc
+func (m *MockAuth) AuthenticateHawk(payload *HawkSignatureAuthenticationRequest) (*HawkSignatureAuthenticationResponse, error) {
+	m.Record("AuthenticateHawk", payload)
+	if m.AuthenticateHawkFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.AuthenticateHawk"}
+	}
+	return m.AuthenticateHawkFunc(payload)
+}
+
+// TestAuthenticate records the call and calls TestAuthenticateFunc
+func (m *MockAuth) TestAuthenticate(payload *TestAuthenticateRequest) (*TestAuthenticateResponse, error) {
+	m.Record("TestAuthenticate", payload)
+	if m.TestAuthenticateFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.TestAuthenticate"}
+	}
+	return m.TestAuthenticateFunc(payload)
+}
+
+// TestAuthenticateGet records the call and calls TestAuthenticateGetFunc
+func (m *MockAuth) TestAuthenticateGet() (*TestAuthenticateResponse, error) {
+	m.Record("TestAuthenticateGet")
+	if m.TestAuthenticateGetFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Auth.TestAuthenticateGet"}
+	}
+	return m.TestAuthenticateGetFunc()
+}
+
+// Heartbeat records the call and calls HeartbeatFunc
+func (m *MockAuth) Heartbeat() error {
+	m.Record("Heartbeat")
+	if m.HeartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Auth.Heartbeat"}
+	}
+	return m.HeartbeatFunc()
+}
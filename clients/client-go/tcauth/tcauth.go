@@ -39,6 +39,7 @@
 package tcauth
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -178,6 +179,24 @@ func (auth *Auth) ListClients_SignedURL(continuationToken, limit, prefix string,
 	return (&cd).SignedURL("/clients/", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListClients, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListClients for more details.
+func (auth *Auth) ListClientsPages(ctx context.Context, limit, prefix string) *tcclient.Pages[ListClientResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListClientResponse, string, error) {
+		c := *auth
+		c.Context = ctx
+		response, err := c.ListClients(continuationToken, limit, prefix)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Get information about a single client.
 //
 // Required scopes:
@@ -387,6 +406,24 @@ func (auth *Auth) ListRoles2_SignedURL(continuationToken, limit string, duration
 	return (&cd).SignedURL("/roles2/", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListRoles2, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListRoles2 for more details.
+func (auth *Auth) ListRoles2Pages(ctx context.Context, limit string) *tcclient.Pages[GetAllRolesResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*GetAllRolesResponse, string, error) {
+		c := *auth
+		c.Context = ctx
+		response, err := c.ListRoles2(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Get a list of all role IDs.
 //
 // If no limit is given, the roleIds of all roles are returned. Since this
@@ -430,6 +467,24 @@ func (auth *Auth) ListRoleIds_SignedURL(continuationToken, limit string, duratio
 	return (&cd).SignedURL("/roleids/", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListRoleIds, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListRoleIds for more details.
+func (auth *Auth) ListRoleIdsPages(ctx context.Context, limit string) *tcclient.Pages[GetRoleIdsResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*GetRoleIdsResponse, string, error) {
+		c := *auth
+		c.Context = ctx
+		response, err := c.ListRoleIds(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Get information about a single role, including the set of scopes that the
 // role expands to.
 //
@@ -691,6 +746,24 @@ func (auth *Auth) AzureTables_SignedURL(account, continuationToken string, durat
 	return (&cd).SignedURL("/azure/"+url.QueryEscape(account)+"/tables", v, duration)
 }
 
+// Returns an iterator over the pages of results of AzureTables, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See AzureTables for more details.
+func (auth *Auth) AzureTablesPages(ctx context.Context, account string) *tcclient.Pages[AzureListTableResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*AzureListTableResponse, string, error) {
+		c := *auth
+		c.Context = ctx
+		response, err := c.AzureTables(account, continuationToken)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** DEPRECATED ***
 //
 // Get a shared access signature (SAS) string for use with a specific Azure
@@ -764,6 +837,24 @@ func (auth *Auth) AzureContainers_SignedURL(account, continuationToken string, d
 	return (&cd).SignedURL("/azure/"+url.QueryEscape(account)+"/containers", v, duration)
 }
 
+// Returns an iterator over the pages of results of AzureContainers, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See AzureContainers for more details.
+func (auth *Auth) AzureContainersPages(ctx context.Context, account string) *tcclient.Pages[AzureListContainersResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*AzureListContainersResponse, string, error) {
+		c := *auth
+		c.Context = ctx
+		response, err := c.AzureContainers(account, continuationToken)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** DEPRECATED ***
 //
 // Get a shared access signature (SAS) string for use with a specific Azure
@@ -975,3 +1066,44 @@ func (auth *Auth) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// AuthAPI is the interface implemented by *Auth, with a method for
+// each API method.  Code that accepts AuthAPI rather than *Auth can be
+// tested with MockAuth.
+type AuthAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	ListClients(continuationToken, limit, prefix string) (*ListClientResponse, error)
+	Client(clientId string) (*GetClientResponse, error)
+	CreateClient(clientId string, payload *CreateClientRequest) (*CreateClientResponse, error)
+	ResetAccessToken(clientId string) (*CreateClientResponse, error)
+	UpdateClient(clientId string, payload *CreateClientRequest) (*GetClientResponse, error)
+	EnableClient(clientId string) (*GetClientResponse, error)
+	DisableClient(clientId string) (*GetClientResponse, error)
+	DeleteClient(clientId string) error
+	ListRoles() (*GetAllRolesNoPagination, error)
+	ListRoles2(continuationToken, limit string) (*GetAllRolesResponse, error)
+	ListRoleIds(continuationToken, limit string) (*GetRoleIdsResponse, error)
+	Role(roleId string) (*GetRoleResponse, error)
+	CreateRole(roleId string, payload *CreateRoleRequest) (*GetRoleResponse, error)
+	UpdateRole(roleId string, payload *CreateRoleRequest) (*GetRoleResponse, error)
+	DeleteRole(roleId string) error
+	ExpandScopes(payload *SetOfScopes) (*SetOfScopes, error)
+	CurrentScopes() (*SetOfScopes, error)
+	AwsS3Credentials(level, bucket, prefix, format string) (*AWSS3CredentialsResponse, error)
+	AzureAccounts() (*AzureListAccountResponse, error)
+	AzureTables(account, continuationToken string) (*AzureListTableResponse, error)
+	AzureTableSAS(account, table, level string) (*AzureTableSharedAccessSignature, error)
+	AzureContainers(account, continuationToken string) (*AzureListContainersResponse, error)
+	AzureContainerSAS(account, container, level string) (*AzureBlobSharedAccessSignature, error)
+	SentryDSN(project string) (*SentryDSNResponse, error)
+	WebsocktunnelToken(wstAudience, wstClient string) (*WebsocktunnelTokenResponse, error)
+	GcpCredentials(projectId, serviceAccount string) (*GCPCredentialsResponse, error)
+	AuthenticateHawk(payload *HawkSignatureAuthenticationRequest) (*HawkSignatureAuthenticationResponse, error)
+	TestAuthenticate(payload *TestAuthenticateRequest) (*TestAuthenticateResponse, error)
+	TestAuthenticateGet() (*TestAuthenticateResponse, error)
+	Heartbeat() error
+}
+
+var _ AuthAPI = (*Auth)(nil)
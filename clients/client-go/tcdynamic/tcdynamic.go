@@ -0,0 +1,236 @@
+// Package tcdynamic provides a client for the HTTP APIs of a Taskcluster
+// deployment that is built at runtime from the API references the
+// deployment publishes under /references, rather than generated in advance.
+// This allows tools to call services and methods that were added to a
+// deployment after the client library was released.
+//
+// The generated packages, such as tcqueue, give typed access to each API
+// method and should be preferred where possible.  With tcdynamic, methods
+// are named as in the API reference, and payloads and responses are JSON:
+//
+//	client, err := tcdynamic.NewFromEnv()
+//	if err != nil {
+//		// handle error...
+//	}
+//	task, err := client.Invoke("queue", "task", []string{taskID}, nil, nil)
+package tcdynamic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/taskcluster/httpbackoff/v3"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// Service is the API reference of a single service
+type Service struct {
+	ServiceName string  `json:"serviceName"`
+	APIVersion  string  `json:"apiVersion"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Entries     []Entry `json:"entries"`
+}
+
+// Entry is a single API method in a service's API reference
+type Entry struct {
+	Type        string   `json:"type"`
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Stability   string   `json:"stability"`
+	Method      string   `json:"method"`
+	Route       string   `json:"route"`
+	Args        []string `json:"args"`
+	Query       []string `json:"query"`
+	Input       string   `json:"input"`
+	Output      string   `json:"output"`
+}
+
+// Entry returns the method of the service with the given name, if it exists
+func (service *Service) Entry(name string) (*Entry, bool) {
+	for i := range service.Entries {
+		if service.Entries[i].Type == "function" && service.Entries[i].Name == name {
+			return &service.Entries[i], true
+		}
+	}
+	return nil, false
+}
+
+// Client calls the APIs described by a deployment's references.  The
+// embedded tcclient.Client is used for every call, with its ServiceName and
+// APIVersion set for the service being called, so its credentials, retry
+// policy and interceptors apply.
+type Client struct {
+	tcclient.Client
+	services map[string]*Service
+}
+
+// New returns a client for the deployment at rootURL, having fetched the
+// deployment's API references.  If credentials are not nil, calls are
+// authenticated with them.
+func New(credentials *tcclient.Credentials, rootURL string) (*Client, error) {
+	client := &Client{
+		Client: tcclient.Client{
+			Credentials:  credentials,
+			RootURL:      rootURL,
+			Authenticate: credentials != nil,
+		},
+	}
+	if err := client.Refresh(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewFromEnv returns a client configured from environment variables, as
+// tcqueue.NewFromEnv and similar functions do, having fetched the
+// deployment's API references.
+func NewFromEnv() (*Client, error) {
+	c := tcclient.CredentialsFromEnvVars()
+	client := &Client{
+		Client: tcclient.Client{
+			Credentials:  c,
+			RootURL:      tcclient.RootURLFromEnvVars(),
+			Authenticate: c.ClientID != "",
+		},
+	}
+	if err := client.Refresh(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// manifest is the manifest of a deployment's references
+type manifest struct {
+	References []string `json:"references"`
+}
+
+// Fetch the JSON document at the given URL into result
+func getJSON(u string, result interface{}) error {
+	resp, _, err := httpbackoff.Get(u)
+	if err != nil {
+		return fmt.Errorf("could not fetch %v: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("could not parse %v: %w", u, err)
+	}
+	return nil
+}
+
+// Refresh fetches the deployment's API references again, to find any
+// services or methods that have been added since the client was created.
+func (client *Client) Refresh() error {
+	var man manifest
+	if err := getJSON(tcurls.APIManifest(client.RootURL), &man); err != nil {
+		return err
+	}
+	services := map[string]*Service{}
+	for _, ref := range man.References {
+		// exchanges and logs references are not API references
+		if !strings.HasSuffix(ref, "/api.json") {
+			continue
+		}
+		service := &Service{}
+		if err := getJSON(tcurls.NormalizeRootURL(client.RootURL)+ref, service); err != nil {
+			return err
+		}
+		services[service.ServiceName] = service
+	}
+	client.services = services
+	return nil
+}
+
+// Services returns the names of the deployment's services, in order
+func (client *Client) Services() []string {
+	names := make([]string, 0, len(client.services))
+	for name := range client.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Service returns the API reference of the named service, if it exists
+func (client *Client) Service(name string) (*Service, bool) {
+	service, ok := client.services[name]
+	return service, ok
+}
+
+// Invoke calls the named method of the named service, such as "queue" and
+// "createTask".  args are the method's route parameters, in the order given
+// in its API reference, and query gives any query string parameters.  payload
+// is the request body, which must be given for methods that have an input
+// schema and must be nil otherwise; it may be a json.RawMessage or any value
+// that encodes to the expected JSON.
+//
+// Invoke returns the JSON response body, or nil for methods that have no
+// output schema.
+func (client *Client) Invoke(serviceName, method string, args []string, query url.Values, payload interface{}) (json.RawMessage, error) {
+	service, ok := client.services[serviceName]
+	if !ok {
+		return nil, fmt.Errorf("unknown service %q; known services are %s", serviceName, strings.Join(client.Services(), ", "))
+	}
+	entry, ok := service.Entry(method)
+	if !ok {
+		return nil, fmt.Errorf("service %q has no method %q", serviceName, method)
+	}
+	if len(args) != len(entry.Args) {
+		return nil, fmt.Errorf("%s.%s takes %d arguments (%s), but %d were given", serviceName, method, len(entry.Args), strings.Join(entry.Args, ", "), len(args))
+	}
+	for param := range query {
+		if !contains(entry.Query, param) {
+			return nil, fmt.Errorf("%s.%s does not accept query parameter %q", serviceName, method, param)
+		}
+	}
+	if entry.Input != "" && payload == nil {
+		return nil, fmt.Errorf("%s.%s requires a payload", serviceName, method)
+	}
+	if entry.Input == "" && payload != nil {
+		return nil, fmt.Errorf("%s.%s does not take a payload", serviceName, method)
+	}
+
+	// encode the payload here, as APICall only accepts pointer-like payloads
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode payload for %s.%s: %w", serviceName, method, err)
+		}
+		payload = json.RawMessage(data)
+	}
+
+	route := entry.Route
+	for i, arg := range entry.Args {
+		route = strings.Replace(route, "<"+arg+">", url.QueryEscape(args[i]), 1)
+	}
+
+	cd := client.Client
+	cd.ServiceName = service.ServiceName
+	cd.APIVersion = service.APIVersion
+	var result interface{}
+	if entry.Output != "" {
+		result = new(json.RawMessage)
+	}
+	if len(query) == 0 {
+		query = nil
+	}
+	_, _, err := (&cd).APICall(payload, strings.ToUpper(entry.Method), route, result, query)
+	if err != nil || entry.Output == "" {
+		return nil, err
+	}
+	return *result.(*json.RawMessage), nil
+}
+
+func contains(list []string, item string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package tcdynamic
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+const apiReference = `{
+	"$schema": "/schemas/common/api-reference-v0.json#",
+	"serviceName": "queue",
+	"apiVersion": "v1",
+	"title": "Queue Service",
+	"entries": [
+		{"type": "function", "name": "ping", "method": "get", "route": "/ping", "args": [], "query": []},
+		{"type": "function", "name": "task", "method": "get", "route": "/task/<taskId>", "args": ["taskId"], "query": [], "output": "v1/task.json#"},
+		{"type": "function", "name": "createTask", "method": "put", "route": "/task/<taskId>", "args": ["taskId"], "query": [], "input": "v1/create-task-request.json#", "output": "v1/task-status-response.json#"},
+		{"type": "function", "name": "listArtifacts", "method": "get", "route": "/task/<taskId>/runs/<runId>/artifacts", "args": ["taskId", "runId"], "query": ["continuationToken", "limit"], "output": "v1/list-artifacts-response.json#"}
+	]
+}`
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/references/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"references": ["/references/queue/v1/api.json", "/references/queue/v1/exchanges.json"]}`))
+	})
+	mux.HandleFunc("/references/queue/v1/api.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(apiReference))
+	})
+	mux.HandleFunc("/api/queue/v1/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method":        r.Method,
+			"path":          r.URL.EscapedPath(),
+			"query":         r.URL.RawQuery,
+			"body":          string(body),
+			"authorization": r.Header.Get("Authorization"),
+		})
+	})
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func invoke(t *testing.T, client *Client, method string, args []string, query url.Values, payload interface{}) map[string]string {
+	t.Helper()
+	raw, err := client.Invoke("queue", method, args, query, payload)
+	require.NoError(t, err)
+	var result map[string]string
+	require.NoError(t, json.Unmarshal(raw, &result))
+	return result
+}
+
+func TestServices(t *testing.T) {
+	client, err := New(nil, testServer(t).URL)
+	require.NoError(t, err)
+	require.Equal(t, []string{"queue"}, client.Services())
+	service, ok := client.Service("queue")
+	require.True(t, ok)
+	require.Equal(t, "Queue Service", service.Title)
+	entry, ok := service.Entry("createTask")
+	require.True(t, ok)
+	require.Equal(t, []string{"taskId"}, entry.Args)
+}
+
+func TestInvokeGet(t *testing.T) {
+	client, err := New(nil, testServer(t).URL)
+	require.NoError(t, err)
+	result := invoke(t, client, "task", []string{"abc/def"}, nil, nil)
+	require.Equal(t, "GET", result["method"])
+	require.Equal(t, "/api/queue/v1/task/abc%2Fdef", result["path"])
+	require.Equal(t, "", result["authorization"])
+}
+
+func TestInvokeWithPayload(t *testing.T) {
+	client, err := New(&tcclient.Credentials{ClientID: "tester", AccessToken: "no-secret"}, testServer(t).URL)
+	require.NoError(t, err)
+	payload := struct {
+		Name string `json:"name"`
+	}{"my-task"}
+	result := invoke(t, client, "createTask", []string{"abc"}, nil, payload)
+	require.Equal(t, "PUT", result["method"])
+	require.JSONEq(t, `{"name": "my-task"}`, result["body"])
+	require.Contains(t, result["authorization"], `Hawk id="tester"`)
+}
+
+func TestInvokeWithQuery(t *testing.T) {
+	client, err := New(nil, testServer(t).URL)
+	require.NoError(t, err)
+	result := invoke(t, client, "listArtifacts", []string{"abc", "0"}, url.Values{"limit": {"10"}}, nil)
+	require.Equal(t, "/api/queue/v1/task/abc/runs/0/artifacts", result["path"])
+	require.Equal(t, "limit=10", result["query"])
+}
+
+func TestInvokeNoOutput(t *testing.T) {
+	client, err := New(nil, testServer(t).URL)
+	require.NoError(t, err)
+	raw, err := client.Invoke("queue", "ping", nil, nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, raw)
+}
+
+func TestInvokeErrors(t *testing.T) {
+	client, err := New(nil, testServer(t).URL)
+	require.NoError(t, err)
+	for name, call := range map[string]func() error{
+		"unknown service": func() error {
+			_, err := client.Invoke("secrets", "ping", nil, nil, nil)
+			return err
+		},
+		"unknown method": func() error {
+			_, err := client.Invoke("queue", "noSuchMethod", nil, nil, nil)
+			return err
+		},
+		"wrong arguments": func() error {
+			_, err := client.Invoke("queue", "task", nil, nil, nil)
+			return err
+		},
+		"unknown query": func() error {
+			_, err := client.Invoke("queue", "task", []string{"abc"}, url.Values{"limit": {"1"}}, nil)
+			return err
+		},
+		"missing payload": func() error {
+			_, err := client.Invoke("queue", "createTask", []string{"abc"}, nil, nil)
+			return err
+		},
+		"unexpected payload": func() error {
+			_, err := client.Invoke("queue", "task", []string{"abc"}, nil, map[string]string{})
+			return err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Error(t, call())
+		})
+	}
+}
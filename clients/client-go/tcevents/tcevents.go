@@ -0,0 +1,431 @@
+// Package tcevents consumes Taskcluster pulse messages over a websocket, via
+// the web-server service of a Taskcluster deployment, for consumers which
+// cannot connect to Pulse directly.
+//
+// Messages are selected with the bindings generated in the tc*events
+// packages, and their payloads are unmarshaled into the corresponding
+// generated message types:
+//
+//	listener := tcevents.NewFromEnv()
+//	sub, err := listener.Subscribe(ctx,
+//		tcqueueevents.TaskCompleted{TaskGroupID: taskGroupId},
+//		tcqueueevents.TaskFailed{TaskGroupID: taskGroupId},
+//	)
+//	if err != nil {
+//		...
+//	}
+//	defer sub.Close()
+//	for msg := range sub.Messages() {
+//		switch payload := msg.Payload.(type) {
+//		case *tcqueueevents.TaskCompletedMessage:
+//			...
+//		}
+//	}
+//	if err := sub.Err(); err != nil {
+//		...
+//	}
+//
+// If the websocket connection is lost, the subscription is made again on a
+// new connection.  Messages published while disconnected are not delivered.
+package tcevents
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/gorilla/websocket"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// The websocket subprotocol spoken by the web-server's subscription endpoint
+const subprotocol = "graphql-ws"
+
+// The ID of the single operation started on each connection
+const operationID = "1"
+
+const query = `subscription Events($subscriptions: [PulseSubscription]!) {
+  pulseMessages(subscriptions: $subscriptions) {
+    payload
+    exchange
+    routingKey
+    redelivered
+    cc
+  }
+}`
+
+// Binding selects messages by exchange and routing key pattern.  It is
+// implemented by the bindings generated in the tc*events packages, such as
+// tcqueueevents.TaskCompleted.
+type Binding interface {
+	RoutingKey() string
+	ExchangeName() string
+	NewPayloadObject() interface{}
+}
+
+// Message is a pulse message received from a subscription
+type Message struct {
+	// The exchange the message was published to
+	Exchange string
+	// The routing key the message was published with
+	RoutingKey string
+	// True if the message has been delivered before
+	Redelivered bool
+	// Other routing keys the message was published with
+	CC []string
+	// The payload, unmarshaled into the type given by the NewPayloadObject
+	// method of the binding for the message's exchange, or nil if it could
+	// not be unmarshaled
+	Payload interface{}
+	// The payload as received
+	RawPayload json.RawMessage
+}
+
+// SubscriptionError is returned when the service rejects a subscription, or
+// reports an error while delivering its messages.  The subscription is not
+// made again after such an error.
+type SubscriptionError struct {
+	Message string
+}
+
+func (err *SubscriptionError) Error() string {
+	return "subscription failed: " + err.Message
+}
+
+// Listener subscribes to pulse messages from a Taskcluster deployment
+type Listener struct {
+	// The root URL of the Taskcluster deployment
+	RootURL string
+	// Credentials sent with each connection, or nil to connect without
+	// credentials
+	Credentials *tcclient.Credentials
+	// The dialer used to open websocket connections; if nil,
+	// websocket.DefaultDialer is used
+	Dialer *websocket.Dialer
+	// The longest time to wait between attempts to reconnect; if zero, 30
+	// seconds is used
+	MaxReconnectInterval time.Duration
+}
+
+// New returns a Listener for the Taskcluster deployment with the given root
+// URL, connecting with the given credentials, which may be nil.
+func New(rootURL string, credentials *tcclient.Credentials) *Listener {
+	return &Listener{
+		RootURL:     rootURL,
+		Credentials: credentials,
+	}
+}
+
+// NewFromEnv returns a Listener for the Taskcluster deployment given by
+// TASKCLUSTER_ROOT_URL, with credentials from the environment variables
+// TASKCLUSTER_CLIENT_ID, TASKCLUSTER_ACCESS_TOKEN and TASKCLUSTER_CERTIFICATE.
+// If TASKCLUSTER_CLIENT_ID is empty/unset, the listener connects without
+// credentials.
+func NewFromEnv() *Listener {
+	var c *tcclient.Credentials
+	if creds := tcclient.CredentialsFromEnvVars(); creds.ClientID != "" {
+		c = creds
+	}
+	return New(os.Getenv("TASKCLUSTER_ROOT_URL"), c)
+}
+
+// A message of the subscription protocol
+type operationMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type pulseSubscription struct {
+	Exchange string `json:"exchange"`
+	Pattern  string `json:"pattern"`
+}
+
+type pulseMessage struct {
+	Payload     json.RawMessage `json:"payload"`
+	Exchange    string          `json:"exchange"`
+	RoutingKey  string          `json:"routingKey"`
+	Redelivered bool            `json:"redelivered"`
+	CC          []string        `json:"cc"`
+}
+
+type executionResult struct {
+	Data *struct {
+		PulseMessages *pulseMessage `json:"pulseMessages"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Return the URL of the deployment's subscription endpoint
+func (l *Listener) endpoint() (string, error) {
+	u, err := url.Parse(l.RootURL)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse root URL %q: %w", l.RootURL, err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("root URL %q is not an http or https URL", l.RootURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/subscription"
+	return u.String(), nil
+}
+
+// Open a connection and start the subscription on it
+func (l *Listener) connect(ctx context.Context, bindings []Binding) (*websocket.Conn, error) {
+	endpoint, err := l.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	dialer := l.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	d := *dialer
+	d.Subprotocols = []string{subprotocol}
+
+	header := http.Header{}
+	if l.Credentials != nil {
+		creds, err := json.Marshal(l.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString(creds))
+	}
+
+	conn, resp, err := d.DialContext(ctx, endpoint, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("cannot connect to %s: %w (HTTP status %s)", endpoint, err, resp.Status)
+		}
+		return nil, fmt.Errorf("cannot connect to %s: %w", endpoint, err)
+	}
+
+	if err := initialize(conn, bindings); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Initialize a new connection and start the subscription
+func initialize(conn *websocket.Conn, bindings []Binding) error {
+	if err := conn.WriteJSON(operationMessage{Type: "connection_init", Payload: json.RawMessage(`{}`)}); err != nil {
+		return err
+	}
+	for {
+		var msg operationMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+		switch msg.Type {
+		case "connection_ack":
+			subscriptions := make([]pulseSubscription, len(bindings))
+			for i, b := range bindings {
+				subscriptions[i] = pulseSubscription{Exchange: b.ExchangeName(), Pattern: b.RoutingKey()}
+			}
+			payload, err := json.Marshal(map[string]interface{}{
+				"query":     query,
+				"variables": map[string]interface{}{"subscriptions": subscriptions},
+			})
+			if err != nil {
+				return err
+			}
+			return conn.WriteJSON(operationMessage{ID: operationID, Type: "start", Payload: payload})
+		case "connection_error":
+			return &SubscriptionError{Message: "connection rejected: " + string(msg.Payload)}
+		}
+	}
+}
+
+// Subscription delivers the messages matching a set of bindings
+type Subscription struct {
+	listener *Listener
+	bindings []Binding
+	messages chan *Message
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error
+
+	closeOnce sync.Once
+}
+
+// Subscribe connects to the deployment and subscribes to messages matching any
+// of the given bindings.  It returns an error if the first connection fails;
+// later connections are retried until the context is done or the
+// subscription is closed.
+func (l *Listener) Subscribe(ctx context.Context, bindings ...Binding) (*Subscription, error) {
+	if len(bindings) == 0 {
+		return nil, errors.New("at least one binding is required")
+	}
+	conn, err := l.connect(ctx, bindings)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription{
+		listener: l,
+		bindings: bindings,
+		messages: make(chan *Message),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go s.run(ctx, conn)
+	return s, nil
+}
+
+// Messages returns the channel on which messages are delivered.  It is closed
+// when the subscription ends, after which Err gives the reason.
+func (s *Subscription) Messages() <-chan *Message {
+	return s.messages
+}
+
+// Err returns the error that ended the subscription, or nil if it was closed
+// or its context is done.  It should be called once the Messages channel has
+// been closed.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the subscription and waits for its connection to be closed.
+func (s *Subscription) Close() error {
+	s.closeOnce.Do(s.cancel)
+	<-s.done
+	return nil
+}
+
+func (s *Subscription) run(ctx context.Context, conn *websocket.Conn) {
+	defer close(s.done)
+	defer close(s.messages)
+
+	maxInterval := s.listener.MaxReconnectInterval
+	if maxInterval == 0 {
+		maxInterval = 30 * time.Second
+	}
+	b := backoff.NewExponentialBackOff()
+	b.MaxInterval = maxInterval
+	b.MaxElapsedTime = 0
+
+	for {
+		err := s.consume(ctx, conn)
+		var subErr *SubscriptionError
+		if errors.As(err, &subErr) {
+			s.err = err
+			return
+		}
+
+		// reconnect, until the context is done
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case <-time.After(b.NextBackOff()):
+			case <-ctx.Done():
+				return
+			}
+			conn, err = s.listener.connect(ctx, s.bindings)
+			if err == nil {
+				b.Reset()
+				break
+			}
+			if errors.As(err, &subErr) {
+				s.err = err
+				return
+			}
+		}
+	}
+}
+
+// Deliver the messages received on the connection, until it fails or the
+// context is done, and then close it
+func (s *Subscription) consume(ctx context.Context, conn *websocket.Conn) error {
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// end the subscription politely, before closing the connection
+			_ = conn.WriteJSON(operationMessage{ID: operationID, Type: "stop"})
+			_ = conn.WriteJSON(operationMessage{Type: "connection_terminate"})
+			conn.Close()
+		case <-stopped:
+			conn.Close()
+		}
+	}()
+
+	for {
+		var msg operationMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+		switch msg.Type {
+		case "data":
+			var result executionResult
+			if err := json.Unmarshal(msg.Payload, &result); err != nil {
+				return err
+			}
+			if len(result.Errors) > 0 {
+				messages := make([]string, len(result.Errors))
+				for i, e := range result.Errors {
+					messages[i] = e.Message
+				}
+				return &SubscriptionError{Message: strings.Join(messages, "; ")}
+			}
+			if result.Data == nil || result.Data.PulseMessages == nil {
+				continue
+			}
+			select {
+			case s.messages <- s.message(result.Data.PulseMessages):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case "error":
+			return &SubscriptionError{Message: string(msg.Payload)}
+		case "complete":
+			// the service ended the subscription, so make it again
+			return errors.New("subscription completed by the service")
+		}
+	}
+}
+
+// Convert a received message, unmarshaling its payload with the binding for
+// its exchange
+func (s *Subscription) message(m *pulseMessage) *Message {
+	msg := &Message{
+		Exchange:    m.Exchange,
+		RoutingKey:  m.RoutingKey,
+		Redelivered: m.Redelivered,
+		CC:          m.CC,
+		RawPayload:  m.Payload,
+	}
+	for _, b := range s.bindings {
+		if b.ExchangeName() == m.Exchange {
+			payload := b.NewPayloadObject()
+			if json.Unmarshal(m.Payload, payload) == nil {
+				msg.Payload = payload
+			}
+			break
+		}
+	}
+	return msg
+}
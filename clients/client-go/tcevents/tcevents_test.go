@@ -0,0 +1,205 @@
+package tcevents
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueueevents"
+)
+
+// A fake web-server subscription endpoint, which calls handle for each
+// subscription started
+type fakeServer struct {
+	t      *testing.T
+	mu     sync.Mutex
+	starts []json.RawMessage
+	auth   []string
+	handle func(conn *websocket.Conn, connection int)
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	require.Equal(f.t, "/subscription", r.URL.Path)
+	upgrader := websocket.Upgrader{Subprotocols: []string{subprotocol}}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var msg operationMessage
+	if conn.ReadJSON(&msg) != nil || msg.Type != "connection_init" {
+		return
+	}
+	_ = conn.WriteJSON(operationMessage{Type: "connection_ack"})
+	_ = conn.WriteJSON(operationMessage{Type: "ka"})
+	if conn.ReadJSON(&msg) != nil || msg.Type != "start" {
+		return
+	}
+	f.mu.Lock()
+	f.starts = append(f.starts, msg.Payload)
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	connection := len(f.starts)
+	f.mu.Unlock()
+	f.handle(conn, connection)
+}
+
+func (f *fakeServer) startCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.starts)
+}
+
+func sendMessage(conn *websocket.Conn, exchange, routingKey string, payload string) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{
+			"pulseMessages": map[string]interface{}{
+				"payload":     json.RawMessage(payload),
+				"exchange":    exchange,
+				"routingKey":  routingKey,
+				"redelivered": false,
+				"cc":          []string{"route.a"},
+			},
+		},
+	})
+	_ = conn.WriteJSON(operationMessage{ID: operationID, Type: "data", Payload: data})
+}
+
+func startServer(t *testing.T, handle func(conn *websocket.Conn, connection int)) (*fakeServer, *Listener) {
+	t.Helper()
+	f := &fakeServer{t: t, handle: handle}
+	s := httptest.NewServer(f)
+	t.Cleanup(s.Close)
+	l := New(s.URL, nil)
+	l.MaxReconnectInterval = 10 * time.Millisecond
+	return f, l
+}
+
+func receive(t *testing.T, sub *Subscription) *Message {
+	t.Helper()
+	select {
+	case msg, ok := <-sub.Messages():
+		require.True(t, ok, "subscription ended: %v", sub.Err())
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	f, l := startServer(t, func(conn *websocket.Conn, connection int) {
+		sendMessage(conn, "exchange/taskcluster-queue/v1/task-completed", "primary.abc", `{"version": 1, "status": {"taskId": "abc"}, "runId": 0}`)
+		// wait for the client to stop
+		var msg operationMessage
+		for conn.ReadJSON(&msg) == nil {
+		}
+	})
+	l.Credentials = &tcclient.Credentials{ClientID: "tester", AccessToken: "secret"}
+
+	sub, err := l.Subscribe(context.Background(), tcqueueevents.TaskCompleted{TaskID: "abc"}, tcqueueevents.TaskFailed{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	msg := receive(t, sub)
+	require.Equal(t, "exchange/taskcluster-queue/v1/task-completed", msg.Exchange)
+	require.Equal(t, "primary.abc", msg.RoutingKey)
+	require.Equal(t, []string{"route.a"}, msg.CC)
+	payload, ok := msg.Payload.(*tcqueueevents.TaskCompletedMessage)
+	require.True(t, ok)
+	require.Equal(t, "abc", payload.Status.TaskID)
+
+	var start struct {
+		Variables struct {
+			Subscriptions []pulseSubscription `json:"subscriptions"`
+		} `json:"variables"`
+	}
+	require.NoError(t, json.Unmarshal(f.starts[0], &start))
+	require.Equal(t, []pulseSubscription{
+		{Exchange: "exchange/taskcluster-queue/v1/task-completed", Pattern: tcqueueevents.TaskCompleted{TaskID: "abc"}.RoutingKey()},
+		{Exchange: "exchange/taskcluster-queue/v1/task-failed", Pattern: tcqueueevents.TaskFailed{}.RoutingKey()},
+	}, start.Variables.Subscriptions)
+
+	creds, err := base64.StdEncoding.DecodeString(f.auth[0][len("Bearer "):])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"clientId": "tester", "accessToken": "secret", "certificate": "", "authorizedScopes": null}`, string(creds))
+
+	require.NoError(t, sub.Close())
+	_, open := <-sub.Messages()
+	require.False(t, open)
+	require.NoError(t, sub.Err())
+}
+
+func TestResubscribeOnReconnect(t *testing.T) {
+	f, l := startServer(t, func(conn *websocket.Conn, connection int) {
+		sendMessage(conn, "exchange/taskcluster-queue/v1/task-failed", "primary.abc", `{"version": 1, "runId": `+strconv.Itoa(connection)+`}`)
+		if connection == 1 {
+			// drop the first connection
+			return
+		}
+		var msg operationMessage
+		for conn.ReadJSON(&msg) == nil {
+		}
+	})
+
+	sub, err := l.Subscribe(context.Background(), tcqueueevents.TaskFailed{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.Equal(t, int64(1), receive(t, sub).Payload.(*tcqueueevents.TaskFailedMessage).RunID)
+	require.Equal(t, int64(2), receive(t, sub).Payload.(*tcqueueevents.TaskFailedMessage).RunID)
+	require.Equal(t, 2, f.startCount())
+}
+
+func TestSubscriptionError(t *testing.T) {
+	_, l := startServer(t, func(conn *websocket.Conn, connection int) {
+		_ = conn.WriteJSON(operationMessage{ID: operationID, Type: "error", Payload: json.RawMessage(`{"message": "Error binding queue"}`)})
+	})
+
+	sub, err := l.Subscribe(context.Background(), tcqueueevents.TaskFailed{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	_, open := <-sub.Messages()
+	require.False(t, open)
+	var subErr *SubscriptionError
+	require.ErrorAs(t, sub.Err(), &subErr)
+	require.Contains(t, subErr.Message, "Error binding queue")
+}
+
+func TestSubscribeConnectionFailure(t *testing.T) {
+	l := New("http://127.0.0.1:1", nil)
+	_, err := l.Subscribe(context.Background(), tcqueueevents.TaskFailed{})
+	require.Error(t, err)
+
+	l = New("ftp://example.com", nil)
+	_, err = l.Subscribe(context.Background(), tcqueueevents.TaskFailed{})
+	require.Error(t, err)
+}
+
+func TestContextCancel(t *testing.T) {
+	_, l := startServer(t, func(conn *websocket.Conn, connection int) {
+		var msg operationMessage
+		for conn.ReadJSON(&msg) == nil {
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := l.Subscribe(ctx, tcqueueevents.TaskFailed{})
+	require.NoError(t, err)
+	cancel()
+
+	_, open := <-sub.Messages()
+	require.False(t, open)
+	require.NoError(t, sub.Err())
+	require.NoError(t, sub.Close())
+}
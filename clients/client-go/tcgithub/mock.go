@@ -0,0 +1,151 @@
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that `${GOPATH}/bin` is in your `PATH`:
+//
+// go install && go generate
+
+package tcgithub
+
+import (
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// MockGithub is a mock implementation of GithubAPI, for use in tests.  Each method
+// records the call, then calls the function in the corresponding `<Method>Func` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type MockGithub struct {
+	tcclient.MockCalls
+	PingFunc                  func() error
+	LbheartbeatFunc           func() error
+	VersionFunc               func() error
+	GithubWebHookConsumerFunc func() error
+	BuildsFunc                func(continuationToken, limit, organization, pullRequest, repository, sha string) (*BuildsResponse, error)
+	CancelBuildsFunc          func(owner, repo, pullRequest, sha string) (*BuildsResponse, error)
+	BadgeFunc                 func(owner, repo, branch string) error
+	RepositoryFunc            func(owner, repo string) (*RepositoryResponse, error)
+	LatestFunc                func(owner, repo, branch string) error
+	CreateStatusFunc          func(owner, repo, sha string, payload *CreateStatusRequest) error
+	CreateCommentFunc         func(owner, repo, number string, payload *CreateCommentRequest) error
+	RenderTaskclusterYmlFunc  func(payload *RenderTaskclusterYmlInput) (*RenderTaskclusterYmlOutput, error)
+	HeartbeatFunc             func() error
+}
+
+var _ GithubAPI = (*MockGithub)(nil)
+
+// Ping records the call and calls PingFunc
+func (m *MockGithub) Ping() error {
+	m.Record("Ping")
+	if m.PingFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.Ping"}
+	}
+	return m.PingFunc()
+}
+
+// Lbheartbeat records the call and calls LbheartbeatFunc
+func (m *MockGithub) Lbheartbeat() error {
+	m.Record("Lbheartbeat")
+	if m.LbheartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.Lbheartbeat"}
+	}
+	return m.LbheartbeatFunc()
+}
+
+// Version records the call and calls VersionFunc
+func (m *MockGithub) Version() error {
+	m.Record("Version")
+	if m.VersionFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.Version"}
+	}
+	return m.VersionFunc()
+}
+
+// GithubWebHookConsumer records the call and calls GithubWebHookConsumerFunc
+func (m *MockGithub) GithubWebHookConsumer() error {
+	m.Record("GithubWebHookConsumer")
+	if m.GithubWebHookConsumerFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.GithubWebHookConsumer"}
+	}
+	return m.GithubWebHookConsumerFunc()
+}
+
+// Builds records the call and calls BuildsFunc
+func (m *MockGithub) Builds(continuationToken, limit, organization, pullRequest, repository, sha string) (*BuildsResponse, error) {
+	m.Record("Builds", continuationToken, limit, organization, pullRequest, repository, sha)
+	if m.BuildsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Github.Builds"}
+	}
+	return m.BuildsFunc(continuationToken, limit, organization, pullRequest, repository, sha)
+}
+
+// CancelBuilds records the call and calls CancelBuildsFunc
+func (m *MockGithub) CancelBuilds(owner, repo, pullRequest, sha string) (*BuildsResponse, error) {
+	m.Record("CancelBuilds", owner, repo, pullRequest, sha)
+	if m.CancelBuildsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Github.CancelBuilds"}
+	}
+	return m.CancelBuildsFunc(owner, repo, pullRequest, sha)
+}
+
+// Badge records the call and calls BadgeFunc
+func (m *MockGithub) Badge(owner, repo, branch string) error {
+	m.Record("Badge", owner, repo, branch)
+	if m.BadgeFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.Badge"}
+	}
+	return m.BadgeFunc(owner, repo, branch)
+}
+
+// Repository records the call and calls RepositoryFunc
+func (m *MockGithub) Repository(owner, repo string) (*RepositoryResponse, error) {
+	m.Record("Repository", owner, repo)
+	if m.RepositoryFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Github.Repository"}
+	}
+	return m.RepositoryFunc(owner, repo)
+}
+
+// Latest records the call and calls LatestFunc
+func (m *MockGithub) Latest(owner, repo, branch string) error {
+	m.Record("Latest", owner, repo, branch)
+	if m.LatestFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.Latest"}
+	}
+	return m.LatestFunc(owner, repo, branch)
+}
+
+// CreateStatus records the call and calls CreateStatusFunc
+func (m *MockGithub) CreateStatus(owner, repo, sha string, payload *CreateStatusRequest) error {
+	m.Record("CreateStatus", owner, repo, sha, payload)
+	if m.CreateStatusFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.CreateStatus"}
+	}
+	return m.CreateStatusFunc(owner, repo, sha, payload)
+}
+
+// CreateComment records the call and calls CreateCommentFunc
+func (m *MockGithub) CreateComment(owner, repo, number string, payload *CreateCommentRequest) error {
+	m.Record("CreateComment", owner, repo, number, payload)
+	if m.CreateCommentFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.CreateComment"}
+	}
+	return m.CreateCommentFunc(owner, repo, number, payload)
+}
+
+// RenderTaskclusterYml records the call and calls RenderTaskclusterYmlFunc
+func (m *MockGithub) RenderTaskclusterYml(payload *RenderTaskclusterYmlInput) (*RenderTaskclusterYmlOutput, error) {
+	m.Record("RenderTaskclusterYml", payload)
+	if m.RenderTaskclusterYmlFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Github.RenderTaskclusterYml"}
+	}
+	return m.RenderTaskclusterYmlFunc(payload)
+}
+
+// Heartbeat records the call and calls HeartbeatFunc
+func (m *MockGithub) Heartbeat() error {
+	m.Record("Heartbeat")
+	if m.HeartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Github.Heartbeat"}
+	}
+	return m.HeartbeatFunc()
+}
@@ -41,6 +41,7 @@
 package tcgithub
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -202,6 +203,24 @@ func (github *Github) Builds_SignedURL(continuationToken, limit, organization, p
 	return (&cd).SignedURL("/builds", v, duration)
 }
 
+// Returns an iterator over the pages of results of Builds, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See Builds for more details.
+func (github *Github) BuildsPages(ctx context.Context, limit, organization, pullRequest, repository, sha string) *tcclient.Pages[BuildsResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*BuildsResponse, string, error) {
+		c := *github
+		c.Context = ctx
+		response, err := c.Builds(continuationToken, limit, organization, pullRequest, repository, sha)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Cancel all running Task Groups associated with given repository and sha or pullRequest number
 //
 // Required scopes:
@@ -363,3 +382,24 @@ func (github *Github) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// GithubAPI is the interface implemented by *Github, with a method for
+// each API method.  Code that accepts GithubAPI rather than *Github can be
+// tested with MockGithub.
+type GithubAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	GithubWebHookConsumer() error
+	Builds(continuationToken, limit, organization, pullRequest, repository, sha string) (*BuildsResponse, error)
+	CancelBuilds(owner, repo, pullRequest, sha string) (*BuildsResponse, error)
+	Badge(owner, repo, branch string) error
+	Repository(owner, repo string) (*RepositoryResponse, error)
+	Latest(owner, repo, branch string) error
+	CreateStatus(owner, repo, sha string, payload *CreateStatusRequest) error
+	CreateComment(owner, repo, number string, payload *CreateCommentRequest) error
+	RenderTaskclusterYml(payload *RenderTaskclusterYmlInput) (*RenderTaskclusterYmlOutput, error)
+	Heartbeat() error
+}
+
+var _ GithubAPI = (*Github)(nil)
@@ -0,0 +1,181 @@
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that `${GOPATH}/bin` is in your `PATH`:
+//
+// go install && go generate
+
+package tchooks
+
+import (
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// MockHooks is a mock implementation of HooksAPI, for use in tests.  Each method
+// records the call, then calls the function in the corresponding `<Method>Func` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type MockHooks struct {
+	tcclient.MockCalls
+	PingFunc                 func() error
+	LbheartbeatFunc          func() error
+	VersionFunc              func() error
+	ListHookGroupsFunc       func() (*HookGroups, error)
+	ListHooksFunc            func(hookGroupId string) (*HookList, error)
+	HookFunc                 func(hookGroupId, hookId string) (*HookDefinition, error)
+	GetHookStatusFunc        func(hookGroupId, hookId string) (*HookStatusResponse, error)
+	CreateHookFunc           func(hookGroupId, hookId string, payload *HookCreationRequest) (*HookDefinition, error)
+	UpdateHookFunc           func(hookGroupId, hookId string, payload *HookCreationRequest) (*HookDefinition, error)
+	RemoveHookFunc           func(hookGroupId, hookId string) error
+	TriggerHookFunc          func(hookGroupId, hookId string, payload *TriggerHookRequest) (*TriggerHookResponse, error)
+	GetTriggerTokenFunc      func(hookGroupId, hookId string) (*TriggerTokenResponse, error)
+	ResetTriggerTokenFunc    func(hookGroupId, hookId string) (*TriggerTokenResponse, error)
+	TriggerHookWithTokenFunc func(hookGroupId, hookId, token string, payload *TriggerHookRequest) (*TriggerHookResponse, error)
+	ListLastFiresFunc        func(hookGroupId, hookId, continuationToken, limit string) (*LastFiresList, error)
+	HeartbeatFunc            func() error
+}
+
+var _ HooksAPI = (*MockHooks)(nil)
+
+// Ping records the call and calls PingFunc
+func (m *MockHooks) Ping() error {
+	m.Record("Ping")
+	if m.PingFunc == nil {
+		return &tcclient.NotMockedError{Method: "Hooks.Ping"}
+	}
+	return m.PingFunc()
+}
+
+// Lbheartbeat records the call and calls LbheartbeatFunc
+func (m *MockHooks) Lbheartbeat() error {
+	m.Record("Lbheartbeat")
+	if m.LbheartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Hooks.Lbheartbeat"}
+	}
+	return m.LbheartbeatFunc()
+}
+
+// Version records the call and calls VersionFunc
+func (m *MockHooks) Version() error {
+	m.Record("Version")
+	if m.VersionFunc == nil {
+		return &tcclient.NotMockedError{Method: "Hooks.Version"}
+	}
+	return m.VersionFunc()
+}
+
+// ListHookGroups records the call and calls ListHookGroupsFunc
+func (m *MockHooks) ListHookGroups() (*HookGroups, error) {
+	m.Record("ListHookGroups")
+	if m.ListHookGroupsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.ListHookGroups"}
+	}
+	return m.ListHookGroupsFunc()
+}
+
+// ListHooks records the call and calls ListHooksFunc
+func (m *MockHooks) ListHooks(hookGroupId string) (*HookList, error) {
+	m.Record("ListHooks", hookGroupId)
+	if m.ListHooksFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.ListHooks"}
+	}
+	return m.ListHooksFunc(hookGroupId)
+}
+
+// Hook records the call and calls HookFunc
+func (m *MockHooks) Hook(hookGroupId, hookId string) (*HookDefinition, error) {
+	m.Record("Hook", hookGroupId, hookId)
+	if m.HookFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.Hook"}
+	}
+	return m.HookFunc(hookGroupId, hookId)
+}
+
+// GetHookStatus records the call and calls GetHookStatusFunc
+func (m *MockHooks) GetHookStatus(hookGroupId, hookId string) (*HookStatusResponse, error) {
+	m.Record("GetHookStatus", hookGroupId, hookId)
+	if m.GetHookStatusFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.GetHookStatus"}
+	}
+	return m.GetHookStatusFunc(hookGroupId, hookId)
+}
+
+// CreateHook records the call and calls CreateHookFunc
+func (m *MockHooks) CreateHook(hookGroupId, hookId string, payload *HookCreationRequest) (*HookDefinition, error) {
+	m.Record("CreateHook", hookGroupId, hookId, payload)
+	if m.CreateHookFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.CreateHook"}
+	}
+	return m.CreateHookFunc(hookGroupId, hookId, payload)
+}
+
+// UpdateHook records the call and calls UpdateHookFunc
+func (m *MockHooks) UpdateHook(hookGroupId, hookId string, payload *HookCreationRequest) (*HookDefinition, error) {
+	m.Record("UpdateHook", hookGroupId, hookId, payload)
+	if m.UpdateHookFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.UpdateHook"}
+	}
+	return m.UpdateHookFunc(hookGroupId, hookId, payload)
+}
+
+// RemoveHook records the call and calls RemoveHookFunc
+func (m *MockHooks) RemoveHook(hookGroupId, hookId string) error {
+	m.Record("RemoveHook", hookGroupId, hookId)
+	if m.RemoveHookFunc == nil {
+		return &tcclient.NotMockedError{Method: "Hooks.RemoveHook"}
+	}
+	return m.RemoveHookFunc(hookGroupId, hookId)
+}
+
+// TriggerHook records the call and calls TriggerHookFunc
+func (m *MockHooks) TriggerHook(hookGroupId, hookId string, payload *TriggerHookRequest) (*TriggerHookResponse, error) {
+	m.Record("TriggerHook", hookGroupId, hookId, payload)
+	if m.TriggerHookFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.TriggerHook"}
+	}
+	return m.TriggerHookFunc(hookGroupId, hookId, payload)
+}
+
+// GetTriggerToken records the call and calls GetTriggerTokenFunc
+func (m *MockHooks) GetTriggerToken(hookGroupId, hookId string) (*TriggerTokenResponse, error) {
+	m.Record("GetTriggerToken", hookGroupId, hookId)
+	if m.GetTriggerTokenFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.GetTriggerToken"}
+	}
+	return m.GetTriggerTokenFunc(hookGroupId, hookId)
+}
+
+// ResetTriggerToken records the call and calls ResetTriggerTokenFunc
+func (m *MockHooks) ResetTriggerToken(hookGroupId, hookId string) (*TriggerTokenResponse, error) {
+	m.Record("ResetTriggerToken", hookGroupId, hookId)
+	if m.ResetTriggerTokenFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.ResetTriggerToken"}
+	}
+	return m.ResetTriggerTokenFunc(hookGroupId, hookId)
+}
+
+// TriggerHookWithToken records the call and calls TriggerHookWithTokenFunc
+func (m *MockHooks) TriggerHookWithToken(hookGroupId, hookId, token string, payload *TriggerHookRequest) (*TriggerHookResponse, error) {
+	m.Record("TriggerHookWithToken", hookGroupId, hookId, token, payload)
+	if m.TriggerHookWithTokenFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.TriggerHookWithToken"}
+	}
+	return m.TriggerHookWithTokenFunc(hookGroupId, hookId, token, payload)
+}
+
+// ListLastFires records the call and calls ListLastFiresFunc
+func (m *MockHooks) ListLastFires(hookGroupId, hookId, continuationToken, limit string) (*LastFiresList, error) {
+	m.Record("ListLastFires", hookGroupId, hookId, continuationToken, limit)
+	if m.ListLastFiresFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Hooks.ListLastFires"}
+	}
+	return m.ListLastFiresFunc(hookGroupId, hookId, continuationToken, limit)
+}
+
+// Heartbeat records the call and calls HeartbeatFunc
+func (m *MockHooks) Heartbeat() error {
+	m.Record("Heartbeat")
+	if m.HeartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Hooks.Heartbeat"}
+	}
+	return m.HeartbeatFunc()
+}
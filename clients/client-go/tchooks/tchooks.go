@@ -34,6 +34,7 @@
 package tchooks
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -387,6 +388,24 @@ func (hooks *Hooks) ListLastFires_SignedURL(hookGroupId, hookId, continuationTok
 	return (&cd).SignedURL("/hooks/"+url.QueryEscape(hookGroupId)+"/"+url.QueryEscape(hookId)+"/last-fires", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListLastFires, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListLastFires for more details.
+func (hooks *Hooks) ListLastFiresPages(ctx context.Context, hookGroupId, hookId, limit string) *tcclient.Pages[LastFiresList] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*LastFiresList, string, error) {
+		c := *hooks
+		c.Context = ctx
+		response, err := c.ListLastFires(hookGroupId, hookId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Respond with a service heartbeat.
 //
 // This endpoint is used to check on backing services this service
@@ -398,3 +417,27 @@ func (hooks *Hooks) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// HooksAPI is the interface implemented by *Hooks, with a method for
+// each API method.  Code that accepts HooksAPI rather than *Hooks can be
+// tested with MockHooks.
+type HooksAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	ListHookGroups() (*HookGroups, error)
+	ListHooks(hookGroupId string) (*HookList, error)
+	Hook(hookGroupId, hookId string) (*HookDefinition, error)
+	GetHookStatus(hookGroupId, hookId string) (*HookStatusResponse, error)
+	CreateHook(hookGroupId, hookId string, payload *HookCreationRequest) (*HookDefinition, error)
+	UpdateHook(hookGroupId, hookId string, payload *HookCreationRequest) (*HookDefinition, error)
+	RemoveHook(hookGroupId, hookId string) error
+	TriggerHook(hookGroupId, hookId string, payload *TriggerHookRequest) (*TriggerHookResponse, error)
+	GetTriggerToken(hookGroupId, hookId string) (*TriggerTokenResponse, error)
+	ResetTriggerToken(hookGroupId, hookId string) (*TriggerTokenResponse, error)
+	TriggerHookWithToken(hookGroupId, hookId, token string, payload *TriggerHookRequest) (*TriggerHookResponse, error)
+	ListLastFires(hookGroupId, hookId, continuationToken, limit string) (*LastFiresList, error)
+	Heartbeat() error
+}
+
+var _ HooksAPI = (*Hooks)(nil)
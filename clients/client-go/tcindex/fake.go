@@ -0,0 +1,180 @@
+package tcindex
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// FakeIndex is a minimal in-memory implementation of the Index service, for
+// use in tests.  It supports InsertTask, FindTask, DeleteTask, ListTasks and
+// ListNamespaces.  Expired entries are not removed.  Other methods behave as
+// in MockIndex, and any method can be replaced by setting the corresponding
+// `<Method>Func` field.
+type FakeIndex struct {
+	MockIndex
+	mu sync.Mutex
+	// indexed tasks, by full index path
+	tasks map[string]IndexedTaskResponse
+}
+
+var _ IndexAPI = (*FakeIndex)(nil)
+
+// NewFakeIndex returns a FakeIndex with no indexed tasks
+func NewFakeIndex() *FakeIndex {
+	i := &FakeIndex{
+		tasks: map[string]IndexedTaskResponse{},
+	}
+	i.InsertTaskFunc = i.insertTask
+	i.FindTaskFunc = i.findTask
+	i.DeleteTaskFunc = i.deleteTask
+	i.ListTasksFunc = i.listTasks
+	i.ListNamespacesFunc = i.listNamespaces
+	return i
+}
+
+func (i *FakeIndex) insertTask(namespace string, payload *InsertTaskRequest) (*IndexedTaskResponse, error) {
+	if payload == nil || namespace == "" {
+		return nil, tcclient.FakeAPIError(400, "InputError", "a namespace and payload are required")
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	// as with the real index, an existing entry is only replaced by one of
+	// equal or higher rank
+	if existing, ok := i.tasks[namespace]; ok && existing.Rank > payload.Rank {
+		return &existing, nil
+	}
+	entry := IndexedTaskResponse{
+		Data:      payload.Data,
+		Expires:   payload.Expires,
+		Namespace: namespace,
+		Rank:      payload.Rank,
+		TaskID:    payload.TaskID,
+	}
+	i.tasks[namespace] = entry
+	return &entry, nil
+}
+
+func (i *FakeIndex) findTask(indexPath string) (*IndexedTaskResponse, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	entry, ok := i.tasks[indexPath]
+	if !ok {
+		return nil, tcclient.FakeAPIError(404, "ResourceNotFound", "indexed task "+indexPath+" not found")
+	}
+	return &entry, nil
+}
+
+func (i *FakeIndex) deleteTask(namespace string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.tasks, namespace)
+	return nil
+}
+
+func (i *FakeIndex) listTasks(namespace, continuationToken, limit string) (*ListTasksResponse, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	paths := []string{}
+	for path := range i.tasks {
+		if parent(path) == namespace {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	start, end, next, err := page(len(paths), continuationToken, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListTasksResponse{
+		ContinuationToken: next,
+		Tasks:             []Task{},
+	}
+	for _, path := range paths[start:end] {
+		entry := i.tasks[path]
+		resp.Tasks = append(resp.Tasks, Task{
+			Data:      entry.Data,
+			Expires:   entry.Expires,
+			Namespace: entry.Namespace,
+			Rank:      entry.Rank,
+			TaskID:    entry.TaskID,
+		})
+	}
+	return resp, nil
+}
+
+func (i *FakeIndex) listNamespaces(namespace, continuationToken, limit string) (*ListNamespacesResponse, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	// each namespace expires with the last of the tasks under it
+	expires := map[string]tcclient.Time{}
+	for path, entry := range i.tasks {
+		for ns := parent(path); ns != ""; ns = parent(ns) {
+			if parent(ns) != namespace {
+				continue
+			}
+			if e, ok := expires[ns]; !ok || time.Time(entry.Expires).After(time.Time(e)) {
+				expires[ns] = entry.Expires
+			}
+		}
+	}
+	names := make([]string, 0, len(expires))
+	for ns := range expires {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+
+	start, end, next, err := page(len(names), continuationToken, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListNamespacesResponse{
+		ContinuationToken: next,
+		Namespaces:        []Namespace{},
+	}
+	for _, ns := range names[start:end] {
+		resp.Namespaces = append(resp.Namespaces, Namespace{
+			Expires:   expires[ns],
+			Name:      ns[strings.LastIndex(ns, ".")+1:],
+			Namespace: ns,
+		})
+	}
+	return resp, nil
+}
+
+// Return the namespace containing the given namespace or index path, which is
+// "" for a top-level namespace
+func parent(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// Return the range of n results to include in the page given by
+// continuationToken and limit, and the continuation token for the next page
+func page(n int, continuationToken, limit string) (start, end int, next string, err error) {
+	if continuationToken != "" {
+		start, err = strconv.Atoi(continuationToken)
+		if err != nil || start < 0 || start > n {
+			return 0, 0, "", tcclient.FakeAPIError(400, "InputError", "invalid continuationToken")
+		}
+	}
+	end = n
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 {
+			return 0, 0, "", tcclient.FakeAPIError(400, "InputError", "invalid limit")
+		}
+		if start+l < n {
+			end = start + l
+			next = strconv.Itoa(end)
+		}
+	}
+	return start, end, next, nil
+}
@@ -0,0 +1,90 @@
+package tcindex_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcindex"
+)
+
+func insert(t *testing.T, index tcindex.IndexAPI, namespace, taskId string, rank float64, expires time.Time) {
+	t.Helper()
+	_, err := index.InsertTask(namespace, &tcindex.InsertTaskRequest{
+		Data:    json.RawMessage(`{}`),
+		Expires: tcclient.Time(expires),
+		Rank:    rank,
+		TaskID:  taskId,
+	})
+	require.NoError(t, err)
+}
+
+func TestFakeIndexFindTask(t *testing.T) {
+	index := tcindex.NewFakeIndex()
+	expires := time.Now().Add(time.Hour)
+
+	_, err := index.FindTask("proj.latest")
+	require.Error(t, err)
+	require.Equal(t, 404, err.(*tcclient.APICallException).CallSummary.HTTPResponse.StatusCode)
+
+	insert(t, index, "proj.latest", "task1", 2, expires)
+	task, err := index.FindTask("proj.latest")
+	require.NoError(t, err)
+	require.Equal(t, "task1", task.TaskID)
+	require.Equal(t, "proj.latest", task.Namespace)
+
+	// a lower rank does not replace the existing entry, but a higher one does
+	insert(t, index, "proj.latest", "task2", 1, expires)
+	task, err = index.FindTask("proj.latest")
+	require.NoError(t, err)
+	require.Equal(t, "task1", task.TaskID)
+	insert(t, index, "proj.latest", "task3", 3, expires)
+	task, err = index.FindTask("proj.latest")
+	require.NoError(t, err)
+	require.Equal(t, "task3", task.TaskID)
+
+	require.NoError(t, index.DeleteTask("proj.latest"))
+	_, err = index.FindTask("proj.latest")
+	require.Error(t, err)
+}
+
+func TestFakeIndexList(t *testing.T) {
+	index := tcindex.NewFakeIndex()
+	soon, later := time.Now().Add(time.Hour), time.Now().Add(2*time.Hour)
+	insert(t, index, "proj.a.latest", "task1", 0, soon)
+	insert(t, index, "proj.b.latest", "task2", 0, soon)
+	insert(t, index, "proj.b.deep.latest", "task3", 0, later)
+	insert(t, index, "proj.c", "task4", 0, soon)
+
+	namespaces, err := index.ListNamespaces("proj", "", "")
+	require.NoError(t, err)
+	names := []string{}
+	for _, ns := range namespaces.Namespaces {
+		names = append(names, ns.Namespace)
+	}
+	require.Equal(t, []string{"proj.a", "proj.b"}, names)
+	require.Equal(t, "b", namespaces.Namespaces[1].Name)
+	require.True(t, time.Time(namespaces.Namespaces[1].Expires).Equal(later))
+
+	top, err := index.ListNamespaces("", "", "")
+	require.NoError(t, err)
+	require.Len(t, top.Namespaces, 1)
+	require.Equal(t, "proj", top.Namespaces[0].Name)
+
+	tasks, err := index.ListTasks("proj", "", "")
+	require.NoError(t, err)
+	require.Len(t, tasks.Tasks, 1)
+	require.Equal(t, "task4", tasks.Tasks[0].TaskID)
+
+	// pagination
+	first, err := index.ListNamespaces("proj", "", "1")
+	require.NoError(t, err)
+	require.Len(t, first.Namespaces, 1)
+	require.NotEmpty(t, first.ContinuationToken)
+	second, err := index.ListNamespaces("proj", first.ContinuationToken, "1")
+	require.NoError(t, err)
+	require.Equal(t, "proj.b", second.Namespaces[0].Namespace)
+	require.Empty(t, second.ContinuationToken)
+}
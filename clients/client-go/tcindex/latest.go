@@ -0,0 +1,104 @@
+package tcindex
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// SignedArtifactURLDuration is how long the signed URLs returned by
+// FindLatestArtifactURL for non-public artifacts are valid.
+const SignedArtifactURLDuration = time.Hour
+
+// TaskFinder finds the task indexed at an index path.  It is implemented by
+// Index, MockIndex and FakeIndex.
+type TaskFinder interface {
+	FindTask(indexPath string) (*IndexedTaskResponse, error)
+}
+
+// TaskCache caches the tasks found at index paths for a short time, so that
+// looking up the same path several times, for example once to compute a
+// cache key and again to download an artifact, consistently gives the same
+// task without calling the index service each time.  Failed lookups are not
+// cached.  A TaskCache is safe for concurrent use.
+type TaskCache struct {
+	// How long a found task is cached
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedTask
+	// for testing
+	now func() time.Time
+}
+
+type cachedTask struct {
+	task    *IndexedTaskResponse
+	expires time.Time
+}
+
+// NewTaskCache returns an empty TaskCache with the given TTL.
+func NewTaskCache(ttl time.Duration) *TaskCache {
+	return &TaskCache{TTL: ttl}
+}
+
+// FindTask returns the task indexed at indexPath, from the cache if it was
+// found less than TTL ago, and otherwise from finder.  A nil TaskCache
+// caches nothing.
+func (cache *TaskCache) FindTask(finder TaskFinder, indexPath string) (*IndexedTaskResponse, error) {
+	if cache == nil {
+		return finder.FindTask(indexPath)
+	}
+	now := time.Now
+	if cache.now != nil {
+		now = cache.now
+	}
+	cache.mu.Lock()
+	entry, ok := cache.entries[indexPath]
+	cache.mu.Unlock()
+	if ok && now().Before(entry.expires) {
+		return entry.task, nil
+	}
+
+	task, err := finder.FindTask(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.entries == nil {
+		cache.entries = map[string]cachedTask{}
+	}
+	cache.entries[indexPath] = cachedTask{task: task, expires: now().Add(cache.TTL)}
+	return task, nil
+}
+
+// FindLatestArtifactURL finds the task indexed at namespace, using cache if
+// it is not nil, and returns the URL of the named artifact of the latest run
+// of that task.  Unlike FindArtifactFromTask_SignedURL, the URL refers to the
+// task found now, so it continues to refer to the same artifact even if
+// another task is later indexed at namespace.
+//
+// The URL is signed, valid for SignedArtifactURLDuration, unless the
+// artifact name starts with `public/` or index has no credentials.
+func (index *Index) FindLatestArtifactURL(namespace, name string, cache *TaskCache) (string, error) {
+	task, err := cache.FindTask(index, namespace)
+	if err != nil {
+		return "", err
+	}
+	queue := tcclient.Client(*index)
+	queue.ServiceName = "queue"
+	queue.APIVersion = "v1"
+	route := "/task/" + url.QueryEscape(task.TaskID) + "/artifacts/" + url.QueryEscape(name)
+	if strings.HasPrefix(name, "public/") || !queue.Authenticate || queue.Credentials == nil {
+		return tcurls.API(queue.RootURL, queue.ServiceName, queue.APIVersion, route), nil
+	}
+	u, err := queue.SignedURL(route, nil, SignedArtifactURLDuration)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
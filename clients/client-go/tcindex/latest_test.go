@@ -0,0 +1,108 @@
+package tcindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+func TestTaskCache(t *testing.T) {
+	index := NewFakeIndex()
+	_, err := index.InsertTask("proj.latest", &InsertTaskRequest{Data: json.RawMessage(`{}`), TaskID: "task1"})
+	require.NoError(t, err)
+
+	now := time.Now()
+	cache := NewTaskCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	task, err := cache.FindTask(index, "proj.latest")
+	require.NoError(t, err)
+	require.Equal(t, "task1", task.TaskID)
+
+	// a newly indexed task is not seen until the cached entry expires
+	_, err = index.InsertTask("proj.latest", &InsertTaskRequest{Data: json.RawMessage(`{}`), TaskID: "task2"})
+	require.NoError(t, err)
+	task, err = cache.FindTask(index, "proj.latest")
+	require.NoError(t, err)
+	require.Equal(t, "task1", task.TaskID)
+
+	now = now.Add(time.Minute)
+	task, err = cache.FindTask(index, "proj.latest")
+	require.NoError(t, err)
+	require.Equal(t, "task2", task.TaskID)
+	require.Equal(t, 2, len(index.CallsTo("FindTask")))
+}
+
+func TestTaskCacheErrors(t *testing.T) {
+	index := NewFakeIndex()
+	cache := NewTaskCache(time.Minute)
+
+	_, err := cache.FindTask(index, "proj.latest")
+	require.Error(t, err)
+	_, err = index.InsertTask("proj.latest", &InsertTaskRequest{Data: json.RawMessage(`{}`), TaskID: "task1"})
+	require.NoError(t, err)
+	task, err := cache.FindTask(index, "proj.latest")
+	require.NoError(t, err)
+	require.Equal(t, "task1", task.TaskID)
+}
+
+func TestNilTaskCache(t *testing.T) {
+	index := NewFakeIndex()
+	_, err := index.InsertTask("proj.latest", &InsertTaskRequest{Data: json.RawMessage(`{}`), TaskID: "task1"})
+	require.NoError(t, err)
+
+	var cache *TaskCache
+	for i := 0; i < 2; i++ {
+		task, err := cache.FindTask(index, "proj.latest")
+		require.NoError(t, err)
+		require.Equal(t, "task1", task.TaskID)
+	}
+	require.Equal(t, 2, len(index.CallsTo("FindTask")))
+}
+
+func indexServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/index/v1/task/proj.latest", r.URL.Path)
+		_, _ = fmt.Fprint(w, `{"namespace": "proj.latest", "taskId": "abc123", "rank": 0, "data": {}, "expires": "2030-01-01T00:00:00.000Z"}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestFindLatestArtifactURLPublic(t *testing.T) {
+	s := indexServer(t)
+	index := New(&tcclient.Credentials{ClientID: "tester", AccessToken: "secret"}, s.URL)
+
+	u, err := index.FindLatestArtifactURL("proj.latest", "public/build/target.tar.gz", nil)
+	require.NoError(t, err)
+	require.Equal(t, s.URL+"/api/queue/v1/task/abc123/artifacts/public%2Fbuild%2Ftarget.tar.gz", u)
+}
+
+func TestFindLatestArtifactURLPrivate(t *testing.T) {
+	s := indexServer(t)
+	index := New(&tcclient.Credentials{ClientID: "tester", AccessToken: "secret"}, s.URL)
+
+	u, err := index.FindLatestArtifactURL("proj.latest", "private/build/secret.txt", NewTaskCache(time.Minute))
+	require.NoError(t, err)
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+	require.Equal(t, "/api/queue/v1/task/abc123/artifacts/private%2Fbuild%2Fsecret.txt", parsed.EscapedPath())
+	require.NotEmpty(t, parsed.Query().Get("bewit"))
+}
+
+func TestFindLatestArtifactURLNoCredentials(t *testing.T) {
+	s := indexServer(t)
+	index := New(nil, s.URL)
+
+	u, err := index.FindLatestArtifactURL("proj.latest", "private/build/secret.txt", nil)
+	require.NoError(t, err)
+	require.Equal(t, s.URL+"/api/queue/v1/task/abc123/artifacts/private%2Fbuild%2Fsecret.txt", u)
+}
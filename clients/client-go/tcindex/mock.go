@@ -0,0 +1,121 @@
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that `${GOPATH}/bin` is in your `PATH`:
+//
+// go install && go generate
+
+package tcindex
+
+import (
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// MockIndex is a mock implementation of IndexAPI, for use in tests.  Each method
+// records the call, then calls the function in the corresponding `<Method>Func` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type MockIndex struct {
+	tcclient.MockCalls
+	PingFunc                 func() error
+	LbheartbeatFunc          func() error
+	VersionFunc              func() error
+	FindTaskFunc             func(indexPath string) (*IndexedTaskResponse, error)
+	ListNamespacesFunc       func(namespace, continuationToken, limit string) (*ListNamespacesResponse, error)
+	ListTasksFunc            func(namespace, continuationToken, limit string) (*ListTasksResponse, error)
+	InsertTaskFunc           func(namespace string, payload *InsertTaskRequest) (*IndexedTaskResponse, error)
+	DeleteTaskFunc           func(namespace string) error
+	FindArtifactFromTaskFunc func(indexPath, name string) error
+	HeartbeatFunc            func() error
+}
+
+var _ IndexAPI = (*MockIndex)(nil)
+
+// Ping records the call and calls PingFunc
+func (m *MockIndex) Ping() error {
+	m.Record("Ping")
+	if m.PingFunc == nil {
+		return &tcclient.NotMockedError{Method: "Index.Ping"}
+	}
+	return m.PingFunc()
+}
+
+// Lbheartbeat records the call and calls LbheartbeatFunc
+func (m *MockIndex) Lbheartbeat() error {
+	m.Record("Lbheartbeat")
+	if m.LbheartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Index.Lbheartbeat"}
+	}
+	return m.LbheartbeatFunc()
+}
+
+// Version records the call and calls VersionFunc
+func (m *MockIndex) Version() error {
+	m.Record("Version")
+	if m.VersionFunc == nil {
+		return &tcclient.NotMockedError{Method: "Index.Version"}
+	}
+	return m.VersionFunc()
+}
+
+// FindTask records the call and calls FindTaskFunc
+func (m *MockIndex) FindTask(indexPath string) (*IndexedTaskResponse, error) {
+	m.Record("FindTask", indexPath)
+	if m.FindTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Index.FindTask"}
+	}
+	return m.FindTaskFunc(indexPath)
+}
+
+// ListNamespaces records the call and calls ListNamespacesFunc
+func (m *MockIndex) ListNamespaces(namespace, continuationToken, limit string) (*ListNamespacesResponse, error) {
+	m.Record("ListNamespaces", namespace, continuationToken, limit)
+	if m.ListNamespacesFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Index.ListNamespaces"}
+	}
+	return m.ListNamespacesFunc(namespace, continuationToken, limit)
+}
+
+// ListTasks records the call and calls ListTasksFunc
+func (m *MockIndex) ListTasks(namespace, continuationToken, limit string) (*ListTasksResponse, error) {
+	m.Record("ListTasks", namespace, continuationToken, limit)
+	if m.ListTasksFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Index.ListTasks"}
+	}
+	return m.ListTasksFunc(namespace, continuationToken, limit)
+}
+
+// InsertTask records the call and calls InsertTaskFunc
+func (m *MockIndex) InsertTask(namespace string, payload *InsertTaskRequest) (*IndexedTaskResponse, error) {
+	m.Record("InsertTask", namespace, payload)
+	if m.InsertTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Index.InsertTask"}
+	}
+	return m.InsertTaskFunc(namespace, payload)
+}
+
+// DeleteTask records the call and calls DeleteTaskFunc
+func (m *MockIndex) DeleteTask(namespace string) error {
+	m.Record("DeleteTask", namespace)
+	if m.DeleteTaskFunc == nil {
+		return &tcclient.NotMockedError{Method: "Index.DeleteTask"}
+	}
+	return m.DeleteTaskFunc(namespace)
+}
+
+// FindArtifactFromTask records the call and calls FindArtifactFromTaskFunc
+func (m *MockIndex) FindArtifactFromTask(indexPath, name string) error {
+	m.Record("FindArtifactFromTask", indexPath, name)
+	if m.FindArtifactFromTaskFunc == nil {
+		return &tcclient.NotMockedError{Method: "Index.FindArtifactFromTask"}
+	}
+	return m.FindArtifactFromTaskFunc(indexPath, name)
+}
+
+// Heartbeat records the call and calls HeartbeatFunc
+func (m *MockIndex) Heartbeat() error {
+	m.Record("Heartbeat")
+	if m.HeartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Index.Heartbeat"}
+	}
+	return m.HeartbeatFunc()
+}
@@ -0,0 +1,61 @@
+package tcindex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namespacePartPattern matches a single dot-separated part of an index
+// namespace, as accepted by the index service
+var namespacePartPattern = regexp.MustCompile(`^[a-zA-Z0-9_!~*'()%-]+$`)
+
+// RoutePrefix is the prefix of task routes that cause a task to be indexed
+// when it completes.
+const RoutePrefix = "index."
+
+// JoinNamespace joins parts with dots to form an index namespace, such as
+// `project.my-app.v2.linux64`.  An error is returned if a part is empty or
+// contains a character that the index service does not allow in namespaces,
+// including `.` and `/`; use EscapeNamespacePart for parts, such as branch
+// names, that may contain these.
+func JoinNamespace(parts ...string) (string, error) {
+	if len(parts) == 0 {
+		return "", errors.New("index namespace must have at least one part")
+	}
+	for _, part := range parts {
+		if !namespacePartPattern.MatchString(part) {
+			return "", fmt.Errorf("invalid index namespace part %q", part)
+		}
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// Route returns the task route which indexes a task, when it completes, at
+// the namespace formed from parts as by JoinNamespace.  For example,
+// Route("project", "my-app", "latest") returns `index.project.my-app.latest`.
+func Route(parts ...string) (string, error) {
+	namespace, err := JoinNamespace(parts...)
+	if err != nil {
+		return "", err
+	}
+	return RoutePrefix + namespace, nil
+}
+
+// EscapeNamespacePart percent-encodes the characters of s that are not
+// allowed in a part of an index namespace, so that, for example, a branch
+// name `release/1.2` becomes `release%2F1%2E2`.
+func EscapeNamespacePart(s string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte("_!~*'()-", c) != -1:
+			escaped.WriteByte(c)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
@@ -0,0 +1,38 @@
+package tcindex_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcindex"
+)
+
+func TestJoinNamespace(t *testing.T) {
+	namespace, err := tcindex.JoinNamespace("project", "my-app", "v2", "linux64")
+	require.NoError(t, err)
+	require.Equal(t, "project.my-app.v2.linux64", namespace)
+
+	for _, parts := range [][]string{{}, {"project", ""}, {"project", "a.b"}, {"project", "a/b"}} {
+		_, err := tcindex.JoinNamespace(parts...)
+		require.Error(t, err, "%q", parts)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	route, err := tcindex.Route("project", "my-app", "latest")
+	require.NoError(t, err)
+	require.Equal(t, "index.project.my-app.latest", route)
+
+	_, err = tcindex.Route("project", "my app")
+	require.Error(t, err)
+}
+
+func TestEscapeNamespacePart(t *testing.T) {
+	require.Equal(t, "release%2F1%2E2", tcindex.EscapeNamespacePart("release/1.2"))
+	require.Equal(t, "a_b-c~d", tcindex.EscapeNamespacePart("a_b-c~d"))
+	require.Equal(t, "100%25%20sure", tcindex.EscapeNamespacePart("100% sure"))
+
+	namespace, err := tcindex.JoinNamespace("project", tcindex.EscapeNamespacePart("feature/x.y"))
+	require.NoError(t, err)
+	require.Equal(t, "project.feature%2Fx%2Ey", namespace)
+}
@@ -40,6 +40,7 @@
 package tcindex
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -197,6 +198,24 @@ func (index *Index) ListNamespaces_SignedURL(namespace, continuationToken, limit
 	return (&cd).SignedURL("/namespaces/"+url.QueryEscape(namespace), v, duration)
 }
 
+// Returns an iterator over the pages of results of ListNamespaces, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListNamespaces for more details.
+func (index *Index) ListNamespacesPages(ctx context.Context, namespace, limit string) *tcclient.Pages[ListNamespacesResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListNamespacesResponse, string, error) {
+		c := *index
+		c.Context = ctx
+		response, err := c.ListNamespaces(namespace, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // List the tasks immediately under a given namespace.
 //
 // This endpoint
@@ -245,6 +264,24 @@ func (index *Index) ListTasks_SignedURL(namespace, continuationToken, limit stri
 	return (&cd).SignedURL("/tasks/"+url.QueryEscape(namespace), v, duration)
 }
 
+// Returns an iterator over the pages of results of ListTasks, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListTasks for more details.
+func (index *Index) ListTasksPages(ctx context.Context, namespace, limit string) *tcclient.Pages[ListTasksResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListTasksResponse, string, error) {
+		c := *index
+		c.Context = ctx
+		response, err := c.ListTasks(namespace, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Insert a task into the index.  If the new rank is less than the existing rank
 // at the given index path, the task is not indexed but the response is still 200 OK.
 //
@@ -327,3 +364,21 @@ func (index *Index) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// IndexAPI is the interface implemented by *Index, with a method for
+// each API method.  Code that accepts IndexAPI rather than *Index can be
+// tested with MockIndex.
+type IndexAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	FindTask(indexPath string) (*IndexedTaskResponse, error)
+	ListNamespaces(namespace, continuationToken, limit string) (*ListNamespacesResponse, error)
+	ListTasks(namespace, continuationToken, limit string) (*ListTasksResponse, error)
+	InsertTask(namespace string, payload *InsertTaskRequest) (*IndexedTaskResponse, error)
+	DeleteTask(namespace string) error
+	FindArtifactFromTask(indexPath, name string) error
+	Heartbeat() error
+}
+
+var _ IndexAPI = (*Index)(nil)
@@ -0,0 +1,131 @@
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that `${GOPATH}/bin` is in your `PATH`:
+//
+// go install && go generate
+
+package tcnotify
+
+import (
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// MockNotify is a mock implementation of NotifyAPI, for use in tests.  Each method
+// records the call, then calls the function in the corresponding `<Method>Func` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type MockNotify struct {
+	tcclient.MockCalls
+	PingFunc                  func() error
+	LbheartbeatFunc           func() error
+	VersionFunc               func() error
+	EmailFunc                 func(payload *SendEmailRequest) error
+	PulseFunc                 func(payload *PostPulseMessageRequest) error
+	MatrixFunc                func(payload *SendMatrixNoticeRequest) error
+	SlackFunc                 func(payload *SendSlackMessage) error
+	AddDenylistAddressFunc    func(payload *NotificationTypeAndAddress) error
+	DeleteDenylistAddressFunc func(payload *NotificationTypeAndAddress) error
+	ListDenylistFunc          func(continuationToken, limit string) (*ListOfNotificationAdresses, error)
+	HeartbeatFunc             func() error
+}
+
+var _ NotifyAPI = (*MockNotify)(nil)
+
+// Ping records the call and calls PingFunc
+func (m *MockNotify) Ping() error {
+	m.Record("Ping")
+	if m.PingFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Ping"}
+	}
+	return m.PingFunc()
+}
+
+// Lbheartbeat records the call and calls LbheartbeatFunc
+func (m *MockNotify) Lbheartbeat() error {
+	m.Record("Lbheartbeat")
+	if m.LbheartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Lbheartbeat"}
+	}
+	return m.LbheartbeatFunc()
+}
+
+// Version records the call and calls VersionFunc
+func (m *MockNotify) Version() error {
+	m.Record("Version")
+	if m.VersionFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Version"}
+	}
+	return m.VersionFunc()
+}
+
+// Email records the call and calls EmailFunc
+func (m *MockNotify) Email(payload *SendEmailRequest) error {
+	m.Record("Email", payload)
+	if m.EmailFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Email"}
+	}
+	return m.EmailFunc(payload)
+}
+
+// Pulse records the call and calls PulseFunc
+func (m *MockNotify) Pulse(payload *PostPulseMessageRequest) error {
+	m.Record("Pulse", payload)
+	if m.PulseFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Pulse"}
+	}
+	return m.PulseFunc(payload)
+}
+
+// Matrix records the call and calls MatrixFunc
+func (m *MockNotify) Matrix(payload *SendMatrixNoticeRequest) error {
+	m.Record("Matrix", payload)
+	if m.MatrixFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Matrix"}
+	}
+	return m.MatrixFunc(payload)
+}
+
+// Slack records the call and calls SlackFunc
+func (m *MockNotify) Slack(payload *SendSlackMessage) error {
+	m.Record("Slack", payload)
+	if m.SlackFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Slack"}
+	}
+	return m.SlackFunc(payload)
+}
+
+// AddDenylistAddress records the call and calls AddDenylistAddressFunc
+func (m *MockNotify) AddDenylistAddress(payload *NotificationTypeAndAddress) error {
+	m.Record("AddDenylistAddress", payload)
+	if m.AddDenylistAddressFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.AddDenylistAddress"}
+	}
+	return m.AddDenylistAddressFunc(payload)
+}
+
+// DeleteDenylistAddress records the call and calls DeleteDenylistAddressFunc
+func (m *MockNotify) DeleteDenylistAddress(payload *NotificationTypeAndAddress) error {
+	m.Record("DeleteDenylistAddress", payload)
+	if m.DeleteDenylistAddressFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.DeleteDenylistAddress"}
+	}
+	return m.DeleteDenylistAddressFunc(payload)
+}
+
+// ListDenylist records the call and calls ListDenylistFunc
+func (m *MockNotify) ListDenylist(continuationToken, limit string) (*ListOfNotificationAdresses, error) {
+	m.Record("ListDenylist", continuationToken, limit)
+	if m.ListDenylistFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Notify.ListDenylist"}
+	}
+	return m.ListDenylistFunc(continuationToken, limit)
+}
+
+// Heartbeat records the call and calls HeartbeatFunc
+func (m *MockNotify) Heartbeat() error {
+	m.Record("Heartbeat")
+	if m.HeartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Notify.Heartbeat"}
+	}
+	return m.HeartbeatFunc()
+}
@@ -35,6 +35,7 @@
 package tcnotify
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -288,6 +289,24 @@ func (notify *Notify) ListDenylist_SignedURL(continuationToken, limit string, du
 	return (&cd).SignedURL("/denylist/list", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListDenylist, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListDenylist for more details.
+func (notify *Notify) ListDenylistPages(ctx context.Context, limit string) *tcclient.Pages[ListOfNotificationAdresses] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListOfNotificationAdresses, string, error) {
+		c := *notify
+		c.Context = ctx
+		response, err := c.ListDenylist(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Respond with a service heartbeat.
 //
 // This endpoint is used to check on backing services this service
@@ -299,3 +318,22 @@ func (notify *Notify) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// NotifyAPI is the interface implemented by *Notify, with a method for
+// each API method.  Code that accepts NotifyAPI rather than *Notify can be
+// tested with MockNotify.
+type NotifyAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	Email(payload *SendEmailRequest) error
+	Pulse(payload *PostPulseMessageRequest) error
+	Matrix(payload *SendMatrixNoticeRequest) error
+	Slack(payload *SendSlackMessage) error
+	AddDenylistAddress(payload *NotificationTypeAndAddress) error
+	DeleteDenylistAddress(payload *NotificationTypeAndAddress) error
+	ListDenylist(continuationToken, limit string) (*ListOfNotificationAdresses, error)
+	Heartbeat() error
+}
+
+var _ NotifyAPI = (*Notify)(nil)
@@ -49,6 +49,19 @@ func (object *Object) DownloadToFile(name string, filepath string) (contentType
 	return object.DownloadToWriteSeeker(name, writeSeeker)
 }
 
+// DownloadToWriter downloads the named object from the object service and
+// writes it to writer, for callers that process the data as it arrives rather
+// than storing it. Since data that has been written cannot be taken back, an
+// intermittent error after some data has been written is only retried if the
+// transfer can be resumed where it stopped. Returns the Content-Type and
+// Content-Length of the downloaded object.
+//
+// The object's hashes can only be verified once all of the data has been
+// written, so callers must not trust the data if an error is returned.
+func (object *Object) DownloadToWriter(name string, writer io.Writer) (contentType string, contentLength int64, err error) {
+	return object.DownloadToWriteSeeker(name, &internal.WriteOnceSeeker{Writer: writer})
+}
+
 // getUrlDownload implements the getUrl download method.
 func (object *Object) getUrlDownload(rawResponse *DownloadObjectResponse, name string, writeSeeker io.WriteSeeker) (contentType string, contentLength int64, err error) {
 	downloadResponse := GetURLDownloadResponse{}
@@ -58,19 +71,37 @@ func (object *Object) getUrlDownload(rawResponse *DownloadObjectResponse, name s
 		return
 	}
 
-	// wrap the writeSeeker so that we can get the hashes of the received data
-	hashingWriter := newHashingWriteSeeker(writeSeeker)
+	hashes, err := unmarshalHashes(downloadResponse.Hashes)
+	if err != nil {
+		return
+	}
+
+	// wrap the writeSeeker so that we can get the hashes of the received data.
+	// Only the strongest hash known to both this client and the object service
+	// needs to be computed; if there is none, verification will fail once the
+	// data has been received.
+	algorithms := SupportedHashes()
+	if algo := negotiateHash(hashes); algo != "" {
+		algorithms = []string{algo}
+	}
+	hashingWriter := newHashingWriteSeeker(writeSeeker, algorithms)
+
+	// the offset from which to resume an interrupted transfer, or 0 to start
+	// from the beginning
+	var resumeFrom int64
 
 	retryFunc := func() (resp *http.Response, tempError error, permError error) {
 		// Explicitly seek to start here, rather than only after a temp error,
 		// since not all temporary errors are caught by this code (e.g. status
 		// codes 500-599 are handled by httpbackoff library implicitly).
-		_, permError = hashingWriter.Seek(0, io.SeekStart)
-		if permError != nil {
-			// not being able to seek to start is a problem that is unlikely to
-			// be solved with retries with exponential backoff, so give up
-			// straight away
-			return
+		if resumeFrom == 0 {
+			_, permError = hashingWriter.Seek(0, io.SeekStart)
+			if permError != nil {
+				// not being able to seek to start is a problem that is unlikely to
+				// be solved with retries with exponential backoff, so give up
+				// straight away
+				return
+			}
 		}
 
 		// if the URL has expired, fetch a new one, verifying that each response
@@ -99,16 +130,56 @@ func (object *Object) getUrlDownload(rawResponse *DownloadObjectResponse, name s
 		// Get the URL.  Note that this adds `Acccept-Encoding: gzip` and will
 		// automatically un-gzip a response if necessary.
 		responseUsed = true
-		resp, tempError = http.Get(downloadResponse.URL)
+		var req *http.Request
+		req, permError = http.NewRequest("GET", downloadResponse.URL, nil)
+		if permError != nil {
+			return
+		}
+		if resumeFrom > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", resumeFrom))
+		}
+		resp, tempError = http.DefaultClient.Do(req)
 
 		// httpbackoff handles http status codes, so we can consider all errors worth retrying here
-		if tempError != nil || resp.StatusCode != 200 {
+		if tempError != nil {
+			// temporary error!
+			return
+		}
+		switch {
+		case resumeFrom > 0 && resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp) == resumeFrom:
+			// continue from where the previous attempt stopped
+		case resp.StatusCode == http.StatusOK:
+			if resumeFrom > 0 {
+				// the server sent the whole object, so start again
+				resumeFrom = 0
+				_, permError = hashingWriter.Seek(0, io.SeekStart)
+				if permError != nil {
+					return
+				}
+			}
+		case resumeFrom > 0:
+			// the server could not continue the transfer, so start again
+			resumeFrom = 0
+			resp.Body.Close()
+			tempError = fmt.Errorf("Could not resume download: HTTP status %v", resp.Status)
+			return
+		default:
 			// temporary error!
 			return
 		}
 		defer resp.Body.Close()
 		contentType = resp.Header.Get("Content-Type")
-		contentLength, tempError = io.Copy(hashingWriter, resp.Body)
+		_, tempError = io.Copy(hashingWriter, resp.Body)
+		contentLength = hashingWriter.bytes
+
+		// an interrupted transfer can be resumed with a range request, if the
+		// server supports them and the response was not compressed (as ranges
+		// apply to the compressed data)
+		if tempError != nil && resp.Header.Get("Accept-Ranges") == "bytes" && !resp.Uncompressed {
+			resumeFrom = hashingWriter.bytes
+		} else {
+			resumeFrom = 0
+		}
 		return
 	}
 	var resp *http.Response
@@ -127,7 +198,9 @@ func (object *Object) getUrlDownload(rawResponse *DownloadObjectResponse, name s
 			Err:      err,
 		}
 	}
-	resp.Body.Close()
+	if resp != nil {
+		resp.Body.Close()
+	}
 	if err != nil {
 		return
 	}
@@ -135,15 +208,6 @@ func (object *Object) getUrlDownload(rawResponse *DownloadObjectResponse, name s
 	// verify hashes if no error has occurred so far.  Note that the download is not
 	// retried if hash verification fails.
 	if err == nil {
-		var hashes map[string]string
-		hashes, err = unmarshalHashes(downloadResponse.Hashes)
-		if err != nil {
-			err = HTTPRetryError{
-				Attempts: attempts,
-				Err:      err,
-			}
-			return
-		}
 		err = verifyHashes(hashingWriter, hashes)
 		if err != nil {
 			err = HTTPRetryError{
@@ -165,6 +229,18 @@ func (object *Object) getUrlDownload(rawResponse *DownloadObjectResponse, name s
 	return
 }
 
+// contentRangeStart returns the offset of the first byte of a partial
+// response, or -1 if it cannot be determined
+func contentRangeStart(resp *http.Response) int64 {
+	var start, end, size int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size); err != nil {
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/*", &start, &end); err != nil {
+			return -1
+		}
+	}
+	return start
+}
+
 // verifyHashes verifies that the hashes observed by the hashingWriter match
 // those in hashes, where present, and that at least one is present.
 func verifyHashes(hashingWriter *hashingWriteSeeker, expectedHashes map[string]string) error {
@@ -1,12 +1,76 @@
 package tcobject
 
-import "encoding/json"
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"hash"
+
+	"lukechampine.com/blake3"
+)
+
+// The hashes supported by hashing{read,write}stream, in order of increasing
+// strength, and a function to create a hash.Hash for each.
+var hashAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha256", sha256.New},
+	{"sha512", sha512.New},
+	{"blake3", func() hash.Hash { return blake3.New(32, nil) }},
+}
 
 // The subset of hashes supported by hashing{read,write}stream which are
 // "accepted" as per the object service's schemas.
 var ACCEPTABLE_HASHES = []string{
 	"sha256",
 	"sha512",
+	"blake3",
+}
+
+// SupportedHashes returns the names of the hash algorithms that are computed
+// for uploads and can be verified for downloads, in order of increasing
+// strength.
+func SupportedHashes() []string {
+	names := make([]string, len(hashAlgorithms))
+	for i, algo := range hashAlgorithms {
+		names[i] = algo.name
+	}
+	return names
+}
+
+// newHashes returns a new hash.Hash for each of the named algorithms, which
+// must be supported.
+func newHashes(algorithms []string) map[string]hash.Hash {
+	hashes := map[string]hash.Hash{}
+	for _, algo := range hashAlgorithms {
+		for _, name := range algorithms {
+			if name == algo.name {
+				hashes[name] = algo.new()
+			}
+		}
+	}
+	return hashes
+}
+
+// negotiateHash returns the strongest acceptable algorithm that is both
+// supported by this package and present in the hashes advertised for an
+// object by the object service, or "" if there is none.  Verifying that hash
+// is sufficient to verify the object's data, so there is no need to compute
+// the others.
+func negotiateHash(advertised map[string]string) string {
+	for i := len(hashAlgorithms) - 1; i >= 0; i-- {
+		name := hashAlgorithms[i].name
+		if _, ok := advertised[name]; !ok {
+			continue
+		}
+		for _, a := range ACCEPTABLE_HASHES {
+			if name == a {
+				return name
+			}
+		}
+	}
+	return ""
 }
 
 // marshalHashes marshals a map of hashes as returned from the hashing
@@ -0,0 +1,15 @@
+package tcobject
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateHash(t *testing.T) {
+	require.Equal(t, "blake3", negotiateHash(map[string]string{"sha256": "a", "sha512": "b", "blake3": "c"}))
+	require.Equal(t, "sha512", negotiateHash(map[string]string{"sha256": "a", "sha512": "b", "sha3-512": "c"}))
+	require.Equal(t, "sha256", negotiateHash(map[string]string{"sha256": "a"}))
+	require.Equal(t, "", negotiateHash(map[string]string{"md5": "a"}))
+	require.Equal(t, "", negotiateHash(nil))
+}
@@ -11,7 +11,7 @@ import (
 func TestHashingReadSeekerOnce(t *testing.T) {
 	inner := bytes.NewReader([]byte("fake content"))
 
-	h := newHashingReadSeeker(inner)
+	h := newHashingReadSeeker(inner, SupportedHashes())
 
 	content, err := io.ReadAll(h)
 	require.NoError(t, err)
@@ -22,12 +22,13 @@ func TestHashingReadSeekerOnce(t *testing.T) {
 
 	require.Equal(t, "98b1ae45059b004178a8eee0c1f6179dcea139c0fd8a69ee47a6f02d97af1f17", hashes["sha256"])
 	require.Equal(t, "e0ea5ae6e392bb46d27eebabf5e7eb817d242505a960079cd9871559eaa94c613aff4034b709ea3cbd7747b304e7da5564083df50ea51f389cddcb942d2a4a09", hashes["sha512"])
+	require.Equal(t, "18bcca1f038462678f3607ce6524b3d91d2d7741914d5888e29a6133e984b3ef", hashes["blake3"])
 }
 
 func TestHashingReadSeekerTwice(t *testing.T) {
 	inner := bytes.NewReader([]byte("fake")) // truncated read
 
-	h := newHashingReadSeeker(inner)
+	h := newHashingReadSeeker(inner, SupportedHashes())
 
 	content, err := io.ReadAll(h)
 	require.NoError(t, err)
@@ -45,12 +46,13 @@ func TestHashingReadSeekerTwice(t *testing.T) {
 
 	require.Equal(t, "98b1ae45059b004178a8eee0c1f6179dcea139c0fd8a69ee47a6f02d97af1f17", hashes["sha256"])
 	require.Equal(t, "e0ea5ae6e392bb46d27eebabf5e7eb817d242505a960079cd9871559eaa94c613aff4034b709ea3cbd7747b304e7da5564083df50ea51f389cddcb942d2a4a09", hashes["sha512"])
+	require.Equal(t, "18bcca1f038462678f3607ce6524b3d91d2d7741914d5888e29a6133e984b3ef", hashes["blake3"])
 }
 
 func TestHashingReadSeekerBadContentLength(t *testing.T) {
 	inner := bytes.NewReader([]byte("fake")) // truncated read
 
-	h := newHashingReadSeeker(inner)
+	h := newHashingReadSeeker(inner, SupportedHashes())
 
 	content, err := io.ReadAll(h)
 	require.NoError(t, err)
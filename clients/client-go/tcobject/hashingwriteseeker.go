@@ -1,8 +1,6 @@
 package tcobject
 
 import (
-	"crypto/sha256"
-	"crypto/sha512"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -17,20 +15,20 @@ import (
 // the hashing. Seeking anywhere else in the wrapped object is an error.
 type hashingWriteSeeker struct {
 	inner io.WriteSeeker
-	// hashes for each supported algorithm
-	sha256 hash.Hash
-	sha512 hash.Hash
+	// hashes for each algorithm being computed
+	hashers map[string]hash.Hash
 	// total number of bytes hashed; if this is not equal to content_length, then something
 	// went terribly wrong.
 	bytes int64
 }
 
-func newHashingWriteSeeker(inner io.WriteSeeker) *hashingWriteSeeker {
+// newHashingWriteSeeker wraps inner, computing hashes with each of the named
+// algorithms as it is written.
+func newHashingWriteSeeker(inner io.WriteSeeker, algorithms []string) *hashingWriteSeeker {
 	return &hashingWriteSeeker{
-		inner:  inner,
-		sha256: sha256.New(),
-		sha512: sha512.New(),
-		bytes:  0,
+		inner:   inner,
+		hashers: newHashes(algorithms),
+		bytes:   0,
 	}
 }
 
@@ -50,15 +48,11 @@ func (h *hashingWriteSeeker) Write(b []byte) (n int, err error) {
 	// case for the standard library's hashing functions.
 	var hashedBytes int
 
-	hashedBytes, _ = h.sha256.Write(b)
-	if hashedBytes != n {
-		err =
-			fmt.Errorf("Sha256.Write consumed %v bytes, not the full buffer (%v)", hashedBytes, n)
-	}
-
-	hashedBytes, _ = h.sha512.Write(b)
-	if hashedBytes != n {
-		err = fmt.Errorf("Sha512.Write only consumed %v bytes, not the full buffer (%v)", hashedBytes, n)
+	for algo, hasher := range h.hashers {
+		hashedBytes, _ = hasher.Write(b)
+		if hashedBytes != n {
+			err = fmt.Errorf("%s hash Write only consumed %v bytes, not the full buffer (%v)", algo, hashedBytes, n)
+		}
 	}
 
 	h.bytes = overflow.Add64p(h.bytes, int64(n))
@@ -77,8 +71,9 @@ func (h *hashingWriteSeeker) Seek(offset int64, whence int) (pos int64, err erro
 	}
 
 	// we have returned to the beginning of the file, so reset the hashes
-	h.sha256.Reset()
-	h.sha512.Reset()
+	for _, hasher := range h.hashers {
+		hasher.Reset()
+	}
 	h.bytes = 0
 	return
 }
@@ -86,8 +81,9 @@ func (h *hashingWriteSeeker) Seek(offset int64, whence int) (pos int64, err erro
 // Hashes returns the calculated hashes, in a shape appropriate for FinishUpload.  If the
 // content length does not match the number of bytes hashed, something has gone wrong.
 func (h *hashingWriteSeeker) hashes() (map[string]string, error) {
-	return map[string]string{
-		"sha256": hex.EncodeToString(h.sha256.Sum(nil)),
-		"sha512": hex.EncodeToString(h.sha512.Sum(nil)),
-	}, nil
+	hashes := map[string]string{}
+	for algo, hasher := range h.hashers {
+		hashes[algo] = hex.EncodeToString(hasher.Sum(nil))
+	}
+	return hashes, nil
 }
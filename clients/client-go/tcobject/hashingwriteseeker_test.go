@@ -24,7 +24,7 @@ func (w *writeSeekingBuffer) Seek(offset int64, whence int) (pos int64, err erro
 
 func TestHashingWriteSeekerOnce(t *testing.T) {
 	inner := &writeSeekingBuffer{}
-	h := newHashingWriteSeeker(inner)
+	h := newHashingWriteSeeker(inner, SupportedHashes())
 
 	source := bytes.NewReader([]byte("fake content"))
 	count, err := io.Copy(h, source)
@@ -37,11 +37,12 @@ func TestHashingWriteSeekerOnce(t *testing.T) {
 
 	require.Equal(t, "98b1ae45059b004178a8eee0c1f6179dcea139c0fd8a69ee47a6f02d97af1f17", hashes["sha256"])
 	require.Equal(t, "e0ea5ae6e392bb46d27eebabf5e7eb817d242505a960079cd9871559eaa94c613aff4034b709ea3cbd7747b304e7da5564083df50ea51f389cddcb942d2a4a09", hashes["sha512"])
+	require.Equal(t, "18bcca1f038462678f3607ce6524b3d91d2d7741914d5888e29a6133e984b3ef", hashes["blake3"])
 }
 
 func TestHashingWriteSeekerTwice(t *testing.T) {
 	inner := &writeSeekingBuffer{}
-	h := newHashingWriteSeeker(inner)
+	h := newHashingWriteSeeker(inner, SupportedHashes())
 
 	source := bytes.NewReader([]byte("fake")) // truncated read
 	_, err := io.Copy(h, source)
@@ -62,4 +63,19 @@ func TestHashingWriteSeekerTwice(t *testing.T) {
 
 	require.Equal(t, "98b1ae45059b004178a8eee0c1f6179dcea139c0fd8a69ee47a6f02d97af1f17", hashes["sha256"])
 	require.Equal(t, "e0ea5ae6e392bb46d27eebabf5e7eb817d242505a960079cd9871559eaa94c613aff4034b709ea3cbd7747b304e7da5564083df50ea51f389cddcb942d2a4a09", hashes["sha512"])
+	require.Equal(t, "18bcca1f038462678f3607ce6524b3d91d2d7741914d5888e29a6133e984b3ef", hashes["blake3"])
+}
+
+func TestHashingWriteSeekerSomeAlgorithms(t *testing.T) {
+	inner := &writeSeekingBuffer{}
+	h := newHashingWriteSeeker(inner, []string{"blake3"})
+
+	_, err := io.Copy(h, bytes.NewReader([]byte("fake content")))
+	require.NoError(t, err)
+
+	hashes, err := h.hashes()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"blake3": "18bcca1f038462678f3607ce6524b3d91d2d7741914d5888e29a6133e984b3ef",
+	}, hashes)
 }
@@ -0,0 +1,50 @@
+package tcobject
+
+import (
+	"errors"
+	"io"
+)
+
+// ProgressFunc is called with the total number of bytes written so far.
+type ProgressFunc func(written int64)
+
+// progressWriter counts the bytes written to the wrapped io.Writer
+type progressWriter struct {
+	inner    io.Writer
+	written  int64
+	progress ProgressFunc
+}
+
+// NewProgressWriter wraps writer so that progress is called after each write,
+// for reporting the progress of a download.  If a download is retried from
+// the beginning, the count returns to zero.  The result can be passed to the
+// DownloadTo* methods of this package or tcqueue; seeking fails if writer is
+// not also an io.Seeker.
+func NewProgressWriter(writer io.Writer, progress ProgressFunc) io.WriteSeeker {
+	return &progressWriter{inner: writer, progress: progress}
+}
+
+func (p *progressWriter) Write(b []byte) (n int, err error) {
+	n, err = p.inner.Write(b)
+	if n > 0 {
+		p.written += int64(n)
+		p.progress(p.written)
+	}
+	return
+}
+
+func (p *progressWriter) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := p.inner.(io.Seeker)
+	if !ok {
+		return 0, errors.New("cannot seek an io.Writer")
+	}
+	pos, err := seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	if pos != p.written {
+		p.written = pos
+		p.progress(p.written)
+	}
+	return pos, nil
+}
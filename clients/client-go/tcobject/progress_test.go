@@ -0,0 +1,36 @@
+package tcobject_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcobject"
+)
+
+func TestProgressWriter(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "download"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	var progress []int64
+	writer := tcobject.NewProgressWriter(file, func(written int64) { progress = append(progress, written) })
+	_, err = writer.Write([]byte("hello"))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte(", world"))
+	require.NoError(t, err)
+	_, err = writer.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("hi"))
+	require.NoError(t, err)
+	require.Equal(t, []int64{5, 12, 0, 2}, progress)
+}
+
+func TestProgressWriterCannotSeek(t *testing.T) {
+	writer := tcobject.NewProgressWriter(&bytes.Buffer{}, func(written int64) {})
+	_, err := writer.Seek(0, io.SeekStart)
+	require.Error(t, err)
+}
@@ -269,3 +269,20 @@ func (object *Object) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// ObjectAPI is the interface implemented by *Object, with a method for
+// each API method.  Code that accepts ObjectAPI rather than *Object can be
+// tested with MockObject.
+type ObjectAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	CreateUpload(name string, payload *CreateUploadRequest) (*CreateUploadResponse, error)
+	FinishUpload(name string, payload *FinishUploadRequest) error
+	StartDownload(name string, payload *DownloadObjectRequest) (*DownloadObjectResponse, error)
+	Object(name string) (*ObjectMetadata, error)
+	Download(name string) error
+	Heartbeat() error
+}
+
+var _ ObjectAPI = (*Object)(nil)
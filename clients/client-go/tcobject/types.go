@@ -27,7 +27,7 @@ type (
 		// transferred, for example.
 		//
 		// At least one non-deprecated algorithm must be included, preferably the
-		// most advanced (SHA512).  Deprecated algorithms may also be included.
+		// most advanced (BLAKE3).  Deprecated algorithms may also be included.
 		//
 		// Defined properties:
 		//
@@ -35,6 +35,10 @@ type (
 		//
 		//  	// Syntax:     ^[a-z0-9]{64}$
 		//  	//
+		//		//  	Blake3 string `json:"blake3,omitempty"`
+		//
+		//  	// Syntax:     ^[a-z0-9]{64}$
+		//  	//
 		//		//  	SHA256 string `json:"sha256,omitempty"`
 		//
 		//  	// Syntax:     ^[a-z0-9]{128}$
@@ -127,7 +131,7 @@ type (
 		// transferred, for example.
 		//
 		// At least one non-deprecated algorithm must be included, preferably the
-		// most advanced (SHA512).  Deprecated algorithms may also be included.
+		// most advanced (BLAKE3).  Deprecated algorithms may also be included.
 		//
 		// Defined properties:
 		//
@@ -135,6 +139,10 @@ type (
 		//
 		//  	// Syntax:     ^[a-z0-9]{64}$
 		//  	//
+		//		//  	Blake3 string `json:"blake3,omitempty"`
+		//
+		//  	// Syntax:     ^[a-z0-9]{64}$
+		//  	//
 		//		//  	SHA256 string `json:"sha256,omitempty"`
 		//
 		//  	// Syntax:     ^[a-z0-9]{128}$
@@ -192,6 +200,10 @@ type (
 		//
 		//  	// Syntax:     ^[a-z0-9]{64}$
 		//  	//
+		//		//  	Blake3 string `json:"blake3,omitempty"`
+		//
+		//  	// Syntax:     ^[a-z0-9]{64}$
+		//  	//
 		//		//  	SHA256 string `json:"sha256,omitempty"`
 		//
 		//  	// Syntax:     ^[a-z0-9]{128}$
@@ -218,7 +230,7 @@ type (
 	// transferred, for example.
 	//
 	// At least one non-deprecated algorithm must be included, preferably the
-	// most advanced (SHA512).  Deprecated algorithms may also be included.
+	// most advanced (BLAKE3).  Deprecated algorithms may also be included.
 	//
 	// Defined properties:
 	//
@@ -226,6 +238,10 @@ type (
 	//
 	//  	// Syntax:     ^[a-z0-9]{64}$
 	//  	//
+	//	//  	Blake3 string `json:"blake3,omitempty"`
+	//
+	//  	// Syntax:     ^[a-z0-9]{64}$
+	//  	//
 	//	//  	SHA256 string `json:"sha256,omitempty"`
 	//
 	//  	// Syntax:     ^[a-z0-9]{128}$
@@ -246,6 +262,10 @@ type (
 	//
 	//  	// Syntax:     ^[a-z0-9]{64}$
 	//  	//
+	//	//  	Blake3 string `json:"blake3,omitempty"`
+	//
+	//  	// Syntax:     ^[a-z0-9]{64}$
+	//  	//
 	//	//  	SHA256 string `json:"sha256,omitempty"`
 	//
 	//  	// Syntax:     ^[a-z0-9]{128}$
@@ -270,6 +290,10 @@ type (
 		//
 		//  	// Syntax:     ^[a-z0-9]{64}$
 		//  	//
+		//		//  	Blake3 string `json:"blake3,omitempty"`
+		//
+		//  	// Syntax:     ^[a-z0-9]{64}$
+		//  	//
 		//		//  	SHA256 string `json:"sha256,omitempty"`
 		//
 		//  	// Syntax:     ^[a-z0-9]{128}$
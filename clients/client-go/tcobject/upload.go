@@ -11,6 +11,7 @@ import (
 
 	"github.com/cenkalti/backoff/v3"
 	"github.com/taskcluster/httpbackoff/v3"
+	"github.com/taskcluster/slugid-go/slugid"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 )
 
@@ -47,10 +48,15 @@ func (object *Object) UploadFromFile(projectID string, name string, contentType
 // UploadFromReadSeeker publishes an Object to the Object Service, with given
 // name, projectID, contentType, contentLength, expiry, and uploadID, with the
 // object content read from readSeeker. The value of contentLength is not
-// validated prior to upload.
+// validated prior to upload. If uploadID is empty, a new one is generated.
 func (object *Object) UploadFromReadSeeker(projectID string, name string, contentType string, contentLength int64, expires time.Time, uploadID string, readSeeker io.ReadSeeker) (err error) {
-	// wrap the readSeeker so that it will capture hashes
-	hashingReadSeeker := newHashingReadSeeker(readSeeker)
+	if uploadID == "" {
+		uploadID = slugid.Nice()
+	}
+
+	// wrap the readSeeker so that it will capture hashes with every supported
+	// algorithm, so that downloaders can verify the strongest they support
+	hashingReadSeeker := newHashingReadSeeker(readSeeker, SupportedHashes())
 
 	proposedUploadMethods := ProposedUploadMethods{}
 
@@ -90,7 +96,8 @@ func (object *Object) UploadFromReadSeeker(projectID string, name string, conten
 			return
 		}
 
-		hashes, err := hashingReadSeeker.hashes(contentLength)
+		var hashes map[string]string
+		hashes, err = hashingReadSeeker.hashes(contentLength)
 		if err != nil {
 			return
 		}
@@ -1,6 +1,7 @@
 package tcobject_test
 
 import (
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
@@ -278,3 +279,173 @@ func TestGetURLBadHash(t *testing.T) {
 	_, _, _, err = object.DownloadToBuf("some/object")
 	assert.Error(t, err)
 }
+
+// TestGetURLBlake3Only verifies that an object with only a BLAKE3 hash can be
+// downloaded.
+func TestGetURLBlake3Only(t *testing.T) {
+	srv, r, object, _ := mockObjectServer(t)
+	defer srv.Close()
+
+	content := []byte("some object data")
+	th := testHandler{t: t, gzipped: false, content: content}
+	r.Handle("/s3/obj/some/object", &th).Methods("GET")
+
+	_, err := object.CreateUpload("some/object", &tcobject.CreateUploadRequest{})
+	assert.NoError(t, err)
+	contentHashes, err := json.Marshal(map[string]string{
+		"blake3": "e732e5f1bd0256531b13328ce95f354d0e90b4e60d84314f599305051da303a8",
+	})
+	require.NoError(t, err)
+	err = object.FinishUpload("some/object", &tcobject.FinishUploadRequest{Hashes: contentHashes})
+	assert.NoError(t, err)
+
+	buf, _, _, err := object.DownloadToBuf("some/object")
+	require.NoError(t, err)
+	assert.Equal(t, content, buf)
+}
+
+// TestGetURLBadBlake3 verifies that the strongest hash is the one verified,
+// so a download fails if its BLAKE3 hash does not match even though its SHA
+// hashes do.
+func TestGetURLBadBlake3(t *testing.T) {
+	srv, r, object, _ := mockObjectServer(t)
+	defer srv.Close()
+
+	content := []byte("some object data")
+	th := testHandler{t: t, gzipped: false, content: content}
+	r.Handle("/s3/obj/some/object", &th).Methods("GET")
+
+	_, err := object.CreateUpload("some/object", &tcobject.CreateUploadRequest{})
+	assert.NoError(t, err)
+	contentHashes, err := json.Marshal(map[string]string{
+		"sha256": "b763426fc2acc4490490247a756a3fe1c4748f8558cbefa65a5ecf7315b2dee6",
+		"sha512": "871eb1e5d438061e164727fec9d68617e32921ffad4756ca493ad31cba2e967a2415a015bf1995a3d355e1f3c098e29cc87b978a2b43484fa27a22e142b2c277",
+		"blake3": "9999999999999999999999999999999999999999999999999999999999999999",
+	})
+	require.NoError(t, err)
+	err = object.FinishUpload("some/object", &tcobject.FinishUploadRequest{Hashes: contentHashes})
+	assert.NoError(t, err)
+
+	_, _, _, err = object.DownloadToBuf("some/object")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "blake3")
+}
+
+// resumingHandler serves content, dropping the connection part-way through
+// the first response, and checking that the next request resumes from there.
+type resumingHandler struct {
+	mu           sync.Mutex
+	t            *testing.T
+	content      []byte
+	acceptRanges bool
+	requests     []string
+}
+
+func (rh *resumingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.requests = append(rh.requests, r.Header.Get("Range"))
+	w.Header().Set("Content-Type", "text/plain")
+	if rh.acceptRanges {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	if len(rh.requests) == 1 {
+		w.Header().Set("Content-Length", fmt.Sprint(len(rh.content)))
+		w.WriteHeader(200)
+		_, _ = w.Write(rh.content[:5])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(rh.t, err)
+		conn.Close()
+		return
+	}
+	var start int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err == nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(rh.content)-1, len(rh.content)))
+		w.WriteHeader(206)
+		_, _ = w.Write(rh.content[start:])
+		return
+	}
+	_, _ = w.Write(rh.content)
+}
+
+func finishContentUpload(t *testing.T, object *tcobject.Object) {
+	t.Helper()
+	_, err := object.CreateUpload("some/object", &tcobject.CreateUploadRequest{})
+	require.NoError(t, err)
+	contentHashes, err := json.Marshal(map[string]string{
+		"sha256": "05c98fa0c442debfec682dc25eb255911264c2f3b0c671c218730da7890ed940",
+		"sha512": "87ebe61a26c4a8da246bf965f8d5d1a65f01391f85c2851340d23283ce1db970e2e69e7c328e5604b2e78a2d0be647e72b87e7337d70918d0cb1f65cc81a8987",
+	})
+	require.NoError(t, err)
+	require.NoError(t, object.FinishUpload("some/object", &tcobject.FinishUploadRequest{Hashes: contentHashes}))
+}
+
+// TestGetURLDownloadResumes tests that a getUrl download interrupted part-way
+// through is resumed with a range request, and the hashes of the whole object
+// are verified.
+func TestGetURLDownloadResumes(t *testing.T) {
+	srv, r, object, _ := mockObjectServer(t)
+	defer srv.Close()
+
+	rh := resumingHandler{t: t, content: content, acceptRanges: true}
+	r.Handle("/s3/obj/some/object", &rh).Methods("GET")
+	finishContentUpload(t, object)
+
+	buf, contentType, contentLength, err := object.DownloadToBuf("some/object")
+	require.NoError(t, err)
+	require.Equal(t, content, buf)
+	require.Equal(t, "text/plain", contentType)
+	require.Equal(t, int64(len(content)), contentLength)
+	require.Equal(t, []string{"", "bytes=5-"}, rh.requests)
+}
+
+// TestGetURLDownloadRestarts tests that a getUrl download interrupted
+// part-way through starts again when the server does not support ranges.
+func TestGetURLDownloadRestarts(t *testing.T) {
+	srv, r, object, _ := mockObjectServer(t)
+	defer srv.Close()
+
+	rh := resumingHandler{t: t, content: content}
+	r.Handle("/s3/obj/some/object", &rh).Methods("GET")
+	finishContentUpload(t, object)
+
+	buf, _, contentLength, err := object.DownloadToBuf("some/object")
+	require.NoError(t, err)
+	require.Equal(t, content, buf)
+	require.Equal(t, int64(len(content)), contentLength)
+	require.Equal(t, []string{"", ""}, rh.requests)
+}
+
+// TestDownloadToWriter tests streaming a download to an io.Writer, resuming
+// after an interruption.
+func TestDownloadToWriter(t *testing.T) {
+	srv, r, object, _ := mockObjectServer(t)
+	defer srv.Close()
+
+	rh := resumingHandler{t: t, content: content, acceptRanges: true}
+	r.Handle("/s3/obj/some/object", &rh).Methods("GET")
+	finishContentUpload(t, object)
+
+	var buf bytes.Buffer
+	_, contentLength, err := object.DownloadToWriter("some/object", &buf)
+	require.NoError(t, err)
+	require.Equal(t, content, buf.Bytes())
+	require.Equal(t, int64(len(content)), contentLength)
+}
+
+// TestDownloadToWriterCannotRestart tests that a download to an io.Writer
+// fails if it is interrupted after data has been written and cannot be
+// resumed.
+func TestDownloadToWriterCannotRestart(t *testing.T) {
+	srv, r, object, _ := mockObjectServer(t)
+	defer srv.Close()
+
+	rh := resumingHandler{t: t, content: content}
+	r.Handle("/s3/obj/some/object", &rh).Methods("GET")
+	finishContentUpload(t, object)
+
+	var buf bytes.Buffer
+	_, _, err := object.DownloadToWriter("some/object", &buf)
+	require.ErrorContains(t, err, "cannot rewind")
+}
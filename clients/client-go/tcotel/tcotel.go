@@ -0,0 +1,108 @@
+// Package tcotel provides OpenTelemetry tracing for Taskcluster clients.
+//
+// Add the interceptor returned by NewInterceptor to a client to create a span
+// for each request the client sends, and to propagate the trace context to
+// the Taskcluster service:
+//
+//	queue := tcqueue.NewFromEnv()
+//	queue.Interceptors = append(queue.Interceptors, tcotel.NewInterceptor(queue.ServiceName))
+//	queue.Context = ctx // the context containing the parent span, if any
+//
+// Spans are created with the globally registered tracer provider and
+// propagator, unless others are given as options.
+package tcotel
+
+import (
+	"net/http"
+	"strconv"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/internal"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The name of the instrumentation library, used to name the tracer
+const instrumentationName = "github.com/taskcluster/taskcluster/v60/clients/client-go/tcotel"
+
+// ServiceKey is the span attribute giving the name of the Taskcluster service
+// being called, such as "queue"
+const ServiceKey = attribute.Key("taskcluster.service")
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// Option configures the interceptor
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider used to create spans, in place
+// of the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithPropagator sets the propagator used to add trace context headers to
+// requests, in place of the global propagator.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// NewInterceptor returns a client interceptor that creates a span for each
+// request to the given Taskcluster service.  Each attempt at an API call,
+// including retries, is a separate span.  The span's parent is taken from
+// the request context, which is the client's Context.
+func NewInterceptor(serviceName string, opts ...Option) tcclient.Interceptor {
+	c := config{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	if c.propagator == nil {
+		c.propagator = otel.GetTextMapPropagator()
+	}
+	tracer := c.tracerProvider.Tracer(instrumentationName, trace.WithInstrumentationVersion(internal.Version))
+
+	return func(req *http.Request, next tcclient.RequestHandler) (*http.Response, error) {
+		attrs := []attribute.KeyValue{
+			ServiceKey.String(serviceName),
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(req.URL.String()),
+			semconv.ServerAddress(req.URL.Hostname()),
+		}
+		if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+			attrs = append(attrs, semconv.ServerPort(port))
+		}
+		ctx, span := tracer.Start(req.Context(), serviceName+" "+req.Method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...))
+		defer span.End()
+
+		req = req.WithContext(ctx)
+		c.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		resp, err := next(req)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return resp, err
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(resp.StatusCode)))
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+		return resp, nil
+	}
+}
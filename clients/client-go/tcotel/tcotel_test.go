@@ -0,0 +1,114 @@
+package tcotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setup(t *testing.T, status int) (*tcclient.Client, *tracetest.SpanRecorder, *http.Header) {
+	t.Helper()
+	var gotHeader http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(s.Close)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := &tcclient.Client{
+		RootURL:     s.URL,
+		ServiceName: "queue",
+		APIVersion:  "v1",
+		RetryPolicy: &tcclient.RetryPolicy{MaxAttempts: 1},
+		Interceptors: []tcclient.Interceptor{
+			NewInterceptor("queue", WithTracerProvider(provider), WithPropagator(propagation.TraceContext{})),
+		},
+	}
+	return client, recorder, &gotHeader
+}
+
+func attrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestSpan(t *testing.T) {
+	client, recorder, gotHeader := setup(t, 200)
+
+	// a parent span in the client's context
+	parentProvider := sdktrace.NewTracerProvider()
+	ctx, parent := parentProvider.Tracer("test").Start(context.Background(), "parent")
+	defer parent.End()
+	client.Context = ctx
+
+	_, _, err := client.APICall(nil, "GET", "/task/abc", nil, nil)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, "queue GET", span.Name())
+	require.Equal(t, trace.SpanKindClient, span.SpanKind())
+	require.Equal(t, parent.SpanContext().TraceID(), span.Parent().TraceID())
+	require.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	require.Equal(t, codes.Unset, span.Status().Code)
+
+	a := attrs(span)
+	require.Equal(t, "queue", a[ServiceKey].AsString())
+	require.Equal(t, "GET", a[semconv.HTTPRequestMethodKey].AsString())
+	require.Equal(t, int64(200), a[semconv.HTTPResponseStatusCodeKey].AsInt64())
+	require.Contains(t, a[semconv.URLFullKey].AsString(), "/api/queue/v1/task/abc")
+
+	// the trace context is propagated to the service, with the new span as
+	// the parent
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(
+		context.Background(), propagation.HeaderCarrier(*gotHeader)))
+	require.Equal(t, span.SpanContext().TraceID(), sc.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), sc.SpanID())
+}
+
+func TestSpanErrorStatus(t *testing.T) {
+	client, recorder, _ := setup(t, 404)
+
+	_, _, err := client.APICall(nil, "GET", "/task/abc", nil, nil)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	a := attrs(spans[0])
+	require.Equal(t, int64(404), a[semconv.HTTPResponseStatusCodeKey].AsInt64())
+	require.Equal(t, "404", a[semconv.ErrorTypeKey].AsString())
+}
+
+func TestSpanTransportError(t *testing.T) {
+	client, recorder, _ := setup(t, 200)
+	client.RootURL = "http://127.0.0.1:1"
+	client.RetryPolicy = &tcclient.RetryPolicy{MaxAttempts: 1, Budget: time.Second}
+
+	_, _, err := client.APICall(nil, "GET", "/task/abc", nil, nil)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.NotEmpty(t, spans[0].Events())
+}
@@ -38,6 +38,7 @@
 package tcpurgecache
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -186,6 +187,24 @@ func (purgeCache *PurgeCache) AllPurgeRequests_SignedURL(continuationToken, limi
 	return (&cd).SignedURL("/purge-cache/list", v, duration)
 }
 
+// Returns an iterator over the pages of results of AllPurgeRequests, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See AllPurgeRequests for more details.
+func (purgeCache *PurgeCache) AllPurgeRequestsPages(ctx context.Context, limit string) *tcclient.Pages[OpenAllPurgeRequestsList] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*OpenAllPurgeRequestsList, string, error) {
+		c := *purgeCache
+		c.Context = ctx
+		response, err := c.AllPurgeRequests(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // List the caches for this `workerPoolId` that should to be
 // purged if they are from before the time given in the response.
 //
@@ -233,3 +252,18 @@ func (purgeCache *PurgeCache) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// PurgeCacheAPI is the interface implemented by *PurgeCache, with a method for
+// each API method.  Code that accepts PurgeCacheAPI rather than *PurgeCache can be
+// tested with MockPurgeCache.
+type PurgeCacheAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	PurgeCache(workerPoolId string, payload *PurgeCacheRequest) error
+	AllPurgeRequests(continuationToken, limit string) (*OpenAllPurgeRequestsList, error)
+	PurgeRequests(workerPoolId, since string) (*OpenPurgeRequestList, error)
+	Heartbeat() error
+}
+
+var _ PurgeCacheAPI = (*PurgeCache)(nil)
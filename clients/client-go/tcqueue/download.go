@@ -47,10 +47,26 @@ func (queue *Queue) DownloadArtifactToFile(taskID string, runID int64, name stri
 	return queue.DownloadArtifactToWriteSeeker(taskID, runID, name, writeSeeker)
 }
 
-// DownloadArtifactToWriteSeeker downloads the named object from the object
-// service and writes it to writeSeeker, retrying if intermittent errors occur.
-// If RunID is -1, the latest run is used.  Returns the Content-Type and
+// DownloadArtifactToWriter downloads an artifact and writes it to writer, for
+// callers that process the data as it arrives rather than storing it.  If
+// RunID is -1, the latest run is used.  Intermittent errors are retried until
+// data has been written; after that, only object artifacts can be retried, by
+// resuming the download where it stopped.  Returns the Content-Type and
 // Content-Length of the downloaded object.
+//
+// The hashes of object artifacts can only be verified once all of the data has
+// been written, so callers must not trust the data if an error is returned.
+// Use tcobject.NewProgressWriter to report the progress of the download.
+func (queue *Queue) DownloadArtifactToWriter(taskID string, runID int64, name string, writer io.Writer) (contentType string, contentLength int64, err error) {
+	return queue.DownloadArtifactToWriteSeeker(taskID, runID, name, &internal.WriteOnceSeeker{Writer: writer})
+}
+
+// DownloadArtifactToWriteSeeker downloads an artifact and writes it to
+// writeSeeker, retrying if intermittent errors occur.  If RunID is -1, the
+// latest run is used.  Link artifacts are resolved by the queue, reference and
+// s3 artifacts are fetched from their URL, following any redirects, and object
+// artifacts are fetched from the object service, verifying their hashes.
+// Returns the Content-Type and Content-Length of the downloaded object.
 func (queue *Queue) DownloadArtifactToWriteSeeker(taskID string, runID int64, name string, writeSeeker io.WriteSeeker) (contentType string, contentLength int64, err error) {
 	// get the artifact content information
 	var artifactJSON *GetArtifactContentResponse
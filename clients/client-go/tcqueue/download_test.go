@@ -1,6 +1,7 @@
 package tcqueue_test
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/taskcluster/httpbackoff/v3"
 	"github.com/taskcluster/slugid-go/slugid"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcobject"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
 	"github.com/taskcluster/taskcluster/v60/internal/mocktc"
 	"github.com/taskcluster/taskcluster/v60/internal/mocktc/mocks3"
@@ -144,3 +146,53 @@ func TestDownloadErrorArtifactToBuf(t *testing.T) {
 	require.True(t, strings.Contains(err.Error(), "uhoh"))
 	require.True(t, strings.Contains(err.Error(), "we are in trouble"))
 }
+
+func TestDownloadS3ArtifactToWriter(t *testing.T) {
+	m := mockTcServices(t)
+	defer m.Close()
+
+	taskId := slugid.Nice()
+
+	m.queueService.FakeS3Artifact(taskId, "0", "some/thing.txt", "text/plain")
+	m.s3.FakeObject(fmt.Sprintf("%s/0/%s", taskId, url.PathEscape("some/thing.txt")), "text/plain", []byte("hello, world"))
+
+	var buf bytes.Buffer
+	var progress []int64
+	writer := tcobject.NewProgressWriter(&buf, func(written int64) { progress = append(progress, written) })
+	contentType, contentLength, err := m.queue.DownloadArtifactToWriter(taskId, 0, "some/thing.txt", writer)
+	require.NoError(t, err)
+	require.Equal(t, "hello, world", buf.String())
+	require.Equal(t, "text/plain", contentType)
+	require.Equal(t, int64(12), contentLength)
+	require.Equal(t, int64(12), progress[len(progress)-1])
+}
+
+func TestDownloadObjectArtifactToWriter(t *testing.T) {
+	m := mockTcServices(t)
+	defer m.Close()
+
+	taskId := slugid.Nice()
+
+	m.queueService.FakeObjectArtifact(taskId, "0", "some/thing.txt", "text/plain")
+	m.objectService.FakeObject(fmt.Sprintf("t/%s/0/some/thing.txt", taskId), map[string]string{
+		"sha256": "09ca7e4eaa6e8ae9c7d261167129184883644d07dfba7cbfbc4c8a2e08360d5b",
+	})
+	m.s3.FakeObject(fmt.Sprintf("obj/t/%s/0/some/thing.txt", taskId), "text/plain", []byte("hello, world"))
+
+	var buf bytes.Buffer
+	contentType, contentLength, err := m.queue.DownloadArtifactToWriter(taskId, -1, "some/thing.txt", &buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello, world", buf.String())
+	require.Equal(t, "text/plain", contentType)
+	require.Equal(t, int64(12), contentLength)
+}
+
+func TestDownloadArtifactToWriterNotFound(t *testing.T) {
+	m := mockTcServices(t)
+	defer m.Close()
+
+	var buf bytes.Buffer
+	_, _, err := m.queue.DownloadArtifactToWriter(slugid.Nice(), 0, "some/thing.txt", &buf)
+	require.Error(t, err)
+	require.Zero(t, buf.Len())
+}
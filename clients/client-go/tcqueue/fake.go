@@ -0,0 +1,291 @@
+package tcqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// FakeQueue is a minimal in-memory implementation of the Queue service, for
+// use in tests.  It supports CreateTask, Task, Status, CancelTask, RerunTask
+// and ListTaskGroup; tasks are created in the pending state and are never
+// claimed.  Other methods behave as in MockQueue, and any method can be
+// replaced by setting the corresponding `<Method>Func` field.
+type FakeQueue struct {
+	MockQueue
+	mu    sync.Mutex
+	tasks map[string]*TaskDefinitionAndStatus
+}
+
+var _ QueueAPI = (*FakeQueue)(nil)
+
+// NewFakeQueue returns a FakeQueue with no tasks
+func NewFakeQueue() *FakeQueue {
+	q := &FakeQueue{
+		tasks: map[string]*TaskDefinitionAndStatus{},
+	}
+	q.CreateTaskFunc = q.createTask
+	q.TaskFunc = q.task
+	q.StatusFunc = q.status
+	q.CancelTaskFunc = q.cancelTask
+	q.RerunTaskFunc = q.rerunTask
+	q.ListTaskGroupFunc = q.listTaskGroup
+	return q
+}
+
+func (q *FakeQueue) createTask(taskId string, payload *TaskDefinitionRequest) (*TaskStatusResponse, error) {
+	def, err := taskDefinition(taskId, payload)
+	if err != nil {
+		return nil, tcclient.FakeAPIError(400, "InputError", err.Error())
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if existing, ok := q.tasks[taskId]; ok {
+		// as with the real queue, creating the same task again is fine
+		if !reflect.DeepEqual(existing.Task, def) {
+			return nil, tcclient.FakeAPIError(409, "RequestConflict", "taskId "+taskId+" already used by another task")
+		}
+		return &TaskStatusResponse{Status: copyStatus(existing.Status)}, nil
+	}
+
+	q.tasks[taskId] = &TaskDefinitionAndStatus{
+		Task: def,
+		Status: TaskStatusStructure{
+			Deadline:      def.Deadline,
+			Expires:       def.Expires,
+			ProjectID:     def.ProjectID,
+			ProvisionerID: def.ProvisionerID,
+			RetriesLeft:   def.Retries,
+			Runs: []RunInformation{
+				{
+					ReasonCreated: "scheduled",
+					RunID:         0,
+					Scheduled:     tcclient.Time(time.Now()),
+					State:         "pending",
+				},
+			},
+			SchedulerID: def.SchedulerID,
+			State:       "pending",
+			TaskGroupID: def.TaskGroupID,
+			TaskID:      taskId,
+			TaskQueueID: def.TaskQueueID,
+			WorkerType:  def.WorkerType,
+		},
+	}
+	return &TaskStatusResponse{Status: copyStatus(q.tasks[taskId].Status)}, nil
+}
+
+// Build the task definition the queue would return for the given request,
+// filling in defaults
+func taskDefinition(taskId string, payload *TaskDefinitionRequest) (TaskDefinitionResponse, error) {
+	var def TaskDefinitionResponse
+	if payload == nil {
+		return def, errors.New("no task definition given")
+	}
+	// the request and response have the same fields
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return def, err
+	}
+	if err := json.Unmarshal(data, &def); err != nil {
+		return def, err
+	}
+
+	switch {
+	case def.TaskQueueID != "":
+		parts := strings.SplitN(def.TaskQueueID, "/", 2)
+		if len(parts) != 2 {
+			return def, fmt.Errorf("invalid taskQueueId %q", def.TaskQueueID)
+		}
+		def.ProvisionerID, def.WorkerType = parts[0], parts[1]
+	default:
+		def.TaskQueueID = def.ProvisionerID + "/" + def.WorkerType
+	}
+	if def.Priority == "" {
+		def.Priority = "lowest"
+	}
+	if def.Requires == "" {
+		def.Requires = "all-completed"
+	}
+	if payload.Retries == 0 {
+		def.Retries = 5
+	}
+	if def.SchedulerID == "" {
+		def.SchedulerID = "-"
+	}
+	if def.TaskGroupID == "" {
+		def.TaskGroupID = taskId
+	}
+	if def.ProjectID == "" {
+		def.ProjectID = "none"
+	}
+	if time.Time(def.Expires).IsZero() {
+		def.Expires = tcclient.Time(time.Time(def.Deadline).AddDate(1, 0, 0))
+	}
+	if def.Dependencies == nil {
+		def.Dependencies = []string{}
+	}
+	if def.Routes == nil {
+		def.Routes = []string{}
+	}
+	if def.Scopes == nil {
+		def.Scopes = []string{}
+	}
+	if def.Tags == nil {
+		def.Tags = map[string]string{}
+	}
+	if def.Extra == nil {
+		def.Extra = json.RawMessage(`{}`)
+	}
+	return def, nil
+}
+
+func (q *FakeQueue) lookup(taskId string) (*TaskDefinitionAndStatus, error) {
+	t, ok := q.tasks[taskId]
+	if !ok {
+		return nil, tcclient.FakeAPIError(404, "ResourceNotFound", "task "+taskId+" not found")
+	}
+	return t, nil
+}
+
+func (q *FakeQueue) task(taskId string) (*TaskDefinitionResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t, err := q.lookup(taskId)
+	if err != nil {
+		return nil, err
+	}
+	def := t.Task
+	return &def, nil
+}
+
+func (q *FakeQueue) status(taskId string) (*TaskStatusResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t, err := q.lookup(taskId)
+	if err != nil {
+		return nil, err
+	}
+	return &TaskStatusResponse{Status: copyStatus(t.Status)}, nil
+}
+
+func (q *FakeQueue) cancelTask(taskId string) (*TaskStatusResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t, err := q.lookup(taskId)
+	if err != nil {
+		return nil, err
+	}
+	status := &t.Status
+	// resolved tasks are left alone
+	if status.State == "unscheduled" || status.State == "pending" || status.State == "running" {
+		now := tcclient.Time(time.Now())
+		if len(status.Runs) == 0 {
+			status.Runs = append(status.Runs, RunInformation{
+				ReasonCreated: "exception",
+				Scheduled:     now,
+			})
+		}
+		run := &status.Runs[len(status.Runs)-1]
+		run.State = "exception"
+		run.ReasonResolved = "canceled"
+		run.Resolved = now
+		status.State = "exception"
+	}
+	return &TaskStatusResponse{Status: copyStatus(*status)}, nil
+}
+
+func (q *FakeQueue) rerunTask(taskId string) (*TaskStatusResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t, err := q.lookup(taskId)
+	if err != nil {
+		return nil, err
+	}
+	status := &t.Status
+	// unresolved tasks are left alone
+	if status.State == "completed" || status.State == "failed" || status.State == "exception" {
+		status.Runs = append(status.Runs, RunInformation{
+			ReasonCreated: "rerun",
+			RunID:         int64(len(status.Runs)),
+			Scheduled:     tcclient.Time(time.Now()),
+			State:         "pending",
+		})
+		status.State = "pending"
+	}
+	return &TaskStatusResponse{Status: copyStatus(*status)}, nil
+}
+
+func (q *FakeQueue) listTaskGroup(taskGroupId, continuationToken, limit string) (*ListTaskGroupResponse, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	taskIds := []string{}
+	for taskId, t := range q.tasks {
+		if t.Task.TaskGroupID == taskGroupId {
+			taskIds = append(taskIds, taskId)
+		}
+	}
+	if len(taskIds) == 0 {
+		return nil, tcclient.FakeAPIError(404, "ResourceNotFound", "task group "+taskGroupId+" not found")
+	}
+	sort.Strings(taskIds)
+
+	start, end, next, err := page(len(taskIds), continuationToken, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListTaskGroupResponse{
+		ContinuationToken: next,
+		TaskGroupID:       taskGroupId,
+		Tasks:             []TaskDefinitionAndStatus{},
+	}
+	for _, taskId := range taskIds {
+		t := q.tasks[taskId]
+		resp.SchedulerID = t.Task.SchedulerID
+		if time.Time(t.Task.Expires).After(time.Time(resp.Expires)) {
+			resp.Expires = t.Task.Expires
+		}
+	}
+	for _, taskId := range taskIds[start:end] {
+		t := q.tasks[taskId]
+		resp.Tasks = append(resp.Tasks, TaskDefinitionAndStatus{Task: t.Task, Status: copyStatus(t.Status)})
+	}
+	return resp, nil
+}
+
+func copyStatus(status TaskStatusStructure) TaskStatusStructure {
+	status.Runs = append([]RunInformation{}, status.Runs...)
+	return status
+}
+
+// Return the range of n results to include in the page given by
+// continuationToken and limit, and the continuation token for the next page
+func page(n int, continuationToken, limit string) (start, end int, next string, err error) {
+	if continuationToken != "" {
+		start, err = strconv.Atoi(continuationToken)
+		if err != nil || start < 0 || start > n {
+			return 0, 0, "", tcclient.FakeAPIError(400, "InputError", "invalid continuationToken")
+		}
+	}
+	end = n
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 {
+			return 0, 0, "", tcclient.FakeAPIError(400, "InputError", "invalid limit")
+		}
+		if start+l < n {
+			end = start + l
+			next = strconv.Itoa(end)
+		}
+	}
+	return start, end, next, nil
+}
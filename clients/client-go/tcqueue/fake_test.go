@@ -0,0 +1,131 @@
+package tcqueue_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+)
+
+func taskDef(taskGroupId string) *tcqueue.TaskDefinitionRequest {
+	now := time.Now()
+	return &tcqueue.TaskDefinitionRequest{
+		Created:     tcclient.Time(now),
+		Deadline:    tcclient.Time(now.Add(time.Hour)),
+		TaskGroupID: taskGroupId,
+		TaskQueueID: "proj/builder",
+		Metadata: tcqueue.TaskMetadata{
+			Name:        "test",
+			Description: "a test task",
+			Owner:       "tester@example.com",
+			Source:      "https://example.com",
+		},
+		Payload: json.RawMessage(`{}`),
+	}
+}
+
+func notFound(t *testing.T, err error) {
+	t.Helper()
+	require.Error(t, err)
+	require.Equal(t, 404, err.(*tcclient.APICallException).CallSummary.HTTPResponse.StatusCode)
+}
+
+func TestMockQueue(t *testing.T) {
+	var queue tcqueue.QueueAPI
+	mock := &tcqueue.MockQueue{
+		StatusFunc: func(taskId string) (*tcqueue.TaskStatusResponse, error) {
+			return &tcqueue.TaskStatusResponse{Status: tcqueue.TaskStatusStructure{TaskID: taskId, State: "completed"}}, nil
+		},
+	}
+	queue = mock
+
+	status, err := queue.Status("abc")
+	require.NoError(t, err)
+	require.Equal(t, "completed", status.Status.State)
+
+	_, err = queue.Task("abc")
+	require.Equal(t, &tcclient.NotMockedError{Method: "Queue.Task"}, err)
+
+	require.Equal(t, []tcclient.MockCall{
+		{Method: "Status", Args: []interface{}{"abc"}},
+		{Method: "Task", Args: []interface{}{"abc"}},
+	}, mock.Calls())
+}
+
+func TestFakeQueue(t *testing.T) {
+	queue := tcqueue.NewFakeQueue()
+
+	_, err := queue.Task("abc")
+	notFound(t, err)
+
+	def := taskDef("group")
+	status, err := queue.CreateTask("abc", def)
+	require.NoError(t, err)
+	require.Equal(t, "pending", status.Status.State)
+	require.Equal(t, "abc", status.Status.TaskID)
+	require.Equal(t, "proj", status.Status.ProvisionerID)
+	require.Equal(t, "builder", status.Status.WorkerType)
+	require.Len(t, status.Status.Runs, 1)
+
+	// creating the same task again succeeds, but a different one conflicts
+	_, err = queue.CreateTask("abc", def)
+	require.NoError(t, err)
+	other := taskDef("group")
+	other.Priority = "high"
+	_, err = queue.CreateTask("abc", other)
+	require.Error(t, err)
+	require.Equal(t, 409, err.(*tcclient.APICallException).CallSummary.HTTPResponse.StatusCode)
+
+	task, err := queue.Task("abc")
+	require.NoError(t, err)
+	require.Equal(t, "lowest", task.Priority)
+	require.Equal(t, "group", task.TaskGroupID)
+	require.Equal(t, "proj/builder", task.TaskQueueID)
+
+	status, err = queue.CancelTask("abc")
+	require.NoError(t, err)
+	require.Equal(t, "exception", status.Status.State)
+	require.Equal(t, "canceled", status.Status.Runs[0].ReasonResolved)
+
+	status, err = queue.Status("abc")
+	require.NoError(t, err)
+	require.Equal(t, "exception", status.Status.State)
+
+	// methods without a fake implementation are not mocked
+	_, err = queue.ClaimWork("proj/builder", &tcqueue.ClaimWorkRequest{})
+	require.Equal(t, &tcclient.NotMockedError{Method: "Queue.ClaimWork"}, err)
+	require.Len(t, queue.CallsTo("CreateTask"), 3)
+}
+
+func TestFakeQueueListTaskGroup(t *testing.T) {
+	queue := tcqueue.NewFakeQueue()
+
+	_, err := queue.ListTaskGroup("group", "", "")
+	notFound(t, err)
+
+	for _, taskId := range []string{"c", "a", "b"} {
+		_, err := queue.CreateTask(taskId, taskDef("group"))
+		require.NoError(t, err)
+	}
+	_, err = queue.CreateTask("d", taskDef("other"))
+	require.NoError(t, err)
+
+	taskIds := []string{}
+	continuationToken := ""
+	for {
+		resp, err := queue.ListTaskGroup("group", continuationToken, "2")
+		require.NoError(t, err)
+		require.Equal(t, "group", resp.TaskGroupID)
+		for _, task := range resp.Tasks {
+			taskIds = append(taskIds, task.Status.TaskID)
+		}
+		continuationToken = resp.ContinuationToken
+		if continuationToken == "" {
+			break
+		}
+	}
+	require.Equal(t, []string{"a", "b", "c"}, taskIds)
+}
@@ -0,0 +1,190 @@
+package tcqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// LiveLogOptions configures FollowLiveLog.
+type LiveLogOptions struct {
+	// Name of the live log artifact; if empty, "public/logs/live.log"
+	LiveLogName string
+	// Name of the artifact holding the complete log once the task is
+	// resolved; if empty, "public/logs/live_backing.log"
+	BackingLogName string
+	// Time to wait before checking the task again, while it has not started
+	// or after the live log connection is lost; if zero, five seconds
+	PollInterval time.Duration
+}
+
+// FollowLiveLog returns a reader for the log of the latest run of the given
+// task, which follows the live log while the task is running and then reads
+// the remainder from the backing log once the task is resolved.  If the task
+// has not started, the reader waits for it to start.  If the connection to
+// the live log is lost, the reader reconnects, skipping the part of the log
+// it has already returned.  options may be nil.
+//
+// The reader returns io.EOF once the complete log has been read.  Closing the
+// reader, or cancelling ctx, stops following the log.
+func (queue *Queue) FollowLiveLog(ctx context.Context, taskID string, options *LiveLogOptions) io.ReadCloser {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	follower := &liveLogFollower{
+		LiveLogOptions: LiveLogOptions{
+			LiveLogName:    "public/logs/live.log",
+			BackingLogName: "public/logs/live_backing.log",
+			PollInterval:   5 * time.Second,
+		},
+		taskID: taskID,
+	}
+	if options != nil {
+		if options.LiveLogName != "" {
+			follower.LiveLogName = options.LiveLogName
+		}
+		if options.BackingLogName != "" {
+			follower.BackingLogName = options.BackingLogName
+		}
+		if options.PollInterval > 0 {
+			follower.PollInterval = options.PollInterval
+		}
+	}
+	ctx, follower.cancel = context.WithCancel(ctx)
+	q := *queue
+	q.Context = ctx
+	follower.queue = &q
+	reader, writer := io.Pipe()
+	follower.reader = reader
+	go func() {
+		_ = writer.CloseWithError(follower.follow(ctx, writer))
+	}()
+	return follower
+}
+
+type liveLogFollower struct {
+	LiveLogOptions
+	queue  *Queue
+	taskID string
+	reader *io.PipeReader
+	cancel context.CancelFunc
+	// number of bytes of the log written so far
+	offset int64
+}
+
+func (follower *liveLogFollower) Read(p []byte) (int, error) {
+	return follower.reader.Read(p)
+}
+
+func (follower *liveLogFollower) Close() error {
+	follower.cancel()
+	return follower.reader.Close()
+}
+
+// Write the log to w, returning nil once it is complete
+func (follower *liveLogFollower) follow(ctx context.Context, w io.Writer) error {
+	for {
+		status, err := follower.queue.Status(follower.taskID)
+		if err != nil {
+			return err
+		}
+		switch status.Status.State {
+		case "unscheduled", "pending":
+		case "running":
+			err := follower.streamLiveLog(ctx, w)
+			if errors.Is(err, errWriteFailed) || ctx.Err() != nil {
+				return err
+			}
+		default:
+			return follower.readBackingLog(w)
+		}
+		select {
+		case <-time.After(follower.PollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// errWriteFailed means that the reader was closed
+var errWriteFailed = errors.New("write failed")
+
+// skipWriter discards the part of the log already written, and writes the
+// rest to w, counting the bytes written
+type skipWriter struct {
+	follower *liveLogFollower
+	w        io.Writer
+	skip     int64
+}
+
+func (sw *skipWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if sw.skip > 0 {
+		skipped := min(sw.skip, int64(len(p)))
+		sw.skip -= skipped
+		p = p[skipped:]
+	}
+	if len(p) > 0 {
+		written, err := sw.w.Write(p)
+		sw.follower.offset += int64(written)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", errWriteFailed, err)
+		}
+	}
+	return n, nil
+}
+
+func (follower *liveLogFollower) newSkipWriter(w io.Writer) *skipWriter {
+	return &skipWriter{follower: follower, w: w, skip: follower.offset}
+}
+
+// Stream the live log of the running task, returning when the stream ends
+func (follower *liveLogFollower) streamLiveLog(ctx context.Context, w io.Writer) error {
+	artifactJSON, err := follower.queue.LatestArtifact(follower.taskID, follower.LiveLogName)
+	if err != nil {
+		return err
+	}
+	var artifact struct {
+		StorageType string `json:"storageType"`
+		URL         string `json:"url"`
+	}
+	if err := json.Unmarshal(*artifactJSON, &artifact); err != nil {
+		return err
+	}
+	if artifact.StorageType != "reference" {
+		// the live log is not being streamed; wait for the task to resolve
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", artifact.URL, nil)
+	if err != nil {
+		return err
+	}
+	var httpClient tcclient.ReducedHTTPClient = http.DefaultClient
+	if follower.queue.HTTPClient != nil {
+		httpClient = follower.queue.HTTPClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("live log %s returned HTTP status %d", artifact.URL, resp.StatusCode)
+	}
+	// the live log server does not support range requests, so skip the
+	// part of the log already written
+	_, err = io.Copy(follower.newSkipWriter(w), resp.Body)
+	return err
+}
+
+// Write the remainder of the backing log of the resolved task
+func (follower *liveLogFollower) readBackingLog(w io.Writer) error {
+	_, _, err := follower.queue.DownloadArtifactToWriter(follower.taskID, -1, follower.BackingLogName, follower.newSkipWriter(w))
+	return err
+}
@@ -0,0 +1,93 @@
+package tcqueue_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+)
+
+// liveLogServer fakes the queue and a live log server for a single task
+type liveLogServer struct {
+	mu          sync.Mutex
+	state       string
+	connections int
+	srv         *httptest.Server
+}
+
+func newLiveLogServer(t *testing.T) *liveLogServer {
+	t.Helper()
+	s := &liveLogServer{state: "pending"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/queue/v1/task/abc/status", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// the task starts once its status has been checked
+		state := s.state
+		if s.state == "pending" {
+			s.state = "running"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": map[string]string{"taskId": "abc", "state": state}})
+	})
+	mux.HandleFunc("/api/queue/v1/task/abc/artifact-content/public/logs/live.log", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"storageType": "reference", "url": s.srv.URL + "/live"})
+	})
+	mux.HandleFunc("/api/queue/v1/task/abc/artifact-content/public/logs/live_backing.log", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"storageType": "reference", "url": s.srv.URL + "/backing"})
+	})
+	mux.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.connections++
+		connection := s.connections
+		s.mu.Unlock()
+		_, _ = w.Write([]byte("one\ntwo\n"))
+		w.(http.Flusher).Flush()
+		if connection == 1 {
+			// lose the connection part way through the log
+			panic(http.ErrAbortHandler)
+		}
+		_, _ = w.Write([]byte("three\n"))
+		s.mu.Lock()
+		s.state = "completed"
+		s.mu.Unlock()
+	})
+	mux.HandleFunc("/backing", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("one\ntwo\nthree\nfour\n"))
+	})
+	s.srv = httptest.NewServer(mux)
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+func TestFollowLiveLog(t *testing.T) {
+	s := newLiveLogServer(t)
+	queue := tcqueue.New(nil, s.srv.URL)
+
+	reader := queue.FollowLiveLog(context.Background(), "abc", &tcqueue.LiveLogOptions{PollInterval: time.Millisecond})
+	defer reader.Close()
+	log, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo\nthree\nfour\n", string(log))
+	require.Equal(t, 2, s.connections)
+}
+
+func TestFollowLiveLogClose(t *testing.T) {
+	s := newLiveLogServer(t)
+	queue := tcqueue.New(nil, s.srv.URL)
+
+	reader := queue.FollowLiveLog(context.Background(), "abc", &tcqueue.LiveLogOptions{PollInterval: time.Millisecond})
+	buf := make([]byte, 4)
+	_, err := io.ReadFull(reader, buf)
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(buf))
+	require.NoError(t, reader.Close())
+	_, err = reader.Read(buf)
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+}
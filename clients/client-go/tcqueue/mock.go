@@ -0,0 +1,481 @@
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that `${GOPATH}/bin` is in your `PATH`:
+//
+// go install && go generate
+
+package tcqueue
+
+import (
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// MockQueue is a mock implementation of QueueAPI, for use in tests.  Each method
+// records the call, then calls the function in the corresponding `<Method>Func` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type MockQueue struct {
+	tcclient.MockCalls
+	PingFunc                func() error
+	LbheartbeatFunc         func() error
+	VersionFunc             func() error
+	TaskFunc                func(taskId string) (*TaskDefinitionResponse, error)
+	StatusFunc              func(taskId string) (*TaskStatusResponse, error)
+	ListTaskGroupFunc       func(taskGroupId, continuationToken, limit string) (*ListTaskGroupResponse, error)
+	CancelTaskGroupFunc     func(taskGroupId string) (*CancelTaskGroupResponse, error)
+	GetTaskGroupFunc        func(taskGroupId string) (*TaskGroupDefinitionResponse, error)
+	SealTaskGroupFunc       func(taskGroupId string) (*TaskGroupDefinitionResponse, error)
+	ListDependentTasksFunc  func(taskId, continuationToken, limit string) (*ListDependentTasksResponse, error)
+	CreateTaskFunc          func(taskId string, payload *TaskDefinitionRequest) (*TaskStatusResponse, error)
+	ScheduleTaskFunc        func(taskId string) (*TaskStatusResponse, error)
+	RerunTaskFunc           func(taskId string) (*TaskStatusResponse, error)
+	CancelTaskFunc          func(taskId string) (*TaskStatusResponse, error)
+	ClaimWorkFunc           func(taskQueueId string, payload *ClaimWorkRequest) (*ClaimWorkResponse, error)
+	ClaimTaskFunc           func(taskId, runId string, payload *TaskClaimRequest) (*TaskClaimResponse, error)
+	ReclaimTaskFunc         func(taskId, runId string) (*TaskReclaimResponse, error)
+	ReportCompletedFunc     func(taskId, runId string) (*TaskStatusResponse, error)
+	ReportFailedFunc        func(taskId, runId string) (*TaskStatusResponse, error)
+	ReportExceptionFunc     func(taskId, runId string, payload *TaskExceptionRequest) (*TaskStatusResponse, error)
+	CreateArtifactFunc      func(taskId, runId, name string, payload *PostArtifactRequest) (*PostArtifactResponse, error)
+	FinishArtifactFunc      func(taskId, runId, name string, payload *FinishArtifactRequest) error
+	GetArtifactFunc         func(taskId, runId, name string) (*GetArtifactResponse, error)
+	GetLatestArtifactFunc   func(taskId, name string) (*GetArtifactResponse, error)
+	ListArtifactsFunc       func(taskId, runId, continuationToken, limit string) (*ListArtifactsResponse, error)
+	ListLatestArtifactsFunc func(taskId, continuationToken, limit string) (*ListArtifactsResponse, error)
+	ArtifactInfoFunc        func(taskId, runId, name string) (*Artifact, error)
+	LatestArtifactInfoFunc  func(taskId, name string) (*Artifact, error)
+	ArtifactFunc            func(taskId, runId, name string) (*GetArtifactContentResponse, error)
+	LatestArtifactFunc      func(taskId, name string) (*GetArtifactContentResponse, error)
+	ListProvisionersFunc    func(continuationToken, limit string) (*ListProvisionersResponse, error)
+	GetProvisionerFunc      func(provisionerId string) (*ProvisionerResponse, error)
+	DeclareProvisionerFunc  func(provisionerId string, payload *ProvisionerRequest) (*ProvisionerResponse, error)
+	PendingTasksFunc        func(taskQueueId string) (*CountPendingTasksResponse, error)
+	ListPendingTasksFunc    func(taskQueueId, continuationToken, limit string) (*ListPendingTasksResponse, error)
+	ListClaimedTasksFunc    func(taskQueueId, continuationToken, limit string) (*ListClaimedTasksResponse, error)
+	ListWorkerTypesFunc     func(provisionerId, continuationToken, limit string) (*ListWorkerTypesResponse, error)
+	GetWorkerTypeFunc       func(provisionerId, workerType string) (*WorkerTypeResponse, error)
+	DeclareWorkerTypeFunc   func(provisionerId, workerType string, payload *WorkerTypeRequest) (*WorkerTypeResponse, error)
+	ListTaskQueuesFunc      func(continuationToken, limit string) (*ListTaskQueuesResponse, error)
+	GetTaskQueueFunc        func(taskQueueId string) (*TaskQueueResponse, error)
+	ListWorkersFunc         func(provisionerId, workerType, continuationToken, limit, quarantined string) (*ListWorkersResponse, error)
+	GetWorkerFunc           func(provisionerId, workerType, workerGroup, workerId string) (*WorkerResponse, error)
+	QuarantineWorkerFunc    func(provisionerId, workerType, workerGroup, workerId string, payload *QuarantineWorkerRequest) (*WorkerResponse, error)
+	DeclareWorkerFunc       func(provisionerId, workerType, workerGroup, workerId string, payload *WorkerRequest) (*WorkerResponse, error)
+	HeartbeatFunc           func() error
+}
+
+var _ QueueAPI = (*MockQueue)(nil)
+
+// Ping records the call and calls PingFunc
+func (m *MockQueue) Ping() error {
+	m.Record("Ping")
+	if m.PingFunc == nil {
+		return &tcclient.NotMockedError{Method: "Queue.Ping"}
+	}
+	return m.PingFunc()
+}
+
+// Lbheartbeat records the call and calls LbheartbeatFunc
+func (m *MockQueue) Lbheartbeat() error {
+	m.Record("Lbheartbeat")
+	if m.LbheartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Queue.Lbheartbeat"}
+	}
+	return m.LbheartbeatFunc()
+}
+
+// Version records the call and calls VersionFunc
+func (m *MockQueue) Version() error {
+	m.Record("Version")
+	if m.VersionFunc == nil {
+		return &tcclient.NotMockedError{Method: "Queue.Version"}
+	}
+	return m.VersionFunc()
+}
+
+// Task records the call and calls TaskFunc
+func (m *MockQueue) Task(taskId string) (*TaskDefinitionResponse, error) {
+	m.Record("Task", taskId)
+	if m.TaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.Task"}
+	}
+	return m.TaskFunc(taskId)
+}
+
+// Status records the call and calls StatusFunc
+func (m *MockQueue) Status(taskId string) (*TaskStatusResponse, error) {
+	m.Record("Status", taskId)
+	if m.StatusFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.Status"}
+	}
+	return m.StatusFunc(taskId)
+}
+
+// ListTaskGroup records the call and calls ListTaskGroupFunc
+func (m *MockQueue) ListTaskGroup(taskGroupId, continuationToken, limit string) (*ListTaskGroupResponse, error) {
+	m.Record("ListTaskGroup", taskGroupId, continuationToken, limit)
+	if m.ListTaskGroupFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListTaskGroup"}
+	}
+	return m.ListTaskGroupFunc(taskGroupId, continuationToken, limit)
+}
+
+// CancelTaskGroup records the call and calls CancelTaskGroupFunc
+func (m *MockQueue) CancelTaskGroup(taskGroupId string) (*CancelTaskGroupResponse, error) {
+	m.Record("CancelTaskGroup", taskGroupId)
+	if m.CancelTaskGroupFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.CancelTaskGroup"}
+	}
+	return m.CancelTaskGroupFunc(taskGroupId)
+}
+
+// GetTaskGroup records the call and calls GetTaskGroupFunc
+func (m *MockQueue) GetTaskGroup(taskGroupId string) (*TaskGroupDefinitionResponse, error) {
+	m.Record("GetTaskGroup", taskGroupId)
+	if m.GetTaskGroupFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.GetTaskGroup"}
+	}
+	return m.GetTaskGroupFunc(taskGroupId)
+}
+
+// SealTaskGroup records the call and calls SealTaskGroupFunc
+func (m *MockQueue) SealTaskGroup(taskGroupId string) (*TaskGroupDefinitionResponse, error) {
+	m.Record("SealTaskGroup", taskGroupId)
+	if m.SealTaskGroupFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.SealTaskGroup"}
+	}
+	return m.SealTaskGroupFunc(taskGroupId)
+}
+
+// ListDependentTasks records the call and calls ListDependentTasksFunc
+func (m *MockQueue) ListDependentTasks(taskId, continuationToken, limit string) (*ListDependentTasksResponse, error) {
+	m.Record("ListDependentTasks", taskId, continuationToken, limit)
+	if m.ListDependentTasksFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListDependentTasks"}
+	}
+	return m.ListDependentTasksFunc(taskId, continuationToken, limit)
+}
+
+// CreateTask records the call and calls CreateTaskFunc
+func (m *MockQueue) CreateTask(taskId string, payload *TaskDefinitionRequest) (*TaskStatusResponse, error) {
+	m.Record("CreateTask", taskId, payload)
+	if m.CreateTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.CreateTask"}
+	}
+	return m.CreateTaskFunc(taskId, payload)
+}
+
+// ScheduleTask records the call and calls ScheduleTaskFunc
+func (m *MockQueue) ScheduleTask(taskId string) (*TaskStatusResponse, error) {
+	m.Record("ScheduleTask", taskId)
+	if m.ScheduleTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ScheduleTask"}
+	}
+	return m.ScheduleTaskFunc(taskId)
+}
+
+// RerunTask records the call and calls RerunTaskFunc
+func (m *MockQueue) RerunTask(taskId string) (*TaskStatusResponse, error) {
+	m.Record("RerunTask", taskId)
+	if m.RerunTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.RerunTask"}
+	}
+	return m.RerunTaskFunc(taskId)
+}
+
+// CancelTask records the call and calls CancelTaskFunc
+func (m *MockQueue) CancelTask(taskId string) (*TaskStatusResponse, error) {
+	m.Record("CancelTask", taskId)
+	if m.CancelTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.CancelTask"}
+	}
+	return m.CancelTaskFunc(taskId)
+}
+
+// ClaimWork records the call and calls ClaimWorkFunc
+func (m *MockQueue) ClaimWork(taskQueueId string, payload *ClaimWorkRequest) (*ClaimWorkResponse, error) {
+	m.Record("ClaimWork", taskQueueId, payload)
+	if m.ClaimWorkFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ClaimWork"}
+	}
+	return m.ClaimWorkFunc(taskQueueId, payload)
+}
+
+// ClaimTask records the call and calls ClaimTaskFunc
+func (m *MockQueue) ClaimTask(taskId, runId string, payload *TaskClaimRequest) (*TaskClaimResponse, error) {
+	m.Record("ClaimTask", taskId, runId, payload)
+	if m.ClaimTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ClaimTask"}
+	}
+	return m.ClaimTaskFunc(taskId, runId, payload)
+}
+
+// ReclaimTask records the call and calls ReclaimTaskFunc
+func (m *MockQueue) ReclaimTask(taskId, runId string) (*TaskReclaimResponse, error) {
+	m.Record("ReclaimTask", taskId, runId)
+	if m.ReclaimTaskFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ReclaimTask"}
+	}
+	return m.ReclaimTaskFunc(taskId, runId)
+}
+
+// ReportCompleted records the call and calls ReportCompletedFunc
+func (m *MockQueue) ReportCompleted(taskId, runId string) (*TaskStatusResponse, error) {
+	m.Record("ReportCompleted", taskId, runId)
+	if m.ReportCompletedFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ReportCompleted"}
+	}
+	return m.ReportCompletedFunc(taskId, runId)
+}
+
+// ReportFailed records the call and calls ReportFailedFunc
+func (m *MockQueue) ReportFailed(taskId, runId string) (*TaskStatusResponse, error) {
+	m.Record("ReportFailed", taskId, runId)
+	if m.ReportFailedFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ReportFailed"}
+	}
+	return m.ReportFailedFunc(taskId, runId)
+}
+
+// ReportException records the call and calls ReportExceptionFunc
+func (m *MockQueue) ReportException(taskId, runId string, payload *TaskExceptionRequest) (*TaskStatusResponse, error) {
+	m.Record("ReportException", taskId, runId, payload)
+	if m.ReportExceptionFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ReportException"}
+	}
+	return m.ReportExceptionFunc(taskId, runId, payload)
+}
+
+// CreateArtifact records the call and calls CreateArtifactFunc
+func (m *MockQueue) CreateArtifact(taskId, runId, name string, payload *PostArtifactRequest) (*PostArtifactResponse, error) {
+	m.Record("CreateArtifact", taskId, runId, name, payload)
+	if m.CreateArtifactFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.CreateArtifact"}
+	}
+	return m.CreateArtifactFunc(taskId, runId, name, payload)
+}
+
+// FinishArtifact records the call and calls FinishArtifactFunc
+func (m *MockQueue) FinishArtifact(taskId, runId, name string, payload *FinishArtifactRequest) error {
+	m.Record("FinishArtifact", taskId, runId, name, payload)
+	if m.FinishArtifactFunc == nil {
+		return &tcclient.NotMockedError{Method: "Queue.FinishArtifact"}
+	}
+	return m.FinishArtifactFunc(taskId, runId, name, payload)
+}
+
+// GetArtifact records the call and calls GetArtifactFunc
+func (m *MockQueue) GetArtifact(taskId, runId, name string) (*GetArtifactResponse, error) {
+	m.Record("GetArtifact", taskId, runId, name)
+	if m.GetArtifactFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.GetArtifact"}
+	}
+	return m.GetArtifactFunc(taskId, runId, name)
+}
+
+// GetLatestArtifact records the call and calls GetLatestArtifactFunc
+func (m *MockQueue) GetLatestArtifact(taskId, name string) (*GetArtifactResponse, error) {
+	m.Record("GetLatestArtifact", taskId, name)
+	if m.GetLatestArtifactFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.GetLatestArtifact"}
+	}
+	return m.GetLatestArtifactFunc(taskId, name)
+}
+
+// ListArtifacts records the call and calls ListArtifactsFunc
+func (m *MockQueue) ListArtifacts(taskId, runId, continuationToken, limit string) (*ListArtifactsResponse, error) {
+	m.Record("ListArtifacts", taskId, runId, continuationToken, limit)
+	if m.ListArtifactsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListArtifacts"}
+	}
+	return m.ListArtifactsFunc(taskId, runId, continuationToken, limit)
+}
+
+// ListLatestArtifacts records the call and calls ListLatestArtifactsFunc
+func (m *MockQueue) ListLatestArtifacts(taskId, continuationToken, limit string) (*ListArtifactsResponse, error) {
+	m.Record("ListLatestArtifacts", taskId, continuationToken, limit)
+	if m.ListLatestArtifactsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListLatestArtifacts"}
+	}
+	return m.ListLatestArtifactsFunc(taskId, continuationToken, limit)
+}
+
+// ArtifactInfo records the call and calls ArtifactInfoFunc
+func (m *MockQueue) ArtifactInfo(taskId, runId, name string) (*Artifact, error) {
+	m.Record("ArtifactInfo", taskId, runId, name)
+	if m.ArtifactInfoFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ArtifactInfo"}
+	}
+	return m.ArtifactInfoFunc(taskId, runId, name)
+}
+
+// LatestArtifactInfo records the call and calls LatestArtifactInfoFunc
+func (m *MockQueue) LatestArtifactInfo(taskId, name string) (*Artifact, error) {
+	m.Record("LatestArtifactInfo", taskId, name)
+	if m.LatestArtifactInfoFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.LatestArtifactInfo"}
+	}
+	return m.LatestArtifactInfoFunc(taskId, name)
+}
+
+// Artifact records the call and calls ArtifactFunc
+func (m *MockQueue) Artifact(taskId, runId, name string) (*GetArtifactContentResponse, error) {
+	m.Record("Artifact", taskId, runId, name)
+	if m.ArtifactFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.Artifact"}
+	}
+	return m.ArtifactFunc(taskId, runId, name)
+}
+
+// LatestArtifact records the call and calls LatestArtifactFunc
+func (m *MockQueue) LatestArtifact(taskId, name string) (*GetArtifactContentResponse, error) {
+	m.Record("LatestArtifact", taskId, name)
+	if m.LatestArtifactFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.LatestArtifact"}
+	}
+	return m.LatestArtifactFunc(taskId, name)
+}
+
+// ListProvisioners records the call and calls ListProvisionersFunc
+func (m *MockQueue) ListProvisioners(continuationToken, limit string) (*ListProvisionersResponse, error) {
+	m.Record("ListProvisioners", continuationToken, limit)
+	if m.ListProvisionersFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListProvisioners"}
+	}
+	return m.ListProvisionersFunc(continuationToken, limit)
+}
+
+// GetProvisioner records the call and calls GetProvisionerFunc
+func (m *MockQueue) GetProvisioner(provisionerId string) (*ProvisionerResponse, error) {
+	m.Record("GetProvisioner", provisionerId)
+	if m.GetProvisionerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.GetProvisioner"}
+	}
+	return m.GetProvisionerFunc(provisionerId)
+}
+
+// DeclareProvisioner records the call and calls DeclareProvisionerFunc
+func (m *MockQueue) DeclareProvisioner(provisionerId string, payload *ProvisionerRequest) (*ProvisionerResponse, error) {
+	m.Record("DeclareProvisioner", provisionerId, payload)
+	if m.DeclareProvisionerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.DeclareProvisioner"}
+	}
+	return m.DeclareProvisionerFunc(provisionerId, payload)
+}
+
+// PendingTasks records the call and calls PendingTasksFunc
+func (m *MockQueue) PendingTasks(taskQueueId string) (*CountPendingTasksResponse, error) {
+	m.Record("PendingTasks", taskQueueId)
+	if m.PendingTasksFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.PendingTasks"}
+	}
+	return m.PendingTasksFunc(taskQueueId)
+}
+
+// ListPendingTasks records the call and calls ListPendingTasksFunc
+func (m *MockQueue) ListPendingTasks(taskQueueId, continuationToken, limit string) (*ListPendingTasksResponse, error) {
+	m.Record("ListPendingTasks", taskQueueId, continuationToken, limit)
+	if m.ListPendingTasksFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListPendingTasks"}
+	}
+	return m.ListPendingTasksFunc(taskQueueId, continuationToken, limit)
+}
+
+// ListClaimedTasks records the call and calls ListClaimedTasksFunc
+func (m *MockQueue) ListClaimedTasks(taskQueueId, continuationToken, limit string) (*ListClaimedTasksResponse, error) {
+	m.Record("ListClaimedTasks", taskQueueId, continuationToken, limit)
+	if m.ListClaimedTasksFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListClaimedTasks"}
+	}
+	return m.ListClaimedTasksFunc(taskQueueId, continuationToken, limit)
+}
+
+// ListWorkerTypes records the call and calls ListWorkerTypesFunc
+func (m *MockQueue) ListWorkerTypes(provisionerId, continuationToken, limit string) (*ListWorkerTypesResponse, error) {
+	m.Record("ListWorkerTypes", provisionerId, continuationToken, limit)
+	if m.ListWorkerTypesFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListWorkerTypes"}
+	}
+	return m.ListWorkerTypesFunc(provisionerId, continuationToken, limit)
+}
+
+// GetWorkerType records the call and calls GetWorkerTypeFunc
+func (m *MockQueue) GetWorkerType(provisionerId, workerType string) (*WorkerTypeResponse, error) {
+	m.Record("GetWorkerType", provisionerId, workerType)
+	if m.GetWorkerTypeFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.GetWorkerType"}
+	}
+	return m.GetWorkerTypeFunc(provisionerId, workerType)
+}
+
+// DeclareWorkerType records the call and calls DeclareWorkerTypeFunc
+func (m *MockQueue) DeclareWorkerType(provisionerId, workerType string, payload *WorkerTypeRequest) (*WorkerTypeResponse, error) {
+	m.Record("DeclareWorkerType", provisionerId, workerType, payload)
+	if m.DeclareWorkerTypeFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.DeclareWorkerType"}
+	}
+	return m.DeclareWorkerTypeFunc(provisionerId, workerType, payload)
+}
+
+// ListTaskQueues records the call and calls ListTaskQueuesFunc
+func (m *MockQueue) ListTaskQueues(continuationToken, limit string) (*ListTaskQueuesResponse, error) {
+	m.Record("ListTaskQueues", continuationToken, limit)
+	if m.ListTaskQueuesFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListTaskQueues"}
+	}
+	return m.ListTaskQueuesFunc(continuationToken, limit)
+}
+
+// GetTaskQueue records the call and calls GetTaskQueueFunc
+func (m *MockQueue) GetTaskQueue(taskQueueId string) (*TaskQueueResponse, error) {
+	m.Record("GetTaskQueue", taskQueueId)
+	if m.GetTaskQueueFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.GetTaskQueue"}
+	}
+	return m.GetTaskQueueFunc(taskQueueId)
+}
+
+// ListWorkers records the call and calls ListWorkersFunc
+func (m *MockQueue) ListWorkers(provisionerId, workerType, continuationToken, limit, quarantined string) (*ListWorkersResponse, error) {
+	m.Record("ListWorkers", provisionerId, workerType, continuationToken, limit, quarantined)
+	if m.ListWorkersFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.ListWorkers"}
+	}
+	return m.ListWorkersFunc(provisionerId, workerType, continuationToken, limit, quarantined)
+}
+
+// GetWorker records the call and calls GetWorkerFunc
+func (m *MockQueue) GetWorker(provisionerId, workerType, workerGroup, workerId string) (*WorkerResponse, error) {
+	m.Record("GetWorker", provisionerId, workerType, workerGroup, workerId)
+	if m.GetWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.GetWorker"}
+	}
+	return m.GetWorkerFunc(provisionerId, workerType, workerGroup, workerId)
+}
+
+// QuarantineWorker records the call and calls QuarantineWorkerFunc
+func (m *MockQueue) QuarantineWorker(provisionerId, workerType, workerGroup, workerId string, payload *QuarantineWorkerRequest) (*WorkerResponse, error) {
+	m.Record("QuarantineWorker", provisionerId, workerType, workerGroup, workerId, payload)
+	if m.QuarantineWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.QuarantineWorker"}
+	}
+	return m.QuarantineWorkerFunc(provisionerId, workerType, workerGroup, workerId, payload)
+}
+
+// DeclareWorker records the call and calls DeclareWorkerFunc
+func (m *MockQueue) DeclareWorker(provisionerId, workerType, workerGroup, workerId string, payload *WorkerRequest) (*WorkerResponse, error) {
+	m.Record("DeclareWorker", provisionerId, workerType, workerGroup, workerId, payload)
+	if m.DeclareWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "Queue.DeclareWorker"}
+	}
+	return m.DeclareWorkerFunc(provisionerId, workerType, workerGroup, workerId, payload)
+}
+
+// Heartbeat records the call and calls HeartbeatFunc
+func (m *MockQueue) Heartbeat() error {
+	m.Record("Heartbeat")
+	if m.HeartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "Queue.Heartbeat"}
+	}
+	return m.HeartbeatFunc()
+}
@@ -0,0 +1,175 @@
+package tcqueue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// DefaultTaskGroupConcurrency is the number of tasks operated on at once by
+// ForEachTaskInGroup, if TaskGroupOptions.Concurrency is not set.
+const DefaultTaskGroupConcurrency = 10
+
+// TaskGroupOptions configures ForEachTaskInGroup, CancelTasks and RerunTasks.
+type TaskGroupOptions struct {
+	// Filter selects the tasks to operate on; if nil, all tasks in the task
+	// group are selected.
+	Filter func(task *TaskDefinitionAndStatus) bool
+	// Concurrency is the maximum number of tasks operated on at once; if
+	// zero, DefaultTaskGroupConcurrency is used.
+	Concurrency int
+}
+
+// InState returns a TaskGroupOptions filter selecting tasks in any of the
+// given states, such as "failed" or "exception".
+func InState(states ...string) func(task *TaskDefinitionAndStatus) bool {
+	return func(task *TaskDefinitionAndStatus) bool {
+		for _, state := range states {
+			if task.Status.State == state {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// TaskGroupResult reports the outcome of an operation on the tasks of a task
+// group.
+type TaskGroupResult struct {
+	// The taskIds of the tasks the operation succeeded for, in order
+	Succeeded []string
+	// The errors of the tasks the operation failed for, by taskId
+	Failed map[string]error
+}
+
+// Err returns a *TaskGroupError if the operation failed for any task, and
+// nil otherwise.
+func (result *TaskGroupResult) Err() error {
+	if len(result.Failed) == 0 {
+		return nil
+	}
+	return &TaskGroupError{Failed: result.Failed}
+}
+
+// TaskGroupError is returned when an operation on the tasks of a task group
+// failed for some of the tasks.
+type TaskGroupError struct {
+	// The errors of the tasks the operation failed for, by taskId
+	Failed map[string]error
+}
+
+func (err *TaskGroupError) Error() string {
+	taskIds := make([]string, 0, len(err.Failed))
+	for taskId := range err.Failed {
+		taskIds = append(taskIds, taskId)
+	}
+	sort.Strings(taskIds)
+	lines := make([]string, len(taskIds))
+	for i, taskId := range taskIds {
+		lines[i] = fmt.Sprintf("  %s: %v", taskId, err.Failed[taskId])
+	}
+	return fmt.Sprintf("operation failed for %d tasks:\n%s", len(taskIds), strings.Join(lines, "\n"))
+}
+
+// ForEachTaskInGroup pages through the tasks of the given task group, calling
+// action for each task selected by options, with up to options.Concurrency
+// calls at once.  options may be nil.
+//
+// The result lists the tasks for which action succeeded and failed, and is
+// returned even if an error occurs.  The error is that from listing the task
+// group, if any, or else result.Err().  If ctx is done, no further tasks are
+// started and ctx.Err() is returned.
+func ForEachTaskInGroup(ctx context.Context, queue QueueAPI, taskGroupId string, options *TaskGroupOptions, action func(task *TaskDefinitionAndStatus) error) (*TaskGroupResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if options == nil {
+		options = &TaskGroupOptions{}
+	}
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultTaskGroupConcurrency
+	}
+
+	result := &TaskGroupResult{
+		Succeeded: []string{},
+		Failed:    map[string]error{},
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	var err error
+	pages := tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListTaskGroupResponse, string, error) {
+		page, err := queue.ListTaskGroup(taskGroupId, continuationToken, "")
+		if err != nil {
+			return nil, "", err
+		}
+		return page, page.ContinuationToken, nil
+	})
+dispatch:
+	for pages.Next() {
+		for i := range pages.Page().Tasks {
+			task := &pages.Page().Tasks[i]
+			if options.Filter != nil && !options.Filter(task) {
+				continue
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				err = ctx.Err()
+				break dispatch
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				actionErr := action(task)
+				mu.Lock()
+				defer mu.Unlock()
+				if actionErr != nil {
+					result.Failed[task.Status.TaskID] = actionErr
+				} else {
+					result.Succeeded = append(result.Succeeded, task.Status.TaskID)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	sort.Strings(result.Succeeded)
+
+	if err == nil {
+		err = pages.Err()
+	}
+	if err != nil {
+		return result, fmt.Errorf("could not list tasks of task group %s: %w", taskGroupId, err)
+	}
+	return result, result.Err()
+}
+
+// CancelTasks cancels the tasks of the given task group selected by options,
+// as ForEachTaskInGroup does.  Unlike Queue.CancelTaskGroup, it can cancel
+// a subset of the tasks, and reports the tasks that could not be canceled.
+func CancelTasks(ctx context.Context, queue QueueAPI, taskGroupId string, options *TaskGroupOptions) (*TaskGroupResult, error) {
+	return ForEachTaskInGroup(ctx, queue, taskGroupId, options, func(task *TaskDefinitionAndStatus) error {
+		_, err := queue.CancelTask(task.Status.TaskID)
+		return err
+	})
+}
+
+// RerunTasks reruns the tasks of the given task group selected by options,
+// as ForEachTaskInGroup does.  For example, to rerun the failed tasks:
+//
+//	result, err := tcqueue.RerunTasks(ctx, queue, taskGroupId, &tcqueue.TaskGroupOptions{
+//		Filter: tcqueue.InState("failed", "exception"),
+//	})
+func RerunTasks(ctx context.Context, queue QueueAPI, taskGroupId string, options *TaskGroupOptions) (*TaskGroupResult, error) {
+	return ForEachTaskInGroup(ctx, queue, taskGroupId, options, func(task *TaskDefinitionAndStatus) error {
+		_, err := queue.RerunTask(task.Status.TaskID)
+		return err
+	})
+}
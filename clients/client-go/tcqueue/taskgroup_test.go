@@ -0,0 +1,131 @@
+package tcqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+)
+
+// fakeTaskGroup returns a fake queue with tasks a to e in task group
+// "group", listed two at a time
+func fakeTaskGroup(t *testing.T) *tcqueue.FakeQueue {
+	t.Helper()
+	queue := tcqueue.NewFakeQueue()
+	for _, taskId := range []string{"a", "b", "c", "d", "e"} {
+		_, err := queue.CreateTask(taskId, taskDef("group"))
+		require.NoError(t, err)
+	}
+	list := queue.ListTaskGroupFunc
+	queue.ListTaskGroupFunc = func(taskGroupId, continuationToken, limit string) (*tcqueue.ListTaskGroupResponse, error) {
+		return list(taskGroupId, continuationToken, "2")
+	}
+	return queue
+}
+
+func state(t *testing.T, queue tcqueue.QueueAPI, taskId string) string {
+	t.Helper()
+	status, err := queue.Status(taskId)
+	require.NoError(t, err)
+	return status.Status.State
+}
+
+func TestCancelAndRerunTasks(t *testing.T) {
+	queue := fakeTaskGroup(t)
+
+	result, err := tcqueue.CancelTasks(context.Background(), queue, "group", &tcqueue.TaskGroupOptions{
+		Filter: func(task *tcqueue.TaskDefinitionAndStatus) bool {
+			return task.Status.TaskID != "c"
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "d", "e"}, result.Succeeded)
+	require.Empty(t, result.Failed)
+	require.Equal(t, "pending", state(t, queue, "c"))
+	require.Equal(t, "exception", state(t, queue, "d"))
+
+	result, err = tcqueue.RerunTasks(context.Background(), queue, "group", &tcqueue.TaskGroupOptions{
+		Filter: tcqueue.InState("exception"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "d", "e"}, result.Succeeded)
+	require.Len(t, queue.CallsTo("RerunTask"), 4)
+	status, err := queue.Status("d")
+	require.NoError(t, err)
+	require.Equal(t, "pending", status.Status.State)
+	require.Len(t, status.Status.Runs, 2)
+}
+
+func TestForEachTaskInGroupPartialFailure(t *testing.T) {
+	queue := fakeTaskGroup(t)
+	cancel := queue.CancelTaskFunc
+	queue.CancelTaskFunc = func(taskId string) (*tcqueue.TaskStatusResponse, error) {
+		if taskId == "b" || taskId == "d" {
+			return nil, errors.New("no scopes")
+		}
+		return cancel(taskId)
+	}
+
+	result, err := tcqueue.CancelTasks(context.Background(), queue, "group", nil)
+	var groupErr *tcqueue.TaskGroupError
+	require.True(t, errors.As(err, &groupErr))
+	require.Len(t, groupErr.Failed, 2)
+	require.Contains(t, err.Error(), "b: no scopes")
+	require.Equal(t, []string{"a", "c", "e"}, result.Succeeded)
+	require.EqualError(t, result.Failed["d"], "no scopes")
+}
+
+func TestForEachTaskInGroupConcurrency(t *testing.T) {
+	queue := fakeTaskGroup(t)
+	var mu sync.Mutex
+	running, highest := 0, 0
+	release := make(chan struct{})
+	go func() {
+		// let the actions finish once two are running at once
+		for {
+			mu.Lock()
+			r := running
+			mu.Unlock()
+			if r == 2 {
+				close(release)
+				return
+			}
+		}
+	}()
+
+	result, err := tcqueue.ForEachTaskInGroup(context.Background(), queue, "group", &tcqueue.TaskGroupOptions{Concurrency: 2}, func(task *tcqueue.TaskDefinitionAndStatus) error {
+		mu.Lock()
+		running++
+		highest = max(highest, running)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Succeeded, 5)
+	require.Equal(t, 2, highest)
+}
+
+func TestForEachTaskInGroupNotFound(t *testing.T) {
+	queue := tcqueue.NewFakeQueue()
+	result, err := tcqueue.CancelTasks(context.Background(), queue, "group", nil)
+	notFound(t, errors.Unwrap(err))
+	require.Empty(t, result.Succeeded)
+}
+
+func TestForEachTaskInGroupCanceled(t *testing.T) {
+	queue := fakeTaskGroup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := tcqueue.ForEachTaskInGroup(ctx, queue, "group", &tcqueue.TaskGroupOptions{Concurrency: 1}, func(task *tcqueue.TaskDefinitionAndStatus) error {
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, len(result.Succeeded), 5)
+}
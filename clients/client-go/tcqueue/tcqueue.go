@@ -78,6 +78,7 @@
 package tcqueue
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -273,6 +274,24 @@ func (queue *Queue) ListTaskGroup_SignedURL(taskGroupId, continuationToken, limi
 	return (&cd).SignedURL("/task-group/"+url.QueryEscape(taskGroupId)+"/list", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListTaskGroup, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListTaskGroup for more details.
+func (queue *Queue) ListTaskGroupPages(ctx context.Context, taskGroupId, limit string) *tcclient.Pages[ListTaskGroupResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListTaskGroupResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListTaskGroup(taskGroupId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** EXPERIMENTAL ***
 //
 // This method will cancel all unresolved tasks (`unscheduled`, `pending` or `running` states)
@@ -399,6 +418,24 @@ func (queue *Queue) ListDependentTasks_SignedURL(taskId, continuationToken, limi
 	return (&cd).SignedURL("/task/"+url.QueryEscape(taskId)+"/dependents", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListDependentTasks, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListDependentTasks for more details.
+func (queue *Queue) ListDependentTasksPages(ctx context.Context, taskId, limit string) *tcclient.Pages[ListDependentTasksResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListDependentTasksResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListDependentTasks(taskId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Create a new task, this is an **idempotent** operation, so repeat it if
 // you get an internal server error or network connection is dropped.
 //
@@ -865,6 +902,24 @@ func (queue *Queue) ListArtifacts_SignedURL(taskId, runId, continuationToken, li
 	return (&cd).SignedURL("/task/"+url.QueryEscape(taskId)+"/runs/"+url.QueryEscape(runId)+"/artifacts", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListArtifacts, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListArtifacts for more details.
+func (queue *Queue) ListArtifactsPages(ctx context.Context, taskId, runId, limit string) *tcclient.Pages[ListArtifactsResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListArtifactsResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListArtifacts(taskId, runId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Returns a list of artifacts and associated meta-data for the latest run
 // from the given task.
 //
@@ -913,6 +968,24 @@ func (queue *Queue) ListLatestArtifacts_SignedURL(taskId, continuationToken, lim
 	return (&cd).SignedURL("/task/"+url.QueryEscape(taskId)+"/artifacts", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListLatestArtifacts, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListLatestArtifacts for more details.
+func (queue *Queue) ListLatestArtifactsPages(ctx context.Context, taskId, limit string) *tcclient.Pages[ListArtifactsResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListArtifactsResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListLatestArtifacts(taskId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Returns associated metadata for a given artifact, in the given task run.
 // The metadata is the same as that returned from `listArtifacts`, and does
 // not grant access to the artifact data.
@@ -1083,6 +1156,24 @@ func (queue *Queue) ListProvisioners_SignedURL(continuationToken, limit string,
 	return (&cd).SignedURL("/provisioners", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListProvisioners, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListProvisioners for more details.
+func (queue *Queue) ListProvisionersPages(ctx context.Context, limit string) *tcclient.Pages[ListProvisionersResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListProvisionersResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListProvisioners(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** DEPRECATED ***
 //
 // Get an active provisioner.
@@ -1210,6 +1301,24 @@ func (queue *Queue) ListPendingTasks_SignedURL(taskQueueId, continuationToken, l
 	return (&cd).SignedURL("/task-queues/"+url.QueryEscape(taskQueueId)+"/pending", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListPendingTasks, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListPendingTasks for more details.
+func (queue *Queue) ListPendingTasksPages(ctx context.Context, taskQueueId, limit string) *tcclient.Pages[ListPendingTasksResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListPendingTasksResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListPendingTasks(taskQueueId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** EXPERIMENTAL ***
 //
 // List claimed tasks for the given `taskQueueId`.
@@ -1254,6 +1363,24 @@ func (queue *Queue) ListClaimedTasks_SignedURL(taskQueueId, continuationToken, l
 	return (&cd).SignedURL("/task-queues/"+url.QueryEscape(taskQueueId)+"/claimed", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListClaimedTasks, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListClaimedTasks for more details.
+func (queue *Queue) ListClaimedTasksPages(ctx context.Context, taskQueueId, limit string) *tcclient.Pages[ListClaimedTasksResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListClaimedTasksResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListClaimedTasks(taskQueueId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** DEPRECATED ***
 //
 // Get all active worker-types for the given provisioner.
@@ -1300,6 +1427,24 @@ func (queue *Queue) ListWorkerTypes_SignedURL(provisionerId, continuationToken,
 	return (&cd).SignedURL("/provisioners/"+url.QueryEscape(provisionerId)+"/worker-types", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListWorkerTypes, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListWorkerTypes for more details.
+func (queue *Queue) ListWorkerTypesPages(ctx context.Context, provisionerId, limit string) *tcclient.Pages[ListWorkerTypesResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListWorkerTypesResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListWorkerTypes(provisionerId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** DEPRECATED ***
 //
 // Get a worker-type from a provisioner.
@@ -1391,6 +1536,24 @@ func (queue *Queue) ListTaskQueues_SignedURL(continuationToken, limit string, du
 	return (&cd).SignedURL("/task-queues", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListTaskQueues, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListTaskQueues for more details.
+func (queue *Queue) ListTaskQueuesPages(ctx context.Context, limit string) *tcclient.Pages[ListTaskQueuesResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListTaskQueuesResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListTaskQueues(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Get a task queue.
 //
 // Required scopes:
@@ -1472,6 +1635,24 @@ func (queue *Queue) ListWorkers_SignedURL(provisionerId, workerType, continuatio
 	return (&cd).SignedURL("/provisioners/"+url.QueryEscape(provisionerId)+"/worker-types/"+url.QueryEscape(workerType)+"/workers", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListWorkers, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListWorkers for more details.
+func (queue *Queue) ListWorkersPages(ctx context.Context, provisionerId, workerType, limit, quarantined string) *tcclient.Pages[ListWorkersResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListWorkersResponse, string, error) {
+		c := *queue
+		c.Context = ctx
+		response, err := c.ListWorkers(provisionerId, workerType, continuationToken, limit, quarantined)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** DEPRECATED ***
 //
 // Get a worker from a worker-type.
@@ -1543,3 +1724,57 @@ func (queue *Queue) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// QueueAPI is the interface implemented by *Queue, with a method for
+// each API method.  Code that accepts QueueAPI rather than *Queue can be
+// tested with MockQueue.
+type QueueAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	Task(taskId string) (*TaskDefinitionResponse, error)
+	Status(taskId string) (*TaskStatusResponse, error)
+	ListTaskGroup(taskGroupId, continuationToken, limit string) (*ListTaskGroupResponse, error)
+	CancelTaskGroup(taskGroupId string) (*CancelTaskGroupResponse, error)
+	GetTaskGroup(taskGroupId string) (*TaskGroupDefinitionResponse, error)
+	SealTaskGroup(taskGroupId string) (*TaskGroupDefinitionResponse, error)
+	ListDependentTasks(taskId, continuationToken, limit string) (*ListDependentTasksResponse, error)
+	CreateTask(taskId string, payload *TaskDefinitionRequest) (*TaskStatusResponse, error)
+	ScheduleTask(taskId string) (*TaskStatusResponse, error)
+	RerunTask(taskId string) (*TaskStatusResponse, error)
+	CancelTask(taskId string) (*TaskStatusResponse, error)
+	ClaimWork(taskQueueId string, payload *ClaimWorkRequest) (*ClaimWorkResponse, error)
+	ClaimTask(taskId, runId string, payload *TaskClaimRequest) (*TaskClaimResponse, error)
+	ReclaimTask(taskId, runId string) (*TaskReclaimResponse, error)
+	ReportCompleted(taskId, runId string) (*TaskStatusResponse, error)
+	ReportFailed(taskId, runId string) (*TaskStatusResponse, error)
+	ReportException(taskId, runId string, payload *TaskExceptionRequest) (*TaskStatusResponse, error)
+	CreateArtifact(taskId, runId, name string, payload *PostArtifactRequest) (*PostArtifactResponse, error)
+	FinishArtifact(taskId, runId, name string, payload *FinishArtifactRequest) error
+	GetArtifact(taskId, runId, name string) (*GetArtifactResponse, error)
+	GetLatestArtifact(taskId, name string) (*GetArtifactResponse, error)
+	ListArtifacts(taskId, runId, continuationToken, limit string) (*ListArtifactsResponse, error)
+	ListLatestArtifacts(taskId, continuationToken, limit string) (*ListArtifactsResponse, error)
+	ArtifactInfo(taskId, runId, name string) (*Artifact, error)
+	LatestArtifactInfo(taskId, name string) (*Artifact, error)
+	Artifact(taskId, runId, name string) (*GetArtifactContentResponse, error)
+	LatestArtifact(taskId, name string) (*GetArtifactContentResponse, error)
+	ListProvisioners(continuationToken, limit string) (*ListProvisionersResponse, error)
+	GetProvisioner(provisionerId string) (*ProvisionerResponse, error)
+	DeclareProvisioner(provisionerId string, payload *ProvisionerRequest) (*ProvisionerResponse, error)
+	PendingTasks(taskQueueId string) (*CountPendingTasksResponse, error)
+	ListPendingTasks(taskQueueId, continuationToken, limit string) (*ListPendingTasksResponse, error)
+	ListClaimedTasks(taskQueueId, continuationToken, limit string) (*ListClaimedTasksResponse, error)
+	ListWorkerTypes(provisionerId, continuationToken, limit string) (*ListWorkerTypesResponse, error)
+	GetWorkerType(provisionerId, workerType string) (*WorkerTypeResponse, error)
+	DeclareWorkerType(provisionerId, workerType string, payload *WorkerTypeRequest) (*WorkerTypeResponse, error)
+	ListTaskQueues(continuationToken, limit string) (*ListTaskQueuesResponse, error)
+	GetTaskQueue(taskQueueId string) (*TaskQueueResponse, error)
+	ListWorkers(provisionerId, workerType, continuationToken, limit, quarantined string) (*ListWorkersResponse, error)
+	GetWorker(provisionerId, workerType, workerGroup, workerId string) (*WorkerResponse, error)
+	QuarantineWorker(provisionerId, workerType, workerGroup, workerId string, payload *QuarantineWorkerRequest) (*WorkerResponse, error)
+	DeclareWorker(provisionerId, workerType, workerGroup, workerId string, payload *WorkerRequest) (*WorkerResponse, error)
+	Heartbeat() error
+}
+
+var _ QueueAPI = (*Queue)(nil)
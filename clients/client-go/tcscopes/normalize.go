@@ -0,0 +1,94 @@
+package tcscopes
+
+import (
+	"sort"
+	"strings"
+)
+
+// Returns the normal form of the given scopes: sorted, without duplicates,
+// and without any scope that is satisfied by another scope in the set.  For
+// example, the normal form of
+//
+//	{"queue:create-task:*", "queue:create-task:proj/ci", "abc", "abc"}
+//
+// is
+//
+//	{"abc", "queue:create-task:*"}
+//
+// Two sets of scopes with the same normal form satisfy exactly the same
+// required scopes.
+func Normalize(scopes []string) Given {
+	sorted := append([]string{}, scopes...)
+	sort.Strings(sorted)
+	result := Given{}
+	for i, scope := range sorted {
+		if i > 0 && scope == sorted[i-1] {
+			continue
+		}
+		redundant := false
+		for _, other := range sorted {
+			if other != scope && strings.HasSuffix(other, "*") && (Given{other}).SatisfiesScope(scope) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
+// Returns the normal form of the union of the given sets of scopes.
+func Merge(sets ...Given) Given {
+	all := []string{}
+	for _, set := range sets {
+		all = append(all, set...)
+	}
+	return Normalize(all)
+}
+
+// Returns the normal form of the scopes satisfied by both a and b, without
+// expanding either.  For example, the intersection of {"queue:*"} and
+// {"queue:create-task:*", "index:*"} is {"queue:create-task:*"}.
+func Intersection(a, b Given) Given {
+	both := []string{}
+	for _, scope := range a {
+		if b.SatisfiesScope(scope) {
+			both = append(both, scope)
+		}
+	}
+	for _, scope := range b {
+		if a.SatisfiesScope(scope) {
+			both = append(both, scope)
+		}
+	}
+	return Normalize(both)
+}
+
+// Returns the scopes of a parameterized role with each `<..>` replaced by
+// param, the part of the role name matched by the `*` at the end of the
+// role's roleId.  As in the auth service, if param ends with `*`, anything
+// following `<..>` in a scope is dropped, so that the resulting scope ends
+// with param.  For example, for a role `repo:github.com/*` with scope
+// `secrets:get:project/<..>/ci`:
+//
+//	Parameterize([]string{"secrets:get:project/<..>/ci"}, "my-repo")
+//
+// gives `secrets:get:project/my-repo/ci`, and a param of `my-*` gives
+// `secrets:get:project/my-*`.
+func Parameterize(scopes []string, param string) Given {
+	result := make(Given, len(scopes))
+	for i, scope := range scopes {
+		before, after, found := strings.Cut(scope, "<..>")
+		switch {
+		case !found:
+			result[i] = scope
+		case strings.HasSuffix(param, "*"):
+			result[i] = before + param
+		default:
+			result[i] = before + param + after
+		}
+	}
+	return result
+}
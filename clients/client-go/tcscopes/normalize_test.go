@@ -0,0 +1,30 @@
+package tcscopes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, Given{"abc", "queue:create-task:*"}, Normalize([]string{"queue:create-task:*", "queue:create-task:proj/ci", "abc", "abc"}))
+	require.Equal(t, Given{"*"}, Normalize([]string{"a", "b:*", "*"}))
+	require.Equal(t, Given{"a*"}, Normalize([]string{"a*", "a", "ab"}))
+	require.Equal(t, Given{}, Normalize(nil))
+}
+
+func TestMerge(t *testing.T) {
+	require.Equal(t, Given{"index:*", "queue:*"}, Merge(Given{"queue:get-task:*", "index:*"}, Given{"queue:*"}))
+}
+
+func TestIntersection(t *testing.T) {
+	require.Equal(t, Given{"queue:create-task:*"}, Intersection(Given{"queue:*"}, Given{"queue:create-task:*", "index:*"}))
+	require.Equal(t, Given{"a:b", "c"}, Intersection(Given{"a:*", "c"}, Given{"a:b", "c", "d"}))
+	require.Equal(t, Given{}, Intersection(Given{"a"}, Given{"b"}))
+}
+
+func TestParameterize(t *testing.T) {
+	scopes := []string{"secrets:get:project/<..>/ci", "queue:route:index.<..>.*", "plain"}
+	require.Equal(t, Given{"secrets:get:project/my-repo/ci", "queue:route:index.my-repo.*", "plain"}, Parameterize(scopes, "my-repo"))
+	require.Equal(t, Given{"secrets:get:project/my-*", "queue:route:index.my-*", "plain"}, Parameterize(scopes, "my-*"))
+}
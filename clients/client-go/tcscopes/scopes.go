@@ -1,9 +1,9 @@
-// Package scopes provides utilities for manipulating and interpreting
+// Package tcscopes provides utilities for manipulating and interpreting
 // Taskcluster scopes.
 //
 // See https://docs.taskcluster.net/presentations/scopes/#/definitions for
 // formal definitions.
-package scopes
+package tcscopes
 
 import (
 	"strings"
@@ -70,13 +70,9 @@ func (given Given) Satisfies(required Required, scopeExpander ScopeExpander) (bo
 			// inner loop - all scopes have to pass in order to pass scope set
 			for _, scope := range set {
 				// just need to find one given scope to satisfy required scope
-				for _, pattern := range given {
-					if scope == pattern || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(scope, pattern[0:len(pattern)-1])) {
-						goto scopeMatch
-					}
+				if !given.SatisfiesScope(scope) {
+					continue checkRequired
 				}
-				continue checkRequired
-			scopeMatch:
 			}
 			return true
 		}
@@ -95,13 +91,24 @@ func (given Given) Satisfies(required Required, scopeExpander ScopeExpander) (bo
 	return checkFunc(expandedGiven, required), nil
 }
 
+// Returns `true` if any of the given scopes satisfies the required scope,
+// without expanding given.
+func (given Given) SatisfiesScope(scope string) bool {
+	for _, pattern := range given {
+		if scope == pattern || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(scope, pattern[0:len(pattern)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
 func (given Given) Expand(scopeExpander ScopeExpander) (expanded Given, err error) {
 	for _, scope := range given {
 		if strings.HasPrefix(scope, "assume:") {
 			goto hasAssume
 		}
 	}
-	expanded = make(Given, 0, len(given))
+	expanded = make(Given, len(given))
 	copy(expanded, given)
 	return
 
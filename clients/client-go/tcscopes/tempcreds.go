@@ -0,0 +1,124 @@
+package tcscopes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+)
+
+// UnsatisfiedScopesError is returned when temporary credentials are requested
+// with scopes that the issuing client does not have, and which the auth
+// service would therefore reject.
+type UnsatisfiedScopesError struct {
+	// IssuerClientID is the clientId of the credentials issuing the
+	// temporary credentials.
+	IssuerClientID string
+	// Unsatisfied lists the requested scopes that the issuer does not
+	// have, in the order they were requested.
+	Unsatisfied []string
+}
+
+func (err *UnsatisfiedScopesError) Error() string {
+	return fmt.Sprintf(
+		"client %q cannot issue temporary credentials with the requested scopes, since it does not have:\n  %s",
+		err.IssuerClientID,
+		strings.Join(err.Unsatisfied, "\n  "),
+	)
+}
+
+// Returns the scopes, in order, which are not satisfied by the given scopes.
+// The given scopes are only expanded if some scope is not satisfied by the
+// given scopes directly.
+func (given Given) Unsatisfied(scopes []string, scopeExpander ScopeExpander) ([]string, error) {
+	unsatisfied := given.unsatisfied(scopes)
+	if len(unsatisfied) == 0 {
+		return nil, nil
+	}
+	expandedGiven, err := given.Expand(scopeExpander)
+	if err != nil {
+		return nil, err
+	}
+	return expandedGiven.unsatisfied(unsatisfied), nil
+}
+
+func (given Given) unsatisfied(scopes []string) (unsatisfied []string) {
+	for _, scope := range scopes {
+		if !given.SatisfiesScope(scope) {
+			unsatisfied = append(unsatisfied, scope)
+		}
+	}
+	return
+}
+
+// CreateNamedTemporaryCredentials creates temporary credentials from issuer,
+// as (*tcclient.Credentials).CreateNamedTemporaryCredentials does, after
+// checking that issuerScopes satisfy the requested scopes. If they do not, an
+// *UnsatisfiedScopesError listing the missing scopes is returned, rather than
+// credentials that the auth service would reject.
+//
+// Named temporary credentials additionally require the issuer to have scope
+// auth:create-client:<tempClientID>. If issuer has authorized scopes, those
+// must also satisfy the requested scopes.
+func CreateNamedTemporaryCredentials(issuer *tcclient.Credentials, issuerScopes Given, scopeExpander ScopeExpander, tempClientID string, duration time.Duration, scopes ...string) (*tcclient.Credentials, error) {
+	required := scopes
+	if tempClientID != "" {
+		required = append([]string{"auth:create-client:" + tempClientID}, scopes...)
+	}
+	unsatisfied, err := issuerScopes.Unsatisfied(required, scopeExpander)
+	if err != nil {
+		return nil, err
+	}
+	if issuer.AuthorizedScopes != nil {
+		// a scope is only available if both the client and the
+		// authorized scopes have it
+		notAuthorized, err := Given(issuer.AuthorizedScopes).Unsatisfied(required, scopeExpander)
+		if err != nil {
+			return nil, err
+		}
+		for _, scope := range required {
+			if contains(notAuthorized, scope) && !contains(unsatisfied, scope) {
+				unsatisfied = append(unsatisfied, scope)
+			}
+		}
+	}
+	if len(unsatisfied) > 0 {
+		return nil, &UnsatisfiedScopesError{
+			IssuerClientID: issuer.ClientID,
+			Unsatisfied:    unsatisfied,
+		}
+	}
+	return issuer.CreateNamedTemporaryCredentials(tempClientID, duration, scopes...)
+}
+
+// CreateTemporaryCredentials is an alias for CreateNamedTemporaryCredentials
+// with an empty name.
+func CreateTemporaryCredentials(issuer *tcclient.Credentials, issuerScopes Given, scopeExpander ScopeExpander, duration time.Duration, scopes ...string) (*tcclient.Credentials, error) {
+	return CreateNamedTemporaryCredentials(issuer, issuerScopes, scopeExpander, "", duration, scopes...)
+}
+
+// CreateNamedTemporaryCredentialsFor creates temporary credentials from the
+// credentials of the given auth client, as CreateNamedTemporaryCredentials
+// does, fetching the issuer's scopes from the auth service with
+// auth.CurrentScopes().
+func CreateNamedTemporaryCredentialsFor(auth *tcauth.Auth, tempClientID string, duration time.Duration, scopes ...string) (*tcclient.Credentials, error) {
+	if auth.Credentials == nil {
+		return nil, fmt.Errorf("cannot create temporary credentials without credentials")
+	}
+	current, err := auth.CurrentScopes()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch scopes of client %q: %w", auth.Credentials.ClientID, err)
+	}
+	return CreateNamedTemporaryCredentials(auth.Credentials, Given(current.Scopes), auth, tempClientID, duration, scopes...)
+}
+
+func contains(list []string, item string) bool {
+	for _, i := range list {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package tcscopes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+)
+
+// fakeExpander expands roles from a fixed map, without recursion
+type fakeExpander map[string][]string
+
+func (roles fakeExpander) ExpandScopes(scopes *tcauth.SetOfScopes) (*tcauth.SetOfScopes, error) {
+	result := &tcauth.SetOfScopes{}
+	for _, scope := range scopes.Scopes {
+		result.Scopes = append(result.Scopes, scope)
+		if role, ok := strings.CutPrefix(scope, "assume:"); ok {
+			result.Scopes = append(result.Scopes, roles[role]...)
+		}
+	}
+	return result, nil
+}
+
+func issuer() *tcclient.Credentials {
+	return &tcclient.Credentials{
+		ClientID:    "issuer",
+		AccessToken: "no-secret",
+	}
+}
+
+func TestUnsatisfied(t *testing.T) {
+	given := Given{"queue:*", "assume:project:foo"}
+	expander := fakeExpander{"project:foo": {"secrets:get:foo/*"}}
+	unsatisfied, err := given.Unsatisfied([]string{"secrets:get:foo/bar", "queue:create-task:x", "index:insert-task:y", "secrets:get:bar"}, expander)
+	require.NoError(t, err)
+	require.Equal(t, []string{"index:insert-task:y", "secrets:get:bar"}, unsatisfied)
+}
+
+func TestUnsatisfiedNoExpansionNeeded(t *testing.T) {
+	// a nil expander would panic if expansion were attempted
+	unsatisfied, err := Given{"queue:*", "assume:project:foo"}.Unsatisfied([]string{"queue:create-task:x"}, nil)
+	require.NoError(t, err)
+	require.Empty(t, unsatisfied)
+}
+
+func TestExpandWithoutRoles(t *testing.T) {
+	expanded, err := Given{"a", "b:*"}.Expand(nil)
+	require.NoError(t, err)
+	require.Equal(t, Given{"a", "b:*"}, expanded)
+}
+
+func TestCreateTemporaryCredentialsSatisfied(t *testing.T) {
+	creds, err := CreateTemporaryCredentials(issuer(), Given{"queue:*"}, nil, time.Hour, "queue:create-task:x")
+	require.NoError(t, err)
+	require.Equal(t, "issuer", creds.ClientID)
+	require.Contains(t, creds.Certificate, "queue:create-task:x")
+}
+
+func TestCreateNamedTemporaryCredentialsSatisfied(t *testing.T) {
+	creds, err := CreateNamedTemporaryCredentials(issuer(), Given{"queue:*", "auth:create-client:temp/*"}, nil, "temp/1", time.Hour, "queue:create-task:x")
+	require.NoError(t, err)
+	require.Equal(t, "temp/1", creds.ClientID)
+}
+
+func TestCreateNamedTemporaryCredentialsUnsatisfied(t *testing.T) {
+	_, err := CreateNamedTemporaryCredentials(issuer(), Given{"queue:*"}, fakeExpander{}, "temp/1", time.Hour, "queue:create-task:x", "secrets:get:x")
+	var unsatisfied *UnsatisfiedScopesError
+	require.True(t, errors.As(err, &unsatisfied))
+	require.Equal(t, "issuer", unsatisfied.IssuerClientID)
+	require.Equal(t, []string{"auth:create-client:temp/1", "secrets:get:x"}, unsatisfied.Unsatisfied)
+	require.Contains(t, err.Error(), "secrets:get:x")
+}
+
+func TestCreateTemporaryCredentialsAuthorizedScopes(t *testing.T) {
+	creds := issuer()
+	creds.AuthorizedScopes = []string{"queue:create-task:x"}
+	_, err := CreateTemporaryCredentials(creds, Given{"queue:*"}, fakeExpander{}, time.Hour, "queue:create-task:x", "queue:create-task:y")
+	var unsatisfied *UnsatisfiedScopesError
+	require.True(t, errors.As(err, &unsatisfied))
+	require.Equal(t, []string{"queue:create-task:y"}, unsatisfied.Unsatisfied)
+}
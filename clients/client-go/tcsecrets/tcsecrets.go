@@ -40,6 +40,7 @@
 package tcsecrets
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -230,6 +231,24 @@ func (secrets *Secrets) List_SignedURL(continuationToken, limit string, duration
 	return (&cd).SignedURL("/secrets", v, duration)
 }
 
+// Returns an iterator over the pages of results of List, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See List for more details.
+func (secrets *Secrets) ListPages(ctx context.Context, limit string) *tcclient.Pages[SecretsList] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*SecretsList, string, error) {
+		c := *secrets
+		c.Context = ctx
+		response, err := c.List(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Respond with a service heartbeat.
 //
 // This endpoint is used to check on backing services this service
@@ -241,3 +260,19 @@ func (secrets *Secrets) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// SecretsAPI is the interface implemented by *Secrets, with a method for
+// each API method.  Code that accepts SecretsAPI rather than *Secrets can be
+// tested with MockSecrets.
+type SecretsAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	Set(name string, payload *Secret) error
+	Remove(name string) error
+	Get(name string) (*Secret, error)
+	List(continuationToken, limit string) (*SecretsList, error)
+	Heartbeat() error
+}
+
+var _ SecretsAPI = (*Secrets)(nil)
@@ -0,0 +1,336 @@
+// Package tctask builds task definitions for the queue's createTask method,
+// filling in defaults and validating the result before it is submitted.  It
+// is intended to cut the boilerplate from Go programs that create many tasks,
+// such as decision tasks.
+//
+//	build := tctask.New("proj-example/ci", "build").
+//		Description("Build the project").
+//		Owner("ci@example.com").
+//		Source("https://github.com/example/project").
+//		Payload(payload)
+//	test := tctask.New("proj-example/ci", "test").
+//		Description("Test the build").
+//		Owner("ci@example.com").
+//		Source("https://github.com/example/project").
+//		DependsOn(build).
+//		Route("index.project.example.test.latest").
+//		Payload(payload)
+//	for _, task := range []*tctask.Builder{build, test} {
+//		if _, err := task.Submit(queue); err != nil {
+//			...
+//		}
+//	}
+package tctask
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/taskcluster/slugid-go/slugid"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+)
+
+// DefaultDeadline is the time after creation by which a task must be
+// complete, if no deadline is given
+const DefaultDeadline = 24 * time.Hour
+
+var (
+	slugIDPattern      = regexp.MustCompile(`^[A-Za-z0-9_-]{8}[Q-T][A-Za-z0-9_-][CGKOSWaeimquy26-][A-Za-z0-9_-]{10}[AQgw]$`)
+	taskQueueIDPattern = regexp.MustCompile(`^[a-zA-Z0-9-_]{1,38}/[a-z]([-a-z0-9]{0,36}[a-z0-9])?$`)
+	identifierPattern  = regexp.MustCompile(`^[a-zA-Z0-9-_]{1,38}$`)
+	projectIDPattern   = regexp.MustCompile(`^[a-zA-Z0-9._/-]{1,500}$`)
+	sourcePattern      = regexp.MustCompile(`^(https?://|ssh://|git@)`)
+	scopePattern       = regexp.MustCompile(`^[ -~]*$`)
+)
+
+// Builder builds a task definition.  Its methods return the Builder, so that
+// calls can be chained; problems such as payloads that cannot be marshaled
+// are reported by Build.
+type Builder struct {
+	taskID     string
+	definition tcqueue.TaskDefinitionRequest
+	deadline   time.Duration
+	expires    time.Duration
+	errs       []error
+}
+
+// New returns a Builder for a task with a new taskId, in the given task
+// queue, with the given name.  The task is created now, with a deadline of
+// DefaultDeadline, unless other values are given.
+func New(taskQueueID, name string) *Builder {
+	return &Builder{
+		taskID: slugid.Nice(),
+		definition: tcqueue.TaskDefinitionRequest{
+			Created:     tcclient.Time(time.Now()),
+			TaskQueueID: taskQueueID,
+			Metadata: tcqueue.TaskMetadata{
+				Name: name,
+			},
+			Payload: json.RawMessage(`{}`),
+		},
+		deadline: DefaultDeadline,
+	}
+}
+
+// TaskID returns the taskId of the task, which is generated by New
+func (b *Builder) TaskID() string {
+	return b.taskID
+}
+
+// Description sets the description of the task
+func (b *Builder) Description(description string) *Builder {
+	b.definition.Metadata.Description = description
+	return b
+}
+
+// Owner sets the entity responsible for the task, such as an email address
+func (b *Builder) Owner(owner string) *Builder {
+	b.definition.Metadata.Owner = owner
+	return b
+}
+
+// Source sets the link to the source of the task
+func (b *Builder) Source(source string) *Builder {
+	b.definition.Metadata.Source = source
+	return b
+}
+
+// TaskGroupID sets the task group of the task; by default, the queue uses the
+// taskId of the task
+func (b *Builder) TaskGroupID(taskGroupID string) *Builder {
+	b.definition.TaskGroupID = taskGroupID
+	return b
+}
+
+// SchedulerID sets the scheduler of the task
+func (b *Builder) SchedulerID(schedulerID string) *Builder {
+	b.definition.SchedulerID = schedulerID
+	return b
+}
+
+// ProjectID sets the project with which the task is associated
+func (b *Builder) ProjectID(projectID string) *Builder {
+	b.definition.ProjectID = projectID
+	return b
+}
+
+// Created sets the creation time of the task, from which the deadline and
+// expiry are measured
+func (b *Builder) Created(created time.Time) *Builder {
+	b.definition.Created = tcclient.Time(created)
+	return b
+}
+
+// Deadline sets the time after creation by which the task must be complete
+func (b *Builder) Deadline(deadline time.Duration) *Builder {
+	b.deadline = deadline
+	return b
+}
+
+// Expires sets the time after creation at which the task is deleted; by
+// default, the queue uses one year after the deadline
+func (b *Builder) Expires(expires time.Duration) *Builder {
+	b.expires = expires
+	return b
+}
+
+// Payload sets the payload of the task, marshaling it to JSON
+func (b *Builder) Payload(payload interface{}) *Builder {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("cannot marshal payload: %w", err))
+		return b
+	}
+	b.definition.Payload = data
+	return b
+}
+
+// Extra sets the extra data of the task, marshaling it to JSON
+func (b *Builder) Extra(extra interface{}) *Builder {
+	data, err := json.Marshal(extra)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("cannot marshal extra: %w", err))
+		return b
+	}
+	b.definition.Extra = data
+	return b
+}
+
+// Route adds routes to which pulse messages about the task are CC'd
+func (b *Builder) Route(routes ...string) *Builder {
+	b.definition.Routes = appendNew(b.definition.Routes, routes...)
+	return b
+}
+
+// Scope adds scopes that the task may use
+func (b *Builder) Scope(scopes ...string) *Builder {
+	b.definition.Scopes = appendNew(b.definition.Scopes, scopes...)
+	return b
+}
+
+// Tag sets a tag of the task
+func (b *Builder) Tag(key, value string) *Builder {
+	if b.definition.Tags == nil {
+		b.definition.Tags = map[string]string{}
+	}
+	b.definition.Tags[key] = value
+	return b
+}
+
+// DependsOn makes the task depend on the tasks being built by the given
+// Builders
+func (b *Builder) DependsOn(tasks ...*Builder) *Builder {
+	for _, task := range tasks {
+		b.definition.Dependencies = appendNew(b.definition.Dependencies, task.taskID)
+	}
+	return b
+}
+
+// DependsOnTaskID makes the task depend on the tasks with the given taskIds
+func (b *Builder) DependsOnTaskID(taskIDs ...string) *Builder {
+	b.definition.Dependencies = appendNew(b.definition.Dependencies, taskIDs...)
+	return b
+}
+
+// Requires sets how the task depends on its dependencies: "all-completed"
+// (the default) or "all-resolved"
+func (b *Builder) Requires(requires string) *Builder {
+	b.definition.Requires = requires
+	return b
+}
+
+// Priority sets the priority of the task, such as "high"
+func (b *Builder) Priority(priority string) *Builder {
+	b.definition.Priority = priority
+	return b
+}
+
+// Retries sets the number of times the task is retried after infrastructure
+// failures.  As zero is omitted from the definition, the queue's default of 5
+// is used in that case.
+func (b *Builder) Retries(retries int64) *Builder {
+	b.definition.Retries = retries
+	return b
+}
+
+// appendNew appends the values that are not already in list
+func appendNew(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// Build validates and returns the task definition.  The error, if any,
+// describes all of the problems found.
+func (b *Builder) Build() (*tcqueue.TaskDefinitionRequest, error) {
+	definition := b.definition
+	created := time.Time(definition.Created)
+	definition.Deadline = tcclient.Time(created.Add(b.deadline))
+	if b.expires != 0 {
+		definition.Expires = tcclient.Time(created.Add(b.expires))
+	}
+
+	errs := append([]error{}, b.errs...)
+	problem := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+	if !taskQueueIDPattern.MatchString(definition.TaskQueueID) {
+		problem("invalid taskQueueId %q", definition.TaskQueueID)
+	}
+	if definition.TaskGroupID != "" && !slugIDPattern.MatchString(definition.TaskGroupID) {
+		problem("invalid taskGroupId %q", definition.TaskGroupID)
+	}
+	if definition.SchedulerID != "" && !identifierPattern.MatchString(definition.SchedulerID) {
+		problem("invalid schedulerId %q", definition.SchedulerID)
+	}
+	if definition.ProjectID != "" && !projectIDPattern.MatchString(definition.ProjectID) {
+		problem("invalid projectId %q", definition.ProjectID)
+	}
+	metadata := definition.Metadata
+	if len(metadata.Name) > 255 {
+		problem("name cannot be longer than 255 characters")
+	}
+	if len(metadata.Description) > 32768 {
+		problem("description cannot be longer than 32768 characters")
+	}
+	if len(metadata.Owner) > 255 {
+		problem("owner cannot be longer than 255 characters")
+	}
+	if !sourcePattern.MatchString(metadata.Source) || len(metadata.Source) > 4096 {
+		problem("invalid source %q: must be a URL of at most 4096 characters", metadata.Source)
+	}
+	if b.deadline <= 0 {
+		problem("deadline must be after creation")
+	}
+	if b.expires != 0 && b.expires < b.deadline {
+		problem("expires cannot be before deadline")
+	}
+	if len(definition.Dependencies) > 100 {
+		problem("at most 100 dependencies are allowed")
+	}
+	for _, dependency := range definition.Dependencies {
+		if !slugIDPattern.MatchString(dependency) {
+			problem("invalid dependency %q", dependency)
+		}
+		if dependency == b.taskID {
+			problem("task cannot depend on itself")
+		}
+	}
+	switch definition.Requires {
+	case "", "all-completed", "all-resolved":
+	default:
+		problem("invalid requires %q", definition.Requires)
+	}
+	switch definition.Priority {
+	case "", "highest", "very-high", "high", "medium", "low", "very-low", "lowest", "normal":
+	default:
+		problem("invalid priority %q", definition.Priority)
+	}
+	if definition.Retries < 0 || definition.Retries > 49 {
+		problem("retries must be 0 to 49")
+	}
+	if len(definition.Routes) > 64 {
+		problem("at most 64 routes are allowed")
+	}
+	for _, route := range definition.Routes {
+		if route == "" || len(route) > 249 {
+			problem("route %q must be 1 to 249 characters", route)
+		}
+	}
+	for _, scope := range definition.Scopes {
+		if !scopePattern.MatchString(scope) {
+			problem("invalid scope %q", scope)
+		}
+	}
+	if len(definition.Payload) == 0 || definition.Payload[0] != '{' {
+		problem("payload must be a JSON object")
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid task definition for task %s: %w", b.taskID, errors.Join(errs...))
+	}
+	return &definition, nil
+}
+
+// Submit validates the task definition and creates the task
+func (b *Builder) Submit(queue tcqueue.QueueAPI) (*tcqueue.TaskStatusResponse, error) {
+	definition, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return queue.CreateTask(b.taskID, definition)
+}
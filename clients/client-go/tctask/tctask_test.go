@@ -0,0 +1,114 @@
+package tctask_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/slugid-go/slugid"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tctask"
+)
+
+func newTask(name string) *tctask.Builder {
+	return tctask.New("proj-example/ci", name).
+		Description("a test task").
+		Owner("tester@example.com").
+		Source("https://github.com/example/project")
+}
+
+func TestBuild(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	build := newTask("build")
+	test := newTask("test").
+		Created(created).
+		Deadline(2*time.Hour).
+		Expires(30*24*time.Hour).
+		DependsOn(build).
+		DependsOnTaskID(build.TaskID()).
+		Route("index.a", "index.b", "index.a").
+		Scope("secrets:get:a").
+		Tag("kind", "test").
+		Priority("high").
+		Retries(2).
+		Payload(map[string]interface{}{"command": []string{"make", "test"}}).
+		Extra(map[string]string{"a": "b"})
+
+	definition, err := test.Build()
+	require.NoError(t, err)
+	require.Equal(t, created, time.Time(definition.Created))
+	require.Equal(t, created.Add(2*time.Hour), time.Time(definition.Deadline))
+	require.Equal(t, created.Add(30*24*time.Hour), time.Time(definition.Expires))
+	require.Equal(t, []string{build.TaskID()}, definition.Dependencies)
+	require.Equal(t, []string{"index.a", "index.b"}, definition.Routes)
+	require.Equal(t, []string{"secrets:get:a"}, definition.Scopes)
+	require.Equal(t, map[string]string{"kind": "test"}, definition.Tags)
+	require.Equal(t, "high", definition.Priority)
+	require.Equal(t, int64(2), definition.Retries)
+	require.JSONEq(t, `{"command": ["make", "test"]}`, string(definition.Payload))
+	require.JSONEq(t, `{"a": "b"}`, string(definition.Extra))
+	require.Equal(t, tcqueue.TaskMetadata{
+		Name:        "test",
+		Description: "a test task",
+		Owner:       "tester@example.com",
+		Source:      "https://github.com/example/project",
+	}, definition.Metadata)
+}
+
+func TestBuildDefaults(t *testing.T) {
+	definition, err := newTask("build").Build()
+	require.NoError(t, err)
+	require.Equal(t, tctask.DefaultDeadline, time.Time(definition.Deadline).Sub(time.Time(definition.Created)))
+	require.True(t, time.Time(definition.Expires).IsZero())
+	require.Equal(t, json.RawMessage(`{}`), definition.Payload)
+}
+
+func TestBuildInvalid(t *testing.T) {
+	task := tctask.New("not a task queue", "build").
+		Source("file:///tmp").
+		Deadline(time.Hour).
+		Expires(time.Minute).
+		DependsOnTaskID("abc").
+		Priority("urgent").
+		Retries(50).
+		Route("").
+		Payload(math.Inf(1))
+	task.DependsOnTaskID(task.TaskID())
+
+	_, err := task.Build()
+	require.Error(t, err)
+	for _, problem := range []string{
+		"cannot marshal payload",
+		"invalid taskQueueId",
+		"invalid source",
+		"expires cannot be before deadline",
+		`invalid dependency "abc"`,
+		"task cannot depend on itself",
+		`invalid priority "urgent"`,
+		"retries must be 0 to 49",
+		`route "" must be 1 to 249 characters`,
+	} {
+		require.ErrorContains(t, err, problem)
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	queue := tcqueue.NewFakeQueue()
+	taskGroupID := slugid.Nice()
+	build := newTask("build").TaskGroupID(taskGroupID)
+	test := newTask("test").TaskGroupID(taskGroupID).DependsOn(build)
+	for _, task := range []*tctask.Builder{build, test} {
+		_, err := task.Submit(queue)
+		require.NoError(t, err)
+	}
+
+	task, err := queue.Task(test.TaskID())
+	require.NoError(t, err)
+	require.Equal(t, []string{build.TaskID()}, task.Dependencies)
+
+	_, err = newTask("invalid").Priority("urgent").Submit(queue)
+	require.Error(t, err)
+	require.Len(t, queue.CallsTo("CreateTask"), 2)
+}
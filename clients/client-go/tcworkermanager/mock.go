@@ -0,0 +1,251 @@
+// The following code is AUTO-GENERATED. Please DO NOT edit.
+// To update this generated code, run the following command:
+// in the /codegenerator/model subdirectory of this project,
+// making sure that `${GOPATH}/bin` is in your `PATH`:
+//
+// go install && go generate
+
+package tcworkermanager
+
+import (
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// MockWorkerManager is a mock implementation of WorkerManagerAPI, for use in tests.  Each method
+// records the call, then calls the function in the corresponding `<Method>Func` field.
+// If that field is nil, the method returns a *tcclient.NotMockedError.
+type MockWorkerManager struct {
+	tcclient.MockCalls
+	PingFunc                      func() error
+	LbheartbeatFunc               func() error
+	VersionFunc                   func() error
+	ListProvidersFunc             func(continuationToken, limit string) (*ProviderList, error)
+	CreateWorkerPoolFunc          func(workerPoolId string, payload *WorkerPoolDefinition) (*WorkerPoolFullDefinition, error)
+	UpdateWorkerPoolFunc          func(workerPoolId string, payload *WorkerPoolDefinition1) (*WorkerPoolFullDefinition, error)
+	DeleteWorkerPoolFunc          func(workerPoolId string) (*WorkerPoolFullDefinition, error)
+	WorkerPoolFunc                func(workerPoolId string) (*WorkerPoolFullDefinition, error)
+	ListWorkerPoolsFunc           func(continuationToken, limit string) (*WorkerPoolList, error)
+	ReportWorkerErrorFunc         func(workerPoolId string, payload *WorkerErrorReport) (*WorkerPoolError, error)
+	WorkerPoolErrorStatsFunc      func(workerPoolId string) (*WorkerPoolErrorStats, error)
+	ListWorkerPoolErrorsFunc      func(workerPoolId, continuationToken, limit string) (*WorkerPoolErrorList, error)
+	ListWorkersForWorkerGroupFunc func(workerPoolId, workerGroup, continuationToken, limit string) (*WorkerListInAGivenWorkerPool, error)
+	WorkerFunc                    func(workerPoolId, workerGroup, workerId string) (*WorkerFullDefinition, error)
+	CreateWorkerFunc              func(workerPoolId, workerGroup, workerId string, payload *WorkerCreationUpdateRequest) (*WorkerFullDefinition, error)
+	UpdateWorkerFunc              func(workerPoolId, workerGroup, workerId string, payload *WorkerCreationUpdateRequest) (*WorkerFullDefinition, error)
+	RemoveWorkerFunc              func(workerPoolId, workerGroup, workerId string) error
+	ListWorkersForWorkerPoolFunc  func(workerPoolId, continuationToken, limit, state string) (*WorkerListInAGivenWorkerPool, error)
+	RegisterWorkerFunc            func(payload *RegisterWorkerRequest) (*RegisterWorkerResponse, error)
+	ReregisterWorkerFunc          func(payload *ReregisterWorkerRequest) (*ReregisterWorkerResponse, error)
+	ListWorkersFunc               func(provisionerId, workerType, continuationToken, limit, quarantined, workerState string) (*ListWorkersResponse, error)
+	GetWorkerFunc                 func(provisionerId, workerType, workerGroup, workerId string) (*WorkerResponse, error)
+	HeartbeatFunc                 func() error
+}
+
+var _ WorkerManagerAPI = (*MockWorkerManager)(nil)
+
+// Ping records the call and calls PingFunc
+func (m *MockWorkerManager) Ping() error {
+	m.Record("Ping")
+	if m.PingFunc == nil {
+		return &tcclient.NotMockedError{Method: "WorkerManager.Ping"}
+	}
+	return m.PingFunc()
+}
+
+// Lbheartbeat records the call and calls LbheartbeatFunc
+func (m *MockWorkerManager) Lbheartbeat() error {
+	m.Record("Lbheartbeat")
+	if m.LbheartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "WorkerManager.Lbheartbeat"}
+	}
+	return m.LbheartbeatFunc()
+}
+
+// Version records the call and calls VersionFunc
+func (m *MockWorkerManager) Version() error {
+	m.Record("Version")
+	if m.VersionFunc == nil {
+		return &tcclient.NotMockedError{Method: "WorkerManager.Version"}
+	}
+	return m.VersionFunc()
+}
+
+// ListProviders records the call and calls ListProvidersFunc
+func (m *MockWorkerManager) ListProviders(continuationToken, limit string) (*ProviderList, error) {
+	m.Record("ListProviders", continuationToken, limit)
+	if m.ListProvidersFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ListProviders"}
+	}
+	return m.ListProvidersFunc(continuationToken, limit)
+}
+
+// CreateWorkerPool records the call and calls CreateWorkerPoolFunc
+func (m *MockWorkerManager) CreateWorkerPool(workerPoolId string, payload *WorkerPoolDefinition) (*WorkerPoolFullDefinition, error) {
+	m.Record("CreateWorkerPool", workerPoolId, payload)
+	if m.CreateWorkerPoolFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.CreateWorkerPool"}
+	}
+	return m.CreateWorkerPoolFunc(workerPoolId, payload)
+}
+
+// UpdateWorkerPool records the call and calls UpdateWorkerPoolFunc
+func (m *MockWorkerManager) UpdateWorkerPool(workerPoolId string, payload *WorkerPoolDefinition1) (*WorkerPoolFullDefinition, error) {
+	m.Record("UpdateWorkerPool", workerPoolId, payload)
+	if m.UpdateWorkerPoolFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.UpdateWorkerPool"}
+	}
+	return m.UpdateWorkerPoolFunc(workerPoolId, payload)
+}
+
+// DeleteWorkerPool records the call and calls DeleteWorkerPoolFunc
+func (m *MockWorkerManager) DeleteWorkerPool(workerPoolId string) (*WorkerPoolFullDefinition, error) {
+	m.Record("DeleteWorkerPool", workerPoolId)
+	if m.DeleteWorkerPoolFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.DeleteWorkerPool"}
+	}
+	return m.DeleteWorkerPoolFunc(workerPoolId)
+}
+
+// WorkerPool records the call and calls WorkerPoolFunc
+func (m *MockWorkerManager) WorkerPool(workerPoolId string) (*WorkerPoolFullDefinition, error) {
+	m.Record("WorkerPool", workerPoolId)
+	if m.WorkerPoolFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.WorkerPool"}
+	}
+	return m.WorkerPoolFunc(workerPoolId)
+}
+
+// ListWorkerPools records the call and calls ListWorkerPoolsFunc
+func (m *MockWorkerManager) ListWorkerPools(continuationToken, limit string) (*WorkerPoolList, error) {
+	m.Record("ListWorkerPools", continuationToken, limit)
+	if m.ListWorkerPoolsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ListWorkerPools"}
+	}
+	return m.ListWorkerPoolsFunc(continuationToken, limit)
+}
+
+// ReportWorkerError records the call and calls ReportWorkerErrorFunc
+func (m *MockWorkerManager) ReportWorkerError(workerPoolId string, payload *WorkerErrorReport) (*WorkerPoolError, error) {
+	m.Record("ReportWorkerError", workerPoolId, payload)
+	if m.ReportWorkerErrorFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ReportWorkerError"}
+	}
+	return m.ReportWorkerErrorFunc(workerPoolId, payload)
+}
+
+// WorkerPoolErrorStats records the call and calls WorkerPoolErrorStatsFunc
+func (m *MockWorkerManager) WorkerPoolErrorStats(workerPoolId string) (*WorkerPoolErrorStats, error) {
+	m.Record("WorkerPoolErrorStats", workerPoolId)
+	if m.WorkerPoolErrorStatsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.WorkerPoolErrorStats"}
+	}
+	return m.WorkerPoolErrorStatsFunc(workerPoolId)
+}
+
+// ListWorkerPoolErrors records the call and calls ListWorkerPoolErrorsFunc
+func (m *MockWorkerManager) ListWorkerPoolErrors(workerPoolId, continuationToken, limit string) (*WorkerPoolErrorList, error) {
+	m.Record("ListWorkerPoolErrors", workerPoolId, continuationToken, limit)
+	if m.ListWorkerPoolErrorsFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ListWorkerPoolErrors"}
+	}
+	return m.ListWorkerPoolErrorsFunc(workerPoolId, continuationToken, limit)
+}
+
+// ListWorkersForWorkerGroup records the call and calls ListWorkersForWorkerGroupFunc
+func (m *MockWorkerManager) ListWorkersForWorkerGroup(workerPoolId, workerGroup, continuationToken, limit string) (*WorkerListInAGivenWorkerPool, error) {
+	m.Record("ListWorkersForWorkerGroup", workerPoolId, workerGroup, continuationToken, limit)
+	if m.ListWorkersForWorkerGroupFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ListWorkersForWorkerGroup"}
+	}
+	return m.ListWorkersForWorkerGroupFunc(workerPoolId, workerGroup, continuationToken, limit)
+}
+
+// Worker records the call and calls WorkerFunc
+func (m *MockWorkerManager) Worker(workerPoolId, workerGroup, workerId string) (*WorkerFullDefinition, error) {
+	m.Record("Worker", workerPoolId, workerGroup, workerId)
+	if m.WorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.Worker"}
+	}
+	return m.WorkerFunc(workerPoolId, workerGroup, workerId)
+}
+
+// CreateWorker records the call and calls CreateWorkerFunc
+func (m *MockWorkerManager) CreateWorker(workerPoolId, workerGroup, workerId string, payload *WorkerCreationUpdateRequest) (*WorkerFullDefinition, error) {
+	m.Record("CreateWorker", workerPoolId, workerGroup, workerId, payload)
+	if m.CreateWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.CreateWorker"}
+	}
+	return m.CreateWorkerFunc(workerPoolId, workerGroup, workerId, payload)
+}
+
+// UpdateWorker records the call and calls UpdateWorkerFunc
+func (m *MockWorkerManager) UpdateWorker(workerPoolId, workerGroup, workerId string, payload *WorkerCreationUpdateRequest) (*WorkerFullDefinition, error) {
+	m.Record("UpdateWorker", workerPoolId, workerGroup, workerId, payload)
+	if m.UpdateWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.UpdateWorker"}
+	}
+	return m.UpdateWorkerFunc(workerPoolId, workerGroup, workerId, payload)
+}
+
+// RemoveWorker records the call and calls RemoveWorkerFunc
+func (m *MockWorkerManager) RemoveWorker(workerPoolId, workerGroup, workerId string) error {
+	m.Record("RemoveWorker", workerPoolId, workerGroup, workerId)
+	if m.RemoveWorkerFunc == nil {
+		return &tcclient.NotMockedError{Method: "WorkerManager.RemoveWorker"}
+	}
+	return m.RemoveWorkerFunc(workerPoolId, workerGroup, workerId)
+}
+
+// ListWorkersForWorkerPool records the call and calls ListWorkersForWorkerPoolFunc
+func (m *MockWorkerManager) ListWorkersForWorkerPool(workerPoolId, continuationToken, limit, state string) (*WorkerListInAGivenWorkerPool, error) {
+	m.Record("ListWorkersForWorkerPool", workerPoolId, continuationToken, limit, state)
+	if m.ListWorkersForWorkerPoolFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ListWorkersForWorkerPool"}
+	}
+	return m.ListWorkersForWorkerPoolFunc(workerPoolId, continuationToken, limit, state)
+}
+
+// RegisterWorker records the call and calls RegisterWorkerFunc
+func (m *MockWorkerManager) RegisterWorker(payload *RegisterWorkerRequest) (*RegisterWorkerResponse, error) {
+	m.Record("RegisterWorker", payload)
+	if m.RegisterWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.RegisterWorker"}
+	}
+	return m.RegisterWorkerFunc(payload)
+}
+
+// ReregisterWorker records the call and calls ReregisterWorkerFunc
+func (m *MockWorkerManager) ReregisterWorker(payload *ReregisterWorkerRequest) (*ReregisterWorkerResponse, error) {
+	m.Record("ReregisterWorker", payload)
+	if m.ReregisterWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ReregisterWorker"}
+	}
+	return m.ReregisterWorkerFunc(payload)
+}
+
+// ListWorkers records the call and calls ListWorkersFunc
+func (m *MockWorkerManager) ListWorkers(provisionerId, workerType, continuationToken, limit, quarantined, workerState string) (*ListWorkersResponse, error) {
+	m.Record("ListWorkers", provisionerId, workerType, continuationToken, limit, quarantined, workerState)
+	if m.ListWorkersFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.ListWorkers"}
+	}
+	return m.ListWorkersFunc(provisionerId, workerType, continuationToken, limit, quarantined, workerState)
+}
+
+// GetWorker records the call and calls GetWorkerFunc
+func (m *MockWorkerManager) GetWorker(provisionerId, workerType, workerGroup, workerId string) (*WorkerResponse, error) {
+	m.Record("GetWorker", provisionerId, workerType, workerGroup, workerId)
+	if m.GetWorkerFunc == nil {
+		return nil, &tcclient.NotMockedError{Method: "WorkerManager.GetWorker"}
+	}
+	return m.GetWorkerFunc(provisionerId, workerType, workerGroup, workerId)
+}
+
+// Heartbeat records the call and calls HeartbeatFunc
+func (m *MockWorkerManager) Heartbeat() error {
+	m.Record("Heartbeat")
+	if m.HeartbeatFunc == nil {
+		return &tcclient.NotMockedError{Method: "WorkerManager.Heartbeat"}
+	}
+	return m.HeartbeatFunc()
+}
@@ -38,6 +38,7 @@
 package tcworkermanager
 
 import (
+	"context"
 	"net/url"
 	"time"
 
@@ -163,6 +164,24 @@ func (workerManager *WorkerManager) ListProviders_SignedURL(continuationToken, l
 	return (&cd).SignedURL("/providers", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListProviders, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListProviders for more details.
+func (workerManager *WorkerManager) ListProvidersPages(ctx context.Context, limit string) *tcclient.Pages[ProviderList] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ProviderList, string, error) {
+		c := *workerManager
+		c.Context = ctx
+		response, err := c.ListProviders(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Create a new worker pool. If the worker pool already exists, this will throw an error.
 //
 // Required scopes:
@@ -280,6 +299,24 @@ func (workerManager *WorkerManager) ListWorkerPools_SignedURL(continuationToken,
 	return (&cd).SignedURL("/worker-pools", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListWorkerPools, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListWorkerPools for more details.
+func (workerManager *WorkerManager) ListWorkerPoolsPages(ctx context.Context, limit string) *tcclient.Pages[WorkerPoolList] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*WorkerPoolList, string, error) {
+		c := *workerManager
+		c.Context = ctx
+		response, err := c.ListWorkerPools(continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Report an error that occurred on a worker.  This error will be included
 // with the other errors in `listWorkerPoolErrors(workerPoolId)`.
 //
@@ -381,6 +418,24 @@ func (workerManager *WorkerManager) ListWorkerPoolErrors_SignedURL(workerPoolId,
 	return (&cd).SignedURL("/worker-pool-errors/"+url.QueryEscape(workerPoolId), v, duration)
 }
 
+// Returns an iterator over the pages of results of ListWorkerPoolErrors, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListWorkerPoolErrors for more details.
+func (workerManager *WorkerManager) ListWorkerPoolErrorsPages(ctx context.Context, workerPoolId, limit string) *tcclient.Pages[WorkerPoolErrorList] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*WorkerPoolErrorList, string, error) {
+		c := *workerManager
+		c.Context = ctx
+		response, err := c.ListWorkerPoolErrors(workerPoolId, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Get the list of all the existing workers in a given group in a given worker pool.
 //
 // Required scopes:
@@ -420,6 +475,24 @@ func (workerManager *WorkerManager) ListWorkersForWorkerGroup_SignedURL(workerPo
 	return (&cd).SignedURL("/workers/"+url.QueryEscape(workerPoolId)+"/"+url.QueryEscape(workerGroup), v, duration)
 }
 
+// Returns an iterator over the pages of results of ListWorkersForWorkerGroup, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListWorkersForWorkerGroup for more details.
+func (workerManager *WorkerManager) ListWorkersForWorkerGroupPages(ctx context.Context, workerPoolId, workerGroup, limit string) *tcclient.Pages[WorkerListInAGivenWorkerPool] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*WorkerListInAGivenWorkerPool, string, error) {
+		c := *workerManager
+		c.Context = ctx
+		response, err := c.ListWorkersForWorkerGroup(workerPoolId, workerGroup, continuationToken, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Get a single worker.
 //
 // Required scopes:
@@ -544,6 +617,24 @@ func (workerManager *WorkerManager) ListWorkersForWorkerPool_SignedURL(workerPoo
 	return (&cd).SignedURL("/workers/"+url.QueryEscape(workerPoolId), v, duration)
 }
 
+// Returns an iterator over the pages of results of ListWorkersForWorkerPool, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListWorkersForWorkerPool for more details.
+func (workerManager *WorkerManager) ListWorkersForWorkerPoolPages(ctx context.Context, workerPoolId, limit, state string) *tcclient.Pages[WorkerListInAGivenWorkerPool] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*WorkerListInAGivenWorkerPool, string, error) {
+		c := *workerManager
+		c.Context = ctx
+		response, err := c.ListWorkersForWorkerPool(workerPoolId, continuationToken, limit, state)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Register a running worker.  Workers call this method on worker start-up.
 //
 // This call both marks the worker as running and returns the credentials
@@ -640,6 +731,24 @@ func (workerManager *WorkerManager) ListWorkers_SignedURL(provisionerId, workerT
 	return (&cd).SignedURL("/provisioners/"+url.QueryEscape(provisionerId)+"/worker-types/"+url.QueryEscape(workerType)+"/workers", v, duration)
 }
 
+// Returns an iterator over the pages of results of ListWorkers, fetching
+// each page as it is needed by passing the `continuationToken` of the
+// previous page.  Iteration stops after the last page, on error, or when ctx
+// is done.
+//
+// See ListWorkers for more details.
+func (workerManager *WorkerManager) ListWorkersPages(ctx context.Context, provisionerId, workerType, limit, quarantined, workerState string) *tcclient.Pages[ListWorkersResponse] {
+	return tcclient.NewPages(ctx, func(ctx context.Context, continuationToken string) (*ListWorkersResponse, string, error) {
+		c := *workerManager
+		c.Context = ctx
+		response, err := c.ListWorkers(provisionerId, workerType, continuationToken, limit, quarantined, workerState)
+		if err != nil {
+			return nil, "", err
+		}
+		return response, response.ContinuationToken, nil
+	})
+}
+
 // Stability: *** EXPERIMENTAL ***
 //
 // Get a worker from a worker-type.
@@ -678,3 +787,34 @@ func (workerManager *WorkerManager) Heartbeat() error {
 	_, _, err := (&cd).APICall(nil, "GET", "/__heartbeat__", nil, nil)
 	return err
 }
+
+// WorkerManagerAPI is the interface implemented by *WorkerManager, with a method for
+// each API method.  Code that accepts WorkerManagerAPI rather than *WorkerManager can be
+// tested with MockWorkerManager.
+type WorkerManagerAPI interface {
+	Ping() error
+	Lbheartbeat() error
+	Version() error
+	ListProviders(continuationToken, limit string) (*ProviderList, error)
+	CreateWorkerPool(workerPoolId string, payload *WorkerPoolDefinition) (*WorkerPoolFullDefinition, error)
+	UpdateWorkerPool(workerPoolId string, payload *WorkerPoolDefinition1) (*WorkerPoolFullDefinition, error)
+	DeleteWorkerPool(workerPoolId string) (*WorkerPoolFullDefinition, error)
+	WorkerPool(workerPoolId string) (*WorkerPoolFullDefinition, error)
+	ListWorkerPools(continuationToken, limit string) (*WorkerPoolList, error)
+	ReportWorkerError(workerPoolId string, payload *WorkerErrorReport) (*WorkerPoolError, error)
+	WorkerPoolErrorStats(workerPoolId string) (*WorkerPoolErrorStats, error)
+	ListWorkerPoolErrors(workerPoolId, continuationToken, limit string) (*WorkerPoolErrorList, error)
+	ListWorkersForWorkerGroup(workerPoolId, workerGroup, continuationToken, limit string) (*WorkerListInAGivenWorkerPool, error)
+	Worker(workerPoolId, workerGroup, workerId string) (*WorkerFullDefinition, error)
+	CreateWorker(workerPoolId, workerGroup, workerId string, payload *WorkerCreationUpdateRequest) (*WorkerFullDefinition, error)
+	UpdateWorker(workerPoolId, workerGroup, workerId string, payload *WorkerCreationUpdateRequest) (*WorkerFullDefinition, error)
+	RemoveWorker(workerPoolId, workerGroup, workerId string) error
+	ListWorkersForWorkerPool(workerPoolId, continuationToken, limit, state string) (*WorkerListInAGivenWorkerPool, error)
+	RegisterWorker(payload *RegisterWorkerRequest) (*RegisterWorkerResponse, error)
+	ReregisterWorker(payload *ReregisterWorkerRequest) (*ReregisterWorkerResponse, error)
+	ListWorkers(provisionerId, workerType, continuationToken, limit, quarantined, workerState string) (*ListWorkersResponse, error)
+	GetWorker(provisionerId, workerType, workerGroup, workerId string) (*WorkerResponse, error)
+	Heartbeat() error
+}
+
+var _ WorkerManagerAPI = (*WorkerManager)(nil)
@@ -133,6 +133,11 @@ type (
 		// Max length: 38
 		ProviderID string `json:"providerId"`
 
+		// Optional breakdown of the time the worker took to start up, allowing pool operators to
+		// see where provisioning latency goes.  The time until the worker first claims a task is
+		// available separately, as the worker's `firstClaim`.
+		StartupTimings StartupTimings `json:"startupTimings,omitempty"`
+
 		// Worker group to which this worker belongs
 		//
 		// Syntax:     ^([a-zA-Z0-9-_]*)$
@@ -268,9 +273,33 @@ type (
 	// Max length: 4096
 	Source1 string
 
+	// Optional breakdown of the time the worker took to start up, allowing pool operators to
+	// see where provisioning latency goes.  The time until the worker first claims a task is
+	// available separately, as the worker's `firstClaim`.
+	StartupTimings struct {
+
+		// Seconds from the host booting to the start of the worker process (for example,
+		// worker-runner).
+		//
+		// Mininum:    0
+		BootSecs float64 `json:"bootSecs,omitempty"`
+
+		// Seconds from the start of the worker process to this call, including fetching
+		// instance metadata and configuration from the cloud provider.
+		//
+		// Mininum:    0
+		ConfigureSecs float64 `json:"configureSecs,omitempty"`
+	}
+
 	// Provider-specific information
 	StaticProviderType struct {
 
+		// An optional PEM-encoded X.509 certificate for the worker.  If given, `registerWorker` also
+		// requires a signature made with the certificate's private key, so that the `staticSecret`
+		// alone is not sufficient to register the worker.  The private key can be held in a file on
+		// the worker or in a hardware device such as a TPM.  RSA, ECDSA and Ed25519 keys are supported.
+		ClientCertificate string `json:"clientCertificate,omitempty"`
+
 		// A secret value shared with the worker.  This value must be passed in the `workerIdentityProof` of the `registerWorker` method.
 		// The ideal way to generate a secret of this form is `slugid() + slugid()`.
 		//
@@ -285,10 +314,20 @@ type (
 	// The form of this proof varies depending on the provider type.
 	StaticProviderType1 struct {
 
+		// Base64-encoded signature, made with the private key of the `clientCertificate` given
+		// when the worker was created, of the string `<workerPoolId>/<workerGroup>/<workerId>/<timestamp>`.
+		// RSA keys use PKCS #1 v1.5 and ECDSA keys use DER-encoded signatures, both with SHA-256.
+		// Required if the worker was created with a `clientCertificate`.
+		Signature string `json:"signature,omitempty"`
+
 		// The secret value that was configured when the worker was created (in `createWorker`).
 		//
 		// Syntax:     ^[a-zA-Z0-9_-]{44}$
 		StaticSecret string `json:"staticSecret"`
+
+		// The time at which the `signature` was made, which must be within 10 minutes of the
+		// current time.  Required if the worker was created with a `clientCertificate`.
+		Timestamp tcclient.Time `json:"timestamp,omitempty"`
 	}
 
 	// Required task metadata
@@ -0,0 +1,98 @@
+package tcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions tunes the HTTP transport used to make requests.  The
+// default transport keeps at most two idle connections per host, so a process
+// making many concurrent requests to a deployment opens and closes
+// connections constantly, which in large fleets can exhaust ephemeral ports.
+// Zero values leave the corresponding setting of http.DefaultTransport
+// unchanged.
+//
+// To use these options, set a client's HTTPClient to the result of
+// NewHTTPClient.  The returned client should be shared by all clients in a
+// process, so that they share a connection pool:
+//
+//	httpClient := tcclient.NewHTTPClient(tcclient.TransportOptions{
+//		MaxIdleConnsPerHost: 50,
+//		IdleConnTimeout:     5 * time.Minute,
+//	})
+//	queue := tcqueue.NewFromEnv()
+//	queue.HTTPClient = httpClient
+type TransportOptions struct {
+	// Maximum number of idle connections, across all hosts
+	MaxIdleConns int
+	// Maximum number of idle connections to each host; the default is
+	// http.DefaultMaxIdleConnsPerHost
+	MaxIdleConnsPerHost int
+	// Maximum number of connections to each host, including those in use;
+	// requests beyond this wait for a connection to become available
+	MaxConnsPerHost int
+	// How long an idle connection is kept before it is closed
+	IdleConnTimeout time.Duration
+	// Maximum time to wait for a TLS handshake
+	TLSHandshakeTimeout time.Duration
+	// Maximum time to wait for a server's response headers after sending a
+	// request, which does not include the time to read the response body
+	ResponseHeaderTimeout time.Duration
+	// Disable HTTP/2, so that all requests use HTTP/1.1
+	DisableHTTP2 bool
+	// DialContext, if not nil, is used to open connections, for example to
+	// set socket options or to dial through a proxy
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Transport returns a new http.Transport with the default settings of
+// http.DefaultTransport, modified by options.
+func (options TransportOptions) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.MaxIdleConns != 0 {
+		transport.MaxIdleConns = options.MaxIdleConns
+	}
+	if options.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	}
+	if options.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = options.MaxConnsPerHost
+	}
+	if options.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = options.IdleConnTimeout
+	}
+	if options.TLSHandshakeTimeout != 0 {
+		transport.TLSHandshakeTimeout = options.TLSHandshakeTimeout
+	}
+	if options.ResponseHeaderTimeout != 0 {
+		transport.ResponseHeaderTimeout = options.ResponseHeaderTimeout
+	}
+	if options.DisableHTTP2 {
+		// a non-nil, empty TLSNextProto disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig.NextProtos = nil
+		}
+	}
+	if options.DialContext != nil {
+		transport.DialContext = options.DialContext
+	}
+	return transport
+}
+
+// NewHTTPClient returns an HTTP client, suitable for Client.HTTPClient, that
+// uses a transport configured with options.  Like the default HTTP client, it
+// does not follow redirects.
+func NewHTTPClient(options TransportOptions) *http.Client {
+	return &http.Client{
+		Transport: options.Transport(),
+		// do not follow redirects
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package tcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportOptions(t *testing.T) {
+	transport := TransportOptions{
+		MaxIdleConns:          500,
+		MaxIdleConnsPerHost:   50,
+		MaxConnsPerHost:       100,
+		IdleConnTimeout:       5 * time.Minute,
+		TLSHandshakeTimeout:   time.Second,
+		ResponseHeaderTimeout: 2 * time.Second,
+	}.Transport()
+	require.Equal(t, 500, transport.MaxIdleConns)
+	require.Equal(t, 50, transport.MaxIdleConnsPerHost)
+	require.Equal(t, 100, transport.MaxConnsPerHost)
+	require.Equal(t, 5*time.Minute, transport.IdleConnTimeout)
+	require.Equal(t, time.Second, transport.TLSHandshakeTimeout)
+	require.Equal(t, 2*time.Second, transport.ResponseHeaderTimeout)
+	require.True(t, transport.ForceAttemptHTTP2)
+}
+
+func TestTransportOptionsDefaults(t *testing.T) {
+	transport := TransportOptions{}.Transport()
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+	require.Equal(t, defaultTransport.MaxIdleConns, transport.MaxIdleConns)
+	require.Equal(t, defaultTransport.IdleConnTimeout, transport.IdleConnTimeout)
+	require.NotSame(t, defaultTransport, transport)
+}
+
+// protocol returns the protocol used for a request to an HTTPS server
+// supporting HTTP/2, using a transport with the given options
+func protocol(t *testing.T, options TransportOptions) string {
+	t.Helper()
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.Proto)
+	}))
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	defer s.Close()
+
+	transport := options.Transport()
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Certificate())
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	resp, err := (&http.Client{Transport: transport}).Get(s.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.Proto
+}
+
+func TestTransportOptionsHTTP2(t *testing.T) {
+	require.Equal(t, "HTTP/2.0", protocol(t, TransportOptions{}))
+	require.Equal(t, "HTTP/1.1", protocol(t, TransportOptions{DisableHTTP2: true}))
+}
+
+func TestNewHTTPClientDialContext(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "http://nosuch.example.com")
+		w.WriteHeader(http.StatusSeeOther)
+	}))
+	defer s.Close()
+
+	var dials int32
+	dialer := &net.Dialer{}
+	httpClient := NewHTTPClient(TransportOptions{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return dialer.DialContext(ctx, network, addr)
+		},
+	})
+
+	client := Client{
+		RootURL:     s.URL,
+		ServiceName: "queue",
+		APIVersion:  "v1",
+		HTTPClient:  httpClient,
+	}
+	_, cs, err := client.APICall(nil, "GET", "/ping", nil, nil)
+	require.NoError(t, err)
+	// the redirect is not followed
+	require.Equal(t, http.StatusSeeOther, cs.HTTPResponse.StatusCode)
+	require.Equal(t, int32(1), atomic.LoadInt32(&dials))
+}
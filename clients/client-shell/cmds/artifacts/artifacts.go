@@ -0,0 +1,52 @@
+// Package artifacts implements the artifacts subcommands.
+package artifacts
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the artifacts subtree.
+	Command = &cobra.Command{
+		Use:   "artifacts",
+		Short: "Provides support for working with the artifacts of tasks.",
+	}
+)
+
+func init() {
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the artifacts subcommands.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+
+		if len(args) < 1 {
+			return fmt.Errorf("%s expects argument <taskId>", cmd.Name())
+		}
+		if err := f(creds, args, cmd.OutOrStdout(), cmd.Flags()); err != nil {
+			return err
+		}
+		completion.RecordTaskIDs(args[0])
+		return nil
+	}
+}
+
+func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
+	return tcqueue.New(credentials, config.RootURL())
+}
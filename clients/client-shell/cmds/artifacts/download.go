@@ -0,0 +1,339 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/taskcluster/httpbackoff/v3"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+)
+
+// partialSuffix is appended to the names of files while they are being
+// downloaded; they are renamed once the download is complete.
+const partialSuffix = ".partial"
+
+func init() {
+	downloadCmd := &cobra.Command{
+		Use:   "download <taskId>",
+		Short: "Download the artifacts of a task to a directory.",
+		Long: `Download the artifacts of a task, or those matching --glob, to the directory
+given by --dest, several at a time.  Each artifact is written to the path given
+by its name, relative to the destination directory.
+
+Object artifacts are verified against the hashes recorded by the object
+service.  Artifacts are first written to a file with the suffix '.partial',
+which is renamed once the download is complete.  Artifacts that already exist
+in the destination directory are skipped, unless --force is given, and the
+partial downloads of reference and s3 artifacts are resumed where they
+stopped, so an interrupted download can be continued by running the command
+again.
+
+The --glob pattern uses the syntax of Go's path.Match, so '*' does not match
+'/': for example, 'public/build/*.zip'.`,
+		RunE:              executeHelperE(runDownload),
+		ValidArgsFunction: completion.TaskIDs,
+	}
+	downloadCmd.Flags().IntP("run", "r", -1, "Specifies which run to consider.")
+	downloadCmd.Flags().StringP("glob", "g", "", "Only download artifacts whose names match this pattern.")
+	downloadCmd.Flags().StringP("dest", "d", ".", "The directory to download the artifacts to.")
+	downloadCmd.Flags().IntP("parallel", "j", 4, "The number of artifacts to download at once.")
+	downloadCmd.Flags().Bool("force", false, "Download artifacts that already exist in the destination directory.")
+	Command.AddCommand(downloadCmd)
+}
+
+func runDownload(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	taskID := args[0]
+
+	options := &DownloadOptions{}
+	options.Glob, _ = flagSet.GetString("glob")
+	options.Dest, _ = flagSet.GetString("dest")
+	options.Parallel, _ = flagSet.GetInt("parallel")
+	options.Force, _ = flagSet.GetBool("force")
+	if _, err := path.Match(options.Glob, ""); err != nil {
+		return fmt.Errorf("invalid --glob pattern %q: %v", options.Glob, err)
+	}
+
+	s, err := q.Status(taskID)
+	if err != nil {
+		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
+	}
+	runID, err := flagSet.GetInt("run")
+	if err != nil {
+		runID = -1
+	}
+	if runID >= len(s.Status.Runs) {
+		return fmt.Errorf("there is no run #%v", runID)
+	}
+	if runID == -1 {
+		if len(s.Status.Runs) == 0 {
+			return fmt.Errorf("task %s has no runs", taskID)
+		}
+		runID = len(s.Status.Runs) - 1
+	}
+
+	return Download(q, taskID, runID, options, out)
+}
+
+// DownloadOptions configures Download.
+type DownloadOptions struct {
+	// Only download artifacts whose names match this path.Match pattern;
+	// if empty, all artifacts are downloaded
+	Glob string
+	// The directory to download the artifacts to; if empty, the current
+	// directory
+	Dest string
+	// The number of artifacts to download at once; if less than one, one
+	Parallel int
+	// Download artifacts that already exist in Dest
+	Force bool
+}
+
+// Download downloads the artifacts of the given run of a task, as for
+// `taskcluster artifacts download`, reporting progress to out.
+func Download(q *tcqueue.Queue, taskID string, runID int, options *DownloadOptions, out io.Writer) error {
+	glob, dest, parallel, force := options.Glob, options.Dest, options.Parallel, options.Force
+	if dest == "" {
+		dest = "."
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var artifacts []tcqueue.Artifact
+	pages := q.ListArtifactsPages(nil, taskID, strconv.Itoa(runID), "")
+	for pages.Next() {
+		for _, a := range pages.Page().Artifacts {
+			if glob == "" {
+				artifacts = append(artifacts, a)
+			} else if matched, _ := path.Match(glob, a.Name); matched {
+				artifacts = append(artifacts, a)
+			}
+		}
+	}
+	if err := pages.Err(); err != nil {
+		return fmt.Errorf("could not fetch artifacts for task %s run %v: %v", taskID, runID, err)
+	}
+	if len(artifacts) == 0 {
+		fmt.Fprintf(out, "No matching artifacts found for task %s run %v.\n", taskID, runID)
+		return nil
+	}
+
+	syncOut := &syncWriter{w: out}
+	var mu sync.Mutex
+	failed := map[string]error{}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for _, a := range artifacts {
+		if a.StorageType == "error" {
+			fmt.Fprintf(syncOut, "skipping %s: error artifact\n", a.Name)
+			continue
+		}
+		filename, err := destPath(dest, a.Name)
+		if err != nil {
+			failed[a.Name] = err
+			continue
+		}
+		if !force {
+			if _, err := os.Stat(filename); err == nil {
+				fmt.Fprintf(syncOut, "skipping %s: %s already exists\n", a.Name, filename)
+				continue
+			}
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(name, filename string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			size, err := downloadArtifact(q, taskID, runID, name, filename)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				failed[name] = err
+				return
+			}
+			fmt.Fprintf(syncOut, "downloaded %s to %s (%d bytes)\n", name, filename, size)
+		}(a.Name, filename)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		names := make([]string, 0, len(failed))
+		for name := range failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines := make([]string, len(names))
+		for i, name := range names {
+			lines[i] = fmt.Sprintf("  %s: %v", name, failed[name])
+		}
+		return fmt.Errorf("could not download %d artifacts:\n%s", len(failed), strings.Join(lines, "\n"))
+	}
+	return nil
+}
+
+// destPath returns the path of the file an artifact is downloaded to, which
+// must be within the destination directory.
+func destPath(dest, name string) (string, error) {
+	rel := filepath.FromSlash(name)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("artifact name %q is not a relative path within the destination directory", name)
+	}
+	return filepath.Join(dest, rel), nil
+}
+
+// downloadArtifact downloads an artifact to filename via a partial file,
+// returning the size of the artifact.
+func downloadArtifact(q *tcqueue.Queue, taskID string, runID int, name, filename string) (size int64, err error) {
+	if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return
+	}
+	partial := filename + partialSuffix
+
+	// link artifacts are resolved by the queue, so the storage type is that
+	// of the artifact that is downloaded
+	content, err := q.Artifact(taskID, strconv.Itoa(runID), name)
+	if err != nil {
+		return
+	}
+	var artifact struct {
+		StorageType string `json:"storageType"`
+		URL         string `json:"url"`
+	}
+	if err = json.Unmarshal(*content, &artifact); err != nil {
+		return
+	}
+
+	switch artifact.StorageType {
+	case "reference", "s3":
+		size, err = resumeURL(q.HTTPBackoffClient, artifact.URL, partial)
+	default:
+		// the hashes of object artifacts can only be verified against all of
+		// the data, so they are downloaded in full; the client resumes
+		// transfers interrupted during the download itself
+		_, size, err = q.DownloadArtifactToFile(taskID, int64(runID), name, partial)
+	}
+	if err != nil {
+		return
+	}
+	err = os.Rename(partial, filename)
+	return
+}
+
+// resumeURL downloads url to filename, retrying if intermittent errors occur.
+// If filename already contains the start of the data, from an interrupted
+// download, only the remainder is requested, using a range request.
+func resumeURL(client *httpbackoff.Client, url, filename string) (size int64, err error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err2 := f.Close()
+		if err == nil {
+			err = err2
+		}
+	}()
+
+	complete := false
+	retryFunc := func() (resp *http.Response, tempError error, permError error) {
+		offset, permError := f.Seek(0, io.SeekEnd)
+		if permError != nil {
+			return
+		}
+		req, permError := http.NewRequest(http.MethodGet, url, nil)
+		if permError != nil {
+			return
+		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+		resp, tempError = http.DefaultClient.Do(req)
+		if tempError != nil {
+			return
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp) == offset:
+			// the remainder of the data follows that in the file
+		case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+			if resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) {
+				// the file already contains all of the data
+				complete = true
+				return
+			}
+			// the file does not contain the start of this data, so start again
+			tempError = f.Truncate(0)
+			if tempError == nil {
+				tempError = errors.New("partial download is longer than the artifact")
+			}
+			return
+		case resp.StatusCode/100 == 2:
+			// the response contains all of the data
+			if permError = f.Truncate(0); permError != nil {
+				return
+			}
+			if _, permError = f.Seek(0, io.SeekStart); permError != nil {
+				return
+			}
+		default:
+			// httpbackoff handles other status codes
+			return
+		}
+		_, tempError = io.Copy(f, resp.Body)
+		return
+	}
+
+	if client == nil {
+		client = &httpbackoff.Client{
+			BackOffSettings: backoff.NewExponentialBackOff(),
+		}
+	}
+	resp, attempts, err := client.Retry(retryFunc)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err != nil && !complete {
+		return 0, fmt.Errorf("could not download %s after %d attempts: %v", url, attempts, err)
+	}
+	return f.Seek(0, io.SeekEnd)
+}
+
+// contentRangeStart returns the offset of the first byte of a partial
+// response, or -1 if it cannot be determined
+func contentRangeStart(resp *http.Response) int64 {
+	var start, end int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/", &start, &end); err != nil {
+		return -1
+	}
+	return start
+}
+
+// syncWriter serializes writes to an io.Writer from concurrent goroutines
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
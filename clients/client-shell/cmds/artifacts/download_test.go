@@ -0,0 +1,190 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+const fakeTaskID = "ANnmjMocTymeTID0tlNJAw"
+
+// the content of the fake artifacts, by name
+var fakeArtifacts = map[string]string{
+	"public/build/target.zip":  strings.Repeat("zip data ", 300),
+	"public/logs/live.log":     "log line\n",
+	"private/secret/token.txt": "s3kr1t",
+}
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+
+	mu sync.Mutex
+	// the Range headers of the requests for artifact data, by name
+	ranges map[string]string
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status": {"taskId": "` + fakeTaskID + `", "state": "completed", "runs": [{"runId": 0}, {"runId": 1}]}}`))
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/1/artifacts", func(w http.ResponseWriter, _ *http.Request) {
+		list := []map[string]string{}
+		for name := range fakeArtifacts {
+			list = append(list, map[string]string{"name": name, "storageType": "reference"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"artifacts": list})
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/1/artifact-content/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/api/queue/v1/task/"+fakeTaskID+"/runs/1/artifact-content/")
+		if _, ok := fakeArtifacts[name]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"storageType": "reference",
+			"url":         suite.testServer.URL + "/data/" + name,
+		})
+	})
+	handler.HandleFunc("/data/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/data/")
+		suite.mu.Lock()
+		suite.ranges[name] = r.Header.Get("Range")
+		suite.mu.Unlock()
+		http.ServeContent(w, r, name, time.Time{}, strings.NewReader(fakeArtifacts[name]))
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.ranges = map[string]string{}
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func setUpCommand(dest, glob string) (*bytes.Buffer, *cobra.Command) {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	cmd.Flags().String("dest", dest, "")
+	cmd.Flags().String("glob", glob, "")
+	cmd.Flags().Int("parallel", 2, "")
+	return buf, cmd
+}
+
+func (suite *FakeServerSuite) TestDownloadGlob() {
+	dest := suite.T().TempDir()
+	buf, cmd := setUpCommand(dest, "public/*/*")
+
+	suite.NoError(runDownload(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	for _, name := range []string{"public/build/target.zip", "public/logs/live.log"} {
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		suite.NoError(err)
+		suite.Equal(fakeArtifacts[name], string(data))
+		suite.Contains(buf.String(), "downloaded "+name)
+	}
+	_, err := os.Stat(filepath.Join(dest, "private"))
+	suite.True(os.IsNotExist(err))
+}
+
+func (suite *FakeServerSuite) TestDownloadNoMatches() {
+	buf, cmd := setUpCommand(suite.T().TempDir(), "public/*.txt")
+
+	suite.NoError(runDownload(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("No matching artifacts found for task "+fakeTaskID+" run 1.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestDownloadBadGlob() {
+	_, cmd := setUpCommand(suite.T().TempDir(), "public/[")
+
+	suite.Error(runDownload(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+}
+
+func (suite *FakeServerSuite) TestDownloadResume() {
+	dest := suite.T().TempDir()
+	name := "public/build/target.zip"
+	filename := filepath.Join(dest, filepath.FromSlash(name))
+	suite.NoError(os.MkdirAll(filepath.Dir(filename), 0755))
+	suite.NoError(os.WriteFile(filename+partialSuffix, []byte(fakeArtifacts[name][:1234]), 0644))
+	_, cmd := setUpCommand(dest, name)
+
+	suite.NoError(runDownload(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	data, err := os.ReadFile(filename)
+	suite.NoError(err)
+	suite.Equal(fakeArtifacts[name], string(data))
+	suite.Equal("bytes=1234-", suite.ranges[name])
+	_, err = os.Stat(filename + partialSuffix)
+	suite.True(os.IsNotExist(err))
+}
+
+func (suite *FakeServerSuite) TestDownloadResumeComplete() {
+	dest := suite.T().TempDir()
+	name := "public/logs/live.log"
+	filename := filepath.Join(dest, filepath.FromSlash(name))
+	suite.NoError(os.MkdirAll(filepath.Dir(filename), 0755))
+	suite.NoError(os.WriteFile(filename+partialSuffix, []byte(fakeArtifacts[name]), 0644))
+	_, cmd := setUpCommand(dest, name)
+
+	suite.NoError(runDownload(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	data, err := os.ReadFile(filename)
+	suite.NoError(err)
+	suite.Equal(fakeArtifacts[name], string(data))
+}
+
+func (suite *FakeServerSuite) TestDownloadSkipsExisting() {
+	dest := suite.T().TempDir()
+	name := "public/logs/live.log"
+	filename := filepath.Join(dest, filepath.FromSlash(name))
+	suite.NoError(os.MkdirAll(filepath.Dir(filename), 0755))
+	suite.NoError(os.WriteFile(filename, []byte("existing"), 0644))
+	buf, cmd := setUpCommand(dest, name)
+
+	suite.NoError(runDownload(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	data, err := os.ReadFile(filename)
+	suite.NoError(err)
+	suite.Equal("existing", string(data))
+	suite.Equal("skipping "+name+": "+filename+" already exists\n", buf.String())
+	suite.Empty(suite.ranges)
+}
+
+func TestDestPath(t *testing.T) {
+	_, err := destPath("dest", "../escape.txt")
+	if err == nil {
+		t.Fatal("expected an error for a name outside the destination directory")
+	}
+	p, err := destPath("dest", "public/build/target.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("dest", "public", "build", "target.zip"); p != want {
+		t.Fatalf("expected %s, got %s", want, p)
+	}
+}
@@ -23,6 +23,10 @@ The following is a couple of example usages for bash:
 'source bash_completion.sh' to add to your current shell,
 Add 'source bash_completion.sh' to your bash login scripts
 On Linux you can also copy it to /etc/bash_completion.d/ so that future bash shells have it active.
+
+Besides commands and flags, the scripts complete worker pool IDs, secret names
+and index namespaces, fetched from the configured deployment with the
+configured credentials, and taskIds used recently with this tool.
         `,
 		Use: use,
 	}
@@ -71,7 +75,7 @@ func genCompletion(shell string) func(*cobra.Command, []string) error {
 
 		switch shell {
 		case "bash":
-			return root.Command.GenBashCompletionFile(filename)
+			return root.Command.GenBashCompletionFileV2(filename, true)
 		case "fish":
 			return root.Command.GenFishCompletionFile(filename, false)
 		case "powershell":
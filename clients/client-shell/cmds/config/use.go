@@ -0,0 +1,50 @@
+package configCmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+func init() {
+	Command.AddCommand(&cobra.Command{
+		Use:   "use [<profile>]",
+		Short: "Select the configuration profile to use, or list the profiles",
+		Long: `Select the configuration profile used when no other profile is given with
+--profile or TASKCLUSTER_PROFILE.  Each profile has its own configuration
+options, such as the root URL and credentials of a deployment; the options of
+the "default" profile are those set when no profile is selected.
+
+Profiles are created by setting their options, for example:
+
+  taskcluster --profile staging config set config.rootUrl https://tc.staging.example.com
+
+Without arguments, the profiles are listed, with the active one marked by *.`,
+		RunE: cmdUse,
+	})
+}
+
+func cmdUse(cmd *cobra.Command, args []string) error {
+	// list the profiles
+	if len(args) == 0 {
+		profiles, err := config.Profiles()
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			marker := " "
+			if profile == config.ActiveProfile() {
+				marker = "*"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", marker, profile)
+		}
+		return nil
+	}
+
+	if err := config.UseProfile(args[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Using profile '%s'.\n", args[0])
+	return nil
+}
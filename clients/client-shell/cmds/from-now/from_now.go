@@ -30,10 +30,23 @@ func fromNow(cmd *cobra.Command, args []string) error {
 	}
 	duration := strings.Join(args, " ")
 
+	timein, err := FromNow(duration, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), timein.Format(time.RFC3339))
+
+	return nil
+}
+
+// FromNow returns the time which is duration, such as "1 year" or "2d 3h",
+// ahead of now.
+func FromNow(duration string, now time.Time) (time.Time, error) {
 	offset, err := parseTime(duration)
 
 	if err != nil {
-		return fmt.Errorf("string '%s' is not a valid time expression", duration)
+		return time.Time{}, fmt.Errorf("string '%s' is not a valid time expression", duration)
 	}
 
 	// logic taken from github.com/taskcluster/taskcluster-client/blob/master/lib/utils.js
@@ -43,12 +56,8 @@ func fromNow(cmd *cobra.Command, args []string) error {
 		time.Minute*time.Duration(offset.minutes) +
 		time.Second*time.Duration(offset.seconds)
 
-	timein := time.Now().Add(timeToAdd)
-	timein = timein.AddDate(offset.years, offset.months, 0)
-
-	fmt.Fprintln(cmd.OutOrStdout(), timein.Format(time.RFC3339))
-
-	return nil
+	timein := now.Add(timeToAdd)
+	return timein.AddDate(offset.years, offset.months, 0), nil
 }
 
 type timeOffset struct {
@@ -1,50 +1,40 @@
 package group
 
 import (
-	"context"
 	"fmt"
 	"html/template"
 	"io"
+	"sort"
 	"strings"
-	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
 )
 
 var listFormat string
 
 func init() {
-	cancelCmd := &cobra.Command{
-		Use:   "cancel <taskGroupId>",
-		Short: "Cancel a whole group by taskGroupId.",
-		Long: "This method fetches all tasks in the given task group, and cancels\n" +
-			"all tasks with respect to worker-type filter if given.\n\n" +
-			"Note that there is a more efficient way to cancel a task group with API:\n\n" +
-			"taskcluster api queue sealTaskGroup <taskGroupId> # seal task group is required before calling cancelTaskGroup \n" +
-			"taskcluster api queue cancelTaskGroup <taskGroupId> # cancel all at once\n",
-		RunE: executeHelperE(runCancel),
-	}
-	cancelCmd.Flags().StringP("worker-type", "w", "", "Only cancel tasks with a certain worker type.")
-	cancelCmd.Flags().BoolP("force", "f", false, "Skip cancellation confirmation.")
-
-	Command.AddCommand(cancelCmd)
-
 	statusCmd := &cobra.Command{
-		Use:   "status <taskGroupId>",
-		Short: "Show the status of a task group",
-		RunE:  executeHelperE(runStatus),
+		Use:               "status <taskGroupId>",
+		Short:             "Show the status of a task group",
+		RunE:              executeHelperE(runStatus),
+		ValidArgsFunction: completion.TaskGroupIDs,
 	}
 
+	output.AddFlag(statusCmd)
+
 	Command.AddCommand(statusCmd)
 
 	listCmd := &cobra.Command{
-		Use:   "list <taskGroupId>",
-		Short: "List task details: ID and label",
-		RunE:  executeHelperE(runList),
+		Use:               "list <taskGroupId>",
+		Short:             "List task details: ID and label",
+		RunE:              executeHelperE(runList),
+		ValidArgsFunction: completion.TaskGroupIDs,
 	}
 	listCmd.Flags().BoolP("all", "a", false, "Include all tasks (Overrides other options).")
 
@@ -56,6 +46,7 @@ func init() {
 	listCmd.Flags().BoolP("pending", "p", false, "Include pending tasks.")
 
 	listCmd.Flags().StringVar(&listFormat, "format-string", "{{ .Status.TaskID }} {{ .Task.Metadata.Name }} {{ .Status.State }}", "Go Template string for output")
+	output.AddFlag(listCmd)
 
 	Command.AddCommand(listCmd)
 }
@@ -64,161 +55,6 @@ func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
 	return tcqueue.New(credentials, config.RootURL())
 }
 
-// runCancel cancels all tasks of a group.
-//
-// It first fetches the list of all tasks associated with the given group,
-// then filters for only cancellable tasks (unscheduled, pending, running),
-// and finally runs all cancellations concurrently, because they are
-// independent of each other.
-func runCancel(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
-	q := makeQueue(credentials)
-	groupID := args[0]
-
-	// Because the list of tasks can be arbitrarily long, we have to loop until
-	// we are told not to.
-	tasks := make([]string, 0)
-	tasksNames := make([]string, 0)
-	cont := ""
-
-	for {
-		// get next TaskGroup for groupID
-		ts, err := q.ListTaskGroup(groupID, cont, "")
-		if err != nil {
-			return fmt.Errorf("could not fetch tasks for group %s: %v", groupID, err)
-		}
-
-		// set tasks that meet the criteria (see filterTask) to be deleted
-		for _, t := range ts.Tasks {
-			if filterTask(t.Status, flags) {
-				// add id to be deleted, and name for cancellation
-				tasks = append(tasks, t.Status.TaskID)
-				tasksNames = append(tasksNames, t.Task.Metadata.Name)
-			}
-		}
-
-		// break if there are no more tasks for that groupID
-		if cont = ts.ContinuationToken; cont == "" {
-			break
-		}
-	}
-
-	if len(tasks) == 0 {
-		fmt.Fprintln(out, "No suitable tasks found for cancellation.")
-		return nil
-	}
-
-	// ask for confirmation before cancellation
-	if force, _ := flags.GetBool("force"); !force && !confirmCancellation(tasks, tasksNames, out) {
-		fmt.Fprintln(out, "Cancellation of tasks aborted.")
-		return nil
-	}
-
-	// Here we use a waitgroup to ensure that we return once all the tasks have
-	// been completed.
-	wg := &sync.WaitGroup{}
-	// The context allows us to exit early if any of the cancellation fails.
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	// errChan allows the first request that panics to propagate the error message
-	// up to us.
-	errChan := make(chan error, 1)
-
-	for _, taskID := range tasks {
-		wg.Add(1)
-		go func(taskID string) {
-			// If we panicked on the way out, abort all the other tasks.
-			defer func() {
-				//recover from panic and abort
-				if err := recover(); err != nil {
-					if e, ok := err.(error); ok {
-						errChan <- e
-					} else {
-						errChan <- fmt.Errorf("%v", err)
-					}
-					cancel()
-				}
-			}()
-
-			fmt.Fprintf(out, "cancelling task %s\n", taskID)
-			c := make(chan error, 1)
-			go func() { _, err := q.CancelTask(taskID); c <- err }()
-
-			// we select the first that returns or closes:
-			// - ctx.Done() if we aborted;
-			// - c if we got a completed cancellation.
-			select {
-			case <-ctx.Done():
-				// nothing because we can't cancel the existing requests.
-			case err := <-c:
-				if err != nil {
-					panic(fmt.Errorf("could not cancel task %s: %v", taskID, err))
-				}
-			}
-			// if we exited normally, we indicate that we completed.
-			wg.Done()
-		}(taskID)
-	}
-	// change the semantics of waitgroup to close a channel instead of blocking
-	// the main thread.
-	regularExit := make(chan bool)
-	go func() { wg.Wait(); close(regularExit) }()
-
-	// We select the first that closes:
-	// - ctx.Done() if we aborted;
-	// - regularExit if all the goroutine exited manually.
-	select {
-	case <-ctx.Done():
-		return fmt.Errorf("could not cancel all tasks: %v", <-errChan)
-	case <-regularExit:
-		return nil
-	}
-}
-
-// filterTask takes a task and returns whether or not this task should be
-// set for cancellation, based on the specified filters through flags
-func filterTask(status tcqueue.TaskStatusStructure, flags *pflag.FlagSet) bool {
-	// first check - only delete tasks that are unscheduled, pending, running
-	if status.State != "unscheduled" && status.State != "pending" && status.State != "running" {
-		return false
-	}
-
-	// filter for worker type, if one specified
-	// if no worker type is specified, its value is "" so the condition is skipped
-	if workerType, _ := flags.GetString("worker-type"); workerType != "" {
-		if workerType != status.WorkerType {
-			return false
-		}
-	}
-
-	// ..other filters can be added here as necessary
-
-	return true
-}
-
-// confirmCancellation lists the tasks to be cancelled and prompts to confirm cancellation
-func confirmCancellation(ids []string, names []string, out io.Writer) bool {
-	// list tasks
-	fmt.Fprintf(out, "The following %d tasks will be cancelled:\n", len(ids))
-
-	for n, id := range ids {
-		fmt.Fprintf(out, "\tTask %s: %s\n", id, names[n])
-	}
-
-	for {
-		fmt.Fprint(out, "Are you sure you want to cancel these tasks? [y/n] ")
-
-		var c string
-		fmt.Scanf("%s", &c)
-
-		if c == "y" || c == "Y" {
-			return true
-		} else if c == "n" || c == "N" {
-			return false
-		}
-		// otherwise reloop to ask again
-	}
-}
-
 // runStatus displays the status summary of tasks in a group.
 //
 // It first fetches the list of all tasks associated with the given group,
@@ -248,6 +84,21 @@ func runStatus(credentials *tcclient.Credentials, args []string, out io.Writer,
 		}
 	}
 
+	if output.Selected(flags) {
+		return output.Write(out, flags, counter, func() *output.Table {
+			states := make([]string, 0, len(counter))
+			for state := range counter {
+				states = append(states, state)
+			}
+			sort.Strings(states)
+			table := &output.Table{Header: []string{"STATE", "TASKS"}}
+			for _, state := range states {
+				table.AddRow(state, fmt.Sprint(counter[state]))
+			}
+			return table
+		})
+	}
+
 	for status, count := range counter {
 		fmt.Fprintf(out, "%s: %d\n", status, count)
 	}
@@ -265,6 +116,8 @@ func runList(credentials *tcclient.Credentials, args []string, out io.Writer, fl
 	cont := ""
 
 	templ := template.Must(template.New("listFormat").Parse(strings.Join([]string{listFormat, "\n"}, "")))
+	structured := output.Selected(flags)
+	tasks := []tcqueue.TaskDefinitionAndStatus{}
 
 	for {
 		// get next TaskGroup for groupID
@@ -274,11 +127,17 @@ func runList(credentials *tcclient.Credentials, args []string, out io.Writer, fl
 		}
 
 		for _, t := range ts.Tasks {
-			if filterListTask(t.Status, flags) {
-				err := templ.Execute(out, t)
-				if err != nil {
-					return err
-				}
+			if !filterListTask(t.Status, flags) {
+				continue
+			}
+			// structured output is written once all tasks are fetched
+			if structured {
+				tasks = append(tasks, t)
+				continue
+			}
+			err := templ.Execute(out, t)
+			if err != nil {
+				return err
 			}
 		}
 
@@ -288,6 +147,15 @@ func runList(credentials *tcclient.Credentials, args []string, out io.Writer, fl
 		}
 	}
 
+	if structured {
+		return output.Write(out, flags, tasks, func() *output.Table {
+			table := &output.Table{Header: []string{"TASK ID", "NAME", "STATE"}}
+			for _, t := range tasks {
+				table.AddRow(t.Status.TaskID, t.Task.Metadata.Name, t.Status.State)
+			}
+			return table
+		})
+	}
 	return nil
 }
 
@@ -28,6 +28,8 @@ func (suite *FakeServerSuite) SetupSuite() {
 	handler := http.NewServeMux()
 
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/cancel", cancelHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/rerun", rerunHandler)
+	handler.HandleFunc("/api/queue/v1/task/", createTaskHandler)
 	handler.HandleFunc("/api/queue/v1/task-group/"+fakeGroupID+"/list", listTaskGroupHandler)
 
 	suite.testServer = httptest.NewServer(handler)
@@ -94,6 +96,9 @@ func listTaskGroupHandler(w http.ResponseWriter, _ *http.Request) {
 			        ]
 			      },
 						"task": {
+						  "created": "2017-03-29T15:49:31.389Z",
+						  "deadline": "2017-03-30T15:49:31.389Z",
+						  "expires": "2018-03-30T15:49:31.389Z",
 						  "metadata": {
 							  "name": "test-framework-task/opt"
 							}
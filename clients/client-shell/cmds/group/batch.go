@@ -0,0 +1,264 @@
+package group
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/taskcluster/slugid-go/slugid"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/task"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+)
+
+// batchCommand is a command that acts on each of the tasks of a task group
+// selected by the filter flags.
+type batchCommand struct {
+	// the action, such as "cancel"
+	verb string
+	// the states of the tasks selected if no --state flag is given
+	defaultStates []string
+	// act acts on a single task, writing a line describing the action to out
+	act func(q *tcqueue.Queue, t *tcqueue.TaskDefinitionAndStatus, out io.Writer) error
+}
+
+var (
+	cancelCommand = &batchCommand{
+		verb:          "cancel",
+		defaultStates: []string{"unscheduled", "pending", "running"},
+		act: func(q *tcqueue.Queue, t *tcqueue.TaskDefinitionAndStatus, out io.Writer) error {
+			fmt.Fprintf(out, "cancelling task %s\n", t.Status.TaskID)
+			_, err := q.CancelTask(t.Status.TaskID)
+			return err
+		},
+	}
+	rerunCommand = &batchCommand{
+		verb:          "rerun",
+		defaultStates: []string{"failed", "exception"},
+		act: func(q *tcqueue.Queue, t *tcqueue.TaskDefinitionAndStatus, out io.Writer) error {
+			fmt.Fprintf(out, "rerunning task %s\n", t.Status.TaskID)
+			_, err := q.RerunTask(t.Status.TaskID)
+			return err
+		},
+	}
+	retriggerCommand = &batchCommand{
+		verb:          "retrigger",
+		defaultStates: []string{"failed", "exception"},
+		act: func(q *tcqueue.Queue, t *tcqueue.TaskDefinitionAndStatus, out io.Writer) error {
+			definition, err := task.RetriggerDefinition(&t.Task, false, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			newTaskID := slugid.Nice()
+			fmt.Fprintf(out, "retriggering task %s as %s\n", t.Status.TaskID, newTaskID)
+			_, err = q.CreateTask(newTaskID, definition)
+			return err
+		},
+	}
+)
+
+func init() {
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <taskGroupId>",
+		Short: "Cancel the tasks of a task group.",
+		Long: "This method fetches all tasks in the given task group, and cancels\n" +
+			"those selected by the filter flags; by default, all unscheduled, pending\n" +
+			"and running tasks.\n\n" +
+			"Note that there is a more efficient way to cancel a whole task group with API:\n\n" +
+			"taskcluster api queue sealTaskGroup <taskGroupId> # seal task group is required before calling cancelTaskGroup \n" +
+			"taskcluster api queue cancelTaskGroup <taskGroupId> # cancel all at once\n",
+		RunE:              executeHelperE(runCancel),
+		ValidArgsFunction: completion.TaskGroupIDs,
+	}
+	rerunCmd := &cobra.Command{
+		Use:   "rerun <taskGroupId>",
+		Short: "Rerun the tasks of a task group.",
+		Long: "This method fetches all tasks in the given task group, and reruns\n" +
+			"those selected by the filter flags; by default, all failed and exception\n" +
+			"tasks.  Tasks past their deadline cannot be rerun; use retrigger instead.\n",
+		RunE:              executeHelperE(runRerun),
+		ValidArgsFunction: completion.TaskGroupIDs,
+	}
+	retriggerCmd := &cobra.Command{
+		Use:   "retrigger <taskGroupId>",
+		Short: "Retrigger the tasks of a task group (new taskIds, updated timestamps).",
+		Long: "This method fetches all tasks in the given task group, and creates a copy,\n" +
+			"in the same task group, of each task selected by the filter flags; by\n" +
+			"default, all failed and exception tasks.  As with 'task retrigger', the new\n" +
+			"tasks have updated timestamps, and no dependencies or routes.\n",
+		RunE:              executeHelperE(runRetrigger),
+		ValidArgsFunction: completion.TaskGroupIDs,
+	}
+
+	for _, cmd := range []*cobra.Command{cancelCmd, rerunCmd, retriggerCmd} {
+		cmd.Flags().StringP("worker-type", "w", "", "Only select tasks with a certain worker type.")
+		cmd.Flags().String("name", "", "Only select tasks whose name matches this regular expression.")
+		cmd.Flags().StringSliceP("state", "s", []string{}, "Only select tasks in these states (repeatable).")
+		cmd.Flags().BoolP("dry-run", "n", false, "List the selected tasks without acting on them.")
+		cmd.Flags().BoolP("force", "f", false, "Skip confirmation.")
+		Command.AddCommand(cmd)
+	}
+}
+
+func runCancel(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	return cancelCommand.run(credentials, args, out, flags)
+}
+
+func runRerun(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	return rerunCommand.run(credentials, args, out, flags)
+}
+
+func runRetrigger(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	return retriggerCommand.run(credentials, args, out, flags)
+}
+
+// run selects the tasks of the task group, asks for confirmation, and then
+// acts on each of the selected tasks concurrently, because they are
+// independent of each other.
+func (bc *batchCommand) run(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	groupID := args[0]
+
+	filter, err := taskFilter(flags, bc.defaultStates)
+	if err != nil {
+		return err
+	}
+
+	// list the selected tasks
+	var mu sync.Mutex
+	selected := map[string]string{}
+	_, err = tcqueue.ForEachTaskInGroup(nil, q, groupID, &tcqueue.TaskGroupOptions{Filter: filter}, func(t *tcqueue.TaskDefinitionAndStatus) error {
+		mu.Lock()
+		defer mu.Unlock()
+		selected[t.Status.TaskID] = t.Task.Metadata.Name
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(selected) == 0 {
+		fmt.Fprintf(out, "No suitable tasks found to %s.\n", bc.verb)
+		return nil
+	}
+
+	if dryRun, _ := flags.GetBool("dry-run"); dryRun {
+		fmt.Fprintf(out, "Would %s the following %d tasks:\n", bc.verb, len(selected))
+		listTasks(selected, out)
+		return nil
+	}
+
+	// ask for confirmation before acting
+	if force, _ := flags.GetBool("force"); !force && !confirm(bc.verb, selected, out) {
+		fmt.Fprintln(out, "Aborted.")
+		return nil
+	}
+
+	syncOut := &syncWriter{w: out}
+	_, err = tcqueue.ForEachTaskInGroup(nil, q, groupID, &tcqueue.TaskGroupOptions{
+		Filter: func(t *tcqueue.TaskDefinitionAndStatus) bool {
+			_, ok := selected[t.Status.TaskID]
+			return ok
+		},
+	}, func(t *tcqueue.TaskDefinitionAndStatus) error {
+		return bc.act(q, t, syncOut)
+	})
+	if err != nil {
+		return fmt.Errorf("could not %s all tasks: %v", bc.verb, err)
+	}
+	return nil
+}
+
+// taskFilter returns a function selecting tasks based on the filter flags,
+// with the given default states
+func taskFilter(flags *pflag.FlagSet, defaultStates []string) (func(t *tcqueue.TaskDefinitionAndStatus) bool, error) {
+	states, err := flags.GetStringSlice("state")
+	if err != nil || len(states) == 0 {
+		states = defaultStates
+	}
+	inState := tcqueue.InState(states...)
+
+	// if no worker type is specified, its value is "" so the condition is skipped
+	workerType, _ := flags.GetString("worker-type")
+
+	var namePattern *regexp.Regexp
+	if name, _ := flags.GetString("name"); name != "" {
+		namePattern, err = regexp.Compile(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --name pattern: %v", err)
+		}
+	}
+
+	return func(t *tcqueue.TaskDefinitionAndStatus) bool {
+		if !inState(t) {
+			return false
+		}
+		if workerType != "" && workerType != t.Status.WorkerType {
+			return false
+		}
+		if namePattern != nil && !namePattern.MatchString(t.Task.Metadata.Name) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// listTasks lists the given tasks, by taskId, with their names
+func listTasks(tasks map[string]string, out io.Writer) {
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(out, "\tTask %s: %s\n", id, tasks[id])
+	}
+}
+
+// confirm lists the tasks to be acted on and prompts to confirm the action
+func confirm(verb string, tasks map[string]string, out io.Writer) bool {
+	fmt.Fprintf(out, "The following %d tasks will be %s:\n", len(tasks), pastParticiple(verb))
+	listTasks(tasks, out)
+
+	for {
+		fmt.Fprintf(out, "Are you sure you want to %s these tasks? [y/n] ", verb)
+
+		var c string
+		fmt.Scanf("%s", &c)
+
+		if c == "y" || c == "Y" {
+			return true
+		} else if c == "n" || c == "N" {
+			return false
+		}
+		// otherwise reloop to ask again
+	}
+}
+
+func pastParticiple(verb string) string {
+	switch verb {
+	case "cancel":
+		return "cancelled"
+	case "rerun":
+		return "rerun"
+	}
+	return verb + "ed"
+}
+
+// syncWriter serializes writes to an io.Writer from concurrent goroutines
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
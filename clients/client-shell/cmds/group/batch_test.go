@@ -0,0 +1,97 @@
+package group
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// returns the rerun status on request
+func rerunHandler(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, `{"status": {"state": "pending", "runs": []}}`)
+}
+
+// accepts any task creation
+func createTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = io.WriteString(w, `{"status": {"state": "pending", "runs": []}}`)
+}
+
+func (suite *FakeServerSuite) TestRunCancelDryRun() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("dry-run", true, "")
+
+	args := []string{fakeGroupID}
+	assert.NoError(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("Would cancel the following 1 tasks:\n\tTask ANnmjMocTymeTID0tlNJAw: test-framework-task/opt\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunCancelNameFilter() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("force", true, "")
+	cmd.Flags().String("name", "^build-", "")
+
+	args := []string{fakeGroupID}
+	assert.NoError(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("No suitable tasks found to cancel.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunCancelBadNameFilter() {
+	_, cmd := setUpCommand()
+	cmd.Flags().String("name", "(", "")
+
+	args := []string{fakeGroupID}
+	assert.Error(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+}
+
+func (suite *FakeServerSuite) TestRunCancelWorkerTypeFilter() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("force", true, "")
+	cmd.Flags().String("worker-type", "other-worker-type", "")
+
+	args := []string{fakeGroupID}
+	assert.NoError(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("No suitable tasks found to cancel.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunRerunDefaultStates() {
+	// by default only failed and exception tasks are rerun
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("force", true, "")
+
+	args := []string{fakeGroupID}
+	assert.NoError(suite.T(), runRerun(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("No suitable tasks found to rerun.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunRerun() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("force", true, "")
+	cmd.Flags().StringSlice("state", []string{"pending"}, "")
+	cmd.Flags().String("name", "^test-", "")
+
+	args := []string{fakeGroupID}
+	assert.NoError(suite.T(), runRerun(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("rerunning task ANnmjMocTymeTID0tlNJAw\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunRetrigger() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("force", true, "")
+	cmd.Flags().StringSlice("state", []string{"pending"}, "")
+
+	args := []string{fakeGroupID}
+	assert.NoError(suite.T(), runRetrigger(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Regexp(`^retriggering task ANnmjMocTymeTID0tlNJAw as [A-Za-z0-9_-]{22}\n$`, buf.String())
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !windows
+
+package group
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal returns false, as terminals are not supported on this platform.
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func makeRaw(in, out *os.File) (func(), error) {
+	return nil, errors.New("terminals are not supported on this platform")
+}
+
+func terminalSize(f *os.File) (int, int, error) {
+	return 0, 0, errors.New("terminals are not supported on this platform")
+}
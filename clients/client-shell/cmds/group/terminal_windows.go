@@ -0,0 +1,50 @@
+package group
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// isTerminal returns true if f is a console.
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(f.Fd()), &mode) == nil
+}
+
+// makeRaw puts the console read from by in in a mode where each key is read
+// as soon as it is pressed, without echo, enables escape sequences on the
+// console written to by out, and returns a function restoring their previous
+// modes.  Ctrl-C is still handled by the system.
+func makeRaw(in, out *os.File) (func(), error) {
+	inHandle, outHandle := windows.Handle(in.Fd()), windows.Handle(out.Fd())
+	var inMode, outMode uint32
+	if err := windows.GetConsoleMode(inHandle, &inMode); err != nil {
+		return nil, err
+	}
+	if err := windows.GetConsoleMode(outHandle, &outMode); err != nil {
+		return nil, err
+	}
+
+	raw := inMode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(inHandle, raw); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(outHandle, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		_ = windows.SetConsoleMode(inHandle, inMode)
+		return nil, err
+	}
+	return func() {
+		_ = windows.SetConsoleMode(inHandle, inMode)
+		_ = windows.SetConsoleMode(outHandle, outMode)
+	}, nil
+}
+
+// terminalSize returns the width and height of the console window f.
+func terminalSize(f *os.File) (int, int, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(f.Fd()), &info); err != nil {
+		return 0, 0, err
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
 )
 
@@ -23,6 +24,10 @@ func executeHelperE(f Executor) func(*cobra.Command, []string) error {
 		if len(args) < 1 {
 			return fmt.Errorf("%s expects argument <taskGroupId>", cmd.Name())
 		}
-		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+		if err := f(creds, args, cmd.OutOrStdout(), cmd.Flags()); err != nil {
+			return err
+		}
+		completion.RecordTaskGroupIDs(args[0])
+		return nil
 	}
 }
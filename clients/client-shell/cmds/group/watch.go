@@ -0,0 +1,584 @@
+package group
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+func init() {
+	watchCmd := &cobra.Command{
+		Use:   "watch <taskGroupId>",
+		Short: "Watch the tasks of a task group in a live dashboard.",
+		Long: `Show the tasks of a task group in a dashboard that is refreshed every
+--interval, with failed tasks first, and the duration of the latest run of each
+task.  Select a task with the arrow keys or j/k, and press enter to see the end
+of its log.  Press f to only show failed tasks, r to refresh, and q to quit.`,
+		RunE:              executeHelperE(runWatch),
+		ValidArgsFunction: completion.TaskGroupIDs,
+	}
+	watchCmd.Flags().DurationP("interval", "i", 10*time.Second, "How often to refresh the task group.")
+	Command.AddCommand(watchCmd)
+}
+
+// watchStateOrder is the order in which tasks are listed by state, with the
+// tasks that need attention first.
+var watchStateOrder = []string{"failed", "exception", "running", "pending", "unscheduled", "completed"}
+
+// watchStateColors are the escape sequences coloring the states.
+var watchStateColors = map[string]string{
+	"failed":    "\x1b[31m",
+	"exception": "\x1b[35m",
+	"running":   "\x1b[33m",
+	"pending":   "\x1b[36m",
+	"completed": "\x1b[32m",
+}
+
+func stateRank(state string) int {
+	for i, s := range watchStateOrder {
+		if s == state {
+			return i
+		}
+	}
+	return len(watchStateOrder)
+}
+
+// watchedTask is a task of the watched task group, with the times of its
+// latest run.
+type watchedTask struct {
+	TaskID    string
+	Name      string
+	State     string
+	Scheduled time.Time
+	Started   time.Time
+	Resolved  time.Time
+}
+
+func newWatchedTask(t *tcqueue.TaskDefinitionAndStatus) watchedTask {
+	task := watchedTask{
+		TaskID: t.Status.TaskID,
+		Name:   t.Task.Metadata.Name,
+		State:  t.Status.State,
+	}
+	if runs := t.Status.Runs; len(runs) > 0 {
+		run := runs[len(runs)-1]
+		task.Scheduled = time.Time(run.Scheduled)
+		task.Started = time.Time(run.Started)
+		task.Resolved = time.Time(run.Resolved)
+	}
+	return task
+}
+
+// duration returns how long the latest run of the task ran, or has been
+// running or pending until now.
+func (t watchedTask) duration(now time.Time) string {
+	var d time.Duration
+	switch {
+	case !t.Started.IsZero() && !t.Resolved.IsZero():
+		d = t.Resolved.Sub(t.Started)
+	case !t.Started.IsZero():
+		d = now.Sub(t.Started)
+	case !t.Scheduled.IsZero() && t.Resolved.IsZero():
+		d = now.Sub(t.Scheduled)
+	default:
+		return ""
+	}
+	return d.Round(time.Second).String()
+}
+
+// fetchGroup fetches the tasks of a task group, sorted by state and name.
+func fetchGroup(q *tcqueue.Queue, groupID string) ([]watchedTask, error) {
+	tasks := []watchedTask{}
+	cont := ""
+	for {
+		ts, err := q.ListTaskGroup(groupID, cont, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch tasks for group %s: %v", groupID, err)
+		}
+		for i := range ts.Tasks {
+			tasks = append(tasks, newWatchedTask(&ts.Tasks[i]))
+		}
+		if cont = ts.ContinuationToken; cont == "" {
+			break
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if ri, rj := stateRank(tasks[i].State), stateRank(tasks[j].State); ri != rj {
+			return ri < rj
+		}
+		return tasks[i].Name < tasks[j].Name
+	})
+	return tasks, nil
+}
+
+// watchKey is a key pressed in the dashboard.
+type watchKey int
+
+const (
+	keyUp watchKey = iota
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyBack
+	keyFilter
+	keyRefresh
+	keyQuit
+)
+
+var watchKeySequences = map[string]watchKey{
+	"\x1b[A":  keyUp,
+	"\x1b[B":  keyDown,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+}
+
+var watchKeyBytes = map[byte]watchKey{
+	'k':    keyUp,
+	'j':    keyDown,
+	'\r':   keyEnter,
+	'\n':   keyEnter,
+	'\x1b': keyBack,
+	'\x7f': keyBack,
+	'\b':   keyBack,
+	'f':    keyFilter,
+	'r':    keyRefresh,
+	'q':    keyQuit,
+	'\x03': keyQuit,
+}
+
+// parseKeys returns the keys pressed in the input b, ignoring unknown keys.
+func parseKeys(b []byte) []watchKey {
+	keys := []watchKey{}
+	for len(b) > 0 {
+		matched := false
+		for seq, key := range watchKeySequences {
+			if bytes.HasPrefix(b, []byte(seq)) {
+				keys = append(keys, key)
+				b = b[len(seq):]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if key, ok := watchKeyBytes[b[0]]; ok {
+			keys = append(keys, key)
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// watchAction is what the dashboard needs to do after a key press.
+type watchAction int
+
+const (
+	actionNone watchAction = iota
+	actionRefresh
+	actionFetchLog
+	actionQuit
+)
+
+// watchModel is the state of the dashboard.
+type watchModel struct {
+	groupID string
+	tasks   []watchedTask
+	// the time of the last refresh, and its error, if it failed
+	updated time.Time
+	err     error
+	// the index of the selected task among the visible ones, and of the
+	// first task shown
+	selected int
+	offset   int
+	// true if only failed tasks are shown
+	failuresOnly bool
+	// the task whose log is shown, if any, with the end of its log
+	logTask  *watchedTask
+	logLines []string
+	logErr   error
+}
+
+// visible returns the tasks shown in the list.
+func (m *watchModel) visible() []watchedTask {
+	if !m.failuresOnly {
+		return m.tasks
+	}
+	failures := []watchedTask{}
+	for _, t := range m.tasks {
+		if t.State == "failed" || t.State == "exception" {
+			failures = append(failures, t)
+		}
+	}
+	return failures
+}
+
+// setTasks replaces the tasks after a refresh, keeping the same task selected.
+func (m *watchModel) setTasks(tasks []watchedTask, now time.Time) {
+	selectedID := ""
+	if visible := m.visible(); m.selected < len(visible) {
+		selectedID = visible[m.selected].TaskID
+	}
+	m.tasks = tasks
+	m.updated = now
+	m.err = nil
+	for i, t := range m.visible() {
+		if t.TaskID == selectedID {
+			m.selected = i
+		}
+	}
+	m.clamp()
+}
+
+func (m *watchModel) clamp() {
+	m.selected = min(m.selected, len(m.visible())-1)
+	m.selected = max(m.selected, 0)
+}
+
+// handleKey updates the model for a key pressed while rows tasks are shown.
+func (m *watchModel) handleKey(key watchKey, rows int) watchAction {
+	if key == keyQuit {
+		return actionQuit
+	}
+	if m.logTask != nil {
+		switch key {
+		case keyBack:
+			m.logTask, m.logLines, m.logErr = nil, nil, nil
+		case keyRefresh:
+			return actionFetchLog
+		}
+		return actionNone
+	}
+
+	switch key {
+	case keyUp:
+		m.selected--
+	case keyDown:
+		m.selected++
+	case keyPageUp:
+		m.selected -= rows
+	case keyPageDown:
+		m.selected += rows
+	case keyFilter:
+		m.failuresOnly = !m.failuresOnly
+		m.selected = 0
+	case keyRefresh:
+		return actionRefresh
+	case keyEnter:
+		if visible := m.visible(); len(visible) > 0 {
+			task := visible[m.selected]
+			m.logTask = &task
+			return actionFetchLog
+		}
+	}
+	m.clamp()
+	return actionNone
+}
+
+// listRows returns the number of tasks shown in a terminal of the given
+// height, below the summary and above the key help.
+func listRows(height int) int {
+	return max(height-5, 1)
+}
+
+// render returns the dashboard drawn on a terminal of the given size, as
+// lines without line endings.
+func (m *watchModel) render(width, height int, now time.Time) []string {
+	if m.logTask != nil {
+		return m.renderLog(width, height)
+	}
+
+	status := "updated " + m.updated.Format("15:04:05")
+	if m.updated.IsZero() {
+		status = "loading..."
+	}
+	if m.err != nil {
+		status = "error: " + m.err.Error()
+	}
+	lines := []string{
+		fit(fmt.Sprintf("Task group %s  %s", m.groupID, status), width),
+		fit(m.summary(), width),
+		"",
+		fit(fmt.Sprintf("%-11s %-10s %-22s  %s", "STATE", "DURATION", "TASK ID", "NAME"), width),
+	}
+
+	visible := m.visible()
+	rows := listRows(height)
+	if m.selected < m.offset {
+		m.offset = m.selected
+	}
+	if m.selected >= m.offset+rows {
+		m.offset = m.selected - rows + 1
+	}
+	m.offset = max(min(m.offset, len(visible)-rows), 0)
+	for i := m.offset; i < len(visible) && i < m.offset+rows; i++ {
+		t := visible[i]
+		row := fit(fmt.Sprintf("%-11s %-10s %-22s  %s", t.State, t.duration(now), t.TaskID, t.Name), width)
+		switch {
+		case i == m.selected:
+			row = "\x1b[7m" + row + strings.Repeat(" ", max(width-len([]rune(row)), 0)) + "\x1b[0m"
+		case watchStateColors[t.State] != "" && len(row) >= len(t.State):
+			row = watchStateColors[t.State] + row[:len(t.State)] + "\x1b[0m" + row[len(t.State):]
+		}
+		lines = append(lines, row)
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+
+	filter := "f failures only"
+	if m.failuresOnly {
+		filter = "f all tasks"
+	}
+	return append(lines, fit("up/down select  enter log  "+filter+"  r refresh  q quit", width))
+}
+
+// summary returns the number of tasks in each state.
+func (m *watchModel) summary() string {
+	counts := map[string]int{}
+	for _, t := range m.tasks {
+		counts[t.State]++
+	}
+	parts := []string{}
+	for _, state := range watchStateOrder {
+		if counts[state] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[state], state))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d tasks", len(m.tasks))
+	}
+	return fmt.Sprintf("%d tasks: %s", len(m.tasks), strings.Join(parts, ", "))
+}
+
+func (m *watchModel) renderLog(width, height int) []string {
+	lines := []string{fit(fmt.Sprintf("Log of %s (%s, %s)", m.logTask.Name, m.logTask.TaskID, m.logTask.State), width)}
+	switch {
+	case m.logErr != nil:
+		lines = append(lines, fit("error: "+m.logErr.Error(), width))
+	case m.logLines == nil:
+		lines = append(lines, "loading...")
+	default:
+		logLines := m.logLines[max(len(m.logLines)-(height-2), 0):]
+		for _, line := range logLines {
+			lines = append(lines, fit(line, width))
+		}
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	return append(lines, fit("esc back  r refresh  q quit", width))
+}
+
+// controlSequences matches escape sequences and other control characters,
+// which would garble the dashboard.
+var controlSequences = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b.?|[\x00-\x08\x0b-\x1f\x7f]`)
+
+// fit removes control characters from s and truncates it to width runes.
+func fit(s string, width int) string {
+	s = controlSequences.ReplaceAllString(strings.ReplaceAll(s, "\t", "    "), "")
+	if runes := []rune(s); len(runes) > width {
+		return string(runes[:max(width, 0)])
+	}
+	return s
+}
+
+// logTailLines is the number of lines at the end of a log shown in the
+// dashboard.
+const logTailLines = 1000
+
+// logFetchTimeout bounds the time spent reading the log of a running task,
+// which is streamed until the task is resolved.
+var logFetchTimeout = 5 * time.Second
+
+// fetchLogTail returns the lines at the end of the log of a task.
+func fetchLogTail(taskID string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), logFetchTimeout)
+	defer cancel()
+
+	path := tcurls.API(config.RootURL(), "queue", "v1", "task/"+taskID+"/artifacts/public/logs/live.log")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the log of task %s: %v", taskID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("could not fetch the log of task %s: received unexpected response code %v", taskID, resp.StatusCode)
+	}
+
+	lines, err := tailLines(resp.Body, logTailLines)
+	if err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("could not read the log of task %s: %v", taskID, err)
+	}
+	return lines, nil
+}
+
+// tailLines returns the last n lines read from r, even if reading fails.
+func tailLines(r io.Reader, n int) ([]string, error) {
+	lines := []string{}
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if len(lines) == n {
+				lines = lines[1:]
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
+
+// runWatch shows the dashboard of a task group until q is pressed.
+func runWatch(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	groupID := args[0]
+
+	interval, _ := flags.GetDuration("interval")
+	if interval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+
+	term, ok := out.(*os.File)
+	if !ok || !isTerminal(term) || !isTerminal(os.Stdin) {
+		return errors.New("watch needs a terminal; use 'taskcluster group status' or 'taskcluster group list' in scripts")
+	}
+	restore, err := makeRaw(os.Stdin, term)
+	if err != nil {
+		return fmt.Errorf("could not set up the terminal: %v", err)
+	}
+	defer restore()
+	// use the alternate screen, without cursor, so that the dashboard does
+	// not overwrite the terminal's history
+	fmt.Fprint(term, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(term, "\x1b[?25h\x1b[?1049l")
+	// the client logs the errors of the requests it retries, which would
+	// garble the dashboard; the final errors are shown in it
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(logOutput)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// the reads of stdin are never interrupted, but the goroutine ends with
+	// the process
+	input := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			input <- append([]byte{}, buf[:n]...)
+		}
+	}()
+
+	type groupResult struct {
+		tasks []watchedTask
+		err   error
+	}
+	groups := make(chan groupResult, 1)
+	refreshing := false
+	refresh := func() {
+		if refreshing {
+			return
+		}
+		refreshing = true
+		go func() {
+			tasks, err := fetchGroup(q, groupID)
+			groups <- groupResult{tasks, err}
+		}()
+	}
+
+	type logResult struct {
+		taskID string
+		lines  []string
+		err    error
+	}
+	logs := make(chan logResult, 1)
+	fetchLog := func(taskID string) {
+		go func() {
+			lines, err := fetchLogTail(taskID)
+			logs <- logResult{taskID, lines, err}
+		}()
+	}
+
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+	// redraw every second, to update the durations of running tasks
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	m := &watchModel{groupID: groupID}
+	refresh()
+	for {
+		width, height, err := terminalSize(term)
+		if err != nil || width == 0 || height == 0 {
+			width, height = 80, 24
+		}
+		fmt.Fprint(term, "\x1b[H"+strings.Join(m.render(width, height, time.Now()), "\x1b[K\r\n")+"\x1b[K\x1b[J")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case b := <-input:
+			for _, key := range parseKeys(b) {
+				switch m.handleKey(key, listRows(height)) {
+				case actionQuit:
+					return nil
+				case actionRefresh:
+					refresh()
+				case actionFetchLog:
+					m.logLines, m.logErr = nil, nil
+					fetchLog(m.logTask.TaskID)
+				}
+			}
+		case r := <-groups:
+			refreshing = false
+			if r.err != nil {
+				m.err = r.err
+			} else {
+				m.setTasks(r.tasks, time.Now())
+			}
+		case r := <-logs:
+			if m.logTask != nil && m.logTask.TaskID == r.taskID {
+				m.logLines, m.logErr = r.lines, r.err
+			}
+		case <-poll.C:
+			refresh()
+		case <-tick.C:
+		}
+	}
+}
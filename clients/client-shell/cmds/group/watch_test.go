@@ -0,0 +1,158 @@
+package group
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+var watchNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func watchTasks() []watchedTask {
+	return []watchedTask{
+		{TaskID: "failedTaskIDxxxxxxxxxx", Name: "test", State: "failed", Started: watchNow.Add(-time.Hour), Resolved: watchNow.Add(-50 * time.Minute)},
+		{TaskID: "runningTaskIDxxxxxxxxx", Name: "build", State: "running", Started: watchNow.Add(-90 * time.Second)},
+		{TaskID: "pendingTaskIDxxxxxxxxx", Name: "lint", State: "pending", Scheduled: watchNow.Add(-time.Minute)},
+		{TaskID: "completedTaskIDxxxxxxx", Name: "docs", State: "completed", Started: watchNow.Add(-time.Hour), Resolved: watchNow.Add(-59 * time.Minute)},
+	}
+}
+
+func (suite *FakeServerSuite) TestFetchGroup() {
+	tasks, err := fetchGroup(makeQueue(&tcclient.Credentials{}), fakeGroupID)
+	suite.NoError(err)
+	suite.Equal([]watchedTask{{
+		TaskID:    fakeTaskID,
+		Name:      "test-framework-task/opt",
+		State:     "pending",
+		Scheduled: time.Date(2017, 3, 29, 15, 49, 32, 292000000, time.UTC),
+		Started:   time.Date(2017, 3, 29, 15, 50, 32, 412000000, time.UTC),
+		Resolved:  time.Date(2017, 3, 29, 15, 53, 27, 562000000, time.UTC),
+	}}, tasks)
+}
+
+func TestWatchedTaskDuration(t *testing.T) {
+	durations := []string{}
+	for _, task := range watchTasks() {
+		durations = append(durations, task.duration(watchNow))
+	}
+	assert.Equal(t, []string{"10m0s", "1m30s", "1m0s", "1m0s"}, durations)
+	assert.Equal(t, "", watchedTask{State: "unscheduled"}.duration(watchNow))
+}
+
+func TestParseKeys(t *testing.T) {
+	assert.Equal(t,
+		[]watchKey{keyDown, keyUp, keyUp, keyDown, keyPageDown, keyEnter, keyBack, keyQuit},
+		parseKeys([]byte("j\x1b[Ak\x1b[B\x1b[6~\rx\x1bq")))
+}
+
+func TestWatchModelNavigation(t *testing.T) {
+	m := &watchModel{groupID: fakeGroupID}
+	m.setTasks(watchTasks(), watchNow)
+
+	assert.Equal(t, actionNone, m.handleKey(keyUp, 2))
+	assert.Equal(t, 0, m.selected)
+	m.handleKey(keyPageDown, 2)
+	assert.Equal(t, 2, m.selected)
+	m.handleKey(keyPageDown, 2)
+	assert.Equal(t, 3, m.selected)
+
+	// the selected task stays selected when the tasks are refreshed
+	tasks := watchTasks()
+	tasks = append(tasks[3:], tasks[:3]...)
+	m.setTasks(tasks, watchNow)
+	assert.Equal(t, 0, m.selected)
+
+	assert.Equal(t, actionRefresh, m.handleKey(keyRefresh, 2))
+	assert.Equal(t, actionQuit, m.handleKey(keyQuit, 2))
+}
+
+func TestWatchModelFailuresOnly(t *testing.T) {
+	m := &watchModel{groupID: fakeGroupID}
+	m.setTasks(watchTasks(), watchNow)
+	m.handleKey(keyDown, 10)
+
+	m.handleKey(keyFilter, 10)
+	assert.Equal(t, 0, m.selected)
+	require.Len(t, m.visible(), 1)
+	assert.Equal(t, "failed", m.visible()[0].State)
+	m.handleKey(keyDown, 10)
+	assert.Equal(t, 0, m.selected)
+}
+
+func TestWatchModelLog(t *testing.T) {
+	m := &watchModel{groupID: fakeGroupID}
+	m.setTasks(watchTasks(), watchNow)
+	m.handleKey(keyDown, 10)
+
+	assert.Equal(t, actionFetchLog, m.handleKey(keyEnter, 10))
+	require.NotNil(t, m.logTask)
+	assert.Equal(t, "runningTaskIDxxxxxxxxx", m.logTask.TaskID)
+	assert.Contains(t, strings.Join(m.render(80, 10, watchNow), "\n"), "loading...")
+
+	m.logLines = []string{"line 1", "line 2", "line \x1b[31m3\x1b[0m"}
+	lines := m.render(80, 4, watchNow)
+	assert.Equal(t, []string{"Log of build (runningTaskIDxxxxxxxxx, running)", "line 2", "line 3", "esc back  r refresh  q quit"}, lines)
+
+	assert.Equal(t, actionFetchLog, m.handleKey(keyRefresh, 10))
+	assert.Equal(t, actionNone, m.handleKey(keyBack, 10))
+	assert.Nil(t, m.logTask)
+	assert.Equal(t, 1, m.selected)
+}
+
+func TestWatchModelRender(t *testing.T) {
+	m := &watchModel{groupID: fakeGroupID}
+	m.setTasks(watchTasks(), watchNow)
+	m.handleKey(keyDown, 2)
+	m.handleKey(keyDown, 2)
+
+	lines := m.render(60, 7, watchNow.Add(30*time.Second))
+	require.Len(t, lines, 7)
+	assert.Equal(t, "Task group "+fakeGroupID+"  updated 12:00:00", lines[0])
+	assert.Equal(t, "4 tasks: 1 failed, 1 running, 1 pending, 1 completed", lines[1])
+	assert.Equal(t, "STATE       DURATION   TASK ID                 NAME", lines[3])
+	// two rows fit, scrolled to show the selected task
+	assert.Equal(t, "\x1b[33mrunning\x1b[0m     2m0s       runningTaskIDxxxxxxxxx  build", lines[4])
+	assert.Equal(t, "\x1b[7mpending     1m30s      pendingTaskIDxxxxxxxxx  lint         \x1b[0m", lines[5])
+	assert.Equal(t, "up/down select  enter log  f failures only  r refresh  q qui", lines[6])
+}
+
+func TestTailLines(t *testing.T) {
+	lines, err := tailLines(strings.NewReader("a\nb\r\nc\nd"), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d"}, lines)
+}
+
+func TestFetchLogTail(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/artifacts/public/logs/live.log", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < logTailLines+10; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+		}
+		// a running task's log is streamed until the task is resolved
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	server := httptest.NewServer(handler)
+	config.SetRootURL(server.URL)
+	timeout := logFetchTimeout
+	logFetchTimeout = 100 * time.Millisecond
+	t.Cleanup(func() {
+		server.Close()
+		config.SetRootURL("")
+		logFetchTimeout = timeout
+	})
+
+	lines, err := fetchLogTail(fakeTaskID)
+	require.NoError(t, err)
+	require.Len(t, lines, logTailLines)
+	assert.Equal(t, "line 10", lines[0])
+	assert.Equal(t, fmt.Sprintf("line %d", logTailLines+9), lines[len(lines)-1])
+}
@@ -0,0 +1,127 @@
+package index
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/spf13/pflag"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcindex"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+// runLs lists the namespaces and then the tasks directly under a namespace,
+// one per line.
+func runLs(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	index := makeIndex(credentials)
+	namespace := ""
+	if len(args) > 0 {
+		namespace = args[0]
+	}
+
+	var listing struct {
+		Namespaces []tcindex.Namespace `json:"namespaces"`
+		Tasks      []tcindex.Task      `json:"tasks"`
+	}
+	listing.Namespaces = []tcindex.Namespace{}
+	listing.Tasks = []tcindex.Task{}
+
+	namespaces := index.ListNamespacesPages(nil, namespace, "")
+	for namespaces.Next() {
+		listing.Namespaces = append(listing.Namespaces, namespaces.Page().Namespaces...)
+	}
+	if err := namespaces.Err(); err != nil {
+		return fmt.Errorf("could not list the namespaces under %q: %v", namespace, err)
+	}
+
+	tasks := index.ListTasksPages(nil, namespace, "")
+	for tasks.Next() {
+		listing.Tasks = append(listing.Tasks, tasks.Page().Tasks...)
+	}
+	if err := tasks.Err(); err != nil {
+		return fmt.Errorf("could not list the tasks under %q: %v", namespace, err)
+	}
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, &listing, func() *output.Table {
+			table := &output.Table{Header: []string{"NAMESPACE", "TASK ID", "EXPIRES"}}
+			for _, ns := range listing.Namespaces {
+				table.AddRow(ns.Namespace+"/", "", output.Time(ns.Expires))
+			}
+			for _, task := range listing.Tasks {
+				table.AddRow(task.Namespace, task.TaskID, output.Time(task.Expires))
+			}
+			return table
+		})
+	}
+
+	for _, ns := range listing.Namespaces {
+		fmt.Fprintf(out, "%s/\n", ns.Namespace)
+	}
+	for _, task := range listing.Tasks {
+		fmt.Fprintf(out, "%s %s\n", task.Namespace, task.TaskID)
+	}
+	return nil
+}
+
+// runFind prints the taskId of the task indexed at a namespace, or the URL
+// of one of its artifacts, or downloads that artifact.
+func runFind(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	namespace := args[0]
+	artifact, _ := flagSet.GetString("artifact")
+	download, _ := flagSet.GetBool("download")
+	if download && artifact == "" {
+		return fmt.Errorf("--download requires --artifact")
+	}
+
+	task, err := makeIndex(credentials).FindTask(namespace)
+	if err != nil {
+		return fmt.Errorf("could not find the task indexed at %s: %v", namespace, err)
+	}
+
+	switch {
+	case artifact == "" && output.Selected(flagSet):
+		return output.Write(out, flagSet, task, func() *output.Table {
+			table := &output.Table{Header: []string{"NAMESPACE", "TASK ID", "RANK", "EXPIRES"}}
+			table.AddRow(task.Namespace, task.TaskID, fmt.Sprint(task.Rank), output.Time(task.Expires))
+			return table
+		})
+	case artifact == "":
+		fmt.Fprintln(out, task.TaskID)
+	case !download:
+		// the index redirects this URL to the artifact of the latest run of
+		// the task it finds, so it stays valid as new tasks are indexed
+		fmt.Fprintln(out, tcurls.API(config.RootURL(), "index", "v1", "task/"+url.QueryEscape(namespace)+"/artifacts/"+url.QueryEscape(artifact)))
+	default:
+		file, _ := flagSet.GetString("file")
+		if file == "" {
+			file = path.Base(artifact)
+		}
+		return downloadLatestArtifact(credentials, task.TaskID, artifact, file, out)
+	}
+	return nil
+}
+
+// downloadLatestArtifact downloads an artifact of the latest run of a task
+// to a file, or to out if the file is '-'.
+func downloadLatestArtifact(credentials *tcclient.Credentials, taskID, name, file string, out io.Writer) error {
+	q := makeQueue(credentials)
+	if file == "-" {
+		if _, _, err := q.DownloadArtifactToWriter(taskID, -1, name, out); err != nil {
+			return fmt.Errorf("could not download artifact %s of task %s: %v", name, taskID, err)
+		}
+		return nil
+	}
+
+	if _, _, err := q.DownloadArtifactToFile(taskID, -1, name, file); err != nil {
+		_ = os.Remove(file)
+		return fmt.Errorf("could not download artifact %s of task %s: %v", name, taskID, err)
+	}
+	fmt.Fprintf(out, "Downloaded artifact %s of task %s to %s.\n", name, taskID, file)
+	return nil
+}
@@ -0,0 +1,81 @@
+// Package index implements the index subcommands.
+package index
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcindex"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+var (
+	// Command is the root of the index subtree.
+	Command = &cobra.Command{
+		Use:   "index",
+		Short: "Provides commands to browse the index and find indexed tasks.",
+	}
+)
+
+func init() {
+	lsCmd := &cobra.Command{
+		Use:   "ls [namespace]",
+		Short: "List the namespaces and indexed tasks directly under a namespace.",
+		Long: `Lists the namespaces directly under the given namespace, or under the root
+of the index if none is given, followed by the tasks indexed directly under
+it.  Namespaces are shown with a trailing '/', and tasks with their taskId.`,
+		Args:              cobra.MaximumNArgs(1),
+		RunE:              executeHelperE(runLs),
+		ValidArgsFunction: completion.IndexNamespaces,
+	}
+	output.AddFlag(lsCmd)
+
+	findCmd := &cobra.Command{
+		Use:   "find <namespace>",
+		Short: "Find the task indexed at a namespace, or its latest artifact.",
+		Long: `Prints the taskId of the task indexed at the given namespace.
+
+With --artifact, prints the URL of the named artifact of the latest run of
+that task instead, and with --download as well, downloads the artifact to
+the file given by --file, which defaults to the last part of the artifact
+name ('-' for stdout).  This allows scripts to fetch the artifacts of, for
+example, the latest nightly build.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              executeHelperE(runFind),
+		ValidArgsFunction: completion.IndexedTasks,
+	}
+	findCmd.Flags().StringP("artifact", "a", "", "The name of an artifact of the indexed task, such as public/build/target.tar.gz.")
+	findCmd.Flags().BoolP("download", "d", false, "Download the artifact given by --artifact.")
+	findCmd.Flags().StringP("file", "f", "", "The file to download the artifact to ('-' for stdout).")
+	output.AddFlag(findCmd)
+
+	Command.AddCommand(lsCmd, findCmd)
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the index subcommands.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
+
+func makeIndex(credentials *tcclient.Credentials) *tcindex.Index {
+	return tcindex.New(credentials, config.RootURL())
+}
+
+func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
+	return tcqueue.New(credentials, config.RootURL())
+}
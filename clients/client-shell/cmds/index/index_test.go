@@ -0,0 +1,151 @@
+package index
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+const (
+	fakeNamespace = "project.app.nightly.latest"
+	fakeTaskID    = "ANnmjMocTymeTID0tlNJAw"
+	fakeArtifact  = "public/build/target.tar.gz"
+	fakeContent   = "the build"
+)
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/index/v1/namespaces/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/api/index/v1/namespaces/") != "project.app" {
+			_, _ = io.WriteString(w, `{"namespaces": []}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"namespaces": [
+			{"namespace": "project.app.nightly", "name": "nightly", "expires": "2030-01-01T00:00:00.000Z"},
+			{"namespace": "project.app.release", "name": "release", "expires": "2030-01-01T00:00:00.000Z"}
+		]}`)
+	})
+	handler.HandleFunc("/api/index/v1/tasks/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/api/index/v1/tasks/") != "project.app" {
+			_, _ = io.WriteString(w, `{"tasks": []}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"tasks": [
+			{"namespace": "project.app.latest", "taskId": "`+fakeTaskID+`", "rank": 0, "data": {}, "expires": "2030-01-01T00:00:00.000Z"}
+		]}`)
+	})
+	handler.HandleFunc("/api/index/v1/task/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/api/index/v1/task/") != fakeNamespace {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, `{"namespace": "`+fakeNamespace+`", "taskId": "`+fakeTaskID+`", "rank": 0, "data": {}, "expires": "2030-01-01T00:00:00.000Z"}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/artifact-content/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/api/queue/v1/task/"+fakeTaskID+"/artifact-content/") != fakeArtifact {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, `{"storageType": "s3", "url": "`+suite.testServer.URL+`/blob"}`)
+	})
+	handler.HandleFunc("/blob", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, fakeContent)
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func setUpCommand() (*bytes.Buffer, *cobra.Command) {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	return buf, cmd
+}
+
+func setUpFindCommand(artifact string, download bool, file string) (*bytes.Buffer, *cobra.Command) {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("artifact", artifact, "")
+	cmd.Flags().Bool("download", download, "")
+	cmd.Flags().String("file", file, "")
+	return buf, cmd
+}
+
+func (suite *FakeServerSuite) TestLs() {
+	buf, cmd := setUpCommand()
+
+	suite.NoError(runLs(&tcclient.Credentials{}, []string{"project.app"}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("project.app.nightly/\nproject.app.release/\nproject.app.latest "+fakeTaskID+"\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestFind() {
+	buf, cmd := setUpFindCommand("", false, "")
+
+	suite.NoError(runFind(&tcclient.Credentials{}, []string{fakeNamespace}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(fakeTaskID+"\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestFindNotFound() {
+	_, cmd := setUpFindCommand("", false, "")
+
+	suite.Error(runFind(&tcclient.Credentials{}, []string{"project.nope"}, cmd.OutOrStdout(), cmd.Flags()))
+}
+
+func (suite *FakeServerSuite) TestFindArtifactURL() {
+	buf, cmd := setUpFindCommand(fakeArtifact, false, "")
+
+	suite.NoError(runFind(&tcclient.Credentials{}, []string{fakeNamespace}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(suite.testServer.URL+"/api/index/v1/task/"+fakeNamespace+"/artifacts/public%2Fbuild%2Ftarget.tar.gz\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestFindDownload() {
+	output := filepath.Join(suite.T().TempDir(), "target.tar.gz")
+	_, cmd := setUpFindCommand(fakeArtifact, true, output)
+
+	suite.NoError(runFind(&tcclient.Credentials{}, []string{fakeNamespace}, cmd.OutOrStdout(), cmd.Flags()))
+
+	data, err := os.ReadFile(output)
+	suite.NoError(err)
+	suite.Equal(fakeContent, string(data))
+}
+
+func (suite *FakeServerSuite) TestFindDownloadStdout() {
+	buf, cmd := setUpFindCommand(fakeArtifact, true, "-")
+
+	suite.NoError(runFind(&tcclient.Credentials{}, []string{fakeNamespace}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(fakeContent, buf.String())
+}
+
+func (suite *FakeServerSuite) TestFindDownloadWithoutArtifact() {
+	_, cmd := setUpFindCommand("", true, "")
+
+	suite.ErrorContains(runFind(&tcclient.Credentials{}, []string{fakeNamespace}, cmd.OutOrStdout(), cmd.Flags()), "--download requires --artifact")
+}
@@ -0,0 +1,42 @@
+// Package object implements the object subcommands.
+package object
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcobject"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the object subtree.
+	Command = &cobra.Command{
+		Use:   "object",
+		Short: "Provides commands to work with the object service.",
+	}
+)
+
+func init() {
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the object subcommands.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
+
+func makeObject(credentials *tcclient.Credentials) *tcobject.Object {
+	return tcobject.New(credentials, config.RootURL())
+}
@@ -0,0 +1,131 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	fromNow "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/from-now"
+)
+
+func init() {
+	uploadCmd := &cobra.Command{
+		Use:   "upload <name> <file>",
+		Short: "Upload a file as an object.",
+		Long: `Uploads a file, or stdin if the file is "-", to the object service as the
+object with the given name.  The upload method is negotiated with the object
+service, and the hashes of the content are recorded so that downloads can be
+verified.  Uploading again with the same --upload-id retries an upload that
+failed, as long as the content is the same.`,
+		Args: cobra.ExactArgs(2),
+		RunE: executeHelperE(runUpload),
+	}
+	uploadCmd.Flags().StringP("project", "p", "", "The project the object belongs to.")
+	uploadCmd.Flags().StringP("expires", "e", "1 year", "When the object expires, as a time such as 2030-01-01T00:00:00Z, or a duration from now such as '1 year'.")
+	uploadCmd.Flags().StringP("content-type", "t", "", "The content type of the object [default: based on the file's extension].")
+	uploadCmd.Flags().String("upload-id", "", "The uploadId of the upload [default: a new slugId].")
+	if err := uploadCmd.MarkFlagRequired("project"); err != nil {
+		panic(fmt.Sprintf("Cannot mark flag required: %s", err))
+	}
+	Command.AddCommand(uploadCmd)
+}
+
+// runUpload uploads a file as an object.
+func runUpload(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	name, path := args[0], args[1]
+	projectID, _ := flagSet.GetString("project")
+	uploadID, _ := flagSet.GetString("upload-id")
+
+	expiresFlag, _ := flagSet.GetString("expires")
+	expires, err := ParseExpires(expiresFlag, time.Now())
+	if err != nil {
+		return err
+	}
+
+	contentType, _ := flagSet.GetString("content-type")
+	if contentType == "" {
+		contentType = ContentType(path)
+	}
+
+	file, size, cleanup, err := OpenFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	err = makeObject(credentials).UploadFromReadSeeker(projectID, name, contentType, size, expires, uploadID, file)
+	if err != nil {
+		return fmt.Errorf("could not upload %s as object %s: %v", path, name, err)
+	}
+	fmt.Fprintf(out, "Uploaded object %s (%d bytes, %s)\n", name, size, contentType)
+	return nil
+}
+
+// ParseExpires parses an expiration time given as an RFC3339 time, or as a
+// duration from now such as "1 year".
+func ParseExpires(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := fromNow.FromNow(value, now)
+	if err != nil || !t.After(now) {
+		return time.Time{}, fmt.Errorf("invalid expiration time '%s': expected a time such as 2030-01-01T00:00:00Z, or a duration from now such as '1 year'", value)
+	}
+	return t, nil
+}
+
+// ContentType returns the content type of the file at path, based on its
+// extension.
+func ContentType(path string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" && path != "-" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// OpenFile opens the file at path for upload, returning it with its size and
+// a function to call once the upload is done.  If path is "-", stdin is copied
+// to a temporary file first, since uploads may need to be retried from the
+// start.
+func OpenFile(path string) (*os.File, int64, func(), error) {
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, nil, err
+		}
+		if !info.Mode().IsRegular() {
+			file.Close()
+			return nil, 0, nil, fmt.Errorf("%s is not a regular file", path)
+		}
+		return file, info.Size(), func() { file.Close() }, nil
+	}
+
+	file, err := os.CreateTemp("", "taskcluster-upload-")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+	size, err := io.Copy(file, os.Stdin)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("could not read stdin: %v", err)
+	}
+	return file, size, cleanup, nil
+}
@@ -0,0 +1,95 @@
+package object
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+func TestParseExpires(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expires, err := ParseExpires("2030-01-01T00:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), expires)
+
+	expires, err = ParseExpires("1 year 2 days", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC), expires)
+
+	_, err = ParseExpires("tomorrow", now)
+	assert.Error(t, err)
+	_, err = ParseExpires("", now)
+	assert.Error(t, err)
+}
+
+func TestContentType(t *testing.T) {
+	assert.Equal(t, "application/json", ContentType("dir/data.json"))
+	assert.Equal(t, "application/octet-stream", ContentType("dir/data"))
+	assert.Equal(t, "application/octet-stream", ContentType("-"))
+}
+
+func TestUpload(t *testing.T) {
+	var created, finished map[string]interface{}
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/object/v1/upload/builds/data.json", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		_, _ = io.WriteString(w, `{"projectId": "proj", "uploadId": "upload", "expires": "2030-01-01T00:00:00.000Z", "uploadMethod": {"dataInline": true}}`)
+	})
+	handler.HandleFunc("/api/object/v1/finish-upload/builds/data.json", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&finished))
+		_, _ = io.WriteString(w, `{}`)
+	})
+	server := httptest.NewServer(handler)
+	config.SetRootURL(server.URL)
+	t.Cleanup(func() {
+		server.Close()
+		config.SetRootURL("")
+	})
+
+	path := filepath.Join(t.TempDir(), "data.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a": 1}`), 0644))
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("project", "proj", "")
+	cmd.Flags().String("expires", "2030-01-01T00:00:00Z", "")
+	cmd.Flags().String("content-type", "", "")
+	cmd.Flags().String("upload-id", "upload", "")
+	out := &stringWriter{}
+
+	require.NoError(t, runUpload(&tcclient.Credentials{}, []string{"builds/data.json", path}, out, cmd.Flags()))
+	assert.Equal(t, "Uploaded object builds/data.json (8 bytes, application/json)\n", out.String())
+
+	assert.Equal(t, "proj", created["projectId"])
+	assert.Equal(t, "upload", created["uploadId"])
+	assert.Equal(t, "2030-01-01T00:00:00.000Z", created["expires"])
+	inline := created["proposedUploadMethods"].(map[string]interface{})["dataInline"].(map[string]interface{})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{"a": 1}`)), inline["objectData"])
+	assert.Equal(t, "application/json", inline["contentType"])
+	assert.Contains(t, finished["hashes"], "sha256")
+}
+
+type stringWriter struct {
+	data []byte
+}
+
+func (w *stringWriter) Write(p []byte) (int, error) {
+	w.data = append(w.data, p...)
+	return len(p), nil
+}
+
+func (w *stringWriter) String() string {
+	return string(w.data)
+}
@@ -0,0 +1,122 @@
+// Package purgecache implements the purge-cache command.
+package purgecache
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcpurgecache"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+var (
+	// Command is the purge-cache command.
+	Command = &cobra.Command{
+		Use:   "purge-cache <workerPoolId> <cacheName>",
+		Short: "Purge a cache on the workers of a worker pool, or list recent purge requests.",
+		Long: `Requests that the workers of the given worker pool purge the named cache,
+so that tasks do not reuse a poisoned cache.  Workers remove the cache before
+they next use it; caches created after the request are not affected.
+
+With --list, the open purge requests are listed instead, most recent first,
+either for the given worker pool or, with no arguments, for all worker pools.
+--output applies to this list.`,
+		Args:              cobra.RangeArgs(0, 2),
+		RunE:              executeHelperE(runPurgeCache),
+		ValidArgsFunction: completion.WorkerPoolIDs,
+	}
+)
+
+func init() {
+	Command.Flags().BoolP("list", "l", false, "List the open purge requests rather than making one.")
+	output.AddFlag(Command)
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the purge-cache command.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
+
+func makePurgeCache(credentials *tcclient.Credentials) *tcpurgecache.PurgeCache {
+	return tcpurgecache.New(credentials, config.RootURL())
+}
+
+// runPurgeCache requests a cache purge, or lists the open purge requests.
+func runPurgeCache(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	if list, _ := flagSet.GetBool("list"); list {
+		if len(args) > 1 {
+			return fmt.Errorf("purge-cache --list expects at most one argument <workerPoolId>")
+		}
+		return listRequests(credentials, args, out, flagSet)
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("purge-cache expects arguments <workerPoolId> <cacheName>")
+	}
+
+	pc := makePurgeCache(credentials)
+	workerPoolID, cacheName := args[0], args[1]
+	if err := pc.PurgeCache(workerPoolID, &tcpurgecache.PurgeCacheRequest{CacheName: cacheName}); err != nil {
+		return fmt.Errorf("could not purge cache %s of worker pool %s: %v", cacheName, workerPoolID, err)
+	}
+	fmt.Fprintf(out, "Requested a purge of cache %s on the workers of worker pool %s.\n", cacheName, workerPoolID)
+	return nil
+}
+
+// listRequests lists the open purge requests of a worker pool, or of all
+// worker pools if none is given, one per line.
+func listRequests(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	pc := makePurgeCache(credentials)
+
+	requests := []tcpurgecache.PurgeCacheRequestsEntry{}
+	if len(args) == 1 {
+		list, err := pc.PurgeRequests(args[0], "")
+		if err != nil {
+			return fmt.Errorf("could not list the purge requests of worker pool %s: %v", args[0], err)
+		}
+		requests = list.Requests
+	} else {
+		pages := pc.AllPurgeRequestsPages(nil, "")
+		for pages.Next() {
+			requests = append(requests, pages.Page().Requests...)
+		}
+		if err := pages.Err(); err != nil {
+			return fmt.Errorf("could not list purge requests: %v", err)
+		}
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		return time.Time(requests[i].Before).After(time.Time(requests[j].Before))
+	})
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, requests, func() *output.Table {
+			table := &output.Table{Header: []string{"BEFORE", "WORKER POOL", "CACHE"}}
+			for _, r := range requests {
+				table.AddRow(output.Time(r.Before), r.ProvisionerID+"/"+r.WorkerType, r.CacheName)
+			}
+			return table
+		})
+	}
+
+	for _, r := range requests {
+		fmt.Fprintf(out, "%s %s/%s %s\n", r.Before, r.ProvisionerID, r.WorkerType, r.CacheName)
+	}
+	return nil
+}
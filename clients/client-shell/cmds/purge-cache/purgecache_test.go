@@ -0,0 +1,111 @@
+package purgecache
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+const fakeWorkerPoolID = "proj-misc/ci"
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// the body of the last purgeCache request
+	purged []byte
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/purge-cache/v1/purge-cache/", func(w http.ResponseWriter, r *http.Request) {
+		switch path := strings.TrimPrefix(r.URL.Path, "/api/purge-cache/v1/purge-cache/"); {
+		case path == "list":
+			if r.URL.Query().Get("continuationToken") == "" {
+				_, _ = io.WriteString(w, `{"requests": [
+					{"provisionerId": "proj-misc", "workerType": "ci", "cacheName": "checkouts", "before": "2024-01-01T00:00:00.000Z"}
+				], "continuationToken": "next"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"requests": [
+				{"provisionerId": "proj-misc", "workerType": "build", "cacheName": "cargo", "before": "2024-01-03T00:00:00.000Z"}
+			]}`)
+		case path != fakeWorkerPoolID:
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost:
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(suite.T(), err)
+			suite.purged = body
+			_, _ = io.WriteString(w, "{}")
+		default:
+			_, _ = io.WriteString(w, `{"requests": [
+				{"provisionerId": "proj-misc", "workerType": "ci", "cacheName": "checkouts", "before": "2024-01-01T00:00:00.000Z"},
+				{"provisionerId": "proj-misc", "workerType": "ci", "cacheName": "pip", "before": "2024-01-02T00:00:00.000Z"}
+			]}`)
+		}
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.purged = nil
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func setUpCommand(list bool) (*bytes.Buffer, *cobra.Command) {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	cmd.Flags().Bool("list", list, "")
+	return buf, cmd
+}
+
+func (suite *FakeServerSuite) TestPurgeCache() {
+	buf, cmd := setUpCommand(false)
+
+	suite.NoError(runPurgeCache(&tcclient.Credentials{}, []string{fakeWorkerPoolID, "checkouts"}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.JSONEq(`{"cacheName": "checkouts"}`, string(suite.purged))
+	suite.Equal("Requested a purge of cache checkouts on the workers of worker pool proj-misc/ci.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestPurgeCacheMissingArgs() {
+	_, cmd := setUpCommand(false)
+
+	suite.Error(runPurgeCache(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+	suite.Nil(suite.purged)
+}
+
+func (suite *FakeServerSuite) TestListWorkerPool() {
+	buf, cmd := setUpCommand(true)
+
+	suite.NoError(runPurgeCache(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("2024-01-02T00:00:00.000Z proj-misc/ci pip\n2024-01-01T00:00:00.000Z proj-misc/ci checkouts\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestListAll() {
+	buf, cmd := setUpCommand(true)
+
+	suite.NoError(runPurgeCache(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("2024-01-03T00:00:00.000Z proj-misc/build cargo\n2024-01-01T00:00:00.000Z proj-misc/ci checkouts\n", buf.String())
+}
@@ -0,0 +1,63 @@
+// Package roles implements the roles subcommands.
+package roles
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+var (
+	// Command is the root of the roles subtree.
+	Command = &cobra.Command{
+		Use:   "roles",
+		Short: "Provides commands to explore roles.",
+	}
+)
+
+func init() {
+	traceCmd := &cobra.Command{
+		Use:   "trace <scope>",
+		Short: "Show which roles grant a scope, and how.",
+		Long: `Lists the roles which grant the given scope, one per line, with the chain of
+roles through which each grants it and the scope at the end of that chain
+which satisfies it.  For example:
+
+    hook-id:proj/nightly -> repo:github.com/org/proj:*: queue:create-task:*
+
+means that the role hook-id:proj/nightly has a scope assuming the role
+repo:github.com/org/proj:*, which has the scope queue:create-task:*.
+
+Scopes of parameterized roles, containing <..>, are not substituted, so
+roles granting the scope only through such scopes are not found.`,
+		Args: cobra.ExactArgs(1),
+		RunE: executeHelperE(runTrace),
+	}
+	output.AddFlag(traceCmd)
+
+	Command.AddCommand(traceCmd)
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the roles subcommands.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
+
+func makeAuth(credentials *tcclient.Credentials) *tcauth.Auth {
+	return tcauth.New(credentials, config.RootURL())
+}
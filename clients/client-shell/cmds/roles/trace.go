@@ -0,0 +1,127 @@
+package roles
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+// grant describes how a role grants a scope.
+type grant struct {
+	// the role granting the scope
+	RoleID string `json:"roleId"`
+	// the roles assumed, in order, by the role to get the scope; empty if
+	// the role has the scope itself
+	Via []string `json:"via"`
+	// the scope of the last role in the chain which satisfies the scope
+	Scope string `json:"scope"`
+}
+
+// runTrace lists the roles which grant a scope.
+func runTrace(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	scope := args[0]
+
+	var roles []tcauth.GetRoleResponse
+	pages := makeAuth(credentials).ListRoles2Pages(nil, "")
+	for pages.Next() {
+		roles = append(roles, pages.Page().Roles...)
+	}
+	if err := pages.Err(); err != nil {
+		return fmt.Errorf("could not list roles: %v", err)
+	}
+
+	grants := traceScope(roles, scope)
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, grants, func() *output.Table {
+			table := &output.Table{Header: []string{"ROLE", "VIA", "SCOPE"}}
+			for _, g := range grants {
+				table.AddRow(g.RoleID, strings.Join(g.Via, " -> "), g.Scope)
+			}
+			return table
+		})
+	}
+
+	if len(grants) == 0 {
+		fmt.Fprintf(out, "No role grants %s.\n", scope)
+		return nil
+	}
+	for _, g := range grants {
+		fmt.Fprintf(out, "%s: %s\n", strings.Join(append([]string{g.RoleID}, g.Via...), " -> "), g.Scope)
+	}
+	return nil
+}
+
+// traceScope returns how each of the roles granting scope grants it, ordered
+// by roleId.  Each role's chain is one of the shortest.
+func traceScope(roles []tcauth.GetRoleResponse, scope string) []grant {
+	// the roles whose expanded scopes satisfy scope
+	var granting []*tcauth.GetRoleResponse
+	for i := range roles {
+		if tcscopes.Given(roles[i].ExpandedScopes).SatisfiesScope(scope) {
+			granting = append(granting, &roles[i])
+		}
+	}
+	sort.Slice(granting, func(i, j int) bool { return granting[i].RoleID < granting[j].RoleID })
+
+	grants := []grant{}
+	for _, role := range granting {
+		// breadth-first search through the assumed roles which grant scope,
+		// for a role which has the scope itself
+		type path struct {
+			role *tcauth.GetRoleResponse
+			via  []string
+		}
+		queue := []path{{role: role}}
+		visited := map[string]bool{role.RoleID: true}
+		for len(queue) > 0 {
+			p := queue[0]
+			queue = queue[1:]
+			if s, ok := satisfying(p.role.Scopes, scope); ok {
+				grants = append(grants, grant{RoleID: role.RoleID, Via: p.via, Scope: s})
+				break
+			}
+			for _, s := range p.role.Scopes {
+				if !strings.HasPrefix(s, "assume:") {
+					continue
+				}
+				for _, next := range granting {
+					if !visited[next.RoleID] && assumes(s, next.RoleID) {
+						visited[next.RoleID] = true
+						via := append(append([]string{}, p.via...), next.RoleID)
+						queue = append(queue, path{role: next, via: via})
+					}
+				}
+			}
+		}
+	}
+	return grants
+}
+
+// satisfying returns the first of scopes which satisfies scope.
+func satisfying(scopes []string, scope string) (string, bool) {
+	for _, s := range scopes {
+		if tcscopes.Given([]string{s}).SatisfiesScope(scope) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// assumes reports whether the scope gives the scopes of the role: either the
+// scope satisfies assume:<roleId>, or the roleId ends with * and the scope
+// starts with the rest of assume:<roleId>.
+func assumes(scope, roleID string) bool {
+	if tcscopes.Given([]string{scope}).SatisfiesScope("assume:" + roleID) {
+		return true
+	}
+	prefix, isPattern := strings.CutSuffix(roleID, "*")
+	return isPattern && strings.HasPrefix(scope, "assume:"+prefix)
+}
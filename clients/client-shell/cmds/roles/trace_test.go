@@ -0,0 +1,43 @@
+package roles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+)
+
+// role returns a role with its expanded scopes, as returned by the auth
+// service
+func role(roleID string, scopes []string, expanded ...string) tcauth.GetRoleResponse {
+	return tcauth.GetRoleResponse{RoleID: roleID, Scopes: scopes, ExpandedScopes: append(append([]string{}, scopes...), expanded...)}
+}
+
+func TestTraceScope(t *testing.T) {
+	roles := []tcauth.GetRoleResponse{
+		role("repo:github.com/org/proj:*", []string{"queue:create-task:highest:proj/*", "assume:project:proj"},
+			"secrets:get:project/proj/*"),
+		role("project:proj", []string{"secrets:get:project/proj/*"}),
+		role("hook-id:proj/nightly", []string{"assume:repo:github.com/org/proj:branch:main"},
+			"queue:create-task:highest:proj/*", "assume:project:proj", "secrets:get:project/proj/*"),
+		role("project:other", []string{"secrets:get:project/other/*"}),
+		role("admin", []string{"*"}),
+	}
+
+	assert.Equal(t, []grant{
+		{RoleID: "admin", Via: nil, Scope: "*"},
+		{RoleID: "hook-id:proj/nightly", Via: []string{"repo:github.com/org/proj:*", "project:proj"}, Scope: "secrets:get:project/proj/*"},
+		{RoleID: "project:proj", Via: nil, Scope: "secrets:get:project/proj/*"},
+		{RoleID: "repo:github.com/org/proj:*", Via: []string{"project:proj"}, Scope: "secrets:get:project/proj/*"},
+	}, traceScope(roles, "secrets:get:project/proj/deploy"))
+
+	assert.Equal(t, []grant{}, traceScope(roles[:4], "auth:create-client:x"))
+}
+
+func TestAssumes(t *testing.T) {
+	assert.True(t, assumes("assume:project:proj", "project:proj"))
+	assert.True(t, assumes("assume:project:*", "project:proj"))
+	assert.True(t, assumes("assume:repo:github.com/org/proj:branch:main", "repo:github.com/org/proj:*"))
+	assert.False(t, assumes("assume:project:proj", "project:other"))
+	assert.False(t, assumes("queue:*", "project:proj"))
+}
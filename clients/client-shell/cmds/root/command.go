@@ -3,6 +3,7 @@ package root
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
 )
 
 var (
@@ -20,10 +21,12 @@ func setUpRootCmd() *cobra.Command {
 	}
 
 	verbose := rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	profile := rootCmd.PersistentFlags().String("profile", "", "Configuration profile to use, instead of the one selected with 'taskcluster config use' [env: TASKCLUSTER_PROFILE]")
 
 	// function to run before every subcommand
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		setUpLogs(*verbose)
+		config.Setup(*profile)
 	}
 
 	return rootCmd
@@ -0,0 +1,16 @@
+package root
+
+// ExitError is returned by commands which should make taskcluster exit with a
+// particular exit code, rather than 1, such as `task await`.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (err *ExitError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *ExitError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,72 @@
+package scopes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+// runExpand prints the expansion of the given scopes, one per line.
+func runExpand(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	expanded, err := makeAuth(credentials).ExpandScopes(&tcauth.SetOfScopes{Scopes: args})
+	if err != nil {
+		return fmt.Errorf("could not expand scopes: %v", err)
+	}
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, expanded, func() *output.Table {
+			table := &output.Table{Header: []string{"SCOPE"}}
+			for _, scope := range expanded.Scopes {
+				table.AddRow(scope)
+			}
+			return table
+		})
+	}
+
+	for _, scope := range expanded.Scopes {
+		fmt.Fprintln(out, scope)
+	}
+	return nil
+}
+
+// runSatisfies checks whether the given scopes satisfy the required scopes,
+// listing those which are not satisfied.
+func runSatisfies(credentials *tcclient.Credentials, _ []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	auth := makeAuth(credentials)
+	required, _ := flagSet.GetStringSlice("required")
+	have, _ := flagSet.GetStringSlice("have")
+
+	if !flagSet.Changed("have") {
+		if credentials == nil {
+			return errors.New("--have must be given when there are no credentials")
+		}
+		current, err := auth.CurrentScopes()
+		if err != nil {
+			return fmt.Errorf("could not get the scopes of the current credentials: %v", err)
+		}
+		have = current.Scopes
+	}
+
+	unsatisfied, err := tcscopes.Given(have).Unsatisfied(required, auth)
+	if err != nil {
+		return fmt.Errorf("could not expand scopes: %v", err)
+	}
+	if len(unsatisfied) == 0 {
+		fmt.Fprintln(out, "All of the required scopes are satisfied.")
+		return nil
+	}
+
+	sort.Strings(unsatisfied)
+	fmt.Fprintln(out, "The following required scopes are not satisfied:")
+	for _, scope := range unsatisfied {
+		fmt.Fprintf(out, "  %s\n", scope)
+	}
+	return fmt.Errorf("%d of %d required scopes are not satisfied", len(unsatisfied), len(required))
+}
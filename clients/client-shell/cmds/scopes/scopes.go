@@ -0,0 +1,70 @@
+// Package scopes implements the scopes subcommands.
+package scopes
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+var (
+	// Command is the root of the scopes subtree.
+	Command = &cobra.Command{
+		Use:   "scopes",
+		Short: "Provides commands to expand and check scopes.",
+	}
+)
+
+func init() {
+	expandCmd := &cobra.Command{
+		Use:   "expand <scope>...",
+		Short: "Expand scopes, replacing assume: scopes with the scopes of the roles they assume.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  executeHelperE(runExpand),
+	}
+	output.AddFlag(expandCmd)
+
+	satisfiesCmd := &cobra.Command{
+		Use:   "satisfies --required <scope>... [--have <scope>...]",
+		Short: "Check whether some scopes satisfy the required scopes.",
+		Long: `Checks whether the scopes given by --have, or else the scopes of the current
+credentials, satisfy all of the scopes given by --required, after expanding
+the roles they assume.  The required scopes which are not satisfied are
+listed, and the command fails if there are any.
+
+Both flags may be repeated, or given a comma-separated list of scopes.`,
+		Args: cobra.NoArgs,
+		RunE: executeHelperE(runSatisfies),
+	}
+	satisfiesCmd.Flags().StringSlice("required", nil, "The scopes which must be satisfied.")
+	satisfiesCmd.Flags().StringSlice("have", nil, "The scopes to check (default: the scopes of the current credentials).")
+	if err := satisfiesCmd.MarkFlagRequired("required"); err != nil {
+		panic(err)
+	}
+
+	Command.AddCommand(expandCmd, satisfiesCmd)
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the scopes subcommands.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
+
+func makeAuth(credentials *tcclient.Credentials) *tcauth.Auth {
+	return tcauth.New(credentials, config.RootURL())
+}
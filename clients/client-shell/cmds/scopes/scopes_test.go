@@ -0,0 +1,104 @@
+package scopes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+// the roles of the fake auth service
+var fakeRoles = map[string][]string{
+	"project:ci": {"queue:create-task:highest:proj-ci/*", "secrets:get:project/ci/*"},
+}
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/auth/v1/scopes/expand", func(w http.ResponseWriter, r *http.Request) {
+		var given tcauth.SetOfScopes
+		assert.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&given))
+		expanded := tcauth.SetOfScopes{Scopes: []string{}}
+		for _, scope := range given.Scopes {
+			expanded.Scopes = append(expanded.Scopes, scope)
+			if roleID, found := strings.CutPrefix(scope, "assume:"); found {
+				expanded.Scopes = append(expanded.Scopes, fakeRoles[roleID]...)
+			}
+		}
+		assert.NoError(suite.T(), json.NewEncoder(w).Encode(&expanded))
+	})
+	handler.HandleFunc("/api/auth/v1/scopes/current", func(w http.ResponseWriter, _ *http.Request) {
+		assert.NoError(suite.T(), json.NewEncoder(w).Encode(&tcauth.SetOfScopes{Scopes: []string{"assume:project:ci"}}))
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func setUpSatisfiesCommand(required []string, have []string) (*bytes.Buffer, *cobra.Command) {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	cmd.Flags().StringSlice("required", required, "")
+	cmd.Flags().StringSlice("have", nil, "")
+	if have != nil {
+		_ = cmd.Flags().Set("have", strings.Join(have, ","))
+	}
+	return buf, cmd
+}
+
+func (suite *FakeServerSuite) TestExpand() {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+
+	suite.NoError(runExpand(&tcclient.Credentials{}, []string{"assume:project:ci", "foo"}, buf, cmd.Flags()))
+
+	suite.Equal("assume:project:ci\nqueue:create-task:highest:proj-ci/*\nsecrets:get:project/ci/*\nfoo\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestSatisfies() {
+	buf, cmd := setUpSatisfiesCommand([]string{"secrets:get:project/ci/deploy"}, []string{"assume:project:ci"})
+
+	suite.NoError(runSatisfies(&tcclient.Credentials{}, nil, buf, cmd.Flags()))
+
+	suite.Equal("All of the required scopes are satisfied.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestSatisfiesCurrentCredentials() {
+	buf, cmd := setUpSatisfiesCommand([]string{"secrets:get:project/ci/deploy"}, nil)
+
+	suite.NoError(runSatisfies(&tcclient.Credentials{}, nil, buf, cmd.Flags()))
+
+	suite.Equal("All of the required scopes are satisfied.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestNotSatisfied() {
+	buf, cmd := setUpSatisfiesCommand([]string{"secrets:get:project/release/key", "queue:create-task:highest:proj-ci/b", "auth:create-client:x"}, []string{"assume:project:ci"})
+
+	err := runSatisfies(&tcclient.Credentials{}, nil, buf, cmd.Flags())
+
+	suite.EqualError(err, "2 of 3 required scopes are not satisfied")
+	suite.Equal("The following required scopes are not satisfied:\n  auth:create-client:x\n  secrets:get:project/release/key\n", buf.String())
+}
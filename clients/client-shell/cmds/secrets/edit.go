@@ -0,0 +1,223 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcsecrets"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/editor"
+	"gopkg.in/yaml.v3"
+)
+
+// runEditor opens a file in the user's editor; tests replace it
+var runEditor = editor.Run
+
+// editableSecret is the YAML document in which a secret is edited.
+type editableSecret struct {
+	Expires string      `yaml:"expires"`
+	Secret  interface{} `yaml:"secret"`
+}
+
+const editHeader = `# Edit the secret %s below; lines starting with '#' are ignored.
+# The secret is written back when the editor exits, unless it has been
+# changed by someone else in the meantime.
+`
+
+// runEdit lets the user edit a secret, and writes it back if it was not
+// changed server-side while it was being edited.
+func runEdit(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	s := makeSecrets(credentials)
+	name := args[0]
+
+	original, err := s.Get(name)
+	if err != nil {
+		return fmt.Errorf("could not get the secret %s: %v", name, err)
+	}
+	document, err := secretToYAML(original)
+	if err != nil {
+		return fmt.Errorf("could not convert the secret %s to YAML: %v", name, err)
+	}
+	header := fmt.Sprintf(editHeader, name)
+	edited, editedFile, err := editor.EditTemp("secret-*.yml", append([]byte(header), document...), runEditor)
+	if err != nil {
+		return err
+	}
+	if edited == nil {
+		fmt.Fprintf(out, "No changes; secret %s was not written.\n", name)
+		return nil
+	}
+
+	// keepEdits adds the location of the edited secret to an error
+	keepEdits := func(err error) error {
+		return fmt.Errorf("%v\nThe edited secret was saved in %s", err, editedFile)
+	}
+
+	secret, err := secretFromYAML(edited)
+	if err != nil {
+		return keepEdits(fmt.Errorf("invalid secret: %v", err))
+	}
+
+	// the secrets service has no versioning, so the best that can be done
+	// is to check that the secret is unchanged just before writing it
+	current, err := s.Get(name)
+	if err != nil {
+		return keepEdits(fmt.Errorf("could not check whether the secret %s has changed: %v", name, err))
+	}
+	if !sameSecret(original, current) {
+		return keepEdits(fmt.Errorf("the secret %s was changed by someone else while it was being edited; it was not written", name))
+	}
+
+	if err := s.Set(name, secret); err != nil {
+		return keepEdits(fmt.Errorf("could not write the secret %s: %v", name, err))
+	}
+	_ = os.Remove(editedFile)
+	fmt.Fprintf(out, "Wrote secret %s.\n", name)
+	return nil
+}
+
+// secretToYAML renders a secret as an editableSecret YAML document.
+func secretToYAML(secret *tcsecrets.Secret) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(secret.Secret))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err := encoder.Encode(&editableSecret{
+		Expires: time.Time(secret.Expires).UTC().Format(time.RFC3339Nano),
+		Secret:  fromJSONNumbers(value),
+	})
+	if err == nil {
+		err = encoder.Close()
+	}
+	return buf.Bytes(), err
+}
+
+// fromJSONNumbers replaces the json.Numbers in a decoded JSON value with
+// ints or float64s, which YAML renders as numbers rather than strings.
+func fromJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = fromJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = fromJSONNumbers(item)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// secretFromYAML parses an edited editableSecret YAML document, checking
+// that the secret can be represented as JSON.
+func secretFromYAML(document []byte) (*tcsecrets.Secret, error) {
+	var edited editableSecret
+	if err := yaml.Unmarshal(document, &edited); err != nil {
+		return nil, err
+	}
+	expires, err := time.Parse(time.RFC3339, edited.Expires)
+	if err != nil {
+		return nil, fmt.Errorf("expires must be a date such as %s: %v", time.Now().UTC().Format(time.RFC3339), err)
+	}
+	value, err := toJSONCompatible(edited.Secret, "secret")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &tcsecrets.Secret{
+		Expires: tcclient.Time(expires),
+		Secret:  json.RawMessage(data),
+	}, nil
+}
+
+// toJSONCompatible checks that a value decoded from YAML can be represented
+// as JSON, converting timestamps to strings.  The path locates the value in
+// error messages.
+func toJSONCompatible(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, int, int64, uint64:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%s: %v cannot be represented as JSON", path, v)
+		}
+		return v, nil
+	case string:
+		// !!binary values are decoded to strings which need not be UTF-8
+		if !utf8.ValidString(v) {
+			return nil, fmt.Errorf("%s: binary data cannot be represented as JSON; use a base64-encoded string", path)
+		}
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if result[i], err = toJSONCompatible(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			var err error
+			if result[key], err = toJSONCompatible(item, path+"."+key); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			stringKey, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: key %v is not a string, so cannot be represented as JSON", path, key)
+			}
+			var err error
+			if result[stringKey], err = toJSONCompatible(item, path+"."+stringKey); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%s: %T cannot be represented as JSON", path, v)
+	}
+}
+
+// sameSecret reports whether two versions of a secret have the same value
+// and expiration date.
+func sameSecret(a, b *tcsecrets.Secret) bool {
+	if !time.Time(a.Expires).Equal(time.Time(b.Expires)) {
+		return false
+	}
+	var aValue, bValue interface{}
+	if json.Unmarshal(a.Secret, &aValue) != nil || json.Unmarshal(b.Secret, &bValue) != nil {
+		return bytes.Equal(a.Secret, b.Secret)
+	}
+	return reflect.DeepEqual(aValue, bValue)
+}
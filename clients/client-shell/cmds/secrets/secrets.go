@@ -0,0 +1,60 @@
+// Package secrets implements the secrets subcommands.
+package secrets
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcsecrets"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the secrets subtree.
+	Command = &cobra.Command{
+		Use:   "secrets",
+		Short: "Provides commands to manage secrets.",
+	}
+)
+
+func init() {
+	editCmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit a secret as YAML and write it back.",
+		Long: `Opens the secret in the editor given by $VISUAL or $EDITOR, as a YAML
+document holding its expiration date and value, and writes the secret back
+once the editor exits.  The value must be representable as JSON: mappings
+must have string keys, and numbers must be finite.
+
+The secret is only written back if it has not been changed by someone else
+since it was opened; otherwise, the edited secret is kept in a file so that
+the edits are not lost.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              executeHelperE(runEdit),
+		ValidArgsFunction: completion.SecretNames,
+	}
+
+	Command.AddCommand(editCmd)
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the secrets subcommands.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
+
+func makeSecrets(credentials *tcclient.Credentials) *tcsecrets.Secrets {
+	return tcsecrets.New(credentials, config.RootURL())
+}
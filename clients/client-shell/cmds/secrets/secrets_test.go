@@ -0,0 +1,191 @@
+package secrets
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+const fakeSecretName = "project/ci/deploy"
+
+const fakeSecret = `{"expires": "2030-01-01T00:00:00.000Z", "secret": {"token": "abc", "retries": 3, "hosts": ["a", "b"]}}`
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+
+	mu sync.Mutex
+	// the secret as stored on the fake server
+	stored string
+	// the body of the last set request
+	written []byte
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/secrets/v1/secret/", func(w http.ResponseWriter, r *http.Request) {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		if strings.TrimPrefix(r.URL.Path, "/api/secrets/v1/secret/") != fakeSecretName {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = io.WriteString(w, suite.stored)
+		case http.MethodPut:
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(suite.T(), err)
+			suite.written = body
+			suite.stored = string(body)
+			_, _ = io.WriteString(w, "{}")
+		}
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.stored = fakeSecret
+	suite.written = nil
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func setUpCommand() (*bytes.Buffer, *cobra.Command) {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	return buf, cmd
+}
+
+// setEditor replaces the editor for the duration of the test with one that
+// replaces old with new in the edited file, remembering the file's name
+func (suite *FakeServerSuite) setEditor(old, new string, filename *string) {
+	orig := runEditor
+	runEditor = func(name string) error {
+		*filename = name
+		data, err := os.ReadFile(name)
+		suite.NoError(err)
+		suite.Contains(string(data), old)
+		return os.WriteFile(name, []byte(strings.Replace(string(data), old, new, 1)), 0644)
+	}
+	suite.T().Cleanup(func() { runEditor = orig })
+}
+
+func (suite *FakeServerSuite) TestEdit() {
+	var filename string
+	suite.setEditor("token: abc", "token: def\n  added: 2024-01-01", &filename)
+	buf, cmd := setUpCommand()
+
+	suite.NoError(runEdit(&tcclient.Credentials{}, []string{fakeSecretName}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("Wrote secret project/ci/deploy.\n", buf.String())
+	suite.JSONEq(`{"expires": "2030-01-01T00:00:00.000Z", "secret": {"token": "def", "added": "2024-01-01T00:00:00Z", "retries": 3, "hosts": ["a", "b"]}}`, string(suite.written))
+	suite.NoFileExists(filename)
+}
+
+func (suite *FakeServerSuite) TestEditExpires() {
+	var filename string
+	suite.setEditor("expires: \"2030-01-01T00:00:00Z\"", "expires: \"2031-06-01T12:00:00Z\"", &filename)
+	_, cmd := setUpCommand()
+
+	suite.NoError(runEdit(&tcclient.Credentials{}, []string{fakeSecretName}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.JSONEq(`{"expires": "2031-06-01T12:00:00.000Z", "secret": {"token": "abc", "retries": 3, "hosts": ["a", "b"]}}`, string(suite.written))
+}
+
+func (suite *FakeServerSuite) TestEditNoChanges() {
+	orig := runEditor
+	runEditor = func(string) error { return nil }
+	defer func() { runEditor = orig }()
+	buf, cmd := setUpCommand()
+
+	suite.NoError(runEdit(&tcclient.Credentials{}, []string{fakeSecretName}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("No changes; secret project/ci/deploy was not written.\n", buf.String())
+	suite.Nil(suite.written)
+}
+
+func (suite *FakeServerSuite) TestEditChangedMeanwhile() {
+	var filename string
+	orig := runEditor
+	runEditor = func(name string) error {
+		filename = name
+		// someone else updates the secret while it is being edited
+		suite.mu.Lock()
+		suite.stored = strings.Replace(fakeSecret, "abc", "xyz", 1)
+		suite.mu.Unlock()
+		data, err := os.ReadFile(name)
+		suite.NoError(err)
+		return os.WriteFile(name, []byte(strings.Replace(string(data), "abc", "def", 1)), 0644)
+	}
+	defer func() { runEditor = orig }()
+	_, cmd := setUpCommand()
+
+	err := runEdit(&tcclient.Credentials{}, []string{fakeSecretName}, cmd.OutOrStdout(), cmd.Flags())
+
+	suite.ErrorContains(err, "was changed by someone else")
+	suite.ErrorContains(err, filename)
+	suite.Nil(suite.written)
+	data, readErr := os.ReadFile(filename)
+	suite.NoError(readErr)
+	suite.Contains(string(data), "token: def")
+	_ = os.Remove(filename)
+}
+
+func (suite *FakeServerSuite) TestEditNotJSONCompatible() {
+	var filename string
+	suite.setEditor("retries: 3", "retries: .nan", &filename)
+	_, cmd := setUpCommand()
+
+	err := runEdit(&tcclient.Credentials{}, []string{fakeSecretName}, cmd.OutOrStdout(), cmd.Flags())
+
+	suite.ErrorContains(err, "secret.retries: NaN cannot be represented as JSON")
+	suite.ErrorContains(err, filename)
+	suite.Nil(suite.written)
+	_ = os.Remove(filename)
+}
+
+func (suite *FakeServerSuite) TestEditInvalidYAML() {
+	var filename string
+	suite.setEditor("token: abc", "token: [abc", &filename)
+	_, cmd := setUpCommand()
+
+	err := runEdit(&tcclient.Credentials{}, []string{fakeSecretName}, cmd.OutOrStdout(), cmd.Flags())
+
+	suite.ErrorContains(err, "invalid secret")
+	suite.Nil(suite.written)
+	_ = os.Remove(filename)
+}
+
+func TestToJSONCompatible(t *testing.T) {
+	_, err := toJSONCompatible(map[interface{}]interface{}{1: "one"}, "secret")
+	assert.EqualError(t, err, "secret: key 1 is not a string, so cannot be represented as JSON")
+
+	_, err = toJSONCompatible([]interface{}{"ok", "\xff"}, "secret")
+	assert.ErrorContains(t, err, "secret[1]: binary data")
+
+	value, err := toJSONCompatible(map[interface{}]interface{}{"a": []interface{}{1, 2.5, nil}}, "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{1, 2.5, nil}}, value)
+}
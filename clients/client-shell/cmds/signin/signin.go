@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
@@ -40,7 +41,10 @@ tc-signin() { eval ` + "`" + `taskcluster signin "${@}"` + "`" + `; }
 
 This will set environment variables in your shell session containing the credentials.
 Note that the JS and Python client recognize the same environment variables, so any
-tools using those libraries can also benefit from this signin method.`,
+tools using those libraries can also benefit from this signin method.
+
+With --cache, the credentials are also saved in the local credential cache, where
+other commands and Go tools can find them without the environment variables.`,
 
 		RunE: cmdSignin,
 	}
@@ -50,6 +54,7 @@ tools using those libraries can also benefit from this signin method.`,
 	cmd.Flags().String("expires", "1d", "Lifetime for this client (keep it short to avoid risk from accidental disclosure).")
 	cmd.Flags().StringArrayP("scope", "s", []string{"*"}, "(can be repeated) Scopes for this client (limit this to avoid risk from accidental disclosure).")
 	cmd.Flags().IntP("port", "p", 0, "Port to use; defaults to random ephemeral port.")
+	cmd.Flags().Bool("cache", false, "Also save the credentials in the local credential cache, for use by other tools.")
 
 	root.Command.AddCommand(cmd)
 }
@@ -86,6 +91,12 @@ func cmdSignin(cmd *cobra.Command, _ []string) error {
 		}
 		log.Infoln("Credentials output as environment variables")
 
+		if useCache, _ := cmd.Flags().GetBool("cache"); useCache {
+			if err := cacheCredentials(rootURL, qs.Get("clientId"), qs.Get("accessToken")); err != nil {
+				log.Errorf("Could not save credentials in the credential cache: %s", err)
+			}
+		}
+
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`
 			<!doctype html>
@@ -172,6 +183,32 @@ func cmdSignin(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
+// Save the credentials in the local credential cache, along with the client's
+// scopes and expiry, if they can be found
+func cacheCredentials(rootURL, clientID, accessToken string) error {
+	file, err := tcclient.DefaultCredentialCacheFile()
+	if err != nil {
+		return err
+	}
+	cached := &tcclient.CachedCredentials{
+		RootURL:     rootURL,
+		ClientID:    clientID,
+		AccessToken: accessToken,
+	}
+	auth := tcauth.New(&tcclient.Credentials{ClientID: clientID, AccessToken: accessToken}, rootURL)
+	if client, err := auth.Client(clientID); err == nil {
+		cached.Scopes = client.Scopes
+		cached.Expires = time.Time(client.Expires)
+	} else {
+		log.Warnf("Could not look up client %s, so its credentials will be cached without renewal: %s", clientID, err)
+	}
+	if err := tcclient.SaveCachedCredentials(file, cached); err != nil {
+		return err
+	}
+	log.Infoln("Credentials saved in " + file)
+	return nil
+}
+
 // Return an appropriate exit code based on whether we have credentials.
 // Useful for shell scripting around 'taskcluster signin' calls.
 func checkSignin() error {
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/taskcluster/slugid-go/slugid"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
 )
 
 // runCancel cancels the runs of a given task.
@@ -20,7 +22,7 @@ func runCancel(credentials *tcclient.Credentials, args []string, out io.Writer,
 	taskID := args[0]
 
 	if noop {
-		return displayNoopMsg("Would cancel", credentials, args)
+		return displayNoopMsg("Would cancel", credentials, args, out)
 	}
 
 	if confirm {
@@ -51,7 +53,7 @@ func runRerun(credentials *tcclient.Credentials, args []string, out io.Writer, f
 	taskID := args[0]
 
 	if noop {
-		return displayNoopMsg("Would re-run", credentials, args)
+		return displayNoopMsg("Would re-run", credentials, args, out)
 	}
 
 	if confirm {
@@ -91,6 +93,9 @@ func runRerun(credentials *tcclient.Credentials, args []string, out io.Writer, f
 //
 // Otherwise, default behavior is to omit those as taskcluster-tools does:
 // https://github.com/taskcluster/taskcluster-tools/blob/e8b6d45f10e7520f717b7a9f5db87d550c74d15e/src/views/UnifiedInspector/ActionsMenu.jsx#L141-L158
+//
+// The new definition can be modified with '--env' and '--set' before it is
+// submitted; see applyOverrides.
 func runRetrigger(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
 	q := makeQueue(credentials)
 	taskID := args[0]
@@ -101,23 +106,60 @@ func runRetrigger(credentials *tcclient.Credentials, args []string, out io.Write
 	}
 
 	exactRetrigger, _ := flagSet.GetBool("exact")
+	env, _ := flagSet.GetStringArray("env")
+	set, _ := flagSet.GetStringArray("set")
+	dryRun, _ := flagSet.GetBool("dry-run")
 
 	newTaskID := slugid.Nice()
-	now := time.Now().UTC()
+	newT, err := RetriggerDefinition(t, exactRetrigger, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	newT, err = applyOverrides(newT, env, set)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		rendered, err := json.MarshalIndent(newT, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(rendered))
+		return nil
+	}
+
+	c, err := q.CreateTask(newTaskID, newT)
+	if err != nil {
+		return fmt.Errorf("could not create task: %v", err)
+	}
 
+	// If we got no error, that means the task was successfully submitted
+	completion.RecordTaskIDs(c.Status.TaskID)
+	completion.RecordTaskGroupIDs(c.Status.TaskGroupID)
+	fmt.Fprintf(out, "Task %s created\n", c.Status.TaskID)
+	return nil
+}
+
+// RetriggerDefinition returns the definition of a new task retriggering the
+// task with definition t: a copy of it created at now, with the same
+// durations until its deadline and expiry, and with no retries.  Unless exact
+// is true, the new task has no dependencies and no routes.
+func RetriggerDefinition(t *tcqueue.TaskDefinitionResponse, exact bool, now time.Time) (*tcqueue.TaskDefinitionRequest, error) {
 	origCreated, err := time.Parse(time.RFC3339, t.Created.String())
 	if err != nil {
-		return fmt.Errorf("could not parse created date: %s", t.Created)
+		return nil, fmt.Errorf("could not parse created date: %s", t.Created)
 	}
 
 	origDeadline, err := time.Parse(time.RFC3339, t.Deadline.String())
 	if err != nil {
-		return fmt.Errorf("could not parse deadline date: %s", t.Deadline)
+		return nil, fmt.Errorf("could not parse deadline date: %s", t.Deadline)
 	}
 
 	origExpires, err := time.Parse(time.RFC3339, t.Expires.String())
 	if err != nil {
-		return fmt.Errorf("could not parse created date: %s", t.Expires)
+		return nil, fmt.Errorf("could not parse created date: %s", t.Expires)
 	}
 
 	// TaskDefinitionRequest: https://github.com/taskcluster/taskcluster-client-go/blob/88cfe471bfe2eb8fc9bc22d9cde6a65e74a9f3e5/tcqueue/types.go#L1368-L1549
@@ -125,12 +167,12 @@ func runRetrigger(credentials *tcclient.Credentials, args []string, out io.Write
 
 	newDependencies := []string{}
 	newRoutes := []string{}
-	if exactRetrigger {
+	if exact {
 		newDependencies = t.Dependencies
 		newRoutes = t.Routes
 	}
 
-	newT := &tcqueue.TaskDefinitionRequest{
+	return &tcqueue.TaskDefinitionRequest{
 		Created:       tcclient.Time(now),
 		Deadline:      tcclient.Time(now.Add(origDeadline.Sub(origCreated))),
 		Expires:       tcclient.Time(now.Add(origExpires.Sub(origCreated))),
@@ -148,16 +190,7 @@ func runRetrigger(credentials *tcclient.Credentials, args []string, out io.Write
 		Routes:        newRoutes,
 		Scopes:        t.Scopes,
 		Tags:          t.Tags,
-	}
-
-	c, err := q.CreateTask(newTaskID, newT)
-	if err != nil {
-		return fmt.Errorf("could not create task: %v", err)
-	}
-
-	// If we got no error, that means the task was successfully submitted
-	fmt.Fprintf(out, "Task %s created\n", c.Status.TaskID)
-	return nil
+	}, nil
 }
 
 // runComplete completes a given task.
@@ -174,7 +207,7 @@ func runComplete(credentials *tcclient.Credentials, args []string, out io.Writer
 	}
 
 	if noop {
-		return displayNoopMsg("Would complete", credentials, args)
+		return displayNoopMsg("Would complete", credentials, args, out)
 	}
 
 	if confirm {
@@ -0,0 +1,143 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/artifacts"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+)
+
+// The exit codes of `task await`; other errors exit with 1.
+const (
+	exitFailed    = 2
+	exitException = 3
+	// the same as timeout(1)
+	exitTimeout = 124
+)
+
+// awaitPollInterval is the time between checks of the task's status.
+var awaitPollInterval = 10 * time.Second
+
+var awaitCmd = &cobra.Command{
+	Use:   "await <taskId>",
+	Short: "Wait for a task to be resolved, exiting with a code for its resolution.",
+	Long: `Waits for a task to be resolved and prints its resolution, so that shell
+pipelines can depend on Taskcluster tasks.  The exit code is:
+
+    0    the task completed
+    2    the task failed
+    3    the task was resolved as exception
+    124  --timeout was reached before the task was resolved
+    1    any other error
+
+A task which is resolved as exception but retried automatically is awaited
+until its last run is resolved.
+
+With --artifacts, the artifacts of the last run whose names match the given
+pattern are downloaded to the directory given by --dest once the task is
+resolved, as for 'taskcluster artifacts download'.`,
+	RunE:              executeHelperE(runAwait),
+	ValidArgsFunction: completion.TaskIDs,
+}
+
+func init() {
+	fs := awaitCmd.Flags()
+	fs.Duration("timeout", 0, "Give up waiting after this long, such as 30m (default: wait forever).")
+	fs.String("artifacts", "", "Download the artifacts whose names match this pattern once the task is resolved, such as 'public/build/*'.")
+	fs.StringP("dest", "d", ".", "The directory to download the artifacts to.")
+
+	Command.AddCommand(awaitCmd)
+}
+
+// runAwait waits for a task to be resolved, and returns a root.ExitError
+// unless it completed.
+func runAwait(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	taskID := args[0]
+
+	timeout, _ := flagSet.GetDuration("timeout")
+	glob, _ := flagSet.GetString("artifacts")
+	dest, _ := flagSet.GetString("dest")
+	if _, err := path.Match(glob, ""); err != nil {
+		return fmt.Errorf("invalid --artifacts pattern %q: %v", glob, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	status, err := awaitResolution(ctx, q, taskID)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &root.ExitError{
+			Code: exitTimeout,
+			Err:  fmt.Errorf("task %s was not resolved within %s", taskID, timeout),
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	run := status.Runs[len(status.Runs)-1]
+	fmt.Fprintln(out, getRunStatusString(run.State, run.ReasonResolved))
+
+	var resolution *root.ExitError
+	switch status.State {
+	case "failed":
+		resolution = &root.ExitError{Code: exitFailed, Err: fmt.Errorf("task %s failed", taskID)}
+	case "exception":
+		resolution = &root.ExitError{Code: exitException, Err: fmt.Errorf("task %s was resolved as exception: %s", taskID, run.ReasonResolved)}
+	}
+
+	if glob != "" {
+		options := &artifacts.DownloadOptions{Glob: glob, Dest: dest, Parallel: 4}
+		if err := artifacts.Download(q, taskID, int(run.RunID), options, out); err != nil {
+			if resolution == nil {
+				return err
+			}
+			// keep the exit code for the resolution of the task
+			resolution.Err = errors.Join(resolution.Err, err)
+		}
+	}
+	if resolution == nil {
+		return nil
+	}
+	return resolution
+}
+
+// awaitResolution polls the status of a task until it is resolved, or ctx
+// is done.
+func awaitResolution(ctx context.Context, q *tcqueue.Queue, taskID string) (*tcqueue.TaskStatusStructure, error) {
+	for {
+		s, err := q.Status(taskID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
+		}
+		switch s.Status.State {
+		case "completed", "failed", "exception":
+			return &s.Status, nil
+		}
+		log.Debugf("task %s is %s; waiting for it to be resolved", taskID, s.Status.State)
+
+		select {
+		case <-time.After(awaitPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package task
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+// setUpAwaitServer starts a fake queue whose task goes through the given
+// states, one per status request, staying in the last one.
+func setUpAwaitServer(t *testing.T, states ...string) {
+	t.Helper()
+	var mu sync.Mutex
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		state := states[0]
+		if len(states) > 1 {
+			states = states[1:]
+		}
+		mu.Unlock()
+		reason := ""
+		switch state {
+		case "completed", "failed":
+			reason = state
+		case "exception":
+			reason = "malformed-payload"
+		}
+		fmt.Fprintf(w, `{"status": {"taskId": "%s", "state": "%s", "runs": [{"runId": 0, "state": "%s", "reasonCreated": "scheduled", "reasonResolved": "%s"}]}}`, fakeTaskID, state, state, reason)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/0/artifacts", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"artifacts": [
+			{"name": "public/build/target.zip", "storageType": "s3", "contentType": "application/zip", "expires": "2030-01-01T00:00:00.000Z"},
+			{"name": "public/logs/live.log", "storageType": "reference", "contentType": "text/plain", "expires": "2030-01-01T00:00:00.000Z"}
+		]}`)
+	})
+	var server *httptest.Server
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/0/artifact-content/public/build/target.zip", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"storageType": "s3", "url": "%s/target.zip"}`, server.URL)
+	})
+	handler.HandleFunc("/target.zip", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "zip")
+	})
+	server = httptest.NewServer(handler)
+	config.SetRootURL(server.URL)
+
+	interval := awaitPollInterval
+	awaitPollInterval = time.Millisecond
+	t.Cleanup(func() {
+		server.Close()
+		config.SetRootURL("")
+		awaitPollInterval = interval
+	})
+}
+
+func setUpAwaitCommand(timeout time.Duration, glob, dest string) (*bytes.Buffer, *cobra.Command) {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	cmd.Flags().Duration("timeout", timeout, "")
+	cmd.Flags().String("artifacts", glob, "")
+	cmd.Flags().String("dest", dest, "")
+	return buf, cmd
+}
+
+func TestAwaitCompleted(t *testing.T) {
+	setUpAwaitServer(t, "pending", "running", "completed")
+	buf, cmd := setUpAwaitCommand(0, "", "")
+
+	assert.NoError(t, runAwait(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+	assert.Equal(t, "completed 'completed'\n", buf.String())
+}
+
+func TestAwaitExitCodes(t *testing.T) {
+	for state, code := range map[string]int{"failed": exitFailed, "exception": exitException} {
+		t.Run(state, func(t *testing.T) {
+			setUpAwaitServer(t, "running", state)
+			_, cmd := setUpAwaitCommand(0, "", "")
+
+			err := runAwait(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags())
+
+			var exitErr *root.ExitError
+			require.True(t, errors.As(err, &exitErr))
+			assert.Equal(t, code, exitErr.Code)
+		})
+	}
+}
+
+func TestAwaitTimeout(t *testing.T) {
+	setUpAwaitServer(t, "running")
+	_, cmd := setUpAwaitCommand(20*time.Millisecond, "", "")
+
+	err := runAwait(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags())
+
+	var exitErr *root.ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, exitTimeout, exitErr.Code)
+}
+
+func TestAwaitArtifacts(t *testing.T) {
+	setUpAwaitServer(t, "running", "failed")
+	dest := t.TempDir()
+	_, cmd := setUpAwaitCommand(0, "public/build/*", dest)
+
+	err := runAwait(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags())
+
+	// the artifacts of failed tasks are downloaded too
+	var exitErr *root.ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, exitFailed, exitErr.Code)
+	data, err := os.ReadFile(filepath.Join(dest, "public", "build", "target.zip"))
+	require.NoError(t, err)
+	assert.Equal(t, "zip", string(data))
+	assert.NoFileExists(t, filepath.Join(dest, "public", "logs", "live.log"))
+}
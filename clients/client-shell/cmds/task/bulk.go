@@ -0,0 +1,134 @@
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+// addBulkFlag adds the --parallel flag of commands using bulkHelperE, and
+// documents how to give them taskIds on stdin.
+func addBulkFlag(cmd *cobra.Command) {
+	cmd.Long = cmd.Short + `
+
+If the taskId is "-", taskIds are read from stdin, one per line, and the
+command is executed for each of them, --parallel at a time.  The output for
+each task is prefixed by its taskId, and followed by a summary of the tasks
+that succeeded and failed.`
+	cmd.Flags().IntP("parallel", "p", 10, "The number of tasks to act on at once when reading taskIds from stdin.")
+}
+
+// bulkHelperE is like executeHelperE, but if the taskId is "-", the command
+// is executed for each of the taskIds read from stdin instead.
+func bulkHelperE(f Executor) func(*cobra.Command, []string) error {
+	single := executeHelperE(f)
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 || args[0] != "-" {
+			return single(cmd, args)
+		}
+
+		flagSet := cmd.Flags()
+		// --confirm reads its answers from stdin, which holds the taskIds
+		if confirm, _ := flagSet.GetBool("confirm"); confirm {
+			return fmt.Errorf("--confirm cannot be used when reading taskIds from stdin")
+		}
+		if output.Selected(flagSet) {
+			return fmt.Errorf("--output cannot be used when reading taskIds from stdin")
+		}
+		parallel, _ := flagSet.GetInt("parallel")
+		if parallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1")
+		}
+
+		taskIDs, err := readTaskIDs(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("could not read taskIds from stdin: %v", err)
+		}
+
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return runBulk(f, creds, taskIDs, parallel, cmd.OutOrStdout(), flagSet)
+	}
+}
+
+// readTaskIDs reads one taskId per line: the first word of each line, so that
+// the output of commands listing tasks can be piped in.  Empty lines and
+// lines starting with # are skipped.
+func readTaskIDs(r io.Reader) ([]string, error) {
+	taskIDs := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		taskIDs = append(taskIDs, fields[0])
+	}
+	return taskIDs, scanner.Err()
+}
+
+// bulkResult is the outcome of executing a command for one of the taskIds.
+type bulkResult struct {
+	out  bytes.Buffer
+	err  error
+	done chan struct{}
+}
+
+// runBulk executes f for each of the taskIds, at most parallel at a time.
+// The output of each execution is written to out in the order of the taskIds,
+// with each line prefixed by its taskId, followed by a summary.  An error is
+// returned if any of the executions failed.
+func runBulk(f Executor, credentials *tcclient.Credentials, taskIDs []string, parallel int, out io.Writer, flagSet *pflag.FlagSet) error {
+	results := make([]*bulkResult, len(taskIDs))
+	for i := range results {
+		results[i] = &bulkResult{done: make(chan struct{})}
+	}
+
+	go func() {
+		sem := make(chan struct{}, parallel)
+		for i, taskID := range taskIDs {
+			sem <- struct{}{}
+			go func(result *bulkResult, taskID string) {
+				defer func() { <-sem }()
+				defer close(result.done)
+				result.err = f(credentials, []string{taskID}, &result.out, flagSet)
+			}(results[i], taskID)
+		}
+	}()
+
+	failed := 0
+	for i, result := range results {
+		<-result.done
+		writePrefixed(out, taskIDs[i]+": ", result.out.String())
+		if result.err != nil {
+			failed++
+			writePrefixed(out, taskIDs[i]+": error: ", result.err.Error())
+		}
+	}
+
+	fmt.Fprintf(out, "%d tasks: %d succeeded, %d failed\n", len(taskIDs), len(taskIDs)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tasks failed", failed, len(taskIDs))
+	}
+	return nil
+}
+
+// writePrefixed writes each line of text to out, prefixed by prefix.
+func writePrefixed(out io.Writer, prefix, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		fmt.Fprintf(out, "%s%s\n", prefix, line)
+	}
+}
@@ -0,0 +1,54 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTaskIDs(t *testing.T) {
+	taskIDs, err := readTaskIDs(strings.NewReader("a\n\n  b  my-task\n# comment\nc"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, taskIDs)
+}
+
+func (suite *FakeServerSuite) TestBulkStatusCommand() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().BoolP("all-runs", "a", false, "")
+	cmd.Flags().IntP("run", "r", -1, "")
+	cmd.Flags().Int("parallel", 2, "")
+	cmd.SetIn(strings.NewReader(fakeTaskID + "\nnoSuchTask\n" + fakeTaskID + "\n"))
+
+	err := bulkHelperE(runStatus)(cmd, []string{"-"})
+
+	suite.EqualError(err, "1 of 3 tasks failed")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	suite.Equal(fakeTaskID+": completed 'completed'", lines[0])
+	suite.True(strings.HasPrefix(lines[1], "noSuchTask: error: could not get the status of the task noSuchTask"))
+	for _, line := range lines[2 : len(lines)-2] {
+		suite.True(strings.HasPrefix(line, "noSuchTask: error: "))
+	}
+	suite.Equal(fakeTaskID+": completed 'completed'", lines[len(lines)-2])
+	suite.Equal("3 tasks: 2 succeeded, 1 failed", lines[len(lines)-1])
+}
+
+func (suite *FakeServerSuite) TestBulkCancelCommand() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("noop", false, "")
+	cmd.Flags().Bool("confirm", false, "")
+	cmd.Flags().Int("parallel", 10, "")
+	cmd.SetIn(strings.NewReader(fakeTaskID + "\n"))
+
+	suite.NoError(bulkHelperE(runCancel)(cmd, []string{"-"}))
+	suite.Equal(fakeTaskID+": cancelled 'cancelled'\n1 tasks: 1 succeeded, 0 failed\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestBulkConfirmRejected() {
+	_, cmd := setUpCommand()
+	cmd.Flags().Bool("confirm", true, "")
+	cmd.Flags().Int("parallel", 10, "")
+
+	suite.EqualError(bulkHelperE(runCancel)(cmd, []string{"-"}), "--confirm cannot be used when reading taskIds from stdin")
+}
@@ -0,0 +1,172 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/taskcluster/slugid-go/slugid"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/internal/jsone"
+	"gopkg.in/yaml.v3"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create -f <file>",
+	Short: "Create a task from a YAML or JSON file, rendered with JSON-e.",
+	Long: `Create a task from a YAML or JSON task definition, which is first rendered
+as a JSON-e template (https://json-e.js.org).  This allows timestamps to be
+given relative to the current time, for example:
+
+  deadline: {$fromNow: '1 day'}
+
+The JSON-e context contains 'taskId', the ID of the new task, and any values
+given with --param.  If the rendered task has no 'taskGroupId', the task is
+in its own task group, and if it has no 'created' or 'deadline', they default
+to now and one day from now.
+
+Use '-f -' to read the task definition from standard input.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return runCreate(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	},
+}
+
+func init() {
+	fs := createCmd.Flags()
+	fs.StringP("file", "f", "", "File containing the task definition, or - for standard input")
+	fs.String("task-id", "", "ID of the new task (default: a new slugid)")
+	fs.StringArrayP("param", "p", []string{}, "Add a string value to the JSON-e context (repeatable) (format: NAME=VALUE)")
+	fs.Bool("dry-run", false, "Print the rendered task definition instead of creating the task")
+	if err := createCmd.MarkFlagRequired("file"); err != nil {
+		panic(fmt.Sprintf("Cannot mark flag required: %s", err))
+	}
+
+	Command.AddCommand(createCmd)
+}
+
+// runCreate renders a task definition template and creates the task.
+func runCreate(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	dryRun, _ := flagSet.GetBool("dry-run")
+
+	taskID, task, err := readTaskDefinition(flagSet)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		rendered, err := json.MarshalIndent(task, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(rendered))
+		return nil
+	}
+
+	q := makeQueue(credentials)
+	resp, err := q.CreateTask(taskID, task)
+	if err != nil {
+		return fmt.Errorf("could not create task: %v", err)
+	}
+	completion.RecordTaskIDs(resp.Status.TaskID)
+	completion.RecordTaskGroupIDs(resp.Status.TaskGroupID)
+	fmt.Fprintf(out, "Task %s created\n", resp.Status.TaskID)
+	return nil
+}
+
+// readTaskDefinition reads the task definition template given by --file and
+// renders it with the parameters given by --param, returning the taskId given
+// by --task-id, or a new one, and the task definition.
+func readTaskDefinition(flagSet *pflag.FlagSet) (string, *tcqueue.TaskDefinitionRequest, error) {
+	filename := stringFlagHelper(flagSet, "file")
+	// validate has no --task-id
+	taskID, _ := flagSet.GetString("task-id")
+	params, _ := flagSet.GetStringArray("param")
+
+	if filename == "" {
+		return "", nil, errors.New("a task definition file must be given with --file")
+	}
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("could not read task definition: %v", err)
+	}
+
+	if taskID == "" {
+		taskID = slugid.Nice()
+	}
+	context := map[string]interface{}{"taskId": taskID}
+	for _, param := range params {
+		name, value, found := strings.Cut(param, "=")
+		if !found {
+			return "", nil, fmt.Errorf("invalid parameter %q; expected NAME=VALUE", param)
+		}
+		context[name] = value
+	}
+
+	task, err := renderTaskDefinition(data, context, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	return taskID, task, nil
+}
+
+// renderTaskDefinition renders a YAML or JSON task definition template with
+// the given context, filling in defaults for taskGroupId, created and
+// deadline.
+func renderTaskDefinition(data []byte, context map[string]interface{}, now time.Time) (*tcqueue.TaskDefinitionRequest, error) {
+	var template interface{}
+	if err := yaml.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("could not parse task definition: %v", err)
+	}
+	rendered, err := jsone.RenderAt(template, context, now)
+	if err != nil {
+		return nil, fmt.Errorf("could not render task definition: %v", err)
+	}
+	definition, ok := rendered.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("task definition must be an object")
+	}
+
+	if _, ok := definition["taskGroupId"]; !ok {
+		definition["taskGroupId"] = context["taskId"]
+	}
+	if _, ok := definition["created"]; !ok {
+		definition["created"] = tcclient.Time(now)
+	}
+	if _, ok := definition["deadline"]; !ok {
+		definition["deadline"] = tcclient.Time(now.Add(24 * time.Hour))
+	}
+
+	// check for misspelled properties, which would otherwise be ignored
+	encoded, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	task := &tcqueue.TaskDefinitionRequest{}
+	if err := decoder.Decode(task); err != nil {
+		return nil, fmt.Errorf("invalid task definition: %v", err)
+	}
+	return task, nil
+}
@@ -0,0 +1,95 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+const fakeCreatedTaskID = "fN1Tpl0jQ8iO-hTBO3p2Ng"
+
+// the body of the last createTask request
+var createdTask map[string]interface{}
+
+func createTaskHandler(w http.ResponseWriter, r *http.Request) {
+	createdTask = nil
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	_ = json.NewDecoder(r.Body).Decode(&createdTask)
+	_, _ = io.WriteString(w, fmt.Sprintf(`{"status": {"taskId": "%s", "state": "pending", "runs": []}}`, fakeCreatedTaskID))
+}
+
+const taskTemplate = `
+provisionerId: proj
+workerType: ci
+metadata:
+  name: build ${branch}
+  description: an ad-hoc task
+  owner: me@example.com
+  source: https://example.com/${taskId}
+payload:
+  maxRunTime: 600
+expires: {$fromNow: '1 week'}
+`
+
+func TestRenderTaskDefinition(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	task, err := renderTaskDefinition([]byte(taskTemplate), map[string]interface{}{"taskId": "abc", "branch": "main"}, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "build main", task.Metadata.Name)
+	assert.Equal(t, "https://example.com/abc", task.Metadata.Source)
+	assert.Equal(t, "abc", task.TaskGroupID)
+	assert.Equal(t, now, time.Time(task.Created).UTC())
+	assert.Equal(t, now.Add(24*time.Hour), time.Time(task.Deadline).UTC())
+	assert.Equal(t, now.AddDate(0, 0, 7), time.Time(task.Expires).UTC())
+	assert.JSONEq(t, `{"maxRunTime": 600}`, string(task.Payload))
+}
+
+func TestRenderTaskDefinitionExplicitValues(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	task, err := renderTaskDefinition([]byte(`{"taskGroupId": "group", "deadline": {"$fromNow": "2 hours"}}`), map[string]interface{}{"taskId": "abc"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "group", task.TaskGroupID)
+	assert.Equal(t, now.Add(2*time.Hour), time.Time(task.Deadline).UTC())
+}
+
+func TestRenderTaskDefinitionErrors(t *testing.T) {
+	context := map[string]interface{}{"taskId": "abc"}
+	for _, template := range []string{
+		"[1, 2]",
+		"provisioner: misspelled",
+		"name: ${nosuchvalue}",
+		"{unclosed",
+	} {
+		_, err := renderTaskDefinition([]byte(template), context, time.Now())
+		assert.Error(t, err, template)
+	}
+}
+
+func (suite *FakeServerSuite) TestCreateCommand() {
+	file := filepath.Join(suite.T().TempDir(), "task.yml")
+	suite.Require().NoError(os.WriteFile(file, []byte(taskTemplate), 0644))
+
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("file", file, "")
+	cmd.Flags().String("task-id", fakeCreatedTaskID, "")
+	cmd.Flags().StringArray("param", []string{"branch=feature"}, "")
+	cmd.Flags().Bool("dry-run", false, "")
+
+	suite.Require().NoError(runCreate(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags()))
+	suite.Equal("Task "+fakeCreatedTaskID+" created\n", buf.String())
+	suite.Equal("build feature", createdTask["metadata"].(map[string]interface{})["name"])
+	suite.Equal(fakeCreatedTaskID, createdTask["taskGroupId"])
+}
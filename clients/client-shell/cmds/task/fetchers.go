@@ -2,19 +2,22 @@ package task
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	tcurls "github.com/taskcluster/taskcluster-lib-urls"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
 )
 
 func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
@@ -31,6 +34,16 @@ func runStatus(credentials *tcclient.Credentials, args []string, out io.Writer,
 		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
 	}
 
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, s.Status, func() *output.Table {
+			table := &output.Table{Header: []string{"RUN", "STATE", "REASON", "RESOLVED"}}
+			for _, r := range s.Status.Runs {
+				table.AddRow(fmt.Sprint(r.RunID), r.State, r.ReasonResolved, output.Time(r.Resolved))
+			}
+			return table
+		})
+	}
+
 	allRuns, _ := flagSet.GetBool("all-runs")
 	runID, _ := flagSet.GetInt("run")
 
@@ -94,7 +107,7 @@ func confirmMsg(command string, credentials *tcclient.Credentials, args []string
 }
 
 // displayNoopMsg displays details when --noop is used
-func displayNoopMsg(command string, credentials *tcclient.Credentials, args []string) error {
+func displayNoopMsg(command string, credentials *tcclient.Credentials, args []string, out io.Writer) error {
 	q := makeQueue(credentials)
 	taskID := args[0]
 
@@ -106,7 +119,7 @@ func displayNoopMsg(command string, credentials *tcclient.Credentials, args []st
 		return fmt.Errorf("could not get the task %s: %v", taskID, err)
 	}
 
-	fmt.Printf("%s %s taskid: %s (state: %s)\n", command, t.Metadata.Name, taskID, run.State)
+	fmt.Fprintf(out, "%s %s taskid: %s (state: %s)\n", command, t.Metadata.Name, taskID, run.State)
 	return nil
 }
 
@@ -125,7 +138,7 @@ func runName(credentials *tcclient.Credentials, args []string, out io.Writer, _
 }
 
 // runDef gets the definition of a given task.
-func runDef(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+func runDef(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
 	q := makeQueue(credentials)
 	taskID := args[0]
 
@@ -134,6 +147,10 @@ func runDef(credentials *tcclient.Credentials, args []string, out io.Writer, _ *
 		return fmt.Errorf("could not get the task %s: %v", taskID, err)
 	}
 
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, t, nil)
+	}
+
 	def, err := json.MarshalIndent(t, "", "  ")
 	if err != nil {
 		return fmt.Errorf("unable to marshal task %s into json: %v", taskID, err)
@@ -175,7 +192,7 @@ func runArtifacts(credentials *tcclient.Credentials, args []string, out io.Write
 		runID = len(s.Status.Runs) - 1
 	}
 
-	buf := bytes.NewBufferString("")
+	artifacts := []tcqueue.Artifact{}
 	continuation := ""
 	for {
 		a, err := q.ListArtifacts(taskID, fmt.Sprint(runID), continuation, "")
@@ -183,9 +200,7 @@ func runArtifacts(credentials *tcclient.Credentials, args []string, out io.Write
 			return fmt.Errorf("could not fetch artifacts for task %s run %v: %v", taskID, runID, err)
 		}
 
-		for _, ar := range a.Artifacts {
-			fmt.Fprintf(buf, "%s\n", ar.Name)
-		}
+		artifacts = append(artifacts, a.Artifacts...)
 
 		continuation = a.ContinuationToken
 		if continuation == "" {
@@ -193,14 +208,36 @@ func runArtifacts(credentials *tcclient.Credentials, args []string, out io.Write
 		}
 	}
 
-	_, err = buf.WriteTo(out)
-	return err
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, artifacts, func() *output.Table {
+			table := &output.Table{Header: []string{"NAME", "STORAGE", "CONTENT TYPE", "EXPIRES"}}
+			for _, a := range artifacts {
+				table.AddRow(a.Name, a.StorageType, a.ContentType, output.Time(a.Expires))
+			}
+			return table
+		})
+	}
+
+	for _, a := range artifacts {
+		fmt.Fprintln(out, a.Name)
+	}
+	return nil
 }
 
+// runLog writes the log of a task to out.  The log is copied unmodified, so
+// ANSI escape sequences such as colors are passed through to the terminal.
 func runLog(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
 	q := makeQueue(credentials)
 	taskID := args[0]
 
+	if timestamps, _ := flagSet.GetBool("timestamps"); timestamps {
+		out = &timestampWriter{w: out, now: time.Now}
+	}
+
+	if follow, _ := flagSet.GetBool("follow"); follow {
+		return followLog(q, taskID, out)
+	}
+
 	s, err := q.Status(taskID)
 	if err != nil {
 		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
@@ -219,15 +256,27 @@ func runLog(credentials *tcclient.Credentials, args []string, out io.Writer, fla
 	}
 	defer resp.Body.Close()
 
-	// Read line by line for live logs.
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		fmt.Fprintln(out, scanner.Text())
-	}
-
 	if resp.StatusCode/100 != 2 {
 		return fmt.Errorf("Received unexpected response code %v", resp.StatusCode)
 	}
 
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// followLog streams the live log of the task while it runs, waiting for it to
+// start if necessary, and the rest of the backing log once it is resolved.
+// Interrupting the command stops following the log.
+func followLog(q *tcqueue.Queue, taskID string, out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	reader := q.FollowLiveLog(ctx, taskID, nil)
+	defer reader.Close()
+
+	_, err := io.Copy(out, reader)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("could not follow the log of task %s: %v", taskID, err)
+	}
 	return nil
 }
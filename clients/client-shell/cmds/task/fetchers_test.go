@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
 )
 
 const fakeTaskID = "ANnmjMocTymeTID0tlNJAw"
@@ -33,6 +34,13 @@ func (suite *FakeServerSuite) SetupSuite() {
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/rerun", reRunHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/"+fakeRunID+"/claim", claimTaskHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/"+fakeRunID+"/completed", manifestHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+fakeCreatedTaskID, createTaskHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/artifact-content/public/logs/live_backing.log", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"storageType": "reference", "url": "`+suite.testServer.URL+`/backing.log"}`)
+	})
+	handler.HandleFunc("/backing.log", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, fakeBackingLog)
+	})
 
 	suite.testServer = httptest.NewServer(handler)
 
@@ -182,6 +190,60 @@ func (suite *FakeServerSuite) TestArtifactsCommand() {
 
 }
 
+func (suite *FakeServerSuite) TestArtifactsCommandOutput() {
+	buf, cmd := setUpCommand()
+	output.AddFlag(cmd)
+	suite.NoError(cmd.Flags().Set("output", "go-template={{range .}}{{.name}},{{end}}"))
+
+	suite.NoError(runArtifacts(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("fake_live.log,fake_live_backing.log,\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestStatusCommandOutput() {
+	buf, cmd := setUpCommand()
+	output.AddFlag(cmd)
+
+	suite.NoError(cmd.Flags().Set("output", "table"))
+	suite.NoError(runStatus(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+	suite.Equal("RUN  STATE      REASON     RESOLVED\n0    completed  completed  \n", buf.String())
+
+	buf.Reset()
+	suite.NoError(cmd.Flags().Set("output", "json"))
+	suite.NoError(runStatus(&tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+	var status map[string]interface{}
+	suite.NoError(json.Unmarshal(buf.Bytes(), &status))
+	suite.Equal("completed", status["state"])
+}
+
+// a log containing ANSI color escape sequences
+const fakeBackingLog = "\x1b[32mgreen line\x1b[0m\nplain line\n"
+
+func (suite *FakeServerSuite) TestLogFollowResolvedTask() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("follow", true, "")
+
+	// run the command; the task is completed, so the backing log is read
+	args := []string{fakeTaskID}
+	assert.NoError(suite.T(), runLog(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(fakeBackingLog, buf.String())
+}
+
+func (suite *FakeServerSuite) TestLogFollowTimestamps() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("follow", true, "")
+	cmd.Flags().Bool("timestamps", true, "")
+
+	// run the command
+	args := []string{fakeTaskID}
+	assert.NoError(suite.T(), runLog(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Regexp("^\\S+Z \x1b\\[32mgreen line\x1b\\[0m\n\\S+Z plain line\n$", buf.String())
+}
+
 func (suite *FakeServerSuite) TestGroupCommand() {
 	// set up to run a command and capture output
 	buf, cmd := setUpCommand()
@@ -0,0 +1,144 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+)
+
+// applyOverrides returns a copy of task with the given overrides applied.
+// Each of env is a KEY=VALUE pair setting payload.env.KEY to the string
+// VALUE, and each of set is a PATH=VALUE pair, applied after env.  PATH is
+// either a JSON pointer (/payload/maxRunTime) or a dotted path
+// (payload.maxRunTime); VALUE is parsed as JSON if possible, and otherwise
+// used as a string.  Missing objects along the path are created.
+func applyOverrides(task *tcqueue.TaskDefinitionRequest, env, set []string) (*tcqueue.TaskDefinitionRequest, error) {
+	if len(env) == 0 && len(set) == 0 {
+		return task, nil
+	}
+
+	encoded, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	for _, e := range env {
+		key, value, ok := strings.Cut(e, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --env %q: expected KEY=VALUE", e)
+		}
+		if doc, err = setPath(doc, []string{"payload", "env", key}, value); err != nil {
+			return nil, fmt.Errorf("invalid --env %q: %v", e, err)
+		}
+	}
+
+	for _, s := range set {
+		rawPath, rawValue, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --set %q: expected PATH=VALUE", s)
+		}
+		path, err := parsePath(rawPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --set %q: %v", s, err)
+		}
+		if doc, err = setPath(doc, path, parseValue(rawValue)); err != nil {
+			return nil, fmt.Errorf("invalid --set %q: %v", s, err)
+		}
+	}
+
+	encoded, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var result tcqueue.TaskDefinitionRequest
+	decoder = json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("task definition is invalid after overrides: %v", err)
+	}
+	return &result, nil
+}
+
+// parsePath splits a JSON pointer or a dotted path into its segments.
+func parsePath(path string) ([]string, error) {
+	var segments []string
+	if pointer, ok := strings.CutPrefix(path, "/"); ok {
+		for _, segment := range strings.Split(pointer, "/") {
+			segment = strings.ReplaceAll(segment, "~1", "/")
+			segments = append(segments, strings.ReplaceAll(segment, "~0", "~"))
+		}
+	} else {
+		segments = strings.Split(path, ".")
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("path %q has an empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+// parseValue interprets value as JSON, falling back to the string itself.
+func parseValue(value string) interface{} {
+	var parsed interface{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&parsed); err != nil || decoder.More() {
+		return value
+	}
+	return parsed
+}
+
+// setPath sets the value at path within doc, which is a generic JSON value,
+// and returns the updated doc.  An array index of "-" appends to the array.
+func setPath(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	key, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case nil:
+		child, err := setPath(nil, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{key: child}, nil
+	case map[string]interface{}:
+		child, err := setPath(node[key], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[key] = child
+		return node, nil
+	case []interface{}:
+		if key == "-" {
+			child, err := setPath(nil, rest, value)
+			if err != nil {
+				return nil, err
+			}
+			return append(node, child), nil
+		}
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(node) {
+			return nil, fmt.Errorf("%q is not an index of an array of length %d", key, len(node))
+		}
+		child, err := setPath(node[index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = child
+		return node, nil
+	default:
+		return nil, fmt.Errorf("cannot set %q within %v, which is not an object or an array", key, node)
+	}
+}
@@ -0,0 +1,125 @@
+package task
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+func overridesTask() *tcqueue.TaskDefinitionRequest {
+	return &tcqueue.TaskDefinitionRequest{
+		ProvisionerID: "proj",
+		WorkerType:    "ci",
+		Payload:       json.RawMessage(`{"command": [["echo", "hi"]], "maxRunTime": 600}`),
+		Routes:        []string{"index.a"},
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	task, err := applyOverrides(overridesTask(),
+		[]string{"DEBUG=1", "GREETING=a=b"},
+		[]string{"payload.maxRunTime=7200", "/payload/command/0/1=bye", "routes.-=index.b", "metadata.name=retriggered"})
+	require.NoError(t, err)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(task.Payload, &payload))
+	assert.Equal(t, map[string]interface{}{
+		"command":    []interface{}{[]interface{}{"echo", "bye"}},
+		"env":        map[string]interface{}{"DEBUG": "1", "GREETING": "a=b"},
+		"maxRunTime": float64(7200),
+	}, payload)
+	assert.Equal(t, []string{"index.a", "index.b"}, task.Routes)
+	assert.Equal(t, "retriggered", task.Metadata.Name)
+	assert.Equal(t, "ci", task.WorkerType)
+}
+
+func TestApplyOverridesNone(t *testing.T) {
+	original := overridesTask()
+	task, err := applyOverrides(original, nil, nil)
+	require.NoError(t, err)
+	assert.Same(t, original, task)
+}
+
+func TestApplyOverridesErrors(t *testing.T) {
+	for _, tc := range []struct {
+		env, set []string
+		err      string
+	}{
+		{env: []string{"DEBUG"}, err: "expected KEY=VALUE"},
+		{set: []string{"payload.maxRunTime"}, err: "expected PATH=VALUE"},
+		{set: []string{"payload..maxRunTime=1"}, err: "empty segment"},
+		{set: []string{"payload.command.3=x"}, err: "not an index"},
+		{set: []string{"payload.maxRunTime.hours=1"}, err: "not an object or an array"},
+		{set: []string{"workerTyp=ci"}, err: "invalid after overrides"},
+	} {
+		_, err := applyOverrides(overridesTask(), tc.env, tc.set)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), tc.err)
+		}
+	}
+}
+
+func TestParsePathPointerEscapes(t *testing.T) {
+	path, err := parsePath("/routes/a~1b~0c")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routes", "a/b~c"}, path)
+}
+
+func TestParseValue(t *testing.T) {
+	assert.Equal(t, json.Number("7200"), parseValue("7200"))
+	assert.Equal(t, true, parseValue("true"))
+	assert.Equal(t, "true", parseValue(`"true"`))
+	assert.Equal(t, "echo hi", parseValue("echo hi"))
+	assert.Equal(t, "1 2", parseValue("1 2"))
+}
+
+func TestRetriggerWithOverrides(t *testing.T) {
+	var created *tcqueue.TaskDefinitionRequest
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{
+			"provisionerId": "proj", "workerType": "ci", "taskGroupId": "`+fakeTaskID+`",
+			"created": "2020-01-01T00:00:00.000Z", "deadline": "2020-01-01T01:00:00.000Z", "expires": "2020-01-08T00:00:00.000Z",
+			"metadata": {"name": "test", "description": "", "owner": "me@example.com", "source": "https://example.com"},
+			"payload": {"maxRunTime": 600}
+		}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/", func(w http.ResponseWriter, r *http.Request) {
+		if !assert.Equal(t, "PUT", r.Method) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		taskID := strings.TrimPrefix(r.URL.Path, "/api/queue/v1/task/")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		_, _ = io.WriteString(w, `{"status": {"taskId": "`+taskID+`", "state": "pending", "runs": []}}`)
+	})
+	server := httptest.NewServer(handler)
+	config.SetRootURL(server.URL)
+	t.Cleanup(func() {
+		server.Close()
+		config.SetRootURL("")
+	})
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("exact", false, "")
+	cmd.Flags().StringArray("env", []string{"DEBUG=1"}, "")
+	cmd.Flags().StringArray("set", []string{"payload.maxRunTime=7200"}, "")
+	cmd.Flags().Bool("dry-run", false, "")
+	buf := &strings.Builder{}
+
+	require.NoError(t, runRetrigger(&tcclient.Credentials{}, []string{fakeTaskID}, buf, cmd.Flags()))
+	require.NotNil(t, created)
+	assert.JSONEq(t, `{"maxRunTime": 7200, "env": {"DEBUG": "1"}}`, string(created.Payload))
+	assert.Equal(t, "test", created.Metadata.Name)
+	assert.Regexp(t, "^Task [A-Za-z0-9_-]{22} created\n$", buf.String())
+}
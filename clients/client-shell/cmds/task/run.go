@@ -12,6 +12,7 @@ import (
 	"github.com/taskcluster/slugid-go/slugid"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
 )
 
@@ -133,6 +134,8 @@ func runRunTask(cmd *cobra.Command, args []string) error {
 	}
 
 	// If we got no error, that means the task was successfully rund
+	completion.RecordTaskIDs(resp.Status.TaskID)
+	completion.RecordTaskGroupIDs(resp.Status.TaskGroupID)
 	fmt.Fprintf(cmd.OutOrStdout(), "Task %s created\n", resp.Status.TaskID)
 
 	return nil
@@ -3,6 +3,8 @@ package task
 
 import (
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
 
 	"github.com/spf13/cobra"
 )
@@ -14,36 +16,61 @@ var (
 		Short: "Provides task-related actions and commands.",
 	}
 	statusCmd = &cobra.Command{
-		Use:   "status <taskId>",
-		Short: "Get the status of a task.",
-		RunE:  executeHelperE(runStatus),
+		Use:               "status (<taskId> | -)",
+		Short:             "Get the status of a task.",
+		RunE:              bulkHelperE(runStatus),
+		ValidArgsFunction: completion.TaskIDs,
+	}
+	defCmd = &cobra.Command{
+		Use:               "def <taskId>",
+		Short:             "Get the full definition of a task.",
+		RunE:              executeHelperE(runDef),
+		ValidArgsFunction: completion.TaskIDs,
 	}
 	artifactsCmd = &cobra.Command{
-		Use:   "artifacts <taskId>",
-		Short: "Get the name of the artifacts of a task.",
-		RunE:  executeHelperE(runArtifacts),
+		Use:               "artifacts <taskId>",
+		Short:             "Get the name of the artifacts of a task.",
+		RunE:              executeHelperE(runArtifacts),
+		ValidArgsFunction: completion.TaskIDs,
+	}
+	logCmd = &cobra.Command{
+		Use:   "log <taskId>",
+		Short: "Streams the log until completion.",
+		Long: `Streams the log of the latest run of a task until completion.  Escape
+sequences, such as colors, are passed through unmodified.
+
+With --follow, the command waits for the task to start if necessary, streams
+the live log while the task runs, and then reads the rest of the log from the
+backing log once the task is resolved, so it can be used at any point in the
+task's lifetime.`,
+		RunE:              executeHelperE(runLog),
+		ValidArgsFunction: completion.TaskIDs,
 	}
 	retriggerCmd = &cobra.Command{
-		Use:   "retrigger <taskId>",
-		Short: "Re-trigger a task (new taskId, updated timestamps).",
-		RunE:  executeHelperE(runRetrigger),
+		Use:               "retrigger <taskId>",
+		Short:             "Re-trigger a task (new taskId, updated timestamps).",
+		RunE:              executeHelperE(runRetrigger),
+		ValidArgsFunction: completion.TaskIDs,
 	}
 	rerunCmd = &cobra.Command{
-		Use:   "rerun <taskId>",
-		Short: "Rerun a task.",
-		RunE:  executeHelperE(runRerun),
+		Use:               "rerun (<taskId> | -)",
+		Short:             "Rerun a task.",
+		RunE:              bulkHelperE(runRerun),
+		ValidArgsFunction: completion.TaskIDs,
 	}
 
 	runcancelCmd = &cobra.Command{
-		Use:   "cancel <taskId>",
-		Short: "Cancel a task.",
-		RunE:  executeHelperE(runCancel),
+		Use:               "cancel (<taskId> | -)",
+		Short:             "Cancel a task.",
+		RunE:              bulkHelperE(runCancel),
+		ValidArgsFunction: completion.TaskIDs,
 	}
 
 	runcompleteCmd = &cobra.Command{
-		Use:   "complete <taskId>",
-		Short: "Completes a task.",
-		RunE:  executeHelperE(runComplete),
+		Use:               "complete <taskId>",
+		Short:             "Completes a task.",
+		RunE:              executeHelperE(runComplete),
+		ValidArgsFunction: completion.TaskIDs,
 	}
 )
 
@@ -52,17 +79,30 @@ var log = root.Logger
 func init() {
 	statusCmd.Flags().BoolP("all-runs", "a", false, "Check all runs of the task.")
 	statusCmd.Flags().IntP("run", "r", -1, "Specifies which run to consider.")
+	addBulkFlag(statusCmd)
+	output.AddFlag(statusCmd)
+
+	output.AddFlag(defCmd)
 
 	artifactsCmd.Flags().IntP("run", "r", -1, "Specifies which run to consider.")
+	output.AddFlag(artifactsCmd)
+
+	logCmd.Flags().BoolP("follow", "f", false, "Wait for the task to start, and follow its log until it is resolved.")
+	logCmd.Flags().BoolP("timestamps", "t", false, "Prefix each line with the time at which it was received.")
 
 	retriggerCmd.Flags().BoolP("exact", "e", false, "Retrigger in exact mode. WARNING: THIS MAY HAVE SIDE EFFECTS. USE AFTER YOU READ THE SOURCE CODE.")
+	retriggerCmd.Flags().StringArray("env", nil, "Set an environment variable in the new task's payload, as KEY=VALUE; may be repeated.")
+	retriggerCmd.Flags().StringArray("set", nil, "Set a value in the new task definition, as PATH=VALUE where PATH is a JSON pointer or dotted path (e.g. payload.maxRunTime=7200) and VALUE is parsed as JSON if possible; may be repeated.")
+	retriggerCmd.Flags().Bool("dry-run", false, "Print the new task definition instead of creating the task.")
 
 	rerunCmd.Flags().BoolP("noop", "n", false, "Using this flag, will tell the command to not actually run, but prints out what it would do.")
 	rerunCmd.Flags().BoolP("confirm", "c", false, "Prompts user with a confirmation (y/n) before performing any changes.")
 	rerunCmd.Flags().BoolP("force", "f", false, "Allows a user to rerun a task not in the exception or failed state.")
+	addBulkFlag(rerunCmd)
 
 	runcancelCmd.Flags().BoolP("noop", "n", false, "Using this flag, will tell the command to not actually run, but prints out what it would do.")
 	runcancelCmd.Flags().BoolP("confirm", "c", false, "Prompts user with a confirmation (y/n) before performing any changes.")
+	addBulkFlag(runcancelCmd)
 
 	runcompleteCmd.Flags().BoolP("noop", "n", false, "Using this flag, will tell the command to not actually run, but prints out what it would do.")
 	runcompleteCmd.Flags().BoolP("confirm", "c", false, "Prompts user with a confirmation (y/n) before performing any changes.")
@@ -72,30 +112,24 @@ func init() {
 		statusCmd,
 		// name
 		&cobra.Command{
-			Use:   "name <taskId>",
-			Short: "Get the name of a task.",
-			RunE:  executeHelperE(runName),
+			Use:               "name <taskId>",
+			Short:             "Get the name of a task.",
+			RunE:              executeHelperE(runName),
+			ValidArgsFunction: completion.TaskIDs,
 		},
 		// definition
-		&cobra.Command{
-			Use:   "def <taskId>",
-			Short: "Get the full definition of a task.",
-			RunE:  executeHelperE(runDef),
-		},
+		defCmd,
 		// group
 		&cobra.Command{
-			Use:   "group <taskId>",
-			Short: "Get the taskGroupID of a task.",
-			RunE:  executeHelperE(runGroup),
+			Use:               "group <taskId>",
+			Short:             "Get the taskGroupID of a task.",
+			RunE:              executeHelperE(runGroup),
+			ValidArgsFunction: completion.TaskIDs,
 		},
 		// artifacts
 		artifactsCmd,
 		// log
-		&cobra.Command{
-			Use:   "log <taskId>",
-			Short: "Streams the log until completion.",
-			RunE:  executeHelperE(runLog),
-		},
+		logCmd,
 	)
 
 	// Commands that take actions
@@ -0,0 +1,112 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcobject"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/object"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+func init() {
+	uploadArtifactCmd := &cobra.Command{
+		Use:   "upload-artifact <taskId> <name> <file>",
+		Short: "Upload a file as an artifact of a task.",
+		Long: `Uploads a file, or stdin if the file is "-", as an object artifact of the
+latest run of a task, or of the run given by --run.  The queue only accepts
+artifacts for runs that are running, or that were resolved very recently, and
+the credentials used need the scope queue:create-artifact:<taskId>/<runId>.`,
+		Args:              cobra.ExactArgs(3),
+		RunE:              executeHelperE(runUploadArtifact),
+		ValidArgsFunction: completion.TaskIDs,
+	}
+	uploadArtifactCmd.Flags().IntP("run", "r", -1, "Specifies which run to consider.")
+	uploadArtifactCmd.Flags().StringP("expires", "e", "", "When the artifact expires, as a time such as 2030-01-01T00:00:00Z, or a duration from now such as '1 year' [default: when the task expires].")
+	uploadArtifactCmd.Flags().StringP("content-type", "t", "", "The content type of the artifact [default: based on the file's extension].")
+	Command.AddCommand(uploadArtifactCmd)
+}
+
+// runUploadArtifact creates an object artifact, uploads its content to the
+// object service with the credentials given by the queue, and marks the
+// artifact as finished.
+func runUploadArtifact(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	taskID, name, path := args[0], args[1], args[2]
+
+	s, err := q.Status(taskID)
+	if err != nil {
+		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
+	}
+	if len(s.Status.Runs) == 0 {
+		return fmt.Errorf("task %s has no runs", taskID)
+	}
+	runID, _ := flagSet.GetInt("run")
+	if runID == -1 {
+		runID = len(s.Status.Runs) - 1
+	}
+	if runID < 0 || runID >= len(s.Status.Runs) {
+		return fmt.Errorf("there is no run #%v", runID)
+	}
+
+	expires := time.Time(s.Status.Expires)
+	if expiresFlag, _ := flagSet.GetString("expires"); expiresFlag != "" {
+		if expires, err = object.ParseExpires(expiresFlag, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	contentType, _ := flagSet.GetString("content-type")
+	if contentType == "" {
+		contentType = object.ContentType(path)
+	}
+
+	file, size, cleanup, err := object.OpenFile(path)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	request, err := json.Marshal(&tcqueue.ObjectArtifactRequest{
+		ContentType: contentType,
+		Expires:     tcclient.Time(expires),
+		StorageType: "object",
+	})
+	if err != nil {
+		return err
+	}
+	payload := tcqueue.PostArtifactRequest(request)
+	run := strconv.Itoa(runID)
+	resp, err := q.CreateArtifact(taskID, run, name, &payload)
+	if err != nil {
+		return fmt.Errorf("could not create artifact %s: %v", name, err)
+	}
+	var artifact tcqueue.ObjectArtifactResponse
+	if err := json.Unmarshal(*resp, &artifact); err != nil {
+		return fmt.Errorf("could not parse the response to creating artifact %s: %v", name, err)
+	}
+
+	objectService := tcobject.New(&tcclient.Credentials{
+		ClientID:    artifact.Credentials.ClientID,
+		AccessToken: artifact.Credentials.AccessToken,
+		Certificate: artifact.Credentials.Certificate,
+	}, config.RootURL())
+	err = objectService.UploadFromReadSeeker(artifact.ProjectID, artifact.Name, contentType, size, time.Time(artifact.Expires), artifact.UploadID, file)
+	if err != nil {
+		return fmt.Errorf("could not upload artifact %s: %v", name, err)
+	}
+
+	if err := q.FinishArtifact(taskID, run, name, &tcqueue.FinishArtifactRequest{UploadID: artifact.UploadID}); err != nil {
+		return fmt.Errorf("could not finish artifact %s: %v", name, err)
+	}
+	fmt.Fprintf(out, "Uploaded artifact %s of task %s, run %d (%d bytes, %s)\n", name, taskID, runID, size, contentType)
+	return nil
+}
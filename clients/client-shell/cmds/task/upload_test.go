@@ -0,0 +1,104 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+func TestUploadArtifact(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		uploaded []byte
+		request  map[string]interface{}
+	)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/api/queue/v1/task/"+fakeTaskID+"/status":
+			fmt.Fprintf(w, `{"status": {"taskId": "%s", "state": "running", "expires": "2030-01-01T00:00:00.000Z", "runs": [{"runId": 0, "state": "running"}]}}`, fakeTaskID)
+		case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/api/queue/v1/task/"):
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			_, _ = io.WriteString(w, `{"storageType": "object", "name": "t/`+fakeTaskID+`/0/public/build/target.bin", "projectId": "proj",
+				"uploadId": "upload", "expires": "2030-01-01T00:00:00.000Z",
+				"credentials": {"clientId": "object-client", "accessToken": "token"}}`)
+		case r.URL.Path == "/api/object/v1/upload/t/"+fakeTaskID+"/0/public/build/target.bin":
+			fmt.Fprintf(w, `{"projectId": "proj", "uploadId": "upload", "expires": "2030-01-01T00:00:00.000Z",
+				"uploadMethod": {"putUrl": {"url": "%s/data", "headers": {}, "expires": "2030-01-01T00:00:00.000Z"}}}`, server.URL)
+		case r.URL.Path == "/data":
+			uploaded, _ = io.ReadAll(r.Body)
+		case r.URL.Path == "/api/object/v1/finish-upload/t/"+fakeTaskID+"/0/public/build/target.bin":
+			_, _ = io.WriteString(w, `{}`)
+		case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/queue/v1/task/"):
+			_, _ = io.WriteString(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	config.SetRootURL(server.URL)
+	t.Cleanup(func() {
+		server.Close()
+		config.SetRootURL("")
+	})
+
+	content := strings.Repeat("artifact ", 2000)
+	path := filepath.Join(t.TempDir(), "target.bin")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	buf, cmd := setUpCommand()
+	cmd.Flags().Int("run", -1, "")
+	cmd.Flags().String("expires", "", "")
+	cmd.Flags().String("content-type", "", "")
+
+	err := runUploadArtifact(&tcclient.Credentials{}, []string{fakeTaskID, "public/build/target.bin", path}, cmd.OutOrStdout(), cmd.Flags())
+	require.NoError(t, err)
+
+	assert.Equal(t, content, string(uploaded))
+	assert.Equal(t, map[string]interface{}{
+		"storageType": "object",
+		"contentType": "application/octet-stream",
+		"expires":     "2030-01-01T00:00:00.000Z",
+	}, request)
+	assert.Equal(t, []string{
+		"GET /api/queue/v1/task/" + fakeTaskID + "/status",
+		"POST /api/queue/v1/task/" + fakeTaskID + "/runs/0/artifacts/public/build/target.bin",
+		"PUT /api/object/v1/upload/t/" + fakeTaskID + "/0/public/build/target.bin",
+		"PUT /data",
+		"POST /api/object/v1/finish-upload/t/" + fakeTaskID + "/0/public/build/target.bin",
+		"PUT /api/queue/v1/task/" + fakeTaskID + "/runs/0/artifacts/public/build/target.bin",
+	}, requests)
+	assert.Equal(t, fmt.Sprintf("Uploaded artifact public/build/target.bin of task %s, run 0 (%d bytes, application/octet-stream)\n", fakeTaskID, len(content)), buf.String())
+}
+
+func TestUploadArtifactNoRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"status": {"taskId": "%s", "state": "unscheduled", "runs": []}}`, fakeTaskID)
+	}))
+	config.SetRootURL(server.URL)
+	t.Cleanup(func() {
+		server.Close()
+		config.SetRootURL("")
+	})
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Int("run", -1, "")
+	err := runUploadArtifact(&tcclient.Credentials{}, []string{fakeTaskID, "public/x", "x"}, io.Discard, cmd.Flags())
+	assert.EqualError(t, err, "task "+fakeTaskID+" has no runs")
+}
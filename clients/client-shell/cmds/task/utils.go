@@ -1,12 +1,15 @@
 package task
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
 )
 
@@ -32,7 +35,11 @@ func executeHelperE(f Executor) func(*cobra.Command, []string) error {
 		if len(args) < 1 {
 			return fmt.Errorf("%s expects argument <taskId>", cmd.Name())
 		}
-		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+		if err := f(creds, args, cmd.OutOrStdout(), cmd.Flags()); err != nil {
+			return err
+		}
+		completion.RecordTaskIDs(args[0])
+		return nil
 	}
 }
 
@@ -43,3 +50,36 @@ func stringFlagHelper(flagset *pflag.FlagSet, flag string) string {
 	}
 	return val
 }
+
+// timestampWriter prefixes each line written to it with the time at which the
+// start of the line was written.
+type timestampWriter struct {
+	w   io.Writer
+	now func() time.Time
+	// true if the last byte written was not a newline
+	midLine bool
+}
+
+func (tw *timestampWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if !tw.midLine {
+			if _, err := io.WriteString(tw.w, tw.now().UTC().Format("2006-01-02T15:04:05.000Z")+" "); err != nil {
+				return written, err
+			}
+			tw.midLine = true
+		}
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i+1]
+			tw.midLine = false
+		}
+		n, err := tw.w.Write(line)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(line):]
+	}
+	return written, nil
+}
@@ -1,8 +1,11 @@
 package task
 
 import (
+	"bytes"
 	"io"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -10,6 +13,19 @@ import (
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 )
 
+func TestMain(m *testing.M) {
+	// keep the taskIds of the tasks created by the tests out of the user's
+	// history of recently used taskIds
+	cache, err := os.MkdirTemp("", "taskcluster-cache")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CACHE_HOME", cache)
+	code := m.Run()
+	os.RemoveAll(cache)
+	os.Exit(code)
+}
+
 func TestStatusString(t *testing.T) {
 	assert := assert.New(t)
 
@@ -46,3 +62,23 @@ func TestStringFlagHelper(t *testing.T) {
 		stringFlagHelper(fs, "not-exists")
 	}, "should panic")
 }
+
+func TestTimestampWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	tw := &timestampWriter{w: buf, now: func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}}
+
+	// lines split across writes are prefixed once
+	for _, s := range []string{"first ", "line\nsecond line\nthi", "rd line\n"} {
+		n, err := io.WriteString(tw, s)
+		assert.NoError(t, err)
+		assert.Equal(t, len(s), n)
+	}
+
+	assert.Equal(t, "2024-01-02T03:04:06.600Z first line\n"+
+		"2024-01-02T03:04:07.600Z second line\n"+
+		"2024-01-02T03:04:08.600Z third line\n", buf.String())
+}
@@ -0,0 +1,165 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/tools/d2g/dockerworker"
+	"github.com/taskcluster/taskcluster/v60/tools/d2g/genericworker"
+	js "github.com/xeipuuv/gojsonschema"
+)
+
+// payloadSchema is the schema of the payloads of a kind of worker.
+type payloadSchema struct {
+	// the service and path of the schema in a deployment
+	service, path string
+	// the schema built into this client, if any
+	embedded func() string
+}
+
+// payloadSchemas are the schemas of the workers accepted by --worker.
+var payloadSchemas = map[string]payloadSchema{
+	"docker-worker":                    {service: "docker-worker", path: "v1/payload.json", embedded: dockerworker.JSONSchema},
+	"generic-worker/multiuser":         {service: "generic-worker", path: "multiuser_posix.json", embedded: genericworker.JSONSchema},
+	"generic-worker/multiuser-windows": {service: "generic-worker", path: "multiuser_windows.json"},
+	"generic-worker/simple":            {service: "generic-worker", path: "simple_posix.json"},
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate -f <file> --worker <worker>",
+	Short: "Validate the payload of a task definition against the payload schema of a worker.",
+	Long: `Renders a task definition as for 'taskcluster task create', and validates its
+payload against the payload schema of the given kind of worker, reporting the
+path of each error within the payload, so that mistakes are found before the
+task is submitted.
+
+The known workers are:
+
+    ` + strings.Join(workerNames(), "\n    ") + `
+
+Schemas built into this client are used where available; --fetch uses the
+schema of the deployment instead, which may be more recent.  The other
+schemas are always fetched from the deployment.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return runValidate(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	},
+}
+
+func init() {
+	fs := validateCmd.Flags()
+	fs.StringP("file", "f", "", "File containing the task definition, or - for standard input")
+	fs.StringArrayP("param", "p", []string{}, "Add a string value to the JSON-e context (repeatable) (format: NAME=VALUE)")
+	fs.StringP("worker", "w", "", "The kind of worker which will run the task, such as generic-worker/multiuser")
+	fs.Bool("fetch", false, "Fetch the payload schema from the deployment, even if it is built into this client")
+	for _, name := range []string{"file", "worker"} {
+		if err := validateCmd.MarkFlagRequired(name); err != nil {
+			panic(fmt.Sprintf("Cannot mark flag required: %s", err))
+		}
+	}
+
+	Command.AddCommand(validateCmd)
+}
+
+// workerNames returns the names of the workers accepted by --worker.
+func workerNames() []string {
+	names := make([]string, 0, len(payloadSchemas))
+	for name := range payloadSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runValidate validates the payload of a task definition.
+func runValidate(_ *tcclient.Credentials, _ []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	worker, _ := flagSet.GetString("worker")
+	fetch, _ := flagSet.GetBool("fetch")
+	schema, ok := payloadSchemas[worker]
+	if !ok {
+		return fmt.Errorf("unknown worker %q; expected one of %s", worker, strings.Join(workerNames(), ", "))
+	}
+
+	_, task, err := readTaskDefinition(flagSet)
+	if err != nil {
+		return err
+	}
+
+	var loader js.JSONLoader
+	if schema.embedded != nil && !fetch {
+		loader = js.NewStringLoader(schema.embedded())
+	} else {
+		loader = js.NewReferenceLoader(tcurls.Schema(config.RootURL(), schema.service, schema.path))
+	}
+	result, err := js.Validate(loader, js.NewBytesLoader(task.Payload))
+	if err != nil {
+		return fmt.Errorf("could not validate the payload: %v", err)
+	}
+	if !result.Valid() {
+		lines := []string{fmt.Sprintf("the payload is not valid for %s:", worker)}
+		for _, desc := range payloadErrors(result.Errors()) {
+			lines = append(lines, "- "+desc)
+		}
+		return errors.New(strings.Join(lines, "\n"))
+	}
+
+	fmt.Fprintf(out, "The payload is valid for %s.\n", worker)
+	return nil
+}
+
+// payloadErrors describes validation errors by their path in the task
+// definition, such as payload.mounts[0].  Errors only saying that a value
+// matched none of several alternatives are left out when the errors from
+// those alternatives are reported.
+func payloadErrors(resultErrors []js.ResultError) []string {
+	var descs []string
+	for _, e := range resultErrors {
+		if e.Type() == "number_one_of" || e.Type() == "number_any_of" {
+			specific := false
+			for _, other := range resultErrors {
+				if other == e {
+					continue
+				}
+				if e.Field() == "(root)" || other.Field() == e.Field() || strings.HasPrefix(other.Field(), e.Field()+".") {
+					specific = true
+					break
+				}
+			}
+			if specific {
+				continue
+			}
+		}
+		descs = append(descs, fmt.Sprintf("%s: %s", payloadPath(e.Field()), e.Description()))
+	}
+	return descs
+}
+
+// payloadPath converts the field of a gojsonschema error, such as
+// mounts.0.file, to its path in the task definition, payload.mounts[0].file.
+func payloadPath(field string) string {
+	path := "payload"
+	if field == "(root)" {
+		return path
+	}
+	for _, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			path += "[" + part + "]"
+		} else {
+			path += "." + part
+		}
+	}
+	return path
+}
@@ -0,0 +1,99 @@
+package task
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+const validTaskDefinition = `
+provisionerId: proj-misc
+workerType: ci
+payload:
+  command: [[echo, hello]]
+  maxRunTime: 600
+metadata: {name: test, description: test, owner: test@example.com, source: 'https://example.com'}
+`
+
+const invalidTaskDefinition = `
+provisionerId: proj-misc
+workerType: ci
+payload:
+  command: [[echo, hello]]
+  maxRunTime: ten
+  mounts:
+    - {file: a, content: {url: 'https://example.com/a'}, bogus: true}
+metadata: {name: test, description: test, owner: test@example.com, source: 'https://example.com'}
+`
+
+func setUpValidateCommand(t *testing.T, definition, worker string, fetch bool) *cobra.Command {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "task.yml")
+	require.NoError(t, os.WriteFile(filename, []byte(definition), 0644))
+	cmd := &cobra.Command{}
+	cmd.Flags().String("file", filename, "")
+	cmd.Flags().StringArray("param", nil, "")
+	cmd.Flags().String("worker", worker, "")
+	cmd.Flags().Bool("fetch", fetch, "")
+	return cmd
+}
+
+func TestValidateEmbeddedSchema(t *testing.T) {
+	cmd := setUpValidateCommand(t, validTaskDefinition, "generic-worker/multiuser", false)
+	var buf bytes.Buffer
+
+	assert.NoError(t, runValidate(&tcclient.Credentials{}, nil, &buf, cmd.Flags()))
+	assert.Equal(t, "The payload is valid for generic-worker/multiuser.\n", buf.String())
+}
+
+func TestValidatePathErrors(t *testing.T) {
+	cmd := setUpValidateCommand(t, invalidTaskDefinition, "generic-worker/multiuser", false)
+	var buf bytes.Buffer
+
+	err := runValidate(&tcclient.Credentials{}, nil, &buf, cmd.Flags())
+
+	assert.ErrorContains(t, err, "the payload is not valid for generic-worker/multiuser:")
+	assert.ErrorContains(t, err, "- payload.maxRunTime: Invalid type. Expected: integer, given: string")
+	assert.ErrorContains(t, err, "- payload.mounts[0]: Additional property bogus is not allowed")
+	// the errors of the alternatives are reported instead
+	assert.NotContains(t, err.Error(), "Must validate one and only one schema")
+}
+
+func TestValidateFetchedSchema(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/schemas/generic-worker/simple_posix.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{
+			"$schema": "http://json-schema.org/draft-06/schema#",
+			"type": "object",
+			"properties": {"maxRunTime": {"type": "integer", "maximum": 300}}
+		}`)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	config.SetRootURL(server.URL)
+	defer config.SetRootURL("")
+
+	cmd := setUpValidateCommand(t, validTaskDefinition, "generic-worker/simple", false)
+	var buf bytes.Buffer
+
+	err := runValidate(&tcclient.Credentials{}, nil, &buf, cmd.Flags())
+
+	assert.ErrorContains(t, err, "- payload.maxRunTime: Must be less than or equal to 300")
+}
+
+func TestValidateUnknownWorker(t *testing.T) {
+	cmd := setUpValidateCommand(t, validTaskDefinition, "scriptworker", false)
+	var buf bytes.Buffer
+
+	assert.ErrorContains(t, runValidate(&tcclient.Credentials{}, nil, &buf, cmd.Flags()), `unknown worker "scriptworker"`)
+}
@@ -0,0 +1,407 @@
+package tcyml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/taskcluster/slugid-go/slugid"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+	"github.com/taskcluster/taskcluster/v60/internal/jsone"
+	"gopkg.in/yaml.v3"
+)
+
+// The values of tasks_for given to .taskcluster.yml by the GitHub
+// integration.
+const (
+	tasksForPush                 = "github-push"
+	tasksForRelease              = "github-release"
+	tasksForPullRequest          = "github-pull-request"
+	tasksForPullRequestUntrusted = "github-pull-request-untrusted"
+)
+
+// defaultFilename is the file rendered when none is given.
+const defaultFilename = ".taskcluster.yml"
+
+func init() {
+	renderCmd := &cobra.Command{
+		Use:   "render [<file>]",
+		Short: "Render a .taskcluster.yml file for a GitHub event.",
+		Long: `Renders a .taskcluster.yml file, by default the one in the current directory,
+with JSON-e as the Taskcluster GitHub integration does for the given GitHub
+event, and prints the resulting tasks and the scopes they would be created
+with.  No tasks are created.
+
+The event is a GitHub webhook payload in a JSON or YAML file, or "-" for stdin,
+such as one copied from the recent deliveries of a GitHub app.  The value of
+tasks_for is inferred from the event; give it with --tasks-for to render
+for github-pull-request-untrusted.
+
+As in the GitHub integration, taskIds are random unless the file sets them, so
+they differ from run to run.  Only version 1 files are supported.`,
+		Example: `  taskcluster tc-yml render --event push.json
+  taskcluster tc-yml render --event pr.json --tasks-for github-pull-request-untrusted ci/.taskcluster.yml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRender(args, cmd.OutOrStdout(), cmd.Flags())
+		},
+	}
+	renderCmd.Flags().StringP("event", "e", "", "A file containing the GitHub webhook payload of the event, or - for stdin.")
+	renderCmd.Flags().String("tasks-for", "", "The value of tasks_for: github-push, github-release, github-pull-request or github-pull-request-untrusted [default: inferred from the event].")
+	renderCmd.Flags().String("root-url", "", "The value of taskcluster_root_url [default: the configured root URL].")
+	renderCmd.Flags().String("scheduler-id", "taskcluster-github", "The schedulerId of the GitHub integration of the deployment.")
+	if err := renderCmd.MarkFlagRequired("event"); err != nil {
+		panic(fmt.Sprintf("Cannot mark flag required: %s", err))
+	}
+	output.AddFlag(renderCmd)
+	Command.AddCommand(renderCmd)
+}
+
+// taskGraph is the result of rendering a .taskcluster.yml file: the tasks the
+// GitHub integration would create, in the order it would create them, and
+// the scopes it would create them with.
+type taskGraph struct {
+	Scopes []string    `json:"scopes"`
+	Tasks  []graphTask `json:"tasks"`
+}
+
+type graphTask struct {
+	TaskID string                 `json:"taskId"`
+	Task   map[string]interface{} `json:"task"`
+}
+
+// renderOptions are the deployment and event specific inputs to rendering.
+type renderOptions struct {
+	TasksFor    string
+	RootURL     string
+	SchedulerID string
+}
+
+// runRender renders a .taskcluster.yml file and prints the task graph.
+func runRender(args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	filename := defaultFilename
+	if len(args) > 0 {
+		filename = args[0]
+	}
+	eventFilename, _ := flagSet.GetString("event")
+	if filename == "-" && eventFilename == "-" {
+		return errors.New("only one of the file and --event can be read from stdin")
+	}
+
+	var template interface{}
+	if err := readYAML(filename, &template); err != nil {
+		return fmt.Errorf("could not read %s: %v", filename, err)
+	}
+	var event map[string]interface{}
+	if err := readYAML(eventFilename, &event); err != nil {
+		return fmt.Errorf("could not read event: %v", err)
+	}
+
+	opts := renderOptions{}
+	opts.TasksFor, _ = flagSet.GetString("tasks-for")
+	if opts.TasksFor == "" {
+		var err error
+		if opts.TasksFor, err = inferTasksFor(event); err != nil {
+			return err
+		}
+	}
+	opts.RootURL, _ = flagSet.GetString("root-url")
+	if opts.RootURL == "" {
+		opts.RootURL = config.RootURL()
+	}
+	opts.SchedulerID, _ = flagSet.GetString("scheduler-id")
+
+	graph, err := render(template, event, opts, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not render %s: %v", filename, err)
+	}
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, graph, func() *output.Table {
+			table := &output.Table{Header: []string{"TASK ID", "NAME", "DEPENDENCIES"}}
+			for _, task := range graph.Tasks {
+				name, _ := lookupString(task.Task, "metadata", "name")
+				table.AddRow(task.TaskID, name, strings.Join(stringList(task.Task["dependencies"]), ","))
+			}
+			return table
+		})
+	}
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// readYAML reads a YAML or JSON file, or stdin if filename is "-".
+func readYAML(filename string, value interface{}) error {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, value)
+}
+
+// inferTasksFor returns the tasks_for the GitHub integration uses for an
+// event, based on the properties of its webhook payload.
+func inferTasksFor(event map[string]interface{}) (string, error) {
+	switch {
+	case event["pull_request"] != nil:
+		return tasksForPullRequest, nil
+	case event["release"] != nil:
+		return tasksForRelease, nil
+	case event["ref"] != nil:
+		return tasksForPush, nil
+	}
+	return "", errors.New("could not tell the kind of the event; give it with --tasks-for")
+}
+
+// render renders a .taskcluster.yml template for an event and compiles the
+// result into a task graph, as the GitHub integration does.
+func render(template interface{}, event map[string]interface{}, opts renderOptions, now time.Time) (*taskGraph, error) {
+	tcyml, ok := template.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the file must contain an object")
+	}
+	if version := fmt.Sprint(tcyml["version"]); version != "1" {
+		return nil, fmt.Errorf("version %s is not supported; only version 1 files can be rendered", version)
+	}
+	delete(tcyml, "version")
+
+	slugids := map[string]string{}
+	context := map[string]interface{}{
+		"taskcluster_root_url": opts.RootURL,
+		"tasks_for":            opts.TasksFor,
+		"event":                event,
+		"as_slugid": jsone.Function(func(args ...interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return nil, errors.New("as_slugid expects one argument")
+			}
+			label, ok := args[0].(string)
+			if !ok {
+				return nil, errors.New("as_slugid expects a string")
+			}
+			if _, ok := slugids[label]; !ok {
+				slugids[label] = slugid.Nice()
+			}
+			return slugids[label], nil
+		}),
+	}
+	rendered, err := jsone.RenderAt(tcyml, context, now)
+	if err != nil {
+		return nil, err
+	}
+	renderedConfig, ok := rendered.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the file must render to an object")
+	}
+
+	route := "statuses"
+	if reporting, _ := renderedConfig["reporting"].(string); reporting != "" {
+		route = "checks"
+	}
+	tasks, err := compileTasks(renderedConfig["tasks"], route, opts.SchedulerID, now)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := createScopes(event, opts.TasksFor)
+	if err != nil {
+		return nil, err
+	}
+	scopes = append(scopes, "queue:route:"+route, "queue:scheduler-id:"+opts.SchedulerID)
+	return &taskGraph{Scopes: scopes, Tasks: tasks}, nil
+}
+
+// compileTasks fills in the defaults the GitHub integration gives the
+// rendered tasks, and orders them so that each task comes after the tasks it
+// depends on.  A sole task is given the same taskId and taskGroupId, making
+// it a decision task; otherwise the tasks get a new task group.
+func compileTasks(value interface{}, route, schedulerID string, now time.Time) ([]graphTask, error) {
+	if value == nil {
+		return []graphTask{}, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("tasks must be an array")
+	}
+	tasks := make([]map[string]interface{}, 0, len(list))
+	for i, item := range list {
+		task, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("tasks[%d] must be an object", i)
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		return []graphTask{}, nil
+	}
+
+	var defaultTaskID, defaultTaskGroupID string
+	if len(tasks) == 1 {
+		taskID, _ := tasks[0]["taskId"].(string)
+		taskGroupID, _ := tasks[0]["taskGroupId"].(string)
+		switch {
+		case taskID != "" && taskGroupID != "":
+		case taskID != "":
+			defaultTaskGroupID = taskID
+		case taskGroupID != "":
+			defaultTaskID = taskGroupID
+		default:
+			defaultTaskID = slugid.Nice()
+			defaultTaskGroupID = defaultTaskID
+		}
+	} else {
+		defaultTaskID = slugid.Nice()
+		defaultTaskGroupID = slugid.Nice()
+	}
+
+	byID := map[string]graphTask{}
+	order := []string{}
+	for i, task := range tasks {
+		routes := []interface{}{}
+		seen := map[string]bool{}
+		for _, r := range append(stringList(task["routes"]), route) {
+			if !seen[r] {
+				seen[r] = true
+				routes = append(routes, r)
+			}
+		}
+		task["routes"] = routes
+		setDefault(task, "taskId", defaultTaskID)
+		setDefault(task, "taskGroupId", defaultTaskGroupID)
+		setDefault(task, "created", tcclient.Time(now).String())
+		setDefault(task, "schedulerId", schedulerID)
+		defaultTaskID = slugid.Nice()
+
+		taskID, ok := task["taskId"].(string)
+		if !ok {
+			return nil, fmt.Errorf("the taskId of tasks[%d] must be a string", i)
+		}
+		delete(task, "taskId")
+		if _, exists := byID[taskID]; exists {
+			return nil, fmt.Errorf("more than one task has taskId %s", taskID)
+		}
+		byID[taskID] = graphTask{TaskID: taskID, Task: task}
+		order = append(order, taskID)
+	}
+
+	// order the tasks so that dependencies come first, keeping the order of
+	// the file otherwise
+	sorted := make([]graphTask, 0, len(order))
+	const visiting, visited = 1, 2
+	state := map[string]int{}
+	var visit func(taskID string) error
+	visit = func(taskID string) error {
+		switch state[taskID] {
+		case visiting:
+			return fmt.Errorf("the dependencies of task %s form a cycle", taskID)
+		case visited:
+			return nil
+		}
+		state[taskID] = visiting
+		for _, dependency := range stringList(byID[taskID].Task["dependencies"]) {
+			if _, ok := byID[dependency]; ok {
+				if err := visit(dependency); err != nil {
+					return err
+				}
+			}
+		}
+		state[taskID] = visited
+		sorted = append(sorted, byID[taskID])
+		return nil
+	}
+	for _, taskID := range order {
+		if err := visit(taskID); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// createScopes returns the role the GitHub integration assumes to create the
+// tasks for an event.
+func createScopes(event map[string]interface{}, tasksFor string) ([]string, error) {
+	repository, _ := lookupString(event, "repository", "name")
+	organization, ok := lookupString(event, "repository", "owner", "login")
+	if !ok {
+		// the owner of the repository of push events has a name but no login
+		organization, _ = lookupString(event, "repository", "owner", "name")
+	}
+	if organization == "" || repository == "" {
+		return nil, errors.New("the event has no repository.name and repository.owner")
+	}
+	prefix := "assume:repo:github.com/" + organization + "/" + repository
+
+	switch tasksFor {
+	case tasksForPullRequest:
+		return []string{prefix + ":pull-request"}, nil
+	case tasksForPullRequestUntrusted:
+		return []string{prefix + ":pull-request-untrusted"}, nil
+	case tasksForPush:
+		ref, _ := lookupString(event, "ref")
+		parts := strings.Split(ref, "/")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("the ref %q of the event is not of the form refs/<kind>/<name>", ref)
+		}
+		name := strings.Join(parts[2:], "/")
+		if parts[1] == "tags" {
+			return []string{prefix + ":tag:" + name}, nil
+		}
+		return []string{prefix + ":branch:" + name}, nil
+	case tasksForRelease:
+		action, _ := lookupString(event, "action")
+		if action == "" {
+			action = "published"
+		}
+		return []string{prefix + ":release:" + action}, nil
+	}
+	return nil, fmt.Errorf("unknown tasks_for %q; expected %s, %s, %s or %s", tasksFor, tasksForPush, tasksForRelease, tasksForPullRequest, tasksForPullRequestUntrusted)
+}
+
+// setDefault sets a property of a task, unless the task already has it or
+// the default value is empty.
+func setDefault(task map[string]interface{}, name, value string) {
+	if _, ok := task[name]; !ok && value != "" {
+		task[name] = value
+	}
+}
+
+// lookupString returns the string at a path of properties in a JSON value.
+func lookupString(value interface{}, path ...string) (string, bool) {
+	for _, name := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		value = object[name]
+	}
+	s, ok := value.(string)
+	return s, ok
+}
+
+// stringList returns the strings in a JSON array, ignoring other values.
+func stringList(value interface{}) []string {
+	list, _ := value.([]interface{})
+	strs := []string{}
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,208 @@
+package tcyml
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var renderNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+var renderOpts = renderOptions{
+	TasksFor:    tasksForPush,
+	RootURL:     "https://tc.example.com",
+	SchedulerID: "taskcluster-github",
+}
+
+func parseYAML(t *testing.T, text string) map[string]interface{} {
+	t.Helper()
+	var value map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(text), &value))
+	return value
+}
+
+const pushEvent = `
+ref: refs/heads/main
+after: abc123
+repository:
+  name: repo
+  url: https://github.com/org/repo
+  owner: {name: org}
+`
+
+func TestRenderDecisionTask(t *testing.T) {
+	template := parseYAML(t, `
+version: 1
+tasks:
+  - provisionerId: proj
+    workerType: ci
+    deadline: {$fromNow: '1 day'}
+    routes: [index.project.main]
+    payload:
+      env:
+        SHA: '${event.after}'
+        ROOT_URL: '${taskcluster_root_url}'
+        TASKS_FOR: '${tasks_for}'
+`)
+	graph, err := render(template, parseYAML(t, pushEvent), renderOpts, renderNow)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"assume:repo:github.com/org/repo:branch:main",
+		"queue:route:statuses",
+		"queue:scheduler-id:taskcluster-github",
+	}, graph.Scopes)
+	require.Len(t, graph.Tasks, 1)
+	task := graph.Tasks[0]
+	assert.Equal(t, task.TaskID, task.Task["taskGroupId"])
+	assert.Equal(t, map[string]interface{}{
+		"provisionerId": "proj",
+		"workerType":    "ci",
+		"taskGroupId":   task.TaskID,
+		"schedulerId":   "taskcluster-github",
+		"created":       "2024-01-01T12:00:00.000Z",
+		"deadline":      "2024-01-02T12:00:00.000Z",
+		"routes":        []interface{}{"index.project.main", "statuses"},
+		"payload": map[string]interface{}{
+			"env": map[string]interface{}{
+				"SHA":       "abc123",
+				"ROOT_URL":  "https://tc.example.com",
+				"TASKS_FOR": "github-push",
+			},
+		},
+	}, task.Task)
+}
+
+func TestRenderDependencies(t *testing.T) {
+	template := parseYAML(t, `
+version: 1
+reporting: checks-v1
+tasks:
+  - taskId: {$eval: as_slugid("test")}
+    dependencies: [{$eval: as_slugid("build")}, other-task]
+  - taskId: {$eval: as_slugid("lint")}
+  - taskId: {$eval: as_slugid("build")}
+    routes: [checks]
+`)
+	graph, err := render(template, parseYAML(t, pushEvent), renderOpts, renderNow)
+	require.NoError(t, err)
+
+	assert.Contains(t, graph.Scopes, "queue:route:checks")
+	require.Len(t, graph.Tasks, 3)
+	build, test, lint := graph.Tasks[0], graph.Tasks[1], graph.Tasks[2]
+	assert.Equal(t, []interface{}{build.TaskID, "other-task"}, test.Task["dependencies"])
+	assert.Nil(t, lint.Task["dependencies"])
+	assert.Equal(t, []interface{}{"checks"}, build.Task["routes"])
+
+	// the tasks get a new task group
+	groupID := build.Task["taskGroupId"]
+	assert.NotContains(t, []string{build.TaskID, test.TaskID, lint.TaskID}, groupID)
+	assert.Equal(t, groupID, test.Task["taskGroupId"])
+	assert.Equal(t, groupID, lint.Task["taskGroupId"])
+}
+
+func TestRenderErrors(t *testing.T) {
+	event := parseYAML(t, pushEvent)
+
+	_, err := render(parseYAML(t, "version: 0\ntasks: []"), event, renderOpts, renderNow)
+	assert.EqualError(t, err, "version 0 is not supported; only version 1 files can be rendered")
+
+	_, err = render(parseYAML(t, "version: 1\ntasks: {$eval: missing}"), event, renderOpts, renderNow)
+	assert.Error(t, err)
+
+	_, err = render(parseYAML(t, `
+version: 1
+tasks:
+  - {taskId: a, dependencies: [b]}
+  - {taskId: b, dependencies: [a]}
+`), event, renderOpts, renderNow)
+	assert.EqualError(t, err, "the dependencies of task a form a cycle")
+
+	_, err = render(parseYAML(t, "version: 1\ntasks: [{taskId: a}, {taskId: a}]"), event, renderOpts, renderNow)
+	assert.EqualError(t, err, "more than one task has taskId a")
+}
+
+func TestRenderNoTasks(t *testing.T) {
+	graph, err := render(parseYAML(t, "version: 1\ntasks: {$if: 'false', then: []}"), parseYAML(t, pushEvent), renderOpts, renderNow)
+	require.NoError(t, err)
+	assert.Equal(t, []graphTask{}, graph.Tasks)
+}
+
+func TestInferTasksFor(t *testing.T) {
+	for event, expected := range map[string]string{
+		pushEvent: tasksForPush,
+		`{"action": "opened", "pull_request": {"number": 1}}`:    tasksForPullRequest,
+		`{"action": "published", "release": {"tag_name": "v1"}}`: tasksForRelease,
+	} {
+		tasksFor, err := inferTasksFor(parseYAML(t, event))
+		require.NoError(t, err)
+		assert.Equal(t, expected, tasksFor)
+	}
+
+	_, err := inferTasksFor(parseYAML(t, `{"zen": "Keep it logically awesome."}`))
+	assert.Error(t, err)
+}
+
+func TestCreateScopes(t *testing.T) {
+	repository := `"repository": {"name": "repo", "owner": {"login": "org"}}`
+	for _, test := range []struct {
+		event, tasksFor, scope string
+	}{
+		{`{"ref": "refs/tags/v1.0", "repository": {"name": "repo", "owner": {"name": "org"}}}`, tasksForPush, "assume:repo:github.com/org/repo:tag:v1.0"},
+		{`{"ref": "refs/heads/feature/x", "repository": {"name": "repo", "owner": {"name": "org"}}}`, tasksForPush, "assume:repo:github.com/org/repo:branch:feature/x"},
+		{`{` + repository + `}`, tasksForPullRequest, "assume:repo:github.com/org/repo:pull-request"},
+		{`{` + repository + `}`, tasksForPullRequestUntrusted, "assume:repo:github.com/org/repo:pull-request-untrusted"},
+		{`{"action": "released", ` + repository + `}`, tasksForRelease, "assume:repo:github.com/org/repo:release:released"},
+		{`{` + repository + `}`, tasksForRelease, "assume:repo:github.com/org/repo:release:published"},
+	} {
+		scopes, err := createScopes(parseYAML(t, test.event), test.tasksFor)
+		require.NoError(t, err)
+		assert.Equal(t, []string{test.scope}, scopes)
+	}
+
+	_, err := createScopes(parseYAML(t, `{`+repository+`}`), "github-issue-comment")
+	assert.Error(t, err)
+	_, err = createScopes(parseYAML(t, `{"ref": "refs/heads/main"}`), tasksForPush)
+	assert.Error(t, err)
+}
+
+func TestRunRender(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".taskcluster.yml"), []byte(`
+version: 1
+tasks:
+  - taskId: decision
+    metadata: {name: 'decision for ${tasks_for}'}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "event.json"), []byte(`{"action": "opened", "pull_request": {}, "repository": {"name": "repo", "owner": {"login": "org"}}}`), 0644))
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("event", filepath.Join(dir, "event.json"), "")
+	cmd.Flags().String("tasks-for", tasksForPullRequestUntrusted, "")
+	cmd.Flags().String("root-url", "https://tc.example.com", "")
+	cmd.Flags().String("scheduler-id", "taskcluster-github", "")
+	cmd.Flags().String("output", "", "")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, runRender([]string{filepath.Join(dir, ".taskcluster.yml")}, buf, cmd.Flags()))
+	var graph taskGraph
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &graph))
+	assert.Equal(t, "assume:repo:github.com/org/repo:pull-request-untrusted", graph.Scopes[0])
+	require.Len(t, graph.Tasks, 1)
+	assert.Equal(t, "decision", graph.Tasks[0].TaskID)
+	assert.Equal(t, "decision", graph.Tasks[0].Task["taskGroupId"])
+	assert.Equal(t, map[string]interface{}{"name": "decision for github-pull-request-untrusted"}, graph.Tasks[0].Task["metadata"])
+
+	buf.Reset()
+	require.NoError(t, cmd.Flags().Set("output", "table"))
+	require.NoError(t, runRender([]string{filepath.Join(dir, ".taskcluster.yml")}, buf, cmd.Flags()))
+	assert.Equal(t, "TASK ID   NAME                                        DEPENDENCIES\ndecision  decision for github-pull-request-untrusted  \n", buf.String())
+}
@@ -0,0 +1,20 @@
+// Package tcyml implements the tc-yml subcommands, for working with the
+// `.taskcluster.yml` files read by the Taskcluster GitHub integration.
+package tcyml
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+)
+
+var (
+	// Command is the root of the tc-yml subtree.
+	Command = &cobra.Command{
+		Use:   "tc-yml",
+		Short: "Provides commands to work with .taskcluster.yml files.",
+	}
+)
+
+func init() {
+	root.Command.AddCommand(Command)
+}
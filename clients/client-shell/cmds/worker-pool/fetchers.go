@@ -0,0 +1,112 @@
+package workerpool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+// runList lists the worker pools, one per line.
+func runList(credentials *tcclient.Credentials, _ []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	wm := makeWorkerManager(credentials)
+	provider, _ := flagSet.GetString("provider")
+
+	pools := []tcworkermanager.WorkerPoolFullDefinition{}
+	pages := wm.ListWorkerPoolsPages(nil, "")
+	for pages.Next() {
+		for _, pool := range pages.Page().WorkerPools {
+			if provider == "" || pool.ProviderID == provider {
+				pools = append(pools, pool)
+			}
+		}
+	}
+	if err := pages.Err(); err != nil {
+		return fmt.Errorf("could not list worker pools: %v", err)
+	}
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, pools, func() *output.Table {
+			table := &output.Table{Header: []string{"WORKER POOL", "PROVIDER", "RUNNING", "REQUESTED"}}
+			for _, pool := range pools {
+				table.AddRow(pool.WorkerPoolID, pool.ProviderID, fmt.Sprint(pool.RunningCount), fmt.Sprint(pool.RequestedCount))
+			}
+			return table
+		})
+	}
+
+	for _, pool := range pools {
+		fmt.Fprintf(out, "%s %s running=%d requested=%d\n", pool.WorkerPoolID, pool.ProviderID, pool.RunningCount, pool.RequestedCount)
+	}
+	return nil
+}
+
+// runGet prints the full definition of a worker pool.
+func runGet(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	wm := makeWorkerManager(credentials)
+	workerPoolID := args[0]
+
+	pool, err := wm.WorkerPool(workerPoolID)
+	if err != nil {
+		return fmt.Errorf("could not get the worker pool %s: %v", workerPoolID, err)
+	}
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, pool, nil)
+	}
+
+	def, err := json.MarshalIndent(pool, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal worker pool %s into json: %v", workerPoolID, err)
+	}
+
+	fmt.Fprintln(out, string(def))
+	return nil
+}
+
+// runErrors lists the errors of a worker pool, most recent first.
+func runErrors(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	wm := makeWorkerManager(credentials)
+	workerPoolID := args[0]
+
+	poolErrors := []tcworkermanager.WorkerPoolError{}
+	pages := wm.ListWorkerPoolErrorsPages(nil, workerPoolID, "")
+	for pages.Next() {
+		poolErrors = append(poolErrors, pages.Page().WorkerPoolErrors...)
+	}
+	if err := pages.Err(); err != nil {
+		return fmt.Errorf("could not list the errors of worker pool %s: %v", workerPoolID, err)
+	}
+
+	sort.SliceStable(poolErrors, func(i, j int) bool {
+		return time.Time(poolErrors[i].Reported).After(time.Time(poolErrors[j].Reported))
+	})
+	if limit, _ := flagSet.GetInt("limit"); limit > 0 && limit < len(poolErrors) {
+		poolErrors = poolErrors[:limit]
+	}
+
+	if output.Selected(flagSet) {
+		return output.Write(out, flagSet, poolErrors, func() *output.Table {
+			table := &output.Table{Header: []string{"REPORTED", "KIND", "TITLE"}}
+			for _, e := range poolErrors {
+				table.AddRow(output.Time(e.Reported), e.Kind, e.Title)
+			}
+			return table
+		})
+	}
+
+	for _, e := range poolErrors {
+		fmt.Fprintf(out, "%s [%s] %s\n", e.Reported, e.Kind, e.Title)
+		for _, line := range strings.Split(strings.TrimRight(e.Description, "\n"), "\n") {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package workerpool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/editor"
+	js "github.com/xeipuuv/gojsonschema"
+)
+
+// editableDefinition holds the fields of a worker pool definition that can be
+// updated, in the order they are shown in the editor.
+type editableDefinition struct {
+	WorkerPoolID string          `json:"workerPoolId"`
+	ProviderID   string          `json:"providerId"`
+	Description  string          `json:"description"`
+	Owner        string          `json:"owner"`
+	EmailOnError bool            `json:"emailOnError"`
+	Config       json.RawMessage `json:"config"`
+}
+
+// runEditor opens a file in the user's editor; tests replace it
+var runEditor = editor.Run
+
+// runUpdate updates a worker pool with a definition edited by the user, or
+// read from a file.
+func runUpdate(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	wm := makeWorkerManager(credentials)
+	workerPoolID := args[0]
+
+	var (
+		data []byte
+		// the file holding the edited definition, which is kept if the
+		// update fails so that the edits are not lost
+		editedFile string
+		err        error
+	)
+	if file, _ := flagSet.GetString("file"); file != "" {
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return fmt.Errorf("could not read the worker pool definition: %v", err)
+		}
+	} else {
+		pool, err := wm.WorkerPool(workerPoolID)
+		if err != nil {
+			return fmt.Errorf("could not get the worker pool %s: %v", workerPoolID, err)
+		}
+		data, editedFile, err = editDefinition(pool)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			fmt.Fprintf(out, "No changes; worker pool %s was not updated.\n", workerPoolID)
+			return nil
+		}
+	}
+
+	// keepEdits adds the location of the edited definition to an error
+	keepEdits := func(err error) error {
+		if editedFile == "" {
+			return err
+		}
+		return fmt.Errorf("%v\nThe edited definition was saved in %s; fix it and run again with --file %s", err, editedFile, editedFile)
+	}
+
+	if err := validateDefinition(config.RootURL(), data); err != nil {
+		return keepEdits(err)
+	}
+	definition := &tcworkermanager.WorkerPoolDefinition1{}
+	if err := json.Unmarshal(data, definition); err != nil {
+		return keepEdits(fmt.Errorf("invalid worker pool definition: %v", err))
+	}
+	if definition.WorkerPoolID != "" && definition.WorkerPoolID != workerPoolID {
+		return keepEdits(fmt.Errorf("the definition is for worker pool %s, not %s", definition.WorkerPoolID, workerPoolID))
+	}
+
+	if dryRun, _ := flagSet.GetBool("dry-run"); dryRun {
+		fmt.Fprintf(out, "The definition is valid; worker pool %s was not updated (dry run).\n", workerPoolID)
+		if editedFile != "" {
+			fmt.Fprintf(out, "The edited definition was saved in %s.\n", editedFile)
+		}
+		return nil
+	}
+
+	if _, err := wm.UpdateWorkerPool(workerPoolID, definition); err != nil {
+		return keepEdits(fmt.Errorf("could not update the worker pool %s: %v", workerPoolID, err))
+	}
+	if editedFile != "" {
+		_ = os.Remove(editedFile)
+	}
+	fmt.Fprintf(out, "Updated worker pool %s.\n", workerPoolID)
+	return nil
+}
+
+// editDefinition lets the user edit the definition of the worker pool in a
+// temporary file, returning the edited definition and the file, or nil if
+// it was not changed.
+func editDefinition(pool *tcworkermanager.WorkerPoolFullDefinition) ([]byte, string, error) {
+	original, err := json.MarshalIndent(&editableDefinition{
+		WorkerPoolID: pool.WorkerPoolID,
+		ProviderID:   pool.ProviderID,
+		Description:  pool.Description,
+		Owner:        pool.Owner,
+		EmailOnError: pool.EmailOnError,
+		Config:       pool.Config,
+	}, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+
+	return editor.EditTemp("worker-pool-*.json", append(original, '\n'), runEditor)
+}
+
+// validateDefinition validates a worker pool definition against the
+// deployment's schema for updateWorkerPool requests.
+func validateDefinition(rootURL string, data []byte) error {
+	schema := js.NewReferenceLoader(tcurls.Schema(rootURL, "worker-manager", "v1/update-worker-pool-request.json"))
+	result, err := js.Validate(schema, js.NewBytesLoader(data))
+	if err != nil {
+		return fmt.Errorf("could not validate the worker pool definition: %v", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	lines := []string{"the worker pool definition is not valid:"}
+	for _, desc := range result.Errors() {
+		lines = append(lines, fmt.Sprintf("- %s", desc))
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,92 @@
+// Package workerpool implements the worker-pool subcommands.
+package workerpool
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/completion"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+var (
+	// Command is the root of the worker-pool subtree.
+	Command = &cobra.Command{
+		Use:   "worker-pool",
+		Short: "Provides commands to manage worker pools.",
+	}
+)
+
+func init() {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the worker pools, with their provider and number of running workers.",
+		Args:  cobra.NoArgs,
+		RunE:  executeHelperE(runList),
+	}
+	listCmd.Flags().String("provider", "", "Only list the worker pools of this provider.")
+	output.AddFlag(listCmd)
+
+	getCmd := &cobra.Command{
+		Use:               "get <workerPoolId>",
+		Short:             "Get the full definition of a worker pool.",
+		Args:              cobra.ExactArgs(1),
+		RunE:              executeHelperE(runGet),
+		ValidArgsFunction: completion.WorkerPoolIDs,
+	}
+	output.AddFlag(getCmd)
+
+	errorsCmd := &cobra.Command{
+		Use:               "errors <workerPoolId>",
+		Short:             "List the recent errors of a worker pool, such as failures to create workers.",
+		Args:              cobra.ExactArgs(1),
+		RunE:              executeHelperE(runErrors),
+		ValidArgsFunction: completion.WorkerPoolIDs,
+	}
+	errorsCmd.Flags().IntP("limit", "n", 0, "Only list this many of the most recent errors.")
+	output.AddFlag(errorsCmd)
+
+	updateCmd := &cobra.Command{
+		Use:   "update <workerPoolId>",
+		Short: "Edit the definition of a worker pool and update it.",
+		Long: `Opens the definition of the worker pool in the editor given by $VISUAL or
+$EDITOR, and updates the worker pool with the edited definition once the
+editor exits.  The definition is validated against the deployment's
+update-worker-pool-request schema before it is submitted; if it is not valid,
+the errors are shown and the edited definition is kept in a file so it can be
+fixed and given with --file.
+
+With --file, the definition is read from the given file, or from stdin if the
+file is '-', instead of being edited.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              executeHelperE(runUpdate),
+		ValidArgsFunction: completion.WorkerPoolIDs,
+	}
+	updateCmd.Flags().StringP("file", "f", "", "Read the new definition from this file ('-' for stdin) rather than editing it.")
+	updateCmd.Flags().BoolP("dry-run", "n", false, "Validate the new definition without updating the worker pool.")
+
+	Command.AddCommand(listCmd, getCmd, errorsCmd, updateCmd)
+	root.Command.AddCommand(Command)
+}
+
+// Executor represents the function interface of the worker-pool subcommands.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error
+
+func executeHelperE(f Executor) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
+
+func makeWorkerManager(credentials *tcclient.Credentials) *tcworkermanager.WorkerManager {
+	return tcworkermanager.New(credentials, config.RootURL())
+}
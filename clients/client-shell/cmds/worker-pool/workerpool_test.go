@@ -0,0 +1,266 @@
+package workerpool
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/output"
+)
+
+const fakeWorkerPoolID = "proj-misc/ci"
+
+const fakeWorkerPool = `{
+  "workerPoolId": "proj-misc/ci",
+  "providerId": "aws",
+  "description": "CI workers",
+  "owner": "ci@example.com",
+  "emailOnError": false,
+  "config": {"maxCapacity": 10},
+  "created": "2024-01-01T00:00:00.000Z",
+  "lastModified": "2024-01-02T00:00:00.000Z",
+  "currentCapacity": 3,
+  "requestedCapacity": 1,
+  "requestedCount": 1,
+  "runningCapacity": 2,
+  "runningCount": 2,
+  "stoppedCapacity": 0,
+  "stoppedCount": 0,
+  "stoppingCapacity": 0,
+  "stoppingCount": 0
+}`
+
+// a simplified version of the update-worker-pool-request schema
+const fakeSchema = `{
+  "$schema": "http://json-schema.org/draft-06/schema#",
+  "type": "object",
+  "properties": {
+    "workerPoolId": {"type": "string"},
+    "providerId": {"type": "string"},
+    "description": {"type": "string"},
+    "owner": {"type": "string", "format": "email"},
+    "emailOnError": {"type": "boolean"},
+    "config": {"type": "object"},
+    "created": {"type": "string"},
+    "lastModified": {"type": "string"}
+  },
+  "additionalProperties": false,
+  "required": ["providerId", "description", "config", "owner", "emailOnError"]
+}`
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// the body of the last updateWorkerPool request
+	updated []byte
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/worker-manager/v1/worker-pools", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"workerPools": [`+fakeWorkerPool+`, {"workerPoolId": "proj-misc/static", "providerId": "static", "runningCount": 1}]}`)
+	})
+	handler.HandleFunc("/api/worker-manager/v1/worker-pool/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimPrefix(r.URL.Path, "/api/worker-manager/v1/worker-pool/") != fakeWorkerPoolID {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodPost {
+			suite.updated, _ = io.ReadAll(r.Body)
+		}
+		_, _ = io.WriteString(w, fakeWorkerPool)
+	})
+	handler.HandleFunc("/api/worker-manager/v1/worker-pool-errors/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"workerPoolErrors": [
+			{"errorId": "e1", "kind": "creation-error", "title": "Instance Creation Error", "description": "quota exceeded", "reported": "2024-01-01T00:00:00.000Z", "workerPoolId": "proj-misc/ci", "extra": {}},
+			{"errorId": "e2", "kind": "termination-error", "title": "Termination Error", "description": "line 1\nline 2", "reported": "2024-01-03T00:00:00.000Z", "workerPoolId": "proj-misc/ci", "extra": {}}
+		]}`)
+	})
+	handler.HandleFunc("/schemas/worker-manager/v1/update-worker-pool-request.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, fakeSchema)
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.updated = nil
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func setUpCommand() (*bytes.Buffer, *cobra.Command) {
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	return buf, cmd
+}
+
+// setEditor replaces the editor for the duration of the test
+func (suite *FakeServerSuite) setEditor(edit func(filename string) error) {
+	orig := runEditor
+	runEditor = edit
+	suite.T().Cleanup(func() { runEditor = orig })
+}
+
+func (suite *FakeServerSuite) TestList() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("provider", "", "")
+
+	suite.NoError(runList(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("proj-misc/ci aws running=2 requested=1\nproj-misc/static static running=1 requested=0\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestListProvider() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("provider", "static", "")
+
+	suite.NoError(runList(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("proj-misc/static static running=1 requested=0\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestListOutput() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("provider", "", "")
+	output.AddFlag(cmd)
+	suite.NoError(cmd.Flags().Set("output", "table"))
+
+	suite.NoError(runList(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("WORKER POOL       PROVIDER  RUNNING  REQUESTED\n"+
+		"proj-misc/ci      aws       2        1\n"+
+		"proj-misc/static  static    1        0\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestGet() {
+	buf, cmd := setUpCommand()
+
+	suite.NoError(runGet(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.JSONEq(fakeWorkerPool, buf.String())
+}
+
+func (suite *FakeServerSuite) TestGetNotFound() {
+	_, cmd := setUpCommand()
+
+	suite.Error(runGet(&tcclient.Credentials{}, []string{"proj-misc/nope"}, cmd.OutOrStdout(), cmd.Flags()))
+}
+
+func (suite *FakeServerSuite) TestErrors() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Int("limit", 0, "")
+
+	suite.NoError(runErrors(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("2024-01-03T00:00:00.000Z [termination-error] Termination Error\n    line 1\n    line 2\n"+
+		"2024-01-01T00:00:00.000Z [creation-error] Instance Creation Error\n    quota exceeded\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestErrorsLimit() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Int("limit", 1, "")
+
+	suite.NoError(runErrors(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("2024-01-03T00:00:00.000Z [termination-error] Termination Error\n    line 1\n    line 2\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestUpdateEditor() {
+	suite.setEditor(func(filename string) error {
+		data, err := os.ReadFile(filename)
+		suite.NoError(err)
+		def := map[string]interface{}{}
+		suite.NoError(json.Unmarshal(data, &def))
+		// only the editable fields are shown
+		suite.NotContains(def, "created")
+		suite.NotContains(def, "runningCount")
+		def["description"] = "Edited"
+		data, err = json.Marshal(def)
+		suite.NoError(err)
+		return os.WriteFile(filename, data, 0644)
+	})
+	buf, cmd := setUpCommand()
+
+	suite.NoError(runUpdate(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("Updated worker pool proj-misc/ci.\n", buf.String())
+	updated := map[string]interface{}{}
+	suite.NoError(json.Unmarshal(suite.updated, &updated))
+	suite.Equal("Edited", updated["description"])
+	suite.Equal(map[string]interface{}{"maxCapacity": float64(10)}, updated["config"])
+}
+
+func (suite *FakeServerSuite) TestUpdateEditorNoChanges() {
+	suite.setEditor(func(string) error { return nil })
+	buf, cmd := setUpCommand()
+
+	suite.NoError(runUpdate(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("No changes; worker pool proj-misc/ci was not updated.\n", buf.String())
+	suite.Nil(suite.updated)
+}
+
+func (suite *FakeServerSuite) TestUpdateEditorInvalid() {
+	suite.setEditor(func(filename string) error {
+		return os.WriteFile(filename, []byte(`{"providerId": "aws", "description": "x", "owner": "ci@example.com", "emailOnError": "yes", "config": {}}`), 0644)
+	})
+	_, cmd := setUpCommand()
+
+	err := runUpdate(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags())
+
+	suite.Error(err)
+	suite.Contains(err.Error(), "emailOnError")
+	suite.Contains(err.Error(), "The edited definition was saved in")
+	suite.Nil(suite.updated)
+	// the edits are kept, for use with --file
+	filename := strings.Fields(err.Error()[strings.Index(err.Error(), "saved in ")+len("saved in "):])[0]
+	filename = strings.TrimSuffix(filename, ";")
+	data, readErr := os.ReadFile(filename)
+	suite.NoError(readErr)
+	suite.Contains(string(data), `"emailOnError": "yes"`)
+	_ = os.Remove(filename)
+}
+
+func (suite *FakeServerSuite) TestUpdateFileDryRun() {
+	filename := filepath.Join(suite.T().TempDir(), "pool.json")
+	suite.NoError(os.WriteFile(filename, []byte(`{"providerId": "aws", "description": "x", "owner": "ci@example.com", "emailOnError": true, "config": {}}`), 0644))
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("file", filename, "")
+	cmd.Flags().Bool("dry-run", true, "")
+
+	suite.NoError(runUpdate(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("The definition is valid; worker pool proj-misc/ci was not updated (dry run).\n", buf.String())
+	suite.Nil(suite.updated)
+}
+
+func (suite *FakeServerSuite) TestUpdateFileWrongWorkerPool() {
+	filename := filepath.Join(suite.T().TempDir(), "pool.json")
+	suite.NoError(os.WriteFile(filename, []byte(`{"workerPoolId": "proj-misc/other", "providerId": "aws", "description": "x", "owner": "ci@example.com", "emailOnError": true, "config": {}}`), 0644))
+	_, cmd := setUpCommand()
+	cmd.Flags().String("file", filename, "")
+
+	suite.EqualError(runUpdate(&tcclient.Credentials{}, []string{fakeWorkerPoolID}, cmd.OutOrStdout(), cmd.Flags()),
+		"the definition is for worker pool proj-misc/other, not proj-misc/ci")
+	suite.Nil(suite.updated)
+}
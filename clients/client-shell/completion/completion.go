@@ -0,0 +1,160 @@
+// Package completion completes the arguments of commands in the shell: worker
+// pool IDs, secret names and index namespaces, fetched from the deployment,
+// and recently used taskIds, from a local history.
+//
+// The functions are cobra ValidArgsFunctions, and only complete the first
+// argument of a command.
+package completion
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcindex"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcsecrets"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+// timeout bounds the API calls made to complete an argument, so that an
+// unreachable deployment does not hang the shell.
+var timeout = 5 * time.Second
+
+// lister lists the candidates for an argument starting with prefix.
+type lister func(ctx context.Context, credentials *tcclient.Credentials, rootURL, prefix string) ([]string, error)
+
+// fromAPI returns a completion function that lists candidates with list,
+// using the configuration of the profile given with --profile, if any.
+// Nothing is completed if no root URL is configured or the API call fails.
+func fromAPI(list lister, directive cobra.ShellCompDirective) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		// the root command's pre-run has loaded the configuration before the
+		// flags of the completed command were parsed
+		if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+			config.Setup(profile)
+		}
+		if !config.HasRootURL() {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		candidates, err := list(ctx, creds, config.RootURL(), toComplete)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return candidates, directive | cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// WorkerPoolIDs completes a workerPoolId.
+var WorkerPoolIDs = fromAPI(listWorkerPoolIDs, cobra.ShellCompDirectiveDefault)
+
+// SecretNames completes the name of a secret.
+var SecretNames = fromAPI(listSecretNames, cobra.ShellCompDirectiveDefault)
+
+// IndexNamespaces completes an index namespace, one level at a time: the
+// namespaces directly under the one before the last dot are listed, without a
+// trailing space so that another level can be typed.
+var IndexNamespaces = fromAPI(listNamespaces, cobra.ShellCompDirectiveNoSpace)
+
+// IndexedTasks completes the namespace of an indexed task as IndexNamespaces
+// does, also listing the indexed tasks directly under the namespace.
+var IndexedTasks = fromAPI(listNamespacesAndTasks, cobra.ShellCompDirectiveNoSpace)
+
+// TaskIDs completes a taskId from the history of recently used taskIds, most
+// recent first.
+func TaskIDs(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return withPrefix(RecentTaskIDs(), toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// TaskGroupIDs completes a taskGroupId from the history of recently used
+// taskGroupIds, most recent first.
+func TaskGroupIDs(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return withPrefix(RecentTaskGroupIDs(), toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+func listWorkerPoolIDs(ctx context.Context, credentials *tcclient.Credentials, rootURL, prefix string) ([]string, error) {
+	ids := []string{}
+	pages := tcworkermanager.New(credentials, rootURL).ListWorkerPoolsPages(ctx, "")
+	for pages.Next() {
+		for _, pool := range pages.Page().WorkerPools {
+			ids = append(ids, pool.WorkerPoolID)
+		}
+	}
+	return withPrefix(ids, prefix), pages.Err()
+}
+
+func listSecretNames(ctx context.Context, credentials *tcclient.Credentials, rootURL, prefix string) ([]string, error) {
+	names := []string{}
+	pages := tcsecrets.New(credentials, rootURL).ListPages(ctx, "")
+	for pages.Next() {
+		names = append(names, pages.Page().Secrets...)
+	}
+	return withPrefix(names, prefix), pages.Err()
+}
+
+func listNamespaces(ctx context.Context, credentials *tcclient.Credentials, rootURL, prefix string) ([]string, error) {
+	index := tcindex.New(credentials, rootURL)
+	namespaces := []string{}
+	pages := index.ListNamespacesPages(ctx, parentNamespace(prefix), "")
+	for pages.Next() {
+		for _, ns := range pages.Page().Namespaces {
+			namespaces = append(namespaces, ns.Namespace)
+		}
+	}
+	return withPrefix(namespaces, prefix), pages.Err()
+}
+
+func listNamespacesAndTasks(ctx context.Context, credentials *tcclient.Credentials, rootURL, prefix string) ([]string, error) {
+	namespaces, err := listNamespaces(ctx, credentials, rootURL, prefix)
+	if err != nil {
+		return nil, err
+	}
+	index := tcindex.New(credentials, rootURL)
+	tasks := []string{}
+	pages := index.ListTasksPages(ctx, parentNamespace(prefix), "")
+	for pages.Next() {
+		for _, task := range pages.Page().Tasks {
+			tasks = append(tasks, task.Namespace)
+		}
+	}
+	return append(namespaces, withPrefix(tasks, prefix)...), pages.Err()
+}
+
+// parentNamespace returns the namespace before the last dot of a partial
+// namespace, which is the one to list to complete it.
+func parentNamespace(prefix string) string {
+	if i := strings.LastIndex(prefix, "."); i >= 0 {
+		return prefix[:i]
+	}
+	return ""
+}
+
+// withPrefix returns the candidates starting with prefix.
+func withPrefix(candidates []string, prefix string) []string {
+	matching := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matching = append(matching, candidate)
+		}
+	}
+	return matching
+}
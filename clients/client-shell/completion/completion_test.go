@@ -0,0 +1,146 @@
+package completion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/slugid-go/slugid"
+	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
+)
+
+func setUpHistory(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	return filepath.Join(dir, "taskcluster", "task-history")
+}
+
+func setUpServer(t *testing.T, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	config.SetRootURL(server.URL)
+	t.Cleanup(func() {
+		server.Close()
+		config.SetRootURL("")
+	})
+}
+
+func TestRecordTaskIDs(t *testing.T) {
+	file := setUpHistory(t)
+	assert.Equal(t, []string{}, RecentTaskIDs())
+
+	first, second, third := slugid.Nice(), slugid.Nice(), slugid.Nice()
+	RecordTaskIDs(first)
+	RecordTaskIDs(second, "not-a-taskId")
+	RecordTaskIDs(third, first)
+	assert.Equal(t, []string{first, third, second}, RecentTaskIDs())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, first+"\n"+third+"\n"+second+"\n", string(data))
+}
+
+func TestRecordTaskIDsLimit(t *testing.T) {
+	setUpHistory(t)
+	taskIDs := []string{}
+	for i := 0; i < historySize+10; i++ {
+		taskIDs = append(taskIDs, slugid.Nice())
+		RecordTaskIDs(taskIDs[i])
+	}
+	recent := RecentTaskIDs()
+	require.Len(t, recent, historySize)
+	assert.Equal(t, taskIDs[len(taskIDs)-1], recent[0])
+	assert.Equal(t, taskIDs[10], recent[historySize-1])
+}
+
+func TestTaskIDs(t *testing.T) {
+	setUpHistory(t)
+	RecordTaskIDs("fN1T7OmPTXGVZHOCkrWcXQ", "XRq_py3rSGyuP8zGsM-7sQ", "XDLsjJZSTKW0Lo8UKqoEeg")
+
+	candidates, directive := TaskIDs(&cobra.Command{}, nil, "X")
+	assert.Equal(t, []string{"XDLsjJZSTKW0Lo8UKqoEeg", "XRq_py3rSGyuP8zGsM-7sQ"}, candidates)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveKeepOrder, directive)
+
+	// only the first argument is completed
+	candidates, _ = TaskIDs(&cobra.Command{}, []string{"fN1T7OmPTXGVZHOCkrWcXQ"}, "")
+	assert.Empty(t, candidates)
+}
+
+func TestTaskGroupIDs(t *testing.T) {
+	setUpHistory(t)
+	RecordTaskIDs("fN1T7OmPTXGVZHOCkrWcXQ")
+	RecordTaskGroupIDs("XRq_py3rSGyuP8zGsM-7sQ", "XDLsjJZSTKW0Lo8UKqoEeg")
+
+	// taskGroupIds are kept apart from taskIds
+	candidates, directive := TaskGroupIDs(&cobra.Command{}, nil, "")
+	assert.Equal(t, []string{"XDLsjJZSTKW0Lo8UKqoEeg", "XRq_py3rSGyuP8zGsM-7sQ"}, candidates)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveKeepOrder, directive)
+	assert.Equal(t, []string{"fN1T7OmPTXGVZHOCkrWcXQ"}, RecentTaskIDs())
+}
+
+func TestWorkerPoolIDs(t *testing.T) {
+	setUpServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("continuationToken") == "" {
+			fmt.Fprint(w, `{"workerPools": [{"workerPoolId": "proj-a/ci"}, {"workerPoolId": "proj-b/ci"}], "continuationToken": "next"}`)
+			return
+		}
+		fmt.Fprint(w, `{"workerPools": [{"workerPoolId": "proj-a/gpu"}]}`)
+	}))
+
+	candidates, directive := WorkerPoolIDs(&cobra.Command{}, nil, "proj-a/")
+	assert.Equal(t, []string{"proj-a/ci", "proj-a/gpu"}, candidates)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func TestCompletionWithoutRootURL(t *testing.T) {
+	config.SetRootURL("")
+	candidates, directive := SecretNames(&cobra.Command{}, nil, "")
+	assert.Empty(t, candidates)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func TestCompletionAPIError(t *testing.T) {
+	setUpServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"code": "InsufficientScopes", "message": "no"}`)
+	}))
+
+	candidates, directive := SecretNames(&cobra.Command{}, nil, "")
+	assert.Empty(t, candidates)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func TestIndexedTasks(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/index/v1/namespaces/project.app", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"namespaces": [{"namespace": "project.app.main", "name": "main"}, {"namespace": "project.app.release", "name": "release"}]}`)
+	})
+	handler.HandleFunc("/api/index/v1/tasks/project.app", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tasks": [{"namespace": "project.app.latest", "taskId": "fN1T7OmPTXGVZHOCkrWcXQ"}]}`)
+	})
+	setUpServer(t, handler)
+
+	candidates, directive := IndexNamespaces(&cobra.Command{}, nil, "project.app.")
+	assert.Equal(t, []string{"project.app.main", "project.app.release"}, candidates)
+	assert.Equal(t, cobra.ShellCompDirectiveNoSpace|cobra.ShellCompDirectiveNoFileComp, directive)
+
+	candidates, _ = IndexedTasks(&cobra.Command{}, nil, "project.app.")
+	assert.Equal(t, []string{"project.app.main", "project.app.release", "project.app.latest"}, candidates)
+
+	candidates, _ = IndexedTasks(&cobra.Command{}, nil, "project.app.l")
+	assert.Equal(t, []string{"project.app.latest"}, candidates)
+}
+
+func TestParentNamespace(t *testing.T) {
+	assert.Equal(t, "", parentNamespace(""))
+	assert.Equal(t, "", parentNamespace("proj"))
+	assert.Equal(t, "project", parentNamespace("project."))
+	assert.Equal(t, "project.app", parentNamespace("project.app.ma"))
+}
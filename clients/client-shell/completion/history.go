@@ -0,0 +1,134 @@
+package completion
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// historySize is the number of taskIds kept in each history.
+const historySize = 100
+
+// names of the files holding the histories of recently used taskIds and
+// taskGroupIds
+const (
+	taskHistory      = "task-history"
+	taskGroupHistory = "task-group-history"
+)
+
+// taskIDPattern matches the slugIds used as taskIds.
+var taskIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8}[Q-T][A-Za-z0-9_-][CGKOSWaeimquy26-][A-Za-z0-9_-]{10}[AQgw]$`)
+
+// historyFile is the location of the named history.
+func historyFile(name string) string {
+	cacheFolder := os.Getenv("XDG_CACHE_HOME")
+	if cacheFolder == "" {
+		homeFolder := os.Getenv("HOME")
+		if homeFolder == "" {
+			homeFolder, _ = homedir.Dir()
+		}
+		if homeFolder == "" {
+			return ""
+		}
+		cacheFolder = filepath.Join(homeFolder, ".cache")
+	}
+	return filepath.Join(cacheFolder, "taskcluster", name)
+}
+
+// RecordTaskIDs adds taskIds to the history of recently used taskIds, which
+// is used to complete taskIds.  Values that are not taskIds are ignored, as
+// are errors writing the history, which only serves completions.
+func RecordTaskIDs(taskIDs ...string) {
+	record(taskHistory, taskIDs)
+}
+
+// RecordTaskGroupIDs adds taskGroupIds to the history of recently used
+// taskGroupIds, as RecordTaskIDs does for taskIds.
+func RecordTaskGroupIDs(taskGroupIDs ...string) {
+	record(taskGroupHistory, taskGroupIDs)
+}
+
+// RecentTaskIDs returns the taskIds recorded by RecordTaskIDs, most recent
+// first.
+func RecentTaskIDs() []string {
+	return recent(taskHistory)
+}
+
+// RecentTaskGroupIDs returns the taskGroupIds recorded by RecordTaskGroupIDs,
+// most recent first.
+func RecentTaskGroupIDs() []string {
+	return recent(taskGroupHistory)
+}
+
+// record adds taskIds to the named history, most recent first.
+func record(name string, taskIDs []string) {
+	file := historyFile(name)
+	if file == "" {
+		return
+	}
+	history := []string{}
+	seen := map[string]bool{}
+	for i := len(taskIDs) - 1; i >= 0; i-- {
+		if taskIDPattern.MatchString(taskIDs[i]) && !seen[taskIDs[i]] {
+			seen[taskIDs[i]] = true
+			history = append(history, taskIDs[i])
+		}
+	}
+	if len(history) == 0 {
+		return
+	}
+	for _, taskID := range recent(name) {
+		if !seen[taskID] {
+			seen[taskID] = true
+			history = append(history, taskID)
+		}
+	}
+	if len(history) > historySize {
+		history = history[:historySize]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return
+	}
+	// write the history to a temporary file and rename it, so that
+	// concurrent commands never see a partial history
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+name+"-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.WriteString(strings.Join(history, "\n") + "\n")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// recent returns the taskIds in the named history, most recent first.
+func recent(name string) []string {
+	taskIDs := []string{}
+	file := historyFile(name)
+	if file == "" {
+		return taskIDs
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return taskIDs
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if taskID := strings.TrimSpace(scanner.Text()); taskIDPattern.MatchString(taskID) {
+			taskIDs = append(taskIDs, taskID)
+		}
+	}
+	return taskIDs
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/client"
 )
 
@@ -24,24 +25,42 @@ var (
 // Defer erroring out on a missing RootURL until we actually need one..
 func RootURL() string {
 	if rootURL == "" {
+		if ActiveProfile() != DefaultProfile {
+			fmt.Fprintf(os.Stderr, "No Root URL specified for profile %s; set TASKCLUSTER_ROOT_URL or config.rootUrl\n", ActiveProfile())
+			os.Exit(1)
+		}
 		fmt.Fprintln(os.Stderr, "No Root URL specified; set TASKCLUSTER_ROOT_URL")
 		os.Exit(1)
 	}
 	return rootURL
 }
 
+// HasRootURL reports whether a root URL is configured, for callers that must
+// not exit when there is none, such as shell completions.
+func HasRootURL() bool {
+	return rootURL != ""
+}
+
 // set the root URL -- this is used only for testing
 func SetRootURL(newRootURL string) {
 	rootURL = newRootURL
 }
 
-// Setup is to be called from main
+// Setup is to be called before running a command, with the name of the
+// profile to use, if one was given with --profile.
 // this was originally the init() function
 // but we want to make sure all other packages have been initialized
 // before calling them, which Load() does
-func Setup() {
+func Setup(profileName string) {
 	var err error
 
+	// select the profile
+	profile, err = selectProfile(profileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration file, error: %s\n", err)
+		os.Exit(1)
+	}
+
 	// load configuration
 	Configuration, err = Load()
 	if err != nil {
@@ -70,4 +89,15 @@ func Setup() {
 		fmt.Fprintln(os.Stderr, "Either ClientID or Access Token not set")
 		os.Exit(1)
 	}
+
+	// fall back to credentials saved by `taskcluster signin --cache`, if any
+	if rootURL != "" {
+		if creds, err := tcclient.NewCachedCredentialsProvider(rootURL).Credentials(); err == nil {
+			Credentials = &client.Credentials{
+				ClientID:    creds.ClientID,
+				AccessToken: creds.AccessToken,
+				Certificate: creds.Certificate,
+			}
+		}
+	}
 }
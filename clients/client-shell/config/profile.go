@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// DefaultProfile is the name of the profile whose options are at the top
+// level of the configuration file.
+const DefaultProfile = "default"
+
+// profile is the name of the active profile, chosen by Setup.
+var profile string
+
+// ActiveProfile returns the name of the profile whose options are in
+// Configuration.
+func ActiveProfile() string {
+	if profile == "" {
+		return DefaultProfile
+	}
+	return profile
+}
+
+// selectProfile returns the profile to use: the given one, such as the value
+// of --profile, or else the one named by TASKCLUSTER_PROFILE, or else the one
+// selected by `taskcluster config use`.
+func selectProfile(name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	if name = os.Getenv("TASKCLUSTER_PROFILE"); name != "" {
+		return name, nil
+	}
+	contents, err := readConfigFile()
+	if err != nil {
+		return "", err
+	}
+	if contents.Profile != "" {
+		return contents.Profile, nil
+	}
+	return DefaultProfile, nil
+}
+
+// Profiles returns the names of the profiles in the configuration file,
+// including the default profile, sorted.
+func Profiles() ([]string, error) {
+	contents, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{DefaultProfile}
+	for name := range contents.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// UseProfile makes the given profile the one used when no other is selected
+// with --profile or TASKCLUSTER_PROFILE.  The profile must exist, except for
+// the default profile.
+func UseProfile(name string) error {
+	contents, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		contents.Profile = ""
+	} else {
+		if _, ok := contents.Profiles[name]; !ok {
+			return fmt.Errorf("profile '%s' does not exist; create it with `taskcluster --profile %s config set config.rootUrl <rootUrl>`", name, name)
+		}
+		contents.Profile = name
+	}
+	return writeConfigFile(contents)
+}
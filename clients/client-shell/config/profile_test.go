@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setUpProfiles(t *testing.T, file string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("TASKCLUSTER_PROFILE", "")
+	if file != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "taskcluster.yml"), []byte(file), 0600))
+	}
+	RegisterOptions("test", map[string]OptionDefinition{
+		"rootUrl": {Default: ""},
+	})
+	t.Cleanup(func() {
+		delete(OptionsDefinitions, "test")
+		profile = ""
+	})
+}
+
+const profilesFile = `
+test:
+  rootUrl: https://tc.example.com
+profile: staging
+profiles:
+  staging:
+    test:
+      rootUrl: https://tc.staging.example.com
+`
+
+func TestSelectProfile(t *testing.T) {
+	setUpProfiles(t, profilesFile)
+
+	name, err := selectProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", name)
+
+	t.Setenv("TASKCLUSTER_PROFILE", "default")
+	name, err = selectProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfile, name)
+
+	name, err = selectProfile("production")
+	require.NoError(t, err)
+	assert.Equal(t, "production", name)
+}
+
+func TestLoadProfile(t *testing.T) {
+	setUpProfiles(t, profilesFile)
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "https://tc.example.com", config["test"]["rootUrl"])
+
+	profile = "staging"
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "https://tc.staging.example.com", config["test"]["rootUrl"])
+
+	profile = "production"
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "", config["test"]["rootUrl"])
+}
+
+func TestSaveProfileKeepsOthers(t *testing.T) {
+	setUpProfiles(t, profilesFile)
+
+	profile = "production"
+	require.NoError(t, Save(map[string]map[string]interface{}{
+		"test": {"rootUrl": "https://tc.production.example.com"},
+	}))
+
+	contents, err := readConfigFile()
+	require.NoError(t, err)
+	assert.Equal(t, "staging", contents.Profile)
+	assert.Equal(t, "https://tc.example.com", contents.options(DefaultProfile)["test"]["rootUrl"])
+	assert.Equal(t, "https://tc.staging.example.com", contents.options("staging")["test"]["rootUrl"])
+	assert.Equal(t, "https://tc.production.example.com", contents.options("production")["test"]["rootUrl"])
+
+	profiles, err := Profiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "production", "staging"}, profiles)
+}
+
+func TestUseProfile(t *testing.T) {
+	setUpProfiles(t, profilesFile)
+
+	assert.Error(t, UseProfile("production"))
+
+	require.NoError(t, UseProfile(DefaultProfile))
+	name, err := selectProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfile, name)
+
+	require.NoError(t, UseProfile("staging"))
+	name, err = selectProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", name)
+}
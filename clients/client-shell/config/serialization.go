@@ -26,25 +26,89 @@ func configFile() string {
 	return filepath.Join(configFolder, "taskcluster.yml")
 }
 
-// Load will load configuration file, and initialize a default configuration
-// if no configuration is present. This only returns an error if a configuration
-// file is present, but we are unable to parse it.
-func Load() (map[string]map[string]interface{}, error) {
-	config := make(map[string]map[string]interface{})
+// fileContents is the structure of the configuration file: the options of the
+// default profile at the top level, those of the named profiles under
+// profiles, and the profile selected by `taskcluster config use`.
+type fileContents struct {
+	Profile  string                                       `yaml:"profile,omitempty"`
+	Profiles map[string]map[string]map[string]interface{} `yaml:"profiles,omitempty"`
+	Default  map[string]map[string]interface{}            `yaml:",inline"`
+}
 
-	// Read config file and unmarshal into config overwriting default values
-	// if os.ReadFile returns an error, it means the config file couldn't
-	// be found and we just skip
+// readConfigFile reads the configuration file, returning empty contents if
+// there is no configuration file.
+func readConfigFile() (*fileContents, error) {
+	contents := &fileContents{}
 	configFile := configFile()
-	if data, err := os.ReadFile(configFile); err == nil {
-		if err = yaml.Unmarshal(data, &config); err != nil {
-			return nil, fmt.Errorf(
-				"read config file %s, but failed to parse YAML, error: %s",
-				configFile, err,
-			)
-		}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		// if os.ReadFile returns an error, it means the config file couldn't
+		// be found and we just skip
+		return contents, nil
+	}
+	if err = yaml.Unmarshal(data, contents); err != nil {
+		return nil, fmt.Errorf(
+			"read config file %s, but failed to parse YAML, error: %s",
+			configFile, err,
+		)
+	}
+	return contents, nil
+}
+
+// writeConfigFile writes the configuration file.
+func writeConfigFile(contents *fileContents) error {
+	data, err := yaml.Marshal(contents)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to serialize configFile, error: %s", err))
 	}
 
+	configFile := configFile()
+	// Attempt to create config folder if it doesn't exist... (ignore errors)
+	_ = os.MkdirAll(filepath.Dir(configFile), 0664)
+	if err = os.WriteFile(configFile, data, 0664); err != nil {
+		return fmt.Errorf("Failed to write config file: %s, error: %s", configFile, err)
+	}
+	return nil
+}
+
+// options returns the options of the given profile, which are empty if the
+// profile does not exist.
+func (contents *fileContents) options(profile string) map[string]map[string]interface{} {
+	var options map[string]map[string]interface{}
+	if profile == DefaultProfile {
+		options = contents.Default
+	} else {
+		options = contents.Profiles[profile]
+	}
+	if options == nil {
+		options = make(map[string]map[string]interface{})
+	}
+	return options
+}
+
+// setOptions replaces the options of the given profile.
+func (contents *fileContents) setOptions(profile string, options map[string]map[string]interface{}) {
+	if profile == DefaultProfile {
+		contents.Default = options
+		return
+	}
+	if contents.Profiles == nil {
+		contents.Profiles = make(map[string]map[string]map[string]interface{})
+	}
+	contents.Profiles[profile] = options
+}
+
+// Load will load the configuration of the active profile from the
+// configuration file, and initialize a default configuration if no
+// configuration is present. This only returns an error if a configuration
+// file is present, but we are unable to parse it.
+func Load() (map[string]map[string]interface{}, error) {
+	contents, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	config := contents.options(ActiveProfile())
+
 	// Populate missing config fields with default values
 	for command, options := range OptionsDefinitions {
 		if _, ok := config[command]; !ok {
@@ -125,7 +189,7 @@ func Load() (map[string]map[string]interface{}, error) {
 	return config, nil
 }
 
-// Save will save configuration.
+// Save will save configuration as the options of the active profile.
 func Save(config map[string]map[string]interface{}) error {
 	result := make(map[string]map[string]interface{})
 
@@ -159,19 +223,11 @@ func Save(config map[string]map[string]interface{}) error {
 		}
 	}
 
-	// Serialize the config data
-	data, err := yaml.Marshal(result)
+	// Replace the options of the active profile, keeping the other profiles
+	contents, err := readConfigFile()
 	if err != nil {
-		panic(fmt.Sprintf("Failed to serialize configFile, error: %s", err))
-	}
-
-	// Write config file
-	configFile := configFile()
-	// Attempt to create config folder if it doesn't exist... (ignore errors)
-	_ = os.MkdirAll(filepath.Dir(configFile), 0664)
-	if err = os.WriteFile(configFile, data, 0664); err != nil {
-		return fmt.Errorf("Failed to write config file: %s, error: %s", configFile, err)
+		return err
 	}
-
-	return nil
+	contents.setOptions(ActiveProfile(), result)
+	return writeConfigFile(contents)
 }
@@ -0,0 +1,74 @@
+// Package editor runs the user's editor, for commands that let the user edit
+// a document, such as a worker pool definition or a secret.
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Command returns the user's editor: the command given by $VISUAL or $EDITOR,
+// or else vi (notepad on Windows).  It may include arguments, such as
+// `code --wait`.
+func Command() string {
+	if editor := os.Getenv("VISUAL"); editor != "" {
+		return editor
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// Run opens filename in the user's editor, returning once the editor exits.
+func Run(filename string) error {
+	editor := Command()
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], filename)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %v", editor, err)
+	}
+	return nil
+}
+
+// EditTemp writes data to a new temporary file, named following pattern as
+// for os.CreateTemp, and opens it with run, which is usually Run.  It returns
+// the edited data and the name of the file, which the caller should remove
+// once the edits are no longer needed, or nil and "" if the data was not
+// changed, apart from whitespace at its start or end.
+func EditTemp(pattern string, data []byte, run func(filename string) error) ([]byte, string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = f.Write(data)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = run(f.Name())
+	}
+	var edited []byte
+	if err == nil {
+		edited, err = os.ReadFile(f.Name())
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return nil, "", err
+	}
+	if bytes.Equal(bytes.TrimSpace(edited), bytes.TrimSpace(data)) {
+		_ = os.Remove(f.Name())
+		return nil, "", nil
+	}
+	return edited, f.Name(), nil
+}
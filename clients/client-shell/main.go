@@ -1,18 +1,22 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/root"
-	"github.com/taskcluster/taskcluster/v60/clients/client-shell/config"
 )
 
 func main() {
-	// set up the whole config thing
-	config.Setup()
+	// the whole config thing is set up by the root command, once the
+	// --profile flag is parsed
 
 	// gentlemen, START YOUR ENGINES
 	if err := root.Command.Execute(); err != nil {
+		var exitErr *root.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	} else {
 		os.Exit(0)
@@ -0,0 +1,168 @@
+// Package output writes the results of commands in the format selected by
+// their --output flag: JSON, YAML, a table, or a Go template.  Commands keep
+// their usual, human-readable output when no format is selected.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"gopkg.in/yaml.v3"
+)
+
+// flagName is the name of the flag selecting the output format.
+const flagName = "output"
+
+// templatePrefix introduces a Go template given to --output.
+const templatePrefix = "go-template="
+
+// AddFlag adds the --output flag to a command.
+func AddFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(flagName, "o", "", "Output format: json, yaml, table or go-template=<template> (default: human-readable text).")
+}
+
+// Selected reports whether an output format was selected with --output.
+func Selected(flagSet *pflag.FlagSet) bool {
+	format, _ := flagSet.GetString(flagName)
+	return format != ""
+}
+
+// Table is the tabular form of a result, for --output table.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// AddRow adds a row to the table.
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Write writes value, which must be marshallable as JSON, in the format
+// selected by --output.  Templates are executed against the JSON form of
+// value, so fields are named as in the Taskcluster API, for example
+// `{{.status.state}}`.  table returns the tabular form of value; if it is
+// nil, --output table is not supported.
+func Write(out io.Writer, flagSet *pflag.FlagSet, value interface{}, table func() *Table) error {
+	format, _ := flagSet.GetString(flagName)
+	switch {
+	case format == "json":
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+
+	case format == "yaml":
+		return writeYAML(out, value)
+
+	case format == "table":
+		if table == nil {
+			return errors.New("--output table is not supported by this command; use json, yaml or go-template=<template>")
+		}
+		return writeTable(out, table())
+
+	case strings.HasPrefix(format, templatePrefix):
+		return writeTemplate(out, strings.TrimPrefix(format, templatePrefix), value)
+
+	default:
+		return fmt.Errorf("unknown output format %q; expected json, yaml, table or go-template=<template>", format)
+	}
+}
+
+// writeYAML writes the JSON form of value as YAML.  Going through JSON keeps
+// the field names and order used by the Taskcluster API.
+func writeYAML(out io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML, so parse it as YAML to keep the order of the keys,
+	// and then drop the JSON (flow) style
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetStyle sets the style of a node and its children to the default block
+// style, leaving the encoder to quote strings where necessary.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// writeTable writes a table with aligned columns.
+func writeTable(out io.Writer, table *Table) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(table.Header, "\t"))
+	for _, row := range table.Rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// writeTemplate executes a Go template against the JSON form of value,
+// followed by a newline unless the template ends with one.
+func writeTemplate(out io.Writer, text string, value interface{}) error {
+	tmpl, err := template.New(flagName).Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid --output template: %v", err)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep integers such as run IDs from being shown as floats
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, generic); err != nil {
+		return fmt.Errorf("could not execute the --output template: %v", err)
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+// Time formats a time for a table cell, leaving the cell empty for the zero
+// time, such as the resolution time of an unresolved run.
+func Time(t tcclient.Time) string {
+	if time.Time(t).IsZero() {
+		return ""
+	}
+	return t.String()
+}
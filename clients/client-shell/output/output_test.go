@@ -0,0 +1,97 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type result struct {
+	TaskID string   `json:"taskId"`
+	RunID  int      `json:"runId"`
+	State  string   `json:"state"`
+	Tags   []string `json:"tags"`
+}
+
+var results = []result{
+	{TaskID: "abc", RunID: 0, State: "completed", Tags: []string{"true", "x"}},
+	{TaskID: "defghi", RunID: 12, State: "failed"},
+}
+
+func resultsTable() *Table {
+	table := &Table{Header: []string{"TASK ID", "STATE"}}
+	for _, r := range results {
+		table.AddRow(r.TaskID, r.State)
+	}
+	return table
+}
+
+func write(t *testing.T, format string, table func() *Table) (string, error) {
+	t.Helper()
+	cmd := &cobra.Command{}
+	AddFlag(cmd)
+	require.NoError(t, cmd.Flags().Set("output", format))
+	var buf bytes.Buffer
+	err := Write(&buf, cmd.Flags(), results, table)
+	return buf.String(), err
+}
+
+func TestSelected(t *testing.T) {
+	cmd := &cobra.Command{}
+	AddFlag(cmd)
+	assert.False(t, Selected(cmd.Flags()))
+	require.NoError(t, cmd.Flags().Set("output", "json"))
+	assert.True(t, Selected(cmd.Flags()))
+}
+
+func TestJSON(t *testing.T) {
+	out, err := write(t, "json", resultsTable)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"taskId": "abc", "runId": 0, "state": "completed", "tags": ["true", "x"]}, {"taskId": "defghi", "runId": 12, "state": "failed", "tags": null}]`, out)
+}
+
+func TestYAML(t *testing.T) {
+	out, err := write(t, "yaml", resultsTable)
+	require.NoError(t, err)
+	assert.Equal(t, `- taskId: abc
+  runId: 0
+  state: completed
+  tags:
+    - "true"
+    - x
+- taskId: defghi
+  runId: 12
+  state: failed
+  tags: null
+`, out)
+}
+
+func TestTable(t *testing.T) {
+	out, err := write(t, "table", resultsTable)
+	require.NoError(t, err)
+	assert.Equal(t, "TASK ID  STATE\nabc      completed\ndefghi   failed\n", out)
+
+	_, err = write(t, "table", nil)
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestTemplate(t *testing.T) {
+	out, err := write(t, "go-template={{range .}}{{.taskId}}/{{.runId}} {{json .tags}}\n{{end}}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "abc/0 [\"true\",\"x\"]\ndefghi/12 null\n", out)
+
+	out, err = write(t, "go-template={{len .}}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2\n", out)
+
+	_, err = write(t, "go-template={{", nil)
+	assert.ErrorContains(t, err, "invalid --output template")
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := write(t, "xml", resultsTable)
+	assert.ErrorContains(t, err, `unknown output format "xml"`)
+}
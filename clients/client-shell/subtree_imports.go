@@ -5,15 +5,24 @@ package main
 
 import (
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/apis"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/artifacts"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/completions"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/config"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/d2g"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/download"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/from-now"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/group"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/index"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/object"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/purge-cache"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/roles"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/scopes"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/secrets"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/signin"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/slugid"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/task"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/tc-yml"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/validate-json"
 	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/version"
+	_ "github.com/taskcluster/taskcluster/v60/clients/client-shell/cmds/worker-pool"
 )
@@ -0,0 +1,317 @@
+package jsone
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// builtin is a function available in every context.  Unlike a Function, it
+// has access to the context in which it is called.
+type builtin struct {
+	name string
+	fn   func(context map[string]interface{}, args []interface{}) (interface{}, error)
+}
+
+func (b *builtin) call(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	return b.fn(context, args)
+}
+
+func invalidArguments(name string) error {
+	return interpreterError("invalid arguments to builtin: %s", name)
+}
+
+// numbers returns the arguments as numbers, if they all are
+func numbers(args []interface{}) ([]float64, bool) {
+	result := make([]float64, len(args))
+	for i, arg := range args {
+		f, ok := arg.(float64)
+		if !ok {
+			return nil, false
+		}
+		result[i] = f
+	}
+	return result, true
+}
+
+// mathBuiltin is a builtin taking one number
+func mathBuiltin(name string, fn func(float64) float64) *builtin {
+	return &builtin{name: name, fn: func(context map[string]interface{}, args []interface{}) (interface{}, error) {
+		n, ok := numbers(args)
+		if !ok || len(n) != 1 {
+			return nil, invalidArguments(name)
+		}
+		return fn(n[0]), nil
+	}}
+}
+
+// stringBuiltin is a builtin taking one string
+func stringBuiltin(name string, fn func(string) interface{}) *builtin {
+	return &builtin{name: name, fn: func(context map[string]interface{}, args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, invalidArguments(name)
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, invalidArguments(name)
+		}
+		return fn(s), nil
+	}}
+}
+
+// extremum is min or max
+func extremum(name string, better func(a, b float64) bool) *builtin {
+	return &builtin{name: name, fn: func(context map[string]interface{}, args []interface{}) (interface{}, error) {
+		n, ok := numbers(args)
+		if !ok || len(n) == 0 {
+			return nil, invalidArguments(name)
+		}
+		result := n[0]
+		for _, f := range n[1:] {
+			if better(f, result) {
+				result = f
+			}
+		}
+		return result, nil
+	}}
+}
+
+var builtins map[string]*builtin
+
+func init() {
+	builtins = map[string]*builtin{}
+	for _, b := range []*builtin{
+		extremum("min", func(a, b float64) bool { return a < b }),
+		extremum("max", func(a, b float64) bool { return a > b }),
+		mathBuiltin("sqrt", math.Sqrt),
+		mathBuiltin("ceil", math.Ceil),
+		mathBuiltin("floor", math.Floor),
+		mathBuiltin("abs", math.Abs),
+		stringBuiltin("lowercase", func(s string) interface{} { return strings.ToLower(s) }),
+		stringBuiltin("uppercase", func(s string) interface{} { return strings.ToUpper(s) }),
+		stringBuiltin("strip", func(s string) interface{} { return strings.TrimSpace(s) }),
+		stringBuiltin("lstrip", func(s string) interface{} { return strings.TrimLeftFunc(s, unicode.IsSpace) }),
+		stringBuiltin("rstrip", func(s string) interface{} { return strings.TrimRightFunc(s, unicode.IsSpace) }),
+		{name: "len", fn: lenBuiltin},
+		{name: "str", fn: strBuiltin},
+		{name: "number", fn: numberBuiltin},
+		{name: "split", fn: splitBuiltin},
+		{name: "join", fn: joinBuiltin},
+		{name: "range", fn: rangeBuiltin},
+		{name: "fromNow", fn: fromNowBuiltin},
+		{name: "typeof", fn: typeofBuiltin},
+		{name: "defined", fn: definedBuiltin},
+	} {
+		builtins[b.name] = b
+	}
+}
+
+func lenBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) == 1 {
+		switch v := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		}
+	}
+	return nil, invalidArguments("len")
+}
+
+func strBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) == 1 {
+		switch v := args[0].(type) {
+		case nil:
+			return "null", nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		case float64:
+			return formatNumber(v), nil
+		case string:
+			return v, nil
+		}
+	}
+	return nil, invalidArguments("str")
+}
+
+func numberBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, invalidArguments("number")
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, invalidArguments("number")
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, interpreterError("string can't be converted to number")
+	}
+	return f, nil
+}
+
+func splitBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, invalidArguments("split")
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, invalidArguments("split")
+	}
+	var sep string
+	switch v := args[1].(type) {
+	case string:
+		sep = v
+	case float64:
+		sep = formatNumber(v)
+	default:
+		return nil, invalidArguments("split")
+	}
+	parts := strings.Split(s, sep)
+	if s == "" && sep == "" {
+		parts = []string{}
+	}
+	result := make([]interface{}, len(parts))
+	for i, part := range parts {
+		result[i] = part
+	}
+	return result, nil
+}
+
+func joinBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, invalidArguments("join")
+	}
+	list, ok := args[0].([]interface{})
+	if !ok {
+		return nil, invalidArguments("join")
+	}
+	var sep string
+	switch v := args[1].(type) {
+	case string:
+		sep = v
+	case float64:
+		sep = formatNumber(v)
+	default:
+		return nil, invalidArguments("join")
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		switch v := item.(type) {
+		case string:
+			parts[i] = v
+		case float64:
+			parts[i] = formatNumber(v)
+		default:
+			return nil, invalidArguments("join")
+		}
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func rangeBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, invalidArguments("range")
+	}
+	var bounds []int
+	for _, arg := range args {
+		i, ok := integer(arg)
+		if !ok {
+			return nil, invalidArguments("range")
+		}
+		bounds = append(bounds, i)
+	}
+	start, end, step := bounds[0], bounds[1], 1
+	if len(bounds) == 3 {
+		step = bounds[2]
+	}
+	if step == 0 {
+		return nil, invalidArguments("range")
+	}
+	result := []interface{}{}
+	for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+		result = append(result, float64(i))
+	}
+	return result, nil
+}
+
+func fromNowBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, invalidArguments("fromNow")
+	}
+	offset, ok := args[0].(string)
+	if !ok {
+		return nil, invalidArguments("fromNow")
+	}
+	reference := context["now"]
+	if len(args) == 2 {
+		reference = args[1]
+	}
+	ref, ok := reference.(string)
+	if !ok {
+		return nil, invalidArguments("fromNow")
+	}
+	return fromNow(offset, ref)
+}
+
+func typeofBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, invalidArguments("typeof")
+	}
+	return typeOf(args[0]), nil
+}
+
+func definedBuiltin(context map[string]interface{}, args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, invalidArguments("defined")
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, invalidArguments("defined")
+	}
+	_, found := context[name]
+	return found, nil
+}
+
+var fromNowPattern = regexp.MustCompile(`^(\s*(-|\+))?` +
+	`(\s*(\d+)\s*(years|year|yr|y))?` +
+	`(\s*(\d+)\s*(months|month|mo))?` +
+	`(\s*(\d+)\s*(weeks|week|wk|w))?` +
+	`(\s*(\d+)\s*(days|day|d))?` +
+	`(\s*(\d+)\s*(hours|hour|h))?` +
+	`(\s*(\d+)\s*(minutes|minute|min|m))?` +
+	`(\s*(\d+)\s*(seconds|second|sec|s))?\s*$`)
+
+// formatTime formats a time as JavaScript's Date.toJSON does
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// fromNow returns the time offset from the reference time, such as "2 days"
+// or "-1 hour"
+func fromNow(offset string, reference string) (interface{}, error) {
+	ref, err := time.Parse(time.RFC3339Nano, reference)
+	if err != nil {
+		return nil, interpreterError("invalid reference time %q", reference)
+	}
+	match := fromNowPattern.FindStringSubmatch(offset)
+	if match == nil {
+		return nil, interpreterError("String: '%s' isn't a time expression", offset)
+	}
+	sign := 1
+	if match[2] == "-" {
+		sign = -1
+	}
+	value := func(i int) int {
+		n, _ := strconv.Atoi(match[i])
+		return sign * n
+	}
+	t := ref.UTC().AddDate(value(4), value(7), 7*value(10)+value(13))
+	t = t.Add(time.Duration(value(16))*time.Hour +
+		time.Duration(value(19))*time.Minute +
+		time.Duration(value(22))*time.Second)
+	return formatTime(t), nil
+}
@@ -0,0 +1,809 @@
+package jsone
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The expression language: tokens
+
+type token struct {
+	kind  string
+	value string
+	start int
+	end   int
+}
+
+// punctuation, longest first so that for example `**` is not read as `*`
+var punctuation = []string{
+	"**", ">=", "<=", "==", "!=", "&&", "||",
+	"+", "-", "*", "/", "[", "]", ".", "(", ")", "{", "}", ":", ",", "<", ">", "!",
+}
+
+var keywords = map[string]bool{"true": true, "false": true, "null": true, "in": true}
+
+// tokenizer reads tokens from source, starting at offset
+type tokenizer struct {
+	source string
+	offset int
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// next returns the next token, or a token of kind "eof"
+func (t *tokenizer) next() (token, error) {
+	for t.offset < len(t.source) && strings.ContainsRune(" \t\r\n", rune(t.source[t.offset])) {
+		t.offset++
+	}
+	start := t.offset
+	if start >= len(t.source) {
+		return token{kind: "eof", start: start, end: start}, nil
+	}
+	c := t.source[start]
+	end := start
+	kind := ""
+	switch {
+	case isDigit(c):
+		for end < len(t.source) && isDigit(t.source[end]) {
+			end++
+		}
+		if end+1 < len(t.source) && t.source[end] == '.' && isDigit(t.source[end+1]) {
+			end++
+			for end < len(t.source) && isDigit(t.source[end]) {
+				end++
+			}
+		}
+		kind = "number"
+	case isIdentifierStart(c):
+		for end < len(t.source) && (isIdentifierStart(t.source[end]) || isDigit(t.source[end])) {
+			end++
+		}
+		kind = "identifier"
+		if keywords[t.source[start:end]] {
+			kind = t.source[start:end]
+		}
+	case c == '"' || c == '\'':
+		closing := strings.IndexByte(t.source[start+1:], c)
+		if closing == -1 {
+			return token{}, &SyntaxError{Message: fmt.Sprintf("unterminated string at position %d", start)}
+		}
+		end = start + closing + 2
+		kind = "string"
+	default:
+		for _, p := range punctuation {
+			if strings.HasPrefix(t.source[start:], p) {
+				end = start + len(p)
+				kind = p
+				break
+			}
+		}
+		if kind == "" {
+			return token{}, &SyntaxError{Message: fmt.Sprintf("unexpected input %q at position %d", t.source[start:start+1], start)}
+		}
+	}
+	t.offset = end
+	return token{kind: kind, value: t.source[start:end], start: start, end: end}, nil
+}
+
+// The expression language: syntax tree
+
+type node interface {
+	eval(context map[string]interface{}) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+type contextValue struct {
+	name string
+}
+
+type unaryOp struct {
+	op      string
+	operand node
+}
+
+type binaryOp struct {
+	op    string
+	left  node
+	right node
+}
+
+type dotAccess struct {
+	left node
+	key  string
+}
+
+type indexAccess struct {
+	left  node
+	index node
+}
+
+type sliceAccess struct {
+	left  node
+	start node
+	end   node
+}
+
+type functionCall struct {
+	callee node
+	args   []node
+}
+
+type listNode struct {
+	items []node
+}
+
+type objectNode struct {
+	keys   []string
+	values []node
+}
+
+// The expression language: parser
+
+type parser struct {
+	tokenizer *tokenizer
+	current   token
+}
+
+func newParser(source string, offset int) (*parser, error) {
+	p := &parser{tokenizer: &tokenizer{source: source, offset: offset}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parser) advance() (err error) {
+	p.current, err = p.tokenizer.next()
+	return
+}
+
+func (p *parser) unexpected(expected ...string) error {
+	found := p.current.value
+	if p.current.kind == "eof" {
+		found = "end of input"
+	}
+	return &SyntaxError{Message: fmt.Sprintf("Found: %s token, expected one of: %s", found, strings.Join(expected, ", "))}
+}
+
+// expect consumes a token of the given kind
+func (p *parser) expect(kind string) (token, error) {
+	if p.current.kind != kind {
+		return token{}, p.unexpected(kind)
+	}
+	t := p.current
+	return t, p.advance()
+}
+
+// parseBinary parses left-associative binary operators at one level of
+// precedence
+func (p *parser) parseBinary(next func() (node, error), ops ...string) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.current.kind
+		found := false
+		for _, o := range ops {
+			if op == o {
+				found = true
+			}
+		}
+		if !found {
+			return left, nil
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryOp{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseExpression() (node, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseBinary(p.parseIn, "&&")
+}
+
+func (p *parser) parseIn() (node, error) {
+	return p.parseBinary(p.parseEquality, "in")
+}
+
+func (p *parser) parseEquality() (node, error) {
+	return p.parseBinary(p.parseComparison, "==", "!=")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	return p.parseBinary(p.parseAdditive, "<", ">", "<=", ">=")
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch op := p.current.kind; op {
+	case "!", "-", "+":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryOp{op: op, operand: operand}, nil
+	}
+	return p.parsePower()
+}
+
+// parsePower parses the right-associative ** operator
+func (p *parser) parsePower() (node, error) {
+	base, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.current.kind != "**" {
+		return base, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &binaryOp{op: "**", left: base, right: exponent}, nil
+}
+
+// parsePostfix parses property access, indexing, slicing and function calls
+func (p *parser) parsePostfix() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.current.kind {
+		case ".":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			key, err := p.expect("identifier")
+			if err != nil {
+				return nil, err
+			}
+			left = &dotAccess{left: left, key: key.value}
+		case "[":
+			if left, err = p.parseIndex(left); err != nil {
+				return nil, err
+			}
+		case "(":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			left = &functionCall{callee: left, args: args}
+		default:
+			return left, nil
+		}
+	}
+}
+
+// parseIndex parses `[index]` or `[start:end]`, either of start and end
+// being optional
+func (p *parser) parseIndex(left node) (node, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var start, end node
+	var err error
+	if p.current.kind != ":" {
+		if start, err = p.parseExpression(); err != nil {
+			return nil, err
+		}
+		if p.current.kind == "]" {
+			return &indexAccess{left: left, index: start}, p.advance()
+		}
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if p.current.kind != "]" {
+		if end, err = p.parseExpression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return &sliceAccess{left: left, start: start, end: end}, nil
+}
+
+// parseList parses comma-separated expressions up to the closing token,
+// which has already been opened
+func (p *parser) parseList(closing string) ([]node, error) {
+	items := []node{}
+	for p.current.kind != closing {
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.current.kind != "," {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect(closing); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.current
+	switch t.kind {
+	case "number":
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, &SyntaxError{Message: fmt.Sprintf("invalid number %s", t.value)}
+		}
+		return &literal{value: value}, p.advance()
+	case "string":
+		return &literal{value: t.value[1 : len(t.value)-1]}, p.advance()
+	case "true", "false":
+		return &literal{value: t.kind == "true"}, p.advance()
+	case "null":
+		return &literal{value: nil}, p.advance()
+	case "identifier":
+		return &contextValue{name: t.value}, p.advance()
+	case "(":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect(")")
+		return expr, err
+	case "[":
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		items, err := p.parseList("]")
+		if err != nil {
+			return nil, err
+		}
+		return &listNode{items: items}, nil
+	case "{":
+		return p.parseObject()
+	}
+	return nil, p.unexpected("!", "-", "+", "(", "[", "{", "number", "string", "identifier", "true", "false", "null")
+}
+
+func (p *parser) parseObject() (node, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	object := &objectNode{}
+	for p.current.kind != "}" {
+		var key string
+		switch p.current.kind {
+		case "string":
+			key = p.current.value[1 : len(p.current.value)-1]
+		case "identifier":
+			key = p.current.value
+		default:
+			return nil, p.unexpected("string", "identifier")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		object.keys = append(object.keys, key)
+		object.values = append(object.values, value)
+		if p.current.kind != "," {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// evaluate evaluates a complete expression
+func evaluate(expression string, context map[string]interface{}) (interface{}, error) {
+	p, err := newParser(expression, 0)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.current.kind != "eof" {
+		return nil, p.unexpected("end of input")
+	}
+	return tree.eval(context)
+}
+
+// parseUntilTerminator evaluates the expression in source starting at
+// offset, which must be followed by the terminator.  It returns the value
+// and the offset of the terminator.
+func parseUntilTerminator(source string, offset int, terminator string, context map[string]interface{}) (interface{}, int, error) {
+	p, err := newParser(source, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	tree, err := p.parseExpression()
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.current.kind != terminator {
+		return nil, 0, p.unexpected(terminator)
+	}
+	value, err := tree.eval(context)
+	return value, p.current.start, err
+}
+
+// The expression language: evaluation
+
+func (n *literal) eval(context map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *contextValue) eval(context map[string]interface{}) (interface{}, error) {
+	value, ok := context[n.name]
+	if !ok {
+		return nil, interpreterError("unknown context value %s", n.name)
+	}
+	return value, nil
+}
+
+func (n *unaryOp) eval(context map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !isTruthy(operand), nil
+	}
+	number, ok := operand.(float64)
+	if !ok {
+		return nil, interpreterError("%s expects number", n.op)
+	}
+	if n.op == "-" {
+		return -number, nil
+	}
+	return number, nil
+}
+
+func (n *binaryOp) eval(context map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	// the logical operators short-circuit
+	switch n.op {
+	case "||":
+		if isTruthy(left) {
+			return true, nil
+		}
+	case "&&":
+		if !isTruthy(left) {
+			return false, nil
+		}
+	}
+	right, err := n.right.eval(context)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "||", "&&":
+		return isTruthy(right), nil
+	case "==":
+		return isEqual(left, right), nil
+	case "!=":
+		return !isEqual(left, right), nil
+	case "in":
+		return evalIn(left, right)
+	case "+":
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		}
+		if l, ok := left.(float64); ok {
+			if r, ok := right.(float64); ok {
+				return l + r, nil
+			}
+		}
+		return nil, interpreterError("infix: + expects number/string + number/string")
+	case "<", ">", "<=", ">=":
+		return compare(n.op, left, right)
+	}
+
+	// the remaining operators are arithmetic
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, interpreterError("infix: %s expects number %s number", n.op, n.op)
+	}
+	switch n.op {
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, interpreterError("division by zero")
+		}
+		return l / r, nil
+	case "**":
+		return math.Pow(l, r), nil
+	}
+	return nil, interpreterError("unknown operator %s", n.op)
+}
+
+func compare(op string, left, right interface{}) (interface{}, error) {
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, interpreterError("infix: %s expects numbers/strings %s numbers/strings", op, op)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, interpreterError("infix: %s expects numbers/strings %s numbers/strings", op, op)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, interpreterError("infix: %s expects numbers/strings %s numbers/strings", op, op)
+	}
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+func evalIn(left, right interface{}) (interface{}, error) {
+	switch r := right.(type) {
+	case map[string]interface{}:
+		key, ok := left.(string)
+		if !ok {
+			return nil, interpreterError("infix: in-object expects string as left operand")
+		}
+		_, found := r[key]
+		return found, nil
+	case []interface{}:
+		for _, item := range r {
+			if isEqual(left, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := left.(string)
+		if !ok {
+			return nil, interpreterError("infix: in-string expects string as left operand")
+		}
+		return strings.Contains(r, s), nil
+	}
+	return nil, interpreterError("infix: in expects string/array/object as right operand")
+}
+
+func (n *dotAccess) eval(context map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := left.(map[string]interface{})
+	if !ok {
+		return nil, interpreterError("infix: . expects objects")
+	}
+	value, ok := object[n.key]
+	if !ok {
+		return nil, interpreterError("object has no property %q", n.key)
+	}
+	return value, nil
+}
+
+// integer returns the value as an int, if it is an integer
+func integer(value interface{}) (int, bool) {
+	f, ok := value.(float64)
+	if !ok || f != math.Trunc(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+func (n *indexAccess) eval(context map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	switch l := left.(type) {
+	case []interface{}, string:
+		i, ok := integer(index)
+		if !ok {
+			return nil, interpreterError("should only use integers to access arrays or strings")
+		}
+		if list, ok := l.([]interface{}); ok {
+			if i < 0 {
+				i += len(list)
+			}
+			if i < 0 || i >= len(list) {
+				return nil, interpreterError("index out of bounds")
+			}
+			return list[i], nil
+		}
+		runes := []rune(l.(string))
+		if i < 0 {
+			i += len(runes)
+		}
+		if i < 0 || i >= len(runes) {
+			return nil, interpreterError("index out of bounds")
+		}
+		return string(runes[i]), nil
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, interpreterError("object keys must be strings")
+		}
+		// unlike `.`, a missing property is null
+		return l[key], nil
+	}
+	return nil, interpreterError("infix: \"[..]\" expects object, array, or string")
+}
+
+func (n *sliceAccess) eval(context map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	var length int
+	switch l := left.(type) {
+	case []interface{}:
+		length = len(l)
+	case string:
+		length = len([]rune(l))
+	default:
+		return nil, interpreterError("infix: \"[..]\" expects object, array, or string")
+	}
+	// bounds are interpreted as in Python: negative values count from the
+	// end, and out-of-range values are clamped
+	bound := func(expr node, otherwise int) (int, error) {
+		if expr == nil {
+			return otherwise, nil
+		}
+		value, err := expr.eval(context)
+		if err != nil {
+			return 0, err
+		}
+		i, ok := integer(value)
+		if !ok {
+			return 0, interpreterError("cannot perform interval access with non-integers")
+		}
+		if i < 0 {
+			i += length
+		}
+		return min(max(i, 0), length), nil
+	}
+	start, err := bound(n.start, 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := bound(n.end, length)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		end = start
+	}
+	if l, ok := left.([]interface{}); ok {
+		return append([]interface{}{}, l[start:end]...), nil
+	}
+	return string([]rune(left.(string))[start:end]), nil
+}
+
+func (n *functionCall) eval(context map[string]interface{}) (interface{}, error) {
+	callee, err := n.callee.eval(context)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		if args[i], err = arg.eval(context); err != nil {
+			return nil, err
+		}
+	}
+	switch fn := callee.(type) {
+	case *builtin:
+		return fn.call(context, args)
+	case Function:
+		result, err := fn(args...)
+		if err != nil {
+			return nil, err
+		}
+		return normalize(result)
+	}
+	return nil, interpreterError("function call requires a function, not %s", typeOf(callee))
+}
+
+func (n *listNode) eval(context map[string]interface{}) (interface{}, error) {
+	result := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(context)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+func (n *objectNode) eval(context map[string]interface{}) (interface{}, error) {
+	result := make(map[string]interface{}, len(n.keys))
+	for i, key := range n.keys {
+		value, err := n.values[i].eval(context)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
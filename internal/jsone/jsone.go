@@ -0,0 +1,418 @@
+// Package jsone implements JSON-e, the data-structure parameterization system
+// used by the Taskcluster services to render `.taskcluster.yml` files, hook
+// task templates and other templates.  It follows the semantics of the
+// JavaScript implementation used by the services, so that Go tools can render
+// templates identically.
+//
+// See https://json-e.js.org for the language reference.
+//
+// Templates and contexts are JSON-like values, as produced by
+// encoding/json or a YAML parser: nil, bool, numbers, string,
+// []interface{} and map[string]interface{}.  Context values may also be
+// functions of type Function.
+//
+//	result, err := jsone.Render(template, map[string]interface{}{
+//		"tasks_for": "github-push",
+//		"event":     event,
+//	})
+package jsone
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Function is a function that can be given in the context of a template and
+// called from expressions
+type Function func(args ...interface{}) (interface{}, error)
+
+// TemplateError is an error in the structure of a template, such as an
+// operator with invalid properties
+type TemplateError struct {
+	Message string
+}
+
+func (err *TemplateError) Error() string {
+	return "TemplateError: " + err.Message
+}
+
+// InterpreterError is an error evaluating an expression, such as an unknown
+// context value or an operand of the wrong type
+type InterpreterError struct {
+	Message string
+}
+
+func (err *InterpreterError) Error() string {
+	return "InterpreterError: " + err.Message
+}
+
+// SyntaxError is an error parsing an expression
+type SyntaxError struct {
+	Message string
+}
+
+func (err *SyntaxError) Error() string {
+	return "SyntaxError: " + err.Message
+}
+
+func templateError(format string, a ...interface{}) error {
+	return &TemplateError{Message: fmt.Sprintf(format, a...)}
+}
+
+func interpreterError(format string, a ...interface{}) error {
+	return &InterpreterError{Message: fmt.Sprintf(format, a...)}
+}
+
+// deleteMarker is returned by operators that produce no value, such as $if
+// without a matching branch; it is removed from the enclosing array or object
+type deleteMarkerType struct{}
+
+var deleteMarker = &deleteMarkerType{}
+
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Render renders template with the given context.  The context may not be
+// nil.  In addition to the builtin functions, the context value `now` is the
+// current time, unless the context gives another value.
+func Render(template interface{}, context map[string]interface{}) (interface{}, error) {
+	return RenderAt(template, context, time.Now())
+}
+
+// RenderAt renders template as Render does, with `now` defaulting to the
+// given time, for reproducible results.
+func RenderAt(template interface{}, context map[string]interface{}, now time.Time) (interface{}, error) {
+	if context == nil {
+		return nil, templateError("context must be an object")
+	}
+	full := map[string]interface{}{}
+	for name, fn := range builtins {
+		full[name] = fn
+	}
+	full["now"] = formatTime(now)
+	for key, value := range context {
+		if !identifierPattern.MatchString(key) {
+			return nil, templateError("top level keys of context must follow /[a-zA-Z_][a-zA-Z0-9_]*/")
+		}
+		v, err := normalize(value)
+		if err != nil {
+			return nil, err
+		}
+		full[key] = v
+	}
+	t, err := normalize(template)
+	if err != nil {
+		return nil, err
+	}
+	result, err := render(t, full)
+	if err != nil {
+		return nil, err
+	}
+	if result == deleteMarker {
+		return nil, nil
+	}
+	if containsFunctions(result) {
+		return nil, templateError("evaluated template contained uncalled functions")
+	}
+	return result, nil
+}
+
+// normalize converts a Go value to the representation used in rendering,
+// with all numbers as float64
+func normalize(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, string, float64, Function, *builtin:
+		return v, nil
+	case func(args ...interface{}) (interface{}, error):
+		return Function(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, templateError("invalid number %s", v)
+		}
+		return f, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = n
+		}
+		return result, nil
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = n
+		}
+		return result, nil
+	case map[interface{}]interface{}:
+		// as produced by gopkg.in/yaml.v2
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			k, ok := key.(string)
+			if !ok {
+				return nil, templateError("object keys must be strings, not %v", key)
+			}
+			n, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = n
+		}
+		return result, nil
+	}
+	// other types, such as structs, are converted via JSON
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, templateError("cannot convert %T to JSON: %v", value, err)
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, templateError("cannot convert %T to JSON: %v", value, err)
+	}
+	return result, nil
+}
+
+func containsFunctions(value interface{}) bool {
+	switch v := value.(type) {
+	case Function, *builtin:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if containsFunctions(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsFunctions(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var reservedKeyPattern = regexp.MustCompile(`^\$[a-zA-Z][a-zA-Z0-9]*$`)
+
+func render(template interface{}, context map[string]interface{}) (interface{}, error) {
+	switch t := template.(type) {
+	case string:
+		return interpolate(t, context)
+	case []interface{}:
+		result := []interface{}{}
+		for _, item := range t {
+			value, err := render(item, context)
+			if err != nil {
+				return nil, err
+			}
+			if value != deleteMarker {
+				result = append(result, value)
+			}
+		}
+		return result, nil
+	case map[string]interface{}:
+		var matches []string
+		for key := range t {
+			if _, ok := operators[key]; ok {
+				matches = append(matches, key)
+			}
+		}
+		if len(matches) > 1 {
+			sort.Strings(matches)
+			return nil, templateError("only one operator allowed, found %s", strings.Join(matches, ", "))
+		}
+		if len(matches) == 1 {
+			return operators[matches[0]](t, context)
+		}
+		result := map[string]interface{}{}
+		for _, key := range sortedKeys(t) {
+			value, err := render(t[key], context)
+			if err != nil {
+				return nil, err
+			}
+			if value == deleteMarker {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(key, "$$"):
+				key = key[1:]
+			case reservedKeyPattern.MatchString(key):
+				return nil, templateError("%s is reserved; use $%s", key, key)
+			default:
+				key, err = interpolate(key, context)
+				if err != nil {
+					return nil, err
+				}
+			}
+			result[key] = value
+		}
+		return result, nil
+	}
+	return template, nil
+}
+
+// interpolate replaces each ${..} in s with the value of the expression it
+// contains; $${ is replaced with ${
+func interpolate(s string, context map[string]interface{}) (string, error) {
+	var result strings.Builder
+	remaining := s
+	for {
+		offset := strings.Index(remaining, "${")
+		if offset == -1 {
+			break
+		}
+		if offset > 0 && remaining[offset-1] == '$' {
+			result.WriteString(remaining[:offset-1])
+			result.WriteString("${")
+			remaining = remaining[offset+2:]
+			continue
+		}
+		result.WriteString(remaining[:offset])
+		value, end, err := parseUntilTerminator(remaining, offset+2, "}", context)
+		if err != nil {
+			return "", err
+		}
+		switch v := value.(type) {
+		case []interface{}, map[string]interface{}:
+			return "", templateError("interpolation of '%s' produced an array or object", remaining[offset+2:end])
+		case nil:
+		case string:
+			result.WriteString(v)
+		case float64:
+			result.WriteString(formatNumber(v))
+		case bool:
+			if v {
+				result.WriteString("true")
+			} else {
+				result.WriteString("false")
+			}
+		default:
+			return "", templateError("interpolation of '%s' produced a function", remaining[offset+2:end])
+		}
+		remaining = remaining[end+1:]
+	}
+	result.WriteString(remaining)
+	return result.String(), nil
+}
+
+// formatNumber formats a number as JavaScript does
+func formatNumber(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	abs := math.Abs(f)
+	if abs != 0 && (abs >= 1e21 || abs < 1e-6) {
+		// JavaScript does not pad the exponent, so 1e-07 is 1e-7
+		mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+		return mantissa + "e" + exponent[:1] + strings.TrimLeft(exponent[1:], "0")
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+func isEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !isEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !isEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case Function, *builtin:
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	return a == b
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "function"
+}
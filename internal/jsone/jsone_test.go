@@ -0,0 +1,218 @@
+package jsone
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var now = time.Date(2017, 1, 19, 16, 27, 20, 974000000, time.UTC)
+
+type renderCase struct {
+	title    string
+	template string
+	context  string
+	result   string
+	err      string
+}
+
+// Cases in the style of the JSON-e specification, given as JSON
+var renderCases = []renderCase{
+	// interpolation
+	{title: "interpolate string", template: `{"a": "hello ${name}"}`, context: `{"name": "world"}`, result: `{"a": "hello world"}`},
+	{title: "interpolate number", template: `"${1 + 2} and ${1.5} and ${10 / 4}"`, result: `"3 and 1.5 and 2.5"`},
+	{title: "interpolate bool and null", template: `"${true}-${null}-"`, result: `"true--"`},
+	{title: "interpolate escape", template: `"$${name} ${name}"`, context: `{"name": "x"}`, result: `"${name} x"`},
+	{title: "interpolate keys", template: `{"${k}": 1}`, context: `{"k": "key"}`, result: `{"key": 1}`},
+	{title: "interpolate with brace in string", template: `"${'}'}"`, result: `"}"`},
+	{title: "interpolate array", template: `"${[1]}"`, err: "TemplateError: interpolation of '[1]' produced an array or object"},
+	{title: "escaped operator key", template: `{"$$eval": "x"}`, result: `{"$eval": "x"}`},
+	{title: "reserved key", template: `{"$foo": 1}`, err: "$foo is reserved"},
+	{title: "two operators", template: `{"$eval": "1", "$json": 2}`, err: "only one operator allowed"},
+
+	// expressions
+	{title: "arithmetic", template: `{"$eval": "1 + 2 * 3 - 4 / 2"}`, result: `5`},
+	{title: "power is right-associative", template: `{"$eval": "2 ** 3 ** 2"}`, result: `512`},
+	{title: "unary minus", template: `{"$eval": "-(1 + 2)"}`, result: `-3`},
+	{title: "string concatenation", template: `{"$eval": "'a' + \"b\""}`, result: `"ab"`},
+	{title: "mixed addition", template: `{"$eval": "'a' + 1"}`, err: "InterpreterError: infix: + expects number/string + number/string"},
+	{title: "comparison", template: `{"$eval": "[1 < 2, 'b' >= 'a', 2 <= 1, 1 == 1.0, [1] == [1], {a: 1} != {a: 1}]"}`, result: `[true, true, false, true, true, false]`},
+	{title: "logic", template: `{"$eval": "[true && false, false || 'x', !0, !'']"}`, result: `[false, true, true, true]`},
+	{title: "short circuit", template: `{"$eval": "false && missing"}`, result: `false`},
+	{title: "in", template: `{"$eval": "['a' in {a: 1}, 2 in [1, 2], 'ell' in 'hello', 'x' in []]"}`, result: `[true, true, true, false]`},
+	{title: "property access", template: `{"$eval": "a.b.c"}`, context: `{"a": {"b": {"c": 3}}}`, result: `3`},
+	{title: "missing property", template: `{"$eval": "a.x"}`, context: `{"a": {}}`, err: `InterpreterError: object has no property "x"`},
+	{title: "missing bracket property", template: `{"$eval": "a['x']"}`, context: `{"a": {}}`, result: `null`},
+	{title: "index", template: `{"$eval": "[a[0], a[-1], 'abc'[1]]"}`, context: `{"a": [1, 2, 3]}`, result: `[1, 3, "b"]`},
+	{title: "index out of bounds", template: `{"$eval": "a[3]"}`, context: `{"a": [1, 2, 3]}`, err: "index out of bounds"},
+	{title: "slice", template: `{"$eval": "[a[1:], a[:-1], a[-2:10], 'hello'[1:3], a[2:1]]"}`, context: `{"a": [1, 2, 3]}`, result: `[[2, 3], [1, 2], [2, 3], "el", []]`},
+	{title: "object literal", template: `{"$eval": "{a: 1, 'b c': [x]}"}`, context: `{"x": null}`, result: `{"a": 1, "b c": [null]}`},
+	{title: "unknown context value", template: `{"$eval": "nope"}`, err: "InterpreterError: unknown context value nope"},
+	{title: "syntax error", template: `{"$eval": "1 +"}`, err: "SyntaxError"},
+	{title: "trailing tokens", template: `{"$eval": "1 2"}`, err: "SyntaxError"},
+
+	// builtins
+	{title: "math builtins", template: `{"$eval": "[min(3, 1, 2), max(1, 3), sqrt(16), ceil(1.2), floor(1.8), abs(-2)]"}`, result: `[1, 3, 4, 2, 1, 2]`},
+	{title: "string builtins", template: `{"$eval": "[lowercase('AB'), uppercase('ab'), strip(' a '), lstrip(' a '), rstrip(' a '), len('héllo'), len([1, 2])]"}`, result: `["ab", "AB", "a", "a ", " a", 5, 2]`},
+	{title: "conversion builtins", template: `{"$eval": "[str(1), str(true), str(null), number('1.5')]"}`, result: `["1", "true", "null", 1.5]`},
+	{title: "split and join", template: `{"$eval": "[split('a,b', ','), join(['a', 1], '-')]"}`, result: `[["a", "b"], "a-1"]`},
+	{title: "range", template: `{"$eval": "[range(0, 3), range(5, 0, -2)]"}`, result: `[[0, 1, 2], [5, 3, 1]]`},
+	{title: "typeof and defined", template: `{"$eval": "[typeof(1), typeof('a'), typeof(null), typeof([]), typeof({}), typeof(min), defined('x'), defined('y')]"}`, context: `{"x": 1}`, result: `["number", "string", "null", "array", "object", "function", true, false]`},
+	{title: "builtin argument types", template: `{"$eval": "min('a')"}`, err: "invalid arguments to builtin: min"},
+	{title: "uncalled function", template: `{"$eval": "min"}`, err: "evaluated template contained uncalled functions"},
+
+	// $fromNow
+	{title: "fromNow", template: `{"$fromNow": "1 day 2 hours"}`, result: `"2017-01-20T18:27:20.974Z"`},
+	{title: "fromNow negative", template: `{"$fromNow": "-1 year 2 months 3 weeks"}`, result: `"2015-10-29T16:27:20.974Z"`},
+	{title: "fromNow from", template: `{"$fromNow": "1 minute 1 sec", "from": "2017-01-19T16:27:20.974Z"}`, result: `"2017-01-19T16:28:21.974Z"`},
+	{title: "fromNow builtin", template: `{"$eval": "fromNow('2 weeks', '2017-01-01T00:00:00.000Z')"}`, result: `"2017-01-15T00:00:00.000Z"`},
+	{title: "fromNow invalid", template: `{"$fromNow": "soon"}`, err: "isn't a time expression"},
+	{title: "now", template: `{"$eval": "now"}`, result: `"2017-01-19T16:27:20.974Z"`},
+
+	// $if
+	{title: "if then", template: `{"$if": "x > 1", "then": "big", "else": "small"}`, context: `{"x": 2}`, result: `"big"`},
+	{title: "if else", template: `{"$if": "x > 1", "then": "big", "else": "small"}`, context: `{"x": 0}`, result: `"small"`},
+	{title: "if deletes", template: `{"a": {"$if": "false", "then": 1}, "b": [1, {"$if": "false", "then": 2}]}`, result: `{"b": [1]}`},
+	{title: "if undefined properties", template: `{"$if": "true", "then": 1, "oops": 2}`, err: "TemplateError: $if has undefined properties: oops"},
+	{title: "top-level delete", template: `{"$if": "false", "then": 1}`, result: `null`},
+
+	// $json
+	{title: "json", template: `{"$json": {"b": [1, "<a>"], "a": null}}`, result: `"{\"a\":null,\"b\":[1,\"<a>\"]}"`},
+
+	// $let
+	{title: "let", template: `{"$let": {"x": 1, "y": "${z}"}, "in": "${x}-${y}"}`, context: `{"z": "zz"}`, result: `"1-zz"`},
+	{title: "let shadows", template: `{"$let": {"x": 2}, "in": {"$eval": "x"}}`, context: `{"x": 1}`, result: `2`},
+	{title: "let without in", template: `{"$let": {"x": 1}}`, err: "$let operator requires an `in` clause"},
+	{title: "let invalid key", template: `{"$let": {"a-b": 1}, "in": 1}`, err: "top level keys of $let must follow"},
+
+	// $map
+	{title: "map array", template: `{"$map": [1, 2], "each(x)": {"$eval": "x * 2"}}`, result: `[2, 4]`},
+	{title: "map array with index", template: `{"$map": ["a", "b"], "each(x, i)": "${i}:${x}"}`, result: `["0:a", "1:b"]`},
+	{title: "map object", template: `{"$map": {"a": 1, "b": 2}, "each(y)": {"${y.key}x": {"$eval": "y.val + 1"}}}`, result: `{"ax": 2, "bx": 3}`},
+	{title: "map object with key", template: `{"$map": {"a": 1}, "each(v,k)": {"${k}": "${v}"}}`, result: `{"a": "1"}`},
+	{title: "map bad each", template: `{"$map": [1], "each": 1}`, err: "TemplateError: $map has undefined properties: each"},
+	{title: "map object to non-object", template: `{"$map": {"a": 1}, "each(x)": 1}`, err: "$map on objects expects each(x) to evaluate to an object"},
+
+	// $find
+	{title: "find", template: `{"$find": [1, 2, 3], "each(x)": "x > 1"}`, result: `2`},
+	{title: "find nothing", template: `{"a": {"$find": [1], "each(x, i)": "i > 0"}}`, result: `{}`},
+
+	// $reduce
+	{title: "reduce", template: `{"$reduce": [1, 2, 3], "initial": 0, "each(acc, x)": {"$eval": "acc + x"}}`, result: `6`},
+	{title: "reduce with index", template: `{"$reduce": ["a", "b"], "initial": "", "each(acc, x, i)": "${acc}${i}${x}"}`, result: `"0a1b"`},
+	{title: "reduce to object", template: `{"$reduce": [{"k": "a", "v": 1}], "initial": {}, "each(acc, x)": {"$merge": [{"$eval": "acc"}, {"${x.k}": {"$eval": "x.v"}}]}}`, result: `{"a": 1}`},
+	{title: "reduce skips deleted", template: `{"$reduce": [1, 2, 3], "initial": 0, "each(acc, x)": {"$if": "x != 2", "then": {"$eval": "acc + x"}}}`, result: `4`},
+	{title: "reduce empty", template: `{"$reduce": [], "initial": 5, "each(acc, x)": 1}`, result: `5`},
+	{title: "reduce non-array", template: `{"$reduce": {}, "initial": 0, "each(acc, x)": 1}`, err: "$reduce value must evaluate to an array"},
+	{title: "reduce without initial", template: `{"$reduce": [1], "each(acc, x)": 1}`, err: "$reduce must have exactly three properties"},
+	{title: "reduce bad each", template: `{"$reduce": [1], "initial": 0, "each(x)": 1}`, err: "$reduce has undefined properties: each(x)"},
+
+	// $match and $switch
+	{title: "match", template: `{"$match": {"x == 1": "a", "x < 2": "b", "x > 1": "c"}}`, context: `{"x": 1}`, result: `["b", "a"]`},
+	{title: "switch", template: `{"$switch": {"x == 1": "a", "x == 2": "b", "$default": "c"}}`, context: `{"x": 2}`, result: `"b"`},
+	{title: "switch default", template: `{"$switch": {"x == 1": "a", "$default": "c"}}`, context: `{"x": 2}`, result: `"c"`},
+	{title: "switch nothing", template: `[{"$switch": {"x == 1": "a"}}]`, context: `{"x": 2}`, result: `[]`},
+	{title: "switch two truthy", template: `{"$switch": {"x > 0": "a", "x > 1": "b"}}`, context: `{"x": 2}`, err: "$switch can only have one truthy condition"},
+
+	// $merge, $mergeDeep, $flatten, $flattenDeep, $reverse, $sort
+	{title: "merge", template: `{"$merge": [{"a": 1, "b": 1}, {"b": 2}]}`, result: `{"a": 1, "b": 2}`},
+	{title: "merge non-objects", template: `{"$merge": [1]}`, err: "$merge value must evaluate to an array of objects"},
+	{title: "mergeDeep", template: `{"$mergeDeep": [{"a": {"b": [1], "c": 1}}, {"a": {"b": [2], "d": 2}}]}`, result: `{"a": {"b": [1, 2], "c": 1, "d": 2}}`},
+	{title: "flatten", template: `{"$flatten": [[1, [2]], 3]}`, result: `[1, [2], 3]`},
+	{title: "flattenDeep", template: `{"$flattenDeep": [[1, [2, [3]]], 4]}`, result: `[1, 2, 3, 4]`},
+	{title: "reverse", template: `{"$reverse": [1, 2, 3]}`, result: `[3, 2, 1]`},
+	{title: "sort", template: `{"$sort": [3, 1, 2]}`, result: `[1, 2, 3]`},
+	{title: "sort by", template: `{"$sort": [{"n": "b"}, {"n": "a"}], "by(x)": "x.n"}`, result: `[{"n": "a"}, {"n": "b"}]`},
+	{title: "sort mixed", template: `{"$sort": [1, "a"]}`, err: "$sort requires all sorted values have the same type"},
+
+	// context
+	{title: "invalid context key", template: `1`, context: `{"a-b": 1}`, err: "top level keys of context must follow"},
+}
+
+func TestRender(t *testing.T) {
+	for _, c := range renderCases {
+		t.Run(c.title, func(t *testing.T) {
+			var template interface{}
+			require.NoError(t, json.Unmarshal([]byte(c.template), &template))
+			context := map[string]interface{}{}
+			if c.context != "" {
+				require.NoError(t, json.Unmarshal([]byte(c.context), &context))
+			}
+			result, err := RenderAt(template, context, now)
+			if c.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.err)
+				return
+			}
+			require.NoError(t, err)
+			actual, err := json.Marshal(result)
+			require.NoError(t, err)
+			require.JSONEq(t, c.result, string(actual))
+		})
+	}
+}
+
+func TestRenderYAML(t *testing.T) {
+	var template interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tasks:
+  $map: {$eval: 'range(0, count)'}
+  each(i):
+    name: task ${i}
+    retries: 2
+    deadline: {$fromNow: '1 day'}
+`), &template))
+
+	result, err := RenderAt(template, map[string]interface{}{"count": 2}, now)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"tasks": []interface{}{
+			map[string]interface{}{"name": "task 0", "retries": float64(2), "deadline": "2017-01-20T16:27:20.974Z"},
+			map[string]interface{}{"name": "task 1", "retries": float64(2), "deadline": "2017-01-20T16:27:20.974Z"},
+		},
+	}, result)
+}
+
+func TestContextFunctions(t *testing.T) {
+	context := map[string]interface{}{
+		"greet": func(args ...interface{}) (interface{}, error) {
+			if len(args) != 1 {
+				return nil, errors.New("greet takes one argument")
+			}
+			return "hello " + args[0].(string), nil
+		},
+	}
+	result, err := Render(`${greet("you")}`, context)
+	require.NoError(t, err)
+	require.Equal(t, "hello you", result)
+
+	_, err = Render(`${greet()}`, context)
+	require.EqualError(t, err, "greet takes one argument")
+}
+
+func TestRenderNilContext(t *testing.T) {
+	_, err := Render(1, nil)
+	var templateErr *TemplateError
+	require.True(t, errors.As(err, &templateErr))
+}
+
+func TestFormatNumber(t *testing.T) {
+	for f, s := range map[float64]string{
+		0:        "0",
+		-1:       "-1",
+		1.25:     "1.25",
+		1e21:     "1e+21",
+		1e20:     "100000000000000000000",
+		1e-7:     "1e-7",
+		0.000001: "0.000001",
+	} {
+		require.Equal(t, s, formatNumber(f), "formatting %v", f)
+	}
+	// as in JavaScript, the shortest representation that round-trips
+	a, b := 0.1, 0.2
+	require.Equal(t, "0.30000000000000004", formatNumber(a+b))
+}
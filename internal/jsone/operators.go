@@ -0,0 +1,613 @@
+package jsone
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type operator func(template map[string]interface{}, context map[string]interface{}) (interface{}, error)
+
+var operators map[string]operator
+
+func init() {
+	// assigned here, as the operators refer to render, which refers to
+	// operators
+	operators = map[string]operator{
+		"$eval":        evalOperator,
+		"$flatten":     flattenOperator,
+		"$flattenDeep": flattenDeepOperator,
+		"$find":        findOperator,
+		"$fromNow":     fromNowOperator,
+		"$if":          ifOperator,
+		"$json":        jsonOperator,
+		"$let":         letOperator,
+		"$map":         mapOperator,
+		"$match":       matchOperator,
+		"$merge":       mergeOperator,
+		"$mergeDeep":   mergeDeepOperator,
+		"$reduce":      reduceOperator,
+		"$reverse":     reverseOperator,
+		"$sort":        sortOperator,
+		"$switch":      switchOperator,
+	}
+}
+
+// checkUndefinedProperties returns an error if template has properties that
+// do not match any of the allowed patterns; the first pattern is the
+// operator itself
+func checkUndefinedProperties(template map[string]interface{}, allowed ...string) error {
+	combined := regexp.MustCompile("^(" + strings.Join(allowed, "|") + ")$")
+	var unknown []string
+	for _, key := range sortedKeys(template) {
+		if !combined.MatchString(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		return templateError("%s has undefined properties: %s", strings.ReplaceAll(allowed[0], `\`, ""), strings.Join(unknown, " "))
+	}
+	return nil
+}
+
+func evalOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$eval`); err != nil {
+		return nil, err
+	}
+	expression, ok := template["$eval"].(string)
+	if !ok {
+		return nil, templateError("$eval must be given a string expression")
+	}
+	return evaluate(expression, context)
+}
+
+func flattenOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$flatten`); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$flatten"], context)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, templateError("$flatten value must evaluate to an array")
+	}
+	result := []interface{}{}
+	for _, item := range list {
+		if inner, ok := item.([]interface{}); ok {
+			result = append(result, inner...)
+		} else {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func flattenDeepOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$flattenDeep`); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$flattenDeep"], context)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, templateError("$flattenDeep value must evaluate to an array")
+	}
+	var flatten func(list []interface{}) []interface{}
+	flatten = func(list []interface{}) []interface{} {
+		result := []interface{}{}
+		for _, item := range list {
+			if inner, ok := item.([]interface{}); ok {
+				result = append(result, flatten(inner)...)
+			} else {
+				result = append(result, item)
+			}
+		}
+		return result
+	}
+	return flatten(list), nil
+}
+
+func fromNowOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$fromNow`, "from"); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$fromNow"], context)
+	if err != nil {
+		return nil, err
+	}
+	offset, ok := value.(string)
+	if !ok {
+		return nil, templateError("$fromNow expects a string")
+	}
+	reference := context["now"]
+	if from, ok := template["from"]; ok {
+		if reference, err = render(from, context); err != nil {
+			return nil, err
+		}
+	}
+	ref, ok := reference.(string)
+	if !ok {
+		return nil, templateError("$fromNow expects a string reference time")
+	}
+	return fromNow(offset, ref)
+}
+
+func ifOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$if`, "then", "else"); err != nil {
+		return nil, err
+	}
+	expression, ok := template["$if"].(string)
+	if !ok {
+		return nil, templateError("$if can evaluate string expressions only")
+	}
+	condition, err := evaluate(expression, context)
+	if err != nil {
+		return nil, err
+	}
+	branch := "else"
+	if isTruthy(condition) {
+		branch = "then"
+	}
+	value, ok := template[branch]
+	if !ok {
+		return deleteMarker, nil
+	}
+	return render(value, context)
+}
+
+func jsonOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$json`); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$json"], context)
+	if err != nil {
+		return nil, err
+	}
+	if containsFunctions(value) {
+		return nil, templateError("$json cannot represent functions")
+	}
+	// encoding/json sorts object keys, as the services do
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, templateError("$json cannot encode value: %v", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func letOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$let`, "in"); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$let"], context)
+	if err != nil {
+		return nil, err
+	}
+	variables, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, templateError("$let value must evaluate to an object")
+	}
+	child := withVariables(context, nil)
+	for key, value := range variables {
+		if !identifierPattern.MatchString(key) {
+			return nil, templateError("top level keys of $let must follow /[a-zA-Z_][a-zA-Z0-9_]*/")
+		}
+		child[key] = value
+	}
+	in, ok := template["in"]
+	if !ok {
+		return nil, templateError("$let operator requires an `in` clause")
+	}
+	return render(in, child)
+}
+
+// withVariables returns a copy of context with the given variables added
+func withVariables(context map[string]interface{}, variables map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(context)+len(variables))
+	for key, value := range context {
+		result[key] = value
+	}
+	for key, value := range variables {
+		result[key] = value
+	}
+	return result
+}
+
+const eachPattern = `each\(([a-zA-Z_][a-zA-Z0-9_]*)(,\s*([a-zA-Z_][a-zA-Z0-9_]*))?\)`
+
+var eachRegexp = regexp.MustCompile("^" + eachPattern + "$")
+
+// eachClause returns the key of the each(..) property of a $map or $find
+// template, and the names of the variables it declares
+func eachClause(op string, template map[string]interface{}) (key, x, i string, err error) {
+	if len(template) != 2 {
+		return "", "", "", templateError("%s must have exactly two properties", op)
+	}
+	for k := range template {
+		if k != op {
+			key = k
+		}
+	}
+	match := eachRegexp.FindStringSubmatch(key)
+	if match == nil {
+		return "", "", "", templateError("%s requires each(identifier) syntax", op)
+	}
+	return key, match[1], match[3], nil
+}
+
+func mapOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$map`, eachPattern); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$map"], context)
+	if err != nil {
+		return nil, err
+	}
+	eachKey, x, i, err := eachClause("$map", template)
+	if err != nil {
+		return nil, err
+	}
+	each := template[eachKey]
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for _, key := range sortedKeys(v) {
+			var variables map[string]interface{}
+			if i != "" {
+				variables = map[string]interface{}{x: v[key], i: key}
+			} else {
+				variables = map[string]interface{}{x: map[string]interface{}{"key": key, "val": v[key]}}
+			}
+			rendered, err := render(each, withVariables(context, variables))
+			if err != nil {
+				return nil, err
+			}
+			if rendered == deleteMarker {
+				continue
+			}
+			object, ok := rendered.(map[string]interface{})
+			if !ok {
+				return nil, templateError("$map on objects expects %s to evaluate to an object", eachKey)
+			}
+			for k, item := range object {
+				result[k] = item
+			}
+		}
+		return result, nil
+	case []interface{}:
+		result := []interface{}{}
+		for index, item := range v {
+			variables := map[string]interface{}{x: item}
+			if i != "" {
+				variables[i] = float64(index)
+			}
+			rendered, err := render(each, withVariables(context, variables))
+			if err != nil {
+				return nil, err
+			}
+			if rendered != deleteMarker {
+				result = append(result, rendered)
+			}
+		}
+		return result, nil
+	}
+	return nil, templateError("$map value must evaluate to an array or object")
+}
+
+func findOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$find`, eachPattern); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$find"], context)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, templateError("$find value must evaluate to an array")
+	}
+	eachKey, x, i, err := eachClause("$find", template)
+	if err != nil {
+		return nil, err
+	}
+	expression, ok := template[eachKey].(string)
+	if !ok {
+		return nil, templateError("each can evaluate string expressions only")
+	}
+	for index, item := range list {
+		variables := map[string]interface{}{x: item}
+		if i != "" {
+			variables[i] = float64(index)
+		}
+		found, err := evaluate(expression, withVariables(context, variables))
+		if err != nil {
+			return nil, err
+		}
+		if isTruthy(found) {
+			return render(item, context)
+		}
+	}
+	return deleteMarker, nil
+}
+
+const reduceEachPattern = `each\(([a-zA-Z_][a-zA-Z0-9_]*),\s*([a-zA-Z_][a-zA-Z0-9_]*)(,\s*([a-zA-Z_][a-zA-Z0-9_]*))?\)`
+
+var reduceEachRegexp = regexp.MustCompile("^" + reduceEachPattern + "$")
+
+func reduceOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$reduce`, `initial`, reduceEachPattern); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$reduce"], context)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, templateError("$reduce value must evaluate to an array")
+	}
+	if len(template) != 3 {
+		return nil, templateError("$reduce must have exactly three properties")
+	}
+	var eachKey string
+	for key := range template {
+		if key != "$reduce" && key != "initial" {
+			eachKey = key
+		}
+	}
+	match := reduceEachRegexp.FindStringSubmatch(eachKey)
+	a, x, i := match[1], match[2], match[4]
+	each := template[eachKey]
+
+	// as in the JavaScript implementation, the initial value is used as is,
+	// and an iteration that renders to nothing leaves the accumulator alone
+	acc := template["initial"]
+	for index, item := range list {
+		variables := map[string]interface{}{a: acc, x: item}
+		if i != "" {
+			variables[i] = float64(index)
+		}
+		rendered, err := render(each, withVariables(context, variables))
+		if err != nil {
+			return nil, err
+		}
+		if rendered != deleteMarker {
+			acc = rendered
+		}
+	}
+	return acc, nil
+}
+
+func matchOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$match`); err != nil {
+		return nil, err
+	}
+	conditions, ok := template["$match"].(map[string]interface{})
+	if !ok {
+		return nil, templateError("$match can evaluate objects only")
+	}
+	result := []interface{}{}
+	for _, condition := range sortedKeys(conditions) {
+		value, err := evaluate(condition, context)
+		if err != nil {
+			return nil, err
+		}
+		if !isTruthy(value) {
+			continue
+		}
+		rendered, err := render(conditions[condition], context)
+		if err != nil {
+			return nil, err
+		}
+		if rendered != deleteMarker {
+			result = append(result, rendered)
+		}
+	}
+	return result, nil
+}
+
+func switchOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$switch`); err != nil {
+		return nil, err
+	}
+	conditions, ok := template["$switch"].(map[string]interface{})
+	if !ok {
+		return nil, templateError("$switch can evaluate objects only")
+	}
+	var matched []string
+	for _, condition := range sortedKeys(conditions) {
+		if condition == "$default" {
+			continue
+		}
+		value, err := evaluate(condition, context)
+		if err != nil {
+			return nil, err
+		}
+		if isTruthy(value) {
+			matched = append(matched, condition)
+		}
+	}
+	if len(matched) > 1 {
+		return nil, templateError("$switch can only have one truthy condition")
+	}
+	if len(matched) == 0 {
+		if def, ok := conditions["$default"]; ok {
+			return render(def, context)
+		}
+		return deleteMarker, nil
+	}
+	return render(conditions[matched[0]], context)
+}
+
+// objects renders the operator's value, which must be an array of objects
+func objects(op string, template map[string]interface{}, context map[string]interface{}) ([]map[string]interface{}, error) {
+	value, err := render(template[op], context)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, templateError("%s value must evaluate to an array of objects", op)
+	}
+	result := make([]map[string]interface{}, len(list))
+	for i, item := range list {
+		if result[i], ok = item.(map[string]interface{}); !ok {
+			return nil, templateError("%s value must evaluate to an array of objects", op)
+		}
+	}
+	return result, nil
+}
+
+func mergeOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$merge`); err != nil {
+		return nil, err
+	}
+	list, err := objects("$merge", template, context)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	for _, object := range list {
+		for key, value := range object {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+func mergeDeepOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$mergeDeep`); err != nil {
+		return nil, err
+	}
+	list, err := objects("$mergeDeep", template, context)
+	if err != nil {
+		return nil, err
+	}
+	// arrays are concatenated, objects are merged, and anything else is
+	// replaced
+	var merge func(left, right interface{}) interface{}
+	merge = func(left, right interface{}) interface{} {
+		if l, ok := left.([]interface{}); ok {
+			if r, ok := right.([]interface{}); ok {
+				return append(append([]interface{}{}, l...), r...)
+			}
+		}
+		if l, ok := left.(map[string]interface{}); ok {
+			if r, ok := right.(map[string]interface{}); ok {
+				result := withVariables(l, nil)
+				for key, value := range r {
+					if existing, ok := result[key]; ok {
+						result[key] = merge(existing, value)
+					} else {
+						result[key] = value
+					}
+				}
+				return result
+			}
+		}
+		return right
+	}
+	var result interface{} = map[string]interface{}{}
+	for _, object := range list {
+		result = merge(result, object)
+	}
+	return result, nil
+}
+
+func reverseOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$reverse`); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$reverse"], context)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, templateError("$reverse value must evaluate to an array")
+	}
+	result := make([]interface{}, len(list))
+	for i, item := range list {
+		result[len(list)-1-i] = item
+	}
+	return result, nil
+}
+
+const byPattern = `by\(([a-zA-Z_][a-zA-Z0-9_]*)\)`
+
+var byRegexp = regexp.MustCompile("^" + byPattern + "$")
+
+func sortOperator(template map[string]interface{}, context map[string]interface{}) (interface{}, error) {
+	if err := checkUndefinedProperties(template, `\$sort`, byPattern); err != nil {
+		return nil, err
+	}
+	value, err := render(template["$sort"], context)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, templateError("$sort value must evaluate to an array")
+	}
+
+	// the values by which to sort, which are the items themselves unless a
+	// by(..) expression is given
+	keys := list
+	if len(template) > 2 {
+		return nil, templateError("$sort has undefined properties")
+	}
+	for key, by := range template {
+		if key == "$sort" {
+			continue
+		}
+		match := byRegexp.FindStringSubmatch(key)
+		expression, ok := by.(string)
+		if match == nil || !ok {
+			return nil, templateError("$sort requires by(identifier) syntax with a string expression")
+		}
+		keys = make([]interface{}, len(list))
+		for i, item := range list {
+			if keys[i], err = evaluate(expression, withVariables(context, map[string]interface{}{match[1]: item})); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	indexes := make([]int, len(list))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if len(keys) > 0 {
+		switch keys[0].(type) {
+		case float64:
+			for _, key := range keys {
+				if _, ok := key.(float64); !ok {
+					return nil, templateError("$sort requires all sorted values have the same type")
+				}
+			}
+			sort.SliceStable(indexes, func(a, b int) bool { return keys[indexes[a]].(float64) < keys[indexes[b]].(float64) })
+		case string:
+			for _, key := range keys {
+				if _, ok := key.(string); !ok {
+					return nil, templateError("$sort requires all sorted values have the same type")
+				}
+			}
+			sort.SliceStable(indexes, func(a, b int) bool { return keys[indexes[a]].(string) < keys[indexes[b]].(string) })
+		default:
+			return nil, templateError("$sort can only sort arrays of numbers or strings")
+		}
+	}
+	result := make([]interface{}, len(list))
+	for i, index := range indexes {
+		result[i] = list[index]
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+// Package netconfig configures the HTTP proxy and additional trusted CA
+// certificates used by a process's HTTP clients, for deployments on networks
+// that require them.
+package netconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Config is the network configuration.  All fields are optional.  Its JSON
+// form is used in the worker-runner protocol's `network-config` message.
+type Config struct {
+	// URL of the proxy for HTTP requests
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// URL of the proxy for HTTPS requests
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// comma-separated hosts, domains and CIDR ranges that are not proxied,
+	// in the format of the NO_PROXY environment variable
+	NoProxy string `json:"noProxy,omitempty"`
+
+	// PEM-encoded CA certificates to trust in addition to the system's
+	CACertificates string `json:"caCertificates,omitempty"`
+}
+
+// IsEmpty returns true if the configuration does not change anything
+func (c *Config) IsEmpty() bool {
+	return *c == Config{}
+}
+
+// CertPool returns the system's certificate pool with the additional CA
+// certificates added, or nil if there are no additional certificates.
+func (c *Config) CertPool() (*x509.CertPool, error) {
+	if c.CACertificates == "" {
+		return nil, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM([]byte(c.CACertificates)) {
+		return nil, fmt.Errorf("no valid PEM-encoded certificates found in CA certificates")
+	}
+	return pool, nil
+}
+
+// Apply the configuration to this process: http.DefaultTransport, used by
+// the default HTTP client and the Taskcluster clients, uses the proxies and
+// trusts the additional CA certificates, and the standard proxy environment
+// variables are set, for any child processes.
+func (c *Config) Apply() error {
+	for _, proxyURL := range []string{c.HTTPProxy, c.HTTPSProxy} {
+		if proxyURL == "" {
+			continue
+		}
+		if _, err := url.Parse(proxyURL); err != nil {
+			return fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+		}
+	}
+	pool, err := c.CertPool()
+	if err != nil {
+		return err
+	}
+
+	for _, env := range []struct{ name, value string }{
+		{"HTTP_PROXY", c.HTTPProxy},
+		{"HTTPS_PROXY", c.HTTPSProxy},
+		{"NO_PROXY", c.NoProxy},
+	} {
+		if env.value == "" {
+			continue
+		}
+		// many tools only recognize the lower-case form
+		for _, name := range []string{env.name, strings.ToLower(env.name)} {
+			if err := os.Setenv(name, env.value); err != nil {
+				return err
+			}
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport)
+	// read the environment, now including the variables set above, rather
+	// than using http.ProxyFromEnvironment, which only reads it once
+	proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	if pool != nil {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package netconfig
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Restore http.DefaultTransport and the proxy environment variables after
+// the test
+func saveState(t *testing.T) {
+	t.Helper()
+	transport := http.DefaultTransport.(*http.Transport)
+	proxy, tlsConfig := transport.Proxy, transport.TLSClientConfig
+	t.Cleanup(func() {
+		transport.Proxy, transport.TLSClientConfig = proxy, tlsConfig
+		transport.CloseIdleConnections()
+	})
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestCACertificates(t *testing.T) {
+	saveState(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	_, err := http.Get(server.URL)
+	require.Error(t, err, "server certificate should not be trusted yet")
+
+	config := &Config{
+		CACertificates: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+	}
+	require.NoError(t, config.Apply())
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, "hello", string(body))
+}
+
+func TestInvalidCACertificates(t *testing.T) {
+	saveState(t)
+	config := &Config{CACertificates: "not a certificate"}
+	require.Error(t, config.Apply())
+	require.Equal(t, (*tls.Config)(nil), http.DefaultTransport.(*http.Transport).TLSClientConfig)
+}
+
+func TestProxy(t *testing.T) {
+	saveState(t)
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	config := &Config{HTTPProxy: proxy.URL, NoProxy: "direct.example.com"}
+	require.NoError(t, config.Apply())
+
+	resp, err := http.Get("http://proxied.example.com/path")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, "via proxy", string(body))
+	require.Equal(t, []string{"http://proxied.example.com/path"}, proxied)
+
+	// hosts in noProxy are not proxied
+	req, err := http.NewRequest("GET", "http://direct.example.com/", nil)
+	require.NoError(t, err)
+	proxyURL, err := http.DefaultTransport.(*http.Transport).Proxy(req)
+	require.NoError(t, err)
+	require.Nil(t, proxyURL)
+
+	// child processes get the settings from the environment
+	require.Equal(t, proxy.URL, os.Getenv("HTTP_PROXY"))
+	require.Equal(t, proxy.URL, os.Getenv("http_proxy"))
+	require.Equal(t, "direct.example.com", os.Getenv("NO_PROXY"))
+}
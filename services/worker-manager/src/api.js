@@ -786,7 +786,7 @@ builder.declare({
     'some proof of its identity, and that proof varies by provider type.',
   ].join('\n'),
 }, async function(req, res) {
-  const { workerPoolId, providerId, workerGroup, workerId, workerIdentityProof } = req.body;
+  const { workerPoolId, providerId, workerGroup, workerId, workerIdentityProof, startupTimings = {} } = req.body;
 
   // carefully check each value provided, since we have not yet validated the
   // worker's "proof"
@@ -866,6 +866,16 @@ builder.declare({
   // not verify here
   const credentials = createCredentials(worker, expires, this.cfg);
 
+  this.monitor.log.workerStartup({
+    workerPoolId,
+    providerId,
+    workerGroup,
+    workerId,
+    provisioningSecs: (new Date() - worker.created) / 1000,
+    bootSecs: startupTimings.bootSecs ?? null,
+    configureSecs: startupTimings.configureSecs ?? null,
+  });
+
   return res.reply({
     expires: expires.toJSON(),
     credentials,
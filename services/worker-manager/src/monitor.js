@@ -58,6 +58,29 @@ MonitorManager.register({
   },
 });
 
+MonitorManager.register({
+  name: 'workerStartup',
+  title: 'Worker Startup',
+  type: 'worker-startup',
+  version: 1,
+  level: 'notice',
+  description: `
+    A worker has registered.  This gives the time from the worker being
+    requested to its registration, with a breakdown of that time as reported
+    by the worker, if available.  The time until the worker first claims a
+    task is available from the worker's \`firstClaim\`.
+  `,
+  fields: {
+    workerPoolId: 'The worker pool ID',
+    providerId: 'The provider that did the work for this worker pool.',
+    workerGroup: 'The worker group for this worker',
+    workerId: 'The worker that registered',
+    provisioningSecs: 'Seconds from the worker being requested to its registration',
+    bootSecs: 'Seconds from the host booting to the start of the worker process, or null if not reported',
+    configureSecs: 'Seconds from the start of the worker process to its registration, or null if not reported',
+  },
+});
+
 MonitorManager.register({
   name: 'workerRemoved',
   title: 'Worker Removed',
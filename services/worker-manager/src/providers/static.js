@@ -1,7 +1,22 @@
+import crypto from 'crypto';
 import taskcluster from 'taskcluster-client';
 import { ApiError, Provider } from './provider.js';
 import { Worker } from '../data.js';
 
+// maximum difference between the timestamp of a signed workerIdentityProof and
+// the current time
+const SIGNATURE_TIMESTAMP_TOLERANCE = 10 * 60 * 1000;
+
+// Parse a PEM-encoded certificate given in providerInfo, throwing an ApiError
+// if it is invalid
+const parseCertificate = pem => {
+  try {
+    return new crypto.X509Certificate(pem);
+  } catch (err) {
+    throw new ApiError(`invalid clientCertificate: ${err.message}`);
+  }
+};
+
 export class StaticProvider extends Provider {
   constructor(conf) {
     super(conf);
@@ -9,10 +24,13 @@ export class StaticProvider extends Provider {
   }
 
   async createWorker({ workerPool, workerGroup, workerId, input }) {
-    const { staticSecret } = input.providerInfo || {};
+    const { staticSecret, clientCertificate } = input.providerInfo || {};
     if (!staticSecret) {
       throw new ApiError('no worker staticSecret provided');
     }
+    if (clientCertificate) {
+      parseCertificate(clientCertificate);
+    }
 
     const { workerPoolId, providerId } = workerPool;
     const workerData = {
@@ -23,7 +41,7 @@ export class StaticProvider extends Provider {
       expires: new Date(input.expires),
       capacity: input.capacity,
       state: Worker.states.RUNNING,
-      providerData: { staticSecret, workerConfig: workerPool.config.workerConfig },
+      providerData: { staticSecret, clientCertificate, workerConfig: workerPool.config.workerConfig },
     };
 
     let worker;
@@ -35,7 +53,9 @@ export class StaticProvider extends Provider {
         throw err;
       }
       const existing = await Worker.get(this.db, { workerPoolId, workerGroup, workerId });
-      if (existing.providerId !== providerId || existing.providerData.staticSecret !== staticSecret) {
+      if (existing.providerId !== providerId ||
+        existing.providerData.staticSecret !== staticSecret ||
+        existing.providerData.clientCertificate !== clientCertificate) {
         throw new ApiError('worker already exists');
       }
       worker = existing;
@@ -45,12 +65,17 @@ export class StaticProvider extends Provider {
   }
 
   async updateWorker({ workerPool, worker, input }) {
+    const { staticSecret, clientCertificate } = input.providerInfo;
+    if (clientCertificate) {
+      parseCertificate(clientCertificate);
+    }
     await worker.update(this.db, worker => {
       worker.expires = input.expires;
       worker.capacity = input.capacity;
       worker.providerData = {
         ...worker.providerData,
-        staticSecret: input.providerInfo.staticSecret,
+        staticSecret,
+        clientCertificate,
       };
     });
     return worker;
@@ -86,6 +111,10 @@ export class StaticProvider extends Provider {
       throw new ApiError('bad staticSecret in workerIdentityProof');
     }
 
+    if (worker.providerData.clientCertificate) {
+      this.verifySignature({ worker, workerIdentityProof });
+    }
+
     let expires;
     const { reregistrationTimeout } = Provider.interpretLifecycle(workerPool.config);
     if (reregistrationTimeout) {
@@ -99,4 +128,38 @@ export class StaticProvider extends Provider {
       workerConfig,
     };
   }
+
+  // Verify that the workerIdentityProof is signed with the private key of the
+  // worker's clientCertificate, so that the staticSecret alone is not
+  // sufficient to register the worker
+  verifySignature({ worker, workerIdentityProof }) {
+    const { timestamp, signature } = workerIdentityProof;
+    if (!timestamp || !signature) {
+      throw new ApiError('missing timestamp or signature in workerIdentityProof');
+    }
+
+    const signed = new Date(timestamp);
+    if (Math.abs(Date.now() - signed.getTime()) > SIGNATURE_TIMESTAMP_TOLERANCE) {
+      throw new ApiError('timestamp in workerIdentityProof is not within 10 minutes of the current time');
+    }
+
+    const certificate = new crypto.X509Certificate(worker.providerData.clientCertificate);
+    if (signed < new Date(certificate.validFrom) || signed > new Date(certificate.validTo)) {
+      throw new ApiError('clientCertificate is not valid at the timestamp in workerIdentityProof');
+    }
+
+    const { publicKey } = certificate;
+    // Ed25519 signatures do not use a separate digest
+    const algorithm = publicKey.asymmetricKeyType === 'ed25519' ? null : 'sha256';
+    const message = `${worker.workerPoolId}/${worker.workerGroup}/${worker.workerId}/${timestamp}`;
+    let valid;
+    try {
+      valid = crypto.verify(algorithm, Buffer.from(message), publicKey, Buffer.from(signature, 'base64'));
+    } catch (err) {
+      valid = false;
+    }
+    if (!valid) {
+      throw new ApiError('bad signature in workerIdentityProof');
+    }
+  }
 }
@@ -1318,6 +1318,28 @@ helper.secrets.mockSuite(testing.suiteName(), [], function (mock, skipping) {
         `worker/${providerId}/${workerPoolId}/${workerGroup}/${workerId}`);
     });
 
+    test('startup timings are logged', async function () {
+      await createWorkerPool({});
+      await createWorker({});
+      const monitor = await helper.load('monitor');
+      monitor.manager.reset();
+
+      await helper.workerManager.registerWorker({
+        ...defaultRegisterWorker,
+        startupTimings: { bootSecs: 12.5, configureSecs: 3 },
+      });
+
+      const logged = monitor.manager.messages.filter(({ Type }) => Type === 'worker-startup');
+      assert.equal(logged.length, 1);
+      assert.equal(logged[0].Fields.workerPoolId, workerPoolId);
+      assert.equal(logged[0].Fields.workerGroup, workerGroup);
+      assert.equal(logged[0].Fields.workerId, workerId);
+      assert.equal(logged[0].Fields.bootSecs, 12.5);
+      assert.equal(logged[0].Fields.configureSecs, 3);
+      assert(logged[0].Fields.provisioningSecs >= 0);
+      monitor.manager.reset();
+    });
+
     test('[Integration] Successful registering an AWS worker', async function () {
       const __dirname = new URL('.', import.meta.url).pathname;
       const awsProviderId = 'aws';
@@ -1,10 +1,16 @@
 import taskcluster from 'taskcluster-client';
 import assert from 'assert';
+import crypto from 'crypto';
+import fs from 'fs';
+import path from 'path';
 import helper from './helper.js';
 import { StaticProvider } from '../src/providers/static.js';
 import testing from 'taskcluster-lib-testing';
 import { WorkerPool, Worker } from '../src/data.js';
 
+const __dirname = new URL('.', import.meta.url).pathname;
+const fixture = name => fs.readFileSync(path.resolve(__dirname, 'fixtures', name)).toString();
+
 helper.secrets.mockSuite(testing.suiteName(), [], function(mock, skipping) {
   helper.withDb(mock, skipping);
   helper.withPulse(mock, skipping);
@@ -92,6 +98,42 @@ helper.secrets.mockSuite(testing.suiteName(), [], function(mock, skipping) {
     ]);
   });
 
+  test('updateWorker updates clientCertificate', async function() {
+    let worker = Worker.fromApi(defaultWorker);
+    await worker.create(helper.db);
+
+    const clientCertificate = fixture('static_client_cert.pem');
+    worker = await provider.updateWorker({
+      workerPool,
+      worker,
+      input: {
+        expires: taskcluster.fromNow('1 day'),
+        capacity: 1,
+        providerInfo: { staticSecret: 'good', clientCertificate },
+      },
+    });
+
+    const rows = await helper.db.fns.get_worker_2(workerPoolId, workerGroup, workerId);
+    assert.deepEqual(rows.map(r => [r.worker_id, r.provider_data]), [
+      ['abc123', { staticSecret: 'good', clientCertificate }],
+    ]);
+  });
+
+  test('createWorker rejects an invalid clientCertificate', async function() {
+    await assert.rejects(() =>
+      provider.createWorker({
+        workerPool,
+        workerGroup,
+        workerId,
+        input: {
+          expires: taskcluster.fromNow('1 day'),
+          capacity: 1,
+          providerInfo: { staticSecret: 'good', clientCertificate: 'not a certificate' },
+        },
+      }),
+    /invalid clientCertificate/);
+  });
+
   test('removeWorker marks the worker as stopped', async function() {
     const worker = Worker.fromApi(defaultWorker);
     await worker.create(helper.db);
@@ -136,6 +178,66 @@ helper.secrets.mockSuite(testing.suiteName(), [], function(mock, skipping) {
       /bad staticSecret/);
     });
 
+    suite('with clientCertificate', function() {
+      const clientCertificate = fixture('static_client_cert.pem');
+      const clientKey = fixture('static_client_key.pem');
+
+      const sign = (timestamp, key = clientKey) => crypto.sign(
+        'sha256', Buffer.from(`${workerPoolId}/${workerGroup}/${workerId}/${timestamp}`), key).toString('base64');
+
+      const createCertWorker = (certificate = clientCertificate) => createWorker({
+        state: 'running',
+        providerData: { staticSecret: 'good', clientCertificate: certificate },
+      });
+
+      test('missing signature', async function() {
+        const worker = await createCertWorker();
+        const workerIdentityProof = { staticSecret: 'good' };
+        await assert.rejects(() =>
+          provider.registerWorker({ workerPool, worker, workerIdentityProof }),
+        /missing timestamp or signature/);
+      });
+
+      test('old timestamp', async function() {
+        const worker = await createCertWorker();
+        const timestamp = taskcluster.fromNow('-1 hour').toJSON();
+        const workerIdentityProof = { staticSecret: 'good', timestamp, signature: sign(timestamp) };
+        await assert.rejects(() =>
+          provider.registerWorker({ workerPool, worker, workerIdentityProof }),
+        /timestamp in workerIdentityProof is not within 10 minutes/);
+      });
+
+      test('signature for a different timestamp', async function() {
+        const worker = await createCertWorker();
+        const timestamp = new Date().toJSON();
+        const workerIdentityProof = {
+          staticSecret: 'good',
+          timestamp,
+          signature: sign(taskcluster.fromNow('-1 minute').toJSON()),
+        };
+        await assert.rejects(() =>
+          provider.registerWorker({ workerPool, worker, workerIdentityProof }),
+        /bad signature/);
+      });
+
+      test('signature for a different certificate', async function() {
+        const worker = await createCertWorker(fixture('static_client_cert_other.pem'));
+        const timestamp = new Date().toJSON();
+        const workerIdentityProof = { staticSecret: 'good', timestamp, signature: sign(timestamp) };
+        await assert.rejects(() =>
+          provider.registerWorker({ workerPool, worker, workerIdentityProof }),
+        /bad signature/);
+      });
+
+      test('successful registration', async function() {
+        const worker = await createCertWorker();
+        const timestamp = new Date().toJSON();
+        const workerIdentityProof = { staticSecret: 'good', timestamp, signature: sign(timestamp) };
+        const res = await provider.registerWorker({ workerPool, worker, workerIdentityProof });
+        assert(res.expires > new Date());
+      });
+    });
+
     test('successful registration', async function() {
       const worker = await createWorker({
         state: 'running',
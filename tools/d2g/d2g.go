@@ -412,10 +412,19 @@ func setOSGroups(dwPayload *dockerworker.DockerWorkerPayload, gwPayload *generic
 	}
 }
 
+// writableDirectoryCaches converts Docker Worker caches into Generic Worker
+// writable directory caches. Cache names are sorted so that the task directory
+// each cache is mounted at is stable across conversions of the same payload.
+// Docker Worker payloads have no read-only mounts, so caches are the only
+// volumes mounted into the container.
 func writableDirectoryCaches(caches map[string]string) []genericworker.WritableDirectoryCache {
-	wdcs := make([]genericworker.WritableDirectoryCache, len(caches))
-	i := 0
+	cacheNames := make([]string, 0, len(caches))
 	for cacheName := range caches {
+		cacheNames = append(cacheNames, cacheName)
+	}
+	sort.Strings(cacheNames)
+	wdcs := make([]genericworker.WritableDirectoryCache, len(cacheNames))
+	for i, cacheName := range cacheNames {
 		wdc := new(genericworker.WritableDirectoryCache)
 		defaults.SetDefaults(wdc)
 
@@ -423,7 +432,6 @@ func writableDirectoryCaches(caches map[string]string) []genericworker.WritableD
 		wdc.Directory = "cache" + strconv.Itoa(i)
 
 		wdcs[i] = *wdc
-		i++
 	}
 	return wdcs
 }
@@ -352,6 +352,38 @@ type (
 		// Since: generic-worker 5.3.0
 		ChainOfTrust bool `json:"chainOfTrust,omitempty"`
 
+		// Installs the certificates listed in `task.payload.codeSigningCertificates`
+		// into the platform keystore for the duration of the task, and removes
+		// them again when the task completes.
+		//
+		// On macOS, the certificates are imported into a temporary keychain whose
+		// path is provided to task commands in environment variable
+		// `TASKCLUSTER_CODESIGNING_KEYCHAIN`. On Windows, they are imported into
+		// the personal certificate store (`Cert:\CurrentUser\My`) of the task
+		// user.
+		//
+		// Requires scope
+		// `generic-worker:code-signing:<provisionerId>/<workerType>`.
+		//
+		// This feature is only available on macOS and Windows.
+		//
+		// Since: generic-worker 60.4.0
+		CodeSigning bool `json:"codeSigning,omitempty"`
+
+		// Starts a headless display server for the task, configured by
+		// `task.payload.display`, and stops it when the task completes. For an
+		// X11 display (Xvfb), environment variable `DISPLAY` is set for task
+		// commands; for a Wayland display (Weston with its headless backend),
+		// environment variable `WAYLAND_DISPLAY` is set. Tasks therefore do not
+		// need to start a display server themselves (e.g. with `xvfb-run`).
+		//
+		// This feature is only available on Linux and FreeBSD. If a task is
+		// submitted with this feature enabled on macOS, the task will resolve as
+		// `exception/malformed-payload`.
+		//
+		// Since: generic-worker 60.4.0
+		Display bool `json:"display,omitempty"`
+
 		// This allows you to interactively run commands from within the worker
 		// as the task user. This may be useful for debugging purposes.
 		// Can be used for SSH-like access to the running worker.
@@ -365,6 +397,35 @@ type (
 		// Since: generic-worker 49.2.0
 		Interactive bool `json:"interactive,omitempty"`
 
+		// Creates a new, unlocked keychain for the task, and imports into it the
+		// certificates listed in `task.payload.keychainCertificates`. The path of
+		// the keychain is provided to task commands in environment variable
+		// `TASKCLUSTER_KEYCHAIN` (e.g. for `codesign --keychain
+		// "${TASKCLUSTER_KEYCHAIN}"`). The keychain is deleted when the task
+		// completes.
+		//
+		// This feature is only available on macOS.
+		//
+		// Since: generic-worker 60.4.0
+		Keychain bool `json:"keychain,omitempty"`
+
+		// Grants the task user read/write access to `/dev/kvm` for the duration
+		// of the task, so that hardware accelerated virtual machines (such as the
+		// Android emulator) can be run. With the multiuser engine, the task user
+		// is added to the group that owns `/dev/kvm`, which is made group
+		// readable and writable if needed; the group membership and the original
+		// device permissions are restored when the task completes. With the simple
+		// engine, the worker user must already have access to `/dev/kvm`.
+		//
+		// Requires scope `generic-worker:kvm:<provisionerId>/<workerType>`.
+		//
+		// This feature is only available on Linux. If a task is submitted with
+		// this feature enabled on a non-Linux, posix platform (FreeBSD, macOS),
+		// the task will resolve as `exception/malformed-payload`.
+		//
+		// Since: generic-worker 60.4.0
+		KVM bool `json:"kvm,omitempty"`
+
 		// The live log feature streams the combined stderr and stdout to a task artifact
 		// so that the output is available while the task is running.
 		//
@@ -385,6 +446,9 @@ type (
 		// default value (`16`) conflicts with another
 		// audio device on the worker.
 		//
+		// Multiple devices, and their parameters, may be requested
+		// with `task.payload.loopbackAudioDevices`.
+		//
 		// This feature is only available on Linux. If a task
 		// is submitted with this feature enabled on a non-Linux,
 		// posix platform (FreeBSD, macOS), the task will resolve as
@@ -404,6 +468,9 @@ type (
 		// scanned in order to determine the correct location.
 		// Tasks should not assume a constant value.
 		//
+		// Multiple devices, and their parameters, may be requested
+		// with `task.payload.loopbackVideoDevices`.
+		//
 		// This feature is only available on Linux. If a task
 		// is submitted with this feature enabled on a non-Linux,
 		// posix platform (FreeBSD, macOS), the task will resolve as
@@ -418,6 +485,29 @@ type (
 		//
 		// Since: generic-worker 10.6.0
 		TaskclusterProxy bool `json:"taskclusterProxy,omitempty"`
+
+		// Boots a fresh virtual machine for the task from the image described
+		// in `task.payload.virtualMachine`, runs each task command inside it,
+		// and discards the virtual machine when the task completes. The task
+		// directory is shared with the virtual machine, so mounts, caches and
+		// artifacts behave as they do for commands that run directly on the
+		// worker.
+		//
+		// On macOS, virtual machines are run using Apple's
+		// Virtualization.framework (via [tart](https://tart.run/)). On Linux,
+		// virtual machines are run using QEMU with KVM acceleration, the task
+		// directory is shared using virtio-fs, and the serial console of the
+		// virtual machine is written to the task log. This feature is not
+		// available on FreeBSD.
+		//
+		// The worker must have config setting `enableVirtualMachines` set to
+		// `true`.
+		//
+		// Requires scope
+		// `generic-worker:virtual-machine:<provisionerId>/<workerType>`.
+		//
+		// Since: generic-worker 60.4.0
+		VirtualMachine bool `json:"virtualMachine,omitempty"`
 	}
 
 	FileMount struct {
@@ -457,6 +547,14 @@ type (
 		// Since: generic-worker 1.0.0
 		Artifacts []Artifact `json:"artifacts,omitempty"`
 
+		// Certificates to install when `task.payload.features.codeSigning` is
+		// `true`. Each certificate is read from the secrets service, using the
+		// task credentials, so the task requires scope `secrets:get:<secret>` for
+		// each secret.
+		//
+		// Since: generic-worker 60.4.0
+		CodeSigningCertificates []SecretCertificate `json:"codeSigningCertificates,omitempty"`
+
 		// One array per command (each command is an array of arguments). Several arrays
 		// for several commands.
 		//
@@ -466,6 +564,12 @@ type (
 		// Array items:
 		Command [][]string `json:"command"`
 
+		// Settings of the headless display started when
+		// `task.payload.features.display` is `true`.
+		//
+		// Since: generic-worker 60.4.0
+		Display *HeadlessDisplay `json:"display,omitempty"`
+
 		// Env vars must be string to __string__ mappings (not number or boolean). For example:
 		// ```
 		// {
@@ -510,11 +614,64 @@ type (
 		// Since: generic-worker 5.3.0
 		Features FeatureFlags `json:"features,omitempty"`
 
+		// The number of NVIDIA GPUs to lease to the task. Where a GPU is
+		// partitioned into MIG (Multi-Instance GPU) slices, each slice counts as
+		// one GPU. Leased GPUs are not leased to any other task until the task
+		// completes, including tasks of other workers on the same host. The UUIDs
+		// of the leased GPUs are passed to the task in environment variables
+		// `CUDA_VISIBLE_DEVICES` and `NVIDIA_VISIBLE_DEVICES`. If the GPUs are not
+		// immediately available, the worker waits for them for up to
+		// `task.payload.maxRunTime` seconds.
+		//
+		// Requires scope `generic-worker:gpu:<provisionerId>/<workerType>`, and
+		// the worker config setting `enableGPUs` to be `true`.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Mininum:    0
+		// Maximum:    64
+		Gpus int64 `json:"gpus,omitempty"`
+
+		// Certificates to import into the temporary task keychain, when
+		// `task.payload.features.keychain` is `true`. Each certificate is read
+		// from the secrets service, using the task credentials, so the task
+		// requires scope `secrets:get:<secret>` for each secret.
+		//
+		// Since: generic-worker 60.4.0
+		KeychainCertificates []SecretCertificate `json:"keychainCertificates,omitempty"`
+
 		// Configuration for task logs.
 		//
 		// Since: generic-worker 48.2.0
 		Logs Logs `json:"logs,omitempty"`
 
+		// The loopback audio devices to create, when
+		// `task.payload.features.loopbackAudio` is `true`. Each device is a
+		// separate snd-aloop sound card, numbered consecutively from the
+		// Generic Worker config setting `loopbackAudioDeviceNumber`. The ALSA
+		// name of the `<I>`th device (e.g. `hw:16`) is passed to the task via
+		// environment variable `TASKCLUSTER_AUDIO_DEVICE_<I>`, and the name of
+		// the first device via `TASKCLUSTER_AUDIO_DEVICE`. If not specified, a
+		// single device with default settings is created.
+		//
+		// The number of channels is not fixed by snd-aloop; it is negotiated
+		// by the first client to open each substream, and may be up to 32.
+		//
+		// Since: generic-worker 60.4.0
+		LoopbackAudioDevices []LoopbackAudioDevice `json:"loopbackAudioDevices,omitempty"`
+
+		// The loopback video devices to create, when
+		// `task.payload.features.loopbackVideo` is `true`. The devices are
+		// numbered consecutively from the Generic Worker config setting
+		// `loopbackVideoDeviceNumber`. The location of the `<I>`th device
+		// (e.g. `/dev/video0`) is passed to the task via environment variable
+		// `TASKCLUSTER_VIDEO_DEVICE_<I>`, and the location of the first device
+		// via `TASKCLUSTER_VIDEO_DEVICE`. If not specified, a single device with
+		// default settings is created.
+		//
+		// Since: generic-worker 60.4.0
+		LoopbackVideoDevices []LoopbackVideoDevice `json:"loopbackVideoDevices,omitempty"`
+
 		// Maximum time the task container can run in seconds.
 		// The maximum value for `maxRunTime` is set by a `maxTaskRunTime` config property specific to each worker-pool.
 		//
@@ -550,6 +707,76 @@ type (
 
 		// This property is allowed for backward compatibility, but is unused.
 		SupersederURL string `json:"supersederUrl,omitempty"`
+
+		// USB devices attached to the worker that the task requires access to.
+		// For each entry, the first matching device not already granted to the
+		// task is made readable and writable by the task user for the duration
+		// of the task, and its device node (e.g. `/dev/bus/usb/001/004`) is
+		// passed to the task in environment variable `TASKCLUSTER_USB_DEVICE_<I>`,
+		// where `<I>` is the index of the entry. When the task completes, access
+		// is revoked and the device is reset.
+		//
+		// Only devices whose vendor and product IDs are listed in the worker
+		// config setting `allowedUSBDevices` may be requested. Each entry
+		// requires scope
+		// `generic-worker:usb-device:<provisionerId>/<workerType>/<vendorId>:<productId>`.
+		//
+		// This property is only supported on Linux.
+		//
+		// Since: generic-worker 60.4.0
+		UsbDevices []USBDevice `json:"usbDevices,omitempty"`
+
+		// Configuration for the virtual machine that task commands run in, when
+		// `task.payload.features.virtualMachine` is `true`.
+		//
+		// Since: generic-worker 60.4.0
+		VirtualMachine *VirtualMachine `json:"virtualMachine,omitempty"`
+	}
+
+	// Settings of the headless display started when
+	// `task.payload.features.display` is `true`.
+	//
+	// Since: generic-worker 60.4.0
+	HeadlessDisplay struct {
+
+		// The colour depth of the display, in bits per pixel. Only applies
+		// to `xvfb`. If not specified, `24` is used.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Possible values:
+		//   * 8
+		//   * 16
+		//   * 24
+		Depth int64 `json:"depth,omitempty"`
+
+		// The height of the display, in pixels. If not specified, `1080` is
+		// used.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Mininum:    1
+		// Maximum:    16384
+		Height int64 `json:"height,omitempty"`
+
+		// The display server to run: `xvfb` for an X11 display, or `weston`
+		// for a Wayland display. If not specified, `xvfb` is used.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Possible values:
+		//   * "xvfb"
+		//   * "weston"
+		Server string `json:"server,omitempty"`
+
+		// The width of the display, in pixels. If not specified, `1920` is
+		// used.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Mininum:    1
+		// Maximum:    16384
+		Width int64 `json:"width,omitempty"`
 	}
 
 	// Content originating from a task artifact that has been indexed by the Taskcluster Index Service.
@@ -597,6 +824,66 @@ type (
 		Live string `json:"live" default:"public/logs/live.log"`
 	}
 
+	LoopbackAudioDevice struct {
+
+		// The number of PCM substreams of the device (snd-aloop
+		// `pcm_substreams` parameter), i.e. the number of independent
+		// streams that can be looped back at the same time.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Default:    8
+		// Mininum:    1
+		// Maximum:    8
+		Substreams int64 `json:"substreams,omitempty"`
+	}
+
+	LoopbackVideoDevice struct {
+
+		// Whether the device only reports the capture capability once a
+		// producer has started writing to it (v4l2loopback `exclusive_caps`
+		// parameter). Some applications, such as web browsers, require
+		// this in order to detect the device as a camera.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Default:    false
+		ExclusiveCaps bool `json:"exclusiveCaps" default:"false"`
+
+		// The name of the device reported to applications (v4l2loopback
+		// `card_label` parameter).
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Syntax:     ^[^,]*$
+		// Max length: 31
+		Label string `json:"label,omitempty"`
+
+		// The maximum frame height, in pixels, that the device supports.
+		// Since v4l2loopback applies a single limit to all devices, the
+		// largest value requested for any device is used (v4l2loopback
+		// `max_height` parameter). If not specified, the v4l2loopback
+		// default is used.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Mininum:    1
+		// Maximum:    8192
+		MaxHeight int64 `json:"maxHeight,omitempty"`
+
+		// The maximum frame width, in pixels, that the device supports.
+		// Since v4l2loopback applies a single limit to all devices, the
+		// largest value requested for any device is used (v4l2loopback
+		// `max_width` parameter). If not specified, the v4l2loopback
+		// default is used.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Mininum:    1
+		// Maximum:    8192
+		MaxWidth int64 `json:"maxWidth,omitempty"`
+	}
+
 	// Image to use for the task.  Images can be specified as an image tag as used by a docker registry, or as an object declaring type and name/namespace
 	NamedDockerImage struct {
 		Name string `json:"name"`
@@ -654,6 +941,31 @@ type (
 		Format string `json:"format"`
 	}
 
+	// A PKCS #12 certificate bundle (certificate and private key) stored in
+	// the secrets service.
+	//
+	// Since: generic-worker 60.4.0
+	SecretCertificate struct {
+
+		// The property of the secret value that holds the base64 encoded
+		// PKCS #12 bundle. If not specified, `certificate` is used.
+		//
+		// Since: generic-worker 60.4.0
+		CertificateKey string `json:"certificateKey,omitempty"`
+
+		// The property of the secret value that holds the password of the
+		// PKCS #12 bundle. If not specified, `password` is used. If the
+		// property does not exist, the bundle is assumed to have no password.
+		//
+		// Since: generic-worker 60.4.0
+		PasswordKey string `json:"passwordKey,omitempty"`
+
+		// The name of the secret that holds the certificate.
+		//
+		// Since: generic-worker 60.4.0
+		Secret string `json:"secret"`
+	}
+
 	// URL to download content from.
 	//
 	// Since: generic-worker 5.4.0
@@ -672,6 +984,72 @@ type (
 		URL string `json:"url"`
 	}
 
+	USBDevice struct {
+
+		// The USB product ID of the device, as four lowercase hexadecimal
+		// digits (e.g. `4ee7`).
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Syntax:     ^[0-9a-f]{4}$
+		ProductID string `json:"productId"`
+
+		// The serial number of the device. If specified, only the device
+		// with this serial number matches.
+		//
+		// Since: generic-worker 60.4.0
+		SerialNumber string `json:"serialNumber,omitempty"`
+
+		// The USB vendor ID of the device, as four lowercase hexadecimal
+		// digits (e.g. `18d1`).
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Syntax:     ^[0-9a-f]{4}$
+		VendorID string `json:"vendorId"`
+	}
+
+	// Configuration for the virtual machine that task commands run in, when
+	// `task.payload.features.virtualMachine` is `true`.
+	//
+	// Since: generic-worker 60.4.0
+	VirtualMachine struct {
+
+		// The number of virtual CPUs to allocate to the virtual machine. If
+		// not specified, the image default is used (on Linux, 1).
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Mininum:    1
+		Cpus int64 `json:"cpus,omitempty"`
+
+		// The prepared image to boot the virtual machine from. On macOS this
+		// is the name of a local or remote (OCI registry) tart image, such as
+		// `ghcr.io/cirruslabs/macos-sonoma-base:latest`. The image is cloned
+		// for the task, so the image itself is never modified.
+		//
+		// On Linux this is the path, relative to the task directory, of a disk
+		// image file in any format supported by `qemu-img`, typically provided
+		// by a file mount. The virtual machine boots from a copy-on-write
+		// overlay of the image, so the image itself is never modified. The
+		// guest must run an ssh server, and mount virtio-fs tag `task` at
+		// `/mnt/task`.
+		//
+		// Required when `task.payload.features.virtualMachine` is `true`.
+		//
+		// Since: generic-worker 60.4.0
+		Image string `json:"image,omitempty"`
+
+		// The amount of memory, in megabytes, to allocate to the virtual
+		// machine. If not specified, the image default is used (on Linux,
+		// 2048).
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Mininum:    512
+		MemoryMB int64 `json:"memoryMB,omitempty"`
+	}
+
 	WritableDirectoryCache struct {
 
 		// Implies a read/write cache directory volume. A unique name for the
@@ -962,6 +1340,32 @@ func JSONSchema() string {
       "title": "Read Only Directory",
       "type": "object"
     },
+    "secretCertificate": {
+      "additionalProperties": false,
+      "description": "A PKCS #12 certificate bundle (certificate and private key) stored in\nthe secrets service.\n\nSince: generic-worker 60.4.0",
+      "properties": {
+        "certificateKey": {
+          "description": "The property of the secret value that holds the base64 encoded\nPKCS #12 bundle. If not specified, ` + "`" + `certificate` + "`" + ` is used.\n\nSince: generic-worker 60.4.0",
+          "title": "Certificate key",
+          "type": "string"
+        },
+        "passwordKey": {
+          "description": "The property of the secret value that holds the password of the\nPKCS #12 bundle. If not specified, ` + "`" + `password` + "`" + ` is used. If the\nproperty does not exist, the bundle is assumed to have no password.\n\nSince: generic-worker 60.4.0",
+          "title": "Password key",
+          "type": "string"
+        },
+        "secret": {
+          "description": "The name of the secret that holds the certificate.\n\nSince: generic-worker 60.4.0",
+          "title": "Secret name",
+          "type": "string"
+        }
+      },
+      "required": [
+        "secret"
+      ],
+      "title": "Secret certificate",
+      "type": "object"
+    },
     "writableDirectoryCache": {
       "additionalProperties": false,
       "dependencies": {
@@ -1072,6 +1476,15 @@ func JSONSchema() string {
           "type": "array",
           "uniqueItems": true
         },
+        "codeSigningCertificates": {
+          "description": "Certificates to install when ` + "`" + `task.payload.features.codeSigning` + "`" + ` is\n` + "`" + `true` + "`" + `. Each certificate is read from the secrets service, using the\ntask credentials, so the task requires scope ` + "`" + `secrets:get:\u003csecret\u003e` + "`" + ` for\neach secret.\n\nSince: generic-worker 60.4.0",
+          "items": {
+            "$ref": "#/definitions/secretCertificate"
+          },
+          "title": "Code signing certificates",
+          "type": "array",
+          "uniqueItems": false
+        },
         "command": {
           "description": "One array per command (each command is an array of arguments). Several arrays\nfor several commands.\n\nSince: generic-worker 0.0.1",
           "items": {
@@ -1087,6 +1500,49 @@ func JSONSchema() string {
           "type": "array",
           "uniqueItems": false
         },
+        "display": {
+          "additionalProperties": false,
+          "description": "Settings of the headless display started when\n` + "`" + `task.payload.features.display` + "`" + ` is ` + "`" + `true` + "`" + `.\n\nSince: generic-worker 60.4.0",
+          "minProperties": 1,
+          "properties": {
+            "depth": {
+              "description": "The colour depth of the display, in bits per pixel. Only applies\nto ` + "`" + `xvfb` + "`" + `. If not specified, ` + "`" + `24` + "`" + ` is used.\n\nSince: generic-worker 60.4.0",
+              "enum": [
+                8,
+                16,
+                24
+              ],
+              "title": "Colour depth",
+              "type": "integer"
+            },
+            "height": {
+              "description": "The height of the display, in pixels. If not specified, ` + "`" + `1080` + "`" + ` is\nused.\n\nSince: generic-worker 60.4.0",
+              "maximum": 16384,
+              "minimum": 1,
+              "title": "Height",
+              "type": "integer"
+            },
+            "server": {
+              "description": "The display server to run: ` + "`" + `xvfb` + "`" + ` for an X11 display, or ` + "`" + `weston` + "`" + `\nfor a Wayland display. If not specified, ` + "`" + `xvfb` + "`" + ` is used.\n\nSince: generic-worker 60.4.0",
+              "enum": [
+                "xvfb",
+                "weston"
+              ],
+              "title": "Display server",
+              "type": "string"
+            },
+            "width": {
+              "description": "The width of the display, in pixels. If not specified, ` + "`" + `1920` + "`" + ` is\nused.\n\nSince: generic-worker 60.4.0",
+              "maximum": 16384,
+              "minimum": 1,
+              "title": "Width",
+              "type": "integer"
+            }
+          },
+          "required": [],
+          "title": "Headless display",
+          "type": "object"
+        },
         "env": {
           "additionalProperties": {
             "type": "string"
@@ -1110,11 +1566,31 @@ func JSONSchema() string {
               "title": "Enable generation of signed Chain of Trust artifacts",
               "type": "boolean"
             },
+            "codeSigning": {
+              "description": "Installs the certificates listed in ` + "`" + `task.payload.codeSigningCertificates` + "`" + `\ninto the platform keystore for the duration of the task, and removes\nthem again when the task completes.\n\nOn macOS, the certificates are imported into a temporary keychain whose\npath is provided to task commands in environment variable\n` + "`" + `TASKCLUSTER_CODESIGNING_KEYCHAIN` + "`" + `. On Windows, they are imported into\nthe personal certificate store (` + "`" + `Cert:\\CurrentUser\\My` + "`" + `) of the task\nuser.\n\nRequires scope\n` + "`" + `generic-worker:code-signing:\u003cprovisionerId\u003e/\u003cworkerType\u003e` + "`" + `.\n\nThis feature is only available on macOS and Windows.\n\nSince: generic-worker 60.4.0",
+              "title": "Install code signing certificates",
+              "type": "boolean"
+            },
+            "display": {
+              "description": "Starts a headless display server for the task, configured by\n` + "`" + `task.payload.display` + "`" + `, and stops it when the task completes. For an\nX11 display (Xvfb), environment variable ` + "`" + `DISPLAY` + "`" + ` is set for task\ncommands; for a Wayland display (Weston with its headless backend),\nenvironment variable ` + "`" + `WAYLAND_DISPLAY` + "`" + ` is set. Tasks therefore do not\nneed to start a display server themselves (e.g. with ` + "`" + `xvfb-run` + "`" + `).\n\nThis feature is only available on Linux and FreeBSD. If a task is\nsubmitted with this feature enabled on macOS, the task will resolve as\n` + "`" + `exception/malformed-payload` + "`" + `.\n\nSince: generic-worker 60.4.0",
+              "title": "Provide a headless display",
+              "type": "boolean"
+            },
             "interactive": {
               "description": "This allows you to interactively run commands from within the worker\nas the task user. This may be useful for debugging purposes.\nCan be used for SSH-like access to the running worker.\nNote that this feature works differently from the ` + "`" + `interactive` + "`" + ` feature\nin docker worker, which ` + "`" + `docker exec` + "`" + `s into the running container.\nSince tasks on generic worker are not guaranteed to be running in a\ncontainer, a bash shell is started on the task user's account.\nA user can then ` + "`" + `docker exec` + "`" + ` into the a running container, if there\nis one.\n\nSince: generic-worker 49.2.0",
               "title": "Interactive shell",
               "type": "boolean"
             },
+            "keychain": {
+              "description": "Creates a new, unlocked keychain for the task, and imports into it the\ncertificates listed in ` + "`" + `task.payload.keychainCertificates` + "`" + `. The path of\nthe keychain is provided to task commands in environment variable\n` + "`" + `TASKCLUSTER_KEYCHAIN` + "`" + ` (e.g. for ` + "`" + `codesign --keychain\n\"${TASKCLUSTER_KEYCHAIN}\"` + "`" + `). The keychain is deleted when the task\ncompletes.\n\nThis feature is only available on macOS.\n\nSince: generic-worker 60.4.0",
+              "title": "Create a temporary keychain for the task",
+              "type": "boolean"
+            },
+            "kvm": {
+              "description": "Grants the task user read/write access to ` + "`" + `/dev/kvm` + "`" + ` for the duration\nof the task, so that hardware accelerated virtual machines (such as the\nAndroid emulator) can be run. With the multiuser engine, the task user\nis added to the group that owns ` + "`" + `/dev/kvm` + "`" + `, which is made group\nreadable and writable if needed; the group membership and the original\ndevice permissions are restored when the task completes. With the simple\nengine, the worker user must already have access to ` + "`" + `/dev/kvm` + "`" + `.\n\nRequires scope ` + "`" + `generic-worker:kvm:\u003cprovisionerId\u003e/\u003cworkerType\u003e` + "`" + `.\n\nThis feature is only available on Linux. If a task is submitted with\nthis feature enabled on a non-Linux, posix platform (FreeBSD, macOS),\nthe task will resolve as ` + "`" + `exception/malformed-payload` + "`" + `.\n\nSince: generic-worker 60.4.0",
+              "title": "Grant access to /dev/kvm",
+              "type": "boolean"
+            },
             "liveLog": {
               "default": true,
               "description": "The live log feature streams the combined stderr and stdout to a task artifact\nso that the output is available while the task is running.\n\nSince: generic-worker 48.2.0",
@@ -1122,12 +1598,12 @@ func JSONSchema() string {
               "type": "boolean"
             },
             "loopbackAudio": {
-              "description": "Audio loopback device created using snd-aloop.\nAn audio device will be available for the task. Its\nlocation will be ` + "`" + `/dev/snd` + "`" + `. Devices inside that directory\nwill take the form ` + "`" + `/dev/snd/controlC\u003cN\u003e` + "`" + `,\n` + "`" + `/dev/snd/pcmC\u003cN\u003eD0c` + "`" + `, ` + "`" + `/dev/snd/pcmC\u003cN\u003eD0p` + "`" + `,\n` + "`" + `/dev/snd/pcmC\u003cN\u003eD1c` + "`" + `, and ` + "`" + `/dev/snd/pcmC\u003cN\u003eD1p` + "`" + `,\nwhere \u003cN\u003e is an integer between 0 and 31, inclusive.\nThe Generic Worker config setting ` + "`" + `loopbackAudioDeviceNumber` + "`" + `\nmay be used to change the device number in case the\ndefault value (` + "`" + `16` + "`" + `) conflicts with another\naudio device on the worker.\n\nThis feature is only available on Linux. If a task\nis submitted with this feature enabled on a non-Linux,\nposix platform (FreeBSD, macOS), the task will resolve as\n` + "`" + `exception/malformed-payload` + "`" + `.\n\nSince: generic-worker 54.5.0",
+              "description": "Audio loopback device created using snd-aloop.\nAn audio device will be available for the task. Its\nlocation will be ` + "`" + `/dev/snd` + "`" + `. Devices inside that directory\nwill take the form ` + "`" + `/dev/snd/controlC\u003cN\u003e` + "`" + `,\n` + "`" + `/dev/snd/pcmC\u003cN\u003eD0c` + "`" + `, ` + "`" + `/dev/snd/pcmC\u003cN\u003eD0p` + "`" + `,\n` + "`" + `/dev/snd/pcmC\u003cN\u003eD1c` + "`" + `, and ` + "`" + `/dev/snd/pcmC\u003cN\u003eD1p` + "`" + `,\nwhere \u003cN\u003e is an integer between 0 and 31, inclusive.\nThe Generic Worker config setting ` + "`" + `loopbackAudioDeviceNumber` + "`" + `\nmay be used to change the device number in case the\ndefault value (` + "`" + `16` + "`" + `) conflicts with another\naudio device on the worker.\n\nMultiple devices, and their parameters, may be requested\nwith ` + "`" + `task.payload.loopbackAudioDevices` + "`" + `.\n\nThis feature is only available on Linux. If a task\nis submitted with this feature enabled on a non-Linux,\nposix platform (FreeBSD, macOS), the task will resolve as\n` + "`" + `exception/malformed-payload` + "`" + `.\n\nSince: generic-worker 54.5.0",
               "title": "Loopback Audio device",
               "type": "boolean"
             },
             "loopbackVideo": {
-              "description": "Video loopback device created using v4l2loopback.\nA video device will be available for the task. Its\nlocation will be passed to the task via environment\nvariable ` + "`" + `TASKCLUSTER_VIDEO_DEVICE` + "`" + `. The\nlocation will be ` + "`" + `/dev/video\u003cN\u003e` + "`" + ` where ` + "`" + `\u003cN\u003e` + "`" + ` is\nan integer between 0 and 255. The value of ` + "`" + `\u003cN\u003e` + "`" + `\nis not static, and therefore either the environment\nvariable should be used, or ` + "`" + `/dev` + "`" + ` should be\nscanned in order to determine the correct location.\nTasks should not assume a constant value.\n\nThis feature is only available on Linux. If a task\nis submitted with this feature enabled on a non-Linux,\nposix platform (FreeBSD, macOS), the task will resolve as\n` + "`" + `exception/malformed-payload` + "`" + `.\n\nSince: generic-worker 53.1.0",
+              "description": "Video loopback device created using v4l2loopback.\nA video device will be available for the task. Its\nlocation will be passed to the task via environment\nvariable ` + "`" + `TASKCLUSTER_VIDEO_DEVICE` + "`" + `. The\nlocation will be ` + "`" + `/dev/video\u003cN\u003e` + "`" + ` where ` + "`" + `\u003cN\u003e` + "`" + ` is\nan integer between 0 and 255. The value of ` + "`" + `\u003cN\u003e` + "`" + `\nis not static, and therefore either the environment\nvariable should be used, or ` + "`" + `/dev` + "`" + ` should be\nscanned in order to determine the correct location.\nTasks should not assume a constant value.\n\nMultiple devices, and their parameters, may be requested\nwith ` + "`" + `task.payload.loopbackVideoDevices` + "`" + `.\n\nThis feature is only available on Linux. If a task\nis submitted with this feature enabled on a non-Linux,\nposix platform (FreeBSD, macOS), the task will resolve as\n` + "`" + `exception/malformed-payload` + "`" + `.\n\nSince: generic-worker 53.1.0",
               "title": "Loopback Video device",
               "type": "boolean"
             },
@@ -1135,12 +1611,33 @@ func JSONSchema() string {
               "description": "The taskcluster proxy provides an easy and safe way to make authenticated\ntaskcluster requests within the scope(s) of a particular task. See\n[the github project](https://github.com/taskcluster/taskcluster/tree/main/tools/taskcluster-proxy) for more information.\n\nSince: generic-worker 10.6.0",
               "title": "Run [taskcluster-proxy](https://github.com/taskcluster/taskcluster/tree/main/tools/taskcluster-proxy) to allow tasks to dynamically proxy requests to taskcluster services",
               "type": "boolean"
+            },
+            "virtualMachine": {
+              "description": "Boots a fresh virtual machine for the task from the image described\nin ` + "`" + `task.payload.virtualMachine` + "`" + `, runs each task command inside it,\nand discards the virtual machine when the task completes. The task\ndirectory is shared with the virtual machine, so mounts, caches and\nartifacts behave as they do for commands that run directly on the\nworker.\n\nOn macOS, virtual machines are run using Apple's\nVirtualization.framework (via [tart](https://tart.run/)). On Linux,\nvirtual machines are run using QEMU with KVM acceleration, the task\ndirectory is shared using virtio-fs, and the serial console of the\nvirtual machine is written to the task log. This feature is not\navailable on FreeBSD.\n\nThe worker must have config setting ` + "`" + `enableVirtualMachines` + "`" + ` set to\n` + "`" + `true` + "`" + `.\n\nRequires scope\n` + "`" + `generic-worker:virtual-machine:\u003cprovisionerId\u003e/\u003cworkerType\u003e` + "`" + `.\n\nSince: generic-worker 60.4.0",
+              "title": "Run commands inside an ephemeral virtual machine",
+              "type": "boolean"
             }
           },
           "required": [],
           "title": "Feature flags",
           "type": "object"
         },
+        "gpus": {
+          "description": "The number of NVIDIA GPUs to lease to the task. Where a GPU is\npartitioned into MIG (Multi-Instance GPU) slices, each slice counts as\none GPU. Leased GPUs are not leased to any other task until the task\ncompletes, including tasks of other workers on the same host. The UUIDs\nof the leased GPUs are passed to the task in environment variables\n` + "`" + `CUDA_VISIBLE_DEVICES` + "`" + ` and ` + "`" + `NVIDIA_VISIBLE_DEVICES` + "`" + `. If the GPUs are not\nimmediately available, the worker waits for them for up to\n` + "`" + `task.payload.maxRunTime` + "`" + ` seconds.\n\nRequires scope ` + "`" + `generic-worker:gpu:\u003cprovisionerId\u003e/\u003cworkerType\u003e` + "`" + `, and\nthe worker config setting ` + "`" + `enableGPUs` + "`" + ` to be ` + "`" + `true` + "`" + `.\n\nSince: generic-worker 60.4.0",
+          "maximum": 64,
+          "minimum": 0,
+          "title": "Number of GPUs",
+          "type": "integer"
+        },
+        "keychainCertificates": {
+          "description": "Certificates to import into the temporary task keychain, when\n` + "`" + `task.payload.features.keychain` + "`" + ` is ` + "`" + `true` + "`" + `. Each certificate is read\nfrom the secrets service, using the task credentials, so the task\nrequires scope ` + "`" + `secrets:get:\u003csecret\u003e` + "`" + ` for each secret.\n\nSince: generic-worker 60.4.0",
+          "items": {
+            "$ref": "#/definitions/secretCertificate"
+          },
+          "title": "Keychain certificates",
+          "type": "array",
+          "uniqueItems": false
+        },
         "logs": {
           "additionalProperties": false,
           "description": "Configuration for task logs.\n\nSince: generic-worker 48.2.0",
@@ -1162,6 +1659,71 @@ func JSONSchema() string {
           "title": "Logs",
           "type": "object"
         },
+        "loopbackAudioDevices": {
+          "description": "The loopback audio devices to create, when\n` + "`" + `task.payload.features.loopbackAudio` + "`" + ` is ` + "`" + `true` + "`" + `. Each device is a\nseparate snd-aloop sound card, numbered consecutively from the\nGeneric Worker config setting ` + "`" + `loopbackAudioDeviceNumber` + "`" + `. The ALSA\nname of the ` + "`" + `\u003cI\u003e` + "`" + `th device (e.g. ` + "`" + `hw:16` + "`" + `) is passed to the task via\nenvironment variable ` + "`" + `TASKCLUSTER_AUDIO_DEVICE_\u003cI\u003e` + "`" + `, and the name of\nthe first device via ` + "`" + `TASKCLUSTER_AUDIO_DEVICE` + "`" + `. If not specified, a\nsingle device with default settings is created.\n\nThe number of channels is not fixed by snd-aloop; it is negotiated\nby the first client to open each substream, and may be up to 32.\n\nSince: generic-worker 60.4.0",
+          "items": {
+            "additionalProperties": false,
+            "properties": {
+              "substreams": {
+                "default": 8,
+                "description": "The number of PCM substreams of the device (snd-aloop\n` + "`" + `pcm_substreams` + "`" + ` parameter), i.e. the number of independent\nstreams that can be looped back at the same time.\n\nSince: generic-worker 60.4.0",
+                "maximum": 8,
+                "minimum": 1,
+                "title": "Substreams",
+                "type": "integer"
+              }
+            },
+            "required": [],
+            "title": "Loopback audio device",
+            "type": "object"
+          },
+          "maxItems": 8,
+          "title": "Loopback audio devices",
+          "type": "array",
+          "uniqueItems": false
+        },
+        "loopbackVideoDevices": {
+          "description": "The loopback video devices to create, when\n` + "`" + `task.payload.features.loopbackVideo` + "`" + ` is ` + "`" + `true` + "`" + `. The devices are\nnumbered consecutively from the Generic Worker config setting\n` + "`" + `loopbackVideoDeviceNumber` + "`" + `. The location of the ` + "`" + `\u003cI\u003e` + "`" + `th device\n(e.g. ` + "`" + `/dev/video0` + "`" + `) is passed to the task via environment variable\n` + "`" + `TASKCLUSTER_VIDEO_DEVICE_\u003cI\u003e` + "`" + `, and the location of the first device\nvia ` + "`" + `TASKCLUSTER_VIDEO_DEVICE` + "`" + `. If not specified, a single device with\ndefault settings is created.\n\nSince: generic-worker 60.4.0",
+          "items": {
+            "additionalProperties": false,
+            "properties": {
+              "exclusiveCaps": {
+                "default": false,
+                "description": "Whether the device only reports the capture capability once a\nproducer has started writing to it (v4l2loopback ` + "`" + `exclusive_caps` + "`" + `\nparameter). Some applications, such as web browsers, require\nthis in order to detect the device as a camera.\n\nSince: generic-worker 60.4.0",
+                "title": "Exclusive capabilities",
+                "type": "boolean"
+              },
+              "label": {
+                "description": "The name of the device reported to applications (v4l2loopback\n` + "`" + `card_label` + "`" + ` parameter).\n\nSince: generic-worker 60.4.0",
+                "maxLength": 31,
+                "pattern": "^[^,]*$",
+                "title": "Label",
+                "type": "string"
+              },
+              "maxHeight": {
+                "description": "The maximum frame height, in pixels, that the device supports.\nSince v4l2loopback applies a single limit to all devices, the\nlargest value requested for any device is used (v4l2loopback\n` + "`" + `max_height` + "`" + ` parameter). If not specified, the v4l2loopback\ndefault is used.\n\nSince: generic-worker 60.4.0",
+                "maximum": 8192,
+                "minimum": 1,
+                "title": "Maximum height",
+                "type": "integer"
+              },
+              "maxWidth": {
+                "description": "The maximum frame width, in pixels, that the device supports.\nSince v4l2loopback applies a single limit to all devices, the\nlargest value requested for any device is used (v4l2loopback\n` + "`" + `max_width` + "`" + ` parameter). If not specified, the v4l2loopback\ndefault is used.\n\nSince: generic-worker 60.4.0",
+                "maximum": 8192,
+                "minimum": 1,
+                "title": "Maximum width",
+                "type": "integer"
+              }
+            },
+            "required": [],
+            "title": "Loopback video device",
+            "type": "object"
+          },
+          "maxItems": 8,
+          "title": "Loopback video devices",
+          "type": "array",
+          "uniqueItems": false
+        },
         "maxRunTime": {
           "description": "Maximum time the task container can run in seconds.\nThe maximum value for ` + "`" + `maxRunTime` + "`" + ` is set by a ` + "`" + `maxTaskRunTime` + "`" + ` config property specific to each worker-pool.\n\nSince: generic-worker 0.0.1",
           "minimum": 1,
@@ -1222,6 +1784,67 @@ func JSONSchema() string {
           "description": "This property is allowed for backward compatibility, but is unused.",
           "title": "unused",
           "type": "string"
+        },
+        "usbDevices": {
+          "description": "USB devices attached to the worker that the task requires access to.\nFor each entry, the first matching device not already granted to the\ntask is made readable and writable by the task user for the duration\nof the task, and its device node (e.g. ` + "`" + `/dev/bus/usb/001/004` + "`" + `) is\npassed to the task in environment variable ` + "`" + `TASKCLUSTER_USB_DEVICE_\u003cI\u003e` + "`" + `,\nwhere ` + "`" + `\u003cI\u003e` + "`" + ` is the index of the entry. When the task completes, access\nis revoked and the device is reset.\n\nOnly devices whose vendor and product IDs are listed in the worker\nconfig setting ` + "`" + `allowedUSBDevices` + "`" + ` may be requested. Each entry\nrequires scope\n` + "`" + `generic-worker:usb-device:\u003cprovisionerId\u003e/\u003cworkerType\u003e/\u003cvendorId\u003e:\u003cproductId\u003e` + "`" + `.\n\nThis property is only supported on Linux.\n\nSince: generic-worker 60.4.0",
+          "items": {
+            "additionalProperties": false,
+            "properties": {
+              "productId": {
+                "description": "The USB product ID of the device, as four lowercase hexadecimal\ndigits (e.g. ` + "`" + `4ee7` + "`" + `).\n\nSince: generic-worker 60.4.0",
+                "pattern": "^[0-9a-f]{4}$",
+                "title": "Product ID",
+                "type": "string"
+              },
+              "serialNumber": {
+                "description": "The serial number of the device. If specified, only the device\nwith this serial number matches.\n\nSince: generic-worker 60.4.0",
+                "title": "Serial number",
+                "type": "string"
+              },
+              "vendorId": {
+                "description": "The USB vendor ID of the device, as four lowercase hexadecimal\ndigits (e.g. ` + "`" + `18d1` + "`" + `).\n\nSince: generic-worker 60.4.0",
+                "pattern": "^[0-9a-f]{4}$",
+                "title": "Vendor ID",
+                "type": "string"
+              }
+            },
+            "required": [
+              "vendorId",
+              "productId"
+            ],
+            "title": "USB device",
+            "type": "object"
+          },
+          "title": "USB devices",
+          "type": "array",
+          "uniqueItems": false
+        },
+        "virtualMachine": {
+          "additionalProperties": false,
+          "description": "Configuration for the virtual machine that task commands run in, when\n` + "`" + `task.payload.features.virtualMachine` + "`" + ` is ` + "`" + `true` + "`" + `.\n\nSince: generic-worker 60.4.0",
+          "minProperties": 1,
+          "properties": {
+            "cpus": {
+              "description": "The number of virtual CPUs to allocate to the virtual machine. If\nnot specified, the image default is used (on Linux, 1).\n\nSince: generic-worker 60.4.0",
+              "minimum": 1,
+              "title": "Virtual CPUs",
+              "type": "integer"
+            },
+            "image": {
+              "description": "The prepared image to boot the virtual machine from. On macOS this\nis the name of a local or remote (OCI registry) tart image, such as\n` + "`" + `ghcr.io/cirruslabs/macos-sonoma-base:latest` + "`" + `. The image is cloned\nfor the task, so the image itself is never modified.\n\nOn Linux this is the path, relative to the task directory, of a disk\nimage file in any format supported by ` + "`" + `qemu-img` + "`" + `, typically provided\nby a file mount. The virtual machine boots from a copy-on-write\noverlay of the image, so the image itself is never modified. The\nguest must run an ssh server, and mount virtio-fs tag ` + "`" + `task` + "`" + ` at\n` + "`" + `/mnt/task` + "`" + `.\n\nRequired when ` + "`" + `task.payload.features.virtualMachine` + "`" + ` is ` + "`" + `true` + "`" + `.\n\nSince: generic-worker 60.4.0",
+              "title": "Virtual machine image",
+              "type": "string"
+            },
+            "memoryMB": {
+              "description": "The amount of memory, in megabytes, to allocate to the virtual\nmachine. If not specified, the image default is used (on Linux,\n2048).\n\nSince: generic-worker 60.4.0",
+              "minimum": 512,
+              "title": "Memory (MB)",
+              "type": "integer"
+            }
+          },
+          "required": [],
+          "title": "Virtual machine",
+          "type": "object"
         }
       },
       "required": [
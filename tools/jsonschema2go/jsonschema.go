@@ -1078,6 +1078,11 @@ func (s *Properties) AsStruct(disableNested bool, enableDefaults bool, extraPack
 			jsonStructTagOptions := ""
 			if !s.Properties[j].IsRequired {
 				jsonStructTagOptions = ",omitempty"
+				// omitempty does not omit structs, so an object which may
+				// not be empty is a pointer, to be left out when not given
+				if s.Properties[j].isNonEmptyStruct() {
+					subType = "*" + subType
+				}
 			}
 			defaultStructTag := ""
 			if def := s.Properties[j].Default; enableDefaults && (def != nil) {
@@ -1102,6 +1107,18 @@ func (s *Properties) AsStruct(disableNested bool, enableDefaults bool, extraPack
 	return
 }
 
+// isNonEmptyStruct returns true if the subschema is an object generated as a
+// struct, which must have at least one property.
+func (jsonSubSchema *JsonSubSchema) isNonEmptyStruct() bool {
+	s := jsonSubSchema.TargetSchema()
+	if s.Type == nil || *s.Type != "object" || s.AnyOf != nil || s.AllOf != nil || s.OneOf != nil {
+		return false
+	}
+	ap := s.AdditionalProperties
+	noExtraProperties := ap != nil && ap.Boolean != nil && !*ap.Boolean
+	return noExtraProperties && s.MinProperties != nil && *s.MinProperties > 0
+}
+
 func (jsonSubSchema *JsonSubSchema) getTypeName() string {
 	if jsonSubSchema.Ref != nil {
 		return jsonSubSchema.RefSubSchema.getTypeName()
@@ -12,6 +12,7 @@ import (
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
 	"github.com/taskcluster/taskcluster/v60/internal"
+	"github.com/taskcluster/taskcluster/v60/internal/netconfig"
 )
 
 var (
@@ -39,6 +40,8 @@ task id.
     --client-id <clientId>          Use a specific auth.taskcluster hawk client id [default: ].
     --access-token <accessToken>    Use a specific auth.taskcluster hawk access token [default: ].
     --certificate <certificate>     Use a specific auth.taskcluster hawk certificate [default: ].
+    --ca-file <file>                Trust the PEM-encoded CA certificates in this file, in addition
+                                    to the system's, for requests to the TC deployment [default: ].
 `
 )
 
@@ -108,6 +111,21 @@ func ParseCommandArgs(argv []string, exit bool) (routes Routes, address string,
 	address = ipAddress + ":" + portStr
 	log.Printf("Listening on: %v", address)
 
+	if caFile := arguments["--ca-file"].(string); caFile != "" {
+		var pem []byte
+		pem, err = os.ReadFile(caFile)
+		if err != nil {
+			err = fmt.Errorf("could not read CA certificates: %w", err)
+			return
+		}
+		network := &netconfig.Config{CACertificates: string(pem)}
+		err = network.Apply()
+		if err != nil {
+			return
+		}
+		log.Printf("Trusting additional CA certificates from %v", caFile)
+	}
+
 	rootURL := arguments["--root-url"]
 	if rootURL == nil || rootURL == "" {
 		rootURL = os.Getenv("TASKCLUSTER_ROOT_URL")
@@ -1,8 +1,11 @@
 package client
 
 import (
+	"crypto/tls"
+	"errors"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -36,6 +39,49 @@ type Config struct {
 	// Configuration for retrying connections to the server
 	Retry RetryConfig
 
+	// The proxy to connect to the server through, as an http://, socks5://
+	// or socks5h:// URL, with any credentials for the proxy as its user
+	// info.  Default is to use the proxy given in the environment, if any
+	// (see http.ProxyFromEnvironment).
+	ProxyURL string
+
+	// How long before the token expires the client gets a new one from the
+	// Configurer.  The client reconnects with the new token once no streams
+	// are open.  Tokens valid for less than twice this are refreshed halfway
+	// through their remaining lifetime.  Default is 5 minutes.
+	TokenRefreshMargin time.Duration
+
+	// Path prefixes, such as /shell, that viewers may only access with the
+	// AccessToken, given in the x-websocktunnel-access-token header or the
+	// websocktunnel-access-token query parameter; other paths are public.
+	// Prefixes match whole path segments.  The token is not passed on to the
+	// client.
+	RestrictedPaths []string
+	AccessToken     string
+
+	// How often the client pings the server, how long it waits for a pong
+	// before considering the connection dead, and how long writing to the
+	// connection may take before it is considered dead.  The client then
+	// reconnects, and Accept returns ErrConnectionDead.  Lower values detect
+	// connections dropped silently, such as by a NAT gateway, sooner.  See
+	// wsmux.Config for the defaults.
+	KeepAliveInterval time.Duration
+	PongTimeout       time.Duration
+	WriteTimeout      time.Duration
+
+	// If set, streams returned from Accept are TLS server connections with this
+	// configuration, so that viewers' TLS connections end at the client, and
+	// the websocktunnel service only sees encrypted traffic.  The service then
+	// only accepts websocket connections from viewers, carrying the TLS
+	// connection in their binary messages; see DialTLS.
+	TLSConfig *tls.Config
+
+	// Compress the traffic with the server (permessage-deflate), if the server
+	// supports it, to reduce bandwidth for verbose traffic such as logs.  Data
+	// which appears to be compressed already is sent as is.  Default is no
+	// compression.
+	Compression bool
+
 	// A Logger for logging status updates; default is no logging
 	Logger util.Logger
 
@@ -45,29 +91,55 @@ type Config struct {
 	// of a ConnectHook to ensure that it is done both for initial connections,
 	// and reconnections.
 	ConnectHook func(*Client)
+
+	// Functions called as the client loses its connection to the server and
+	// reconnects, for example to alert on tunnels that keep disconnecting.
+	// OnDisconnect is called with the error that ended the connection.
+	// OnReconnectAttempt is called before each attempt to reconnect, with
+	// the number of the attempt, starting at 1.  OnReconnected is called once
+	// the client has reconnected, with the number of attempts it took and the
+	// time since the connection was lost, before the ConnectHook.  These are
+	// called while the client is reconnecting, so they should not block; see
+	// also Client.Stats.
+	OnDisconnect       func(c *Client, err error)
+	OnReconnectAttempt func(c *Client, attempt int)
+	OnReconnected      func(c *Client, attempts int, latency time.Duration)
 }
 
 // Configurer is a function which can generate a Config object to be used by
 // the client.  This is called whenever a reconnection is made, and should
-// return a Config with a "fresh" token at that time.
+// return a Config with a "fresh" token at that time, and before the current
+// token expires.
 type Configurer func() (Config, error)
 
 // Client is used to connect to a websocktunnel instance and serve content over
 // the tunnel.  Client implements net.Listener.
 type Client struct {
-	m           sync.Mutex
-	id          string
-	tunnelAddr  string
-	token       string
-	url         atomic.Value
-	retry       RetryConfig
-	logger      util.Logger
-	configurer  Configurer
-	session     *wsmux.Session
-	state       clientState
-	closed      chan struct{}
-	acceptErr   net.Error
-	connectHook func(*Client)
+	m          sync.Mutex
+	id         string
+	tunnelAddr string
+	token      string
+	// when token expires; zero if it does not
+	tokenExpires       time.Time
+	tokenRefreshMargin time.Duration
+	proxyURL           string
+	restrictedPaths    []string
+	accessToken        string
+	tlsConfig          *tls.Config
+	sessionConfig      wsmux.Config
+	url                atomic.Value
+	retry              RetryConfig
+	logger             util.Logger
+	configurer         Configurer
+	session            *wsmux.Session
+	state              clientState
+	closed             chan struct{}
+	acceptErr          net.Error
+	connectHook        func(*Client)
+	onDisconnect       func(*Client, error)
+	onReconnectAttempt func(*Client, int)
+	onReconnected      func(*Client, int, time.Duration)
+	stats              stats
 }
 
 // New creates a new Client instance.
@@ -80,15 +152,16 @@ func New(configurer Configurer) (*Client, error) {
 	cl := &Client{configurer: configurer}
 	cl.setConfig(config)
 	cl.closed = make(chan struct{}, 1)
-	conn, url, err := cl.connectWithRetry()
+	conn, url, err := cl.connectWithRetry(nil)
 	if err != nil {
 		return nil, err
 	}
 	cl.url.Store(url)
-	cl.session = wsmux.Client(conn, wsmux.Config{})
+	cl.session = cl.newSession(conn)
 	if cl.connectHook != nil {
 		cl.connectHook(cl)
 	}
+	go cl.refreshTokens()
 	return cl, nil
 }
 
@@ -112,30 +185,62 @@ func (c *Client) Accept() (net.Conn, error) {
 
 	c.m.Lock()
 	defer c.m.Unlock()
-	if c.state == stateBroken || c.state == stateClosed {
-		return nil, c.acceptErr
-	}
+	for {
+		if c.state == stateBroken || c.state == stateClosed {
+			return nil, c.acceptErr
+		}
 
-	// c.session.Accept() will block until c.session.Close() is called
-	// c.session.Close() is called when c.Close() is called, but
-	// c.Close() also locks c.m, so we need to unlock c.m before calling
-	// c.session.Accept() to avoid the deadlock
-	//
-	// The deadlock was first observed in go1.19 due to
-	// https://go-review.googlesource.com/c/go/+/409537.
-	// This started enforcing that listeners are closed before
-	// active connections are cleaned up after a server shutdown.
-	// Prior to this, we unknowingly leaked deadlocked goroutines.
-	c.m.Unlock()
-	stream, err := c.session.Accept()
-	c.m.Lock()
-	if err != nil {
+		// session.Accept() will block until session.Close() is called
+		// c.session.Close() is called when c.Close() is called, but
+		// c.Close() also locks c.m, so we need to unlock c.m before calling
+		// session.Accept() to avoid the deadlock
+		//
+		// The deadlock was first observed in go1.19 due to
+		// https://go-review.googlesource.com/c/go/+/409537.
+		// This started enforcing that listeners are closed before
+		// active connections are cleaned up after a server shutdown.
+		// Prior to this, we unknowingly leaked deadlocked goroutines.
+		session := c.session
+		c.m.Unlock()
+		stream, err := session.Accept()
+		c.m.Lock()
+		if err == nil {
+			if c.tlsConfig != nil {
+				// the handshake happens on the first read or write, so that
+				// a slow viewer does not hold up Accept
+				return tls.Server(stream, c.tlsConfig), nil
+			}
+			return stream, nil
+		}
+		// the session was replaced by one using a new token, so accept
+		// from that one instead
+		if c.isClosed() {
+			return nil, ErrClientClosed
+		}
+		if c.session != session {
+			continue
+		}
 		c.state = stateBroken
 		c.acceptErr = ErrClientReconnecting
-		go c.reconnect()
+		c.stats.disconnects.Add(1)
+		if isDeadConnection(err) {
+			c.logger.Printf("connection to %s is dead: %v", c.tunnelAddr, err)
+			err = ErrConnectionDead
+			go c.reconnect(err)
+			return nil, err
+		}
+		go c.reconnect(err)
 		return nil, c.acceptErr
 	}
-	return stream, nil
+}
+
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
 }
 
 // Addr returns the net.Addr of the underlying wsmux session
@@ -169,6 +274,24 @@ func (c *Client) setConfig(config Config) {
 	c.id = config.ID
 	c.tunnelAddr = util.MakeWsURL(config.TunnelAddr)
 	c.token = config.Token
+	c.proxyURL = config.ProxyURL
+	c.restrictedPaths = config.RestrictedPaths
+	c.accessToken = config.AccessToken
+	c.tlsConfig = config.TLSConfig
+	c.tokenExpires = util.GetTokenExp(config.Token)
+	c.tokenRefreshMargin = config.TokenRefreshMargin
+	if c.tokenRefreshMargin == 0 {
+		c.tokenRefreshMargin = defaultTokenRefreshMargin
+	}
+
+	c.sessionConfig = wsmux.Config{
+		// Log:              c.logger,
+		StreamBufferSize:  4 * 1024,
+		KeepAliveInterval: config.KeepAliveInterval,
+		PongTimeout:       config.PongTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		Compression:       config.Compression,
+	}
 
 	c.retry = config.Retry.withDefaultValues()
 	c.logger = config.Logger
@@ -176,22 +299,37 @@ func (c *Client) setConfig(config Config) {
 		c.logger = &util.NilLogger{}
 	}
 	c.connectHook = config.ConnectHook
+	c.onDisconnect = config.OnDisconnect
+	c.onReconnectAttempt = config.OnReconnectAttempt
+	c.onReconnected = config.OnReconnected
 }
 
-// connectWithRetry returns a websocket connection to the tunnel
-func (c *Client) connectWithRetry() (*websocket.Conn, string, error) {
+// connectWithRetry returns a websocket connection to the tunnel.  If given,
+// onAttempt is called before each attempt to connect.
+func (c *Client) connectWithRetry(onAttempt func()) (*websocket.Conn, string, error) {
 	// if token is expired or not usable, get a new token from the authorizer
 	if !util.IsTokenUsable(c.token) {
-		config, err := c.configurer()
-		if err != nil {
+		if err := c.reconfigure(); err != nil {
 			return nil, "", err
 		}
-		c.setConfig(config)
 	}
+	refreshed := false
 
-	header := make(http.Header)
-	header.Set("Authorization", "Bearer "+c.token)
-	header.Set("x-websocktunnel-id", c.id)
+	dialer, err := newDialer(c.proxyURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dial := func(header http.Header) (*websocket.Conn, *http.Response, error) {
+		if onAttempt != nil {
+			onAttempt()
+		}
+		// the server only compresses the connection if asked to
+		dialer.EnableCompression = c.sessionConfig.Compression
+		return dialer.Dial(c.tunnelAddr, header)
+	}
+
+	header := c.header()
 
 	currentDelay := c.retry.InitialDelay
 	maxTimer := time.After(c.retry.MaxElapsedTime)
@@ -199,7 +337,7 @@ func (c *Client) connectWithRetry() (*websocket.Conn, string, error) {
 
 	for {
 		c.logger.Printf("trying to connect to %s", c.tunnelAddr)
-		conn, res, err := websocket.DefaultDialer.Dial(c.tunnelAddr, header)
+		conn, res, err := dial(header)
 		if err == nil {
 			c.logger.Printf("connected to %s ", c.tunnelAddr)
 			url := res.Header.Get("x-websocktunnel-client-url")
@@ -209,6 +347,19 @@ func (c *Client) connectWithRetry() (*websocket.Conn, string, error) {
 		if !shouldRetry(res) {
 			c.logger.Printf("connection failed with error:%v, response:%v", err, res)
 			if isAuthError(res) {
+				// the token may have been rejected for expiring while it
+				// was in use, so try once more with a new one
+				if !refreshed {
+					refreshed = true
+					if err := c.reconfigure(); err != nil {
+						return nil, "", err
+					}
+					if dialer, err = newDialer(c.proxyURL); err != nil {
+						return nil, "", err
+					}
+					header = c.header()
+					continue
+				}
 				return nil, "", ErrAuthFailed
 			}
 			return nil, "", ErrRetryFailed
@@ -221,7 +372,7 @@ func (c *Client) connectWithRetry() (*websocket.Conn, string, error) {
 			return nil, "", ErrRetryTimedOut
 		case <-backoff:
 			c.logger.Printf("trying to connect to %s", c.tunnelAddr)
-			conn, res, err := websocket.DefaultDialer.Dial(c.tunnelAddr, header)
+			conn, res, err := dial(header)
 			if err == nil {
 				url := res.Header.Get("x-websocktunnel-client-url")
 				return conn, url, nil
@@ -237,14 +388,43 @@ func (c *Client) connectWithRetry() (*websocket.Conn, string, error) {
 	}
 }
 
-// reconnect is used to repair broken connections
-func (c *Client) reconnect() {
+// header returns the headers for registering with the server
+func (c *Client) header() http.Header {
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+c.token)
+	header.Set("x-websocktunnel-id", c.id)
+	if len(c.restrictedPaths) > 0 {
+		header.Set("x-websocktunnel-restricted-paths", strings.Join(c.restrictedPaths, ","))
+		header.Set("x-websocktunnel-access-token", c.accessToken)
+	}
+	if c.tlsConfig != nil {
+		header.Set("x-websocktunnel-tls", "true")
+	}
+	return header
+}
+
+// reconnect is used to repair broken connections, after the session ended
+// with the given error
+func (c *Client) reconnect(cause error) {
+	lost := time.Now()
 	c.m.Lock()
 	defer c.m.Unlock()
-	conn, url, err := c.connectWithRetry()
+	if c.onDisconnect != nil {
+		c.onDisconnect(c, cause)
+	}
+
+	attempts := 0
+	conn, url, err := c.connectWithRetry(func() {
+		attempts++
+		c.stats.reconnectAttempts.Add(1)
+		if c.onReconnectAttempt != nil {
+			c.onReconnectAttempt(c, attempts)
+		}
+	})
 	if err != nil {
 		// set error and return
 		c.logger.Printf("unable to reconnect to %s", c.tunnelAddr)
+		c.stats.reconnectFailures.Add(1)
 		c.acceptErr = ErrRetryFailed
 		return
 	}
@@ -254,20 +434,44 @@ func (c *Client) reconnect() {
 		c.session = nil
 	}
 
-	sessionConfig := wsmux.Config{
-		// Log:              c.logger,
-		StreamBufferSize: 4 * 1024,
-	}
-	c.session = wsmux.Client(conn, sessionConfig)
+	c.session = c.newSession(conn)
 	c.url.Store(url)
 	c.state = stateRunning
 	c.logger.Printf("state: running")
 	c.acceptErr = nil
+	c.stats.reconnects.Add(1)
+	if c.onReconnected != nil {
+		c.onReconnected(c, attempts, time.Since(lost))
+	}
 	if c.connectHook != nil {
 		c.connectHook(c)
 	}
 }
 
+// reconfigure gets a new Config, with a new token, from the configurer.
+func (c *Client) reconfigure() error {
+	config, err := c.configurer()
+	if err != nil {
+		return err
+	}
+	c.setConfig(config)
+	if !util.IsTokenUsable(c.token) {
+		return ErrBadToken
+	}
+	return nil
+}
+
+// newSession starts a wsmux session over a connection to the tunnel.
+func (c *Client) newSession(conn *websocket.Conn) *wsmux.Session {
+	return wsmux.Client(conn, c.sessionConfig)
+}
+
+// isDeadConnection returns true if a session ended because its connection
+// stopped responding.
+func isDeadConnection(err error) bool {
+	return errors.Is(err, wsmux.ErrKeepAliveExpired) || errors.Is(err, wsmux.ErrConnectionWriteTimeout)
+}
+
 // simple utility to check if client should retry connection
 func shouldRetry(r *http.Response) bool {
 	// retry on connection failures (e.g., server down)
@@ -2,9 +2,12 @@ package client
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -250,3 +253,152 @@ func TestClientReconnect(t *testing.T) {
 	}
 
 }
+
+// Ensure the reconnection hooks are called, and reconnections counted
+func TestReconnectHooks(t *testing.T) {
+	connections := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			// the first connection is lost
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+			_ = conn.Close()
+		case 2:
+			// the first attempt to reconnect fails
+			http.Error(w, http.StatusText(503), 503)
+		default:
+			_, _ = upgrader.Upgrade(w, r, nil)
+		}
+	}))
+	defer server.Close()
+
+	var m sync.Mutex
+	events := []string{}
+	record := func(event string) {
+		m.Lock()
+		defer m.Unlock()
+		events = append(events, event)
+	}
+	reconnected := make(chan struct{})
+
+	configurer := testConfigurer("workerID", util.MakeWsURL(server.URL), RetryConfig{InitialDelay: 10 * time.Millisecond}, genLogger())
+	client, err := New(func() (Config, error) {
+		config, err := configurer()
+		config.ConnectHook = func(*Client) {
+			record("connect")
+		}
+		config.OnDisconnect = func(_ *Client, err error) {
+			if err == nil {
+				t.Error("OnDisconnect called without an error")
+			}
+			record("disconnect")
+		}
+		config.OnReconnectAttempt = func(_ *Client, attempt int) {
+			record(fmt.Sprintf("attempt %d", attempt))
+		}
+		config.OnReconnected = func(_ *Client, attempts int, latency time.Duration) {
+			if latency <= 0 {
+				t.Errorf("unexpected latency %v", latency)
+			}
+			record(fmt.Sprintf("reconnected after %d attempts", attempts))
+			close(reconnected)
+		}
+		return config, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	go func() {
+		for {
+			if _, err := client.Accept(); err == ErrClientClosed {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-reconnected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("client did not reconnect")
+	}
+	// the connect hook is called after OnReconnected
+	time.Sleep(50 * time.Millisecond)
+
+	m.Lock()
+	defer m.Unlock()
+	expected := []string{"connect", "disconnect", "attempt 1", "attempt 2", "reconnected after 2 attempts", "connect"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("expected hooks to be called as %v; got %v", expected, events)
+	}
+	if stats := client.Stats(); stats != (Stats{Disconnects: 1, ReconnectAttempts: 2, Reconnects: 1}) {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+// Ensure a connection which stops responding is detected as dead
+func TestDeadConnection(t *testing.T) {
+	connections := int32(0)
+	done := make(chan struct{})
+	defer close(done)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if atomic.AddInt32(&connections, 1) == 1 {
+			// never read from the first connection, so pings go unanswered,
+			// as if it was dropped silently
+			<-done
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	disconnected := make(chan error, 1)
+	configurer := testConfigurer("workerID", util.MakeWsURL(server.URL), RetryConfig{}, genLogger())
+	client, err := New(func() (Config, error) {
+		config, err := configurer()
+		config.KeepAliveInterval = time.Second
+		config.PongTimeout = 100 * time.Millisecond
+		config.OnDisconnect = func(_ *Client, err error) {
+			disconnected <- err
+		}
+		return config, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.Accept()
+	if err != ErrConnectionDead {
+		t.Fatalf("expected %v; got %v", ErrConnectionDead, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("dead connection detected after %v", elapsed)
+	}
+	if err := <-disconnected; err != ErrConnectionDead {
+		t.Fatalf("OnDisconnect called with %v", err)
+	}
+
+	// the client reconnects
+	for i := 0; i < 20 && atomic.LoadInt32(&connections) < 2; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&connections) < 2 {
+		t.Fatal("client did not reconnect")
+	}
+}
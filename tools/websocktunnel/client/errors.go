@@ -37,6 +37,12 @@ var (
 	// a delay.
 	ErrClientReconnecting = Error{errString: "client reconnecting", reconnect: true}
 
+	// ErrConnectionDead is returned from an Accept call when the connection to
+	// the server stopped responding to pings, or could not be written to in
+	// time (see Config.KeepAliveInterval).  The client reconnects, so this is
+	// a temporary error, like ErrClientReconnecting.
+	ErrConnectionDead = Error{errString: "connection to the server is dead", reconnect: true, timeout: true}
+
 	// ErrClientClosed is returned from an Accept call when the client is closed.
 	ErrClientClosed = Error{errString: "client closed"}
 
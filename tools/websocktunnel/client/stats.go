@@ -0,0 +1,38 @@
+package client
+
+import "sync/atomic"
+
+// Stats counts the disconnections and reconnections of a Client since it was
+// created.
+type Stats struct {
+	// Number of times the connection to the server was lost
+	Disconnects uint64
+
+	// Number of attempts to reconnect to the server
+	ReconnectAttempts uint64
+
+	// Number of times the client reconnected to the server
+	Reconnects uint64
+
+	// Number of times the client gave up reconnecting to the server
+	ReconnectFailures uint64
+}
+
+type stats struct {
+	disconnects       atomic.Uint64
+	reconnectAttempts atomic.Uint64
+	reconnects        atomic.Uint64
+	reconnectFailures atomic.Uint64
+}
+
+// Stats returns counts of the client's disconnections and reconnections,
+// which can be exported as metrics.  A tunnel that keeps disconnecting is
+// unavailable to viewers for some of the time.
+func (c *Client) Stats() Stats {
+	return Stats{
+		Disconnects:       c.stats.disconnects.Load(),
+		ReconnectAttempts: c.stats.reconnectAttempts.Load(),
+		Reconnects:        c.stats.reconnects.Load(),
+		ReconnectFailures: c.stats.reconnectFailures.Load(),
+	}
+}
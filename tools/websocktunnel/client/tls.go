@@ -0,0 +1,88 @@
+package client
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+// DialTLS connects to a client with a TLSConfig, as a viewer, returning a TLS
+// connection to the client.  The URL is the client's URL, with any path and
+// query, such as the websocktunnel-access-token parameter for restricted
+// paths.  The TLS connection is carried in the binary messages of a websocket
+// connection to the websocktunnel service, which does not see its contents.
+// The config must verify the client's certificate; ServerName defaults to the
+// URL's hostname, which the client's certificate is unlikely to match.
+func DialTLS(viewerURL string, config *tls.Config) (net.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(util.MakeWsURL(viewerURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(&websocketConn{Conn: conn}, config)
+	if err := tlsConn.Handshake(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// websocketConn is a net.Conn carrying a byte stream in the binary messages
+// of a websocket connection.
+type websocketConn struct {
+	*websocket.Conn
+
+	// the message being read, if any
+	reader io.Reader
+
+	// lock for writing, as tls.Conn may write alerts while another write is
+	// in progress
+	wm sync.Mutex
+}
+
+func (c *websocketConn) Read(b []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			mtype, reader, err := c.NextReader()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			if mtype != websocket.BinaryMessage {
+				continue
+			}
+			c.reader = reader
+		}
+		n, err := c.reader.Read(b)
+		if err == io.EOF {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *websocketConn) Write(b []byte) (int, error) {
+	c.wm.Lock()
+	defer c.wm.Unlock()
+	if err := c.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *websocketConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
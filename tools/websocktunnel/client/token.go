@@ -0,0 +1,147 @@
+package client
+
+import (
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+const (
+	defaultTokenRefreshMargin = 5 * time.Minute
+
+	// idleCheckInterval is how often a client with a new token checks whether
+	// its streams are closed, so that it can reconnect with that token.
+	idleCheckInterval = time.Second
+)
+
+// refreshDelay returns how long to wait before refreshing a token which
+// expires after remaining: until margin before it expires, or until halfway
+// through its remaining lifetime, if that is sooner.
+func refreshDelay(remaining, margin time.Duration) time.Duration {
+	if remaining <= 2*margin {
+		return remaining / 2
+	}
+	return remaining - margin
+}
+
+// refreshTokens gets a new token from the configurer before the current one
+// expires, and reconnects with it, until the client is closed.  This way, a
+// client never has to reconnect with an expired token, which the server
+// would reject.
+func (c *Client) refreshTokens() {
+	// the delay before trying again after the configurer failed
+	var retryDelay time.Duration
+	for {
+		c.m.Lock()
+		expires, margin := c.tokenExpires, c.tokenRefreshMargin
+		logger, retry := c.logger, c.retry
+		c.m.Unlock()
+		if expires.IsZero() {
+			// the token does not expire
+			return
+		}
+
+		wait := refreshDelay(time.Until(expires), margin)
+		if retryDelay > 0 {
+			wait = retryDelay
+		}
+		select {
+		case <-c.closed:
+			return
+		case <-time.After(wait):
+		}
+
+		config, err := c.configurer()
+		if err == nil && !util.GetTokenExp(config.Token).After(expires) {
+			// no newer token is available yet
+			err = ErrBadToken
+		}
+		if err != nil {
+			logger.Printf("unable to get a new token: %v", err)
+			if retryDelay == 0 {
+				retryDelay = retry.InitialDelay
+			} else {
+				retryDelay = retry.nextDelay(retryDelay)
+			}
+			continue
+		}
+		retryDelay = 0
+
+		c.m.Lock()
+		// a reconnection may have got an even newer token meanwhile
+		if !c.tokenExpires.After(util.GetTokenExp(config.Token)) {
+			c.setConfig(config)
+		}
+		c.m.Unlock()
+
+		if !c.renewWhenIdle() {
+			return
+		}
+	}
+}
+
+// renewWhenIdle reconnects with the client's current token once its session
+// has no open streams, so that no proxied connection is interrupted.  It
+// gives up when the token is due to be refreshed again: the server only checks
+// tokens when clients connect, so the session stays usable, and the next
+// reconnection will use the newest token.  It returns false if the client was
+// closed.
+func (c *Client) renewWhenIdle() bool {
+	c.m.Lock()
+	deadline := time.After(refreshDelay(time.Until(c.tokenExpires), c.tokenRefreshMargin))
+	c.m.Unlock()
+
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return false
+		case <-deadline:
+			c.logger.Printf("streams still open; not reconnecting with the new token")
+			return true
+		case <-ticker.C:
+		}
+		if c.renewSession() {
+			return true
+		}
+	}
+}
+
+// renewSession replaces the client's session with one authenticated with the
+// current token, unless streams are open on it, in which case it returns
+// false.
+func (c *Client) renewSession() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.state != stateRunning || c.isClosed() {
+		// a reconnection, which uses the new token, is under way
+		return true
+	}
+	// streams opened from now on wait to be accepted, and fail to open when
+	// the server closes the session, so that it opens them on the new one
+	previous := c.session
+	previous.PauseAccept()
+	if previous.NumStreams() > 0 {
+		previous.ResumeAccept()
+		return false
+	}
+
+	conn, url, err := c.connectWithRetry(nil)
+	if err != nil {
+		// the current session is still usable
+		previous.ResumeAccept()
+		c.logger.Printf("unable to reconnect with a new token: %v", err)
+		return true
+	}
+	// the server closes the previous session when the new one is registered;
+	// Accept moves on to the new session
+	c.session = c.newSession(conn)
+	c.url.Store(url)
+	_ = previous.Close()
+	c.logger.Printf("reconnected with a new token")
+	if c.connectHook != nil {
+		c.connectHook(c)
+	}
+	return true
+}
@@ -0,0 +1,308 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/wsmux"
+)
+
+// shortLivedConfigurer returns a Configurer generating a new token, valid for
+// the given duration, on each call, and counting the calls.
+func shortLivedConfigurer(id, addr string, validFor time.Duration, calls *int32) Configurer {
+	return func() (Config, error) {
+		n := atomic.AddInt32(calls, 1)
+		now := time.Now()
+		token := jwt.New(jwt.SigningMethodHS256)
+		token.Claims.(jwt.MapClaims)["nbf"] = now.Unix() - 300
+		token.Claims.(jwt.MapClaims)["exp"] = now.Add(validFor).Unix()
+		token.Claims.(jwt.MapClaims)["tid"] = id
+		token.Claims.(jwt.MapClaims)["jti"] = n
+		tokString, _ := token.SignedString([]byte("test-secret"))
+		return Config{
+			ID:                 id,
+			TunnelAddr:         addr,
+			Token:              tokString,
+			Logger:             genLogger(),
+			TokenRefreshMargin: time.Second,
+		}, nil
+	}
+}
+
+// registration is a connection of a client to tokenServer
+type registration struct {
+	token   string
+	at      time.Time
+	session *wsmux.Session
+}
+
+// tokenServer accepts client connections, recording the token of each
+func tokenServer(t *testing.T) (*httptest.Server, chan registration) {
+	registrations := make(chan registration, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := util.ExtractJWT(r.Header.Get("Authorization"))
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		registrations <- registration{token: token, at: time.Now(), session: wsmux.Server(conn, wsmux.Config{})}
+	}))
+	t.Cleanup(server.Close)
+	return server, registrations
+}
+
+func TestRefreshDelay(t *testing.T) {
+	if d := refreshDelay(time.Hour, 5*time.Minute); d != 55*time.Minute {
+		t.Fatalf("unexpected delay %v", d)
+	}
+	if d := refreshDelay(8*time.Minute, 5*time.Minute); d != 4*time.Minute {
+		t.Fatalf("unexpected delay %v", d)
+	}
+	if d := refreshDelay(-time.Second, 5*time.Minute); d > 0 {
+		t.Fatalf("unexpected delay %v", d)
+	}
+}
+
+// Ensure the client reconnects with a new token before its token expires
+func TestTokenRefresh(t *testing.T) {
+	server, registrations := tokenServer(t)
+	calls := int32(0)
+	client, err := New(shortLivedConfigurer("workerID", util.MakeWsURL(server.URL), 4*time.Second, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	go func() {
+		for {
+			if _, err := client.Accept(); err == ErrClientClosed {
+				return
+			}
+		}
+	}()
+
+	first := <-registrations
+	select {
+	case second := <-registrations:
+		if second.token == first.token {
+			t.Fatal("client reconnected with the same token")
+		}
+		if !first.session.IsClosed() {
+			// the proxy closes the previous session itself; the client
+			// closes it too
+			time.Sleep(100 * time.Millisecond)
+			if !first.session.IsClosed() {
+				t.Fatal("previous session was not closed")
+			}
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatal("client did not reconnect with a new token")
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("configurer called %d times", n)
+	}
+}
+
+// Ensure the client waits for open streams to be closed before reconnecting
+// with a new token, and keeps accepting streams on the new session
+func TestTokenRefreshWaitsForStreams(t *testing.T) {
+	server, registrations := tokenServer(t)
+	calls := int32(0)
+	client, err := New(shortLivedConfigurer("workerID", util.MakeWsURL(server.URL), 4*time.Second, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	first := <-registrations
+	var wg sync.WaitGroup
+	var remote net.Conn
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var err error
+		if remote, err = first.session.Open(); err != nil {
+			t.Error(err)
+		}
+	}()
+	stream, err := client.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	// the token is refreshed after about 3s; keep the stream open past that
+	time.Sleep(4 * time.Second)
+	select {
+	case <-registrations:
+		t.Fatal("client reconnected while a stream was open")
+	default:
+	}
+	closed := time.Now()
+	_ = stream.Close()
+	if remote != nil {
+		_ = remote.Close()
+	}
+
+	var second registration
+	select {
+	case second = <-registrations:
+		if second.at.Before(closed) {
+			t.Fatal("client reconnected while a stream was open")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("client did not reconnect with a new token")
+	}
+
+	// streams are accepted from the new session
+	go func() {
+		_, _ = second.session.Open()
+	}()
+	accepted := make(chan error, 1)
+	go func() {
+		_, err := client.Accept()
+		accepted <- err
+	}()
+	select {
+	case err := <-accepted:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("stream on the new session was not accepted")
+	}
+}
+
+// Ensure a rejected token is replaced by a new one
+func TestAuthFailureGetsNewToken(t *testing.T) {
+	tries := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&tries, 1) == 1 {
+			http.Error(w, http.StatusText(401), 401)
+			return
+		}
+		_, _ = upgrader.Upgrade(w, r, nil)
+	}))
+	defer server.Close()
+
+	calls := int32(0)
+	client, err := New(shortLivedConfigurer("workerID", util.MakeWsURL(server.URL), time.Hour, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("configurer called %d times", n)
+	}
+}
+
+// Ensure a configurer which cannot generate a usable token is an error
+func TestExpiredToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = upgrader.Upgrade(w, r, nil)
+	}))
+	defer server.Close()
+
+	calls := int32(0)
+	_, err := New(shortLivedConfigurer("workerID", util.MakeWsURL(server.URL), -time.Minute, &calls))
+	if err != ErrBadToken {
+		t.Fatalf("should fail with %v. Instead failed with %v", ErrBadToken, err)
+	}
+}
+
+// Ensure a stream opened while the client reconnects with a new token is not
+// accepted on the previous session, so that the server can open it again on
+// the new one
+func TestTokenRefreshStreamOpenedDuringRenewal(t *testing.T) {
+	registrations := make(chan registration, 10)
+	renewing := make(chan struct{})
+	proceed := make(chan struct{})
+	connections := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1) == 2 {
+			// hold the reconnection while a stream is opened on the previous
+			// session
+			close(renewing)
+			<-proceed
+		}
+		token := util.ExtractJWT(r.Header.Get("Authorization"))
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		registrations <- registration{token: token, at: time.Now(), session: wsmux.Server(conn, wsmux.Config{})}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		select {
+		case <-proceed:
+		default:
+			close(proceed)
+		}
+	})
+
+	calls := int32(0)
+	client, err := New(shortLivedConfigurer("workerID", util.MakeWsURL(server.URL), 4*time.Second, &calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	accepted := make(chan net.Conn, 10)
+	go func() {
+		for {
+			stream, err := client.Accept()
+			if err == ErrClientClosed {
+				return
+			}
+			if err == nil {
+				accepted <- stream
+			}
+		}
+	}()
+
+	first := <-registrations
+	select {
+	case <-renewing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not reconnect with a new token")
+	}
+	opened := make(chan error, 1)
+	go func() {
+		_, err := first.session.Open()
+		opened <- err
+	}()
+	time.Sleep(500 * time.Millisecond)
+	select {
+	case <-accepted:
+		t.Fatal("stream was accepted on the previous session during renewal")
+	default:
+	}
+
+	close(proceed)
+	select {
+	case err := <-opened:
+		if err != wsmux.ErrSessionClosed {
+			t.Fatalf("stream on the previous session should fail to open with %v, but got %v", wsmux.ErrSessionClosed, err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("stream on the previous session did not fail to open")
+	}
+
+	second := <-registrations
+	go func() {
+		_, _ = second.session.Open()
+	}()
+	select {
+	case <-accepted:
+	case <-time.After(3 * time.Second):
+		t.Fatal("stream on the new session was not accepted")
+	}
+}
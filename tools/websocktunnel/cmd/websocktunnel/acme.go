@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// acmeManager returns a manager obtaining and renewing certificates for the
+// hostnames in ACME_HOSTNAMES from an ACME certificate authority (Let's
+// Encrypt by default), or nil if ACME_HOSTNAMES is not set.
+func acmeManager(logger *log.Logger, urlPrefix string) *autocert.Manager {
+	hostnames := []string{}
+	for _, hostname := range strings.Split(os.Getenv("ACME_HOSTNAMES"), ",") {
+		if hostname = strings.TrimSpace(hostname); hostname != "" {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	if len(hostnames) == 0 {
+		return nil
+	}
+
+	// without a cache, a new certificate would be requested on every start,
+	// quickly hitting the certificate authority's rate limits
+	cacheDir := os.Getenv("ACME_CACHE_DIR")
+	if cacheDir == "" {
+		panic("ACME_CACHE_DIR is required with ACME_HOSTNAMES")
+	}
+
+	if u, err := url.Parse(urlPrefix); err == nil {
+		found := false
+		for _, hostname := range hostnames {
+			found = found || hostname == u.Hostname()
+		}
+		if !found {
+			logger.Warnf("the hostname of URL_PREFIX is not in ACME_HOSTNAMES: %s", urlPrefix)
+		}
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(hostnames...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      os.Getenv("ACME_EMAIL"),
+	}
+	if directoryURL := os.Getenv("ACME_DIRECTORY_URL"); directoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: directoryURL}
+	}
+	logger.WithFields(log.Fields{
+		"hostnames": hostnames,
+	}).Info("obtaining TLS certificates with ACME")
+	return manager
+}
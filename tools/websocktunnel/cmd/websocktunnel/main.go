@@ -0,0 +1,310 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"log/syslog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	docopt "github.com/docopt/docopt-go"
+	"github.com/gorilla/websocket"
+	mozlog "github.com/mozilla-services/go-mozlogrus"
+	log "github.com/sirupsen/logrus"
+	lSyslog "github.com/sirupsen/logrus/hooks/syslog"
+	"github.com/taskcluster/taskcluster/v60/internal"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/wsproxy"
+)
+
+const usage = `Websocketunnel Server
+
+Usage: websocktunnel [-h | --help]
+
+Environment:
+ URL_PREFIX (required)								URL prefix (http(s)://hostname(:port)) at which
+													this service is publicly exposed
+ PORT (optional; defaults to 80 or 443)				port on which to listent
+ TLS_CERTIFICATE (optional; no TLS if not provided) base64-encoded TLS certificate
+ TLS_KEY											corresponding base64-encoded TLS key
+ ACME_HOSTNAMES (optional)							comma-separated hostnames for which to obtain
+													TLS certificates with ACME, instead of
+													TLS_CERTIFICATE and TLS_KEY
+ ACME_CACHE_DIR (required with ACME_HOSTNAMES)		directory in which to store ACME certificates
+ ACME_EMAIL (optional)								contact email for the ACME account
+ ACME_DIRECTORY_URL (optional)						ACME directory; defaults to Let's Encrypt
+ ACME_HTTP_PORT (optional; defaults to 80)			port on which to answer ACME HTTP-01
+													challenges
+ TASKCLUSTER_PROXY_SECRET_A							JWT secret
+ TASKCLUSTER_PROXY_SECRET_B							alternate JWT secret
+ SYSLOG_ADDR										address to which to send syslog output
+ AUDIENCE											JWT 'audience' claim
+ AUDIT_LOG_FILE (optional)							file to which to append an audit log entry,
+													as JSON, for every viewer request
+ AUDIT_SYSLOG_ADDR (optional)						address to which to send audit log entries
+													as syslog output
+ METRICS_PORT (optional)							port on which to serve Prometheus metrics
+													at /metrics
+ MAX_STREAMS_PER_CLIENT (optional)					maximum concurrent viewer connections to
+													each client
+ MAX_BYTES_PER_SECOND_PER_CLIENT (optional)			maximum bandwidth of viewer connections to
+													each client
+ MAX_CONNECTIONS_PER_SECOND_PER_CLIENT (optional)	maximum rate of new viewer connections to
+													each client
+ ENABLE_COMPRESSION (optional)						set to "true" to compress traffic with clients
+													that request it
+ ADMIN_PORT (optional)								port on which to serve the token revocation
+													endpoint at /revocations
+ ADMIN_TOKEN (required with ADMIN_PORT)				bearer token for the revocation endpoint
+ REVOCATION_LIST_URL (optional)						URL of a JSON list of revoked tokens
+ REVOCATION_LIST_INTERVAL (optional; defaults to 1m)	how often to fetch the revocation list
+ PEERS (optional)									comma-separated internal URLs of the other
+													instances, to which viewer requests for
+													clients connected to them are forwarded
+ PEER_SECRET (required with PEERS)					secret shared by the instances
+
+Options:
+-h --help       Show help`
+
+func main() {
+	_, _ = docopt.ParseArgs(usage, nil, "websocktunnel "+internal.Version)
+
+	urlPrefix := os.Getenv("URL_PREFIX")
+	if urlPrefix == "" {
+		panic("URL_PREFIX is required")
+	}
+
+	logger := log.New()
+
+	if env := os.Getenv("ENV"); env == "production" {
+		// add mozlog formatter
+		logger.Formatter = &mozlog.MozLogFormatter{
+			LoggerName: "websocktunnel",
+		}
+
+		// add syslog hook if addr is provided
+		syslogAddr := os.Getenv("SYSLOG_ADDR")
+		if syslogAddr != "" {
+			hook, err := lSyslog.NewSyslogHook("udp", syslogAddr, syslog.LOG_DEBUG, "websocktunnel")
+			if err != nil {
+				panic(err)
+			}
+			logger.Hooks.Add(hook)
+		}
+	}
+
+	// send viewer audit log entries to their own sinks, if configured
+	var auditLogger *log.Logger
+	auditLogFile, auditSyslogAddr := os.Getenv("AUDIT_LOG_FILE"), os.Getenv("AUDIT_SYSLOG_ADDR")
+	if auditLogFile != "" || auditSyslogAddr != "" {
+		auditLogger = log.New()
+		auditLogger.Formatter = &log.JSONFormatter{}
+		auditLogger.Out = io.Discard
+		if auditLogFile != "" {
+			f, err := os.OpenFile(auditLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+			if err != nil {
+				panic(err)
+			}
+			defer f.Close()
+			auditLogger.Out = f
+		}
+		if auditSyslogAddr != "" {
+			hook, err := lSyslog.NewSyslogHook("udp", auditSyslogAddr, syslog.LOG_INFO|syslog.LOG_AUTH, "websocktunnel-audit")
+			if err != nil {
+				panic(err)
+			}
+			auditLogger.Hooks.Add(hook)
+		}
+	}
+
+	// Load secrets
+	signingSecretA := os.Getenv("TASKCLUSTER_PROXY_SECRET_A")
+	signingSecretB := os.Getenv("TASKCLUSTER_PROXY_SECRET_B")
+
+	// Load TLS certificates, or get them with ACME
+	useTLS := true
+	var tlsConfig *tls.Config
+	var err error
+	acmeManager := acmeManager(logger, urlPrefix)
+	if acmeManager != nil {
+		// this supports TLS-ALPN challenges
+		tlsConfig = acmeManager.TLSConfig()
+	} else {
+		tlsKeyEnc := os.Getenv("TLS_KEY")
+		tlsCertEnc := os.Getenv("TLS_CERTIFICATE")
+
+		tlsKey, _ := base64.StdEncoding.DecodeString(tlsKeyEnc)
+		tlsCert, _ := base64.StdEncoding.DecodeString(tlsCertEnc)
+		cert, err := tls.X509KeyPair([]byte(tlsCert), []byte(tlsKey))
+		if err != nil {
+			logger.Error(err.Error())
+			useTLS = false
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+		}
+	}
+
+	//load port
+	port := os.Getenv("PORT")
+	if port == "" {
+		if useTLS {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+
+	// load audience value
+	audience := os.Getenv("AUDIENCE")
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+		// permessage-deflate, with clients that request it
+		EnableCompression: os.Getenv("ENABLE_COMPRESSION") == "true",
+	}
+
+	// load per-client limits
+	limits := wsproxy.Limits{}
+	if v := os.Getenv("MAX_STREAMS_PER_CLIENT"); v != "" {
+		if limits.MaxStreams, err = strconv.Atoi(v); err != nil {
+			panic(err)
+		}
+	}
+	if v := os.Getenv("MAX_BYTES_PER_SECOND_PER_CLIENT"); v != "" {
+		if limits.MaxBytesPerSecond, err = strconv.ParseInt(v, 10, 64); err != nil {
+			panic(err)
+		}
+	}
+	if v := os.Getenv("MAX_CONNECTIONS_PER_SECOND_PER_CLIENT"); v != "" {
+		if limits.MaxConnectionsPerSecond, err = strconv.ParseFloat(v, 64); err != nil {
+			panic(err)
+		}
+	}
+
+	// load the other instances of the service
+	var peers []string
+	if v := os.Getenv("PEERS"); v != "" {
+		peers = strings.Split(v, ",")
+	}
+	peerSecret := os.Getenv("PEER_SECRET")
+
+	// answer ACME HTTP-01 challenges, redirecting other requests to HTTPS
+	if acmeManager != nil {
+		acmePort := os.Getenv("ACME_HTTP_PORT")
+		if acmePort == "" {
+			acmePort = "80"
+		}
+		if acmePort == port {
+			panic("ACME_HTTP_PORT must differ from PORT")
+		}
+		acmeServer := &http.Server{Addr: ":" + acmePort, Handler: acmeManager.HTTPHandler(nil)}
+		logger.WithFields(log.Fields{
+			"acme-addr": acmeServer.Addr,
+		}).Info("starting ACME challenge server")
+		go func() {
+			if err := acmeServer.ListenAndServe(); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
+	// serve metrics on a separate port, if requested
+	var metrics *wsproxy.Metrics
+	if metricsPort := os.Getenv("METRICS_PORT"); metricsPort != "" {
+		metrics = wsproxy.NewMetrics()
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics)
+		metricsServer := &http.Server{Addr: ":" + metricsPort, Handler: mux}
+		logger.WithFields(log.Fields{
+			"metrics-addr": metricsServer.Addr,
+		}).Info("starting metrics server")
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
+	// revoke tokens through the admin endpoint, or a remote list
+	var revocations *wsproxy.Revocations
+	adminPort, revocationListURL := os.Getenv("ADMIN_PORT"), os.Getenv("REVOCATION_LIST_URL")
+	if adminPort != "" || revocationListURL != "" {
+		revocations = wsproxy.NewRevocations(os.Getenv("ADMIN_TOKEN"))
+	}
+	if adminPort != "" {
+		if os.Getenv("ADMIN_TOKEN") == "" {
+			panic("ADMIN_TOKEN is required with ADMIN_PORT")
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/revocations", revocations)
+		adminServer := &http.Server{Addr: ":" + adminPort, Handler: mux}
+		logger.WithFields(log.Fields{
+			"admin-addr": adminServer.Addr,
+		}).Info("starting admin server")
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil {
+				panic(err)
+			}
+		}()
+	}
+	if revocationListURL != "" {
+		interval := time.Minute
+		if v := os.Getenv("REVOCATION_LIST_INTERVAL"); v != "" {
+			if interval, err = time.ParseDuration(v); err != nil {
+				panic(err)
+			}
+		}
+		go func() {
+			for {
+				if err := revocations.Fetch(revocationListURL); err != nil {
+					logger.Errorf("unable to fetch the revocation list: %v", err)
+				}
+				time.Sleep(interval)
+			}
+		}()
+	}
+
+	// will panic if secrets are not loaded
+	proxy, _ := wsproxy.New(wsproxy.Config{
+		Logger:      logger,
+		AuditLogger: auditLogger,
+		Upgrader:    upgrader,
+		JWTSecretA:  []byte(signingSecretA),
+		JWTSecretB:  []byte(signingSecretB),
+		URLPrefix:   urlPrefix,
+		Audience:    audience,
+		Metrics:     metrics,
+		Limits:      limits,
+		Revocations: revocations,
+		Peers:       peers,
+		PeerSecret:  peerSecret,
+	})
+
+	server := &http.Server{Addr: ":" + port, Handler: proxy}
+	defer func() {
+		_ = server.Close()
+	}()
+	logger.WithFields(log.Fields{
+		"server-addr": server.Addr,
+	}).Info("starting server")
+
+	// create tls config and serve
+	if useTLS {
+		listener, err := tls.Listen("tcp", ":"+port, tlsConfig)
+		if err != nil {
+			panic(err)
+		}
+		_ = server.Serve(listener)
+	} else {
+		err = server.ListenAndServe()
+		if err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package wsmux
+
+const (
+	// minCompressSize is the size below which data frames are not compressed,
+	// as compressing them saves little or nothing
+	minCompressSize = 256
+
+	// compressSampleSize is the number of bytes of a frame examined to decide
+	// whether it is already compressed
+	compressSampleSize = 512
+
+	// maxDistinctBytes is the number of distinct byte values in a sample above
+	// which data is considered already compressed (or encrypted).  Such data
+	// is close to random, and a random sample of 256 to 512 bytes contains
+	// about 160 to 220 distinct values, while text contains far fewer.
+	maxDistinctBytes = 128
+)
+
+// compressible returns true if a frame is worth compressing: a data frame
+// large enough, which does not appear to be compressed already, such as a
+// gzipped response or an image.  Frames are parts of streams, so this looks
+// at the frame's data itself rather than for the headers of compressed
+// formats.
+func compressible(f frame) bool {
+	if f.msg != msgDAT || len(f.payload) < minCompressSize {
+		return false
+	}
+	sample := f.payload
+	if len(sample) > compressSampleSize {
+		sample = sample[:compressSampleSize]
+	}
+	var seen [256]bool
+	distinct := 0
+	for _, b := range sample {
+		if !seen[b] {
+			seen[b] = true
+			distinct++
+		}
+	}
+	return distinct <= maxDistinctBytes
+}
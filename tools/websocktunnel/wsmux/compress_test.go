@@ -0,0 +1,85 @@
+package wsmux
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+func TestCompressible(t *testing.T) {
+	text := []byte(strings.Repeat("[taskcluster 2024-01-01T00:00:00Z] Running task...\n", 20))
+	random := make([]byte, 1024)
+	_, _ = rand.Read(random)
+
+	for _, test := range []struct {
+		name         string
+		f            frame
+		compressible bool
+	}{
+		{"text", newDataFrame(1, text), true},
+		{"random", newDataFrame(1, random), false},
+		{"small", newDataFrame(1, text[:100]), false},
+		{"ack", newAckFrame(1, 1024), false},
+	} {
+		if compressible(test.f) != test.compressible {
+			t.Fatalf("%s: expected compressible to be %v", test.name, test.compressible)
+		}
+	}
+}
+
+func TestCompressedSession(t *testing.T) {
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		session := Server(conn, Config{Log: genLogger(), Compression: true})
+		defer session.Close()
+		str, err := session.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = io.Copy(str, str)
+		_ = str.Close()
+	}))
+	defer server.Close()
+
+	conn, res, err := (&websocket.Dialer{EnableCompression: true}).Dial(util.MakeWsURL(server.URL), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate") {
+		t.Fatal("compression was not negotiated")
+	}
+	session := Client(conn, Config{Log: genLogger(), Compression: true})
+	defer session.Close()
+
+	// both compressible and incompressible data make it through
+	data := []byte(strings.Repeat("some verbose log line\n", 2000))
+	random := make([]byte, 32*1024)
+	_, _ = rand.Read(random)
+	data = append(data, random...)
+
+	str, err := session.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_, _ = str.Write(data)
+	}()
+	received := make([]byte, len(data))
+	if _, err := io.ReadFull(str, received); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, received) {
+		t.Fatal("data not echoed correctly")
+	}
+}
@@ -34,6 +34,10 @@ var (
 	//ErrKeepAliveExpired is returned when the keep alive timer expired
 	ErrKeepAliveExpired = errors.New("keep alive timer expired")
 
+	// ErrConnectionWriteTimeout is returned when a frame could not be written to the
+	// websocket connection within the session's WriteTimeout
+	ErrConnectionWriteTimeout = errors.New("wsmux: connection write timed out")
+
 	// ErrMalformedHeader indicate a websocket frame header was invalid.
 	ErrMalformedHeader = errors.New("malformed header")
 
@@ -53,7 +53,8 @@ func newHeader(msg byte, id uint32) header {
 // type:
 //
 //   - msgDAT: the payload is the binary data
-//   - msgSYN: no payload
+//   - msgSYN: an optional signed byte giving the priority of the stream; streams
+//     have PriorityNormal without it
 //   - msgACK: payload is a little-endian u32 indicating the number of bytes handled
 //     on the remote end and thus no longer "in flight".
 //   - msgFIN: no payload
@@ -119,12 +120,20 @@ func newDataFrame(id uint32, buf []byte) frame {
 	return frame
 }
 
-// newSynFrame creates a new msgSYN frame.
-func newSynFrame(id uint32) frame {
-	frame := frame{id: id, msg: msgSYN, payload: nil}
+// newSynFrame creates a new msgSYN frame for a stream with the given priority.
+func newSynFrame(id uint32, priority Priority) frame {
+	frame := frame{id: id, msg: msgSYN, payload: []byte{byte(priority)}}
 	return frame
 }
 
+// synPriority returns the priority of the stream opened by a msgSYN frame.
+func synPriority(f frame) Priority {
+	if len(f.payload) == 0 {
+		return PriorityNormal
+	}
+	return Priority(int8(f.payload[0]))
+}
+
 // newSynFrame creates a new msgACK frame containing the given capacity.
 func newAckFrame(id uint32, cap uint32) frame {
 	frame := frame{id: id, msg: msgACK}
@@ -17,9 +17,20 @@ import (
 // All of the fields are optional.
 type Config struct {
 	// KeepAliveInterval is the interval between keepAlives.  The session will send websocket
-	// ping frames at this interval. Default: 10 seconds
+	// ping frames at this interval. Default: 20 seconds
 	KeepAliveInterval time.Duration
 
+	// PongTimeout is the time the session waits for a pong after sending a ping.  If none
+	// is received, the connection is considered dead and the session is aborted with
+	// ErrKeepAliveExpired.  This is at most KeepAliveInterval. Default: KeepAliveInterval
+	PongTimeout time.Duration
+
+	// WriteTimeout is the time allowed to write a frame to the websocket connection.  If
+	// it is exceeded, the connection is considered dead and the session is aborted with
+	// ErrConnectionWriteTimeout.  Pings are always written with a timeout, of half of
+	// KeepAliveInterval by default.  Default: no timeout for frames
+	WriteTimeout time.Duration
+
 	// StreamAcceptDeadline is the time after which opening a new stream will time out.
 	// Default: 30 seconds
 	StreamAcceptDeadline time.Duration
@@ -34,6 +45,12 @@ type Config struct {
 	// StreamBufferSize sets the maximum buffer size of streams created by the session.
 	// Default: 1024 bytes
 	StreamBufferSize int
+
+	// Compression enables compressing data frames if the websocket connection has
+	// negotiated permessage-deflate (see websocket.Dialer.EnableCompression and
+	// websocket.Upgrader.EnableCompression).  Frames which are small, or appear to
+	// be compressed already, are sent uncompressed.  Default: false
+	Compression bool
 }
 
 // Server instantiates a new server session over a websocket connection.
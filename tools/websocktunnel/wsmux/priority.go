@@ -0,0 +1,116 @@
+package wsmux
+
+import (
+	"sync"
+)
+
+// Priority determines the share of the websocket connection a stream gets when
+// several streams are sending data at once.  Streams are opened with
+// PriorityNormal unless OpenPriority is used, and the priority is sent to the
+// remote end, so that the stream has the same priority in both directions.
+type Priority int8
+
+const (
+	// PriorityLow is for bulk transfers which can wait
+	PriorityLow Priority = -1
+	// PriorityNormal is the default priority
+	PriorityNormal Priority = 0
+	// PriorityHigh is for interactive traffic, such as shells
+	PriorityHigh Priority = 1
+)
+
+// numPriorities is the number of priority levels, indexed by level()
+const numPriorities = 3
+
+// priorityWeights are the number of frames sent from each level, low to high,
+// in one round of the scheduler, when all levels have frames waiting
+var priorityWeights = [numPriorities]int{1, 4, 16}
+
+// level returns the index of a priority in the scheduler's queues.  Unknown
+// priorities, which may be sent by a newer remote end, are clamped to the
+// nearest known one.
+func (p Priority) level() int {
+	switch {
+	case p < PriorityLow:
+		return 0
+	case p > PriorityHigh:
+		return numPriorities - 1
+	default:
+		return int(p - PriorityLow)
+	}
+}
+
+// sendRequest is a frame waiting to be written to the websocket connection;
+// the result of writing it is sent to done.
+type sendRequest struct {
+	f    frame
+	done chan error
+}
+
+// sendQueue schedules frames for writing to the websocket connection.  Control
+// frames (SYN, ACK and FIN) are small and keep streams moving, so they are
+// always sent first.  Data frames are sent with a weighted round robin between
+// priority levels: in each round, each level can send up to its weight in
+// frames, so that higher priorities get most of the connection without
+// starving lower ones.
+type sendQueue struct {
+	m       sync.Mutex
+	control []*sendRequest
+	data    [numPriorities][]*sendRequest
+	credits [numPriorities]int
+
+	// signalled when a frame is pushed
+	ready chan struct{}
+}
+
+func newSendQueue() *sendQueue {
+	return &sendQueue{
+		credits: priorityWeights,
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+// push adds a frame to the queue, with the priority of its stream.
+func (q *sendQueue) push(req *sendRequest, p Priority) {
+	q.m.Lock()
+	if req.f.msg == msgDAT {
+		l := p.level()
+		q.data[l] = append(q.data[l], req)
+	} else {
+		q.control = append(q.control, req)
+	}
+	q.m.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes the next frame to send from the queue, returning nil if there is
+// none.
+func (q *sendQueue) pop() *sendRequest {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if len(q.control) > 0 {
+		req := q.control[0]
+		q.control = q.control[1:]
+		return req
+	}
+
+	for round := 0; round < 2; round++ {
+		for l := numPriorities - 1; l >= 0; l-- {
+			if len(q.data[l]) > 0 && q.credits[l] > 0 {
+				q.credits[l]--
+				req := q.data[l][0]
+				q.data[l] = q.data[l][1:]
+				return req
+			}
+		}
+		// every level with frames waiting has used its credits, so start
+		// a new round
+		q.credits = priorityWeights
+	}
+	return nil
+}
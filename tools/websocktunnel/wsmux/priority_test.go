@@ -0,0 +1,101 @@
+package wsmux
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+func TestSendQueueSchedule(t *testing.T) {
+	q := newSendQueue()
+	push := func(msg byte, id uint32, p Priority) {
+		q.push(&sendRequest{f: frame{id: id, msg: msg}}, p)
+	}
+	for i := 0; i < 20; i++ {
+		push(msgDAT, 1, PriorityLow)
+		push(msgDAT, 2, PriorityNormal)
+		push(msgDAT, 3, PriorityHigh)
+	}
+	push(msgACK, 1, PriorityLow)
+
+	order := []uint32{}
+	for req := q.pop(); req != nil; req = q.pop() {
+		order = append(order, req.f.id)
+	}
+	if len(order) != 61 {
+		t.Fatalf("expected 61 frames; got %d", len(order))
+	}
+	// control frames come first
+	if order[0] != 1 {
+		t.Fatalf("control frame not sent first: %v", order)
+	}
+	// in each round, each level sends up to its weight in frames
+	firstRound := map[uint32]int{}
+	for _, id := range order[1:22] {
+		firstRound[id]++
+	}
+	if firstRound[3] != 16 || firstRound[2] != 4 || firstRound[1] != 1 {
+		t.Fatalf("unexpected first round: %v", order)
+	}
+	// once high priority frames run out, the others share the connection
+	last := order[len(order)-1]
+	if last != 1 {
+		t.Fatalf("expected low priority frames last: %v", order)
+	}
+}
+
+func TestPriorityLevel(t *testing.T) {
+	for p, level := range map[Priority]int{
+		-100:           0,
+		PriorityLow:    0,
+		PriorityNormal: 1,
+		PriorityHigh:   2,
+		100:            2,
+	} {
+		if p.level() != level {
+			t.Fatalf("expected level %d for priority %d; got %d", level, p, p.level())
+		}
+	}
+}
+
+func TestOpenPriority(t *testing.T) {
+	accepted := make(chan *stream, 2)
+	server := httptest.NewServer(genWebSocketHandler(t, func(t *testing.T, conn *websocket.Conn) {
+		session := Server(conn, Config{Log: genLogger()})
+		for i := 0; i < 2; i++ {
+			str, err := session.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- str.(*stream)
+		}
+	}))
+	defer server.Close()
+	conn, _, err := (&websocket.Dialer{}).Dial(util.MakeWsURL(server.URL), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := Client(conn, Config{Log: genLogger()})
+	defer session.Close()
+
+	// the remote end gives accepted streams the priority they were opened with
+	for _, p := range []Priority{PriorityHigh, PriorityNormal} {
+		str, err := session.OpenPriority(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if str.(*stream).priority != p {
+			t.Fatalf("expected priority %d; got %d", p, str.(*stream).priority)
+		}
+		if remote := <-accepted; remote.priority != p {
+			t.Fatalf("expected remote priority %d; got %d", p, remote.priority)
+		}
+	}
+
+	// streams opened by older versions, without a priority, are normal
+	if p := synPriority(frame{id: 1, msg: msgSYN}); p != PriorityNormal {
+		t.Fatalf("expected normal priority; got %d", p)
+	}
+}
@@ -1,6 +1,7 @@
 package wsmux
 
 import (
+	"errors"
 	"net"
 	"sync"
 	"time"
@@ -39,8 +40,8 @@ type Session struct {
 	// error to be returned by any outstanding Accept calls
 	acceptErr error
 
-	// lock for sending data on the connection
-	sendLock sync.Mutex
+	// frames waiting to be written to the connection by sendLoop
+	queue *sendQueue
 
 	// Open calls must complete in this duration
 	streamAcceptDeadline time.Duration
@@ -68,8 +69,21 @@ type Session struct {
 	// Keep alives are sent at this period
 	keepAliveInterval time.Duration
 
+	// The session is aborted if no pong is received within this duration
+	// after sending a keep alive
+	pongTimeout time.Duration
+
+	// Deadline for writing a frame, or 0 for no deadline
+	writeTimeout time.Duration
+
 	// Set by the pong handler
 	pongSeen bool
+
+	// Compress data frames worth compressing, if the connection supports it
+	compression bool
+
+	// closed by ResumeAccept; nil unless accepting is paused
+	acceptPaused chan struct{}
 }
 
 // newSession creates a new session based on the given configuration, applying
@@ -87,6 +101,8 @@ func newSession(conn *websocket.Conn, server bool, conf Config) *Session {
 		logger:               &util.NilLogger{},
 		streamBufferSize:     DefaultCapacity,
 		closeCallback:        conf.CloseCallback,
+		queue:                newSendQueue(),
+		compression:          conf.Compression,
 	}
 
 	// streams opened by server are even numbered
@@ -98,6 +114,11 @@ func newSession(conn *websocket.Conn, server bool, conf Config) *Session {
 	if conf.KeepAliveInterval != 0 {
 		s.keepAliveInterval = conf.KeepAliveInterval
 	}
+	s.pongTimeout = s.keepAliveInterval
+	if conf.PongTimeout != 0 && conf.PongTimeout < s.keepAliveInterval {
+		s.pongTimeout = conf.PongTimeout
+	}
+	s.writeTimeout = conf.WriteTimeout
 	if conf.StreamAcceptDeadline != 0 {
 		s.streamAcceptDeadline = conf.StreamAcceptDeadline
 	}
@@ -113,6 +134,7 @@ func newSession(conn *websocket.Conn, server bool, conf Config) *Session {
 	s.conn.SetPongHandler(s.pongHandler)
 
 	go s.recvLoop()
+	go s.sendLoop()
 	go s.removeDeadStreams()
 	go s.sendKeepAlives()
 	return s
@@ -127,14 +149,20 @@ func (s *Session) Accept() (net.Conn, error) {
 		return nil, s.acceptErr
 	case str := <-s.streamCh:
 		if str == nil {
-			return nil, ErrSessionClosed
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return nil, s.acceptErr
+		}
+
+		if err := s.waitAcceptResumed(); err != nil {
+			return nil, err
 		}
 
 		// "accept" the stream locally, putting it into a state where it can read and write
 		str.acceptStream(uint32(s.streamBufferSize))
 
 		// and inform the other side that this stream has been accepted
-		if err := s.send(newAckFrame(str.id, uint32(s.streamBufferSize))); err != nil {
+		if err := s.send(newAckFrame(str.id, uint32(s.streamBufferSize)), str.priority); err != nil {
 			s.abort(err)
 			return nil, err
 		}
@@ -142,6 +170,48 @@ func (s *Session) Accept() (net.Conn, error) {
 	}
 }
 
+// PauseAccept stops Accept from accepting streams until ResumeAccept is
+// called.  Streams opened by the remote end meanwhile wait to be accepted, so
+// if the session is closed first, they fail to open, and the remote end can
+// open them again elsewhere.
+func (s *Session) PauseAccept() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.acceptPaused == nil {
+		s.acceptPaused = make(chan struct{})
+	}
+}
+
+// ResumeAccept lets Accept accept streams again, after PauseAccept.
+func (s *Session) ResumeAccept() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.acceptPaused != nil {
+		close(s.acceptPaused)
+		s.acceptPaused = nil
+	}
+}
+
+// waitAcceptResumed waits while accepting is paused, returning the accept error
+// if the session is closed meanwhile.
+func (s *Session) waitAcceptResumed() error {
+	for {
+		s.mu.Lock()
+		paused := s.acceptPaused
+		s.mu.Unlock()
+		if paused == nil {
+			return nil
+		}
+		select {
+		case <-paused:
+		case <-s.closed:
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.acceptErr
+		}
+	}
+}
+
 // Open a new stream to the remote end, returning a `net.Conn` as well as a
 // stream ID.  The remote end must call Accept to accept the connection.  If
 // this does not occur within the deadline, this function will fail.
@@ -150,6 +220,11 @@ func (s *Session) Accept() (net.Conn, error) {
 // frame containing that ID to the remote side.  The stream is considered
 // accepted when a msgACK frame arrives with the same stream ID.
 func (s *Session) Open() (net.Conn, error) {
+	return s.OpenPriority(PriorityNormal)
+}
+
+// OpenPriority opens a new stream as Open does, with the given priority.
+func (s *Session) OpenPriority(priority Priority) (net.Conn, error) {
 	select {
 	case <-s.closed:
 		return nil, ErrSessionClosed
@@ -172,10 +247,10 @@ func (s *Session) Open() (net.Conn, error) {
 	id := s.nextID
 	s.nextID += 2
 
-	str := newStream(id, s)
+	str := newStream(id, s, priority)
 	s.streams[id] = str
 
-	if err := s.send(newSynFrame(id)); err != nil {
+	if err := s.send(newSynFrame(id, priority), priority); err != nil {
 		return nil, err
 	}
 
@@ -231,7 +306,10 @@ func (s *Session) Close() error {
 		v.kill()
 	}
 	s.streams = nil
-	s.acceptErr = ErrSessionClosed
+	// keep the error the session was aborted with, if any
+	if s.acceptErr == nil {
+		s.acceptErr = ErrSessionClosed
+	}
 
 	close(s.closed)
 	close(s.streamCh)
@@ -244,6 +322,20 @@ func (s *Session) Addr() net.Addr {
 	return s.conn.LocalAddr()
 }
 
+// NumStreams returns the number of streams of the session that are open, or
+// that are closed but still have data to be read.
+func (s *Session) NumStreams() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, str := range s.streams {
+		if !str.isRemovable() {
+			n++
+		}
+	}
+	return n
+}
+
 // IsClosed returns true if the session is closed.
 func (s *Session) IsClosed() bool {
 	select {
@@ -264,24 +356,34 @@ func (s *Session) pongHandler(data string) error {
 
 // sendKeepAlives sends a ping message every keepAliveInterval, until the
 // connection closes.  If there is an error sending the ping, or no pong is
-// received during the interval, the connection is aborted.
+// received within pongTimeout, the connection is aborted.
 func (s *Session) sendKeepAlives() {
 	ticker := time.NewTicker(s.keepAliveInterval)
+	defer ticker.Stop()
 	for {
-		s.sendLock.Lock()
-		err := s.conn.WriteControl(
-			websocket.PingMessage, nil,
-			// use a deadline of half the keepAliveInterval, to ensure the message
-			// is sent in a reasonable amount of time
-			time.Now().Add(s.keepAliveInterval/2))
-		s.sendLock.Unlock()
+		// use a deadline of half the keepAliveInterval, to ensure the message
+		// is sent in a reasonable amount of time
+		deadline := s.keepAliveInterval / 2
+		if s.writeTimeout != 0 && s.writeTimeout < deadline {
+			deadline = s.writeTimeout
+		}
+		s.mu.Lock()
+		s.pongSeen = false
+		s.mu.Unlock()
+		// WriteControl may be called concurrently with other writes, so this
+		// does not go through sendLoop; that way a write blocked on a dead
+		// connection does not prevent detecting that it is dead
+		err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(deadline))
 		if err != nil {
+			if isTimeout(err) {
+				err = ErrConnectionWriteTimeout
+			}
 			s.abort(err)
 			return
 		}
 
 		select {
-		case <-ticker.C:
+		case <-time.After(s.pongTimeout):
 		case <-s.closed:
 			return
 		}
@@ -290,28 +392,78 @@ func (s *Session) sendKeepAlives() {
 		// considered failed
 		s.mu.Lock()
 		pongSeen := s.pongSeen
-		s.pongSeen = false
 		s.mu.Unlock()
 		if !pongSeen {
 			s.logger.Printf("No pong message seen; aborting session")
 			s.abort(ErrKeepAliveExpired)
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.closed:
+			return
 		}
 	}
 }
 
-// send transmits a frame over the websocket connection.
-func (s *Session) send(f frame) error {
+// send queues a frame to be written to the websocket connection by sendLoop,
+// and waits until it is written.  Data frames are scheduled according to the
+// priority of their stream.
+func (s *Session) send(f frame, priority Priority) error {
 	select {
 	case <-s.closed:
 		return ErrSessionClosed
 	default:
 	}
-	s.sendLock.Lock()
-	defer s.sendLock.Unlock()
+	req := &sendRequest{f: f, done: make(chan error, 1)}
+	s.queue.push(req, priority)
+	select {
+	case err := <-req.done:
+		return err
+	case <-s.closed:
+		return ErrSessionClosed
+	}
+}
+
+// sendLoop writes the frames queued by send to the websocket connection, in
+// the order chosen by the queue, until the session is closed.
+func (s *Session) sendLoop() {
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-s.queue.ready:
+		}
+		for req := s.queue.pop(); req != nil; req = s.queue.pop() {
+			req.done <- s.write(req.f)
+		}
+	}
+}
+
+// write writes a frame to the websocket connection.
+func (s *Session) write(f frame) error {
+	if s.writeTimeout != 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+	// this has no effect unless compression was negotiated
+	s.conn.EnableWriteCompression(s.compression && compressible(f))
 	err := s.conn.WriteMessage(websocket.BinaryMessage, f.serialize())
+	if err != nil && isTimeout(err) {
+		// the websocket connection can not be written to after a timeout;
+		// abort asynchronously, as senders may hold the session's lock
+		go s.abort(ErrConnectionWriteTimeout)
+		return ErrConnectionWriteTimeout
+	}
 	return err
 }
 
+// isTimeout returns true if err is a timeout writing to the connection
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // called when websocket connection is closed
 func (s *Session) closeHandler(code int, text string) error {
 	s.logger.Printf("wsmux connection closed: code %d : %s", code, text)
@@ -351,7 +503,7 @@ func (s *Session) recvLoop() {
 		}
 
 		if fr.msg == msgSYN {
-			go s.handleSyn(fr.id)
+			go s.handleSyn(fr.id, synPriority(*fr))
 		} else {
 			s.mu.Lock()
 			str := s.streams[fr.id]
@@ -364,10 +516,11 @@ func (s *Session) recvLoop() {
 	}
 }
 
-// handleSyn creates a new stream and adds it to s.streamCh so that it can be returned
-// from Accept.  As part of the two-way stream setup handshake, it responds with a
-// msgACK frame indicating that the request has been received.
-func (s *Session) handleSyn(id uint32) {
+// handleSyn creates a new stream, with the priority requested by the remote end,
+// and adds it to s.streamCh so that it can be returned from Accept.  As part of
+// the two-way stream setup handshake, it responds with a msgACK frame
+// indicating that the request has been received.
+func (s *Session) handleSyn(id uint32, priority Priority) {
 	s.mu.Lock()
 
 	// check if stream exists
@@ -378,7 +531,7 @@ func (s *Session) handleSyn(id uint32) {
 		return
 	}
 
-	str := newStream(id, s)
+	str := newStream(id, s, priority)
 	s.streams[id] = str
 
 	defer s.mu.Unlock()
@@ -398,7 +551,11 @@ func (s *Session) abort(e error) {
 
 	s.mu.Lock()
 	s.logger.Printf("session aborting: %v", e)
-	s.acceptErr = e
+	// the first error aborting the session is the one reported; closing the
+	// connection causes others
+	if s.acceptErr == nil {
+		s.acceptErr = e
+	}
 	s.mu.Unlock()
 	s.Close()
 }
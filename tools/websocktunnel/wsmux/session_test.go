@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"testing"
+	"time"
 
 	"net/http/httptest"
 
@@ -80,3 +81,109 @@ func TestEchoLarge(t *testing.T) {
 		t.Fatal("message not consistent")
 	}
 }
+
+// readConn reads from the connection, replying to pings, until it is closed
+func readConn(t *testing.T, conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	server := httptest.NewServer(genWebSocketHandler(t, readConn))
+	defer server.Close()
+	conn, _, err := (&websocket.Dialer{}).Dial(util.MakeWsURL(server.URL), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := Client(conn, Config{Log: genLogger(), KeepAliveInterval: 100 * time.Millisecond, PongTimeout: 50 * time.Millisecond})
+	defer session.Close()
+
+	time.Sleep(500 * time.Millisecond)
+	if session.IsClosed() {
+		t.Fatal("session closed although pongs were received")
+	}
+}
+
+func TestKeepAliveExpired(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	// the server never reads from the connection, so never replies to pings,
+	// as if the connection had been dropped
+	server := httptest.NewServer(genWebSocketHandler(t, func(t *testing.T, conn *websocket.Conn) {
+		<-done
+	}))
+	defer server.Close()
+	conn, _, err := (&websocket.Dialer{}).Dial(util.MakeWsURL(server.URL), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	session := Client(conn, Config{Log: genLogger(), KeepAliveInterval: time.Second, PongTimeout: 100 * time.Millisecond})
+
+	_, err = session.Accept()
+	if err != ErrKeepAliveExpired {
+		t.Fatalf("expected %v; got %v", ErrKeepAliveExpired, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("dead connection detected after %v", elapsed)
+	}
+}
+
+// Ensure streams are not accepted while accepting is paused, and fail to open
+// if the session is closed before accepting resumes
+func TestPauseAccept(t *testing.T) {
+	sessions := make(chan *Session, 1)
+	server := httptest.NewServer(genWebSocketHandler(t, func(t *testing.T, conn *websocket.Conn) {
+		sessions <- Server(conn, Config{Log: genLogger()})
+	}))
+	defer server.Close()
+	conn, _, err := (&websocket.Dialer{}).Dial(util.MakeWsURL(server.URL), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := Client(conn, Config{Log: genLogger()})
+	defer client.Close()
+	session := <-sessions
+
+	accepted := make(chan error, 2)
+	accept := func() {
+		_, err := session.Accept()
+		accepted <- err
+	}
+	opened := make(chan error, 2)
+	open := func() {
+		_, err := client.Open()
+		opened <- err
+	}
+
+	session.PauseAccept()
+	go accept()
+	go open()
+	select {
+	case <-accepted:
+		t.Fatal("stream was accepted while accepting was paused")
+	case <-time.After(200 * time.Millisecond):
+	}
+	session.ResumeAccept()
+	if err := <-accepted; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-opened; err != nil {
+		t.Fatal(err)
+	}
+
+	session.PauseAccept()
+	go accept()
+	go open()
+	time.Sleep(200 * time.Millisecond)
+	_ = session.Close()
+	if err := <-accepted; err != ErrSessionClosed {
+		t.Fatalf("Accept should fail with %v, but got %v", ErrSessionClosed, err)
+	}
+	if err := <-opened; err != ErrSessionClosed {
+		t.Fatalf("Open should fail with %v, but got %v", ErrSessionClosed, err)
+	}
+}
@@ -43,6 +43,9 @@ type stream struct {
 	// id of the stream within the session
 	id uint32
 
+	// priority of the data frames sent on the stream
+	priority Priority
+
 	// mutex for state transitions
 	m sync.Mutex
 
@@ -82,12 +85,13 @@ type stream struct {
 
 // newStream creates a new stream with the given id.  No frames are sent.  This
 // is used both for locally initiated streams and remotely initiated streams.
-func newStream(id uint32, session *Session) *stream {
+func newStream(id uint32, session *Session, priority Priority) *stream {
 	if session == nil {
 		panic("session must not be nil")
 	}
 	str := &stream{
 		id:        id,
+		priority:  priority,
 		b:         newBuffer(session.streamBufferSize),
 		unblocked: 0,
 		state:     streamCreated,
@@ -293,7 +297,7 @@ func (s *stream) Close() error {
 		s.state = streamClosed
 	}
 
-	if err := s.session.send(newFinFrame(s.id)); err != nil {
+	if err := s.session.send(newFinFrame(s.id), s.priority); err != nil {
 		return err
 	}
 
@@ -331,7 +335,7 @@ func (s *stream) Read(buf []byte) (int, error) {
 	// msgDAT frame, but when we are about to return it to the caller; this conveys information about how
 	// quickly this process is actually consuming the data, rather than just how quickly the local TCP
 	// stack can receive it.
-	if err := s.session.send(newAckFrame(s.id, uint32(n))); err != nil {
+	if err := s.session.send(newAckFrame(s.id, uint32(n)), s.priority); err != nil {
 		return n, err
 	}
 
@@ -370,7 +374,7 @@ func (s *stream) Write(buf []byte) (int, error) {
 		// send as much data as unblocked allows; we will wait for msgACKs
 		// before sending any additional bytes.
 		cap := util.Min(len(buf), int(s.unblocked))
-		if err := s.session.send(newDataFrame(s.id, buf[:cap])); err != nil {
+		if err := s.session.send(newDataFrame(s.id, buf[:cap]), s.priority); err != nil {
 			return w, err
 		}
 		buf = buf[cap:]
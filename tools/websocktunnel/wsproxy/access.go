@@ -0,0 +1,105 @@
+package wsproxy
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	// restrictedPathsHeader is sent by clients at registration, giving a
+	// comma-separated list of the path prefixes that viewers may only access
+	// with the access token given in accessTokenHeader.
+	restrictedPathsHeader = "x-websocktunnel-restricted-paths"
+
+	// accessTokenHeader gives the access token, both in registrations and in
+	// viewer requests.
+	accessTokenHeader = "x-websocktunnel-access-token"
+
+	// accessTokenParam is the query parameter giving the access token in
+	// viewer requests, for viewers which can not set headers, such as
+	// browsers opening websockets.
+	accessTokenParam = "websocktunnel-access-token"
+)
+
+// accessPolicy restricts access to some paths of a client to viewers
+// presenting its access token.
+type accessPolicy struct {
+	restricted []string
+	token      string
+}
+
+// parseAccessPolicy parses the access policy declared in the headers of a
+// registration request.  It returns nil if no paths are restricted.
+func parseAccessPolicy(header http.Header) (*accessPolicy, error) {
+	value := header.Get(restrictedPathsHeader)
+	if value == "" {
+		return nil, nil
+	}
+	policy := &accessPolicy{token: header.Get(accessTokenHeader)}
+	if policy.token == "" {
+		return nil, errors.New("restricted paths require an access token")
+	}
+	for _, prefix := range strings.Split(value, ",") {
+		prefix = strings.TrimSpace(prefix)
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, errors.New("restricted paths must start with /")
+		}
+		policy.restricted = append(policy.restricted, path.Clean(prefix))
+	}
+	return policy, nil
+}
+
+// isRestricted returns true if the given request path is under one of the
+// restricted prefixes.  Prefixes match whole path segments, so /shell
+// restricts /shell and /shell/1, but not /shellfish.
+func (a *accessPolicy) isRestricted(requestPath string) bool {
+	// compare the path as the client will see it, after decoding and
+	// resolving any . or .. segments
+	cleaned := path.Clean("/" + requestPath)
+	for _, prefix := range a.restricted {
+		if prefix == "/" || cleaned == prefix || strings.HasPrefix(cleaned, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// allows returns true if the given access token is the client's.
+func (a *accessPolicy) allows(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// checkAccess checks that a viewer request to the given path of a client (a
+// request URI) is allowed by the client's access policy, if any.  It returns
+// the path to send to the client, without any access token parameter.
+func (p *proxy) checkAccess(id string, r *http.Request, requestURI string) (string, bool) {
+	p.m.RLock()
+	policy := p.policies[id]
+	p.m.RUnlock()
+
+	// never pass access tokens on to clients
+	token := r.Header.Get(accessTokenHeader)
+	r.Header.Del(accessTokenHeader)
+	u, err := url.ParseRequestURI(requestURI)
+	if err != nil {
+		return requestURI, policy == nil
+	}
+	if query := u.Query(); query.Has(accessTokenParam) {
+		if token == "" {
+			token = query.Get(accessTokenParam)
+		}
+		query.Del(accessTokenParam)
+		u.RawQuery = query.Encode()
+		requestURI = u.RequestURI()
+		r.URL.RawQuery = u.RawQuery
+	}
+
+	if policy == nil || !policy.isRestricted(u.Path) {
+		return requestURI, true
+	}
+	return requestURI, policy.allows(token)
+}
@@ -0,0 +1,133 @@
+package wsproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/client"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+func TestParseAccessPolicy(t *testing.T) {
+	policy, err := parseAccessPolicy(http.Header{})
+	require.NoError(t, err)
+	require.Nil(t, policy)
+
+	header := http.Header{}
+	header.Set(restrictedPathsHeader, "/shell, /admin/")
+	_, err = parseAccessPolicy(header)
+	require.Error(t, err, "an access token is required")
+
+	header.Set(accessTokenHeader, "secret")
+	policy, err = parseAccessPolicy(header)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/shell", "/admin"}, policy.restricted)
+
+	for p, restricted := range map[string]bool{
+		"/shell":          true,
+		"/shell/":         true,
+		"/shell/1":        true,
+		"//shell":         true,
+		"/log/../shell/1": true,
+		"/admin":          true,
+		"/shellfish":      false,
+		"/log/shell":      false,
+		"/":               false,
+	} {
+		require.Equal(t, restricted, policy.isRestricted(p), p)
+	}
+
+	header.Set(restrictedPathsHeader, "shell")
+	_, err = parseAccessPolicy(header)
+	require.Error(t, err, "paths must start with /")
+}
+
+func TestProxyRestrictedPaths(t *testing.T) {
+	proxy, err := New(Config{
+		Upgrader:   upgrader,
+		JWTSecretA: []byte("test-secret"),
+		JWTSecretB: []byte("another-secret"),
+		URLPrefix:  "http://localhost",
+		Logger:     genLogger(),
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	configurer := testConfigurer("myclient", util.MakeWsURL(server.URL), client.RetryConfig{}, genLogger())
+	cl, err := client.New(func() (client.Config, error) {
+		config, err := configurer()
+		config.RestrictedPaths = []string{"/shell"}
+		config.AccessToken = "secret"
+		return config, err
+	})
+	require.NoError(t, err)
+	defer cl.Close()
+
+	// the client replies with the request URI, and the access token it sees
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RequestURI() + " " + r.Header.Get(accessTokenHeader)))
+	})}
+	defer srv.Close()
+	go func() {
+		_ = srv.Serve(cl)
+	}()
+
+	get := func(uri, token string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/myclient"+uri, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set(accessTokenHeader, token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	status, body := get("/log", "")
+	require.Equal(t, 200, status)
+	require.Equal(t, "/log ", body)
+
+	for _, uri := range []string{"/shell", "/shell/1", "/%73hell", "/log/../shell"} {
+		status, _ = get(uri, "")
+		require.Equal(t, http.StatusUnauthorized, status, uri)
+		status, _ = get(uri, "wrong")
+		require.Equal(t, http.StatusUnauthorized, status, uri)
+	}
+
+	// the token is accepted in a header or query parameter, and not passed on
+	status, body = get("/shell/1", "secret")
+	require.Equal(t, 200, status)
+	require.Equal(t, "/shell/1 ", body)
+	status, body = get("/shell?a=1&websocktunnel-access-token=secret", "")
+	require.Equal(t, 200, status)
+	require.Equal(t, "/shell?a=1 ", body)
+}
+
+func TestProxyRegisterRestrictedPathsWithoutToken(t *testing.T) {
+	proxy, err := New(Config{
+		Upgrader:   upgrader,
+		JWTSecretA: []byte("test-secret"),
+		JWTSecretB: []byte("another-secret"),
+		URLPrefix:  "http://localhost",
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+workeridjwt)
+	header.Set("x-websocktunnel-id", "workerid")
+	header.Set(restrictedPathsHeader, "/shell")
+	_, res, err := websocket.DefaultDialer.Dial(util.MakeWsURL(server.URL), header)
+	require.Error(t, err)
+	require.NotNil(t, res)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
@@ -0,0 +1,104 @@
+package wsproxy
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// auditWriter records the status of the response to a viewer request, for the
+// audit log.  It supports flushing and hijacking, which the proxy uses to
+// stream responses and to upgrade websockets.
+type auditWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (aw *auditWriter) WriteHeader(status int) {
+	if aw.status == 0 {
+		aw.status = status
+	}
+	aw.ResponseWriter.WriteHeader(status)
+}
+
+func (aw *auditWriter) Write(b []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	return aw.ResponseWriter.Write(b)
+}
+
+func (aw *auditWriter) Flush() {
+	_ = http.NewResponseController(aw.ResponseWriter).Flush()
+}
+
+func (aw *auditWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(aw.ResponseWriter).Hijack()
+	if err == nil {
+		// the connection is only hijacked to upgrade it to a websocket
+		aw.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+func (aw *auditWriter) Unwrap() http.ResponseWriter {
+	return aw.ResponseWriter
+}
+
+// viewerIP returns the IP address of the viewer making a request.  For
+// requests forwarded by a peer, that is the address the peer got the request
+// from, which the peer appended to X-Forwarded-For.
+func viewerIP(r *http.Request, fromPeer bool) string {
+	if fromPeer {
+		forwardedFor := r.Header.Values("X-Forwarded-For")
+		if len(forwardedFor) > 0 {
+			last := forwardedFor[len(forwardedFor)-1]
+			return strings.TrimSpace(last[strings.LastIndex(last, ",")+1:])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// forwardedFor returns the X-Forwarded-For header as the viewer sent it, before
+// any peer appended to it.  It is set by load balancers in front of the
+// service, but viewers may also set it, so it is logged for information only.
+func forwardedFor(r *http.Request, fromPeer bool) string {
+	value := strings.Join(r.Header.Values("X-Forwarded-For"), ", ")
+	if fromPeer {
+		if i := strings.LastIndex(value, ","); i >= 0 {
+			return strings.TrimSpace(value[:i])
+		}
+		return ""
+	}
+	return value
+}
+
+// audit logs a viewer request to the audit logger, once it is done.
+func (p *proxy) audit(r *http.Request, id, path string, fromPeer bool, aw *auditWriter, start time.Time) {
+	fields := logrus.Fields{
+		"audit":      true,
+		"tunnel-id":  id,
+		"path":       path,
+		"method":     r.Method,
+		"viewer-ip":  viewerIP(r, fromPeer),
+		"websocket":  aw.status == http.StatusSwitchingProtocols,
+		"status":     aw.status,
+		"duration":   time.Since(start).Seconds(),
+		"started-at": start.UTC().Format(time.RFC3339Nano),
+	}
+	if ff := forwardedFor(r, fromPeer); ff != "" {
+		fields["forwarded-for"] = ff
+	}
+	if fromPeer {
+		fields["peer-addr"] = r.RemoteAddr
+	}
+	p.auditLogger.WithFields(fields).Info("viewer request")
+}
@@ -0,0 +1,133 @@
+package wsproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	logTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/client"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+func TestViewerIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/myclient/", nil)
+	r.RemoteAddr = "10.0.0.1:4000"
+	r.Header.Set("X-Forwarded-For", "192.0.2.1")
+	require.Equal(t, "10.0.0.1", viewerIP(r, false))
+	require.Equal(t, "192.0.2.1", forwardedFor(r, false))
+
+	// a peer appends the address it got the request from
+	r.Header.Set("X-Forwarded-For", "192.0.2.1, 10.0.0.2")
+	require.Equal(t, "10.0.0.2", viewerIP(r, true))
+	require.Equal(t, "192.0.2.1", forwardedFor(r, true))
+	r.Header.Set("X-Forwarded-For", "10.0.0.2")
+	require.Equal(t, "10.0.0.2", viewerIP(r, true))
+	require.Equal(t, "", forwardedFor(r, true))
+}
+
+func TestAuditLog(t *testing.T) {
+	auditLogger, hook := logTest.NewNullLogger()
+	proxy, err := New(Config{
+		Upgrader:    upgrader,
+		JWTSecretA:  []byte("test-secret"),
+		JWTSecretB:  []byte("another-secret"),
+		URLPrefix:   "http://localhost",
+		Logger:      genLogger(),
+		AuditLogger: auditLogger,
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	configurer := testConfigurer("myclient", util.MakeWsURL(server.URL), client.RetryConfig{}, genLogger())
+	cl, err := client.New(func() (client.Config, error) {
+		config, err := configurer()
+		config.RestrictedPaths = []string{"/shell"}
+		config.AccessToken = "secret"
+		return config, err
+	})
+	require.NoError(t, err)
+	defer cl.Close()
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	})}
+	defer srv.Close()
+	go func() {
+		_ = srv.Serve(cl)
+	}()
+
+	get := func(uri string) {
+		res, err := http.Get(server.URL + uri)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+	}
+	get("/myclient/log")
+	get("/myclient/shell?websocktunnel-access-token=wrong")
+	get("/otherclient/log")
+	conn, _, err := websocket.DefaultDialer.Dial(util.MakeWsURL(server.URL)+"/myclient/shell?websocktunnel-access-token=secret", nil)
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	// the websocket request is logged when the viewer connection ends
+	require.Eventually(t, func() bool { return len(hook.AllEntries()) == 4 }, 5*time.Second, 10*time.Millisecond)
+	expected := []struct {
+		id, path  string
+		status    int
+		websocket bool
+	}{
+		{"myclient", "/log", http.StatusOK, false},
+		{"myclient", "/shell", http.StatusUnauthorized, false},
+		{"otherclient", "/log", http.StatusGatewayTimeout, false},
+		{"myclient", "/shell", http.StatusSwitchingProtocols, true},
+	}
+	for i, entry := range hook.AllEntries() {
+		require.Equal(t, logrus.InfoLevel, entry.Level)
+		require.Equal(t, true, entry.Data["audit"])
+		require.Equal(t, expected[i].id, entry.Data["tunnel-id"])
+		// access tokens are never logged
+		require.Equal(t, expected[i].path, entry.Data["path"])
+		require.Equal(t, expected[i].status, entry.Data["status"])
+		require.Equal(t, expected[i].websocket, entry.Data["websocket"])
+		require.Equal(t, "127.0.0.1", entry.Data["viewer-ip"])
+		require.NotContains(t, entry.Data, "peer-addr")
+	}
+}
+
+func TestVersionNotAudited(t *testing.T) {
+	versionJson := filepath.Join(t.TempDir(), "version.json")
+	require.NoError(t, os.WriteFile(versionJson, []byte(`{"version": "v1.0.0"}`), 0644))
+	oldVersionJsonPath := versionJsonPath
+	versionJsonPath = versionJson
+	defer func() { versionJsonPath = oldVersionJsonPath }()
+
+	auditLogger, hook := logTest.NewNullLogger()
+	proxy, err := New(Config{
+		Upgrader:    upgrader,
+		JWTSecretA:  []byte("test-secret"),
+		JWTSecretB:  []byte("another-secret"),
+		URLPrefix:   "http://localhost",
+		Logger:      genLogger(),
+		AuditLogger: auditLogger,
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/__version__", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"version": "v1.0.0"}`, w.Body.String())
+	require.Empty(t, hook.AllEntries())
+}
@@ -0,0 +1,183 @@
+package wsproxy
+
+import (
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// Limits limits the viewer traffic to each client, so that viewers of one
+// client can not starve those of other clients.  Each limit applies to each
+// client ID separately.  All of the fields are optional; zero means no limit.
+type Limits struct {
+	// MaxStreams is the maximum number of concurrent viewer connections to a
+	// client.  Further viewer requests get a 429 response.
+	MaxStreams int
+
+	// MaxBytesPerSecond is the maximum bandwidth, in bytes per second, of the
+	// data proxied between a client and its viewers, in both directions
+	// combined.  Transfers beyond it are slowed down; one second's worth may
+	// be transferred at once.
+	MaxBytesPerSecond int64
+
+	// MaxConnectionsPerSecond is the maximum rate of new viewer connections to
+	// a client.  Further viewer requests get a 429 response with a
+	// Retry-After header.  Bursts of up to ConnectionBurst connections are
+	// allowed; the default is one second's worth, and at least 1.
+	MaxConnectionsPerSecond float64
+	ConnectionBurst         int
+}
+
+func (l Limits) enabled() bool {
+	return l.MaxStreams > 0 || l.MaxBytesPerSecond > 0 || l.MaxConnectionsPerSecond > 0
+}
+
+// tokenBucket is a token bucket rate limiter, with tokens added at rate per
+// second up to burst.
+type tokenBucket struct {
+	m      sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// refill adds the tokens accumulated since the last call; call with b.m held.
+func (b *tokenBucket) refill() {
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// allow takes a token if one is available.  Otherwise it returns false and
+// how long until one is.
+func (b *tokenBucket) allow() (bool, time.Duration) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.refill()
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// wait takes n tokens, waiting until they are available.  Tokens are taken
+// even if they are not yet available, so that concurrent callers are served
+// in turn.
+func (b *tokenBucket) wait(n int) {
+	b.m.Lock()
+	b.refill()
+	b.tokens -= float64(n)
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.m.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// clientLimiter applies the limits to the viewer traffic of one client.
+type clientLimiter struct {
+	m           sync.Mutex
+	streams     int
+	connections *tokenBucket
+	bandwidth   *tokenBucket
+}
+
+func newClientLimiter(limits Limits) *clientLimiter {
+	l := &clientLimiter{}
+	if limits.MaxConnectionsPerSecond > 0 {
+		burst := float64(limits.ConnectionBurst)
+		if burst == 0 {
+			burst = math.Max(1, math.Ceil(limits.MaxConnectionsPerSecond))
+		}
+		l.connections = newTokenBucket(limits.MaxConnectionsPerSecond, burst)
+	}
+	if limits.MaxBytesPerSecond > 0 {
+		l.bandwidth = newTokenBucket(float64(limits.MaxBytesPerSecond), float64(limits.MaxBytesPerSecond))
+	}
+	return l
+}
+
+// limiter returns the limiter for a client, or nil if no limits are set.
+func (p *proxy) limiter(id string) *clientLimiter {
+	if !p.limits.enabled() {
+		return nil
+	}
+	p.lm.Lock()
+	defer p.lm.Unlock()
+	l, ok := p.limiters[id]
+	if !ok {
+		l = newClientLimiter(p.limits)
+		p.limiters[id] = l
+	}
+	return l
+}
+
+// removeLimiter forgets the limiter of a client which disconnected, unless
+// viewer connections to it are still open.
+func (p *proxy) removeLimiter(id string) {
+	p.lm.Lock()
+	defer p.lm.Unlock()
+	if l, ok := p.limiters[id]; ok {
+		l.m.Lock()
+		defer l.m.Unlock()
+		if l.streams == 0 {
+			delete(p.limiters, id)
+		}
+	}
+}
+
+// acquire admits a new viewer connection, returning a function to call once
+// it is finished.  If the connection is not admitted, acquire returns false,
+// and how long the viewer should wait before retrying, if known.
+func (l *clientLimiter) acquire(maxStreams int) (func(), bool, time.Duration) {
+	if l == nil {
+		return func() {}, true, 0
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	if maxStreams > 0 && l.streams >= maxStreams {
+		return nil, false, 0
+	}
+	if l.connections != nil {
+		if ok, retryAfter := l.connections.allow(); !ok {
+			return nil, false, retryAfter
+		}
+	}
+	l.streams++
+	return func() {
+		l.m.Lock()
+		defer l.m.Unlock()
+		l.streams--
+	}, true, 0
+}
+
+// throttle limits the bandwidth of a stream to the client, if required.
+func (l *clientLimiter) throttle(stream net.Conn) net.Conn {
+	if l == nil || l.bandwidth == nil {
+		return stream
+	}
+	return &throttledConn{Conn: stream, bandwidth: l.bandwidth}
+}
+
+type throttledConn struct {
+	net.Conn
+	bandwidth *tokenBucket
+}
+
+func (c *throttledConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.bandwidth.wait(n)
+	return n, err
+}
+
+func (c *throttledConn) Write(b []byte) (int, error) {
+	c.bandwidth.wait(len(b))
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,138 @@
+package wsproxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/wsmux"
+)
+
+// limitedProxy starts a proxy with the given limits, and a client with id
+// workerid serving handler, returning the proxy's URL.
+func limitedProxy(t *testing.T, limits Limits, handler http.Handler) string {
+	t.Helper()
+	proxy, err := New(Config{
+		Upgrader:   upgrader,
+		Logger:     genLogger(),
+		JWTSecretA: []byte("test-secret"),
+		JWTSecretB: []byte("another-secret"),
+		URLPrefix:  "http://localhost",
+		Limits:     limits,
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+workeridjwt)
+	header.Set("x-websocktunnel-id", "workerid")
+	clientWs, _, err := websocket.DefaultDialer.Dial(util.MakeWsURL(server.URL), header)
+	require.NoError(t, err)
+	clientServer := &http.Server{Handler: handler}
+	go func() {
+		_ = clientServer.Serve(wsmux.Client(clientWs, wsmux.Config{}))
+	}()
+	t.Cleanup(func() { _ = clientServer.Close() })
+	return server.URL
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(10, 2)
+	for i := 0; i < 2; i++ {
+		ok, _ := b.allow()
+		require.True(t, ok)
+	}
+	ok, retryAfter := b.allow()
+	require.False(t, ok)
+	require.InDelta(t, 100*time.Millisecond, retryAfter, float64(10*time.Millisecond))
+
+	time.Sleep(retryAfter)
+	ok, _ = b.allow()
+	require.True(t, ok)
+}
+
+func TestLimitsMaxStreams(t *testing.T) {
+	unblock := make(chan struct{})
+	started := make(chan struct{}, 1)
+	url := limitedProxy(t, Limits{MaxStreams: 1}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			started <- struct{}{}
+			<-unblock
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	done := make(chan int)
+	go func() {
+		resp, err := http.Get(url + "/workerid/block")
+		if err != nil {
+			t.Error(err)
+			done <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+	<-started
+
+	resp, err := http.Get(url + "/workerid/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	close(unblock)
+	require.Equal(t, 200, <-done)
+
+	resp, err = http.Get(url + "/workerid/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+}
+
+func TestLimitsConnectionRate(t *testing.T) {
+	url := limitedProxy(t, Limits{MaxConnectionsPerSecond: 0.5}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	resp, err := http.Get(url + "/workerid/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+
+	resp, err = http.Get(url + "/workerid/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	// requests for unknown clients are not limited
+	resp, err = http.Get(url + "/otherid/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func TestLimitsBandwidth(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 60*1024)
+	url := limitedProxy(t, Limits{MaxBytesPerSecond: 40 * 1024}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+
+	start := time.Now()
+	resp, err := http.Get(url + "/workerid/")
+	require.NoError(t, err)
+	reply, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, body, reply)
+
+	// a second's worth is transferred at once, and the rest at the limit
+	require.Greater(t, time.Since(start), 400*time.Millisecond)
+}
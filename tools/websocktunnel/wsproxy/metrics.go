@@ -0,0 +1,143 @@
+package wsproxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reconnectWindow is how soon after a client disconnects its next
+// registration counts as a reconnection.
+const reconnectWindow = 5 * time.Minute
+
+// Metrics collects metrics about a proxy, and serves them in the Prometheus
+// text format as an http.Handler.  Create one with NewMetrics and pass it to
+// New in Config.Metrics, then serve it on a separate port, as
+// /metrics would otherwise conflict with viewer URLs.
+type Metrics struct {
+	// returns the number of connected clients; set by the proxy
+	clients func() int
+
+	viewerSessions    atomic.Int64
+	viewerRequests    atomic.Uint64
+	limitedRequests   atomic.Uint64
+	forwardedRequests atomic.Uint64
+	bytesToClient     atomic.Uint64
+	bytesToViewer     atomic.Uint64
+	authFailures      atomic.Uint64
+	registrations     atomic.Uint64
+	reconnections     atomic.Uint64
+	disconnections    atomic.Uint64
+
+	m sync.Mutex
+	// when clients recently disconnected, by id
+	disconnected map[string]time.Time
+}
+
+// NewMetrics creates a new, empty, Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		clients:      func() int { return 0 },
+		disconnected: make(map[string]time.Time),
+	}
+}
+
+// clientRegistered counts the registration of a client, as a reconnection if
+// it replaces a session of the same client or the client disconnected
+// recently.
+func (m *Metrics) clientRegistered(id string, replaced bool) {
+	m.registrations.Add(1)
+
+	m.m.Lock()
+	defer m.m.Unlock()
+	now := time.Now()
+	if last, ok := m.disconnected[id]; replaced || (ok && now.Sub(last) < reconnectWindow) {
+		m.reconnections.Add(1)
+	}
+	delete(m.disconnected, id)
+	for other, last := range m.disconnected {
+		if now.Sub(last) >= reconnectWindow {
+			delete(m.disconnected, other)
+		}
+	}
+}
+
+// clientDisconnected counts the disconnection of a client.
+func (m *Metrics) clientDisconnected(id string) {
+	m.disconnections.Add(1)
+
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.disconnected[id] = time.Now()
+}
+
+// viewerSession counts a viewer request, which is active until the returned
+// function is called.
+func (m *Metrics) viewerSession() func() {
+	m.viewerRequests.Add(1)
+	m.viewerSessions.Add(1)
+	return func() {
+		m.viewerSessions.Add(-1)
+	}
+}
+
+// countBytes wraps a stream to a client, counting the bytes written to and
+// read from it.
+func (m *Metrics) countBytes(stream net.Conn) net.Conn {
+	return &countingConn{Conn: stream, metrics: m}
+}
+
+type countingConn struct {
+	net.Conn
+	metrics *Metrics
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.metrics.bytesToViewer.Add(uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.metrics.bytesToClient.Add(uint64(n))
+	return n, err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+func (m *Metrics) write(w io.Writer) {
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("websocktunnel_clients", "gauge", "Number of connected clients.")
+	fmt.Fprintf(w, "websocktunnel_clients %d\n", m.clients())
+	metric("websocktunnel_viewer_sessions", "gauge", "Number of viewer requests being proxied to clients.")
+	fmt.Fprintf(w, "websocktunnel_viewer_sessions %d\n", m.viewerSessions.Load())
+	metric("websocktunnel_viewer_requests_total", "counter", "Number of viewer requests proxied to clients.")
+	fmt.Fprintf(w, "websocktunnel_viewer_requests_total %d\n", m.viewerRequests.Load())
+	metric("websocktunnel_limited_viewer_requests_total", "counter", "Number of viewer requests rejected for exceeding the limits for their client.")
+	fmt.Fprintf(w, "websocktunnel_limited_viewer_requests_total %d\n", m.limitedRequests.Load())
+	metric("websocktunnel_forwarded_viewer_requests_total", "counter", "Number of viewer requests forwarded to the instance holding their client.")
+	fmt.Fprintf(w, "websocktunnel_forwarded_viewer_requests_total %d\n", m.forwardedRequests.Load())
+	metric("websocktunnel_proxied_bytes_total", "counter", "Bytes proxied between viewers and clients, including HTTP headers.")
+	fmt.Fprintf(w, "websocktunnel_proxied_bytes_total{direction=\"to_client\"} %d\n", m.bytesToClient.Load())
+	fmt.Fprintf(w, "websocktunnel_proxied_bytes_total{direction=\"to_viewer\"} %d\n", m.bytesToViewer.Load())
+	metric("websocktunnel_auth_failures_total", "counter", "Number of client registrations rejected for a missing or invalid token.")
+	fmt.Fprintf(w, "websocktunnel_auth_failures_total %d\n", m.authFailures.Load())
+	metric("websocktunnel_client_registrations_total", "counter", "Number of client registrations.")
+	fmt.Fprintf(w, "websocktunnel_client_registrations_total %d\n", m.registrations.Load())
+	metric("websocktunnel_client_reconnections_total", "counter", "Number of client registrations replacing a session of the same client, or within 5 minutes of it disconnecting.")
+	fmt.Fprintf(w, "websocktunnel_client_reconnections_total %d\n", m.reconnections.Load())
+	metric("websocktunnel_client_disconnections_total", "counter", "Number of client sessions that ended.")
+	fmt.Fprintf(w, "websocktunnel_client_disconnections_total %d\n", m.disconnections.Load())
+}
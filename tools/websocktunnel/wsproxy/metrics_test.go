@@ -0,0 +1,122 @@
+package wsproxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/wsmux"
+)
+
+// scrape returns the values of the metrics served by m, by name (including
+// labels)
+func scrape(t *testing.T, m *Metrics) map[string]int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	values := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, " ")
+		require.True(t, ok, line)
+		n, err := strconv.Atoi(value)
+		require.NoError(t, err, line)
+		values[name] = n
+	}
+	return values
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	proxy, err := New(Config{
+		Upgrader:   upgrader,
+		Logger:     genLogger(),
+		JWTSecretA: []byte("test-secret"),
+		JWTSecretB: []byte("another-secret"),
+		URLPrefix:  "http://localhost",
+		Metrics:    metrics,
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+	wsURL := util.MakeWsURL(server.URL)
+
+	// a registration with a token for another client fails
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+wsworkerjwt)
+	header.Set("x-websocktunnel-id", "workerid")
+	_, res, _ := websocket.DefaultDialer.Dial(wsURL, header)
+	require.NotNil(t, res)
+	require.Equal(t, 401, res.StatusCode)
+
+	register := func() *http.Server {
+		header.Set("Authorization", "Bearer "+workeridjwt)
+		clientWs, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+		require.NoError(t, err)
+		clientServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(w, r.Body)
+		})}
+		go func() {
+			_ = clientServer.Serve(wsmux.Client(clientWs, wsmux.Config{}))
+		}()
+		return clientServer
+	}
+	clientServer := register()
+
+	resp, err := http.Post(server.URL+"/workerid/", "application/text", bytes.NewBufferString("message"))
+	require.NoError(t, err)
+	reply, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "message", string(reply))
+	_ = resp.Body.Close()
+
+	values := scrape(t, metrics)
+	require.Equal(t, 1, values["websocktunnel_clients"])
+	require.Equal(t, 0, values["websocktunnel_viewer_sessions"])
+	require.Equal(t, 1, values["websocktunnel_viewer_requests_total"])
+	require.Equal(t, 1, values["websocktunnel_auth_failures_total"])
+	require.Equal(t, 1, values["websocktunnel_client_registrations_total"])
+	require.Equal(t, 0, values["websocktunnel_client_reconnections_total"])
+	// the request and response include headers as well as the message
+	require.Greater(t, values[`websocktunnel_proxied_bytes_total{direction="to_client"}`], len("message"))
+	require.Greater(t, values[`websocktunnel_proxied_bytes_total{direction="to_viewer"}`], len("message"))
+
+	// registering again replaces the session, and counts as a reconnection
+	_ = clientServer.Close()
+	clientServer = register()
+	defer clientServer.Close()
+
+	values = scrape(t, metrics)
+	require.Equal(t, 1, values["websocktunnel_clients"])
+	require.Equal(t, 2, values["websocktunnel_client_registrations_total"])
+	require.Equal(t, 1, values["websocktunnel_client_reconnections_total"])
+	require.Equal(t, 1, values["websocktunnel_client_disconnections_total"])
+}
+
+func TestMetricsReconnectWindow(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.clientRegistered("a", false)
+	metrics.clientRegistered("b", false)
+	metrics.clientDisconnected("a")
+	metrics.clientDisconnected("b")
+	// b disconnected too long ago for its registration to be a reconnection
+	metrics.disconnected["b"] = time.Now().Add(-reconnectWindow)
+
+	metrics.clientRegistered("a", false)
+	metrics.clientRegistered("b", false)
+	require.Equal(t, uint64(4), metrics.registrations.Load())
+	require.Equal(t, uint64(1), metrics.reconnections.Load())
+	require.Empty(t, metrics.disconnected)
+}
@@ -0,0 +1,109 @@
+package wsproxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/wsmux"
+)
+
+// tlsHeader is sent by clients at registration, with value "true", if they
+// terminate TLS connections from viewers themselves.  Viewers of such clients
+// must connect with a websocket, whose binary messages are passed through to
+// the client as a byte stream, without the proxy interpreting them.
+const tlsHeader = "x-websocktunnel-tls"
+
+// passthroughBufferSize is the size of the reads from streams, each sent to
+// the viewer as a message
+const passthroughBufferSize = 32 * 1024
+
+// isPassthrough returns true if the client with the given id terminates TLS
+// itself.
+func (p *proxy) isPassthrough(id string) bool {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.passthrough[id]
+}
+
+// passthroughProxy upgrades a viewer request to a websocket, and passes the
+// content of its binary messages through to a new stream to the client, and
+// the data from the stream back as binary messages, until either side closes.
+func (p *proxy) passthroughProxy(w http.ResponseWriter, r *http.Request, session *wsmux.Session, limiter *clientLimiter, tunnelID string) error {
+	stream, err := p.openStream(tunnelID, session, wsmux.PriorityNormal)
+	if err != nil {
+		p.logerrorf(tunnelID, r.RemoteAddr, "could not create stream: path=%s", r.URL.RequestURI())
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+	conn := p.metrics.countBytes(limiter.throttle(stream))
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+	viewerConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		p.logerrorf(tunnelID, r.RemoteAddr, "could not upgrade viewer connection: path=%s, error: %v", r.URL.RequestURI(), err)
+		_ = conn.Close()
+		return err
+	}
+	p.logf(tunnelID, r.RemoteAddr, "passing viewer connection through to the client")
+
+	// closing either connection ends the copy in the other direction
+	done := make(chan error, 2)
+	go func() {
+		done <- copyFromViewer(conn, viewerConn)
+		_ = conn.Close()
+	}()
+	go func() {
+		done <- copyToViewer(viewerConn, conn)
+		_ = viewerConn.Close()
+	}()
+	err = <-done
+	<-done
+	return err
+}
+
+// copyFromViewer writes the binary messages of a viewer connection to a
+// stream, until the viewer closes the connection.
+func copyFromViewer(dest net.Conn, src *websocket.Conn) error {
+	for {
+		mtype, reader, err := src.NextReader()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return err
+			}
+			return nil
+		}
+		if mtype != websocket.BinaryMessage {
+			continue
+		}
+		if _, err := io.Copy(dest, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// copyToViewer sends the data read from a stream to a viewer connection as
+// binary messages, until the stream is closed.
+func copyToViewer(dest *websocket.Conn, src net.Conn) error {
+	buf := make([]byte, passthroughBufferSize)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if err := dest.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			_ = dest.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package wsproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/client"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+func TestTLSPassthrough(t *testing.T) {
+	proxy, err := New(Config{
+		Upgrader:   upgrader,
+		JWTSecretA: []byte("test-secret"),
+		JWTSecretB: []byte("another-secret"),
+		URLPrefix:  "http://localhost",
+		Logger:     genLogger(),
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	// borrow the certificate of a test TLS server, for example.com
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	certServer.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(certServer.Certificate())
+
+	configurer := testConfigurer("myclient", util.MakeWsURL(server.URL), client.RetryConfig{}, genLogger())
+	cl, err := client.New(func() (client.Config, error) {
+		config, err := configurer()
+		config.TLSConfig = &tls.Config{Certificates: certServer.TLS.Certificates}
+		return config, err
+	})
+	require.NoError(t, err)
+	defer cl.Close()
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			http.Error(w, "not TLS", 500)
+			return
+		}
+		_, _ = w.Write([]byte("hello from " + r.URL.Path))
+	})}
+	defer srv.Close()
+	go func() {
+		_ = srv.Serve(cl)
+	}()
+
+	// viewers connect to the client over TLS, carried by a websocket
+	viewer := &http.Client{Transport: &http.Transport{
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return client.DialTLS(server.URL+"/myclient/", &tls.Config{RootCAs: roots, ServerName: "example.com"})
+		},
+	}}
+	for i := 0; i < 2; i++ {
+		res, err := viewer.Get("https://myclient/secure")
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, 200, res.StatusCode, string(body))
+		require.Equal(t, "hello from /secure", string(body))
+		viewer.CloseIdleConnections()
+	}
+
+	// the client's certificate must be trusted
+	_, err = client.DialTLS(server.URL+"/myclient/", &tls.Config{ServerName: "example.com"})
+	require.Error(t, err)
+
+	// plain requests are rejected
+	res, err := http.Get(server.URL + "/myclient/secure")
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
@@ -0,0 +1,202 @@
+package wsproxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// peerSecretHeader authenticates requests between instances
+	peerSecretHeader = "x-websocktunnel-peer-secret"
+
+	// lookupHeader asks an instance whether it holds the session of the
+	// client with the given ID
+	lookupHeader = "x-websocktunnel-lookup"
+
+	// forwardedHeader marks a viewer request forwarded by another instance,
+	// which must not be forwarded again
+	forwardedHeader = "x-websocktunnel-forwarded"
+
+	// lookupTimeout bounds asking the peers for a client
+	lookupTimeout = 2 * time.Second
+
+	// routeTTL is how long the instance holding a client is remembered
+	routeTTL = 30 * time.Second
+)
+
+// peers routes viewer requests for clients connected to other instances of
+// the service to those instances.
+type peers struct {
+	secret  string
+	proxies map[string]*httputil.ReverseProxy
+	client  *http.Client
+
+	m sync.Mutex
+	// the peer holding each client, by client ID
+	routes map[string]route
+}
+
+type route struct {
+	peer    string
+	expires time.Time
+}
+
+func newPeers(urls []string, secret string, logger *log.Logger) *peers {
+	ps := &peers{
+		secret:  secret,
+		proxies: make(map[string]*httputil.ReverseProxy),
+		client:  &http.Client{Timeout: lookupTimeout},
+		routes:  make(map[string]route),
+	}
+	for _, peerURL := range urls {
+		peerURL = strings.TrimSuffix(strings.TrimSpace(peerURL), "/")
+		target, err := url.Parse(peerURL)
+		if err != nil || target.Host == "" {
+			panic("wsproxy: invalid peer URL " + peerURL)
+		}
+		ps.proxies[peerURL] = &httputil.ReverseProxy{
+			Director: func(r *http.Request) {
+				r.URL.Scheme = target.Scheme
+				r.URL.Host = target.Host
+				r.Header.Set(peerSecretHeader, secret)
+				r.Header.Set(forwardedHeader, "true")
+			},
+			// stream responses, such as live logs, as they arrive
+			FlushInterval: -1,
+			ErrorLog:      logger,
+		}
+	}
+	return ps
+}
+
+// authenticated returns true if a request comes from a peer.
+func (ps *peers) authenticated(r *http.Request) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(peerSecretHeader)), []byte(ps.secret)) == 1
+}
+
+// find returns the peer holding the session of a client, asking all peers
+// unless it is known, or "" if none does.
+func (ps *peers) find(id string) string {
+	ps.m.Lock()
+	if route, ok := ps.routes[id]; ok && time.Now().Before(route.expires) {
+		ps.m.Unlock()
+		return route.peer
+	}
+	ps.m.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	found := make(chan string, len(ps.proxies))
+	var wg sync.WaitGroup
+	for peerURL := range ps.proxies {
+		wg.Add(1)
+		go func(peerURL string) {
+			defer wg.Done()
+			if ps.holds(ctx, peerURL, id) {
+				found <- peerURL
+			}
+		}(peerURL)
+	}
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	peer, ok := <-found
+	if !ok {
+		return ""
+	}
+	ps.m.Lock()
+	defer ps.m.Unlock()
+	ps.routes[id] = route{peer: peer, expires: time.Now().Add(routeTTL)}
+	return peer
+}
+
+// holds asks a peer whether it holds the session of a client.
+func (ps *peers) holds(ctx context.Context, peerURL, id string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peerURL+"/", nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set(peerSecretHeader, ps.secret)
+	req.Header.Set(lookupHeader, id)
+	res, err := ps.client.Do(req)
+	if err != nil {
+		return false
+	}
+	_ = res.Body.Close()
+	return res.StatusCode == http.StatusOK
+}
+
+// forget drops the route to a client, once its peer no longer holds it.
+func (ps *peers) forget(id string) {
+	ps.m.Lock()
+	defer ps.m.Unlock()
+	delete(ps.routes, id)
+}
+
+// serveLookup answers a peer asking whether this instance holds the session of
+// a client.
+func (p *proxy) serveLookup(w http.ResponseWriter, r *http.Request) {
+	if p.peers == nil || !p.peers.authenticated(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if _, ok := p.getWorkerSession(r.Header.Get(lookupHeader)); !ok {
+		http.NotFound(w, r)
+	}
+}
+
+// fromPeer returns true if a viewer request was forwarded by a peer, removing
+// the headers added by the peer, which must not reach clients.
+func (p *proxy) fromPeer(r *http.Request) bool {
+	forwarded := p.peers != nil && r.Header.Get(forwardedHeader) != "" && p.peers.authenticated(r)
+	r.Header.Del(forwardedHeader)
+	r.Header.Del(peerSecretHeader)
+	return forwarded
+}
+
+// forwardToPeer forwards a viewer request for a client not connected to this
+// instance to the peer holding its session, if any.  It returns false if no
+// peer holds it.
+func (p *proxy) forwardToPeer(w http.ResponseWriter, r *http.Request, id string) bool {
+	if p.peers == nil {
+		return false
+	}
+	peer := p.peers.find(id)
+	if peer == "" {
+		return false
+	}
+	p.logf(id, r.RemoteAddr, "forwarding request to %s", peer)
+	p.metrics.forwardedRequests.Add(1)
+	// the peer responds with 504 if it no longer holds the client
+	rw := &statusRecorder{ResponseWriter: w}
+	p.peers.proxies[peer].ServeHTTP(rw, r)
+	if rw.status == http.StatusGatewayTimeout {
+		p.peers.forget(id)
+	}
+	return true
+}
+
+// statusRecorder records the status of a response.  It supports flushing and
+// hijacking, as the reverse proxy requires for streaming and websockets.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *statusRecorder) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *statusRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,135 @@
+package wsproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/client"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+// peeredProxies starts two proxies, each a peer of the other
+func peeredProxies(t *testing.T) (*httptest.Server, *httptest.Server) {
+	t.Helper()
+	var handlerA, handlerB http.Handler
+	serverA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerA.ServeHTTP(w, r)
+	}))
+	t.Cleanup(serverA.Close)
+	serverB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerB.ServeHTTP(w, r)
+	}))
+	t.Cleanup(serverB.Close)
+
+	newPeeredProxy := func(peer string) http.Handler {
+		proxy, err := New(Config{
+			Upgrader:   upgrader,
+			Logger:     genLogger(),
+			JWTSecretA: []byte("test-secret"),
+			JWTSecretB: []byte("another-secret"),
+			URLPrefix:  "http://localhost",
+			Peers:      []string{peer},
+			PeerSecret: "peer-secret",
+		})
+		require.NoError(t, err)
+		return proxy
+	}
+	handlerA = newPeeredProxy(serverB.URL)
+	handlerB = newPeeredProxy(serverA.URL)
+	return serverA, serverB
+}
+
+func TestPeerForwarding(t *testing.T) {
+	serverA, serverB := peeredProxies(t)
+
+	// the client connects to B
+	configurer := testConfigurer("myclient", util.MakeWsURL(serverB.URL), client.RetryConfig{}, genLogger())
+	cl, err := client.New(func() (client.Config, error) {
+		config, err := configurer()
+		config.RestrictedPaths = []string{"/shell"}
+		config.AccessToken = "secret"
+		return config, err
+	})
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			mtype, msg, err := conn.ReadMessage()
+			if err == nil {
+				_ = conn.WriteMessage(mtype, msg)
+			}
+			return
+		}
+		// peer headers are not passed on to the client
+		if r.Header.Get(peerSecretHeader) != "" || r.Header.Get(forwardedHeader) != "" {
+			http.Error(w, "peer headers leaked", 500)
+			return
+		}
+		_, _ = w.Write([]byte("hello from " + r.URL.Path))
+	})}
+	go func() {
+		_ = srv.Serve(cl)
+	}()
+
+	get := func(url string, header http.Header) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	// viewers reach the client through either instance
+	for _, server := range []*httptest.Server{serverA, serverB} {
+		status, body := get(server.URL+"/myclient/log", nil)
+		require.Equal(t, 200, status, body)
+		require.Equal(t, "hello from /log", body)
+	}
+
+	// restricted paths are checked by the instance holding the client
+	status, _ := get(serverA.URL+"/myclient/shell", nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, body := get(serverA.URL+"/myclient/shell", http.Header{"X-Websocktunnel-Access-Token": {"secret"}})
+	require.Equal(t, 200, status, body)
+
+	// websockets are forwarded too
+	conn, _, err := websocket.DefaultDialer.Dial(util.MakeWsURL(serverA.URL)+"/myclient/ws", nil)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("echo")))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "echo", string(msg))
+	_ = conn.Close()
+
+	// a viewer cannot pose as a peer
+	status, _ = get(serverA.URL+"/myclient/log", http.Header{"X-Websocktunnel-Forwarded": {"true"}, "X-Websocktunnel-Peer-Secret": {"wrong"}})
+	require.Equal(t, 200, status)
+	status, _ = get(serverA.URL+"/", http.Header{"X-Websocktunnel-Lookup": {"myclient"}, "X-Websocktunnel-Peer-Secret": {"wrong"}})
+	require.Equal(t, http.StatusForbidden, status)
+
+	// once the client is gone, neither instance finds it
+	_ = srv.Close()
+	_ = cl.Close()
+	time.Sleep(100 * time.Millisecond)
+	for _, server := range []*httptest.Server{serverA, serverB} {
+		status, _ := get(server.URL+"/myclient/log", nil)
+		require.Equal(t, http.StatusGatewayTimeout, status)
+	}
+	status, _ = get(serverA.URL+"/otherclient/log", nil)
+	require.Equal(t, http.StatusGatewayTimeout, status)
+}
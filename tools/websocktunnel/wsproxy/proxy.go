@@ -3,9 +3,13 @@ package wsproxy
 import (
 	"bufio"
 	"io"
+	stdlog "log"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,12 +37,18 @@ var (
 // Config contains the run time parameters for the proxy
 type Config struct {
 	// Upgrader is a websocket.Upgrader instance which is used to upgrade incoming
-	// websocket connections from Clients.
+	// websocket connections from Clients.  If its EnableCompression is set,
+	// traffic with clients requesting compression is compressed.
 	Upgrader websocket.Upgrader
 
 	// Logger is used to log proxy events. Refer util.Logger.
 	Logger *logrus.Logger
 
+	// AuditLogger, if given, logs an entry for every viewer request, with the
+	// tunnel ID, path, viewer IP, status and duration.  Entries go to Logger
+	// otherwise.
+	AuditLogger *logrus.Logger
+
 	// JWTSecretA and JWTSecretB are used by the proxy to verify JWTs from Clients.
 	JWTSecretA []byte
 	JWTSecretB []byte
@@ -49,6 +59,23 @@ type Config struct {
 
 	// Audience value for aud claim
 	Audience string
+
+	// Metrics, if given, collects metrics about the proxy; see NewMetrics
+	Metrics *Metrics
+
+	// Limits limits the viewer traffic to each client
+	Limits Limits
+
+	// Revocations, if given, lists revoked client tokens; see NewRevocations
+	Revocations *Revocations
+
+	// Peers are the base URLs of the other instances of the service sharing
+	// the same URLPrefix, at which this instance can reach them.  Viewer
+	// requests for clients not connected to this instance are forwarded to
+	// the instance holding the client's session, if any.  PeerSecret
+	// authenticates the instances to one another, and is required with Peers.
+	Peers      []string
+	PeerSecret string
 }
 
 // proxy is used to send http and ws requests to a registered client.
@@ -56,13 +83,30 @@ type Config struct {
 type proxy struct {
 	m               sync.RWMutex
 	pool            map[string]*wsmux.Session
+	policies        map[string]*accessPolicy
+	passthrough     map[string]bool
+	tokens          map[string]tokenInfo
 	upgrader        websocket.Upgrader
 	logger          *logrus.Logger
+	auditLogger     *logrus.Logger
 	onSessionRemove func(string)
 	jwtSecretA      []byte
 	jwtSecretB      []byte
 	urlPrefix       string
 	audience        string
+	metrics         *Metrics
+	limits          Limits
+	peers           *peers
+	revocations     *Revocations
+
+	// lock for limiters, which holds the limiter of each client with viewer
+	// traffic, when limits are set
+	lm       sync.Mutex
+	limiters map[string]*clientLimiter
+
+	// closed when the session replacing a client's session is registered,
+	// locked by m
+	replacements map[string]chan struct{}
 }
 
 // New creates a new proxy instance and wraps it as an http.Handler.
@@ -80,6 +124,14 @@ func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	if r.URL.Path == "/__version__" {
 		http.ServeFile(w, r, versionJsonPath)
+		return
+	}
+
+	// Peers ask whether a client is connected to this instance with a GET of
+	// path / with a header set
+	if r.URL.Path == "/" && r.Header.Get(lookupHeader) != "" {
+		p.serveLookup(w, r)
+		return
 	}
 
 	// Client registration requests are a GET of path / with some headers set
@@ -109,13 +161,22 @@ func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func newProxy(conf Config) (*proxy, error) {
 	p := &proxy{
-		pool:       make(map[string]*wsmux.Session),
-		upgrader:   conf.Upgrader,
-		logger:     conf.Logger,
-		jwtSecretA: conf.JWTSecretA,
-		jwtSecretB: conf.JWTSecretB,
-		urlPrefix:  strings.TrimSuffix(conf.URLPrefix, "/"),
-		audience:   conf.Audience,
+		pool:         make(map[string]*wsmux.Session),
+		policies:     make(map[string]*accessPolicy),
+		passthrough:  make(map[string]bool),
+		tokens:       make(map[string]tokenInfo),
+		revocations:  conf.Revocations,
+		upgrader:     conf.Upgrader,
+		logger:       conf.Logger,
+		auditLogger:  conf.AuditLogger,
+		jwtSecretA:   conf.JWTSecretA,
+		jwtSecretB:   conf.JWTSecretB,
+		urlPrefix:    strings.TrimSuffix(conf.URLPrefix, "/"),
+		audience:     conf.Audience,
+		metrics:      conf.Metrics,
+		limits:       conf.Limits,
+		limiters:     make(map[string]*clientLimiter),
+		replacements: make(map[string]chan struct{}),
 	}
 
 	if len(p.jwtSecretA) == 0 || len(p.jwtSecretB) == 0 {
@@ -126,6 +187,25 @@ func newProxy(conf Config) (*proxy, error) {
 		logger, _ := nullLog.NewNullLogger()
 		p.logger = logger
 	}
+	if p.auditLogger == nil {
+		p.auditLogger = p.logger
+	}
+
+	if len(conf.Peers) > 0 {
+		if conf.PeerSecret == "" {
+			panic("wsproxy: missing peer secret")
+		}
+		p.peers = newPeers(conf.Peers, conf.PeerSecret, stdlog.New(p.logger.Writer(), "", 0))
+	}
+
+	if p.metrics == nil {
+		p.metrics = NewMetrics()
+	}
+	p.metrics.clients = p.numClients
+
+	if p.revocations != nil {
+		p.revocations.subscribe(p.disconnectRevoked)
+	}
 
 	return p, nil
 
@@ -139,6 +219,32 @@ func (p *proxy) setSessionRemoveHandler(h func(string)) {
 	p.onSessionRemove = h
 }
 
+// openStream opens a stream to a client on its session, with the given
+// priority.  Clients replace their session to use a new token, and streams
+// that were being opened on the previous session when it was closed are
+// opened on the new one.
+func (p *proxy) openStream(id string, session *wsmux.Session, priority wsmux.Priority) (net.Conn, error) {
+	for {
+		stream, err := session.OpenPriority(priority)
+		if err != wsmux.ErrSessionClosed {
+			return stream, err
+		}
+		// wait for a replacement that is still registering; once it has
+		// registered, it is the client's current session
+		p.m.RLock()
+		replacement := p.replacements[id]
+		p.m.RUnlock()
+		if replacement != nil {
+			<-replacement
+		}
+		next, ok := p.getWorkerSession(id)
+		if !ok || next == session {
+			return nil, err
+		}
+		session = next
+	}
+}
+
 // getWorkerSession returns true if a session with the given id is present
 func (p *proxy) getWorkerSession(id string) (*wsmux.Session, bool) {
 	p.m.RLock()
@@ -147,12 +253,23 @@ func (p *proxy) getWorkerSession(id string) (*wsmux.Session, bool) {
 	return s, ok
 }
 
+// numClients returns the number of connected clients
+func (p *proxy) numClients() int {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return len(p.pool)
+}
+
 // removeTunnel is an idempotent operation which deletes a client session from the proxy's
 // pool
 func (p *proxy) removeTunnel(id string) {
 	p.m.Lock()
 	defer p.m.Unlock()
 	delete(p.pool, id)
+	delete(p.policies, id)
+	delete(p.passthrough, id)
+	delete(p.tokens, id)
+	p.removeLimiter(id)
 	p.logf(id, "", "session removed")
 }
 
@@ -170,6 +287,7 @@ func (p *proxy) register(w http.ResponseWriter, r *http.Request, id, tokenString
 	if tokenString == "" {
 		// No jwt. Connection not authorized
 		p.logerrorf(id, r.RemoteAddr, "could not retreive auth token")
+		p.metrics.authFailures.Add(1)
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
@@ -181,15 +299,47 @@ func (p *proxy) register(w http.ResponseWriter, r *http.Request, id, tokenString
 	}
 
 	// validation does not require lock
-	if err := p.validateJWT(id, tokenString); err != nil {
+	claims, err := p.validateJWT(id, tokenString)
+	if err != nil {
 		p.logerrorf(id, r.RemoteAddr, "unable to validate token: %v", err)
+		p.metrics.authFailures.Add(1)
 		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 		return
 	}
+	token := newTokenInfo(tokenString, claims)
+	if p.revocations.revoked(token) {
+		p.logerrorf(id, r.RemoteAddr, "token is revoked")
+		p.metrics.authFailures.Add(1)
+		http.Error(w, "The token is revoked", http.StatusUnauthorized)
+		return
+	}
+
+	policy, err := parseAccessPolicy(r.Header)
+	if err != nil {
+		p.logerrorf(id, r.RemoteAddr, "invalid access policy: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	p.m.Lock()
 
+	// streams that were being opened on an existing session are opened on
+	// this one once it is registered; see openStream
+	if _, ok := p.pool[id]; ok {
+		replacement := make(chan struct{})
+		p.replacements[id] = replacement
+		defer func() {
+			p.m.Lock()
+			if p.replacements[id] == replacement {
+				delete(p.replacements, id)
+			}
+			p.m.Unlock()
+			close(replacement)
+		}()
+	}
+
 	// remove any existing session forcibly
+	replaced := false
 	for {
 		existingSession := p.pool[id]
 		if existingSession == nil {
@@ -200,6 +350,7 @@ func (p *proxy) register(w http.ResponseWriter, r *http.Request, id, tokenString
 		// (which will remove it), and try again.  Note that Session.Close is
 		// properly reentrant, so two goroutines calling this at the same time
 		// will not cause an issue.
+		replaced = true
 		p.m.Unlock()
 		_ = existingSession.Close()
 		p.m.Lock()
@@ -223,8 +374,11 @@ func (p *proxy) register(w http.ResponseWriter, r *http.Request, id, tokenString
 	// generate config
 	conf := wsmux.Config{
 		StreamBufferSize: 4 * 1024,
+		// used if the client asked for compression and the upgrader allows it
+		Compression: p.upgrader.EnableCompression,
 		CloseCallback: func() {
 			p.removeTunnel(id)
+			p.metrics.clientDisconnected(id)
 			if p.onSessionRemove != nil {
 				p.onSessionRemove(id)
 			}
@@ -233,11 +387,47 @@ func (p *proxy) register(w http.ResponseWriter, r *http.Request, id, tokenString
 	}
 
 	p.pool[id] = wsmux.Server(conn, conf)
+	if policy != nil {
+		p.policies[id] = policy
+		p.logf(id, r.RemoteAddr, "restricted paths: %v", policy.restricted)
+	}
+	p.tokens[id] = token
+	if r.Header.Get(tlsHeader) == "true" {
+		p.passthrough[id] = true
+		p.logf(id, r.RemoteAddr, "client terminates TLS")
+	}
+	p.metrics.clientRegistered(id, replaced)
 	p.logf(id, r.RemoteAddr, "added new tunnel")
 }
 
 // serveRequest serves tunnel endpoints to viewers
 func (p *proxy) serveRequest(w http.ResponseWriter, r *http.Request, id string, path string) {
+	// forward requests for clients connected to other instances, unless
+	// forwarded by another instance already
+	fromPeer := p.fromPeer(r)
+	if _, ok := p.getWorkerSession(id); !ok && !fromPeer && p.forwardToPeer(w, r, id) {
+		return
+	}
+
+	// audit every viewer request served by this instance; forwarded requests
+	// are audited by the peer serving them
+	aw := &auditWriter{ResponseWriter: w}
+	w = aw
+	start := time.Now()
+	defer func() {
+		// path no longer holds any access token
+		p.audit(r, id, path, fromPeer, aw, start)
+	}()
+
+	// check access first, so that access tokens are removed before anything
+	// is logged
+	path, ok := p.checkAccess(id, r, path)
+	if !ok {
+		p.logerrorf(id, r.RemoteAddr, "viewer request to a restricted path without a valid access token: path=%s", path)
+		http.Error(w, "An access token is required for this path", http.StatusUnauthorized)
+		return
+	}
+
 	// log new request arrival
 	p.logf(id, r.RemoteAddr, "request: host=%s path=%s", r.Host, path)
 	p.logf(id, r.RemoteAddr, "request: URL: %v", r.URL)
@@ -251,12 +441,43 @@ func (p *proxy) serveRequest(w http.ResponseWriter, r *http.Request, id string,
 		return
 	}
 
+	limiter := p.limiter(id)
+	release, ok, retryAfter := limiter.acquire(p.limits.MaxStreams)
+	if !ok {
+		p.logerrorf(id, r.RemoteAddr, "viewer request exceeds the limits for this client")
+		p.metrics.limitedRequests.Add(1)
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		http.Error(w, "Too many requests for this client", http.StatusTooManyRequests)
+		return
+	}
+	defer func() {
+		release()
+		// forget the limiter if the client disconnected meanwhile
+		if _, ok := p.getWorkerSession(id); !ok {
+			p.removeLimiter(id)
+		}
+	}()
+	defer p.metrics.viewerSession()()
+
 	// set original path as header
 	r.Header.Set("x-websocktunnel-original-path", r.URL.Path)
 
+	// pass connections to clients terminating TLS through as they are
+	if p.isPassthrough(id) {
+		if !websocket.IsWebSocketUpgrade(r) {
+			p.logerrorf(id, r.RemoteAddr, "viewer request to a client terminating TLS is not a websocket")
+			http.Error(w, "This client only accepts TLS connections over websockets", http.StatusBadRequest)
+			return
+		}
+		_ = p.passthroughProxy(w, r, session, limiter, id)
+		return
+	}
+
 	// check for a websocket request
 	if websocket.IsWebSocketUpgrade(r) {
-		_ = p.websocketProxy(w, r, session, id, path)
+		_ = p.websocketProxy(w, r, session, limiter, id, path)
 		return
 	}
 
@@ -269,12 +490,13 @@ func (p *proxy) serveRequest(w http.ResponseWriter, r *http.Request, id string,
 	}
 	r.URL = reqURI
 	p.logf(id, r.RemoteAddr, "attempting to open new stream")
-	reqStream, err := session.Open()
+	stream, err := p.openStream(id, session, wsmux.PriorityNormal)
 	if err != nil {
 		p.logerrorf(id, r.RemoteAddr, "could not open stream: path=%s", path)
 		http.Error(w, http.StatusText(500), 500)
 		return
 	}
+	reqStream := p.metrics.countBytes(limiter.throttle(stream))
 
 	// rewrite path for tunnel and write request
 	err = r.Write(reqStream)
@@ -331,7 +553,7 @@ func (p *proxy) serveRequest(w http.ResponseWriter, r *http.Request, id string,
 
 // validate jwt
 // jwt signing and verification algorithm must be HMAC
-func (p *proxy) validateJWT(id string, tokenString string) error {
+func (p *proxy) validateJWT(id string, tokenString string) (jwt.MapClaims, error) {
 	// parse jwt token
 	// default parser verifies iat token if present. This can be a problem because of clocks not being
 	// in sync.
@@ -361,7 +583,7 @@ func (p *proxy) validateJWT(id string, tokenString string) error {
 
 	if err != nil {
 		p.logerrorf(id, "", "%v: auth failed", err)
-		return ErrAuthFailed
+		return nil, ErrAuthFailed
 	}
 
 	// check claims
@@ -370,34 +592,34 @@ func (p *proxy) validateJWT(id string, tokenString string) error {
 
 	if !ok {
 		p.logerrorf(id, "", "%v: could not parse claims", err)
-		return ErrTokenNotValid
+		return nil, ErrTokenNotValid
 	}
 	p.logf(id, "", "claims: %v", claims)
 
 	if !claims.VerifyExpiresAt(now, true) {
 		p.logerrorf(id, "", "%v", err)
-		return ErrAuthFailed
+		return nil, ErrAuthFailed
 	}
 	if !claims.VerifyNotBefore(now, true) {
 		p.logerrorf(id, "", "%v", err)
-		return ErrAuthFailed
+		return nil, ErrAuthFailed
 	}
 	if claims["tid"] != id {
 		p.logerrorf(id, "", "%v", err)
-		return ErrAuthFailed
+		return nil, ErrAuthFailed
 	}
 
 	if claims["exp"].(float64)-claims["nbf"].(float64) > float64(monthUnix) {
 		p.logerrorf(id, "", "jwt should not be valid for more than 31 days")
-		return ErrAuthFailed
+		return nil, ErrAuthFailed
 	}
 
 	if !claims.VerifyAudience(p.audience, false) {
 		p.logerrorf(id, "", "%v", err)
-		return ErrAuthFailed
+		return nil, ErrAuthFailed
 	}
 
-	return nil
+	return claims, nil
 }
 
 // proxy logging utilities
@@ -680,6 +680,77 @@ func TestProxySessionRemoved(t *testing.T) {
 	}
 }
 
+// Ensure a viewer request whose stream was not accepted on a client's session
+// when the client replaced it is served on the new session
+func TestProxyStreamOpenedDuringReplacement(t *testing.T) {
+	proxyConfig := Config{
+		Upgrader:   upgrader,
+		Logger:     genLogger(),
+		JWTSecretA: []byte("test-secret"),
+		JWTSecretB: []byte("another-secret"),
+		URLPrefix:  "http://localhost",
+	}
+	proxy, err := New(proxyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	wsURL := util.MakeWsURL(server.URL)
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+workeridjwt)
+	header.Set("x-websocktunnel-id", "workerid")
+	previousWs, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the previous session does not accept streams, as a client reconnecting
+	// with a new token does not
+	previous := wsmux.Client(previousWs, wsmux.Config{})
+	previous.PauseAccept()
+	go func() {
+		_, _ = previous.Accept()
+	}()
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(server.URL + "/workerid/")
+		results <- result{resp, err}
+	}()
+	require.Eventually(t, func() bool { return previous.NumStreams() > 0 }, 3*time.Second, 10*time.Millisecond)
+
+	clientWs, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("GET successful"))
+	})}
+	go func() {
+		_ = clientServer.Serve(wsmux.Client(clientWs, wsmux.Config{}))
+	}()
+	defer func() {
+		_ = clientServer.Close()
+	}()
+
+	select {
+	case res := <-results:
+		require.NoError(t, res.err)
+		defer res.resp.Body.Close()
+		require.Equal(t, http.StatusOK, res.resp.StatusCode)
+		reply, err := io.ReadAll(res.resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "GET successful", string(reply))
+	case <-time.After(5 * time.Second):
+		t.Fatal("viewer request was not served")
+	}
+}
+
 // Simple test to ensure that proxy authenticates valid jwt and rejects other jwt
 func TestProxyAuth(t *testing.T) {
 	proxyConfig := Config{
@@ -0,0 +1,272 @@
+package wsproxy
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/wsmux"
+)
+
+// fetchTimeout bounds fetching a remote revocation list
+const fetchTimeout = 30 * time.Second
+
+// Revocation revokes client tokens, either a single token, or the tokens for a
+// tunnel ID (the tid claim) or a Taskcluster client (the sub claim).  Exactly
+// one of TokenHash, TunnelID and ClientID must be set.
+type Revocation struct {
+	// TokenHash is the hex-encoded SHA-256 hash of the revoked token, so that
+	// revocation lists do not contain usable tokens.
+	TokenHash string `json:"tokenHash,omitempty"`
+
+	// TunnelID revokes the tokens for this tunnel ID.
+	TunnelID string `json:"tunnelId,omitempty"`
+
+	// ClientID revokes the tokens issued to this Taskcluster client.
+	ClientID string `json:"clientId,omitempty"`
+
+	// IssuedBefore limits the revocation of the tokens for TunnelID or
+	// ClientID to those issued before this time, so that new tokens can be
+	// issued once the cause of the revocation is addressed.  If zero, all
+	// such tokens are revoked.  Revocations made through the admin endpoint
+	// default to the time of the revocation.
+	IssuedBefore time.Time `json:"issuedBefore"`
+
+	// Expires is when the revocation is dropped.  Revocations made through
+	// the admin endpoint expire after the maximum lifetime of a token, when
+	// the tokens they revoke have expired.  Revocations from a remote list
+	// do not expire.
+	Expires time.Time `json:"expires"`
+}
+
+func (rev Revocation) validate() error {
+	set := 0
+	for _, v := range []string{rev.TokenHash, rev.TunnelID, rev.ClientID} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of tokenHash, tunnelId and clientId must be given")
+	}
+	if rev.TokenHash != "" {
+		if b, err := hex.DecodeString(rev.TokenHash); err != nil || len(b) != sha256.Size {
+			return errors.New("tokenHash must be a hex-encoded SHA-256 hash")
+		}
+	}
+	return nil
+}
+
+// revokes returns true if the revocation applies to a token.
+func (rev Revocation) revokes(token tokenInfo, now time.Time) bool {
+	if !rev.Expires.IsZero() && now.After(rev.Expires) {
+		return false
+	}
+	switch {
+	case rev.TokenHash != "":
+		return subtle.ConstantTimeCompare([]byte(rev.TokenHash), []byte(token.hash)) == 1
+	case rev.TunnelID != "" && rev.TunnelID != token.tunnelID:
+		return false
+	case rev.ClientID != "" && rev.ClientID != token.clientID:
+		return false
+	}
+	return rev.IssuedBefore.IsZero() || token.issued.Before(rev.IssuedBefore)
+}
+
+// tokenInfo is what revocations are matched against in a client's token.
+type tokenInfo struct {
+	hash     string
+	tunnelID string
+	clientID string
+	issued   time.Time
+}
+
+// newTokenInfo gets the information about a validated token from its claims.
+// Tokens are issued when they become valid if they have no iat claim.
+func newTokenInfo(tokenString string, claims jwt.MapClaims) tokenInfo {
+	hash := sha256.Sum256([]byte(tokenString))
+	info := tokenInfo{hash: hex.EncodeToString(hash[:])}
+	info.tunnelID, _ = claims["tid"].(string)
+	info.clientID, _ = claims["sub"].(string)
+	if issued, ok := claims["iat"].(float64); ok {
+		info.issued = time.Unix(int64(issued), 0)
+	} else if nbf, ok := claims["nbf"].(float64); ok {
+		info.issued = time.Unix(int64(nbf), 0)
+	}
+	return info
+}
+
+// Revocations is a list of revoked client tokens.  Clients with revoked tokens
+// can not register, and those already connected are disconnected.  Create one
+// with NewRevocations and pass it to New in Config.Revocations.
+//
+// Revocations is also an http.Handler for administrators to revoke tokens,
+// which should be served on a separate port, as its paths would otherwise
+// conflict with viewer URLs.  A GET lists the revocations, and a POST of a
+// Revocation as JSON adds it.  Requests must have the admin token given to
+// NewRevocations as a bearer token.
+type Revocations struct {
+	adminToken string
+	client     *http.Client
+
+	m sync.Mutex
+	// revocations made through the admin endpoint, and from the remote list
+	local, remote []Revocation
+	// called after revocations are added
+	onChange []func()
+}
+
+// NewRevocations creates an empty revocation list, administered with the given
+// token.  If it is empty, the admin endpoint rejects all requests.
+func NewRevocations(adminToken string) *Revocations {
+	return &Revocations{
+		adminToken: adminToken,
+		client:     &http.Client{Timeout: fetchTimeout},
+	}
+}
+
+// Revoke adds a revocation, defaulting IssuedBefore to now for revocations of
+// the tokens of a tunnel or client.
+func (rv *Revocations) Revoke(rev Revocation) error {
+	if err := rev.validate(); err != nil {
+		return err
+	}
+	now := time.Now()
+	if rev.TokenHash == "" && rev.IssuedBefore.IsZero() {
+		rev.IssuedBefore = now
+	}
+	rev.Expires = now.Add(monthUnix)
+
+	rv.m.Lock()
+	// drop expired revocations
+	local := rv.local[:0]
+	for _, r := range rv.local {
+		if now.Before(r.Expires) {
+			local = append(local, r)
+		}
+	}
+	rv.local = append(local, rev)
+	rv.m.Unlock()
+	rv.changed()
+	return nil
+}
+
+// Fetch replaces the revocations from the remote list with those at url, a
+// JSON array of Revocation objects.  Call it periodically to follow changes to
+// the list.
+func (rv *Revocations) Fetch(url string) error {
+	res, err := rv.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching revocation list: %s", res.Status)
+	}
+	var remote []Revocation
+	if err := json.NewDecoder(res.Body).Decode(&remote); err != nil {
+		return fmt.Errorf("invalid revocation list: %w", err)
+	}
+	for _, rev := range remote {
+		if err := rev.validate(); err != nil {
+			return fmt.Errorf("invalid revocation list: %w", err)
+		}
+	}
+
+	rv.m.Lock()
+	rv.remote = remote
+	rv.m.Unlock()
+	rv.changed()
+	return nil
+}
+
+// revoked returns true if a token is revoked.
+func (rv *Revocations) revoked(token tokenInfo) bool {
+	if rv == nil {
+		return false
+	}
+	now := time.Now()
+	rv.m.Lock()
+	defer rv.m.Unlock()
+	for _, list := range [][]Revocation{rv.local, rv.remote} {
+		for _, rev := range list {
+			if rev.revokes(token, now) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subscribe registers a function to call after revocations are added.
+func (rv *Revocations) subscribe(f func()) {
+	rv.m.Lock()
+	defer rv.m.Unlock()
+	rv.onChange = append(rv.onChange, f)
+}
+
+func (rv *Revocations) changed() {
+	rv.m.Lock()
+	onChange := rv.onChange
+	rv.m.Unlock()
+	for _, f := range onChange {
+		f()
+	}
+}
+
+// ServeHTTP serves the admin endpoint.
+func (rv *Revocations) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	expected := "Bearer " + rv.adminToken
+	if rv.adminToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rv.m.Lock()
+		list := append(append([]Revocation{}, rv.local...), rv.remote...)
+		rv.m.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(list)
+	case http.MethodPost:
+		var rev Revocation
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&rev); err != nil {
+			http.Error(w, "invalid revocation: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := rv.Revoke(rev); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// disconnectRevoked closes the sessions of clients whose tokens are revoked.
+func (p *proxy) disconnectRevoked() {
+	p.m.RLock()
+	revoked := make(map[string]*wsmux.Session)
+	for id, token := range p.tokens {
+		if session, ok := p.pool[id]; ok && p.revocations.revoked(token) {
+			revoked[id] = session
+		}
+	}
+	p.m.RUnlock()
+
+	// closing a session removes it, which needs the lock
+	for id, session := range revoked {
+		p.logf(id, "", "disconnecting client with a revoked token")
+		_ = session.Close()
+	}
+}
@@ -0,0 +1,157 @@
+package wsproxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/util"
+)
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func TestRevocationRevokes(t *testing.T) {
+	now := time.Now()
+	token := tokenInfo{hash: hashToken("token"), tunnelID: "worker", clientID: "project/worker", issued: now.Add(-time.Hour)}
+
+	for _, test := range []struct {
+		name    string
+		rev     Revocation
+		revokes bool
+	}{
+		{"token", Revocation{TokenHash: hashToken("token")}, true},
+		{"other token", Revocation{TokenHash: hashToken("other")}, false},
+		{"tunnel", Revocation{TunnelID: "worker"}, true},
+		{"other tunnel", Revocation{TunnelID: "other"}, false},
+		{"tunnel, token issued before", Revocation{TunnelID: "worker", IssuedBefore: now}, true},
+		{"tunnel, token issued after", Revocation{TunnelID: "worker", IssuedBefore: now.Add(-2 * time.Hour)}, false},
+		{"client", Revocation{ClientID: "project/worker"}, true},
+		{"other client", Revocation{ClientID: "project/other"}, false},
+		{"expired", Revocation{TunnelID: "worker", Expires: now.Add(-time.Minute)}, false},
+	} {
+		require.Equal(t, test.revokes, test.rev.revokes(token, now), test.name)
+	}
+
+	require.Error(t, Revocation{}.validate())
+	require.Error(t, Revocation{TunnelID: "worker", ClientID: "project/worker"}.validate())
+	require.Error(t, Revocation{TokenHash: "token"}.validate())
+}
+
+func TestRevocationsAdmin(t *testing.T) {
+	rv := NewRevocations("admin-token")
+	server := httptest.NewServer(rv)
+	defer server.Close()
+
+	request := func(method, token, body string) *http.Response {
+		return adminRequest(t, server.URL, method, token, body)
+	}
+
+	require.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "", "").StatusCode)
+	require.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "wrong", `{"tunnelId": "worker"}`).StatusCode)
+	require.Equal(t, http.StatusBadRequest, request(http.MethodPost, "admin-token", `{}`).StatusCode)
+	require.Equal(t, http.StatusCreated, request(http.MethodPost, "admin-token", `{"tunnelId": "worker"}`).StatusCode)
+	require.Equal(t, http.StatusOK, request(http.MethodGet, "admin-token", "").StatusCode)
+
+	require.True(t, rv.revoked(tokenInfo{tunnelID: "worker", issued: time.Now().Add(-time.Minute)}))
+	// tokens issued after the revocation are accepted
+	require.False(t, rv.revoked(tokenInfo{tunnelID: "worker", issued: time.Now().Add(time.Minute)}))
+
+	// without an admin token, the endpoint is disabled
+	disabled := httptest.NewServer(NewRevocations(""))
+	defer disabled.Close()
+	require.Equal(t, http.StatusUnauthorized, adminRequest(t, disabled.URL, http.MethodGet, "", "").StatusCode)
+}
+
+func adminRequest(t *testing.T, url, method, token, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	return res
+}
+
+func TestRevocationsFetch(t *testing.T) {
+	list := `[{"tunnelId": "worker"}, {"tokenHash": "` + hashToken("token") + `"}]`
+	listServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(list))
+	}))
+	defer listServer.Close()
+
+	rv := NewRevocations("")
+	require.NoError(t, rv.Fetch(listServer.URL))
+	require.True(t, rv.revoked(tokenInfo{tunnelID: "worker", issued: time.Now()}))
+	require.True(t, rv.revoked(tokenInfo{hash: hashToken("token")}))
+
+	// each fetch replaces the list
+	list = `[]`
+	require.NoError(t, rv.Fetch(listServer.URL))
+	require.False(t, rv.revoked(tokenInfo{tunnelID: "worker", issued: time.Now()}))
+
+	list = `[{"tunnelId": "worker", "clientId": "project/worker"}]`
+	require.Error(t, rv.Fetch(listServer.URL))
+}
+
+func TestRevokedClientDisconnected(t *testing.T) {
+	rv := NewRevocations("admin-token")
+	proxy, err := newProxy(Config{
+		Upgrader:    upgrader,
+		Logger:      genLogger(),
+		JWTSecretA:  []byte("test-secret"),
+		JWTSecretB:  []byte("another-secret"),
+		URLPrefix:   "http://localhost",
+		Revocations: rv,
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	register := func(token string) (*websocket.Conn, *http.Response, error) {
+		header := make(http.Header)
+		header.Set("Authorization", "Bearer "+token)
+		header.Set("x-websocktunnel-id", "workerid")
+		return websocket.DefaultDialer.Dial(util.MakeWsURL(server.URL), header)
+	}
+
+	conn, _, err := register(workeridjwt)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, ok := proxy.getWorkerSession("workerid")
+	require.True(t, ok)
+
+	// revoking the token disconnects the client immediately
+	require.NoError(t, rv.Revoke(Revocation{TokenHash: hashToken(workeridjwt)}))
+	_, ok = proxy.getWorkerSession("workerid")
+	require.False(t, ok)
+
+	// and it can not register again
+	_, res, err := register(workeridjwt)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// other tokens for the same client are still accepted
+	other := jwt.New(jwt.SigningMethodHS256)
+	other.Claims.(jwt.MapClaims)["nbf"] = time.Now().Unix() - 300
+	other.Claims.(jwt.MapClaims)["exp"] = time.Now().Add(time.Hour).Unix()
+	other.Claims.(jwt.MapClaims)["tid"] = "workerid"
+	other.Claims.(jwt.MapClaims)["sub"] = "project/worker"
+	otherToken, err := other.SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+	conn, _, err = register(otherToken)
+	require.NoError(t, err)
+	_ = conn.Close()
+}
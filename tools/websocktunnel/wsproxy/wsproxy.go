@@ -11,11 +11,13 @@ import (
 	"github.com/taskcluster/taskcluster/v60/tools/websocktunnel/wsmux"
 )
 
-func (p *proxy) websocketProxy(w http.ResponseWriter, r *http.Request, session *wsmux.Session, tunnelID string, path string) error {
+func (p *proxy) websocketProxy(w http.ResponseWriter, r *http.Request, session *wsmux.Session, limiter *clientLimiter, tunnelID string, path string) error {
 	// at this point, we are sure that r is a http websocket upgrade request
 	// connClosure returns the wsmux stream to Dial
 	p.logf(tunnelID, r.RemoteAddr, "creating WS bridge: path=%s", r.URL.RequestURI())
-	stream, err := session.Open()
+	// websockets typically carry interactive traffic, such as shells, which
+	// must not wait behind bulk transfers, such as live logs
+	stream, err := p.openStream(tunnelID, session, wsmux.PriorityHigh)
 	p.logf(tunnelID, r.RemoteAddr, "opened new stream for ws: path=%s", r.URL.RequestURI())
 
 	if err != nil {
@@ -23,7 +25,7 @@ func (p *proxy) websocketProxy(w http.ResponseWriter, r *http.Request, session *
 		return err
 	}
 	connClosure := func(network, addr string) (net.Conn, error) {
-		return stream, nil
+		return p.metrics.countBytes(limiter.throttle(stream)), nil
 	}
 	dialer := &websocket.Dialer{
 		NetDial:      connClosure,
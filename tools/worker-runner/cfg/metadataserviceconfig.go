@@ -0,0 +1,7 @@
+package cfg
+
+// Configuration for the local metadata service.  See the usage string for
+// field descriptions.
+type MetadataServiceConfig struct {
+	Address string `yaml:"address"`
+}
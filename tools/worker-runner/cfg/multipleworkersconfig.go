@@ -0,0 +1,10 @@
+package cfg
+
+// Configuration for running multiple workers on the same host.  See the usage
+// string for field descriptions.
+type MultipleWorkersConfig struct {
+	Count             int             `yaml:"count"`
+	WorkerPaths       []string        `yaml:"workerPaths"`
+	WorkerConfigPaths []string        `yaml:"workerConfigPaths"`
+	WorkerConfigs     []*WorkerConfig `yaml:"workerConfigs"`
+}
@@ -0,0 +1,10 @@
+package cfg
+
+// Configuration for HTTP proxies and additional trusted CA certificates.  See
+// the usage string for field descriptions.
+type NetworkConfig struct {
+	HTTPProxy          string   `yaml:"httpProxy"`
+	HTTPSProxy         string   `yaml:"httpsProxy"`
+	NoProxy            string   `yaml:"noProxy"`
+	CACertificateFiles []string `yaml:"caCertificateFiles"`
+}
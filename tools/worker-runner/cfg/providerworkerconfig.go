@@ -15,6 +15,10 @@ import (
 type ProviderWorkerConfig struct {
 	Config *WorkerConfig `json:"config,omitempty"`
 	Files  []files.File  `json:"files,omitempty"`
+
+	// Options for worker-runner itself, from a `workerRunner` property
+	// alongside the worker implementation's property.
+	RunnerOptions *WorkerConfig `json:"-"`
 }
 
 // ParseProviderWorkerConfig takes a RawMessage representing the `workerConfig`
@@ -38,12 +42,21 @@ func ParseProviderWorkerConfig(runnercfg *RunnerConfig, body *json.RawMessage) (
 		return pwc, fmt.Errorf("while parsing workerConfig from worker-manager: %s", err)
 	}
 
-	if len(bodyMap) == 1 {
+	// ..optionally accompanied by options for worker-runner
+	runnerOptions, hasRunnerOptions := bodyMap["workerRunner"]
+	if len(bodyMap) == 1 || (len(bodyMap) == 2 && hasRunnerOptions) {
 		if inner, ok := bodyMap[workerImpl]; ok {
 			err = json.Unmarshal(inner, &pwc)
 			if err != nil {
 				return pwc, fmt.Errorf("while parsing workerConfig from worker-manager: %s", err)
 			}
+			if hasRunnerOptions {
+				pwc.RunnerOptions = NewWorkerConfig()
+				err = json.Unmarshal(runnerOptions, pwc.RunnerOptions)
+				if err != nil {
+					return pwc, fmt.Errorf("while parsing workerConfig.workerRunner from worker-manager: %s", err)
+				}
+			}
 			return pwc, nil
 		}
 	}
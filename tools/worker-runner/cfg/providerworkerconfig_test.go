@@ -35,6 +35,25 @@ func TestPWCCorrectForm(t *testing.T) {
 	require.Equal(t, "my file", pwc.Files[0].Description)
 }
 
+func TestPWCRunnerOptions(t *testing.T) {
+	runnercfg := makeRunnerConfig("test-worker")
+	message := json.RawMessage(`{
+	  "testWorker": {
+	    "config": {
+	      "someValue": true
+	    }
+	  },
+	  "workerRunner": {
+	    "someOption": "yes"
+	  }
+	}`)
+	pwc, err := ParseProviderWorkerConfig(runnercfg, &message)
+	require.NoError(t, err)
+	require.Equal(t, true, pwc.Config.MustGet("someValue"))
+	require.Equal(t, "yes", pwc.RunnerOptions.MustGet("someOption"))
+	require.False(t, pwc.Config.Has("workerRunner"))
+}
+
 func TestPWCCompatibilityFlatFormForm(t *testing.T) {
 	runnercfg := makeRunnerConfig("test-worker")
 	message := json.RawMessage(`{
@@ -15,6 +15,11 @@ type RunnerConfig struct {
 	Logging              *LoggingConfig             `yaml:"logging"`
 	GetSecrets           bool                       `yaml:"getSecrets"`
 	CacheOverRestarts    string                     `yaml:"cacheOverRestarts"`
+	SecretBackends       *SecretBackendsConfig      `yaml:"secretBackends"`
+	MultipleWorkers      *MultipleWorkersConfig     `yaml:"multipleWorkers"`
+	CrashLoopThreshold   int                        `yaml:"crashLoopThreshold"`
+	Network              *NetworkConfig             `yaml:"network"`
+	MetadataService      *MetadataServiceConfig     `yaml:"metadataService"`
 }
 
 // Load a configuration file
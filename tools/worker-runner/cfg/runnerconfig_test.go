@@ -20,4 +20,6 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, "ec2", runnercfg.Provider.ProviderType, "should read providerType correctly")
 	assert.Equal(t, 10.0, runnercfg.WorkerConfig.MustGet("x"), "should read workerConfig correctly")
 	assert.Equal(t, true, runnercfg.GetSecrets, "getSecrets should default to true")
+	assert.Equal(t, "gcp", runnercfg.SecretBackends.Vault.AuthMethod, "should read secretBackends correctly")
+	assert.Nil(t, runnercfg.SecretBackends.AWS, "should leave unconfigured secret backends nil")
 }
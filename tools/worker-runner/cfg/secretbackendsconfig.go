@@ -0,0 +1,37 @@
+package cfg
+
+// Configuration for the external secret stores which worker configuration
+// values may refer to.  Only stores that require configuration appear here.
+type SecretBackendsConfig struct {
+	AWS   *AWSSecretsManagerConfig `yaml:"awsSecretsManager"`
+	Vault *VaultConfig             `yaml:"vault"`
+}
+
+// Configuration for AWS Secrets Manager.
+type AWSSecretsManagerConfig struct {
+	// Region containing the secrets; this defaults to the region in which the
+	// instance is running.
+	Region string `yaml:"region"`
+}
+
+// Configuration for HashiCorp Vault.
+type VaultConfig struct {
+	// Address of the Vault server, e.g., `https://vault.example.com:8200`.
+	Address string `yaml:"address"`
+
+	// Vault namespace, if any (Vault Enterprise).
+	Namespace string `yaml:"namespace"`
+
+	// Method used to log in to Vault: `aws`, `gcp`, or `token`.
+	AuthMethod string `yaml:"authMethod"`
+
+	// Path at which the auth method is mounted; this defaults to the name of
+	// the auth method.
+	AuthMount string `yaml:"authMount"`
+
+	// Vault role to log in as, for the `aws` and `gcp` methods.
+	Role string `yaml:"role"`
+
+	// File containing a Vault token, for the `token` method.
+	TokenPath string `yaml:"tokenPath"`
+}
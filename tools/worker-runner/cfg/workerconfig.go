@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"reflect"
 	"strings"
 
 	yaml "gopkg.in/yaml.v3"
@@ -228,6 +229,76 @@ func (wc *WorkerConfig) Has(key string) bool {
 	return err == nil
 }
 
+func replaceStrings(value interface{}, fn func(string) (string, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case map[string]interface{}:
+		res := make(map[string]interface{})
+		for key, value := range v {
+			var err error
+			res[key], err = replaceStrings(value, fn)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, value := range v {
+			var err error
+			res[i], err = replaceStrings(value, fn)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	default:
+		return value, nil
+	}
+}
+
+// Replace every string value in the configuration, at any depth, with the
+// result of calling fn on it.  Object keys are not affected.
+//
+// This returns a new WorkerConfig containing the updated values.
+func (wc *WorkerConfig) ReplaceStrings(fn func(string) (string, error)) (*WorkerConfig, error) {
+	if wc == nil {
+		return NewWorkerConfig(), nil
+	}
+	data, err := replaceStrings(wc.data, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkerConfig{
+		data: data.(map[string]interface{}),
+	}, nil
+}
+
+// Return a WorkerConfig containing the top-level properties of other that are
+// not present in, or have different values from, this WorkerConfig.
+// Properties removed in other are not reflected in the result.
+func (wc *WorkerConfig) Diff(other *WorkerConfig) *WorkerConfig {
+	res := NewWorkerConfig()
+	if other == nil {
+		return res
+	}
+	for key, value := range other.data {
+		if wc != nil {
+			if existing, ok := wc.data[key]; ok && reflect.DeepEqual(existing, value) {
+				continue
+			}
+		}
+		res.data[key] = value
+	}
+	return res
+}
+
+// Return true if the configuration has no properties
+func (wc *WorkerConfig) IsEmpty() bool {
+	return wc == nil || len(wc.data) == 0
+}
+
 func NewWorkerConfig() *WorkerConfig {
 	return &WorkerConfig{
 		data: make(map[string]interface{}),
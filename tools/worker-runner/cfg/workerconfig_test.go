@@ -236,3 +236,56 @@ func TestGet(t *testing.T) {
 	assert.NoError(t, err, "shouldn't fail")
 	assert.Equal(t, "z", res, "got correct value")
 }
+
+func TestReplaceStrings(t *testing.T) {
+	var wc WorkerConfig
+
+	err := json.Unmarshal([]byte(`{"a": "x", "b": {"c": ["x", "y", 10]}, "x": true}`), &wc)
+	assert.NoError(t, err, "shouldn't fail")
+	wc2, err := wc.ReplaceStrings(func(s string) (string, error) {
+		if s == "x" {
+			return "replaced", nil
+		}
+		return s, nil
+	})
+	assert.NoError(t, err, "shouldn't fail")
+	assert.Equal(t,
+		map[string]interface{}{
+			"a": "replaced",
+			"b": map[string]interface{}{
+				"c": []interface{}{"replaced", "y", 10.0},
+			},
+			"x": true,
+		}, wc2.data, "should replace string values")
+
+	// and just check wc wasn't modified
+	assert.Equal(t, "x", wc.MustGet("a"), "should not change original")
+}
+
+func TestReplaceStringsError(t *testing.T) {
+	var wc WorkerConfig
+
+	err := json.Unmarshal([]byte(`{"a": {"b": "x"}}`), &wc)
+	assert.NoError(t, err, "shouldn't fail")
+	_, err = wc.ReplaceStrings(func(s string) (string, error) {
+		return "", fmt.Errorf("uhoh")
+	})
+	assert.Equal(t, fmt.Errorf("uhoh"), err, "should have errored")
+}
+
+func TestDiff(t *testing.T) {
+	var wc1, wc2 WorkerConfig
+
+	err := json.Unmarshal([]byte(`{"same": {"x": 1}, "changed": [1, 2], "removed": true}`), &wc1)
+	assert.NoError(t, err, "shouldn't fail")
+	err = json.Unmarshal([]byte(`{"same": {"x": 1}, "changed": [1, 3], "added": "yes"}`), &wc2)
+	assert.NoError(t, err, "shouldn't fail")
+
+	diff := wc1.Diff(&wc2)
+	assert.Equal(t,
+		map[string]interface{}{
+			"changed": []interface{}{1.0, 3.0},
+			"added":   "yes",
+		}, diff.data, "should contain only new and changed properties")
+	assert.True(t, wc1.Diff(&wc1).IsEmpty(), "should be empty for identical configs")
+}
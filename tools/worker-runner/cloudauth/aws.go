@@ -0,0 +1,75 @@
+// Package cloudauth gets credentials for cloud APIs from the instance
+// metadata services of the clouds in which workers run, for use by
+// worker-runner components that call those APIs directly.
+package cloudauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+// Base URL of the EC2 instance metadata service (overridden in tests)
+var AWSMetadataBaseURL = "http://169.254.169.254/latest"
+
+// AWSCredentials are temporary credentials for an AWS IAM role
+type AWSCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+
+	// time at which the credentials expire; zero if unknown
+	Expiration time.Time `json:"Expiration"`
+}
+
+// Expiring returns true if the credentials expire within the given duration
+func (c *AWSCredentials) Expiring(within time.Duration) bool {
+	return !c.Expiration.IsZero() && time.Until(c.Expiration) < within
+}
+
+// QueryAWSMetadata gets the given path from the EC2 instance metadata service
+func QueryAWSMetadata(path string) (string, error) {
+	resp, _, err := httpbackoff.Get(AWSMetadataBaseURL + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	return string(content), err
+}
+
+// AWSInstanceRegion gets the region in which this instance is running
+func AWSInstanceRegion() (string, error) {
+	region, err := QueryAWSMetadata("/meta-data/placement/region")
+	if err != nil {
+		return "", fmt.Errorf("could not determine instance region: %w", err)
+	}
+	return strings.TrimSpace(region), nil
+}
+
+// AWSInstanceCredentials gets the temporary credentials for the IAM role of
+// this instance's instance profile.
+func AWSInstanceCredentials() (*AWSCredentials, error) {
+	roles, err := QueryAWSMetadata("/meta-data/iam/security-credentials/")
+	if err != nil {
+		return nil, fmt.Errorf("could not determine instance IAM role: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(roles), "\n")
+	if role == "" {
+		return nil, fmt.Errorf("instance has no IAM role")
+	}
+	content, err := QueryAWSMetadata("/meta-data/iam/security-credentials/" + role)
+	if err != nil {
+		return nil, fmt.Errorf("could not get credentials for instance IAM role %s: %w", role, err)
+	}
+	creds := &AWSCredentials{}
+	err = json.Unmarshal([]byte(content), creds)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse credentials for instance IAM role %s: %w", role, err)
+	}
+	return creds, nil
+}
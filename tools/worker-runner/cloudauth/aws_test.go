@@ -0,0 +1,38 @@
+package cloudauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSInstanceCredentials(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("worker-role\n"))
+	})
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/worker-role", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"AccessKeyId": "AKID", "SecretAccessKey": "SECRET", "Token": "TOKEN", "Expiration": "2030-01-02T03:04:05Z"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	oldMetadataBaseURL := AWSMetadataBaseURL
+	AWSMetadataBaseURL = server.URL + "/latest"
+	defer func() { AWSMetadataBaseURL = oldMetadataBaseURL }()
+
+	creds, err := AWSInstanceCredentials()
+	require.NoError(t, err)
+	require.Equal(t, "AKID", creds.AccessKeyID)
+	require.Equal(t, "TOKEN", creds.Token)
+	require.Equal(t, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), creds.Expiration)
+}
+
+func TestAWSCredentialsExpiring(t *testing.T) {
+	require.False(t, (&AWSCredentials{}).Expiring(time.Hour), "unknown expiration")
+	require.False(t, (&AWSCredentials{Expiration: time.Now().Add(2 * time.Hour)}).Expiring(time.Hour))
+	require.True(t, (&AWSCredentials{Expiration: time.Now().Add(30 * time.Minute)}).Expiring(time.Hour))
+}
@@ -0,0 +1,65 @@
+package cloudauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Base URL of the GCE metadata service (overridden in tests)
+var GCPMetadataBaseURL = "http://metadata.google.internal/computeMetadata/v1"
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// QueryGCPMetadata gets the given path from the GCE metadata service
+func QueryGCPMetadata(path string) ([]byte, error) {
+	req, err := http.NewRequest("GET", GCPMetadataBaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+	return content, nil
+}
+
+// GCPAccessToken gets an OAuth2 access token for the instance's service
+// account, and the time at which it expires.
+func GCPAccessToken() (string, time.Time, error) {
+	content, err := QueryGCPMetadata("/instance/service-accounts/default/token")
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not get access token for instance service account: %w", err)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err = json.Unmarshal(content, &token)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not parse access token for instance service account: %w", err)
+	}
+	return token.AccessToken, time.Now().Add(time.Duration(token.ExpiresIn) * time.Second), nil
+}
+
+// GCPIdentityToken gets a signed identity token (JWT) for the instance's
+// service account, with the given audience.
+func GCPIdentityToken(audience string) (string, error) {
+	token, err := QueryGCPMetadata("/instance/service-accounts/default/identity?format=full&audience=" + url.QueryEscape(audience))
+	if err != nil {
+		return "", fmt.Errorf("could not get identity token for instance service account: %w", err)
+	}
+	return string(token), nil
+}
@@ -39,6 +39,8 @@ func main() {
 	_, err = runner.Run(filename)
 	if err != nil {
 		log.Printf("%s", err)
+		logging.Flush()
 		os.Exit(1)
 	}
+	logging.Flush()
 }
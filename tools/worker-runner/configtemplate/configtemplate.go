@@ -0,0 +1,100 @@
+// Package configtemplate renders worker configuration values as Go templates,
+// with information about the instance, so that worker pools whose
+// configuration varies only by, say, zone or instance type can share a single
+// configuration.
+package configtemplate
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+)
+
+// Render the worker configuration as a template, if the worker pool has
+// enabled this with the `templateWorkerConfig` runner option.
+func ConfigureRun(state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	enabled := false
+	if state.RunnerOptions != nil {
+		if opt, err := state.RunnerOptions.Get("templateWorkerConfig"); err == nil {
+			enabled, _ = opt.(bool)
+		}
+	}
+	if !enabled {
+		return nil
+	}
+
+	data := templateData(state)
+	rendered := 0
+	workerConfig, err := state.WorkerConfig.ReplaceStrings(func(value string) (string, error) {
+		if !strings.Contains(value, "{{") {
+			return value, nil
+		}
+		result, err := render(value, data)
+		if err != nil {
+			return "", err
+		}
+		rendered++
+		return result, nil
+	})
+	if err != nil {
+		return err
+	}
+	state.WorkerConfig = workerConfig
+
+	log.Printf("Rendered %d templated values in worker configuration", rendered)
+	return nil
+}
+
+// The data available to templates.  Keys are camel-cased to match the rest of
+// the worker configuration.
+func templateData(state *run.State) map[string]interface{} {
+	location := make(map[string]interface{})
+	for k, v := range state.WorkerLocation {
+		location[k] = v
+	}
+	metadata := make(map[string]interface{})
+	for k, v := range state.ProviderMetadata {
+		metadata[k] = v
+	}
+	return map[string]interface{}{
+		"rootUrl":      state.RootURL,
+		"providerId":   state.ProviderID,
+		"workerPoolId": state.WorkerPoolID,
+		"workerGroup":  state.WorkerGroup,
+		"workerId":     state.WorkerID,
+		"location":     location,
+		"metadata":     metadata,
+	}
+}
+
+// Functions available to templates, in addition to the text/template builtins
+var funcs = template.FuncMap{
+	// Like the builtin index, but failing if the key is not present; this is
+	// useful for keys which are not valid identifiers, such as `local-ipv4`.
+	"get": func(m map[string]interface{}, key string) (interface{}, error) {
+		value, ok := m[key]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", key)
+		}
+		return value, nil
+	},
+}
+
+func render(value string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Funcs(funcs).Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid template in worker configuration: %w", err)
+	}
+	var result strings.Builder
+	err = tmpl.Execute(&result, data)
+	if err != nil {
+		return "", fmt.Errorf("could not render template in worker configuration: %w", err)
+	}
+	return result.String(), nil
+}
@@ -0,0 +1,82 @@
+package configtemplate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+)
+
+func setup(t *testing.T, workerConfig string, enabled bool) *run.State {
+	t.Helper()
+	var wc cfg.WorkerConfig
+	require.NoError(t, json.Unmarshal([]byte(workerConfig), &wc))
+	runnerOptions, err := cfg.NewWorkerConfig().Set("templateWorkerConfig", enabled)
+	require.NoError(t, err)
+	return &run.State{
+		RootURL:      "https://tc.example.com",
+		ProviderID:   "aws",
+		WorkerPoolID: "pp/wt",
+		WorkerGroup:  "us-east-1",
+		WorkerID:     "i-123",
+		ProviderMetadata: map[string]interface{}{
+			"instance-type": "m5.large",
+			"local-ipv4":    "10.0.0.1",
+		},
+		WorkerLocation: map[string]string{
+			"cloud":            "aws",
+			"region":           "us-east-1",
+			"availabilityZone": "us-east-1a",
+		},
+		WorkerConfig:  &wc,
+		RunnerOptions: runnerOptions,
+	}
+}
+
+func TestRender(t *testing.T) {
+	state := setup(t, `{
+		"cacheUrl": "https://cache-{{ .location.availabilityZone }}.example.com",
+		"nested": {"ip": ["{{ get .metadata \"local-ipv4\" }}"]},
+		"name": "{{ .workerPoolId }}/{{ .workerId }} ({{ index .metadata \"instance-type\" }})",
+		"plain": "unchanged",
+		"number": 10
+	}`, true)
+
+	require.NoError(t, ConfigureRun(state))
+
+	require.Equal(t, "https://cache-us-east-1a.example.com", state.WorkerConfig.MustGet("cacheUrl"))
+	require.Equal(t, []interface{}{"10.0.0.1"}, state.WorkerConfig.MustGet("nested.ip"))
+	require.Equal(t, "pp/wt/i-123 (m5.large)", state.WorkerConfig.MustGet("name"))
+	require.Equal(t, "unchanged", state.WorkerConfig.MustGet("plain"))
+	require.Equal(t, 10.0, state.WorkerConfig.MustGet("number"))
+}
+
+func TestNotEnabled(t *testing.T) {
+	state := setup(t, `{"x": "{{ .workerId }}"}`, false)
+
+	require.NoError(t, ConfigureRun(state))
+
+	require.Equal(t, "{{ .workerId }}", state.WorkerConfig.MustGet("x"))
+}
+
+func TestMissingKey(t *testing.T) {
+	for _, tmpl := range []string{
+		`{{ .location.zone }}`,
+		`{{ get .metadata \"public-ipv4\" }}`,
+	} {
+		t.Run(tmpl, func(t *testing.T) {
+			state := setup(t, `{"x": "`+tmpl+`"}`, true)
+			err := ConfigureRun(state)
+			require.ErrorContains(t, err, "could not render template in worker configuration")
+		})
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	state := setup(t, `{"x": "{{ .workerId "}`, true)
+
+	err := ConfigureRun(state)
+	require.ErrorContains(t, err, "invalid template in worker configuration")
+}
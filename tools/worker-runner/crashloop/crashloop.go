@@ -0,0 +1,167 @@
+package crashloop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	taskcluster "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/diagnostics"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/errorreport"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+const (
+	// crashes older than this are forgotten
+	crashWindow = time.Hour
+
+	// default number of crashes within crashWindow that constitute a crash
+	// loop
+	defaultThreshold = 3
+
+	// delay before exiting after a crash, doubling with each crash in
+	// crashWindow up to maxBackoff
+	minBackoff = 10 * time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// Determine whether the given exit code indicates that a worker crashed.
+// Workers exit with 0, or with an exit code of 64 or above (following
+// generic-worker), when they stop deliberately; any other exit code, such as
+// 2 for a Go panic or -1 for a process killed by a signal, indicates a crash.
+func IsCrashExitCode(exitCode int) bool {
+	return exitCode != 0 && (exitCode < 64 || exitCode > 127)
+}
+
+// Determine whether the error returned from a worker's Wait method indicates
+// that it crashed.  Errors carrying an exit code, such as *exec.ExitError,
+// are judged by IsCrashExitCode; other errors are taken to be crashes.
+func IsCrash(err error) bool {
+	if err == nil {
+		return false
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return IsCrashExitCode(exitErr.ExitCode())
+	}
+	return true
+}
+
+// Detector tracks worker crashes across restarts of worker-runner (when
+// cacheOverRestarts is set), reporting each crash to worker-manager with
+// diagnostics, backing off after each crash, and detecting crash loops.
+type Detector struct {
+	runnercfg *cfg.RunnerConfig
+	state     *run.State
+
+	// Factory for worker-manager clients
+	factory tc.WorkerManagerClientFactory
+
+	// injectable for testing
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+// Record that the worker exited with the given error from its Wait method.
+// If this was a crash, report it to worker-manager, and either wait before
+// returning, so that a restart of worker-runner does not come too soon, or,
+// if the worker has crashed repeatedly, report it as a crash loop.  Returns
+// true if a crash loop was detected, in which case the worker should not be
+// restarted.
+func (d *Detector) WorkerExited(workerErr error) bool {
+	if !IsCrash(workerErr) {
+		return false
+	}
+
+	d.state.Lock()
+	now := d.now()
+	crashes := []time.Time{now}
+	for _, t := range d.state.WorkerCrashes {
+		if now.Sub(t) < crashWindow {
+			crashes = append(crashes, t)
+		}
+	}
+	d.state.WorkerCrashes = crashes
+	if d.runnercfg.CacheOverRestarts != "" {
+		if err := d.state.WriteCacheFile(d.runnercfg.CacheOverRestarts); err != nil {
+			log.Printf("Error recording worker crash: %v", err)
+		}
+	}
+	d.state.Unlock()
+
+	threshold := d.runnercfg.CrashLoopThreshold
+	if threshold == 0 {
+		threshold = defaultThreshold
+	}
+
+	if len(crashes) < threshold {
+		backoff := min(minBackoff<<(len(crashes)-1), maxBackoff)
+		log.Printf("Worker crashed (%v); %d crash(es) in the last %s; waiting %s before exiting", workerErr, len(crashes), crashWindow, backoff)
+		d.report(&tcworkermanager.WorkerErrorReport{
+			Kind:        "worker-crash",
+			Title:       "Worker Crashed",
+			Description: fmt.Sprintf("The worker crashed: %v", workerErr),
+		}, workerErr, len(crashes))
+		d.sleep(backoff)
+		return false
+	}
+
+	log.Printf("Worker crashed (%v); %d crashes in the last %s indicate a crash loop", workerErr, len(crashes), crashWindow)
+	d.report(&tcworkermanager.WorkerErrorReport{
+		Kind:        "worker-crash-loop",
+		Title:       "Worker Crash Loop",
+		Description: fmt.Sprintf("The worker crashed %d times in %s; most recently: %v", len(crashes), crashWindow, workerErr),
+	}, workerErr, len(crashes))
+	return true
+}
+
+// Report a crash to worker-manager, with diagnostics including the most
+// recent log lines, any panic trace, and information about the host
+func (d *Detector) report(payload *tcworkermanager.WorkerErrorReport, workerErr error, count int) {
+	extra, err := json.Marshal(struct {
+		*diagnostics.Diagnostics
+		CrashCount int `json:"crashCount"`
+	}{diagnostics.Collect(workerErr), count})
+	if err != nil {
+		log.Printf("Error encoding crash report: %v", err)
+		return
+	}
+
+	d.state.Lock()
+	defer d.state.Unlock()
+	payload.Extra = extra
+	payload.WorkerGroup = d.state.WorkerGroup
+	payload.WorkerID = d.state.WorkerID
+	err = errorreport.ReportWorkerError(d.state, d.factory, payload)
+	if err != nil {
+		log.Printf("Error reporting crash: %v", err)
+	}
+}
+
+// Make a new Detector object
+func New(runnercfg *cfg.RunnerConfig, state *run.State) *Detector {
+	return new(runnercfg, state, nil)
+}
+
+// Private constructor allowing injection of a fake factory
+func new(runnercfg *cfg.RunnerConfig, state *run.State, factory tc.WorkerManagerClientFactory) *Detector {
+	if factory == nil {
+		factory = func(rootURL string, credentials *taskcluster.Credentials) (tc.WorkerManager, error) {
+			prov := tcworkermanager.New(credentials, rootURL)
+			return prov, nil
+		}
+	}
+
+	return &Detector{
+		runnercfg: runnercfg,
+		state:     state,
+		factory:   factory,
+		sleep:     time.Sleep,
+		now:       time.Now,
+	}
+}
@@ -0,0 +1,131 @@
+package crashloop
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+type exitCodeError int
+
+func (e exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", int(e))
+}
+
+func (e exitCodeError) ExitCode() int {
+	return int(e)
+}
+
+func TestIsCrash(t *testing.T) {
+	require.False(t, IsCrash(nil))
+	for _, code := range []int{0, 67, 69, 72} {
+		require.False(t, IsCrash(exitCodeError(code)), "exit code %d", code)
+	}
+	for _, code := range []int{-1, 1, 2, 1067, 0xc0000005} {
+		require.True(t, IsCrash(exitCodeError(code)), "exit code %d", code)
+	}
+	require.True(t, IsCrash(fmt.Errorf("something went wrong")))
+	require.True(t, IsCrash(fmt.Errorf("wrapped: %w", exitCodeError(2))))
+
+	t.Run("exec.ExitError", func(t *testing.T) {
+		err := exec.Command("sh", "-c", "exit 2").Run()
+		require.True(t, IsCrash(err))
+		err = exec.Command("sh", "-c", "exit 68").Run()
+		require.False(t, IsCrash(err))
+	})
+}
+
+func TestWorkerExited(t *testing.T) {
+	_, _ = tc.FakeWorkerManagerWorkerErrorReports()
+
+	cacheFile := filepath.Join(t.TempDir(), "cache.json")
+	runnercfg := &cfg.RunnerConfig{CacheOverRestarts: cacheFile}
+	state := &run.State{
+		RootURL:      "https://tc.example.com",
+		WorkerPoolID: "w/p",
+		WorkerGroup:  "wg",
+		WorkerID:     "wid",
+	}
+	d := new(runnercfg, state, tc.FakeWorkerManagerClientFactory)
+
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	var sleeps []time.Duration
+	d.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	logging.Tail.Add("panic: oh no")
+
+	require.False(t, d.WorkerExited(nil))
+	require.False(t, d.WorkerExited(exitCodeError(68)))
+	require.Empty(t, state.WorkerCrashes)
+
+	// a crash over an hour ago is forgotten
+	state.WorkerCrashes = []time.Time{now.Add(-2 * time.Hour)}
+	require.False(t, d.WorkerExited(exitCodeError(2)))
+	require.False(t, d.WorkerExited(exitCodeError(2)))
+	require.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second}, sleeps)
+
+	// each crash is reported, with diagnostics
+	reports, err := tc.FakeWorkerManagerWorkerErrorReports()
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	for i, report := range reports {
+		require.Equal(t, "worker-crash", report.Kind)
+		require.Equal(t, "wid", report.WorkerID)
+
+		var extra struct {
+			ExitCode   int      `json:"exitCode"`
+			CrashCount int      `json:"crashCount"`
+			LogExcerpt []string `json:"logExcerpt"`
+			System     struct {
+				OS string `json:"os"`
+			} `json:"system"`
+		}
+		require.NoError(t, json.Unmarshal(report.Extra, &extra))
+		require.Equal(t, 2, extra.ExitCode)
+		require.Equal(t, i+1, extra.CrashCount)
+		require.Contains(t, extra.LogExcerpt, "panic: oh no")
+		require.Equal(t, runtime.GOOS, extra.System.OS)
+	}
+
+	// crashes are recorded in the cache file, to survive restarts
+	var cached run.State
+	_, err = run.ReadCacheFile(&cached, cacheFile)
+	require.NoError(t, err)
+	require.Len(t, cached.WorkerCrashes, 2)
+
+	require.True(t, d.WorkerExited(exitCodeError(2)))
+	require.Len(t, sleeps, 2)
+
+	reports, err = tc.FakeWorkerManagerWorkerErrorReports()
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, "worker-crash-loop", reports[0].Kind)
+	require.Equal(t, "wid", reports[0].WorkerID)
+
+	var extra struct {
+		CrashCount int      `json:"crashCount"`
+		LogExcerpt []string `json:"logExcerpt"`
+	}
+	require.NoError(t, json.Unmarshal(reports[0].Extra, &extra))
+	require.Equal(t, 3, extra.CrashCount)
+	require.Contains(t, extra.LogExcerpt, "panic: oh no")
+}
+
+func TestThreshold(t *testing.T) {
+	d := new(&cfg.RunnerConfig{CrashLoopThreshold: 1}, &run.State{}, tc.FakeWorkerManagerClientFactory)
+	d.sleep = func(time.Duration) { t.Fatal("should not sleep") }
+
+	require.True(t, d.WorkerExited(exitCodeError(1)))
+	_, _ = tc.FakeWorkerManagerWorkerErrorReports()
+}
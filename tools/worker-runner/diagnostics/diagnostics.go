@@ -0,0 +1,86 @@
+// Package diagnostics collects information about an abnormal worker exit,
+// for inclusion in the error reported to worker-manager.
+package diagnostics
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/internal"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
+)
+
+// Diagnostics describes an abnormal worker exit.  It is sent as the `extra`
+// property of a worker error report.
+type Diagnostics struct {
+	// the error returned from the worker's Wait method
+	Error string `json:"error"`
+
+	// the worker's exit code, if it exited rather than failing to run
+	ExitCode *int `json:"exitCode,omitempty"`
+
+	// the most recent lines logged by worker-runner and the worker
+	LogExcerpt []string `json:"logExcerpt"`
+
+	// the worker's Go panic or fatal error trace, if any
+	PanicTrace []string `json:"panicTrace,omitempty"`
+
+	System System `json:"system"`
+}
+
+// System describes the host.  Properties that cannot be determined on this
+// platform are omitted.
+type System struct {
+	OS                  string `json:"os"`
+	Arch                string `json:"arch"`
+	Hostname            string `json:"hostname,omitempty"`
+	NumCPU              int    `json:"numCPU"`
+	WorkerRunnerVersion string `json:"workerRunnerVersion"`
+
+	// seconds since the host booted
+	UptimeSecs float64 `json:"uptimeSecs,omitempty"`
+
+	// the 1, 5 and 15 minute load averages
+	LoadAverage []float64 `json:"loadAverage,omitempty"`
+
+	MemoryTotalBytes     uint64 `json:"memoryTotalBytes,omitempty"`
+	MemoryAvailableBytes uint64 `json:"memoryAvailableBytes,omitempty"`
+}
+
+// Collect diagnostics for a worker that exited with the given error.  This
+// takes the panic trace from logging.PanicTrace, so that it is not reported
+// again for a later exit.
+func Collect(workerErr error) *Diagnostics {
+	d := &Diagnostics{
+		Error:      workerErr.Error(),
+		LogExcerpt: logging.Tail.Lines(),
+		PanicTrace: logging.PanicTrace.Take(),
+		System:     systemInfo(),
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(workerErr, &exitErr) {
+		exitCode := exitErr.ExitCode()
+		d.ExitCode = &exitCode
+	}
+	return d
+}
+
+func systemInfo() System {
+	s := System{
+		OS:                  runtime.GOOS,
+		Arch:                runtime.GOARCH,
+		NumCPU:              runtime.NumCPU(),
+		WorkerRunnerVersion: internal.Version,
+	}
+	s.Hostname, _ = os.Hostname()
+	addPlatformInfo(&s)
+	return s
+}
+
+// Uptime returns the time since the host booted, if it can be determined on
+// this platform
+func Uptime() (time.Duration, bool) {
+	return uptime()
+}
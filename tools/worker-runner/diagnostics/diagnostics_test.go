@@ -0,0 +1,41 @@
+package diagnostics
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
+)
+
+type exitCodeError int
+
+func (e exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", int(e))
+}
+
+func (e exitCodeError) ExitCode() int {
+	return int(e)
+}
+
+func TestCollect(t *testing.T) {
+	logging.Tail.Add("about to crash")
+	logging.PanicTrace.Add("panic: oh no")
+	logging.PanicTrace.Add("goroutine 1 [running]:")
+
+	d := Collect(fmt.Errorf("worker failed: %w", exitCodeError(2)))
+	require.Equal(t, "worker failed: exit code 2", d.Error)
+	require.NotNil(t, d.ExitCode)
+	require.Equal(t, 2, *d.ExitCode)
+	require.Contains(t, d.LogExcerpt, "about to crash")
+	require.Equal(t, []string{"panic: oh no", "goroutine 1 [running]:"}, d.PanicTrace)
+	require.Equal(t, runtime.GOOS, d.System.OS)
+	require.Equal(t, runtime.GOARCH, d.System.Arch)
+	require.NotZero(t, d.System.NumCPU)
+
+	// the panic trace is only reported once
+	d = Collect(fmt.Errorf("could not start worker"))
+	require.Nil(t, d.ExitCode)
+	require.Nil(t, d.PanicTrace)
+}
@@ -0,0 +1,70 @@
+package diagnostics
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the proc filesystem (overridden in tests)
+var procDir = "/proc"
+
+// Read the time since boot from /proc/uptime
+func uptime() (time.Duration, bool) {
+	data, err := os.ReadFile(procDir + "/uptime")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
+
+// Add uptime, load and memory information from /proc; anything that cannot
+// be read is omitted
+func addPlatformInfo(s *System) {
+	if up, ok := uptime(); ok {
+		s.UptimeSecs = up.Seconds()
+	}
+
+	if data, err := os.ReadFile(procDir + "/loadavg"); err == nil {
+		fields := strings.Fields(string(data))
+		for i := 0; i < 3 && i < len(fields); i++ {
+			load, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			s.LoadAverage = append(s.LoadAverage, load)
+		}
+	}
+
+	if f, err := os.Open(procDir + "/meminfo"); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			// lines are of the form "MemTotal:       16318044 kB"
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 2 {
+				continue
+			}
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch fields[0] {
+			case "MemTotal:":
+				s.MemoryTotalBytes = kb * 1024
+			case "MemAvailable:":
+				s.MemoryAvailableBytes = kb * 1024
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package diagnostics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlatformInfo(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "uptime"), []byte("3600.50 7000.00\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "loadavg"), []byte("0.50 1.25 2.00 1/234 5678\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "meminfo"), []byte(
+		"MemTotal:       16000000 kB\nMemFree:         1000000 kB\nMemAvailable:    8000000 kB\n"), 0644))
+
+	oldProcDir := procDir
+	procDir = dir
+	defer func() { procDir = oldProcDir }()
+
+	var s System
+	addPlatformInfo(&s)
+	require.Equal(t, 3600.5, s.UptimeSecs)
+
+	up, ok := Uptime()
+	require.True(t, ok)
+	require.Equal(t, 3600500*time.Millisecond, up)
+	require.Equal(t, []float64{0.5, 1.25, 2}, s.LoadAverage)
+	require.Equal(t, uint64(16000000*1024), s.MemoryTotalBytes)
+	require.Equal(t, uint64(8000000*1024), s.MemoryAvailableBytes)
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package diagnostics
+
+import "time"
+
+func uptime() (time.Duration, bool) {
+	return 0, false
+}
+
+func addPlatformInfo(s *System) {
+}
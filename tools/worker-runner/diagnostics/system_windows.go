@@ -0,0 +1,17 @@
+package diagnostics
+
+import (
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func uptime() (time.Duration, bool) {
+	return windows.DurationSinceBoot(), true
+}
+
+func addPlatformInfo(s *System) {
+	if up, ok := uptime(); ok {
+		s.UptimeSecs = up.Seconds()
+	}
+}
@@ -0,0 +1,159 @@
+// Package integrity verifies the files on the worker's image, such as the
+// worker binary and the CA bundle, against hashes given in the worker pool
+// configuration, before the worker is started.
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	taskcluster "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/errorreport"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+// Checker verifies file hashes, reporting any mismatch to worker-manager
+type Checker struct {
+	state *run.State
+
+	// Factory for worker-manager clients
+	factory tc.WorkerManagerClientFactory
+}
+
+// Read the expected hashes from the `fileHashes` runner option, a map from
+// file path to hex-encoded SHA-256 digest, optionally prefixed with
+// "sha256:".  Returns nil if the option is not set.
+func expectedHashes(state *run.State) (map[string]string, error) {
+	if state.RunnerOptions == nil {
+		return nil, nil
+	}
+	opt, err := state.RunnerOptions.Get("fileHashes")
+	if err != nil {
+		return nil, nil
+	}
+	m, ok := opt.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("fileHashes must be an object mapping file paths to SHA-256 hashes")
+	}
+	hashes := make(map[string]string, len(m))
+	for path, v := range m {
+		hash, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("fileHashes value for %s must be a string", path)
+		}
+		hash = strings.ToLower(strings.TrimPrefix(hash, "sha256:"))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("fileHashes value for %s is not a hex-encoded SHA-256 hash", path)
+		}
+		hashes[path] = hash
+	}
+	return hashes, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Check the files given in the `fileHashes` runner option.  If any file is
+// missing or does not match its hash, this reports the mismatch to
+// worker-manager as a worker error and returns an error, and the worker
+// should not be started.
+func (c *Checker) Check() error {
+	c.state.Lock()
+	defer c.state.Unlock()
+
+	hashes, err := expectedHashes(c.state)
+	if err != nil || len(hashes) == 0 {
+		return err
+	}
+
+	paths := make([]string, 0, len(hashes))
+	for path := range hashes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	mismatches := map[string]string{}
+	for _, path := range paths {
+		actual, err := hashFile(path)
+		switch {
+		case err != nil:
+			mismatches[path] = err.Error()
+		case actual != hashes[path]:
+			mismatches[path] = fmt.Sprintf("expected SHA-256 %s, got %s", hashes[path], actual)
+		}
+	}
+	if len(mismatches) == 0 {
+		log.Printf("Verified hashes of %d files", len(hashes))
+		return nil
+	}
+
+	for _, path := range paths {
+		if problem, ok := mismatches[path]; ok {
+			log.Printf("Integrity check failed for %s: %s", path, problem)
+		}
+	}
+	c.report(mismatches)
+	return fmt.Errorf("integrity check failed for %d of %d files; not starting worker", len(mismatches), len(hashes))
+}
+
+// Report the mismatches to worker-manager; the state must be locked
+func (c *Checker) report(mismatches map[string]string) {
+	extra, err := json.Marshal(map[string]interface{}{
+		"files": mismatches,
+	})
+	if err != nil {
+		log.Printf("Error encoding integrity check report: %v", err)
+		return
+	}
+
+	err = errorreport.ReportWorkerError(c.state, c.factory, &tcworkermanager.WorkerErrorReport{
+		Kind:        "worker-integrity-failure",
+		Title:       "Worker Integrity Check Failed",
+		Description: fmt.Sprintf("%d file(s) on the worker did not match the hashes in the worker pool configuration", len(mismatches)),
+		Extra:       extra,
+		WorkerGroup: c.state.WorkerGroup,
+		WorkerID:    c.state.WorkerID,
+	})
+	if err != nil {
+		log.Printf("Error reporting integrity check failure: %v", err)
+	}
+}
+
+// Make a new Checker object
+func New(state *run.State) *Checker {
+	return new(state, nil)
+}
+
+// Private constructor allowing injection of a fake factory
+func new(state *run.State, factory tc.WorkerManagerClientFactory) *Checker {
+	if factory == nil {
+		factory = func(rootURL string, credentials *taskcluster.Credentials) (tc.WorkerManager, error) {
+			prov := tcworkermanager.New(credentials, rootURL)
+			return prov, nil
+		}
+	}
+
+	return &Checker{
+		state:   state,
+		factory: factory,
+	}
+}
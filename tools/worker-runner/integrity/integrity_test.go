@@ -0,0 +1,101 @@
+package integrity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+// SHA-256 of "hello\n"
+const helloHash = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+func setup(t *testing.T, fileHashes interface{}) (*Checker, string) {
+	t.Helper()
+	_, _ = tc.FakeWorkerManagerWorkerErrorReports()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hello"), []byte("hello\n"), 0644))
+
+	state := &run.State{
+		RootURL:      "https://tc.example.com",
+		WorkerPoolID: "w/p",
+		WorkerGroup:  "wg",
+		WorkerID:     "wid",
+	}
+	if fileHashes != nil {
+		runnerOptions, err := cfg.NewWorkerConfig().Set("fileHashes", fileHashes)
+		require.NoError(t, err)
+		state.RunnerOptions = runnerOptions
+	}
+	return new(state, tc.FakeWorkerManagerClientFactory), dir
+}
+
+func TestNoOption(t *testing.T) {
+	c, _ := setup(t, nil)
+	require.NoError(t, c.Check())
+
+	reports, _ := tc.FakeWorkerManagerWorkerErrorReports()
+	require.Empty(t, reports)
+}
+
+func TestMatch(t *testing.T) {
+	c, dir := setup(t, nil)
+	runnerOptions, err := cfg.NewWorkerConfig().Set("fileHashes", map[string]interface{}{
+		filepath.Join(dir, "hello"): "sha256:" + helloHash,
+	})
+	require.NoError(t, err)
+	c.state.RunnerOptions = runnerOptions
+
+	require.NoError(t, c.Check())
+
+	reports, _ := tc.FakeWorkerManagerWorkerErrorReports()
+	require.Empty(t, reports)
+}
+
+func TestMismatch(t *testing.T) {
+	c, dir := setup(t, nil)
+	hello := filepath.Join(dir, "hello")
+	missing := filepath.Join(dir, "missing")
+	runnerOptions, err := cfg.NewWorkerConfig().Set("fileHashes", map[string]interface{}{
+		hello:   "0000000000000000000000000000000000000000000000000000000000000000",
+		missing: helloHash,
+	})
+	require.NoError(t, err)
+	c.state.RunnerOptions = runnerOptions
+
+	err = c.Check()
+	require.ErrorContains(t, err, "integrity check failed for 2 of 2 files")
+
+	reports, _ := tc.FakeWorkerManagerWorkerErrorReports()
+	require.Len(t, reports, 1)
+	require.Equal(t, "worker-integrity-failure", reports[0].Kind)
+	require.Equal(t, "wg", reports[0].WorkerGroup)
+	require.Equal(t, "wid", reports[0].WorkerID)
+
+	var extra struct {
+		Files map[string]string `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(reports[0].Extra, &extra))
+	require.Contains(t, extra.Files[hello], "got "+helloHash)
+	require.Contains(t, extra.Files, missing)
+}
+
+func TestInvalidOption(t *testing.T) {
+	for name, fileHashes := range map[string]interface{}{
+		"not an object": "abc",
+		"not a string":  map[string]interface{}{"/bin/sh": 123},
+		"not a hash":    map[string]interface{}{"/bin/sh": "md5:abc"},
+		"short hash":    map[string]interface{}{"/bin/sh": "abcd"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, _ := setup(t, fileHashes)
+			require.Error(t, c.Check())
+		})
+	}
+}
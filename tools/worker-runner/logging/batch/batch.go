@@ -0,0 +1,118 @@
+// Package batch supports log destinations that send messages to a remote
+// service in batches, rather than one at a time.
+package batch
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// interval at which buffered entries are sent
+	defaultInterval = 5 * time.Second
+
+	// maximum number of entries to buffer; beyond this, the oldest entries
+	// are dropped, so that an unavailable service does not exhaust memory
+	maxBuffered = 10000
+)
+
+// Entry is a single log message.  Exactly one of Text and Structured is set.
+type Entry struct {
+	Time       time.Time
+	Text       string
+	Structured map[string]interface{}
+}
+
+// SendFunc sends a batch of entries, in chronological order.
+type SendFunc func(entries []Entry) error
+
+// Batcher buffers log entries and sends them in batches of at most maxBatch
+// entries, at a regular interval or when a full batch is available.  Errors
+// sending a batch are written to stderr, as there is nowhere else to log
+// them, and the batch is dropped.
+type Batcher struct {
+	mutex    sync.Mutex
+	entries  []Entry
+	dropped  int
+	maxBatch int
+	send     SendFunc
+
+	// serializes calls to send
+	sendMutex sync.Mutex
+
+	// signalled when a full batch is available
+	full chan struct{}
+}
+
+// New creates a Batcher and starts sending entries in the background.
+func New(maxBatch int, send SendFunc) *Batcher {
+	return newBatcher(maxBatch, defaultInterval, send)
+}
+
+func newBatcher(maxBatch int, interval time.Duration, send SendFunc) *Batcher {
+	b := &Batcher{
+		maxBatch: maxBatch,
+		send:     send,
+		full:     make(chan struct{}, 1),
+	}
+	go b.loop(interval)
+	return b
+}
+
+func (b *Batcher) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.full:
+		}
+		b.Flush()
+	}
+}
+
+// Add an entry to be sent
+func (b *Batcher) Add(entry Entry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.entries = append(b.entries, entry)
+	if len(b.entries) > maxBuffered {
+		b.dropped += len(b.entries) - maxBuffered
+		b.entries = b.entries[len(b.entries)-maxBuffered:]
+	}
+	if len(b.entries) >= b.maxBatch {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush sends all buffered entries, returning when they have been sent (or
+// failed to send).
+func (b *Batcher) Flush() {
+	b.sendMutex.Lock()
+	defer b.sendMutex.Unlock()
+
+	b.mutex.Lock()
+	entries := b.entries
+	dropped := b.dropped
+	b.entries = nil
+	b.dropped = 0
+	b.mutex.Unlock()
+
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Dropped %d log messages that could not be sent\n", dropped)
+	}
+
+	for len(entries) > 0 {
+		n := min(len(entries), b.maxBatch)
+		if err := b.send(entries[:n]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error sending %d log messages: %v\n", n, err)
+		}
+		entries = entries[n:]
+	}
+}
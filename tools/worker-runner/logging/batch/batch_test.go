@@ -0,0 +1,78 @@
+package batch
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mutex   sync.Mutex
+	batches [][]string
+	err     error
+}
+
+func (r *recorder) send(entries []Entry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	batch := []string{}
+	for _, e := range entries {
+		batch = append(batch, e.Text)
+	}
+	r.batches = append(r.batches, batch)
+	return r.err
+}
+
+func (r *recorder) get() [][]string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.batches
+}
+
+func TestFlush(t *testing.T) {
+	r := &recorder{}
+	b := newBatcher(2, time.Hour, r.send)
+
+	b.Flush()
+	require.Empty(t, r.get(), "nothing to send")
+
+	b.Add(Entry{Text: "one"})
+	b.Flush()
+	require.Equal(t, [][]string{{"one"}}, r.get())
+}
+
+func TestFullBatchSentImmediately(t *testing.T) {
+	r := &recorder{}
+	b := newBatcher(2, time.Hour, r.send)
+
+	b.Add(Entry{Text: "one"})
+	b.Add(Entry{Text: "two"})
+	require.Eventually(t, func() bool { return len(r.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, [][]string{{"one", "two"}}, r.get())
+}
+
+func TestInterval(t *testing.T) {
+	r := &recorder{}
+	b := newBatcher(100, 10*time.Millisecond, r.send)
+
+	b.Add(Entry{Text: "one"})
+	require.Eventually(t, func() bool { return len(r.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSplitsAndDropsOnError(t *testing.T) {
+	r := &recorder{err: fmt.Errorf("uhoh")}
+	b := newBatcher(2, time.Hour, r.send)
+
+	for _, text := range []string{"one", "two", "three"} {
+		b.Add(Entry{Text: text})
+	}
+	b.Flush()
+	require.Equal(t, [][]string{{"one", "two"}, {"three"}}, r.get())
+
+	// failed batches are not retried
+	b.Flush()
+	require.Len(t, r.get(), 2)
+}
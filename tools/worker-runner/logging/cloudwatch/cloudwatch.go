@@ -0,0 +1,233 @@
+package cloudwatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/batch"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/stdio"
+)
+
+const (
+	// limits on a single PutLogEvents call; see
+	// https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+	maxBatchEvents = 10000
+	maxBatchBytes  = 1048576
+	eventOverhead  = 26
+	maxEventBytes  = 262144 - eventOverhead
+)
+
+// URL of the CloudWatch Logs API in the given region (overridden in tests)
+var logsURL = func(region string) string {
+	return "https://logs." + region + ".amazonaws.com/"
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+type cloudWatchLogDestination struct {
+	batcher *batch.Batcher
+
+	logGroup  string
+	logStream string
+	region    string
+
+	// fetches credentials (overridden in tests)
+	getCredentials func() (*cloudauth.AWSCredentials, error)
+
+	// accessed only from send, which the batcher does not call concurrently
+	creds         *cloudauth.AWSCredentials
+	streamCreated bool
+}
+
+func (dst *cloudWatchLogDestination) LogUnstructured(message string) {
+	dst.batcher.Add(batch.Entry{Time: time.Now(), Text: message})
+}
+
+func (dst *cloudWatchLogDestination) LogStructured(message map[string]interface{}) {
+	dst.batcher.Add(batch.Entry{Time: time.Now(), Structured: message})
+}
+
+func (dst *cloudWatchLogDestination) Flush() {
+	dst.batcher.Flush()
+}
+
+// Call a CloudWatch Logs API method, returning the AWS error type (such as
+// ResourceAlreadyExistsException) along with any error
+func (dst *cloudWatchLogDestination) call(method string, payload interface{}) (string, error) {
+	if dst.creds == nil || dst.creds.Expiring(5*time.Minute) {
+		creds, err := dst.getCredentials()
+		if err != nil {
+			return "", err
+		}
+		dst.creds = creds
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", logsURL(dst.region), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+method)
+	cloudauth.SignAWSRequest(req, body, dst.creds, dst.region, "logs", time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var awsErr struct {
+			Type string `json:"__type"`
+		}
+		_ = json.Unmarshal(respBody, &awsErr)
+		// the type may be prefixed with a namespace, ending in '#'
+		errType := awsErr.Type[strings.LastIndex(awsErr.Type, "#")+1:]
+		return errType, fmt.Errorf("%s: %s: %s", method, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return "", nil
+}
+
+func (dst *cloudWatchLogDestination) createLogStream() error {
+	errType, err := dst.call("CreateLogStream", map[string]string{
+		"logGroupName":  dst.logGroup,
+		"logStreamName": dst.logStream,
+	})
+	if err != nil && errType != "ResourceAlreadyExistsException" {
+		return err
+	}
+	dst.streamCreated = true
+	return nil
+}
+
+type logEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+func (dst *cloudWatchLogDestination) putLogEvents(events []logEvent) error {
+	_, err := dst.call("PutLogEvents", map[string]interface{}{
+		"logGroupName":  dst.logGroup,
+		"logStreamName": dst.logStream,
+		"logEvents":     events,
+	})
+	return err
+}
+
+// Send a batch of entries, splitting it as necessary to meet the limits on
+// the size of a PutLogEvents call
+func (dst *cloudWatchLogDestination) send(entries []batch.Entry) error {
+	if !dst.streamCreated {
+		if err := dst.createLogStream(); err != nil {
+			return err
+		}
+	}
+
+	events := make([]logEvent, 0, len(entries))
+	for _, entry := range entries {
+		message := entry.Text
+		if entry.Structured != nil {
+			j, err := json.Marshal(entry.Structured)
+			if err != nil {
+				message = logging.ToUnstructured(entry.Structured)
+			} else {
+				message = string(j)
+			}
+		}
+		if len(message) > maxEventBytes {
+			message = message[:maxEventBytes]
+		}
+		events = append(events, logEvent{Timestamp: entry.Time.UnixMilli(), Message: message})
+	}
+	// events in a call must be in chronological order
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+
+	start, size := 0, 0
+	for i, event := range events {
+		eventSize := len(event.Message) + eventOverhead
+		if i > start && (size+eventSize > maxBatchBytes || i-start >= maxBatchEvents) {
+			if err := dst.putLogEvents(events[start:i]); err != nil {
+				return err
+			}
+			start, size = i, 0
+		}
+		size += eventSize
+	}
+	return dst.putLogEvents(events[start:])
+}
+
+func New(runnercfg *cfg.RunnerConfig) logging.Logger {
+	logGroup, _ := runnercfg.Logging.Data["logGroup"].(string)
+	if logGroup == "" {
+		log.Printf("logging.logGroup is required for cloudwatch logging (falling back to stdio)")
+		return stdio.New(runnercfg)
+	}
+	logStream, _ := runnercfg.Logging.Data["logStream"].(string)
+	if logStream == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Printf("Could not determine hostname for logging.logStream: %v (falling back to stdio)", err)
+			return stdio.New(runnercfg)
+		}
+		logStream = hostname
+	}
+	region, _ := runnercfg.Logging.Data["region"].(string)
+	if region == "" {
+		var err error
+		region, err = cloudauth.AWSInstanceRegion()
+		if err != nil {
+			log.Printf("%v (falling back to stdio)", err)
+			return stdio.New(runnercfg)
+		}
+	}
+
+	dst := &cloudWatchLogDestination{
+		logGroup:       logGroup,
+		logStream:      logStream,
+		region:         region,
+		getCredentials: cloudauth.AWSInstanceCredentials,
+	}
+	dst.batcher = batch.New(maxBatchEvents, dst.send)
+	return dst
+}
+
+func Usage() string {
+	return `
+
+The "cloudwatch" logging sends log messages to AWS CloudWatch Logs, using the
+credentials of the instance's IAM role, which must allow
+logs:CreateLogStream and logs:PutLogEvents on the log group.  Messages are
+sent in batches every few seconds.  Structured messages are sent as JSON.
+
+` + "```yaml" + `
+logging:
+	implementation: cloudwatch
+	# name of the log group, which must already exist
+	logGroup: workers
+	# (optional) name of the log stream, which is created if necessary;
+	# defaults to the hostname
+	logStream: my-worker
+	# (optional) region of the log group; defaults to the instance's region
+	region: us-east-1
+` + "```" + `
+
+`
+}
@@ -0,0 +1,128 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/batch"
+)
+
+type fakeCloudWatch struct {
+	mutex   sync.Mutex
+	calls   []string
+	events  [][]logEvent
+	streams map[string]bool
+}
+
+func (f *fakeCloudWatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+		!strings.Contains(r.Header.Get("Authorization"), "/us-west-2/logs/") {
+		w.WriteHeader(403)
+		return
+	}
+	method := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")
+	f.calls = append(f.calls, method)
+
+	body, _ := io.ReadAll(r.Body)
+	var input struct {
+		LogGroupName  string     `json:"logGroupName"`
+		LogStreamName string     `json:"logStreamName"`
+		LogEvents     []logEvent `json:"logEvents"`
+	}
+	_ = json.Unmarshal(body, &input)
+	stream := input.LogGroupName + "/" + input.LogStreamName
+
+	switch method {
+	case "CreateLogStream":
+		if f.streams[stream] {
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte(`{"__type": "com.amazonaws.logs#ResourceAlreadyExistsException"}`))
+			return
+		}
+		f.streams[stream] = true
+	case "PutLogEvents":
+		if !f.streams[stream] {
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException"}`))
+			return
+		}
+		f.events = append(f.events, input.LogEvents)
+	}
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func setup(t *testing.T) (*fakeCloudWatch, *cloudWatchLogDestination) {
+	t.Helper()
+	fake := &fakeCloudWatch{streams: map[string]bool{}}
+	server := httptest.NewServer(fake)
+	oldLogsURL := logsURL
+	logsURL = func(region string) string { return server.URL }
+	t.Cleanup(func() {
+		logsURL = oldLogsURL
+		server.Close()
+	})
+
+	dst := &cloudWatchLogDestination{
+		logGroup:  "workers",
+		logStream: "wkr",
+		region:    "us-west-2",
+		getCredentials: func() (*cloudauth.AWSCredentials, error) {
+			return &cloudauth.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+		},
+	}
+	return fake, dst
+}
+
+func TestSend(t *testing.T) {
+	fake, dst := setup(t)
+	now := time.Now()
+
+	require.NoError(t, dst.send([]batch.Entry{
+		{Time: now.Add(time.Second), Text: "second"},
+		{Time: now, Structured: map[string]interface{}{"x": 1}},
+	}))
+	require.NoError(t, dst.send([]batch.Entry{{Time: now, Text: "third"}}))
+
+	require.Equal(t, []string{"CreateLogStream", "PutLogEvents", "PutLogEvents"}, fake.calls)
+	require.Equal(t, [][]logEvent{
+		{{Timestamp: now.UnixMilli(), Message: `{"x":1}`}, {Timestamp: now.Add(time.Second).UnixMilli(), Message: "second"}},
+		{{Timestamp: now.UnixMilli(), Message: "third"}},
+	}, fake.events)
+}
+
+func TestExistingStream(t *testing.T) {
+	fake, dst := setup(t)
+	fake.streams["workers/wkr"] = true
+
+	require.NoError(t, dst.send([]batch.Entry{{Time: time.Now(), Text: "hello"}}))
+	require.Equal(t, []string{"CreateLogStream", "PutLogEvents"}, fake.calls)
+	require.Len(t, fake.events, 1)
+}
+
+func TestSplitsLargeBatches(t *testing.T) {
+	fake, dst := setup(t)
+
+	big := strings.Repeat("x", maxEventBytes+100)
+	entries := []batch.Entry{}
+	for i := 0; i < 6; i++ {
+		entries = append(entries, batch.Entry{Time: time.Now(), Text: big})
+	}
+	require.NoError(t, dst.send(entries))
+
+	// each event is truncated to the maximum size, and four fit in a call
+	require.Len(t, fake.events, 2)
+	require.Len(t, fake.events[0], 4)
+	require.Len(t, fake.events[1], 2)
+	require.Len(t, fake.events[0][0].Message, maxEventBytes)
+}
@@ -0,0 +1,166 @@
+package file
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/stdio"
+)
+
+const (
+	defaultMaxSizeMB = 100
+	defaultMaxFiles  = 5
+)
+
+type fileLogDestination struct {
+	log *log.Logger
+}
+
+func (dst *fileLogDestination) LogUnstructured(message string) {
+	dst.log.Println(message)
+}
+
+func (dst *fileLogDestination) LogStructured(message map[string]interface{}) {
+	dst.log.Println(logging.ToUnstructured(message))
+}
+
+// rotatingFile is an io.Writer that writes to a file, rotating it when it
+// reaches a maximum size.  The rotated files are named with suffixes .1, .2,
+// and so on, with .1 being the most recent.
+type rotatingFile struct {
+	mutex    sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+	err := rf.open()
+	if err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+// Shift each rotated file along by one, discarding the oldest, and start a
+// new file.  The current file must be closed first, as Windows does not
+// allow renaming open files.
+func (rf *rotatingFile) rotate() error {
+	_ = rf.file.Close()
+	rf.file = nil
+	for i := rf.maxFiles - 1; i >= 1; i-- {
+		// errors are ignored here, as the files may not exist yet
+		_ = os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	var err error
+	if rf.maxFiles > 0 {
+		err = os.Rename(rf.path, rf.path+".1")
+	} else {
+		err = os.Remove(rf.path)
+	}
+	// reopen the file even if it could not be rotated, so that logging
+	// continues
+	if err2 := rf.open(); err2 != nil {
+		return err2
+	}
+	return err
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	if rf.file == nil {
+		// a previous rotation could not reopen the file
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			// if the file could not be reopened, there is nowhere left to
+			// write to
+			if rf.file == nil {
+				return 0, err
+			}
+			fmt.Fprintf(os.Stderr, "Error rotating log file %s: %v\n", rf.path, err)
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func New(runnercfg *cfg.RunnerConfig) logging.Logger {
+	path, _ := runnercfg.Logging.Data["path"].(string)
+	if path == "" {
+		log.Printf("logging.path is required for file logging (falling back to stdio)")
+		return stdio.New(runnercfg)
+	}
+	maxSizeMB := defaultMaxSizeMB
+	if v, ok := runnercfg.Logging.Data["maxSizeMB"].(int); ok {
+		maxSizeMB = v
+	}
+	maxFiles := defaultMaxFiles
+	if v, ok := runnercfg.Logging.Data["maxFiles"].(int); ok {
+		maxFiles = v
+	}
+
+	rf, err := openRotatingFile(path, int64(maxSizeMB)*1024*1024, maxFiles)
+	if err != nil {
+		log.Printf("Could not open log file %s: %v (falling back to stdio)", path, err)
+		return stdio.New(runnercfg)
+	}
+	return &fileLogDestination{log: log.New(rf, "", log.LstdFlags)}
+}
+
+func Usage() string {
+	return `
+
+The "file" logging logs to a file with a timestamp prefix, rotating the file
+when it reaches a maximum size.  This is useful where there is no system
+logging service to capture stderr, such as when worker-runner runs as a
+Windows service.
+
+` + "```yaml" + `
+logging:
+	implementation: file
+	# path of the log file
+	path: c:\worker-runner\worker-runner.log
+	# (optional) size in megabytes at which the file is rotated; default 100
+	maxSizeMB: 100
+	# (optional) number of rotated files to keep, named with suffixes .1, .2,
+	# and so on, with .1 being the most recent; default 5
+	maxFiles: 5
+` + "```" + `
+
+`
+}
@@ -0,0 +1,50 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+)
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker-runner.log")
+	rf, err := openRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"aaaaaaa\n", "bbbbbbb\n", "ccccccc\n", "ddddddd\n"} {
+		_, err = rf.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	read := func(path string) string {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(content)
+	}
+	require.Equal(t, "ddddddd\n", read(path))
+	require.Equal(t, "ccccccc\n", read(path+".1"))
+	require.Equal(t, "bbbbbbb\n", read(path+".2"))
+	require.NoFileExists(t, path+".3")
+}
+
+func TestAppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worker-runner.log")
+	require.NoError(t, os.WriteFile(path, []byte("before\n"), 0644))
+
+	dst := New(&cfg.RunnerConfig{
+		Logging: &cfg.LoggingConfig{
+			Implementation: "file",
+			Data:           map[string]interface{}{"path": path},
+		},
+	})
+	dst.LogStructured(map[string]interface{}{"textPayload": "after"})
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(content), "before\n"))
+	require.True(t, strings.HasSuffix(string(content), " after\n"))
+}
@@ -0,0 +1,180 @@
+package googlecloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/batch"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/stdio"
+)
+
+const (
+	defaultLogName = "worker-runner"
+
+	// entries per entries.write call; the API allows more, but also limits
+	// the request size to 10MB
+	maxBatchEntries = 1000
+)
+
+// URL of the entries.write method of the Cloud Logging API (overridden in
+// tests)
+var writeURL = "https://logging.googleapis.com/v2/entries:write"
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+type monitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels"`
+}
+
+type logEntry struct {
+	Timestamp   string                 `json:"timestamp"`
+	TextPayload string                 `json:"textPayload,omitempty"`
+	JSONPayload map[string]interface{} `json:"jsonPayload,omitempty"`
+}
+
+type googleCloudLogDestination struct {
+	batcher *batch.Batcher
+
+	logName  string
+	resource monitoredResource
+
+	// fetches an access token (overridden in tests)
+	getAccessToken func() (string, time.Time, error)
+
+	// accessed only from send, which the batcher does not call concurrently
+	accessToken string
+	expires     time.Time
+}
+
+func (dst *googleCloudLogDestination) LogUnstructured(message string) {
+	dst.batcher.Add(batch.Entry{Time: time.Now(), Text: message})
+}
+
+func (dst *googleCloudLogDestination) LogStructured(message map[string]interface{}) {
+	dst.batcher.Add(batch.Entry{Time: time.Now(), Structured: message})
+}
+
+func (dst *googleCloudLogDestination) Flush() {
+	dst.batcher.Flush()
+}
+
+func (dst *googleCloudLogDestination) send(entries []batch.Entry) error {
+	if dst.accessToken == "" || time.Until(dst.expires) < 5*time.Minute {
+		var err error
+		dst.accessToken, dst.expires, err = dst.getAccessToken()
+		if err != nil {
+			return err
+		}
+	}
+
+	logEntries := make([]logEntry, 0, len(entries))
+	for _, entry := range entries {
+		logEntries = append(logEntries, logEntry{
+			Timestamp:   entry.Time.UTC().Format(time.RFC3339Nano),
+			TextPayload: entry.Text,
+			JSONPayload: entry.Structured,
+		})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"logName":  dst.logName,
+		"resource": dst.resource,
+		"entries":  logEntries,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+dst.accessToken)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("entries.write: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// Describe this instance as a Cloud Logging monitored resource, using the
+// metadata service
+func instanceResource() (monitoredResource, error) {
+	labels := map[string]string{}
+	for label, path := range map[string]string{
+		"project_id":  "/project/project-id",
+		"instance_id": "/instance/id",
+		"zone":        "/instance/zone",
+	} {
+		value, err := cloudauth.QueryGCPMetadata(path)
+		if err != nil {
+			return monitoredResource{}, fmt.Errorf("could not get instance %s: %w", label, err)
+		}
+		// the zone is given as projects/<number>/zones/<zone>
+		v := string(value)
+		labels[label] = v[strings.LastIndex(v, "/")+1:]
+	}
+	return monitoredResource{Type: "gce_instance", Labels: labels}, nil
+}
+
+func New(runnercfg *cfg.RunnerConfig) logging.Logger {
+	resource, err := instanceResource()
+	if err != nil {
+		log.Printf("%v (falling back to stdio)", err)
+		return stdio.New(runnercfg)
+	}
+
+	project, _ := runnercfg.Logging.Data["project"].(string)
+	if project == "" {
+		project = resource.Labels["project_id"]
+	}
+	logName, _ := runnercfg.Logging.Data["logName"].(string)
+	if logName == "" {
+		logName = defaultLogName
+	}
+
+	dst := &googleCloudLogDestination{
+		logName:        fmt.Sprintf("projects/%s/logs/%s", project, url.PathEscape(logName)),
+		resource:       resource,
+		getAccessToken: cloudauth.GCPAccessToken,
+	}
+	dst.batcher = batch.New(maxBatchEntries, dst.send)
+	return dst
+}
+
+func Usage() string {
+	return `
+
+The "google-cloud-logging" logging sends log messages to Google Cloud Logging,
+as the instance's service account, which must have the Logs Writer role.  The
+entries are associated with the instance (a gce_instance resource).  Messages
+are sent in batches every few seconds.  Structured messages are sent as JSON
+payloads.
+
+` + "```yaml" + `
+logging:
+	implementation: google-cloud-logging
+	# (optional) project to log to; defaults to the instance's project
+	project: my-project
+	# (optional) name of the log; default worker-runner
+	logName: worker-runner
+` + "```" + `
+
+`
+}
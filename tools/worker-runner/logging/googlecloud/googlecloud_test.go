@@ -0,0 +1,125 @@
+package googlecloud
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+)
+
+type writeRequest struct {
+	LogName  string            `json:"logName"`
+	Resource monitoredResource `json:"resource"`
+	Entries  []logEntry        `json:"entries"`
+}
+
+// Serve a fake GCE metadata service and Cloud Logging API for the duration of
+// the test, returning a function to get the requests made to entries.write
+func setupFakeGCP(t *testing.T) func() []writeRequest {
+	t.Helper()
+	var mutex sync.Mutex
+	var requests []writeRequest
+
+	metadata := map[string]string{
+		"/computeMetadata/v1/project/project-id":                      "my-project",
+		"/computeMetadata/v1/instance/id":                             "1234",
+		"/computeMetadata/v1/instance/zone":                           "projects/5678/zones/us-east1-b",
+		"/computeMetadata/v1/instance/service-accounts/default/token": `{"access_token": "TOKEN", "expires_in": 3600}`,
+	}
+	mux := http.NewServeMux()
+	for path, value := range metadata {
+		value := value
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(403)
+				return
+			}
+			_, _ = w.Write([]byte(value))
+		})
+	}
+	mux.HandleFunc("/v2/entries:write", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer TOKEN" {
+			w.WriteHeader(401)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req writeRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		mutex.Lock()
+		requests = append(requests, req)
+		mutex.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+
+	oldMetadataBaseURL := cloudauth.GCPMetadataBaseURL
+	oldWriteURL := writeURL
+	cloudauth.GCPMetadataBaseURL = server.URL + "/computeMetadata/v1"
+	writeURL = server.URL + "/v2/entries:write"
+	t.Cleanup(func() {
+		cloudauth.GCPMetadataBaseURL = oldMetadataBaseURL
+		writeURL = oldWriteURL
+		server.Close()
+	})
+
+	return func() []writeRequest {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests
+	}
+}
+
+func TestLogging(t *testing.T) {
+	requests := setupFakeGCP(t)
+
+	dst := New(&cfg.RunnerConfig{
+		Logging: &cfg.LoggingConfig{
+			Implementation: "google-cloud-logging",
+			Data:           map[string]interface{}{"logName": "workers/runner"},
+		},
+	})
+	gcl, ok := dst.(*googleCloudLogDestination)
+	require.True(t, ok, "did not fall back to stdio")
+
+	gcl.LogUnstructured("hello")
+	gcl.LogStructured(map[string]interface{}{"taskId": "abc"})
+	gcl.Flush()
+
+	reqs := requests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "projects/my-project/logs/workers%2Frunner", reqs[0].LogName)
+	require.Equal(t, monitoredResource{
+		Type: "gce_instance",
+		Labels: map[string]string{
+			"project_id":  "my-project",
+			"instance_id": "1234",
+			"zone":        "us-east1-b",
+		},
+	}, reqs[0].Resource)
+	require.Len(t, reqs[0].Entries, 2)
+	require.Equal(t, "hello", reqs[0].Entries[0].TextPayload)
+	require.Equal(t, map[string]interface{}{"taskId": "abc"}, reqs[0].Entries[1].JSONPayload)
+}
+
+func TestNotOnGCP(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	oldMetadataBaseURL := cloudauth.GCPMetadataBaseURL
+	cloudauth.GCPMetadataBaseURL = server.URL
+	defer func() { cloudauth.GCPMetadataBaseURL = oldMetadataBaseURL }()
+
+	dst := New(&cfg.RunnerConfig{
+		Logging: &cfg.LoggingConfig{Implementation: "google-cloud-logging"},
+	})
+	_, ok := dst.(*googleCloudLogDestination)
+	require.False(t, ok, "should fall back to stdio")
+}
@@ -0,0 +1,148 @@
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/stdio"
+)
+
+const (
+	defaultSocket     = "/run/systemd/journal/socket"
+	defaultIdentifier = "worker-runner"
+
+	// syslog "informational" priority
+	priorityInfo = "6"
+)
+
+type journaldLogDestination struct {
+	conn       net.Conn
+	identifier string
+}
+
+// Convert a structured message key to a journal field name, which may
+// contain only uppercase letters, digits and underscores, and may not start
+// with an underscore or digit (see systemd.journal-fields(7))
+func fieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// Append a field to a journal entry in the native protocol.  Values
+// containing newlines use the binary form, with an explicit length.
+func appendField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if strings.Contains(value, "\n") {
+		buf.WriteByte('\n')
+		_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	} else {
+		buf.WriteByte('=')
+	}
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+func (dst *journaldLogDestination) send(message string, fields map[string]string) {
+	var buf bytes.Buffer
+	appendField(&buf, "MESSAGE", message)
+	appendField(&buf, "PRIORITY", priorityInfo)
+	appendField(&buf, "SYSLOG_IDENTIFIER", dst.identifier)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		appendField(&buf, name, fields[name])
+	}
+
+	if _, err := dst.conn.Write(buf.Bytes()); err != nil {
+		// there is nowhere else to log this; messages too large for a single
+		// datagram are among the possible causes
+		fmt.Fprintf(os.Stderr, "Error writing to journal: %v\n%s\n", err, message)
+	}
+}
+
+func (dst *journaldLogDestination) LogUnstructured(message string) {
+	dst.send(message, nil)
+}
+
+// Structured messages are logged with the unstructured form as the message,
+// and each property as a journal field
+func (dst *journaldLogDestination) LogStructured(message map[string]interface{}) {
+	fields := map[string]string{}
+	for k, v := range message {
+		name := fieldName(k)
+		switch name {
+		case "", "MESSAGE", "PRIORITY", "SYSLOG_IDENTIFIER":
+			continue
+		}
+		if str, ok := v.(string); ok {
+			fields[name] = str
+		} else if j, err := json.Marshal(v); err == nil {
+			fields[name] = string(j)
+		}
+	}
+	dst.send(logging.ToUnstructured(message), fields)
+}
+
+func New(runnercfg *cfg.RunnerConfig) logging.Logger {
+	socket, _ := runnercfg.Logging.Data["socket"].(string)
+	if socket == "" {
+		socket = defaultSocket
+	}
+	identifier, _ := runnercfg.Logging.Data["identifier"].(string)
+	if identifier == "" {
+		identifier = defaultIdentifier
+	}
+
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		log.Printf("Could not connect to journald at %s: %v (falling back to stdio)", socket, err)
+		return stdio.New(runnercfg)
+	}
+	return &journaldLogDestination{conn: conn, identifier: identifier}
+}
+
+func Usage() string {
+	return `
+
+The "journald" logging sends log messages directly to the systemd journal,
+using its native protocol.  Properties of structured messages are included as
+journal fields, with names converted to uppercase (so a "taskId" property can
+be matched with "journalctl TASKID=...").  This is only available on Linux.
+
+` + "```yaml" + `
+logging:
+	implementation: journald
+	# (optional) value of the SYSLOG_IDENTIFIER field; default worker-runner
+	identifier: worker-runner
+	# (optional) path of the journal socket; default /run/systemd/journal/socket
+	socket: /run/systemd/journal/socket
+` + "```" + `
+
+`
+}
@@ -0,0 +1,106 @@
+//go:build linux
+
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+)
+
+// Parse a journal entry in the native protocol
+func parseEntry(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "=\n")
+		require.NotEqual(t, -1, i)
+		name := string(data[:i])
+		if data[i] == '=' {
+			data = data[i+1:]
+			end := bytes.IndexByte(data, '\n')
+			fields[name] = string(data[:end])
+			data = data[end+1:]
+		} else {
+			size := binary.LittleEndian.Uint64(data[i+1 : i+9])
+			data = data[i+9:]
+			fields[name] = string(data[:size])
+			require.Equal(t, byte('\n'), data[size])
+			data = data[size+1:]
+		}
+	}
+	return fields
+}
+
+func TestJournald(t *testing.T) {
+	// socket paths are limited in length, so avoid t.TempDir
+	dir, err := os.MkdirTemp("", "journald")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "socket")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	dst := New(&cfg.RunnerConfig{
+		Logging: &cfg.LoggingConfig{
+			Implementation: "journald",
+			Data:           map[string]interface{}{"socket": socket, "identifier": "test"},
+		},
+	})
+
+	receive := func() map[string]string {
+		buf := make([]byte, 65536)
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		return parseEntry(t, buf[:n])
+	}
+
+	dst.LogUnstructured("hello\nworld")
+	require.Equal(t, map[string]string{
+		"MESSAGE":           "hello\nworld",
+		"PRIORITY":          "6",
+		"SYSLOG_IDENTIFIER": "test",
+	}, receive())
+
+	dst.LogStructured(map[string]interface{}{
+		"textPayload": "task started",
+		"taskId":      "abc",
+		"run-count":   2,
+		"_internal":   "x",
+		"priority":    "ignored",
+	})
+	require.Equal(t, map[string]string{
+		"MESSAGE":           "task started; _internal: x; priority: ignored; run-count: 2; taskId: abc",
+		"PRIORITY":          "6",
+		"SYSLOG_IDENTIFIER": "test",
+		"TEXTPAYLOAD":       "task started",
+		"TASKID":            "abc",
+		"RUN_COUNT":         "2",
+		"INTERNAL":          "x",
+	}, receive())
+}
+
+func TestFieldName(t *testing.T) {
+	require.Equal(t, "TASK_ID", fieldName("task.id"))
+	require.Equal(t, "X", fieldName("__1x"))
+	require.Equal(t, "", fieldName("_"))
+}
+
+func TestNoJournal(t *testing.T) {
+	dst := New(&cfg.RunnerConfig{
+		Logging: &cfg.LoggingConfig{
+			Implementation: "journald",
+			Data:           map[string]interface{}{"socket": filepath.Join(t.TempDir(), "nonexistent")},
+		},
+	})
+	_, ok := dst.(*journaldLogDestination)
+	require.False(t, ok, "should fall back to stdio")
+}
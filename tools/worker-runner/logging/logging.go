@@ -9,8 +9,14 @@ import (
 	"strings"
 
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/cloudwatch"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/file"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/googlecloud"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/journald"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/stdio"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/syslog"
+	yaml "gopkg.in/yaml.v3"
 )
 
 var Destination logging.Logger
@@ -27,7 +33,12 @@ type implInfo struct {
 }
 
 var implementations map[string]implInfo = map[string]implInfo{
-	"stdio": implInfo{stdio.New, stdio.Usage},
+	"cloudwatch":           implInfo{cloudwatch.New, cloudwatch.Usage},
+	"file":                 implInfo{file.New, file.Usage},
+	"google-cloud-logging": implInfo{googlecloud.New, googlecloud.Usage},
+	"journald":             implInfo{journald.New, journald.Usage},
+	"stdio":                implInfo{stdio.New, stdio.Usage},
+	"syslog":               implInfo{syslog.New, syslog.Usage},
 }
 
 func Configure(runnercfg *cfg.RunnerConfig) {
@@ -45,11 +56,55 @@ func Configure(runnercfg *cfg.RunnerConfig) {
 	Destination = li.constructor(runnercfg)
 }
 
+// Reconfigure logging with the `logging` runner option from the worker
+// pool's configuration, if set.  This takes precedence over the runner
+// configuration.
+func ConfigureFromRunnerOptions(runnercfg *cfg.RunnerConfig, runnerOptions *cfg.WorkerConfig) error {
+	if runnerOptions == nil {
+		return nil
+	}
+	opt, err := runnerOptions.Get("logging")
+	if err != nil {
+		return nil
+	}
+
+	// round-trip the option through YAML, so that it is interpreted exactly
+	// as the same configuration in the runner config would be (in particular,
+	// with integers decoded as int rather than float64)
+	buf, err := yaml.Marshal(opt)
+	if err != nil {
+		return fmt.Errorf("could not encode workerRunner.logging: %w", err)
+	}
+	var lc cfg.LoggingConfig
+	err = yaml.Unmarshal(buf, &lc)
+	if err != nil {
+		return fmt.Errorf("invalid workerRunner.logging: %w", err)
+	}
+
+	log.Printf("Switching to %s logging as configured by the worker pool", lc.Implementation)
+	Flush()
+	withLogging := *runnercfg
+	withLogging.Logging = &lc
+	Configure(&withLogging)
+	return nil
+}
+
+// Send any messages buffered by the current Destination.  This should be
+// called before worker-runner exits.
+func Flush() {
+	if f, ok := Destination.(logging.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func Usage() string {
 	rv := []string{strings.ReplaceAll(
 		`Worker-Runner supports plugins to send log messages (both from worker-runner itself and from the worker)
 To various destinations for aggregation.  This is configured with the |logging| property in the runner config,
-with the |implementation| property of that object specifying the plugin to use.  Allowed values are:
+with the |implementation| property of that object specifying the plugin to use.  A worker pool can select a
+different destination by setting |logging| in the |workerRunner| section of its worker configuration, in the
+same format; this takes effect once the worker pool's configuration has been fetched, and is used for the
+worker's output.  Allowed values are:
 `, "|", "`")}
 
 	sortedImpls := make([]string, len(implementations))
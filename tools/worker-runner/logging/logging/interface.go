@@ -11,3 +11,9 @@ type Logger interface {
 	// Log a structured message.
 	LogStructured(message map[string]interface{})
 }
+
+// A Flusher is a Logger that buffers messages.  Its Flush method sends any
+// buffered messages, and is called before worker-runner exits.
+type Flusher interface {
+	Flush()
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+)
+
+func TestConfigureFromRunnerOptions(t *testing.T) {
+	oldDestination := Destination
+	defer func() { Destination = oldDestination }()
+	dest := &TestLogDestination{}
+
+	runnerOptions := func(j string) *cfg.WorkerConfig {
+		wc := cfg.NewWorkerConfig()
+		require.NoError(t, json.Unmarshal([]byte(j), wc))
+		return wc
+	}
+
+	t.Run("not set", func(t *testing.T) {
+		Destination = dest
+		require.NoError(t, ConfigureFromRunnerOptions(&cfg.RunnerConfig{}, nil))
+		require.NoError(t, ConfigureFromRunnerOptions(&cfg.RunnerConfig{}, runnerOptions(`{"other": true}`)))
+		require.Equal(t, dest, Destination)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		Destination = dest
+		err := ConfigureFromRunnerOptions(&cfg.RunnerConfig{}, runnerOptions(`{"logging": {"path": "/tmp/x"}}`))
+		require.Error(t, err)
+		require.Equal(t, dest, Destination)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		Destination = dest
+		path := filepath.Join(t.TempDir(), "runner.log")
+		options, err := json.Marshal(map[string]interface{}{
+			"logging": map[string]interface{}{
+				"implementation": "file",
+				"path":           path,
+				"maxSizeMB":      1,
+			},
+		})
+		require.NoError(t, err)
+		runnercfg := &cfg.RunnerConfig{
+			Logging: &cfg.LoggingConfig{Implementation: "stdio"},
+		}
+		require.NoError(t, ConfigureFromRunnerOptions(runnercfg, runnerOptions(string(options))))
+
+		Destination.LogUnstructured("to the file")
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(content), "to the file")
+
+		// the runner config itself is not modified
+		require.Equal(t, "stdio", runnercfg.Logging.Implementation)
+	})
+}
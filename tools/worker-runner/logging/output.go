@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+type outputWriter struct {
+	mutex   sync.Mutex
+	partial []byte
+}
+
+// NewOutputWriter returns an io.Writer that logs each complete line written
+// to it to the current Destination, and adds it to Tail and PanicTrace.  It
+// is used to capture a worker's stderr, so that it is sent to the same place
+// as worker-runner's own logs.
+func NewOutputWriter() io.Writer {
+	return &outputWriter{}
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	data := append(w.partial, p...)
+	for {
+		newline := bytes.IndexByte(data, '\n')
+		if newline == -1 {
+			break
+		}
+		line := string(bytes.TrimRight(data[:newline], "\r"))
+		Tail.Add(line)
+		PanicTrace.Add(line)
+		Destination.LogUnstructured(line)
+		data = data[newline+1:]
+	}
+	w.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
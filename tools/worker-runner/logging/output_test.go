@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputWriter(t *testing.T) {
+	oldDestination := Destination
+	dest := &TestLogDestination{}
+	Destination = dest
+	defer func() { Destination = oldDestination }()
+
+	w := NewOutputWriter()
+	_, _ = w.Write([]byte("panic: oh no\n\ngoroutine 1 [run"))
+	_, _ = w.Write([]byte("ning]:\r\n"))
+
+	require.Equal(t, []map[string]interface{}{
+		{"textPayload": "panic: oh no"},
+		{"textPayload": ""},
+		{"textPayload": "goroutine 1 [running]:"},
+	}, dest.Messages())
+	require.Equal(t, []string{"panic: oh no", "", "goroutine 1 [running]:"}, Tail.Lines()[len(Tail.Lines())-3:])
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+)
+
+// The maximum number of lines kept of a panic trace
+const panicTraceLines = 500
+
+// PanicTrace holds the most recent Go panic or fatal error trace in the
+// worker's output.  Such traces are usually too long to be kept in Tail.
+var PanicTrace = NewPanicTraceBuffer(panicTraceLines)
+
+// PanicTraceBuffer captures the lines of output from the start of a panic
+// trace.
+type PanicTraceBuffer struct {
+	mutex sync.Mutex
+	lines []string
+	max   int
+}
+
+func NewPanicTraceBuffer(max int) *PanicTraceBuffer {
+	return &PanicTraceBuffer{max: max}
+}
+
+// Add a line of output, starting a new trace if it begins one
+func (p *PanicTraceBuffer) Add(line string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// nested panics are indented, so do not start a new trace
+	if strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ") {
+		p.lines = []string{line}
+		return
+	}
+	if len(p.lines) > 0 && len(p.lines) < p.max {
+		p.lines = append(p.lines, line)
+	}
+}
+
+// Take returns the lines of the current trace, if any, and clears it, so
+// that it is not reported again for a later exit
+func (p *PanicTraceBuffer) Take() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	lines := p.lines
+	p.lines = nil
+	return lines
+}
@@ -0,0 +1,28 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanicTraceBuffer(t *testing.T) {
+	trace := NewPanicTraceBuffer(4)
+	trace.Add("starting up")
+	require.Nil(t, trace.Take())
+
+	trace.Add("panic: first")
+	trace.Add("panic: second")
+	trace.Add("\tpanic: nested")
+	trace.Add("")
+	trace.Add("goroutine 1 [running]:")
+	trace.Add("main.main()")
+	require.Equal(t, []string{"panic: second", "\tpanic: nested", "", "goroutine 1 [running]:"}, trace.Take())
+
+	// taken traces are cleared
+	trace.Add("more output")
+	require.Nil(t, trace.Take())
+
+	trace.Add("fatal error: concurrent map writes")
+	require.Equal(t, []string{"fatal error: concurrent map writes"}, trace.Take())
+}
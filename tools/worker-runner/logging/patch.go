@@ -10,7 +10,9 @@ type unstructuredWriter struct{}
 func (w *unstructuredWriter) Write(p []byte) (n int, err error) {
 	// https://golang.org/pkg/log/
 	// > Each logging operation makes a single call to the Writer's Write method.
-	Destination.LogUnstructured(string(bytes.TrimRight(p, "\n")))
+	message := string(bytes.TrimRight(p, "\n"))
+	Tail.Add(message)
+	Destination.LogUnstructured(message)
 	n = len(p)
 	return
 }
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
+	loggingCommon "github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
 	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
 )
 
@@ -11,6 +12,7 @@ func SetProtocol(proto *workerproto.Protocol) {
 	proto.Register("log", func(msg workerproto.Message) {
 		body, ok := msg.Properties["body"]
 		if ok {
+			logging.Tail.Add(loggingCommon.ToUnstructured(body.(map[string]interface{})))
 			logging.Destination.LogStructured(body.(map[string]interface{}))
 		} else {
 			logging.Destination.LogUnstructured("received log message from worker lacking 'body' property")
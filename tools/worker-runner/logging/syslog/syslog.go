@@ -0,0 +1,40 @@
+package syslog
+
+import (
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
+)
+
+const defaultTag = "worker-runner"
+
+func New(runnercfg *cfg.RunnerConfig) logging.Logger {
+	network, _ := runnercfg.Logging.Data["network"].(string)
+	address, _ := runnercfg.Logging.Data["address"].(string)
+	tag, _ := runnercfg.Logging.Data["tag"].(string)
+	if tag == "" {
+		tag = defaultTag
+	}
+	return newSyslog(runnercfg, network, address, tag)
+}
+
+func Usage() string {
+	return `
+
+The "syslog" logging sends log messages to syslog, either the local syslog
+daemon or a remote server, with the "daemon" facility and "info" severity.
+Structured messages are converted to text.  This is not available on Windows.
+
+` + "```yaml" + `
+logging:
+	implementation: syslog
+	# (optional) network and address of a remote syslog server, such as
+	# "udp" and "logs.example.com:514"; by default the local syslog daemon
+	# is used
+	network: udp
+	address: logs.example.com:514
+	# (optional) tag for messages; default worker-runner
+	tag: worker-runner
+` + "```" + `
+
+`
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package syslog
+
+import (
+	"log"
+	"log/syslog"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/stdio"
+)
+
+type syslogLogDestination struct {
+	writer *syslog.Writer
+}
+
+func (dst *syslogLogDestination) LogUnstructured(message string) {
+	_ = dst.writer.Info(message)
+}
+
+func (dst *syslogLogDestination) LogStructured(message map[string]interface{}) {
+	_ = dst.writer.Info(logging.ToUnstructured(message))
+}
+
+func newSyslog(runnercfg *cfg.RunnerConfig, network, address, tag string) logging.Logger {
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		log.Printf("Could not connect to syslog: %v (falling back to stdio)", err)
+		return stdio.New(runnercfg)
+	}
+	return &syslogLogDestination{writer: writer}
+}
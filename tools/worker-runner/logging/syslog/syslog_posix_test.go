@@ -0,0 +1,42 @@
+//go:build !windows
+
+package syslog
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+)
+
+func TestRemoteSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	dst := New(&cfg.RunnerConfig{
+		Logging: &cfg.LoggingConfig{
+			Implementation: "syslog",
+			Data:           map[string]interface{}{"network": "udp", "address": conn.LocalAddr().String()},
+		},
+	})
+	_, ok := dst.(*syslogLogDestination)
+	require.True(t, ok, "did not fall back to stdio")
+
+	receive := func() string {
+		buf := make([]byte, 65536)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+
+	// <30> is the daemon facility with info severity
+	dst.LogUnstructured("hello")
+	msg := receive()
+	require.Regexp(t, `^<30>.* worker-runner\[\d+\]: hello\n$`, msg)
+
+	dst.LogStructured(map[string]interface{}{"textPayload": "started", "taskId": "abc"})
+	msg = receive()
+	require.Regexp(t, `worker-runner\[\d+\]: started; taskId: abc\n$`, msg)
+}
@@ -0,0 +1,14 @@
+package syslog
+
+import (
+	"log"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/stdio"
+)
+
+func newSyslog(runnercfg *cfg.RunnerConfig, network, address, tag string) logging.Logger {
+	log.Printf("syslog logging is not supported on Windows (falling back to stdio)")
+	return stdio.New(runnercfg)
+}
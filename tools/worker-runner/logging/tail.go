@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"sync"
+)
+
+// The number of lines kept in Tail
+const tailLines = 50
+
+// Tail holds the most recent lines logged by worker-runner and the worker,
+// for inclusion in error reports.  It can also be used as an io.Writer, to
+// capture output such as a worker's stderr.
+var Tail = NewTailBuffer(tailLines)
+
+// TailBuffer keeps the last few lines added to it.
+type TailBuffer struct {
+	mutex   sync.Mutex
+	lines   []string
+	max     int
+	partial []byte
+}
+
+func NewTailBuffer(max int) *TailBuffer {
+	return &TailBuffer{max: max}
+}
+
+// Add a line to the buffer, discarding the oldest line if it is full
+func (t *TailBuffer) Add(line string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.add(line)
+}
+
+func (t *TailBuffer) add(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+// Write adds each complete line in p to the buffer, holding any incomplete
+// final line until the next call.
+func (t *TailBuffer) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	data := append(t.partial, p...)
+	for {
+		newline := bytes.IndexByte(data, '\n')
+		if newline == -1 {
+			break
+		}
+		t.add(string(bytes.TrimRight(data[:newline], "\r")))
+		data = data[newline+1:]
+	}
+	t.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// Lines returns the lines currently in the buffer, oldest first
+func (t *TailBuffer) Lines() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]string(nil), t.lines...)
+}
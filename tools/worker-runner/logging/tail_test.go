@@ -0,0 +1,17 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailBuffer(t *testing.T) {
+	tail := NewTailBuffer(3)
+	tail.Add("one")
+	_, _ = tail.Write([]byte("two\nthr"))
+	require.Equal(t, []string{"one", "two"}, tail.Lines())
+
+	_, _ = tail.Write([]byte("ee\r\nfour\n"))
+	require.Equal(t, []string{"two", "three", "four"}, tail.Lines())
+}
@@ -0,0 +1,187 @@
+// Package metadataservice serves information about the worker and the
+// instance it runs on over HTTP on a local address, so that tasks can
+// identify where they ran without querying cloud-specific metadata services.
+package metadataservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+const defaultAddress = "127.0.0.1:8125"
+
+// TerminationNotice describes a request for the worker to stop
+type TerminationNotice struct {
+	// time at which worker-runner asked the worker to stop
+	Time time.Time `json:"time"`
+
+	// whether the worker was asked to finish its current tasks first
+	FinishTasks bool `json:"finishTasks"`
+}
+
+// Metadata is the JSON body served by the metadata service
+type Metadata struct {
+	RootURL           string             `json:"rootUrl"`
+	ProviderID        string             `json:"providerId"`
+	WorkerPoolID      string             `json:"workerPoolId"`
+	WorkerGroup       string             `json:"workerGroup"`
+	WorkerID          string             `json:"workerId"`
+	Region            string             `json:"region,omitempty"`
+	InstanceType      string             `json:"instanceType,omitempty"`
+	WorkerLocation    map[string]string  `json:"workerLocation"`
+	TerminationNotice *TerminationNotice `json:"terminationNotice"`
+}
+
+type MetadataService struct {
+	// address to listen on, or "" if the service is disabled
+	address string
+
+	state *run.State
+
+	server   *http.Server
+	listener net.Listener
+
+	// set when a graceful-termination message is sent to the worker
+	terminationNotice *TerminationNotice
+	lock              sync.Mutex
+}
+
+// Make a new MetadataService.  It is disabled unless `metadataService` is
+// given in the runner configuration.
+func New(runnercfg *cfg.RunnerConfig, state *run.State) *MetadataService {
+	ms := &MetadataService{state: state}
+	if runnercfg.MetadataService != nil {
+		ms.address = runnercfg.MetadataService.Address
+		if ms.address == "" {
+			ms.address = defaultAddress
+		}
+	}
+	return ms
+}
+
+// Wrap the transport to the worker, so that the metadata service can report
+// the termination notices sent over it.  The providers and other components
+// all notify the worker of impending termination with a graceful-termination
+// message, so this captures them all, from wherever they originate.
+func (ms *MetadataService) WrapTransport(transp workerproto.Transport) workerproto.Transport {
+	if ms.address == "" {
+		return transp
+	}
+	return &observingTransport{Transport: transp, ms: ms}
+}
+
+type observingTransport struct {
+	workerproto.Transport
+	ms *MetadataService
+}
+
+func (t *observingTransport) Send(msg workerproto.Message) {
+	if msg.Type == "graceful-termination" {
+		finishTasks, _ := msg.Properties["finish-tasks"].(bool)
+		t.ms.lock.Lock()
+		// keep the first notice, unless a later one no longer allows tasks to
+		// finish
+		if t.ms.terminationNotice == nil || (t.ms.terminationNotice.FinishTasks && !finishTasks) {
+			t.ms.terminationNotice = &TerminationNotice{Time: time.Now().UTC(), FinishTasks: finishTasks}
+		}
+		t.ms.lock.Unlock()
+	}
+	t.Transport.Send(msg)
+}
+
+func (ms *MetadataService) metadata() Metadata {
+	ms.state.RLock()
+	md := Metadata{
+		RootURL:        ms.state.RootURL,
+		ProviderID:     ms.state.ProviderID,
+		WorkerPoolID:   ms.state.WorkerPoolID,
+		WorkerGroup:    ms.state.WorkerGroup,
+		WorkerID:       ms.state.WorkerID,
+		Region:         ms.state.WorkerLocation["region"],
+		WorkerLocation: map[string]string{},
+	}
+	for k, v := range ms.state.WorkerLocation {
+		md.WorkerLocation[k] = v
+	}
+	if md.Region == "" {
+		md.Region, _ = ms.state.ProviderMetadata["region"].(string)
+	}
+	md.InstanceType, _ = ms.state.ProviderMetadata["instance-type"].(string)
+	ms.state.RUnlock()
+
+	ms.lock.Lock()
+	if ms.terminationNotice != nil {
+		notice := *ms.terminationNotice
+		md.TerminationNotice = &notice
+	}
+	ms.lock.Unlock()
+	return md
+}
+
+func (ms *MetadataService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := json.MarshalIndent(ms.metadata(), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// the termination notice may change at any time
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// Start serving, once the run state is complete
+func (ms *MetadataService) WorkerStarted() error {
+	if ms.address == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", ms.address)
+	if err != nil {
+		return fmt.Errorf("could not start metadata service: %w", err)
+	}
+	ms.listener = listener
+	ms.server = &http.Server{
+		Handler:           ms,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Serving worker metadata at http://%s/", listener.Addr())
+	go func() {
+		if err := ms.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Metadata service failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (ms *MetadataService) WorkerFinished() error {
+	if ms.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := ms.server.Shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		// a request is still in progress; don't wait for it any longer
+		return ms.server.Close()
+	}
+	return err
+}
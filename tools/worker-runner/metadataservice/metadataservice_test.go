@@ -0,0 +1,120 @@
+package metadataservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+func startService(t *testing.T) (*MetadataService, workerproto.Transport, string) {
+	t.Helper()
+	state := &run.State{
+		RootURL:      "https://tc.example.com",
+		ProviderID:   "aws",
+		WorkerPoolID: "w/p",
+		WorkerGroup:  "us-east-1",
+		WorkerID:     "i-123",
+		WorkerLocation: map[string]string{
+			"cloud":            "aws",
+			"region":           "us-east-1",
+			"availabilityZone": "us-east-1a",
+		},
+		ProviderMetadata: map[string]interface{}{
+			"instance-type": "m5.large",
+		},
+	}
+	runnercfg := &cfg.RunnerConfig{
+		MetadataService: &cfg.MetadataServiceConfig{Address: "127.0.0.1:0"},
+	}
+
+	ms := New(runnercfg, state)
+	transp := ms.WrapTransport(workerproto.NewNullTransport())
+	require.NoError(t, ms.WorkerStarted())
+	t.Cleanup(func() {
+		// don't leave keep-alive connections to the stopped service behind
+		http.DefaultClient.CloseIdleConnections()
+		require.NoError(t, ms.WorkerFinished())
+	})
+	return ms, transp, "http://" + ms.listener.Addr().String()
+}
+
+func getMetadata(t *testing.T, url string) Metadata {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var md Metadata
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&md))
+	return md
+}
+
+func TestMetadata(t *testing.T) {
+	_, _, url := startService(t)
+
+	md := getMetadata(t, url+"/")
+	require.Equal(t, "https://tc.example.com", md.RootURL)
+	require.Equal(t, "aws", md.ProviderID)
+	require.Equal(t, "w/p", md.WorkerPoolID)
+	require.Equal(t, "us-east-1", md.WorkerGroup)
+	require.Equal(t, "i-123", md.WorkerID)
+	require.Equal(t, "us-east-1", md.Region)
+	require.Equal(t, "m5.large", md.InstanceType)
+	require.Equal(t, "us-east-1a", md.WorkerLocation["availabilityZone"])
+	require.Nil(t, md.TerminationNotice)
+}
+
+func TestTerminationNotice(t *testing.T) {
+	_, transp, url := startService(t)
+
+	transp.Send(workerproto.Message{Type: "new-credentials"})
+	require.Nil(t, getMetadata(t, url).TerminationNotice)
+
+	transp.Send(workerproto.Message{
+		Type:       "graceful-termination",
+		Properties: map[string]interface{}{"finish-tasks": true},
+	})
+	notice := getMetadata(t, url).TerminationNotice
+	require.NotNil(t, notice)
+	require.True(t, notice.FinishTasks)
+	require.False(t, notice.Time.IsZero())
+
+	// a later notice that does not allow tasks to finish replaces it
+	transp.Send(workerproto.Message{
+		Type:       "graceful-termination",
+		Properties: map[string]interface{}{"finish-tasks": false},
+	})
+	notice = getMetadata(t, url).TerminationNotice
+	require.NotNil(t, notice)
+	require.False(t, notice.FinishTasks)
+}
+
+func TestBadRequests(t *testing.T) {
+	_, _, url := startService(t)
+
+	resp, err := http.Get(url + "/credentials")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(url, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestDisabled(t *testing.T) {
+	ms := New(&cfg.RunnerConfig{}, &run.State{})
+	transp := workerproto.NewNullTransport()
+	require.Equal(t, workerproto.Transport(transp), ms.WrapTransport(transp))
+	require.NoError(t, ms.WorkerStarted())
+	require.Nil(t, ms.listener)
+	require.NoError(t, ms.WorkerFinished())
+}
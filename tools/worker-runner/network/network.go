@@ -0,0 +1,106 @@
+// Package network applies the HTTP proxy and additional trusted CA settings
+// in the runner configuration to worker-runner, and passes them on to the
+// worker in a `network-config` message.
+package network
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/internal/netconfig"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+// Hosts of the cloud metadata services, which must be reached directly, and
+// so are always added to noProxy when a proxy is configured.
+var metadataHosts = []string{"169.254.169.254", "metadata.google.internal"}
+
+type Network struct {
+	config *netconfig.Config
+
+	// the protocol (set in SetProtocol)
+	proto *workerproto.Protocol
+}
+
+// Read the network configuration, including any CA certificate files, from
+// the runner configuration.
+func readConfig(runnercfg *cfg.RunnerConfig) (*netconfig.Config, error) {
+	config := &netconfig.Config{}
+	nc := runnercfg.Network
+	if nc == nil {
+		return config, nil
+	}
+
+	config.HTTPProxy = nc.HTTPProxy
+	config.HTTPSProxy = nc.HTTPSProxy
+	config.NoProxy = nc.NoProxy
+	if config.HTTPProxy != "" || config.HTTPSProxy != "" {
+		noProxy := metadataHosts
+		if config.NoProxy != "" {
+			noProxy = append([]string{config.NoProxy}, noProxy...)
+		}
+		config.NoProxy = strings.Join(noProxy, ",")
+	}
+
+	var certs []string
+	for _, path := range nc.CACertificateFiles {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA certificates from %s: %w", path, err)
+		}
+		certs = append(certs, strings.TrimSpace(string(pem)))
+	}
+	if len(certs) > 0 {
+		config.CACertificates = strings.Join(certs, "\n") + "\n"
+	}
+	return config, nil
+}
+
+func (n *Network) SetProtocol(proto *workerproto.Protocol) {
+	n.proto = proto
+	if !n.config.IsEmpty() {
+		proto.AddCapability("network-config")
+	}
+}
+
+// Send the configuration to the worker as soon as the protocol is
+// initialized, as the worker waits for it before making any requests.
+func (n *Network) WorkerStarted() error {
+	if n.config.IsEmpty() {
+		return nil
+	}
+	go func() {
+		n.proto.WaitUntilInitialized()
+		if !n.proto.Capable("network-config") {
+			log.Printf("Worker does not support network-config; it must be configured separately to use the HTTP proxy and CA certificates")
+			return
+		}
+		n.proto.Send(workerproto.Message{
+			Type: "network-config",
+			Properties: map[string]interface{}{
+				"config": n.config,
+			},
+		})
+	}()
+	return nil
+}
+
+// Configure worker-runner's network settings from the runner configuration,
+// returning a Network object to pass them on to the worker.  This should be
+// called before worker-runner makes any HTTP requests.
+func Configure(runnercfg *cfg.RunnerConfig) (*Network, error) {
+	config, err := readConfig(runnercfg)
+	if err != nil {
+		return nil, err
+	}
+	if !config.IsEmpty() {
+		log.Printf("Configuring HTTP proxy and CA certificates")
+		if err := config.Apply(); err != nil {
+			return nil, fmt.Errorf("invalid network configuration: %w", err)
+		}
+	}
+	return &Network{config: config}, nil
+}
@@ -0,0 +1,80 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/internal/netconfig"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca1 := filepath.Join(dir, "ca1.pem")
+	ca2 := filepath.Join(dir, "ca2.pem")
+	require.NoError(t, os.WriteFile(ca1, []byte("-----BEGIN CERTIFICATE-----\none\n-----END CERTIFICATE-----\n"), 0644))
+	require.NoError(t, os.WriteFile(ca2, []byte("-----BEGIN CERTIFICATE-----\ntwo\n-----END CERTIFICATE-----"), 0644))
+
+	config, err := readConfig(&cfg.RunnerConfig{
+		Network: &cfg.NetworkConfig{
+			HTTPSProxy:         "http://proxy.example.com:3128",
+			NoProxy:            ".internal.example.com",
+			CACertificateFiles: []string{ca1, ca2},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &netconfig.Config{
+		HTTPSProxy:     "http://proxy.example.com:3128",
+		NoProxy:        ".internal.example.com,169.254.169.254,metadata.google.internal",
+		CACertificates: "-----BEGIN CERTIFICATE-----\none\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\ntwo\n-----END CERTIFICATE-----\n",
+	}, config)
+
+	t.Run("no proxy", func(t *testing.T) {
+		config, err := readConfig(&cfg.RunnerConfig{Network: &cfg.NetworkConfig{NoProxy: "example.com"}})
+		require.NoError(t, err)
+		require.Equal(t, "example.com", config.NoProxy)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := readConfig(&cfg.RunnerConfig{
+			Network: &cfg.NetworkConfig{CACertificateFiles: []string{filepath.Join(dir, "nosuch.pem")}},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestNotConfigured(t *testing.T) {
+	n, err := Configure(&cfg.RunnerConfig{})
+	require.NoError(t, err)
+
+	wkr := ptesting.NewFakeWorkerWithCapabilities("network-config")
+	defer wkr.Close()
+	n.SetProtocol(wkr.RunnerProtocol)
+	require.NoError(t, n.WorkerStarted())
+	wkr.RunnerProtocol.Start(false)
+	wkr.RunnerProtocol.WaitUntilInitialized()
+	require.False(t, wkr.RunnerProtocol.Capable("network-config"))
+}
+
+func TestSendsConfig(t *testing.T) {
+	n := &Network{config: &netconfig.Config{HTTPProxy: "http://proxy:3128", NoProxy: "169.254.169.254"}}
+
+	wkr := ptesting.NewFakeWorkerWithCapabilities("network-config")
+	defer wkr.Close()
+	gotConfig := wkr.MessageReceivedFunc("network-config", func(msg workerproto.Message) bool {
+		config, ok := msg.Properties["config"].(map[string]interface{})
+		return ok && config["httpProxy"] == "http://proxy:3128" && config["noProxy"] == "169.254.169.254"
+	})
+
+	n.SetProtocol(wkr.RunnerProtocol)
+	require.NoError(t, n.WorkerStarted())
+	wkr.RunnerProtocol.Start(false)
+	wkr.RunnerProtocol.WaitUntilInitialized()
+
+	require.Eventually(t, gotConfig, 5*time.Second, 10*time.Millisecond)
+}
@@ -17,6 +17,9 @@ import (
 
 const TERMINATION_PATH = "/meta-data/spot/termination-time"
 
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/rebalance-recommendations.html
+const REBALANCE_PATH = "/meta-data/events/recommendations/rebalance"
+
 type AWSProvider struct {
 	runnercfg                  *cfg.RunnerConfig
 	workerManagerClientFactory tc.WorkerManagerClientFactory
@@ -24,6 +27,9 @@ type AWSProvider struct {
 	proto                      *workerproto.Protocol
 	workerIdentityProof        map[string]interface{}
 	terminationTicker          *time.Ticker
+	// if true, start draining the worker when EC2 recommends rebalancing
+	drainOnRebalance bool
+	draining         bool
 }
 
 func (p *AWSProvider) ConfigureRun(state *run.State) error {
@@ -119,7 +125,41 @@ func (p *AWSProvider) checkTerminationTime() bool {
 	return false
 }
 
+// checkRebalanceRecommendation asks the worker to finish its current tasks
+// and stop claiming new ones, if configured to do so and EC2 has issued a
+// rebalance recommendation, which typically arrives well before the spot
+// interruption notice.
+func (p *AWSProvider) checkRebalanceRecommendation() bool {
+	if !p.drainOnRebalance || p.draining {
+		return false
+	}
+	_, err := p.metadataService.queryMetadata(REBALANCE_PATH)
+	// if the file exists (so, no error), the instance is at elevated risk of interruption
+	if err == nil {
+		log.Println("EC2 Metadata Service recommends rebalancing; draining worker")
+		if p.proto != nil && p.proto.Capable("graceful-termination") {
+			p.proto.Send(workerproto.Message{
+				Type: "graceful-termination",
+				Properties: map[string]interface{}{
+					"finish-tasks": true,
+				},
+			})
+		}
+		p.draining = true
+		return true
+	}
+	return false
+}
+
 func (p *AWSProvider) WorkerStarted(state *run.State) error {
+	state.RLock()
+	if state.RunnerOptions != nil {
+		if drain, err := state.RunnerOptions.Get("drainOnRebalanceRecommendation"); err == nil {
+			p.drainOnRebalance, _ = drain.(bool)
+		}
+	}
+	state.RUnlock()
+
 	// start polling for graceful shutdown
 	p.terminationTicker = time.NewTicker(30 * time.Second)
 	p.proto.AddCapability("graceful-termination")
@@ -128,7 +168,9 @@ func (p *AWSProvider) WorkerStarted(state *run.State) error {
 		for {
 			<-p.terminationTicker.C
 			log.Println("polling for termination-time")
-			p.checkTerminationTime()
+			if !p.checkTerminationTime() {
+				p.checkRebalanceRecommendation()
+			}
 		}
 	}()
 
@@ -165,6 +207,21 @@ defined by this provider has the following fields:
 * cloud: aws
 * region
 * availabilityZone
+
+When a spot interruption notice is issued, the worker is asked to terminate
+gracefully, without finishing its current tasks.  To have the worker finish its
+current tasks and stop claiming new ones as soon as EC2 issues a
+[rebalance recommendation](https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/rebalance-recommendations.html),
+which usually precedes the interruption notice, set
+` + "`drainOnRebalanceRecommendation`" + ` in the ` + "`workerRunner`" + ` section of the worker
+pool's worker configuration:
+
+` + "```yaml" + `
+workerConfig:
+  genericWorker: ..
+  workerRunner:
+    drainOnRebalanceRecommendation: true
+` + "```" + `
 `
 }
 
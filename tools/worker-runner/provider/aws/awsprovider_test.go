@@ -130,3 +130,74 @@ func TestCheckTerminationTime(t *testing.T) {
 		require.True(t, gotTerm())
 	})
 }
+
+func TestCheckRebalanceRecommendation(t *testing.T) {
+	test := func(t *testing.T, proto *workerproto.Protocol, drain bool) bool {
+		t.Helper()
+		metaData := map[string]string{}
+
+		p := &AWSProvider{
+			metadataService:  &fakeMetadataService{nil, nil, metaData, ""},
+			proto:            proto,
+			drainOnRebalance: drain,
+		}
+
+		proto.AddCapability("graceful-termination")
+		proto.Start(false)
+		proto.WaitUntilInitialized()
+
+		// no recommendation yet..
+		require.False(t, p.checkRebalanceRecommendation())
+
+		metaData[REBALANCE_PATH] = `{"noticeTime": "2020-11-05T08:22:00Z"}`
+		drained := p.checkRebalanceRecommendation()
+
+		// only drain once
+		require.False(t, p.checkRebalanceRecommendation())
+		return drained
+	}
+
+	isFinishTasks := func(msg workerproto.Message) bool {
+		return msg.Properties["finish-tasks"] == true
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+
+		require.False(t, test(t, wkr.RunnerProtocol, false))
+
+		require.False(t, gotTerm())
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", isFinishTasks)
+
+		require.True(t, test(t, wkr.RunnerProtocol, true))
+
+		require.True(t, gotTerm())
+	})
+}
+
+func TestWorkerStartedRunnerOptions(t *testing.T) {
+	wkr := ptesting.NewFakeWorkerWithCapabilities()
+	defer wkr.Close()
+
+	p := &AWSProvider{
+		metadataService: &fakeMetadataService{nil, nil, map[string]string{}, ""},
+	}
+	p.SetProtocol(wkr.RunnerProtocol)
+
+	options, err := cfg.NewWorkerConfig().Set("drainOnRebalanceRecommendation", true)
+	require.NoError(t, err)
+	state := run.State{RunnerOptions: options}
+	require.NoError(t, p.WorkerStarted(&state))
+	defer p.terminationTicker.Stop()
+
+	require.True(t, p.drainOnRebalance)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
@@ -22,6 +23,8 @@ type AzureProvider struct {
 	proto                      *workerproto.Protocol
 	workerIdentityProof        map[string]interface{}
 	terminationTicker          *time.Ticker
+	// name of this VM, as used in the Resources of scheduled events
+	vmName string
 }
 
 type CustomData struct {
@@ -66,6 +69,7 @@ func (p *AzureProvider) ConfigureRun(state *run.State) error {
 	state.WorkerPoolID = taggedData.WorkerPoolId
 	state.WorkerGroup = taggedData.WorkerGroup
 	state.WorkerID = instanceData.Compute.Name
+	p.vmName = instanceData.Compute.Name
 
 	state.WorkerLocation = map[string]string{
 		"cloud":  "azure",
@@ -101,6 +105,7 @@ func (p *AzureProvider) GetWorkerIdentityProof() (map[string]interface{}, error)
 }
 
 func (p *AzureProvider) UseCachedRun(run *run.State) error {
+	p.vmName = run.WorkerID
 	return nil
 }
 
@@ -108,39 +113,77 @@ func (p *AzureProvider) SetProtocol(proto *workerproto.Protocol) {
 	p.proto = proto
 }
 
+// terminationDeadline returns the time at which the scheduled events show
+// this VM will be taken away, and whether any such event is scheduled.
+func (p *AzureProvider) terminationDeadline(evts *ScheduledEvents) (time.Time, bool) {
+	var deadline time.Time
+	found := false
+	for _, evt := range evts.Events {
+		// Freeze events pause the VM for a few seconds, which running tasks
+		// survive; all other event types end them.
+		if evt.EventType == "Freeze" {
+			continue
+		}
+		// events may also be delivered for other VMs in the same
+		// availability set or scale set
+		if p.vmName != "" && len(evt.Resources) != 0 && !slices.Contains(evt.Resources, p.vmName) {
+			continue
+		}
+		// an event with no NotBefore time has already started
+		notBefore := time.Now()
+		if evt.NotBefore != "" {
+			t, err := time.Parse(time.RFC1123, evt.NotBefore)
+			if err != nil {
+				log.Printf("Could not parse NotBefore time %q of scheduled event %s: %v", evt.NotBefore, evt.EventId, err)
+			} else {
+				notBefore = t
+			}
+		}
+		if !found || notBefore.Before(deadline) {
+			deadline = notBefore
+		}
+		found = true
+	}
+	return deadline, found
+}
+
 func (p *AzureProvider) checkTerminationTime() bool {
 	evts, err := p.metadataService.queryScheduledEvents()
 	if err != nil {
 		log.Printf("While fetching scheduled-events metadata: %v", err)
 		return false
 	}
+	if evts == nil {
+		return false
+	}
 
-	// if there are any events, let's consider that a signal we should go away
-	if evts != nil && len(evts.Events) != 0 {
-		log.Println("Azure Metadata Service says a maintenance event is imminent")
-		if p.proto != nil && p.proto.Capable("graceful-termination") {
-			p.proto.Send(workerproto.Message{
-				Type: "graceful-termination",
-				Properties: map[string]interface{}{
-					// termination generally doesn't leave time to finish
-					// tasks. We prefer to have the worker exit cleanly
-					// immediately, resolving tasks as
-					// exception/worker-shutdown, than to allow Azure to
-					// terminate the worker mid-tasks, which leaves the task
-					// still "running" on the queue until the claim expires, at
-					// which time it is completed as exception/claim-expired.
-					// Either one results in a retry, but the first option is
-					// faster and gives the user more context as to what
-					// happened.
-					"finish-tasks": false,
-				},
-			})
-		}
+	deadline, ok := p.terminationDeadline(evts)
+	if !ok {
+		return false
+	}
 
-		return true
+	log.Printf("Azure Metadata Service says a maintenance event is imminent, not before %v", deadline.UTC().Format(time.RFC3339))
+	if p.proto != nil && p.proto.Capable("graceful-termination") {
+		p.proto.Send(workerproto.Message{
+			Type: "graceful-termination",
+			Properties: map[string]interface{}{
+				// termination generally doesn't leave time to finish
+				// tasks. We prefer to have the worker exit cleanly
+				// immediately, resolving tasks as
+				// exception/worker-shutdown, than to allow Azure to
+				// terminate the worker mid-tasks, which leaves the task
+				// still "running" on the queue until the claim expires, at
+				// which time it is completed as exception/claim-expired.
+				// Either one results in a retry, but the first option is
+				// faster and gives the user more context as to what
+				// happened.
+				"finish-tasks": false,
+				"deadline":     deadline.UTC().Format(time.RFC3339),
+			},
+		})
 	}
 
-	return false
+	return true
 }
 
 func (p *AzureProvider) WorkerStarted(state *run.State) error {
@@ -191,6 +234,13 @@ defined by this provider has the following fields:
 
 * cloud: azure
 * region
+
+The provider polls the [Scheduled Events](https://learn.microsoft.com/en-us/azure/virtual-machines/linux/scheduled-events)
+metadata endpoint, and when an event that will end running tasks (such as
+spot eviction, with event type Preempt) is scheduled for the VM, asks the worker to
+terminate gracefully, without finishing its current tasks.  The time at which
+the event will start is passed to the worker as the ` + "`deadline`" + ` of the
+graceful-termination message.
 `
 }
 
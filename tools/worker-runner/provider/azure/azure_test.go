@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
@@ -113,6 +114,28 @@ func TestConfigureRun(t *testing.T) {
 	}, proof)
 }
 
+func TestTerminationDeadline(t *testing.T) {
+	p := &AzureProvider{vmName: "vm-w-p-test"}
+
+	_, ok := p.terminationDeadline(&ScheduledEvents{})
+	require.False(t, ok)
+
+	deadline, ok := p.terminationDeadline(&ScheduledEvents{Events: []ScheduledEvent{
+		{EventType: "Reboot", Resources: []string{"vm-w-p-test"}, NotBefore: "Mon, 19 Sep 2016 18:35:00 GMT"},
+		{EventType: "Terminate", Resources: []string{"vm-w-p-test"}, NotBefore: "Mon, 19 Sep 2016 18:30:00 GMT"},
+	}})
+	require.True(t, ok)
+	require.Equal(t, time.Date(2016, 9, 19, 18, 30, 0, 0, time.UTC), deadline.UTC())
+
+	// an event that has already started has no NotBefore time
+	before := time.Now()
+	deadline, ok = p.terminationDeadline(&ScheduledEvents{Events: []ScheduledEvent{
+		{EventType: "Preempt", EventStatus: "Started"},
+	}})
+	require.True(t, ok)
+	require.False(t, deadline.Before(before))
+}
+
 func TestCheckTerminationTime(t *testing.T) {
 	test := func(t *testing.T, proto *workerproto.Protocol, hasCapability bool) {
 		t.Helper()
@@ -125,6 +148,7 @@ func TestCheckTerminationTime(t *testing.T) {
 			metadataService:            mds,
 			proto:                      proto,
 			terminationTicker:          nil,
+			vmName:                     "vm-w-p-test",
 		}
 
 		proto.AddCapability("graceful-termination")
@@ -139,20 +163,30 @@ func TestCheckTerminationTime(t *testing.T) {
 
 		mds.ScheduledEventsError = nil
 
-		evt := struct {
-			EventId      string
-			EventType    string
-			ResourceType string
-			Resources    []string
-			EventStatus  string
-			NotBefore    string
-		}{
+		// freeze events and events for other VMs are ignored
+		evts.Events = append(evts.Events, ScheduledEvent{
+			EventType: "Freeze",
+			Resources: []string{"vm-w-p-test"},
+			NotBefore: "Mon, 19 Sep 2016 18:29:47 GMT",
+		}, ScheduledEvent{
 			EventType: "Preempt",
-		}
-		evts.Events = append(evts.Events, evt)
+			Resources: []string{"some-other-vm"},
+			NotBefore: "Mon, 19 Sep 2016 18:29:47 GMT",
+		})
+		require.False(t, p.checkTerminationTime())
+
+		evts.Events = append(evts.Events, ScheduledEvent{
+			EventType: "Preempt",
+			Resources: []string{"vm-w-p-test"},
+			NotBefore: "Mon, 19 Sep 2016 18:29:57 GMT",
+		})
 		require.True(t, p.checkTerminationTime())
 	}
 
+	isDeadline := func(msg workerproto.Message) bool {
+		return msg.Properties["deadline"] == "2016-09-19T18:29:57Z"
+	}
+
 	t.Run("without capability", func(t *testing.T) {
 		wkr := ptesting.NewFakeWorkerWithCapabilities()
 		defer wkr.Close()
@@ -168,7 +202,7 @@ func TestCheckTerminationTime(t *testing.T) {
 		wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
 		defer wkr.Close()
 
-		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", isDeadline)
 
 		test(t, wkr.RunnerProtocol, false)
 
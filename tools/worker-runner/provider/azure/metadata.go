@@ -44,14 +44,17 @@ type Tag struct {
 
 // Data from the /scheduledevents endpoint
 type ScheduledEvents struct {
-	Events []struct {
-		EventId      string
-		EventType    string
-		ResourceType string
-		Resources    []string
-		EventStatus  string
-		NotBefore    string
-	}
+	Events []ScheduledEvent
+}
+
+type ScheduledEvent struct {
+	EventId      string
+	EventType    string
+	ResourceType string
+	Resources    []string
+	EventStatus  string
+	// RFC 1123 formatted time, or empty once the event has started
+	NotBefore string
 }
 
 // Data from the /attested/document endpoint.
@@ -0,0 +1,140 @@
+package digitalocean
+
+import (
+	"fmt"
+	"strconv"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/provider"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+type DigitalOceanProvider struct {
+	runnercfg                  *cfg.RunnerConfig
+	workerManagerClientFactory tc.WorkerManagerClientFactory
+	metadataService            MetadataService
+	proto                      *workerproto.Protocol
+	workerIdentityProof        map[string]interface{}
+}
+
+func (p *DigitalOceanProvider) ConfigureRun(state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	userData, err := p.metadataService.queryUserData()
+	if err != nil {
+		return fmt.Errorf("could not query user data: %v", err)
+	}
+
+	droplet, err := p.metadataService.queryDropletMetadata()
+	if err != nil {
+		return fmt.Errorf("could not query droplet metadata: %v", err)
+	}
+	dropletID := strconv.FormatInt(droplet.DropletID, 10)
+
+	state.RootURL = userData.RootURL
+	state.ProviderID = userData.ProviderID
+	state.WorkerPoolID = userData.WorkerPoolID
+	state.WorkerGroup = userData.WorkerGroup
+	state.WorkerID = dropletID
+
+	state.WorkerLocation = map[string]string{
+		"cloud":  "digitalocean",
+		"region": droplet.Region,
+	}
+
+	providerMetadata := map[string]interface{}{
+		"instance-id":     dropletID,
+		"region":          droplet.Region,
+		"public-hostname": droplet.Hostname,
+	}
+	if len(droplet.Interfaces.Public) > 0 {
+		providerMetadata["public-ipv4"] = droplet.Interfaces.Public[0].IPv4.IPAddress
+	}
+	if len(droplet.Interfaces.Private) > 0 {
+		providerMetadata["local-ipv4"] = droplet.Interfaces.Private[0].IPv4.IPAddress
+	}
+
+	state.ProviderMetadata = providerMetadata
+
+	p.workerIdentityProof = map[string]interface{}{
+		"dropletId": interface{}(dropletID),
+		"token":     interface{}(userData.WorkerIdentityToken),
+	}
+
+	return nil
+}
+
+func (p *DigitalOceanProvider) GetWorkerIdentityProof() (map[string]interface{}, error) {
+	return p.workerIdentityProof, nil
+}
+
+func (p *DigitalOceanProvider) UseCachedRun(run *run.State) error {
+	return nil
+}
+
+func (p *DigitalOceanProvider) SetProtocol(proto *workerproto.Protocol) {
+	p.proto = proto
+}
+
+func (p *DigitalOceanProvider) WorkerStarted(state *run.State) error {
+	return nil
+}
+
+func (p *DigitalOceanProvider) WorkerFinished(state *run.State) error {
+	return nil
+}
+
+func clientFactory(rootURL string, credentials *tcclient.Credentials) (tc.WorkerManager, error) {
+	prov := tcworkermanager.New(credentials, rootURL)
+	return prov, nil
+}
+
+func New(runnercfg *cfg.RunnerConfig) (provider.Provider, error) {
+	return new(runnercfg, nil, nil)
+}
+
+func Usage() string {
+	return `
+The providerType "digitalocean" is intended for workers provisioned with worker-manager
+providers using providerType "digitalocean".  It requires
+
+` + "```yaml" + `
+provider:
+    providerType: digitalocean
+` + "```" + `
+
+Worker-manager does not yet have a provider of type "digitalocean", so workers
+using this provider cannot yet be provisioned and registered end to end.
+
+Worker-manager is expected to provide the worker's configuration as JSON user
+data, including a ` + "`workerIdentityToken`" + ` that is used, along with the droplet ID,
+to register the worker.
+
+The [$TASKCLUSTER_WORKER_LOCATION](https://docs.taskcluster.net/docs/manual/design/env-vars#taskcluster_worker_location)
+defined by this provider has the following fields:
+
+* cloud: digitalocean
+* region
+`
+}
+
+// New takes its dependencies as optional arguments, allowing injection of fake dependencies for testing.
+func new(runnercfg *cfg.RunnerConfig, workerManagerClientFactory tc.WorkerManagerClientFactory, metadataService MetadataService) (*DigitalOceanProvider, error) {
+	if workerManagerClientFactory == nil {
+		workerManagerClientFactory = clientFactory
+	}
+	if metadataService == nil {
+		metadataService = &realMetadataService{}
+	}
+	return &DigitalOceanProvider{
+		runnercfg:                  runnercfg,
+		workerManagerClientFactory: workerManagerClientFactory,
+		metadataService:            metadataService,
+		proto:                      nil,
+	}, nil
+}
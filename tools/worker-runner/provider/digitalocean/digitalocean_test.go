@@ -0,0 +1,67 @@
+package digitalocean
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+func TestDigitalOceanConfigureRun(t *testing.T) {
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "digitalocean",
+		},
+		WorkerImplementation: cfg.WorkerImplementationConfig{
+			Implementation: "whatever-worker",
+		},
+	}
+
+	userData := &UserData{
+		WorkerPoolID:        "w/p",
+		ProviderID:          "do1",
+		WorkerGroup:         "wg",
+		RootURL:             "https://tc.example.com",
+		WorkerIdentityToken: "i-promise",
+	}
+	droplet := &DropletMetadata{
+		DropletID: 2756294,
+		Hostname:  "my-worker",
+		Region:    "ams3",
+	}
+	droplet.Interfaces.Public = []dropletInterface{{}}
+	droplet.Interfaces.Public[0].IPv4.IPAddress = "1.2.3.4"
+	mds := &fakeMetadataService{nil, userData, droplet}
+
+	p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory, mds)
+	require.NoError(t, err, "creating provider")
+
+	state := run.State{}
+	err = p.ConfigureRun(&state)
+	require.NoError(t, err, "ConfigureRun")
+
+	require.Equal(t, "https://tc.example.com", state.RootURL, "rootURL is correct")
+	require.Equal(t, "do1", state.ProviderID, "providerID is correct")
+	require.Equal(t, "w/p", state.WorkerPoolID, "workerPoolID is correct")
+	require.Equal(t, "wg", state.WorkerGroup, "workerGroup is correct")
+	require.Equal(t, "2756294", state.WorkerID, "workerID is correct")
+
+	require.Equal(t, map[string]interface{}{
+		"instance-id":     "2756294",
+		"region":          "ams3",
+		"public-hostname": "my-worker",
+		"public-ipv4":     "1.2.3.4",
+	}, state.ProviderMetadata, "providerMetadata is correct")
+
+	require.Equal(t, "digitalocean", state.WorkerLocation["cloud"])
+	require.Equal(t, "ams3", state.WorkerLocation["region"])
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"dropletId": "2756294",
+		"token":     "i-promise",
+	}, proof)
+}
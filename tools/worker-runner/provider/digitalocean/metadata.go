@@ -0,0 +1,86 @@
+package digitalocean
+
+// See https://docs.digitalocean.com/reference/api/metadata-api/
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+var metadataBaseURL = "http://169.254.169.254/metadata/v1"
+
+// user-data sent to us from the worker-manager service
+type UserData struct {
+	WorkerPoolID string `json:"workerPoolId"`
+	ProviderID   string `json:"providerId"`
+	WorkerGroup  string `json:"workerGroup"`
+	RootURL      string `json:"rootUrl"`
+
+	// DigitalOcean does not provide signed instance identity documents, so
+	// worker-manager generates a secret for each droplet it creates, and
+	// checks it when the worker registers
+	WorkerIdentityToken string `json:"workerIdentityToken"`
+
+	// NOTE: this is ignored, in preference to the configuration
+	// returned from registerWorker
+	ProviderWorkerConfig *json.RawMessage `json:"workerConfig"`
+}
+
+// The subset of the droplet metadata document that we use
+type DropletMetadata struct {
+	DropletID  int64  `json:"droplet_id"`
+	Hostname   string `json:"hostname"`
+	Region     string `json:"region"`
+	Interfaces struct {
+		Public  []dropletInterface `json:"public"`
+		Private []dropletInterface `json:"private"`
+	} `json:"interfaces"`
+}
+
+type dropletInterface struct {
+	IPv4 struct {
+		IPAddress string `json:"ip_address"`
+	} `json:"ipv4"`
+}
+
+type MetadataService interface {
+	// Query the UserData and return the parsed contents
+	queryUserData() (*UserData, error)
+
+	// Query the droplet metadata document and return the parsed contents
+	queryDropletMetadata() (*DropletMetadata, error)
+}
+
+type realMetadataService struct{}
+
+func (mds *realMetadataService) queryUserData() (*UserData, error) {
+	content, err := mds.queryMetadata("/user-data")
+	if err != nil {
+		return nil, err
+	}
+	userData := &UserData{}
+	err = json.Unmarshal([]byte(content), userData)
+	return userData, err
+}
+
+func (mds *realMetadataService) queryDropletMetadata() (*DropletMetadata, error) {
+	content, err := mds.queryMetadata(".json")
+	if err != nil {
+		return nil, err
+	}
+	metadata := &DropletMetadata{}
+	err = json.Unmarshal([]byte(content), metadata)
+	return metadata, err
+}
+
+func (mds *realMetadataService) queryMetadata(path string) (string, error) {
+	resp, _, err := httpbackoff.Get(metadataBaseURL + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	return string(content), err
+}
@@ -0,0 +1,100 @@
+package digitalocean
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+type fakeMetadataService struct {
+	UserDataError   error
+	UserData        *UserData
+	DropletMetadata *DropletMetadata
+}
+
+func (mds *fakeMetadataService) queryUserData() (*UserData, error) {
+	if mds.UserDataError != nil {
+		return nil, mds.UserDataError
+	}
+	return mds.UserData, nil
+}
+
+func (mds *fakeMetadataService) queryDropletMetadata() (*DropletMetadata, error) {
+	return mds.DropletMetadata, nil
+}
+
+func TestQueryDropletMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metadata/v1.json" {
+			w.WriteHeader(200)
+			fmt.Fprintln(w, `{
+				"droplet_id": 2756294,
+				"hostname": "my-worker",
+				"region": "ams3",
+				"interfaces": {
+					"public": [{"ipv4": {"ip_address": "1.2.3.4"}}],
+					"private": [{"ipv4": {"ip_address": "10.0.0.2"}}]
+				}
+			}`)
+		} else {
+			w.WriteHeader(404)
+			fmt.Fprintln(w, "Not Found")
+		}
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/metadata/v1"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/metadata/v1"
+	}()
+
+	ms := realMetadataService{}
+
+	md, err := ms.queryDropletMetadata()
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2756294), md.DropletID)
+		assert.Equal(t, "my-worker", md.Hostname)
+		assert.Equal(t, "ams3", md.Region)
+		assert.Equal(t, "1.2.3.4", md.Interfaces.Public[0].IPv4.IPAddress)
+		assert.Equal(t, "10.0.0.2", md.Interfaces.Private[0].IPv4.IPAddress)
+	}
+
+	_, err = ms.queryMetadata("/NOSUCH")
+	if assert.Error(t, err) {
+		httperr, ok := err.(httpbackoff.BadHttpResponseCode)
+		assert.True(t, ok)
+		assert.Equal(t, 404, httperr.HttpResponseCode)
+	}
+}
+
+func TestQueryUserData(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metadata/v1/user-data" {
+			w.WriteHeader(200)
+			fmt.Fprintln(w, `{"workerPoolId": "w/p", "workerIdentityToken": "sekrit", "workerConfig": {"from-worker-config": true}}`)
+		} else {
+			w.WriteHeader(404)
+			fmt.Fprintf(w, "Not Found: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/metadata/v1"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/metadata/v1"
+	}()
+
+	ms := realMetadataService{}
+
+	ud, err := ms.queryUserData()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "w/p", ud.WorkerPoolID)
+		assert.Equal(t, "sekrit", ud.WorkerIdentityToken)
+		assert.Equal(t, json.RawMessage(`{"from-worker-config": true}`), *ud.ProviderWorkerConfig)
+	}
+}
@@ -0,0 +1,318 @@
+// Package external implements a provider that delegates to a plugin program,
+// allowing custom providers to be implemented outside of worker-runner.  The
+// protocol between worker-runner and the plugin is described in Usage.
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/provider"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+// version of the plugin protocol, passed to the plugin in each request
+const protocolVersion = 1
+
+// The request written to the plugin's stdin
+type request struct {
+	Version int                    `json:"version"`
+	Config  map[string]interface{} `json:"config"`
+}
+
+// The response to the configure-run subcommand
+type configureRunResponse struct {
+	RootURL             string                 `json:"rootUrl"`
+	ProviderID          string                 `json:"providerId"`
+	WorkerPoolID        string                 `json:"workerPoolId"`
+	WorkerGroup         string                 `json:"workerGroup"`
+	WorkerID            string                 `json:"workerId"`
+	WorkerLocation      map[string]string      `json:"workerLocation"`
+	ProviderMetadata    map[string]interface{} `json:"providerMetadata"`
+	WorkerIdentityProof map[string]interface{} `json:"workerIdentityProof"`
+	Credentials         *tcclient.Credentials  `json:"credentials"`
+}
+
+// An event written by the watch subcommand, one per line
+type watchEvent struct {
+	Type        string `json:"type"`
+	FinishTasks bool   `json:"finishTasks"`
+	Deadline    string `json:"deadline"`
+}
+
+type ExternalProvider struct {
+	runnercfg *cfg.RunnerConfig
+	proto     *workerproto.Protocol
+
+	// the plugin command, and the configuration passed to it
+	command []string
+	config  map[string]interface{}
+
+	workerIdentityProof map[string]interface{}
+
+	// the running watch subcommand, if any
+	watch     *exec.Cmd
+	watchLock sync.Mutex
+}
+
+// Make a command running the given plugin subcommand, with the request on
+// its stdin and its stderr logged
+func (p *ExternalProvider) pluginCommand(subcommand string) (*exec.Cmd, error) {
+	input, err := json.Marshal(request{Version: protocolVersion, Config: p.config})
+	if err != nil {
+		return nil, err
+	}
+	args := append(append([]string{}, p.command[1:]...), subcommand)
+	cmd := exec.Command(p.command[0], args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = logging.NewOutputWriter()
+	return cmd, nil
+}
+
+func (p *ExternalProvider) ConfigureRun(state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	cmd, err := p.pluginCommand("configure-run")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("provider plugin configure-run failed: %w", err)
+	}
+	var resp configureRunResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return fmt.Errorf("invalid output from provider plugin configure-run: %w", err)
+	}
+	if resp.WorkerIdentityProof == nil && resp.Credentials == nil {
+		return errors.New("provider plugin configure-run returned neither workerIdentityProof nor credentials")
+	}
+
+	state.RootURL = tcurls.NormalizeRootURL(resp.RootURL)
+	state.ProviderID = resp.ProviderID
+	state.WorkerPoolID = resp.WorkerPoolID
+	state.WorkerGroup = resp.WorkerGroup
+	state.WorkerID = resp.WorkerID
+
+	state.WorkerLocation = map[string]string{
+		"cloud": "external",
+	}
+	for k, v := range resp.WorkerLocation {
+		state.WorkerLocation[k] = v
+	}
+
+	state.ProviderMetadata = map[string]interface{}{}
+	for k, v := range resp.ProviderMetadata {
+		state.ProviderMetadata[k] = v
+	}
+
+	if resp.Credentials != nil {
+		state.Credentials = *resp.Credentials
+	}
+	p.workerIdentityProof = resp.WorkerIdentityProof
+
+	return nil
+}
+
+func (p *ExternalProvider) GetWorkerIdentityProof() (map[string]interface{}, error) {
+	return p.workerIdentityProof, nil
+}
+
+func (p *ExternalProvider) UseCachedRun(run *run.State) error {
+	return nil
+}
+
+func (p *ExternalProvider) SetProtocol(proto *workerproto.Protocol) {
+	p.proto = proto
+}
+
+// Handle a line of output from the watch subcommand
+func (p *ExternalProvider) handleWatchEvent(line []byte) {
+	var event watchEvent
+	if err := json.Unmarshal(line, &event); err != nil {
+		log.Printf("Invalid output from provider plugin watch: %v", err)
+		return
+	}
+	switch event.Type {
+	case "graceful-termination":
+		log.Printf("Provider plugin says termination is imminent")
+		// the plugin may report this before the worker has said whether it
+		// supports it
+		p.proto.WaitUntilInitialized()
+		if p.proto.Capable("graceful-termination") {
+			properties := map[string]interface{}{
+				"finish-tasks": event.FinishTasks,
+			}
+			if event.Deadline != "" {
+				if _, err := time.Parse(time.RFC3339, event.Deadline); err == nil {
+					properties["deadline"] = event.Deadline
+				} else {
+					log.Printf("Ignoring invalid deadline %q from provider plugin", event.Deadline)
+				}
+			}
+			p.proto.Send(workerproto.Message{
+				Type:       "graceful-termination",
+				Properties: properties,
+			})
+		}
+	default:
+		// ignore event types that this version does not understand, so that
+		// plugins can support several versions
+		log.Printf("Ignoring unrecognized event type %q from provider plugin", event.Type)
+	}
+}
+
+// Run the watch subcommand, which reports events such as impending
+// termination while the worker runs.  A plugin that has nothing to watch for
+// exits immediately.
+func (p *ExternalProvider) WorkerStarted(state *run.State) error {
+	p.proto.AddCapability("graceful-termination")
+
+	cmd, err := p.pluginCommand("watch")
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start provider plugin watch: %w", err)
+	}
+	p.watchLock.Lock()
+	p.watch = cmd
+	p.watchLock.Unlock()
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+				p.handleWatchEvent(line)
+			}
+		}
+		err := cmd.Wait()
+		p.watchLock.Lock()
+		stopped := p.watch == nil
+		p.watch = nil
+		p.watchLock.Unlock()
+		if err != nil && !stopped {
+			log.Printf("Provider plugin watch failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop the watch subcommand, if it is still running
+func (p *ExternalProvider) WorkerFinished(state *run.State) error {
+	p.watchLock.Lock()
+	defer p.watchLock.Unlock()
+	if p.watch != nil {
+		_ = p.watch.Process.Kill()
+		p.watch = nil
+	}
+	return nil
+}
+
+func New(runnercfg *cfg.RunnerConfig) (provider.Provider, error) {
+	p := &ExternalProvider{
+		runnercfg: runnercfg,
+		config:    map[string]interface{}{},
+	}
+
+	if args, ok := runnercfg.Provider.Data["command"].([]interface{}); ok {
+		for _, arg := range args {
+			if s, ok := arg.(string); ok {
+				p.command = append(p.command, s)
+			}
+		}
+		if len(p.command) != len(args) {
+			p.command = nil
+		}
+	}
+	if len(p.command) == 0 {
+		return nil, errors.New("provider.command must be a non-empty list of strings")
+	}
+
+	for k, v := range runnercfg.Provider.Data {
+		if k != "command" {
+			p.config[k] = v
+		}
+	}
+	return p, nil
+}
+
+func Usage() string {
+	return strings.ReplaceAll(`
+The providerType "external" delegates to a plugin program, allowing a custom
+provider to be implemented without modifying worker-runner.  It requires
+
+|||yaml
+provider:
+    providerType: external
+    # the plugin program and any arguments
+    command: [/usr/local/bin/my-provider, --verbose]
+    # any other properties are passed to the plugin
+    region: ...
+|||
+
+Worker-runner runs the plugin with a subcommand appended to the given command,
+writing a JSON request to its stdin of the form
+|{"version": 1, "config": {..}}|, where |config| contains the provider
+properties other than |providerType| and |command|.  The version will be
+incremented for incompatible changes to the protocol.  The plugin's stderr is
+logged.
+
+The |configure-run| subcommand is run at startup, and must write a JSON object
+to stdout with the following properties, and exit successfully:
+
+* |rootUrl|, |providerId|, |workerPoolId|, |workerGroup|, |workerId|: the
+  worker's identity
+* |workerLocation|: (optional) properties for TASKCLUSTER_WORKER_LOCATION,
+  with string values
+* |providerMetadata|: (optional) provider metadata for the worker, such as
+  |instance-type|
+* |workerIdentityProof|: the proof to pass to worker-manager's
+  |registerWorker| method, which must be understood by the worker-manager
+  provider for this worker pool; or
+* |credentials|: Taskcluster credentials (|clientId|, |accessToken|, and
+  optionally |certificate|), in which case the worker is not registered with
+  worker-manager
+
+Since worker-manager's providers are not pluggable, a plugin for a cloud that
+worker-manager does not support will typically manage workers in a
+worker-manager |static| provider, returning a |staticSecret| proof.
+
+The |watch| subcommand is run when the worker starts, and runs for as long as
+the worker does.  It writes a JSON object to stdout, on a single line, for each
+event.  The event |{"type": "graceful-termination", "finishTasks": false}|,
+optionally with a |deadline| property giving the time of termination in
+RFC3339 format, asks the worker to stop, for example because the instance is
+about to be terminated.  Other event types are ignored.  A plugin with nothing
+to watch for should exit immediately.  Plugins should exit successfully with no
+output for unrecognized subcommands, which may be added in future versions.
+
+The [$TASKCLUSTER_WORKER_LOCATION](https://docs.taskcluster.net/docs/manual/design/env-vars#taskcluster_worker_location)
+defined by this provider has the following fields:
+
+* cloud: external
+
+as well as any worker location values from the plugin.
+
+NOTE: with the 'cacheOverRestarts' configuration, |configure-run| is not run
+on subsequent startups, but |watch| is.
+`, "|", "`")
+}
@@ -0,0 +1,165 @@
+package external
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
+)
+
+// A plugin that records its request and implements each subcommand with the
+// given shell code
+func writePlugin(t *testing.T, configureRun, watch string) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+	dir := t.TempDir()
+	requestFile := filepath.Join(dir, "request.json")
+	plugin := filepath.Join(dir, "plugin.sh")
+	script := `#!/bin/sh
+cat > ` + requestFile + `
+case "$2" in
+configure-run) ` + configureRun + ` ;;
+watch) ` + watch + ` ;;
+esac
+`
+	require.NoError(t, os.WriteFile(plugin, []byte(script), 0755))
+	return plugin, requestFile
+}
+
+func newProvider(t *testing.T, plugin string) *ExternalProvider {
+	t.Helper()
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "external",
+			Data: map[string]interface{}{
+				"command": []interface{}{plugin, "--flag"},
+				"region":  "moon-1",
+			},
+		},
+	}
+	p, err := New(runnercfg)
+	require.NoError(t, err)
+	return p.(*ExternalProvider)
+}
+
+func TestConfigureRun(t *testing.T) {
+	plugin, requestFile := writePlugin(t, `echo '{
+		"rootUrl": "https://tc.example.com/",
+		"providerId": "custom",
+		"workerPoolId": "w/p",
+		"workerGroup": "moon-1",
+		"workerId": "wkr-1",
+		"workerLocation": {"region": "moon-1"},
+		"providerMetadata": {"instance-type": "large"},
+		"workerIdentityProof": {"token": "let-me-in"}
+	}'`, "")
+	p := newProvider(t, plugin)
+
+	state := run.State{}
+	require.NoError(t, p.ConfigureRun(&state))
+
+	request, err := os.ReadFile(requestFile)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"version": 1, "config": {"region": "moon-1"}}`, string(request))
+
+	require.Equal(t, "https://tc.example.com", state.RootURL)
+	require.Equal(t, "custom", state.ProviderID)
+	require.Equal(t, "w/p", state.WorkerPoolID)
+	require.Equal(t, "moon-1", state.WorkerGroup)
+	require.Equal(t, "wkr-1", state.WorkerID)
+	require.Equal(t, map[string]string{"cloud": "external", "region": "moon-1"}, state.WorkerLocation)
+	require.Equal(t, map[string]interface{}{"instance-type": "large"}, state.ProviderMetadata)
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"token": "let-me-in"}, proof)
+}
+
+func TestConfigureRunCredentials(t *testing.T) {
+	plugin, _ := writePlugin(t, `echo '{
+		"rootUrl": "https://tc.example.com",
+		"providerId": "custom",
+		"workerPoolId": "w/p",
+		"workerGroup": "wg",
+		"workerId": "wi",
+		"credentials": {"clientId": "cli", "accessToken": "at"}
+	}'`, "")
+	p := newProvider(t, plugin)
+
+	state := run.State{}
+	require.NoError(t, p.ConfigureRun(&state))
+	require.Equal(t, "cli", state.Credentials.ClientID)
+	require.Equal(t, "at", state.Credentials.AccessToken)
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Nil(t, proof)
+}
+
+func TestConfigureRunErrors(t *testing.T) {
+	for name, configureRun := range map[string]string{
+		"failure":        "exit 1",
+		"invalid output": "echo not-json",
+		"no proof":       `echo '{"rootUrl": "https://tc.example.com"}'`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			plugin, _ := writePlugin(t, configureRun, "")
+			p := newProvider(t, plugin)
+			require.Error(t, p.ConfigureRun(&run.State{}))
+		})
+	}
+}
+
+func TestInvalidCommand(t *testing.T) {
+	for name, command := range map[string]interface{}{
+		"missing":    nil,
+		"empty":      []interface{}{},
+		"not string": []interface{}{"plugin", 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			runnercfg := &cfg.RunnerConfig{
+				Provider: cfg.ProviderConfig{
+					ProviderType: "external",
+					Data:         map[string]interface{}{"command": command},
+				},
+			}
+			_, err := New(runnercfg)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	plugin, _ := writePlugin(t, "", `
+		echo '{"type": "something-new"}'
+		echo '{"type": "graceful-termination", "finishTasks": true, "deadline": "2030-01-01T00:00:00Z"}'
+		exec sleep 60`)
+
+	wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
+	defer wkr.Close()
+	gotTerm := wkr.MessageReceivedFunc("graceful-termination", func(msg workerproto.Message) bool {
+		return msg.Properties["finish-tasks"] == true && msg.Properties["deadline"] == "2030-01-01T00:00:00Z"
+	})
+
+	p := newProvider(t, plugin)
+	p.SetProtocol(wkr.RunnerProtocol)
+	require.NoError(t, p.WorkerStarted(&run.State{}))
+	wkr.RunnerProtocol.Start(false)
+	wkr.RunnerProtocol.WaitUntilInitialized()
+
+	require.Eventually(t, gotTerm, 10*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, p.WorkerFinished(&run.State{}))
+	p.watchLock.Lock()
+	require.Nil(t, p.watch)
+	p.watchLock.Unlock()
+}
@@ -18,6 +18,10 @@ import (
 
 const TERMINATION_PATH = "/instance/preempted"
 
+// GCE gives preempted instances 30 seconds' notice before they are stopped;
+// see https://cloud.google.com/compute/docs/instances/preemptible#preemption-process
+const PREEMPTION_NOTICE = 30 * time.Second
+
 type GoogleProvider struct {
 	runnercfg                  *cfg.RunnerConfig
 	workerManagerClientFactory tc.WorkerManagerClientFactory
@@ -25,6 +29,9 @@ type GoogleProvider struct {
 	proto                      *workerproto.Protocol
 	workerIdentityProof        map[string]interface{}
 	terminationTicker          *time.Ticker
+	// time at which the instance will be stopped, once preemption is
+	// detected
+	terminationDeadline time.Time
 }
 
 func (p *GoogleProvider) ConfigureRun(state *run.State) error {
@@ -114,13 +121,19 @@ func (p *GoogleProvider) checkTerminationTime() bool {
 	value, err := p.metadataService.queryMetadata(TERMINATION_PATH)
 	// if the file exists and contains TRUE, it's time to go away
 	if err == nil && value == "TRUE" {
-		log.Println("GCP Metadata Service says termination is imminent")
+		if p.terminationDeadline.IsZero() {
+			p.terminationDeadline = time.Now().Add(PREEMPTION_NOTICE)
+		}
+		log.Printf("GCP Metadata Service says termination is imminent, at %v", p.terminationDeadline.UTC().Format(time.RFC3339))
 		if p.proto != nil && p.proto.Capable("graceful-termination") {
 			p.proto.Send(workerproto.Message{
 				Type: "graceful-termination",
 				Properties: map[string]interface{}{
-					// preemption generally doesn't leave time to finish tasks
+					// preemption generally doesn't leave time to finish
+					// tasks, but the worker may use the remaining time to
+					// let a task complete or checkpoint its work
 					"finish-tasks": false,
+					"deadline":     p.terminationDeadline.UTC().Format(time.RFC3339),
 				},
 			})
 		}
@@ -130,8 +143,10 @@ func (p *GoogleProvider) checkTerminationTime() bool {
 }
 
 func (p *GoogleProvider) WorkerStarted(state *run.State) error {
-	// start polling for graceful shutdown
-	p.terminationTicker = time.NewTicker(15 * time.Second)
+	// start polling for graceful shutdown; this is frequent, since the
+	// preemption notice is short, and the worker can make use of whatever
+	// remains of it
+	p.terminationTicker = time.NewTicker(5 * time.Second)
 	p.proto.AddCapability("graceful-termination")
 
 	go func() {
@@ -174,6 +189,11 @@ defined by this provider has the following fields:
 * cloud: google
 * region
 * zone
+
+When the instance is preempted, the worker is asked to terminate gracefully,
+without finishing its current tasks.  The time at which the instance will be
+stopped (30 seconds after the preemption notice) is passed to the worker as the
+` + "`deadline`" + ` of the graceful-termination message.
 `
 }
 
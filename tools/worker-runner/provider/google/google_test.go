@@ -2,6 +2,7 @@ package google
 
 import (
 	"testing"
+	"time"
 
 	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
 
@@ -124,7 +125,19 @@ func TestCheckTerminationTime(t *testing.T) {
 		require.False(t, p.checkTerminationTime())
 
 		metaData["/instance/preempted"] = "TRUE"
+		before := time.Now()
 		require.True(t, p.checkTerminationTime())
+		deadline := p.terminationDeadline
+		require.False(t, deadline.Before(before.Add(PREEMPTION_NOTICE)))
+
+		// the deadline does not move on subsequent polls
+		require.True(t, p.checkTerminationTime())
+		require.Equal(t, deadline, p.terminationDeadline)
+	}
+
+	hasDeadline := func(msg workerproto.Message) bool {
+		_, err := time.Parse(time.RFC3339, msg.Properties["deadline"].(string))
+		return err == nil
 	}
 
 	t.Run("without capability", func(t *testing.T) {
@@ -142,7 +155,7 @@ func TestCheckTerminationTime(t *testing.T) {
 		wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
 		defer wkr.Close()
 
-		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", hasDeadline)
 
 		test(t, wkr.RunnerProtocol, false)
 
@@ -0,0 +1,168 @@
+package hetzner
+
+import (
+	"fmt"
+	"log"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/provider"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+type HetznerProvider struct {
+	runnercfg                  *cfg.RunnerConfig
+	workerManagerClientFactory tc.WorkerManagerClientFactory
+	metadataService            MetadataService
+	proto                      *workerproto.Protocol
+	workerIdentityProof        map[string]interface{}
+}
+
+func (p *HetznerProvider) ConfigureRun(state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	workerID, err := p.metadataService.queryMetadata("/instance-id")
+	if err != nil {
+		return fmt.Errorf("could not query metadata: %v", err)
+	}
+
+	userData, err := p.metadataService.queryUserData()
+	if err != nil {
+		return fmt.Errorf("could not query user data: %v", err)
+	}
+
+	state.RootURL = userData.RootURL
+	state.ProviderID = userData.ProviderID
+	state.WorkerPoolID = userData.WorkerPoolID
+	state.WorkerGroup = userData.WorkerGroup
+	state.WorkerID = workerID
+
+	providerMetadata := map[string]interface{}{
+		"instance-id": workerID,
+	}
+	for _, f := range []struct {
+		name string
+		path string
+	}{
+		{"public-hostname", "/hostname"},
+		{"public-ipv4", "/public-ipv4"},
+		{"region", "/region"},
+		{"availability-zone", "/availability-zone"},
+	} {
+		value, err := p.metadataService.queryMetadata(f.path)
+		if err != nil {
+			return fmt.Errorf("error querying Hetzner metadata %v: %v", f.path, err)
+		}
+		providerMetadata[f.name] = value
+	}
+
+	state.WorkerLocation = map[string]string{
+		"cloud":            "hetzner",
+		"region":           providerMetadata["region"].(string),
+		"availabilityZone": providerMetadata["availability-zone"].(string),
+	}
+
+	state.ProviderMetadata = providerMetadata
+
+	p.workerIdentityProof = map[string]interface{}{
+		"serverId": interface{}(workerID),
+		"token":    interface{}(userData.WorkerIdentityToken),
+	}
+
+	return nil
+}
+
+func (p *HetznerProvider) GetWorkerIdentityProof() (map[string]interface{}, error) {
+	return p.workerIdentityProof, nil
+}
+
+func (p *HetznerProvider) UseCachedRun(run *run.State) error {
+	return nil
+}
+
+func (p *HetznerProvider) SetProtocol(proto *workerproto.Protocol) {
+	p.proto = proto
+}
+
+// Hetzner has no termination notice in its metadata service; instead, servers
+// are sent an ACPI shutdown before they are deleted, which arrives as SIGTERM
+// from the init system.
+func (p *HetznerProvider) HandleTerminationSignal() {
+	log.Println("Hetzner server is shutting down")
+	if p.proto != nil && p.proto.Capable("graceful-termination") {
+		p.proto.Send(workerproto.Message{
+			Type: "graceful-termination",
+			Properties: map[string]interface{}{
+				// the server is deleted shortly after the shutdown request,
+				// so there is no time to finish tasks
+				"finish-tasks": false,
+			},
+		})
+	}
+}
+
+func (p *HetznerProvider) WorkerStarted(state *run.State) error {
+	p.proto.AddCapability("graceful-termination")
+	return nil
+}
+
+func (p *HetznerProvider) WorkerFinished(state *run.State) error {
+	return nil
+}
+
+func clientFactory(rootURL string, credentials *tcclient.Credentials) (tc.WorkerManager, error) {
+	prov := tcworkermanager.New(credentials, rootURL)
+	return prov, nil
+}
+
+func New(runnercfg *cfg.RunnerConfig) (provider.Provider, error) {
+	return new(runnercfg, nil, nil)
+}
+
+func Usage() string {
+	return `
+The providerType "hetzner" is intended for workers provisioned with worker-manager
+providers using providerType "hetzner".  It requires
+
+` + "```yaml" + `
+provider:
+    providerType: hetzner
+` + "```" + `
+
+Worker-manager does not yet have a provider of type "hetzner", so workers
+using this provider cannot yet be provisioned and registered end to end.
+
+Worker-manager is expected to provide the worker's configuration as JSON user
+data, including a ` + "`workerIdentityToken`" + ` that is used to register the worker.
+
+When the server is shut down prior to deletion, the worker is asked to
+terminate gracefully, without finishing its current tasks.
+
+The [$TASKCLUSTER_WORKER_LOCATION](https://docs.taskcluster.net/docs/manual/design/env-vars#taskcluster_worker_location)
+defined by this provider has the following fields:
+
+* cloud: hetzner
+* region
+* availabilityZone
+`
+}
+
+// New takes its dependencies as optional arguments, allowing injection of fake dependencies for testing.
+func new(runnercfg *cfg.RunnerConfig, workerManagerClientFactory tc.WorkerManagerClientFactory, metadataService MetadataService) (*HetznerProvider, error) {
+	if workerManagerClientFactory == nil {
+		workerManagerClientFactory = clientFactory
+	}
+	if metadataService == nil {
+		metadataService = &realMetadataService{}
+	}
+	return &HetznerProvider{
+		runnercfg:                  runnercfg,
+		workerManagerClientFactory: workerManagerClientFactory,
+		metadataService:            metadataService,
+		proto:                      nil,
+	}, nil
+}
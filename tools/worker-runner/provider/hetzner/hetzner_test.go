@@ -0,0 +1,116 @@
+package hetzner
+
+import (
+	"testing"
+	"time"
+
+	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+func fakeMetadata() *fakeMetadataService {
+	userData := &UserData{
+		WorkerPoolID:        "w/p",
+		ProviderID:          "hetzner1",
+		WorkerGroup:         "wg",
+		RootURL:             "https://tc.example.com",
+		WorkerIdentityToken: "i-promise",
+	}
+	metaData := map[string]string{
+		"/instance-id":       "12345",
+		"/hostname":          "my-worker",
+		"/public-ipv4":       "1.2.3.4",
+		"/region":            "eu-central",
+		"/availability-zone": "fsn1-dc14",
+	}
+	return &fakeMetadataService{nil, userData, metaData}
+}
+
+func TestHetznerConfigureRun(t *testing.T) {
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "hetzner",
+		},
+		WorkerImplementation: cfg.WorkerImplementationConfig{
+			Implementation: "whatever-worker",
+		},
+	}
+
+	p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory, fakeMetadata())
+	require.NoError(t, err, "creating provider")
+
+	state := run.State{}
+	err = p.ConfigureRun(&state)
+	require.NoError(t, err, "ConfigureRun")
+
+	require.Equal(t, "https://tc.example.com", state.RootURL, "rootURL is correct")
+	require.Equal(t, "hetzner1", state.ProviderID, "providerID is correct")
+	require.Equal(t, "w/p", state.WorkerPoolID, "workerPoolID is correct")
+	require.Equal(t, "wg", state.WorkerGroup, "workerGroup is correct")
+	require.Equal(t, "12345", state.WorkerID, "workerID is correct")
+
+	require.Equal(t, map[string]interface{}{
+		"instance-id":       "12345",
+		"public-hostname":   "my-worker",
+		"public-ipv4":       "1.2.3.4",
+		"region":            "eu-central",
+		"availability-zone": "fsn1-dc14",
+	}, state.ProviderMetadata, "providerMetadata is correct")
+
+	require.Equal(t, "hetzner", state.WorkerLocation["cloud"])
+	require.Equal(t, "eu-central", state.WorkerLocation["region"])
+	require.Equal(t, "fsn1-dc14", state.WorkerLocation["availabilityZone"])
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"serverId": "12345",
+		"token":    "i-promise",
+	}, proof)
+}
+
+func TestTerminationSignal(t *testing.T) {
+	test := func(t *testing.T, wkr *ptesting.FakeWorker) {
+		t.Helper()
+
+		p := &HetznerProvider{
+			metadataService: fakeMetadata(),
+		}
+
+		p.SetProtocol(wkr.RunnerProtocol)
+		state := run.State{}
+		require.NoError(t, p.WorkerStarted(&state))
+		wkr.RunnerProtocol.Start(false)
+		wkr.RunnerProtocol.WaitUntilInitialized()
+
+		p.HandleTerminationSignal()
+		// give the worker time to receive the message
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Run("without capability", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities()
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+
+		test(t, wkr)
+
+		require.False(t, gotTerm())
+	})
+
+	t.Run("with capability", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+
+		test(t, wkr)
+
+		require.True(t, gotTerm())
+	})
+}
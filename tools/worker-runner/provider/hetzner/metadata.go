@@ -0,0 +1,65 @@
+package hetzner
+
+// See https://docs.hetzner.cloud/#server-metadata
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+var metadataBaseURL = "http://169.254.169.254/hetzner/v1"
+
+// user-data sent to us from the worker-manager service
+type UserData struct {
+	WorkerPoolID string `json:"workerPoolId"`
+	ProviderID   string `json:"providerId"`
+	WorkerGroup  string `json:"workerGroup"`
+	RootURL      string `json:"rootUrl"`
+
+	// Hetzner does not provide signed instance identity documents, so
+	// worker-manager generates a secret for each server it creates, and
+	// checks it when the worker registers
+	WorkerIdentityToken string `json:"workerIdentityToken"`
+
+	// NOTE: this is ignored, in preference to the configuration
+	// returned from registerWorker
+	ProviderWorkerConfig *json.RawMessage `json:"workerConfig"`
+}
+
+type MetadataService interface {
+	// Query the UserData and return the parsed contents
+	queryUserData() (*UserData, error)
+
+	// Query an arbitrary metadata value; path is the portion following `metadata`
+	queryMetadata(path string) (string, error)
+}
+
+type realMetadataService struct{}
+
+func (mds *realMetadataService) queryUserData() (*UserData, error) {
+	content, err := mds.get("/userdata")
+	if err != nil {
+		return nil, err
+	}
+	userData := &UserData{}
+	err = json.Unmarshal([]byte(content), userData)
+	return userData, err
+}
+
+func (mds *realMetadataService) queryMetadata(path string) (string, error) {
+	content, err := mds.get("/metadata" + path)
+	return strings.TrimSpace(content), err
+}
+
+func (mds *realMetadataService) get(path string) (string, error) {
+	resp, _, err := httpbackoff.Get(metadataBaseURL + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	return string(content), err
+}
@@ -0,0 +1,95 @@
+package hetzner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+type fakeMetadataService struct {
+	UserDataError error
+	UserData      *UserData
+	Metadata      map[string]string
+}
+
+func (mds *fakeMetadataService) queryUserData() (*UserData, error) {
+	if mds.UserDataError != nil {
+		return nil, mds.UserDataError
+	}
+	return mds.UserData, nil
+}
+
+func (mds *fakeMetadataService) queryMetadata(path string) (string, error) {
+	if path[0] != '/' {
+		panic("path must start with /")
+	}
+	res, ok := mds.Metadata[path]
+	if !ok {
+		return "", fmt.Errorf("not found: %s", path)
+	}
+	return res, nil
+}
+
+func TestQueryMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hetzner/v1/metadata/instance-id" {
+			w.WriteHeader(200)
+			fmt.Fprintln(w, "42")
+		} else {
+			w.WriteHeader(404)
+			fmt.Fprintln(w, "Not Found")
+		}
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/hetzner/v1"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/hetzner/v1"
+	}()
+
+	ms := realMetadataService{}
+
+	rv, err := ms.queryMetadata("/instance-id")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "42", rv)
+	}
+
+	_, err = ms.queryMetadata("/NOSUCH")
+	if assert.Error(t, err) {
+		httperr, ok := err.(httpbackoff.BadHttpResponseCode)
+		assert.True(t, ok)
+		assert.Equal(t, 404, httperr.HttpResponseCode)
+	}
+}
+
+func TestQueryUserData(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hetzner/v1/userdata" {
+			w.WriteHeader(200)
+			fmt.Fprintln(w, `{"workerPoolId": "w/p", "workerIdentityToken": "sekrit", "workerConfig": {"from-worker-config": true}}`)
+		} else {
+			w.WriteHeader(404)
+			fmt.Fprintf(w, "Not Found: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/hetzner/v1"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/hetzner/v1"
+	}()
+
+	ms := realMetadataService{}
+
+	ud, err := ms.queryUserData()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "w/p", ud.WorkerPoolID)
+		assert.Equal(t, "sekrit", ud.WorkerIdentityToken)
+		assert.Equal(t, json.RawMessage(`{"from-worker-config": true}`), *ud.ProviderWorkerConfig)
+	}
+}
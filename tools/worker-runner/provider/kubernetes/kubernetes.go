@@ -0,0 +1,224 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/provider"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+const (
+	defaultConfigDir  = "/etc/taskcluster"
+	defaultPodInfoDir = "/etc/podinfo"
+	defaultTokenPath  = "/var/run/secrets/tokens/taskcluster"
+)
+
+type KubernetesProvider struct {
+	runnercfg                  *cfg.RunnerConfig
+	workerManagerClientFactory tc.WorkerManagerClientFactory
+	proto                      *workerproto.Protocol
+	workerIdentityProof        map[string]interface{}
+}
+
+// optionalPath returns the string value of the named provider configuration
+// property, or def if it is not set.
+func (p *KubernetesProvider) optionalPath(name, def string) (string, error) {
+	val, ok := p.runnercfg.Provider.Data[name]
+	if !ok {
+		return def, nil
+	}
+	s, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("configuration value `provider.%s` should have type string", name)
+	}
+	return s, nil
+}
+
+// readFile returns the whitespace-trimmed content of the named file in dir, as
+// projected into the pod by a ConfigMap or downward API volume.
+func readFile(dir, name string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func (p *KubernetesProvider) ConfigureRun(state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	configDir, err := p.optionalPath("configDir", defaultConfigDir)
+	if err != nil {
+		return err
+	}
+	podInfoDir, err := p.optionalPath("podInfoDir", defaultPodInfoDir)
+	if err != nil {
+		return err
+	}
+	tokenPath, err := p.optionalPath("tokenPath", defaultTokenPath)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]string{}
+	for _, f := range []struct {
+		dir  string
+		name string
+	}{
+		{configDir, "rootUrl"},
+		{configDir, "providerId"},
+		{configDir, "workerPoolId"},
+		{configDir, "workerGroup"},
+		{podInfoDir, "name"},
+		{podInfoDir, "namespace"},
+		{podInfoDir, "uid"},
+		{podInfoDir, "nodeName"},
+	} {
+		value, err := readFile(f.dir, f.name)
+		if err != nil {
+			return fmt.Errorf("could not read pod configuration: %v", err)
+		}
+		values[f.name] = value
+	}
+
+	state.RootURL = tcurls.NormalizeRootURL(values["rootUrl"])
+	state.ProviderID = values["providerId"]
+	state.WorkerPoolID = values["workerPoolId"]
+	state.WorkerGroup = values["workerGroup"]
+	state.WorkerID = values["name"]
+
+	state.WorkerLocation = map[string]string{
+		"cloud":     "kubernetes",
+		"namespace": values["namespace"],
+	}
+
+	state.ProviderMetadata = map[string]interface{}{
+		"instance-id": values["name"],
+		"namespace":   values["namespace"],
+		"pod-uid":     values["uid"],
+		"node-name":   values["nodeName"],
+	}
+
+	// the worker identity is a service account token bound to this pod,
+	// with the deployment's root URL as its audience
+	token, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return fmt.Errorf("could not read service account token: %v", err)
+	}
+
+	p.workerIdentityProof = map[string]interface{}{
+		"token": interface{}(strings.TrimSpace(string(token))),
+	}
+
+	return nil
+}
+
+func (p *KubernetesProvider) GetWorkerIdentityProof() (map[string]interface{}, error) {
+	return p.workerIdentityProof, nil
+}
+
+func (p *KubernetesProvider) UseCachedRun(run *run.State) error {
+	return errors.New("do not use cacheOverRestarts with kubernetes provider")
+}
+
+func (p *KubernetesProvider) SetProtocol(proto *workerproto.Protocol) {
+	p.proto = proto
+}
+
+// The kubelet sends SIGTERM to the container when the pod is evicted, preempted
+// or deleted, and kills it once the pod's termination grace period has elapsed.
+func (p *KubernetesProvider) HandleTerminationSignal() {
+	log.Println("Pod is being terminated")
+	if p.proto != nil && p.proto.Capable("graceful-termination") {
+		p.proto.Send(workerproto.Message{
+			Type: "graceful-termination",
+			Properties: map[string]interface{}{
+				// the termination grace period is generally too short to
+				// finish tasks
+				"finish-tasks": false,
+			},
+		})
+	}
+}
+
+func (p *KubernetesProvider) WorkerStarted(state *run.State) error {
+	p.proto.AddCapability("graceful-termination")
+	return nil
+}
+
+func (p *KubernetesProvider) WorkerFinished(state *run.State) error {
+	return nil
+}
+
+func clientFactory(rootURL string, credentials *tcclient.Credentials) (tc.WorkerManager, error) {
+	prov := tcworkermanager.New(credentials, rootURL)
+	return prov, nil
+}
+
+func New(runnercfg *cfg.RunnerConfig) (provider.Provider, error) {
+	return new(runnercfg, nil)
+}
+
+func Usage() string {
+	return `
+The providerType "kubernetes" is intended for workers running in Kubernetes pods
+that are provisioned with worker-manager providers using providerType
+"kubernetes".  It requires
+
+` + "```yaml" + `
+provider:
+    providerType: kubernetes
+    # (optional) directory containing a ConfigMap with keys rootUrl,
+    # providerId, workerPoolId and workerGroup
+    configDir: /etc/taskcluster
+    # (optional) directory containing a downward API volume with files name,
+    # namespace, uid and nodeName, from the corresponding pod fields
+    podInfoDir: /etc/podinfo
+    # (optional) path of a projected service account token whose audience
+    # is the deployment's root URL
+    tokenPath: /var/run/secrets/tokens/taskcluster
+` + "```" + `
+
+Worker-manager does not yet have a provider of type "kubernetes", so workers
+using this provider cannot yet be provisioned and registered end to end.
+
+The service account token is used as proof of the worker's identity when it
+registers with worker-manager.  The worker ID is the pod name.
+
+When the pod is evicted, preempted or deleted, the worker is asked to
+terminate gracefully, without finishing its current tasks.
+
+The [$TASKCLUSTER_WORKER_LOCATION](https://docs.taskcluster.net/docs/manual/design/env-vars#taskcluster_worker_location)
+defined by this provider has the following fields:
+
+* cloud: kubernetes
+* namespace
+
+NOTE: do not use the 'cacheOverRestarts' configuration with the kubernetes
+provider.  A restarted container should register afresh.
+`
+}
+
+// New takes its dependencies as optional arguments, allowing injection of fake dependencies for testing.
+func new(runnercfg *cfg.RunnerConfig, workerManagerClientFactory tc.WorkerManagerClientFactory) (*KubernetesProvider, error) {
+	if workerManagerClientFactory == nil {
+		workerManagerClientFactory = clientFactory
+	}
+	return &KubernetesProvider{
+		runnercfg:                  runnercfg,
+		workerManagerClientFactory: workerManagerClientFactory,
+		proto:                      nil,
+	}, nil
+}
@@ -0,0 +1,146 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestKubernetesConfigureRun(t *testing.T) {
+	configDir := t.TempDir()
+	podInfoDir := t.TempDir()
+	tokenPath := filepath.Join(t.TempDir(), "token")
+
+	writeFiles(t, configDir, map[string]string{
+		"rootUrl":      "https://tc.example.com/",
+		"providerId":   "k8s1",
+		"workerPoolId": "w/p",
+		"workerGroup":  "wg",
+	})
+	writeFiles(t, podInfoDir, map[string]string{
+		"name":      "worker-abc12",
+		"namespace": "workers",
+		"uid":       "f3a1b2c4-0000-1111-2222-333344445555",
+		"nodeName":  "node-7\n",
+	})
+	require.NoError(t, os.WriteFile(tokenPath, []byte("i-promise\n"), 0600))
+
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "kubernetes",
+			Data: map[string]interface{}{
+				"configDir":  configDir,
+				"podInfoDir": podInfoDir,
+				"tokenPath":  tokenPath,
+			},
+		},
+		WorkerImplementation: cfg.WorkerImplementationConfig{
+			Implementation: "whatever-worker",
+		},
+	}
+
+	p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory)
+	require.NoError(t, err, "creating provider")
+
+	state := run.State{}
+	err = p.ConfigureRun(&state)
+	require.NoError(t, err, "ConfigureRun")
+
+	require.Equal(t, "https://tc.example.com", state.RootURL, "rootURL is correct")
+	require.Equal(t, "k8s1", state.ProviderID, "providerID is correct")
+	require.Equal(t, "w/p", state.WorkerPoolID, "workerPoolID is correct")
+	require.Equal(t, "wg", state.WorkerGroup, "workerGroup is correct")
+	require.Equal(t, "worker-abc12", state.WorkerID, "workerID is correct")
+
+	require.Equal(t, map[string]interface{}{
+		"instance-id": "worker-abc12",
+		"namespace":   "workers",
+		"pod-uid":     "f3a1b2c4-0000-1111-2222-333344445555",
+		"node-name":   "node-7",
+	}, state.ProviderMetadata, "providerMetadata is correct")
+
+	require.Equal(t, map[string]string{
+		"cloud":     "kubernetes",
+		"namespace": "workers",
+	}, state.WorkerLocation)
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"token": "i-promise",
+	}, proof)
+}
+
+func TestKubernetesConfigureRunMissingConfig(t *testing.T) {
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "kubernetes",
+			Data: map[string]interface{}{
+				"configDir":  t.TempDir(),
+				"podInfoDir": t.TempDir(),
+			},
+		},
+	}
+
+	p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory)
+	require.NoError(t, err, "creating provider")
+
+	state := run.State{}
+	err = p.ConfigureRun(&state)
+	require.ErrorContains(t, err, "could not read pod configuration")
+}
+
+func TestTerminationSignal(t *testing.T) {
+	test := func(t *testing.T, wkr *ptesting.FakeWorker) {
+		t.Helper()
+
+		p := &KubernetesProvider{}
+
+		p.SetProtocol(wkr.RunnerProtocol)
+		state := run.State{}
+		require.NoError(t, p.WorkerStarted(&state))
+		wkr.RunnerProtocol.Start(false)
+		wkr.RunnerProtocol.WaitUntilInitialized()
+
+		p.HandleTerminationSignal()
+		// give the worker time to receive the message
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Run("without capability", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities()
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+
+		test(t, wkr)
+
+		require.False(t, gotTerm())
+	})
+
+	t.Run("with capability", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+
+		test(t, wkr)
+
+		require.True(t, gotTerm())
+	})
+}
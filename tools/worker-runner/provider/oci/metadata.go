@@ -0,0 +1,111 @@
+package oci
+
+// See https://docs.oracle.com/en-us/iaas/Content/Compute/Tasks/gettingmetadata.htm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+var metadataBaseURL = "http://169.254.169.254/opc/v2"
+
+// user-data sent to us from the worker-manager service
+type UserData struct {
+	WorkerPoolID string `json:"workerPoolId"`
+	ProviderID   string `json:"providerId"`
+	WorkerGroup  string `json:"workerGroup"`
+	RootURL      string `json:"rootUrl"`
+
+	// NOTE: this is ignored, in preference to the configuration
+	// returned from registerWorker
+	ProviderWorkerConfig *json.RawMessage `json:"workerConfig"`
+}
+
+// The subset of the instance document returned from /instance/ that we use
+type InstanceDocument struct {
+	ID                 string `json:"id"`
+	DisplayName        string `json:"displayName"`
+	CompartmentID      string `json:"compartmentId"`
+	Image              string `json:"image"`
+	Shape              string `json:"shape"`
+	CanonicalRegion    string `json:"canonicalRegionName"`
+	AvailabilityDomain string `json:"availabilityDomain"`
+	FaultDomain        string `json:"faultDomain"`
+	Metadata           struct {
+		// base64-encoded, as for cloud-init
+		UserData string `json:"user_data"`
+	} `json:"metadata"`
+}
+
+type MetadataService interface {
+	// Query the UserData and return the parsed contents
+	queryUserData() (*UserData, error)
+
+	// return both parsed and unparsed instance document
+	queryInstanceDocument() (string, *InstanceDocument, error)
+
+	// Query an arbitrary metadata value; path is the portion following `v2`
+	queryMetadata(path string) (string, error)
+}
+
+type realMetadataService struct{}
+
+func (mds *realMetadataService) queryUserData() (*UserData, error) {
+	_, doc, err := mds.queryInstanceDocument()
+	if err != nil {
+		return nil, err
+	}
+	content, err := base64.StdEncoding.DecodeString(doc.Metadata.UserData)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode user_data: %v", err)
+	}
+	userData := &UserData{}
+	err = json.Unmarshal(content, userData)
+	return userData, err
+}
+
+func (mds *realMetadataService) queryInstanceDocument() (string, *InstanceDocument, error) {
+	content, err := mds.queryMetadata("/instance/")
+	if err != nil {
+		return "", nil, err
+	}
+	doc := &InstanceDocument{}
+	err = json.Unmarshal([]byte(content), doc)
+	return content, doc, err
+}
+
+func (mds *realMetadataService) queryMetadata(path string) (string, error) {
+	client := http.Client{}
+	req, err := http.NewRequest("GET", metadataBaseURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// version 2 of the metadata service requires this header
+	req.Header.Set("Authorization", "Bearer Oracle")
+
+	resp, _, err := httpbackoff.ClientDo(&client, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	return string(content), err
+}
+
+// primaryPrivateIP returns the private IP of the first VNIC in the given
+// /vnics/ response, which is the instance's primary VNIC.
+func primaryPrivateIP(vnics string) string {
+	var parsed []struct {
+		PrivateIP string `json:"privateIp"`
+	}
+	if err := json.Unmarshal([]byte(vnics), &parsed); err != nil || len(parsed) == 0 {
+		return ""
+	}
+	return parsed[0].PrivateIP
+}
@@ -0,0 +1,112 @@
+package oci
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+type fakeMetadataService struct {
+	UserDataError    error
+	UserData         *UserData
+	Metadata         map[string]string
+	InstanceDocument string
+}
+
+func (mds *fakeMetadataService) queryUserData() (*UserData, error) {
+	if mds.UserDataError != nil {
+		return nil, mds.UserDataError
+	}
+	return mds.UserData, nil
+}
+
+func (mds *fakeMetadataService) queryMetadata(path string) (string, error) {
+	if path[0] != '/' {
+		panic("path must start with /")
+	}
+	res, ok := mds.Metadata[path]
+	if !ok {
+		return "", fmt.Errorf("not found: %s", path)
+	}
+	return res, nil
+}
+
+func (mds *fakeMetadataService) queryInstanceDocument() (string, *InstanceDocument, error) {
+	doc := &InstanceDocument{}
+	err := json.Unmarshal([]byte(mds.InstanceDocument), doc)
+	return mds.InstanceDocument, doc, err
+}
+
+func TestQueryMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer Oracle" {
+			w.WriteHeader(401)
+			fmt.Fprintln(w, "Authorization Missing")
+		} else if r.URL.Path == "/opc/v2/instance/id" {
+			w.WriteHeader(200)
+			fmt.Fprintln(w, "ocid1.instance.oc1..abc")
+		} else {
+			w.WriteHeader(404)
+			fmt.Fprintln(w, "Not Found")
+		}
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/opc/v2"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/opc/v2"
+	}()
+
+	ms := realMetadataService{}
+
+	rv, err := ms.queryMetadata("/instance/id")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ocid1.instance.oc1..abc\n", rv)
+	}
+
+	_, err = ms.queryMetadata("/instance/NOSUCH")
+	if assert.Error(t, err) {
+		httperr, ok := err.(httpbackoff.BadHttpResponseCode)
+		assert.True(t, ok)
+		assert.Equal(t, 404, httperr.HttpResponseCode)
+	}
+}
+
+func TestQueryUserData(t *testing.T) {
+	userData := base64.StdEncoding.EncodeToString([]byte(`{"workerPoolId": "w/p", "workerConfig": {"from-worker-config": true}}`))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/opc/v2/instance/" {
+			w.WriteHeader(200)
+			fmt.Fprintf(w, `{"id": "ocid1.instance.oc1..abc", "metadata": {"user_data": "%s"}}`, userData)
+		} else {
+			w.WriteHeader(404)
+			fmt.Fprintf(w, "Not Found: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/opc/v2"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/opc/v2"
+	}()
+
+	ms := realMetadataService{}
+
+	ud, err := ms.queryUserData()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "w/p", ud.WorkerPoolID)
+		assert.Equal(t, json.RawMessage(`{"from-worker-config": true}`), *ud.ProviderWorkerConfig)
+	}
+}
+
+func TestPrimaryPrivateIP(t *testing.T) {
+	assert.Equal(t, "10.0.0.2", primaryPrivateIP(`[{"vnicId": "v1", "privateIp": "10.0.0.2"}, {"vnicId": "v2", "privateIp": "10.0.1.2"}]`))
+	assert.Equal(t, "", primaryPrivateIP(`[]`))
+	assert.Equal(t, "", primaryPrivateIP(`garbage`))
+}
@@ -0,0 +1,222 @@
+package oci
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/provider"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+type OCIProvider struct {
+	runnercfg                  *cfg.RunnerConfig
+	workerManagerClientFactory tc.WorkerManagerClientFactory
+	metadataService            MetadataService
+	proto                      *workerproto.Protocol
+	workerIdentityProof        map[string]interface{}
+}
+
+func (p *OCIProvider) ConfigureRun(state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	userData, err := p.metadataService.queryUserData()
+	if err != nil {
+		return fmt.Errorf("could not query user data: %v", err)
+	}
+
+	docString, doc, err := p.metadataService.queryInstanceDocument()
+	if err != nil {
+		return fmt.Errorf("could not query instance document: %v", err)
+	}
+
+	state.RootURL = userData.RootURL
+	state.ProviderID = userData.ProviderID
+	state.WorkerPoolID = userData.WorkerPoolID
+	state.WorkerGroup = userData.WorkerGroup
+	state.WorkerID = doc.ID
+
+	state.WorkerLocation = map[string]string{
+		"cloud":              "oci",
+		"region":             doc.CanonicalRegion,
+		"availabilityDomain": doc.AvailabilityDomain,
+	}
+
+	providerMetadata := map[string]interface{}{
+		"instance-id":         doc.ID,
+		"image":               doc.Image,
+		"instance-type":       doc.Shape,
+		"compartment-id":      doc.CompartmentID,
+		"region":              doc.CanonicalRegion,
+		"availability-domain": doc.AvailabilityDomain,
+		"fault-domain":        doc.FaultDomain,
+		"public-hostname":     doc.DisplayName,
+	}
+
+	vnics, err := p.metadataService.queryMetadata("/vnics/")
+	if err == nil {
+		if ip := primaryPrivateIP(vnics); ip != "" {
+			providerMetadata["local-ipv4"] = ip
+		}
+	}
+
+	state.ProviderMetadata = providerMetadata
+
+	// the worker identity is proven with the instance principal certificate,
+	// by signing the instance document with its private key
+	certificate, err := p.metadataService.queryMetadata("/identity/cert.pem")
+	if err != nil {
+		return fmt.Errorf("could not query instance principal certificate: %v", err)
+	}
+	intermediate, err := p.metadataService.queryMetadata("/identity/intermediate.pem")
+	if err != nil {
+		return fmt.Errorf("could not query instance principal intermediate certificate: %v", err)
+	}
+	key, err := p.metadataService.queryMetadata("/identity/key.pem")
+	if err != nil {
+		return fmt.Errorf("could not query instance principal private key: %v", err)
+	}
+	signature, err := signDocument(key, docString)
+	if err != nil {
+		return fmt.Errorf("could not sign instance document: %v", err)
+	}
+
+	p.workerIdentityProof = map[string]interface{}{
+		"document":                interface{}(docString),
+		"signature":               interface{}(signature),
+		"certificate":             interface{}(certificate),
+		"intermediateCertificate": interface{}(intermediate),
+	}
+
+	return nil
+}
+
+// signDocument signs the given document with the PEM-encoded RSA private
+// key, returning the base64-encoded PKCS #1 v1.5 SHA-256 signature.
+func signDocument(keyPEM string, document string) (string, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return "", errors.New("no PEM data found in private key")
+	}
+	var key *rsa.PrivateKey
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = k
+	} else {
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+		var ok bool
+		if key, ok = k.(*rsa.PrivateKey); !ok {
+			return "", errors.New("private key is not an RSA key")
+		}
+	}
+	digest := sha256.Sum256([]byte(document))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func (p *OCIProvider) GetWorkerIdentityProof() (map[string]interface{}, error) {
+	return p.workerIdentityProof, nil
+}
+
+func (p *OCIProvider) UseCachedRun(run *run.State) error {
+	return nil
+}
+
+func (p *OCIProvider) SetProtocol(proto *workerproto.Protocol) {
+	p.proto = proto
+}
+
+// Preemptible instances are given a soft stop (ACPI shutdown) before they are
+// terminated, which arrives as SIGTERM from the init system.
+func (p *OCIProvider) HandleTerminationSignal() {
+	log.Println("OCI instance is shutting down")
+	if p.proto != nil && p.proto.Capable("graceful-termination") {
+		p.proto.Send(workerproto.Message{
+			Type: "graceful-termination",
+			Properties: map[string]interface{}{
+				// preemption only leaves a short grace period, which is not
+				// enough to finish tasks
+				"finish-tasks": false,
+			},
+		})
+	}
+}
+
+func (p *OCIProvider) WorkerStarted(state *run.State) error {
+	p.proto.AddCapability("graceful-termination")
+	return nil
+}
+
+func (p *OCIProvider) WorkerFinished(state *run.State) error {
+	return nil
+}
+
+func clientFactory(rootURL string, credentials *tcclient.Credentials) (tc.WorkerManager, error) {
+	prov := tcworkermanager.New(credentials, rootURL)
+	return prov, nil
+}
+
+func New(runnercfg *cfg.RunnerConfig) (provider.Provider, error) {
+	return new(runnercfg, nil, nil)
+}
+
+func Usage() string {
+	return `
+The providerType "oci" is intended for workers provisioned with worker-manager
+providers using providerType "oci", on Oracle Cloud Infrastructure.  It requires
+
+` + "```yaml" + `
+provider:
+    providerType: oci
+` + "```" + `
+
+Worker-manager does not yet have a provider of type "oci", so workers
+using this provider cannot yet be provisioned and registered end to end.
+
+The worker's configuration is read from the instance's base64-encoded
+` + "`user_data`" + ` metadata, and the worker registers using its instance principal
+certificate.  When a preemptible instance is stopped prior to termination, the
+worker is asked to terminate gracefully, without finishing its current tasks.
+
+The [$TASKCLUSTER_WORKER_LOCATION](https://docs.taskcluster.net/docs/manual/design/env-vars#taskcluster_worker_location)
+defined by this provider has the following fields:
+
+* cloud: oci
+* region
+* availabilityDomain
+`
+}
+
+// New takes its dependencies as optional arguments, allowing injection of fake dependencies for testing.
+func new(runnercfg *cfg.RunnerConfig, workerManagerClientFactory tc.WorkerManagerClientFactory, metadataService MetadataService) (*OCIProvider, error) {
+	if workerManagerClientFactory == nil {
+		workerManagerClientFactory = clientFactory
+	}
+	if metadataService == nil {
+		metadataService = &realMetadataService{}
+	}
+	return &OCIProvider{
+		runnercfg:                  runnercfg,
+		workerManagerClientFactory: workerManagerClientFactory,
+		metadataService:            metadataService,
+		proto:                      nil,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package oci
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+const instanceDocument = `{
+  "id": "ocid1.instance.oc1.eu-frankfurt-1.abc",
+  "displayName": "my-worker",
+  "compartmentId": "ocid1.compartment.oc1..def",
+  "image": "ocid1.image.oc1.eu-frankfurt-1.ghi",
+  "shape": "VM.Standard.E4.Flex",
+  "canonicalRegionName": "eu-frankfurt-1",
+  "availabilityDomain": "Uocm:EU-FRANKFURT-1-AD-1",
+  "faultDomain": "FAULT-DOMAIN-2"
+}`
+
+func fakeMetadata(t *testing.T) (*fakeMetadataService, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	userData := &UserData{
+		WorkerPoolID: "w/p",
+		ProviderID:   "oci1",
+		WorkerGroup:  "wg",
+		RootURL:      "https://tc.example.com",
+	}
+	metaData := map[string]string{
+		"/vnics/":                    `[{"vnicId": "v1", "privateIp": "10.0.0.2"}]`,
+		"/identity/cert.pem":         "CERT",
+		"/identity/intermediate.pem": "INTERMEDIATE",
+		"/identity/key.pem":          string(keyPEM),
+	}
+	return &fakeMetadataService{nil, userData, metaData, instanceDocument}, key
+}
+
+func TestOCIConfigureRun(t *testing.T) {
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "oci",
+		},
+		WorkerImplementation: cfg.WorkerImplementationConfig{
+			Implementation: "whatever-worker",
+		},
+	}
+
+	mds, key := fakeMetadata(t)
+	p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory, mds)
+	require.NoError(t, err, "creating provider")
+
+	state := run.State{}
+	err = p.ConfigureRun(&state)
+	require.NoError(t, err, "ConfigureRun")
+
+	require.Equal(t, "https://tc.example.com", state.RootURL, "rootURL is correct")
+	require.Equal(t, "oci1", state.ProviderID, "providerID is correct")
+	require.Equal(t, "w/p", state.WorkerPoolID, "workerPoolID is correct")
+	require.Equal(t, "wg", state.WorkerGroup, "workerGroup is correct")
+	require.Equal(t, "ocid1.instance.oc1.eu-frankfurt-1.abc", state.WorkerID, "workerID is correct")
+
+	require.Equal(t, map[string]interface{}{
+		"instance-id":         "ocid1.instance.oc1.eu-frankfurt-1.abc",
+		"image":               "ocid1.image.oc1.eu-frankfurt-1.ghi",
+		"instance-type":       "VM.Standard.E4.Flex",
+		"compartment-id":      "ocid1.compartment.oc1..def",
+		"region":              "eu-frankfurt-1",
+		"availability-domain": "Uocm:EU-FRANKFURT-1-AD-1",
+		"fault-domain":        "FAULT-DOMAIN-2",
+		"public-hostname":     "my-worker",
+		"local-ipv4":          "10.0.0.2",
+	}, state.ProviderMetadata, "providerMetadata is correct")
+
+	require.Equal(t, "oci", state.WorkerLocation["cloud"])
+	require.Equal(t, "eu-frankfurt-1", state.WorkerLocation["region"])
+	require.Equal(t, "Uocm:EU-FRANKFURT-1-AD-1", state.WorkerLocation["availabilityDomain"])
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Equal(t, instanceDocument, proof["document"])
+	require.Equal(t, "CERT", proof["certificate"])
+	require.Equal(t, "INTERMEDIATE", proof["intermediateCertificate"])
+
+	signature, err := base64.StdEncoding.DecodeString(proof["signature"].(string))
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(instanceDocument))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestSignDocumentPKCS8(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	signature, err := signDocument(string(keyPEM), "doc")
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("doc"))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], decoded))
+
+	_, err = signDocument("not a key", "doc")
+	require.Error(t, err)
+}
+
+func TestTerminationSignal(t *testing.T) {
+	test := func(t *testing.T, wkr *ptesting.FakeWorker) {
+		t.Helper()
+
+		p := &OCIProvider{}
+
+		p.SetProtocol(wkr.RunnerProtocol)
+		state := run.State{}
+		require.NoError(t, p.WorkerStarted(&state))
+		wkr.RunnerProtocol.Start(false)
+		wkr.RunnerProtocol.WaitUntilInitialized()
+
+		p.HandleTerminationSignal()
+		// give the worker time to receive the message
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Run("without capability", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities()
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+
+		test(t, wkr)
+
+		require.False(t, gotTerm())
+	})
+
+	t.Run("with capability", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
+		defer wkr.Close()
+
+		gotTerm := wkr.MessageReceivedFunc("graceful-termination", nil)
+
+		test(t, wkr)
+
+		require.True(t, gotTerm())
+	})
+}
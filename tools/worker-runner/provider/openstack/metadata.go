@@ -0,0 +1,100 @@
+package openstack
+
+// See https://docs.openstack.org/nova/latest/user/metadata.html
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+var metadataBaseURL = "http://169.254.169.254/openstack/latest"
+
+// user-data sent to us from the worker-manager service
+type UserData struct {
+	WorkerPoolID string `json:"workerPoolId"`
+	ProviderID   string `json:"providerId"`
+	WorkerGroup  string `json:"workerGroup"`
+	RootURL      string `json:"rootUrl"`
+
+	// OpenStack does not provide signed instance identity documents, so
+	// worker-manager generates a secret for each server it creates, and
+	// checks it when the worker registers
+	WorkerIdentityToken string `json:"workerIdentityToken"`
+
+	// NOTE: this is ignored, in preference to the configuration
+	// returned from registerWorker
+	ProviderWorkerConfig *json.RawMessage `json:"workerConfig"`
+}
+
+// The subset of meta_data.json that we use
+type InstanceMetadata struct {
+	UUID             string `json:"uuid"`
+	Name             string `json:"name"`
+	Hostname         string `json:"hostname"`
+	AvailabilityZone string `json:"availability_zone"`
+	ProjectID        string `json:"project_id"`
+}
+
+type MetadataService interface {
+	// Query the UserData and return the parsed contents
+	queryUserData() (*UserData, error)
+
+	// Query meta_data.json and return the parsed contents
+	queryInstanceMetadata() (*InstanceMetadata, error)
+}
+
+// realMetadataService reads from the metadata service, falling back to the
+// config drive mounted at configDrivePath if the metadata service cannot be
+// reached.
+type realMetadataService struct {
+	configDrivePath string
+}
+
+func (mds *realMetadataService) queryUserData() (*UserData, error) {
+	content, err := mds.query("/user_data")
+	if err != nil {
+		return nil, err
+	}
+	userData := &UserData{}
+	err = json.Unmarshal(content, userData)
+	return userData, err
+}
+
+func (mds *realMetadataService) queryInstanceMetadata() (*InstanceMetadata, error) {
+	content, err := mds.query("/meta_data.json")
+	if err != nil {
+		return nil, err
+	}
+	metadata := &InstanceMetadata{}
+	err = json.Unmarshal(content, metadata)
+	return metadata, err
+}
+
+func (mds *realMetadataService) query(path string) ([]byte, error) {
+	configDriveFile := filepath.Join(mds.configDrivePath, "openstack", "latest", filepath.FromSlash(path))
+	if _, err := os.Stat(configDriveFile); err == nil {
+		// only wait for the metadata service with backoff if there is no
+		// config drive to fall back to
+		client := http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get(metadataBaseURL + path)
+		if err == nil {
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return io.ReadAll(resp.Body)
+			}
+		}
+		return os.ReadFile(configDriveFile)
+	}
+	resp, _, err := httpbackoff.Get(metadataBaseURL + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,96 @@
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMetadataService struct {
+	UserDataError    error
+	UserData         *UserData
+	InstanceMetadata *InstanceMetadata
+}
+
+func (mds *fakeMetadataService) queryUserData() (*UserData, error) {
+	if mds.UserDataError != nil {
+		return nil, mds.UserDataError
+	}
+	return mds.UserData, nil
+}
+
+func (mds *fakeMetadataService) queryInstanceMetadata() (*InstanceMetadata, error) {
+	return mds.InstanceMetadata, nil
+}
+
+func TestQueryMetadataService(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/openstack/latest/meta_data.json":
+			w.WriteHeader(200)
+			fmt.Fprintln(w, `{"uuid": "83679162-1378-4288-a2d4-70e13ec132aa", "availability_zone": "nova"}`)
+		case "/openstack/latest/user_data":
+			w.WriteHeader(200)
+			fmt.Fprintln(w, `{"workerPoolId": "w/p", "workerIdentityToken": "sekrit"}`)
+		default:
+			w.WriteHeader(404)
+			fmt.Fprintf(w, "Not Found: %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/openstack/latest"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/openstack/latest"
+	}()
+
+	ms := realMetadataService{configDrivePath: t.TempDir()}
+
+	md, err := ms.queryInstanceMetadata()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "83679162-1378-4288-a2d4-70e13ec132aa", md.UUID)
+		assert.Equal(t, "nova", md.AvailabilityZone)
+	}
+
+	ud, err := ms.queryUserData()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "w/p", ud.WorkerPoolID)
+		assert.Equal(t, "sekrit", ud.WorkerIdentityToken)
+	}
+}
+
+func TestQueryConfigDriveFallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	metadataBaseURL = ts.URL + "/openstack/latest"
+	defer func() {
+		metadataBaseURL = "http://169.254.169.254/openstack/latest"
+	}()
+
+	configDrive := t.TempDir()
+	dir := filepath.Join(configDrive, "openstack", "latest")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "meta_data.json"), []byte(`{"uuid": "from-config-drive"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user_data"), []byte(`{"workerPoolId": "w/p"}`), 0644))
+
+	ms := realMetadataService{configDrivePath: configDrive}
+
+	md, err := ms.queryInstanceMetadata()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "from-config-drive", md.UUID)
+	}
+
+	ud, err := ms.queryUserData()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "w/p", ud.WorkerPoolID)
+	}
+}
@@ -0,0 +1,145 @@
+package openstack
+
+import (
+	"fmt"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/provider"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+const defaultConfigDrivePath = "/mnt/config"
+
+type OpenStackProvider struct {
+	runnercfg                  *cfg.RunnerConfig
+	workerManagerClientFactory tc.WorkerManagerClientFactory
+	metadataService            MetadataService
+	proto                      *workerproto.Protocol
+	workerIdentityProof        map[string]interface{}
+}
+
+func (p *OpenStackProvider) ConfigureRun(state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	userData, err := p.metadataService.queryUserData()
+	if err != nil {
+		return fmt.Errorf("could not query user data: %v", err)
+	}
+
+	metadata, err := p.metadataService.queryInstanceMetadata()
+	if err != nil {
+		return fmt.Errorf("could not query instance metadata: %v", err)
+	}
+
+	state.RootURL = userData.RootURL
+	state.ProviderID = userData.ProviderID
+	state.WorkerPoolID = userData.WorkerPoolID
+	state.WorkerGroup = userData.WorkerGroup
+	state.WorkerID = metadata.UUID
+
+	state.WorkerLocation = map[string]string{
+		"cloud":            "openstack",
+		"availabilityZone": metadata.AvailabilityZone,
+	}
+
+	state.ProviderMetadata = map[string]interface{}{
+		"instance-id":       metadata.UUID,
+		"instance-name":     metadata.Name,
+		"public-hostname":   metadata.Hostname,
+		"availability-zone": metadata.AvailabilityZone,
+		"project-id":        metadata.ProjectID,
+	}
+
+	p.workerIdentityProof = map[string]interface{}{
+		"instanceId": interface{}(metadata.UUID),
+		"token":      interface{}(userData.WorkerIdentityToken),
+	}
+
+	return nil
+}
+
+func (p *OpenStackProvider) GetWorkerIdentityProof() (map[string]interface{}, error) {
+	return p.workerIdentityProof, nil
+}
+
+func (p *OpenStackProvider) UseCachedRun(run *run.State) error {
+	return nil
+}
+
+func (p *OpenStackProvider) SetProtocol(proto *workerproto.Protocol) {
+	p.proto = proto
+}
+
+func (p *OpenStackProvider) WorkerStarted(state *run.State) error {
+	return nil
+}
+
+func (p *OpenStackProvider) WorkerFinished(state *run.State) error {
+	return nil
+}
+
+func clientFactory(rootURL string, credentials *tcclient.Credentials) (tc.WorkerManager, error) {
+	prov := tcworkermanager.New(credentials, rootURL)
+	return prov, nil
+}
+
+func New(runnercfg *cfg.RunnerConfig) (provider.Provider, error) {
+	return new(runnercfg, nil, nil)
+}
+
+func Usage() string {
+	return `
+The providerType "openstack" is intended for workers provisioned with worker-manager
+providers using providerType "openstack".  It requires
+
+` + "```yaml" + `
+provider:
+    providerType: openstack
+    # (optional) where the config drive is mounted, if the metadata service
+    # may be unavailable
+    configDrivePath: /mnt/config
+` + "```" + `
+
+Worker-manager does not yet have a provider of type "openstack", so workers
+using this provider cannot yet be provisioned and registered end to end.
+
+Worker-manager is expected to provide the worker's configuration as JSON user
+data, including a ` + "`workerIdentityToken`" + ` that is used, along with the
+instance UUID, to register the worker.  User data and instance metadata are
+read from the metadata service, or from the config drive if it is mounted and
+the metadata service cannot be reached.
+
+The [$TASKCLUSTER_WORKER_LOCATION](https://docs.taskcluster.net/docs/manual/design/env-vars#taskcluster_worker_location)
+defined by this provider has the following fields:
+
+* cloud: openstack
+* availabilityZone
+`
+}
+
+// New takes its dependencies as optional arguments, allowing injection of fake dependencies for testing.
+func new(runnercfg *cfg.RunnerConfig, workerManagerClientFactory tc.WorkerManagerClientFactory, metadataService MetadataService) (*OpenStackProvider, error) {
+	if workerManagerClientFactory == nil {
+		workerManagerClientFactory = clientFactory
+	}
+	if metadataService == nil {
+		configDrivePath := defaultConfigDrivePath
+		if val, ok := runnercfg.Provider.Data["configDrivePath"]; ok {
+			if configDrivePath, ok = val.(string); !ok {
+				return nil, fmt.Errorf("configuration value `provider.configDrivePath` should have type string")
+			}
+		}
+		metadataService = &realMetadataService{configDrivePath: configDrivePath}
+	}
+	return &OpenStackProvider{
+		runnercfg:                  runnercfg,
+		workerManagerClientFactory: workerManagerClientFactory,
+		metadataService:            metadataService,
+		proto:                      nil,
+	}, nil
+}
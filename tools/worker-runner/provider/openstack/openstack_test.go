@@ -0,0 +1,68 @@
+package openstack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+)
+
+func TestOpenStackConfigureRun(t *testing.T) {
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "openstack",
+		},
+		WorkerImplementation: cfg.WorkerImplementationConfig{
+			Implementation: "whatever-worker",
+		},
+	}
+
+	userData := &UserData{
+		WorkerPoolID:        "w/p",
+		ProviderID:          "os1",
+		WorkerGroup:         "wg",
+		RootURL:             "https://tc.example.com",
+		WorkerIdentityToken: "i-promise",
+	}
+	metadata := &InstanceMetadata{
+		UUID:             "83679162-1378-4288-a2d4-70e13ec132aa",
+		Name:             "worker-1",
+		Hostname:         "worker-1.novalocal",
+		AvailabilityZone: "nova",
+		ProjectID:        "f7ac731cc11f40efbc03a9f9e1d1d21f",
+	}
+	mds := &fakeMetadataService{nil, userData, metadata}
+
+	p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory, mds)
+	require.NoError(t, err, "creating provider")
+
+	state := run.State{}
+	err = p.ConfigureRun(&state)
+	require.NoError(t, err, "ConfigureRun")
+
+	require.Equal(t, "https://tc.example.com", state.RootURL, "rootURL is correct")
+	require.Equal(t, "os1", state.ProviderID, "providerID is correct")
+	require.Equal(t, "w/p", state.WorkerPoolID, "workerPoolID is correct")
+	require.Equal(t, "wg", state.WorkerGroup, "workerGroup is correct")
+	require.Equal(t, "83679162-1378-4288-a2d4-70e13ec132aa", state.WorkerID, "workerID is correct")
+
+	require.Equal(t, map[string]interface{}{
+		"instance-id":       "83679162-1378-4288-a2d4-70e13ec132aa",
+		"instance-name":     "worker-1",
+		"public-hostname":   "worker-1.novalocal",
+		"availability-zone": "nova",
+		"project-id":        "f7ac731cc11f40efbc03a9f9e1d1d21f",
+	}, state.ProviderMetadata, "providerMetadata is correct")
+
+	require.Equal(t, "openstack", state.WorkerLocation["cloud"])
+	require.Equal(t, "nova", state.WorkerLocation["availabilityZone"])
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"instanceId": "83679162-1378-4288-a2d4-70e13ec132aa",
+		"token":      "i-promise",
+	}, proof)
+}
@@ -8,7 +8,13 @@ import (
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/aws"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/azure"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/digitalocean"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/external"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/google"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/hetzner"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/kubernetes"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/oci"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/openstack"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/provider"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/standalone"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider/static"
@@ -20,11 +26,17 @@ type providerInfo struct {
 }
 
 var providers map[string]providerInfo = map[string]providerInfo{
-	"standalone": providerInfo{standalone.New, standalone.Usage},
-	"google":     providerInfo{google.New, google.Usage},
-	"static":     providerInfo{static.New, static.Usage},
-	"aws":        providerInfo{aws.New, aws.Usage},
-	"azure":      providerInfo{azure.New, azure.Usage},
+	"standalone":   providerInfo{standalone.New, standalone.Usage},
+	"google":       providerInfo{google.New, google.Usage},
+	"static":       providerInfo{static.New, static.Usage},
+	"aws":          providerInfo{aws.New, aws.Usage},
+	"azure":        providerInfo{azure.New, azure.Usage},
+	"hetzner":      providerInfo{hetzner.New, hetzner.Usage},
+	"oci":          providerInfo{oci.New, oci.Usage},
+	"digitalocean": providerInfo{digitalocean.New, digitalocean.Usage},
+	"kubernetes":   providerInfo{kubernetes.New, kubernetes.Usage},
+	"openstack":    providerInfo{openstack.New, openstack.Usage},
+	"external":     providerInfo{external.New, external.Usage},
 }
 
 func New(runnercfg *cfg.RunnerConfig) (provider.Provider, error) {
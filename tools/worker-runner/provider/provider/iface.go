@@ -7,6 +7,10 @@ import (
 
 // Provider is responsible for determining the identity of this worker and gathering
 // Takcluster credentials.
+//
+// A provider whose instances are told to shut down with SIGTERM may also
+// implement `HandleTerminationSignal()`, which the run loop calls when it
+// receives the signal; providers must not install signal handlers themselves.
 type Provider interface {
 	// Configure the given state.  This is expected to set the Taskcluster deployment
 	// and worker-information fields, but may modify any part of the state it desires.
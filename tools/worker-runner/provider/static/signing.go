@@ -0,0 +1,83 @@
+package static
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// A signer signs the message in a workerIdentityProof, returning the raw
+// signature
+type signer func(message []byte) ([]byte, error)
+
+// Parse a PEM-encoded private key in PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) form
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM-encoded private key found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("could not parse %s as a private key", block.Type)
+}
+
+// Return a signer using the private key in the given file.  RSA keys produce
+// PKCS #1 v1.5 signatures and ECDSA keys DER-encoded signatures, both over a
+// SHA-256 digest, as worker-manager expects.
+func keyFileSigner(path string) (signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read clientKey: %w", err)
+	}
+	key, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clientKey %s: %w", path, err)
+	}
+	return func(message []byte) ([]byte, error) {
+		if _, ok := key.(ed25519.PrivateKey); ok {
+			return key.Sign(rand.Reader, message, crypto.Hash(0))
+		}
+		digest := sha256.Sum256(message)
+		return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}, nil
+}
+
+// Return a signer that runs the given command with the message on stdin,
+// expecting the signature on stdout.  This allows the use of keys that
+// cannot be read from a file, such as those held in a TPM.
+func commandSigner(command []string) signer {
+	return func(message []byte) ([]byte, error) {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = bytes.NewReader(message)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		signature, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("signingCommand failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		if len(signature) == 0 {
+			return nil, errors.New("signingCommand produced no output")
+		}
+		return signature, nil
+	}
+}
@@ -1,8 +1,10 @@
 package static
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"time"
 
 	tcurls "github.com/taskcluster/taskcluster-lib-urls"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
@@ -28,6 +30,14 @@ type StaticProvider struct {
 	workerManagerClientFactory tc.WorkerManagerClientFactory
 	proto                      *workerproto.Protocol
 	workerIdentityProof        map[string]interface{}
+
+	// the worker's identity, which is signed along with a timestamp
+	workerPoolID string
+	workerGroup  string
+	workerID     string
+
+	// set if the worker has a client certificate (nil otherwise)
+	sign signer
 }
 
 func (p *StaticProvider) ConfigureRun(state *run.State) error {
@@ -70,12 +80,67 @@ func (p *StaticProvider) ConfigureRun(state *run.State) error {
 	p.workerIdentityProof = map[string]interface{}{
 		"staticSecret": interface{}(pc.StaticSecret),
 	}
+	p.workerPoolID = pc.WorkerPoolID
+	p.workerGroup = pc.WorkerGroup
+	p.workerID = pc.WorkerID
+
+	clientKey, hasClientKey := p.runnercfg.Provider.Data["clientKey"]
+	signingCommand, hasSigningCommand := p.runnercfg.Provider.Data["signingCommand"]
+	switch {
+	case hasClientKey && hasSigningCommand:
+		return errors.New("only one of clientKey and signingCommand may be given")
+	case hasClientKey:
+		path, ok := clientKey.(string)
+		if !ok {
+			return errors.New("clientKey must be a string")
+		}
+		p.sign, err = keyFileSigner(path)
+		if err != nil {
+			return err
+		}
+	case hasSigningCommand:
+		var command []string
+		if args, ok := signingCommand.([]interface{}); ok {
+			for _, arg := range args {
+				if s, ok := arg.(string); ok {
+					command = append(command, s)
+				}
+			}
+			if len(command) != len(args) {
+				command = nil
+			}
+		}
+		if len(command) == 0 {
+			return errors.New("signingCommand must be a non-empty list of strings")
+		}
+		p.sign = commandSigner(command)
+	}
 
 	return nil
 }
 
+// The proof is signed afresh each time, as worker-manager only accepts
+// recent timestamps.
 func (p *StaticProvider) GetWorkerIdentityProof() (map[string]interface{}, error) {
-	return p.workerIdentityProof, nil
+	if p.sign == nil {
+		return p.workerIdentityProof, nil
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	message := fmt.Sprintf("%s/%s/%s/%s", p.workerPoolID, p.workerGroup, p.workerID, timestamp)
+	signature, err := p.sign([]byte(message))
+	if err != nil {
+		return nil, fmt.Errorf("could not sign workerIdentityProof: %w", err)
+	}
+
+	proof := map[string]interface{}{
+		"timestamp": timestamp,
+		"signature": base64.StdEncoding.EncodeToString(signature),
+	}
+	for k, v := range p.workerIdentityProof {
+		proof[k] = v
+	}
+	return proof, nil
 }
 
 func (p *StaticProvider) UseCachedRun(run *run.State) error {
@@ -117,6 +182,12 @@ provider:
     workerGroup: ...
     workerID: ...
     staticSecret: ... # shared secret configured for this worker in worker-manager
+    # (optional) path of the PEM-encoded private key of the clientCertificate
+    # configured for this worker in worker-manager
+    clientKey: /etc/worker-runner/client-key.pem
+    # (optional) alternatively, a command that signs its stdin with that key,
+    # writing the signature to stdout
+    signingCommand: [openssl, dgst, -sha256, -sign, ...]
 	# (optional) custom provider-metadata entries to be passed to worker
 	providerMetadata: {prop: val, ..}
     # (optional) custom properties for TASKCLUSTER_WORKER_LOCATION
@@ -131,6 +202,20 @@ defined by this provider has the following fields:
 
 as well as any worker location values from the configuration.
 
+If the worker was created with a ` + "`clientCertificate`" + `, the workerIdentityProof
+must also be signed with the certificate's private key, so that the static
+secret alone is not sufficient to register the worker.  Either ` + "`clientKey`" + ` or
+` + "`signingCommand`" + ` must then be given.  The signing command is useful for keys
+that cannot be read from a file, such as those held in a TPM; for example,
+with the [tpm2 OpenSSL provider](https://github.com/tpm2-software/tpm2-openssl):
+
+` + "```yaml" + `
+signingCommand: [openssl, dgst, -sha256, -sign, "handle:0x81000001", -provider, tpm2, -provider, default]
+` + "```" + `
+
+The command must produce signatures in the same form as ` + "`openssl dgst -sha256 -sign`" + `
+(PKCS #1 v1.5 for RSA keys, DER-encoded for ECDSA keys).
+
 NOTE: do not use the 'cacheOverRestarts' configuration with the static
 provider.  The static provider can re-initialize itself "from scratch" on every
 startup, and does not need to cache anything.
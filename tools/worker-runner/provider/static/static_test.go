@@ -1,7 +1,22 @@
 package static
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
@@ -69,3 +84,130 @@ func TestUseCachedRun(t *testing.T) {
 	err = p.UseCachedRun(&run.State{})
 	require.Error(t, err)
 }
+
+func signedProof(t *testing.T, data map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	runnercfg := &cfg.RunnerConfig{
+		Provider: cfg.ProviderConfig{
+			ProviderType: "static",
+			Data: map[string]interface{}{
+				"rootURL":      "https://tc.example.com",
+				"providerID":   "static-1",
+				"workerPoolID": "w/p",
+				"workerGroup":  "wg",
+				"workerID":     "wi",
+				"staticSecret": "quiet",
+			},
+		},
+	}
+	for k, v := range data {
+		runnercfg.Provider.Data[k] = v
+	}
+
+	p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory)
+	require.NoError(t, err, "creating provider")
+	require.NoError(t, p.ConfigureRun(&run.State{}))
+
+	proof, err := p.GetWorkerIdentityProof()
+	require.NoError(t, err)
+	require.Equal(t, "quiet", proof["staticSecret"])
+
+	timestamp, err := time.Parse(time.RFC3339, proof["timestamp"].(string))
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), timestamp, time.Minute)
+	return proof
+}
+
+func TestClientKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pkcs8 := func(key interface{}) *pem.Block {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name   string
+		block  *pem.Block
+		verify func(digest, message, signature []byte) bool
+	}{
+		{"ecdsa-pkcs8", pkcs8(ecKey), func(digest, _, sig []byte) bool {
+			return ecdsa.VerifyASN1(&ecKey.PublicKey, digest, sig)
+		}},
+		{"ecdsa-sec1", &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}, func(digest, _, sig []byte) bool {
+			return ecdsa.VerifyASN1(&ecKey.PublicKey, digest, sig)
+		}},
+		{"rsa-pkcs1", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}, func(digest, _, sig []byte) bool {
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest, sig) == nil
+		}},
+		{"ed25519", pkcs8(edKey), func(_, message, sig []byte) bool {
+			return ed25519.Verify(edKey.Public().(ed25519.PublicKey), message, sig)
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key.pem")
+			require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(test.block), 0600))
+
+			proof := signedProof(t, map[string]interface{}{"clientKey": path})
+
+			message := []byte("w/p/wg/wi/" + proof["timestamp"].(string))
+			digest := sha256.Sum256(message)
+			signature, err := base64.StdEncoding.DecodeString(proof["signature"].(string))
+			require.NoError(t, err)
+			require.True(t, test.verify(digest[:], message, signature), "signature is valid")
+		})
+	}
+}
+
+func TestSigningCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell command")
+	}
+	// a "signature" that is just the message, upper-cased by tr to show that
+	// the output of the command is used
+	proof := signedProof(t, map[string]interface{}{
+		"signingCommand": []interface{}{"sh", "-c", "tr a-z A-Z"},
+	})
+	signature, err := base64.StdEncoding.DecodeString(proof["signature"].(string))
+	require.NoError(t, err)
+	require.Equal(t, strings.ToUpper("w/p/wg/wi/"+proof["timestamp"].(string)), string(signature))
+}
+
+func TestSigningConfigErrors(t *testing.T) {
+	for name, data := range map[string]map[string]interface{}{
+		"both":            {"clientKey": "key.pem", "signingCommand": []interface{}{"sign"}},
+		"missing key":     {"clientKey": filepath.Join(t.TempDir(), "nosuch.pem")},
+		"empty command":   {"signingCommand": []interface{}{}},
+		"non-string args": {"signingCommand": []interface{}{"sign", 1}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			runnercfg := &cfg.RunnerConfig{
+				Provider: cfg.ProviderConfig{
+					ProviderType: "static",
+					Data: map[string]interface{}{
+						"rootURL":      "https://tc.example.com",
+						"providerID":   "static-1",
+						"workerPoolID": "w/p",
+						"workerGroup":  "wg",
+						"workerID":     "wi",
+						"staticSecret": "quiet",
+					},
+				},
+			}
+			for k, v := range data {
+				runnercfg.Provider.Data[k] = v
+			}
+			p, err := new(runnercfg, tc.FakeWorkerManagerClientFactory)
+			require.NoError(t, err, "creating provider")
+			require.Error(t, p.ConfigureRun(&run.State{}))
+		})
+	}
+}
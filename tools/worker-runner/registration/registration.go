@@ -12,12 +12,17 @@ import (
 	taskcluster "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcworkermanager"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/diagnostics"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/util"
 	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
 )
 
+// the time at which worker-runner started, approximated by package
+// initialization
+var processStarted = time.Now()
+
 type RegistrationManager struct {
 	runnercfg *cfg.RunnerConfig
 	state     *run.State
@@ -60,6 +65,7 @@ func (reg *RegistrationManager) RegisterWorker(workerIdentityProofMap map[string
 		WorkerGroup:         reg.state.WorkerGroup,
 		WorkerID:            reg.state.WorkerID,
 		WorkerIdentityProof: json.RawMessage(workerIdentityProof),
+		StartupTimings:      startupTimings(),
 	})
 	if err != nil {
 		return fmt.Errorf("could not register worker: %w", err)
@@ -80,11 +86,25 @@ func (reg *RegistrationManager) RegisterWorker(workerIdentityProofMap map[string
 
 		reg.state.WorkerConfig = reg.state.WorkerConfig.Merge(pwc.Config)
 		reg.state.Files = append(reg.state.Files, pwc.Files...)
+		reg.state.RunnerOptions = reg.state.RunnerOptions.Merge(pwc.RunnerOptions)
 	}
 
 	return nil
 }
 
+// Calculate the startup timings to report to worker-manager.  The boot time
+// is omitted if the host's uptime cannot be determined.
+func startupTimings() tcworkermanager.StartupTimings {
+	configure := time.Since(processStarted)
+	timings := tcworkermanager.StartupTimings{
+		ConfigureSecs: configure.Seconds(),
+	}
+	if up, ok := diagnostics.Uptime(); ok && up > configure {
+		timings.BootSecs = (up - configure).Seconds()
+	}
+	return timings
+}
+
 func (reg *RegistrationManager) UseCachedRun() error {
 	expire := time.Time(reg.state.CredentialsExpire)
 	if expire.IsZero() {
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/diagnostics"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
 	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
@@ -48,6 +49,7 @@ func TestRegisterWorker(t *testing.T) {
 
 	require.Equal(t, true, state.WorkerConfig.MustGet("from-register-worker"), "value for from-register-worker")
 	require.Equal(t, "a file.", state.Files[0].Description)
+	require.Equal(t, true, state.RunnerOptions.MustGet("from-register-worker"), "runner option from-register-worker")
 
 	call, err := tc.FakeWorkerManagerRegistration()
 	require.NoError(t, err)
@@ -56,6 +58,10 @@ func TestRegisterWorker(t *testing.T) {
 	require.Equal(t, "wg", call.WorkerGroup)
 	require.Equal(t, "wid", call.WorkerID)
 	require.Equal(t, json.RawMessage([]byte(`{"because":"I said so"}`)), call.WorkerIdentityProof)
+	require.Greater(t, call.StartupTimings.ConfigureSecs, 0.0)
+	if _, ok := diagnostics.Uptime(); ok {
+		require.Greater(t, call.StartupTimings.BootSecs, 0.0)
+	}
 }
 
 func TestCredsExpirationGraceful(t *testing.T) {
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	taskcluster "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
@@ -58,8 +59,16 @@ type State struct {
 	WorkerConfig *cfg.WorkerConfig
 	Files        []files.File
 
+	// options for worker-runner itself, supplied by the provider for this
+	// worker pool
+	RunnerOptions *cfg.WorkerConfig
+
 	// The worker location configuration
 	WorkerLocation map[string]string
+
+	// Times at which the worker crashed recently, kept across restarts to
+	// detect crash loops
+	WorkerCrashes []time.Time `yaml:",omitempty"`
 }
 
 // Check that the provided provided the information it was supposed to.
@@ -5,15 +5,22 @@ import (
 	"log"
 
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/configtemplate"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/crashloop"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/errorreport"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/exit"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/files"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/integrity"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
 	loggingProtocol "github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging/protocol"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/metadataservice"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/network"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/provider"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/registration"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/secretrefs"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/secrets"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/systemd"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/worker"
 	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
 )
@@ -29,6 +36,14 @@ func Run(configFile string) (state run.State, err error) {
 		return
 	}
 
+	// apply network settings before making any HTTP requests, including
+	// those of remote log destinations
+
+	nw, err := network.Configure(runnercfg)
+	if err != nil {
+		return
+	}
+
 	logging.Configure(runnercfg)
 
 	runCached := false
@@ -51,8 +66,12 @@ func Run(configFile string) (state run.State, err error) {
 	}
 
 	reg := registration.New(runnercfg, &state)
+	um := secrets.NewUpdateManager(runnercfg, &state)
 	er := errorreport.New(&state)
 	em := exit.New(runnercfg, &state)
+	sd := systemd.New()
+	cl := crashloop.New(runnercfg, &state)
+	ms := metadataservice.New(runnercfg, &state)
 
 	if !runCached {
 		log.Printf("Configuring with provider %s", runnercfg.Provider.ProviderType)
@@ -89,6 +108,15 @@ func Run(configFile string) (state run.State, err error) {
 		return
 	}
 
+	// switch to the log destination selected by the worker pool, if any
+
+	state.Lock()
+	err = logging.ConfigureFromRunnerOptions(runnercfg, state.RunnerOptions)
+	state.Unlock()
+	if err != nil {
+		return
+	}
+
 	// log the worker identity; this is useful for finding the worker in logfiles
 	state.Lock()
 	log.Printf("Identified as worker %s/%s", state.WorkerGroup, state.WorkerID)
@@ -104,6 +132,24 @@ func Run(configFile string) (state run.State, err error) {
 		}
 	}
 
+	// render templated worker configuration, if enabled
+
+	if !runCached {
+		err = configtemplate.ConfigureRun(&state)
+		if err != nil {
+			return
+		}
+	}
+
+	// resolve references to secrets in external secret stores
+
+	if !runCached {
+		err = secretrefs.ConfigureRun(runnercfg, &state)
+		if err != nil {
+			return
+		}
+	}
+
 	// initialize worker
 
 	worker, err := worker.New(runnercfg)
@@ -145,6 +191,15 @@ func Run(configFile string) (state run.State, err error) {
 		}
 	}
 
+	// verify the image before starting the worker, so that a worker on a
+	// tampered image never claims a task
+
+	err = integrity.New(&state).Check()
+	if err != nil {
+		_ = em.WorkerFinished()
+		return
+	}
+
 	// start
 
 	log.Printf("Starting worker")
@@ -155,7 +210,7 @@ func Run(configFile string) (state run.State, err error) {
 
 	// set up protocol
 
-	proto := workerproto.NewProtocol(transp)
+	proto := workerproto.NewProtocol(ms.WrapTransport(transp))
 
 	// inform other components about the protocol
 	loggingProtocol.SetProtocol(proto)
@@ -164,6 +219,9 @@ func Run(configFile string) (state run.State, err error) {
 	reg.SetProtocol(proto)
 	er.SetProtocol(proto)
 	em.SetProtocol(proto)
+	um.SetProtocol(proto)
+	sd.SetProtocol(proto)
+	nw.SetProtocol(proto)
 
 	// call the WorkerStarted methods before starting the proto so that there
 	// are no race conditions around the capabilities negotiation
@@ -177,6 +235,35 @@ func Run(configFile string) (state run.State, err error) {
 		return
 	}
 
+	err = um.WorkerStarted()
+	if err != nil {
+		return
+	}
+
+	err = sd.WorkerStarted()
+	if err != nil {
+		return
+	}
+
+	err = nw.WorkerStarted()
+	if err != nil {
+		return
+	}
+
+	err = ms.WorkerStarted()
+	if err != nil {
+		return
+	}
+
+	// listen for SIGTERM on behalf of the components that handle it
+	terminationSignalHandlers := []interface{}{provider}
+	if sd.Enabled() {
+		// systemd stops the unit by sending SIGTERM
+		terminationSignalHandlers = append(terminationSignalHandlers, sd)
+	}
+	stopTerminationSignals := handleTerminationSignals(terminationSignalHandlers...)
+	defer stopTerminationSignals()
+
 	proto.Start(false)
 
 	// wait for the worker to terminate, first reading everything from the
@@ -184,6 +271,11 @@ func Run(configFile string) (state run.State, err error) {
 	proto.WaitForEOF()
 	err = worker.Wait()
 	if err != nil {
+		if cl.WorkerExited(err) {
+			// rather than restarting a worker that will only crash again,
+			// remove it
+			_ = em.WorkerFinished()
+		}
 		return
 	}
 
@@ -200,10 +292,25 @@ func Run(configFile string) (state run.State, err error) {
 		return
 	}
 
+	err = um.WorkerFinished()
+	if err != nil {
+		return
+	}
+
+	err = sd.WorkerFinished()
+	if err != nil {
+		return
+	}
+
 	err = em.WorkerFinished()
 	if err != nil {
 		return
 	}
 
+	err = ms.WorkerFinished()
+	if err != nil {
+		return
+	}
+
 	return
 }
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// terminationSignalHandler is implemented by components that need to know
+// when worker-runner receives SIGTERM, such as providers whose cloud asks the
+// instance to shut down before deleting it.  Signal handlers are process-wide,
+// so the run loop installs one and calls each component from it.
+type terminationSignalHandler interface {
+	HandleTerminationSignal()
+}
+
+// Call HandleTerminationSignal of each of the given components that implement
+// it whenever SIGTERM is received, until the returned function is called.
+func handleTerminationSignals(components ...interface{}) (stop func()) {
+	var handlers []terminationSignalHandler
+	for _, c := range components {
+		if h, ok := c.(terminationSignalHandler); ok {
+			handlers = append(handlers, h)
+		}
+	}
+	if len(handlers) == 0 {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	go func() {
+		for range signals {
+			for _, h := range handlers {
+				h.HandleTerminationSignal()
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(signals)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package runner
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTerminationSignalHandler struct {
+	signals chan struct{}
+}
+
+func (h *fakeTerminationSignalHandler) HandleTerminationSignal() {
+	h.signals <- struct{}{}
+}
+
+func TestHandleTerminationSignals(t *testing.T) {
+	h1 := &fakeTerminationSignalHandler{signals: make(chan struct{}, 1)}
+	h2 := &fakeTerminationSignalHandler{signals: make(chan struct{}, 1)}
+	stop := handleTerminationSignals(h1, "not a handler", h2)
+	defer stop()
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	for _, h := range []*fakeTerminationSignalHandler{h1, h2} {
+		select {
+		case <-h.signals:
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler was not called for SIGTERM")
+		}
+	}
+}
+
+func TestHandleTerminationSignalsNoHandlers(t *testing.T) {
+	stop := handleTerminationSignals("not a handler")
+	stop()
+}
@@ -33,6 +33,47 @@ the following fields:
   secrets service and merged with the worker configuration.  This option is
   generally only used in testing.
 
+* |secretBackends|: configuration for the external secret stores that worker
+  configuration values may refer to; see "Secret References" in the worker
+  configuration documentation.
+
+  * |awsSecretsManager|: AWS Secrets Manager, authenticating with the
+    instance's IAM role.
+
+    * |region|: the region containing the secrets; defaults to the instance's
+      region.
+
+  * |vault|: HashiCorp Vault.
+
+    * |address|: (required) the address of the Vault server.
+    * |namespace|: the Vault namespace, if any.
+    * |authMethod|: (required) how to log in to Vault: |aws| (with the
+      instance's IAM role), |gcp| (with the instance's service account), or
+      |token| (with a token read from a file, such as one written by Vault
+      Agent).
+    * |authMount|: the path at which the auth method is mounted; defaults to
+      the name of the auth method.
+    * |role|: the Vault role to log in as, for the |aws| and |gcp| methods.
+    * |tokenPath|: the file containing the token, for the |token| method.
+
+* |multipleWorkers|: configuration for running several workers on the same
+  host, such as one per GPU.  Each worker has a |workerId| derived from that
+  of the host by appending |-<index>|, counting from zero, and they share
+  worker-runner's registration with worker-manager.  This cannot be combined
+  with |cacheOverRestarts|.  Note that the worker pool's |worker-id:*| role
+  must grant each worker's scopes, for example
+  |queue:worker-id:<..>-*|.
+
+  * |count|: the number of workers to run.
+  * |workerPaths|: properties of the |worker| section, such as |configPath|,
+    that are paths which must differ between workers; the worker index is
+    appended to each, before any extension.
+  * |workerConfigPaths|: properties of the worker configuration, such as
+    |tasksDir| and |cachesDir|, that are paths which must differ between
+    workers, treated in the same way.
+  * |workerConfigs|: a list of worker configurations, the first merged into the
+    configuration of the first worker, and so on.
+
 * |cacheOverRestarts|: if set to a filename, then the runner state is written
   to this JSON file at startup.  On subsequent startups, if the file exists,
   then it is loaded and the worker started directly without consulting
@@ -40,6 +81,51 @@ the following fields:
   implementations that restart the system as part of their normal operation
   and expect to start up with the same config after a restart.
 
+* |crashLoopThreshold|: the number of times the worker may crash (exit with
+  a code other than 0 or 64 and above) within an hour before worker-runner
+  considers it to be in a crash loop; default 3.  Each crash is reported to
+  worker-manager as a |worker-crash| error, with diagnostics: the exit code,
+  the most recent log lines, the worker's panic trace, if any, and
+  information about the host such as its memory and load.  After each crash,
+  worker-runner waits before exiting, starting at 10 seconds and doubling
+  with each crash, so that restarts (for example by systemd) back off.  Once
+  the threshold is reached, worker-runner reports the crash loop, with the
+  same diagnostics, as a |worker-crash-loop| error instead, and removes the
+  worker.  Crashes are only counted across restarts of worker-runner when
+  |cacheOverRestarts| is set.
+
+* |network|: configuration for networks that require an HTTP proxy or
+  additional trusted CA certificates.  These settings apply to worker-runner
+  and, by the |network-config| message, to the worker and the processes it
+  starts, such as taskcluster-proxy.  They are also set as the standard proxy
+  environment variables for the worker.
+
+  * |httpProxy|: URL of the proxy for HTTP requests.
+  * |httpsProxy|: URL of the proxy for HTTPS requests.
+  * |noProxy|: comma-separated hosts, domains and CIDR ranges to connect to
+    directly, in the format of the |NO_PROXY| environment variable.  The
+    cloud metadata services (|169.254.169.254| and
+    |metadata.google.internal|) are always added when a proxy is configured.
+  * |caCertificateFiles|: files containing PEM-encoded CA certificates to trust
+    in addition to the system's, such as that of a TLS-intercepting proxy.
+
+* |metadataService|: configuration for a local HTTP service providing
+  information about the worker to tasks, so that they can label their output
+  without querying cloud-specific metadata services.  A |GET| request to |/|
+  returns a JSON object with |rootUrl|, |providerId|, |workerPoolId|,
+  |workerGroup|, |workerId|, |region|, |instanceType|, |workerLocation| (as in
+  |TASKCLUSTER_WORKER_LOCATION|), and |terminationNotice|.  The last is |null|
+  until worker-runner asks the worker to stop, such as on a spot termination
+  notice, when it has properties |time| and |finishTasks| (whether the worker
+  was asked to finish its current tasks first).  With |multipleWorkers|, the
+  identity is that of the host.  The service is disabled unless this property
+  is given.
+
+  * |address|: the address to listen on; default |127.0.0.1:8125|.  To make
+    the service available to tasks in containers, use an address they can
+    reach, such as that of a bridge interface or a link-local address
+    assigned to the host.
+
 **NOTE** for Windows users: the configuration file must be a UNIX-style text file.
 DOS-style newlines and encodings other than utf-8 are not supported.`, "|", "`")
 }
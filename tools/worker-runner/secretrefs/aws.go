@@ -0,0 +1,75 @@
+package secretrefs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+)
+
+// URL of the Secrets Manager API in the given region (overridden in tests)
+var awsSecretsManagerURL = func(region string) string {
+	return "https://secretsmanager." + region + ".amazonaws.com/"
+}
+
+// awsBackend gets secrets from AWS Secrets Manager, authenticating with the
+// instance's IAM role.  Secret names are secret names or ARNs.
+type awsBackend struct {
+	region string
+	creds  *cloudauth.AWSCredentials
+}
+
+func newAWSBackend(config *cfg.SecretBackendsConfig) (backend, error) {
+	b := &awsBackend{}
+	if config.AWS != nil {
+		b.region = config.AWS.Region
+	}
+	var err error
+	if b.region == "" {
+		b.region, err = cloudauth.AWSInstanceRegion()
+		if err != nil {
+			return nil, err
+		}
+	}
+	b.creds, err = cloudauth.AWSInstanceCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *awsBackend) getSecret(name string) (string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", awsSecretsManagerURL(b.region), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	cloudauth.SignAWSRequest(req, body, b.creds, b.region, "secretsmanager", time.Now())
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	err = doJSON(req, &result)
+	if err != nil {
+		return "", err
+	}
+	if result.SecretString == "" && result.SecretBinary != "" {
+		binary, err := base64.StdEncoding.DecodeString(result.SecretBinary)
+		if err != nil {
+			return "", fmt.Errorf("could not decode binary secret: %w", err)
+		}
+		return string(binary), nil
+	}
+	return result.SecretString, nil
+}
@@ -0,0 +1,94 @@
+package secretrefs
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+)
+
+// Serve a fake EC2 metadata service and Secrets Manager API for the duration
+// of the test
+func setupFakeAWS(t *testing.T) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/meta-data/placement/region", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("us-west-2"))
+	})
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("worker-role\n"))
+	})
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/worker-role", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"AccessKeyId": "AKID", "SecretAccessKey": "SECRET", "Token": "TOKEN"}`))
+	})
+	mux.HandleFunc("/secretsmanager/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			r.Header.Get("X-Amz-Security-Token") != "TOKEN" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			w.WriteHeader(400)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var input struct {
+			SecretId string
+		}
+		_ = json.Unmarshal(body, &input)
+		switch input.SecretId {
+		case "string-secret":
+			_, _ = w.Write([]byte(`{"SecretString": "sekrit"}`))
+		case "binary-secret":
+			_, _ = w.Write([]byte(`{"SecretBinary": "YmluYXJ5"}`))
+		default:
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException"}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+
+	oldMetadataBaseURL := cloudauth.AWSMetadataBaseURL
+	oldSecretsManagerURL := awsSecretsManagerURL
+	cloudauth.AWSMetadataBaseURL = server.URL + "/latest"
+	awsSecretsManagerURL = func(region string) string {
+		return server.URL + "/secretsmanager/" + region
+	}
+	t.Cleanup(func() {
+		cloudauth.AWSMetadataBaseURL = oldMetadataBaseURL
+		awsSecretsManagerURL = oldSecretsManagerURL
+		server.Close()
+	})
+}
+
+func TestAWSBackend(t *testing.T) {
+	setupFakeAWS(t)
+
+	b, err := newAWSBackend(&cfg.SecretBackendsConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "us-west-2", b.(*awsBackend).region)
+
+	secret, err := b.getSecret("string-secret")
+	require.NoError(t, err)
+	require.Equal(t, "sekrit", secret)
+
+	secret, err = b.getSecret("binary-secret")
+	require.NoError(t, err)
+	require.Equal(t, "binary", secret)
+
+	_, err = b.getSecret("missing")
+	require.ErrorContains(t, err, "ResourceNotFoundException")
+}
+
+func TestAWSBackendRegion(t *testing.T) {
+	setupFakeAWS(t)
+
+	b, err := newAWSBackend(&cfg.SecretBackendsConfig{
+		AWS: &cfg.AWSSecretsManagerConfig{Region: "eu-central-1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "eu-central-1", b.(*awsBackend).region)
+}
@@ -0,0 +1,56 @@
+package secretrefs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+)
+
+// Base URL of the Secret Manager API (overridden in tests)
+var gcpSecretManagerBaseURL = "https://secretmanager.googleapis.com/v1"
+
+// gcpBackend gets secrets from GCP Secret Manager, authenticating as the
+// instance's service account.  Secret names are resource names of the form
+// `projects/<project>/secrets/<secret>[/versions/<version>]`; the latest
+// version is used if none is given.
+type gcpBackend struct {
+	accessToken string
+}
+
+func newGCPBackend(config *cfg.SecretBackendsConfig) (backend, error) {
+	accessToken, _, err := cloudauth.GCPAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	return &gcpBackend{accessToken: accessToken}, nil
+}
+
+func (b *gcpBackend) getSecret(name string) (string, error) {
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	req, err := http.NewRequest("GET", gcpSecretManagerBaseURL+"/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.accessToken)
+
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	err = doJSON(req, &result)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("could not decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,75 @@
+package secretrefs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+)
+
+// Serve a fake GCE metadata service and Secret Manager API for the duration
+// of the test
+func setupFakeGCP(t *testing.T) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(403)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "ACCESS", "expires_in": 3600, "token_type": "Bearer"}`))
+	})
+	mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/identity", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(403)
+			return
+		}
+		_, _ = w.Write([]byte("jwt-for-" + r.URL.Query().Get("audience")))
+	})
+	mux.HandleFunc("/v1/projects/proj/secrets/sec/versions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ACCESS" {
+			w.WriteHeader(403)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/projects/proj/secrets/sec/versions/latest:access":
+			_, _ = w.Write([]byte(`{"payload": {"data": "bGF0ZXN0"}}`))
+		case "/v1/projects/proj/secrets/sec/versions/3:access":
+			_, _ = w.Write([]byte(`{"payload": {"data": "dmVyc2lvbiAz"}}`))
+		default:
+			w.WriteHeader(404)
+		}
+	})
+	server := httptest.NewServer(mux)
+
+	oldMetadataBaseURL := cloudauth.GCPMetadataBaseURL
+	oldSecretManagerBaseURL := gcpSecretManagerBaseURL
+	cloudauth.GCPMetadataBaseURL = server.URL + "/computeMetadata/v1"
+	gcpSecretManagerBaseURL = server.URL + "/v1"
+	t.Cleanup(func() {
+		cloudauth.GCPMetadataBaseURL = oldMetadataBaseURL
+		gcpSecretManagerBaseURL = oldSecretManagerBaseURL
+		server.Close()
+	})
+}
+
+func TestGCPBackend(t *testing.T) {
+	setupFakeGCP(t)
+
+	b, err := newGCPBackend(&cfg.SecretBackendsConfig{})
+	require.NoError(t, err)
+
+	secret, err := b.getSecret("projects/proj/secrets/sec")
+	require.NoError(t, err)
+	require.Equal(t, "latest", secret)
+
+	secret, err = b.getSecret("projects/proj/secrets/sec/versions/3")
+	require.NoError(t, err)
+	require.Equal(t, "version 3", secret)
+
+	_, err = b.getSecret("projects/proj/secrets/sec/versions/4")
+	require.ErrorContains(t, err, "404 Not Found")
+}
@@ -0,0 +1,170 @@
+// Package secretrefs resolves references to secrets held in external secret
+// stores, such as AWS Secrets Manager, GCP Secret Manager, or HashiCorp Vault,
+// that appear as string values in the worker configuration.  This allows
+// sensitive values such as access tokens to be kept out of instance user data
+// and runner configuration files.
+package secretrefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+)
+
+// A backend fetches secrets from a secret store.
+type backend interface {
+	// Get the value of the named secret.
+	getSecret(name string) (string, error)
+}
+
+// Constructors for the supported backends, keyed by the scheme used in
+// references to their secrets.
+var backends = map[string]func(*cfg.SecretBackendsConfig) (backend, error){
+	"aws-secretsmanager": newAWSBackend,
+	"gcp-secretmanager":  newGCPBackend,
+	"vault":              newVaultBackend,
+}
+
+// Resolve any secret references in the worker configuration, replacing them
+// with the secret values.
+func ConfigureRun(runnercfg *cfg.RunnerConfig, state *run.State) error {
+	state.Lock()
+	defer state.Unlock()
+
+	r := newResolver(runnercfg.SecretBackends)
+	workerConfig, err := state.WorkerConfig.ReplaceStrings(r.resolve)
+	if err != nil {
+		return err
+	}
+	state.WorkerConfig = workerConfig
+
+	if r.resolved > 0 {
+		log.Printf("Resolved %d secret references in worker configuration", r.resolved)
+	}
+	return nil
+}
+
+type resolver struct {
+	config *cfg.SecretBackendsConfig
+
+	// backends that have been used so far, by scheme; each is created (and
+	// authenticates) the first time one of its secrets is referenced
+	backends map[string]backend
+
+	// secret values fetched so far, by scheme and name, so that each secret is
+	// fetched only once regardless of how many times it is referenced
+	cache map[string]string
+
+	// number of references resolved
+	resolved int
+}
+
+func newResolver(config *cfg.SecretBackendsConfig) *resolver {
+	if config == nil {
+		config = &cfg.SecretBackendsConfig{}
+	}
+	return &resolver{
+		config:   config,
+		backends: make(map[string]backend),
+		cache:    make(map[string]string),
+	}
+}
+
+// Resolve a single configuration value.  Values of the form
+// `<scheme>://<name>[#<key>]`, where scheme names a supported backend, are
+// replaced with the named secret or, if a key is given, with that property of
+// the secret (which must then be a JSON object).  Other values are returned
+// unchanged.
+func (r *resolver) resolve(value string) (string, error) {
+	scheme, ref, found := strings.Cut(value, "://")
+	if !found {
+		return value, nil
+	}
+	if _, ok := backends[scheme]; !ok {
+		return value, nil
+	}
+	name, key, _ := strings.Cut(ref, "#")
+	if name == "" {
+		return "", fmt.Errorf("secret reference %s does not name a secret", value)
+	}
+
+	// note that error messages must not include the secret value
+	secret, err := r.get(scheme, name)
+	if err != nil {
+		return "", fmt.Errorf("could not get secret %s://%s: %w", scheme, name, err)
+	}
+	r.resolved++
+	if key == "" {
+		return secret, nil
+	}
+
+	var properties map[string]interface{}
+	err = json.Unmarshal([]byte(secret), &properties)
+	if err != nil {
+		return "", fmt.Errorf("secret %s://%s is not a JSON object, so property %s cannot be selected", scheme, name, key)
+	}
+	property, ok := properties[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s://%s has no property %s", scheme, name, key)
+	}
+	str, ok := property.(string)
+	if !ok {
+		return "", fmt.Errorf("property %s of secret %s://%s is not a string", key, scheme, name)
+	}
+	return str, nil
+}
+
+func (r *resolver) get(scheme, name string) (string, error) {
+	cacheKey := scheme + "://" + name
+	if secret, ok := r.cache[cacheKey]; ok {
+		return secret, nil
+	}
+
+	b, ok := r.backends[scheme]
+	if !ok {
+		var err error
+		b, err = backends[scheme](r.config)
+		if err != nil {
+			return "", err
+		}
+		r.backends[scheme] = b
+	}
+
+	secret, err := b.getSecret(name)
+	if err != nil {
+		return "", err
+	}
+	r.cache[cacheKey] = secret
+	return secret, nil
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Make an HTTP request, failing for anything but a 200 response, and decode
+// the JSON response body into result.
+func doJSON(req *http.Request, result interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		return fmt.Errorf("%s %s: could not parse response: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package secretrefs
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+)
+
+type fakeBackend struct {
+	secrets map[string]string
+	created int
+	gets    int
+}
+
+func (b *fakeBackend) getSecret(name string) (string, error) {
+	b.gets++
+	secret, ok := b.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("no such secret")
+	}
+	return secret, nil
+}
+
+// Register a fake backend with the `fake` scheme for the duration of the test
+func setupFakeBackend(t *testing.T) *fakeBackend {
+	t.Helper()
+	fake := &fakeBackend{
+		secrets: map[string]string{
+			"plain": "sekrit",
+			"json":  `{"accessToken": "tok", "port": 8080}`,
+		},
+	}
+	backends["fake"] = func(*cfg.SecretBackendsConfig) (backend, error) {
+		fake.created++
+		return fake, nil
+	}
+	t.Cleanup(func() {
+		delete(backends, "fake")
+	})
+	return fake
+}
+
+func TestConfigureRun(t *testing.T) {
+	fake := setupFakeBackend(t)
+
+	var workerConfig cfg.WorkerConfig
+	err := json.Unmarshal([]byte(`{
+		"plain": "fake://plain",
+		"nested": {"token": "fake://json#accessToken", "list": ["fake://json#accessToken", "unchanged"]},
+		"url": "https://example.com",
+		"unknown": "unknown://plain",
+		"number": 10
+	}`), &workerConfig)
+	require.NoError(t, err)
+
+	state := &run.State{WorkerConfig: &workerConfig}
+	err = ConfigureRun(&cfg.RunnerConfig{}, state)
+	require.NoError(t, err)
+
+	require.Equal(t, "sekrit", state.WorkerConfig.MustGet("plain"))
+	require.Equal(t, "tok", state.WorkerConfig.MustGet("nested.token"))
+	require.Equal(t, []interface{}{"tok", "unchanged"}, state.WorkerConfig.MustGet("nested.list"))
+	require.Equal(t, "https://example.com", state.WorkerConfig.MustGet("url"))
+	require.Equal(t, "unknown://plain", state.WorkerConfig.MustGet("unknown"))
+	require.Equal(t, 10.0, state.WorkerConfig.MustGet("number"))
+
+	// the backend is created once, and each secret is fetched only once
+	require.Equal(t, 1, fake.created)
+	require.Equal(t, 2, fake.gets)
+}
+
+func TestResolveErrors(t *testing.T) {
+	setupFakeBackend(t)
+
+	for _, test := range []struct {
+		ref string
+		err string
+	}{
+		{"fake://", "secret reference fake:// does not name a secret"},
+		{"fake://missing", "could not get secret fake://missing: no such secret"},
+		{"fake://plain#accessToken", "secret fake://plain is not a JSON object, so property accessToken cannot be selected"},
+		{"fake://json#missing", "secret fake://json has no property missing"},
+		{"fake://json#port", "property port of secret fake://json is not a string"},
+	} {
+		t.Run(test.ref, func(t *testing.T) {
+			r := newResolver(nil)
+			_, err := r.resolve(test.ref)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
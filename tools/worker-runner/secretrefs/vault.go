@@ -0,0 +1,174 @@
+package secretrefs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+)
+
+// URL and body of the STS GetCallerIdentity request signed to log in to Vault
+// with the `aws` method
+const (
+	awsSTSURL  = "https://sts.amazonaws.com/"
+	awsSTSBody = "Action=GetCallerIdentity&Version=2011-06-15"
+)
+
+// vaultBackend gets secrets from HashiCorp Vault.  Secret names are API paths,
+// such as `secret/data/workers` for a KV version 2 secret.  The secret value
+// is the JSON encoding of the secret's data.
+type vaultBackend struct {
+	config *cfg.VaultConfig
+	token  string
+}
+
+func newVaultBackend(config *cfg.SecretBackendsConfig) (backend, error) {
+	if config.Vault == nil || config.Vault.Address == "" {
+		return nil, fmt.Errorf("secretBackends.vault.address must be set in the runner configuration to use Vault secrets")
+	}
+	b := &vaultBackend{config: config.Vault}
+	err := b.login()
+	if err != nil {
+		return nil, fmt.Errorf("could not log in to Vault: %w", err)
+	}
+	return b, nil
+}
+
+func (b *vaultBackend) newRequest(method, path string, body interface{}) (*http.Request, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(b.config.Address, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if b.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.config.Namespace)
+	}
+	if b.token != "" {
+		req.Header.Set("X-Vault-Token", b.token)
+	}
+	return req, nil
+}
+
+func (b *vaultBackend) login() error {
+	method := b.config.AuthMethod
+	mount := b.config.AuthMount
+	if mount == "" {
+		mount = method
+	}
+
+	var loginData map[string]interface{}
+	switch method {
+	case "token":
+		if b.config.TokenPath == "" {
+			return fmt.Errorf("secretBackends.vault.tokenPath must be set for the token auth method")
+		}
+		token, err := os.ReadFile(b.config.TokenPath)
+		if err != nil {
+			return err
+		}
+		b.token = strings.TrimSpace(string(token))
+		return nil
+	case "gcp":
+		jwt, err := cloudauth.GCPIdentityToken("http://vault/" + b.config.Role)
+		if err != nil {
+			return err
+		}
+		loginData = map[string]interface{}{
+			"role": b.config.Role,
+			"jwt":  jwt,
+		}
+	case "aws":
+		creds, err := cloudauth.AWSInstanceCredentials()
+		if err != nil {
+			return err
+		}
+		loginData, err = awsVaultLoginData(creds, b.config.Role, time.Now())
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported auth method %q; expected aws, gcp, or token", method)
+	}
+
+	req, err := b.newRequest("POST", "auth/"+mount+"/login", loginData)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	err = doJSON(req, &result)
+	if err != nil {
+		return err
+	}
+	b.token = result.Auth.ClientToken
+	return nil
+}
+
+// Build the login data for Vault's `aws` auth method, consisting of a signed
+// STS GetCallerIdentity request which Vault sends to AWS to verify the
+// instance's IAM role.
+func awsVaultLoginData(creds *cloudauth.AWSCredentials, role string, now time.Time) (map[string]interface{}, error) {
+	req, err := http.NewRequest("POST", awsSTSURL, strings.NewReader(awsSTSBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	cloudauth.SignAWSRequest(req, []byte(awsSTSBody), creds, "us-east-1", "sts", now)
+	headers, err := json.Marshal(req.Header)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"role":                    role,
+		"iam_http_request_method": "POST",
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(awsSTSURL)),
+		"iam_request_body":        base64.StdEncoding.EncodeToString([]byte(awsSTSBody)),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+	}, nil
+}
+
+func (b *vaultBackend) getSecret(name string) (string, error) {
+	req, err := b.newRequest("GET", strings.TrimPrefix(name, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = doJSON(req, &result)
+	if err != nil {
+		return "", err
+	}
+
+	// KV version 2 secrets nest the secret data alongside its metadata
+	data := result.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,120 @@
+package secretrefs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cloudauth"
+)
+
+// Serve a fake Vault server for the duration of the test, returning its
+// address
+func setupFakeVault(t *testing.T) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/gcp/login", func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		if input["role"] != "worker" || input["jwt"] != "jwt-for-http://vault/worker" {
+			w.WriteHeader(400)
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth": {"client_token": "VAULT-TOKEN"}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/workers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "VAULT-TOKEN" || r.Header.Get("X-Vault-Namespace") != "ns" {
+			w.WriteHeader(403)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"accessToken": "tok"}, "metadata": {"version": 1}}}`))
+	})
+	mux.HandleFunc("/v1/kv1/workers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "VAULT-TOKEN" {
+			w.WriteHeader(403)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"accessToken": "tok1"}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestVaultBackendGCP(t *testing.T) {
+	setupFakeGCP(t)
+	address := setupFakeVault(t)
+
+	b, err := newVaultBackend(&cfg.SecretBackendsConfig{
+		Vault: &cfg.VaultConfig{
+			Address:    address,
+			Namespace:  "ns",
+			AuthMethod: "gcp",
+			Role:       "worker",
+		},
+	})
+	require.NoError(t, err)
+
+	secret, err := b.getSecret("secret/data/workers")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"accessToken": "tok"}`, secret)
+}
+
+func TestVaultBackendToken(t *testing.T) {
+	address := setupFakeVault(t)
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("VAULT-TOKEN\n"), 0600))
+
+	b, err := newVaultBackend(&cfg.SecretBackendsConfig{
+		Vault: &cfg.VaultConfig{
+			Address:    address,
+			AuthMethod: "token",
+			TokenPath:  tokenPath,
+		},
+	})
+	require.NoError(t, err)
+
+	secret, err := b.getSecret("kv1/workers")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"accessToken": "tok1"}`, secret)
+
+	_, err = b.getSecret("secret/data/workers")
+	require.ErrorContains(t, err, "403 Forbidden")
+}
+
+func TestVaultBackendNotConfigured(t *testing.T) {
+	_, err := newVaultBackend(&cfg.SecretBackendsConfig{})
+	require.ErrorContains(t, err, "secretBackends.vault.address must be set")
+}
+
+func TestVaultBackendBadAuthMethod(t *testing.T) {
+	_, err := newVaultBackend(&cfg.SecretBackendsConfig{
+		Vault: &cfg.VaultConfig{Address: "https://vault.example.com", AuthMethod: "ldap"},
+	})
+	require.ErrorContains(t, err, `unsupported auth method "ldap"`)
+}
+
+func TestAWSVaultLoginData(t *testing.T) {
+	creds := &cloudauth.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", Token: "TOKEN"}
+	data, err := awsVaultLoginData(creds, "worker", time.Now())
+	require.NoError(t, err)
+
+	require.Equal(t, "worker", data["role"])
+	require.Equal(t, "POST", data["iam_http_request_method"])
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte(awsSTSURL)), data["iam_request_url"])
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte(awsSTSBody)), data["iam_request_body"])
+
+	encodedHeaders, err := base64.StdEncoding.DecodeString(data["iam_request_headers"].(string))
+	require.NoError(t, err)
+	var headers http.Header
+	require.NoError(t, json.Unmarshal(encodedHeaders, &headers))
+	require.Equal(t, "TOKEN", headers.Get("X-Amz-Security-Token"))
+	require.Contains(t, headers.Get("Authorization"), "/us-east-1/sts/aws4_request")
+}
@@ -32,10 +32,27 @@ func configureRun(runnercfg *cfg.RunnerConfig, state *run.State, secretsClientFa
 		return err
 	}
 
+	config, found, err := fetchConfig(secretsClient, state.WorkerPoolID)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		log.Printf("WARNING: No worker secrets for worker pool %v.", state.WorkerPoolID)
+	}
+	state.WorkerConfig = state.WorkerConfig.Merge(config)
+	return nil
+}
+
+// Fetch the worker configuration stored in the secrets for the given worker
+// pool.  The second return value is false if there are no such secrets.
+func fetchConfig(secretsClient tc.Secrets, workerPoolID string) (*cfg.WorkerConfig, bool, error) {
+	var config *cfg.WorkerConfig
+
 	// Consult secrets named both `worker-type:..` and (preferred) `worker-pool:..`.
 	found := false
 	for _, prefix := range []string{"worker-type:", "worker-pool:"} {
-		secretName := prefix + state.WorkerPoolID
+		secretName := prefix + workerPoolID
 		secResponse, err := secretsClient.Get(secretName)
 		if err != nil {
 			if apiCallException, isAPICallException := err.(*tcclient.APICallException); isAPICallException {
@@ -57,7 +74,7 @@ func configureRun(runnercfg *cfg.RunnerConfig, state *run.State, secretsClientFa
 					}
 				}
 			}
-			return err
+			return nil, false, err
 		}
 
 		// some secrets contain raw configuration, while others contain the preferred {config: .., files: ..}.  If we have
@@ -74,20 +91,17 @@ func configureRun(runnercfg *cfg.RunnerConfig, state *run.State, secretsClientFa
 			log.Printf("Falling back to legacy secret format without top-level config/files properties")
 			err := json.Unmarshal(secResponse.Secret, &secret.Config)
 			if err != nil {
-				return fmt.Errorf("secret value is not a JSON object")
+				return nil, false, fmt.Errorf("secret value is not a JSON object")
 			}
 		}
 
 		found = true
-		state.WorkerConfig = state.WorkerConfig.Merge(secret.Config)
+		config = config.Merge(secret.Config)
 
 		if len(secret.Files) != 0 {
-			return fmt.Errorf("secret files are nonempty - files are not supported yet")
+			return nil, false, fmt.Errorf("secret files are nonempty - files are not supported yet")
 		}
 	}
 
-	if !found {
-		log.Printf("WARNING: No worker secrets for worker pool %v.", state.WorkerPoolID)
-	}
-	return nil
+	return config, found, nil
 }
@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"log"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+// UpdateManager watches the worker pool's secrets for changes to the worker
+// configuration while the worker is running, and sends any changed properties
+// to the worker in a `config-update` message.  This is enabled by the
+// `configUpdateIntervalSecs` runner option.
+type UpdateManager struct {
+	runnercfg *cfg.RunnerConfig
+	state     *run.State
+
+	// Factory for secrets clients
+	factory tc.SecretsClientFactory
+
+	// the protocol (set in SetProtocol)
+	proto *workerproto.Protocol
+
+	// the configuration from the secrets when last checked
+	last *cfg.WorkerConfig
+
+	ticker *time.Ticker
+}
+
+func (um *UpdateManager) SetProtocol(proto *workerproto.Protocol) {
+	um.proto = proto
+	proto.AddCapability("config-update")
+}
+
+func (um *UpdateManager) WorkerStarted() error {
+	if !um.runnercfg.GetSecrets {
+		return nil
+	}
+
+	var interval float64
+	um.state.RLock()
+	if um.state.RunnerOptions != nil {
+		if v, err := um.state.RunnerOptions.Get("configUpdateIntervalSecs"); err == nil {
+			interval, _ = v.(float64)
+		}
+	}
+	um.state.RUnlock()
+	if interval <= 0 {
+		return nil
+	}
+
+	var err error
+	um.last, _, err = um.fetch()
+	if err != nil {
+		return err
+	}
+
+	um.ticker = time.NewTicker(time.Duration(interval) * time.Second)
+	go func() {
+		for range um.ticker.C {
+			um.checkForUpdates()
+		}
+	}()
+	return nil
+}
+
+func (um *UpdateManager) WorkerFinished() error {
+	if um.ticker != nil {
+		um.ticker.Stop()
+	}
+	return nil
+}
+
+func (um *UpdateManager) fetch() (*cfg.WorkerConfig, bool, error) {
+	um.state.RLock()
+	defer um.state.RUnlock()
+
+	// the credentials may have been renewed since the last call
+	secretsClient, err := um.factory(um.state.RootURL, &um.state.Credentials)
+	if err != nil {
+		return nil, false, err
+	}
+	return fetchConfig(secretsClient, um.state.WorkerPoolID)
+}
+
+// Check the secrets for changed configuration, and send any changes to the
+// worker.  Returns true if an update was sent.
+func (um *UpdateManager) checkForUpdates() bool {
+	if !um.proto.Capable("config-update") {
+		return false
+	}
+
+	config, _, err := um.fetch()
+	if err != nil {
+		log.Printf("Error checking secrets for configuration updates: %v", err)
+		return false
+	}
+	changes := um.last.Diff(config)
+	um.last = config
+	if changes.IsEmpty() {
+		return false
+	}
+
+	log.Println("Worker configuration in secrets has changed; sending config-update to worker")
+	um.proto.Send(workerproto.Message{
+		Type: "config-update",
+		Properties: map[string]interface{}{
+			"config": changes,
+		},
+	})
+	return true
+}
+
+// Make a new UpdateManager object
+func NewUpdateManager(runnercfg *cfg.RunnerConfig, state *run.State) *UpdateManager {
+	return newUpdateManager(runnercfg, state, clientFactory)
+}
+
+// Private constructor allowing injection of a fake factory
+func newUpdateManager(runnercfg *cfg.RunnerConfig, state *run.State, factory tc.SecretsClientFactory) *UpdateManager {
+	return &UpdateManager{
+		runnercfg: runnercfg,
+		state:     state,
+		factory:   factory,
+	}
+}
@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcsecrets"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/tc"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
+)
+
+func TestCheckForUpdates(t *testing.T) {
+	test := func(t *testing.T, capabilities ...string) (*UpdateManager, *ptesting.FakeWorker) {
+		t.Helper()
+		runnercfg, state := setup(t)
+		runnercfg.GetSecrets = true
+		state.RunnerOptions, _ = cfg.NewWorkerConfig().Set("configUpdateIntervalSecs", 3600.0)
+
+		tc.FakeSecretsCreateSecret("worker-pool:pp/wt", &tcsecrets.Secret{
+			Secret: []byte(`{"config": {"paused": false, "other": 1}}`),
+		})
+
+		wkr := ptesting.NewFakeWorkerWithCapabilities(capabilities...)
+		um := newUpdateManager(runnercfg, state, tc.FakeSecretsClientFactory)
+		um.SetProtocol(wkr.RunnerProtocol)
+		require.NoError(t, um.WorkerStarted())
+		t.Cleanup(func() {
+			require.NoError(t, um.WorkerFinished())
+			wkr.Close()
+		})
+		wkr.RunnerProtocol.Start(false)
+		wkr.RunnerProtocol.WaitUntilInitialized()
+		return um, wkr
+	}
+
+	t.Run("without capability", func(t *testing.T) {
+		um, wkr := test(t)
+		gotUpdate := wkr.MessageReceivedFunc("config-update", nil)
+
+		tc.FakeSecretsCreateSecret("worker-pool:pp/wt", &tcsecrets.Secret{
+			Secret: []byte(`{"config": {"paused": true, "other": 1}}`),
+		})
+		require.False(t, um.checkForUpdates())
+		require.False(t, gotUpdate())
+	})
+
+	t.Run("with capability", func(t *testing.T) {
+		um, wkr := test(t, "config-update")
+		gotUpdate := wkr.MessageReceivedFunc("config-update", func(msg workerproto.Message) bool {
+			config := msg.Properties["config"].(map[string]interface{})
+			return len(config) == 1 && config["paused"] == true
+		})
+
+		// no change
+		require.False(t, um.checkForUpdates())
+
+		tc.FakeSecretsCreateSecret("worker-pool:pp/wt", &tcsecrets.Secret{
+			Secret: []byte(`{"config": {"paused": true, "other": 1}}`),
+		})
+		require.True(t, um.checkForUpdates())
+		require.True(t, gotUpdate())
+
+		// the change is only sent once
+		require.False(t, um.checkForUpdates())
+	})
+}
@@ -0,0 +1,184 @@
+// Package systemd implements the systemd service notification protocol
+// (sd_notify), so that worker-runner can be run in a unit with Type=notify
+// and, optionally, WatchdogSec.
+package systemd
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+// Notifier sends service status notifications to systemd.  All of its methods
+// are no-ops when worker-runner was not started by systemd with a
+// notification socket.
+type Notifier struct {
+	// path of systemd's notification socket, or "" if notifications are
+	// disabled
+	socket string
+
+	// interval at which systemd expects watchdog notifications, or zero if
+	// the watchdog is disabled
+	watchdog time.Duration
+
+	proto *workerproto.Protocol
+
+	// time of the last heartbeat from the worker
+	lastHeartbeat     time.Time
+	lastHeartbeatLock sync.Mutex
+
+	stop chan struct{}
+}
+
+// Make a new Notifier, configured from the environment variables set by
+// systemd
+func New() *Notifier {
+	return new(os.Getenv)
+}
+
+// Private constructor allowing injection of the environment
+func new(getenv func(string) string) *Notifier {
+	n := &Notifier{
+		socket: getenv("NOTIFY_SOCKET"),
+		stop:   make(chan struct{}),
+	}
+
+	// WATCHDOG_PID, if set, names the process systemd expects to hear from
+	if pid := getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return n
+	}
+	if usec, err := strconv.ParseInt(getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+		n.watchdog = time.Duration(usec) * time.Microsecond
+	}
+	return n
+}
+
+// Enabled returns true if worker-runner was started by systemd with a
+// notification socket
+func (n *Notifier) Enabled() bool {
+	return n.socket != ""
+}
+
+// Send a notification to systemd, such as "READY=1"
+func (n *Notifier) notify(state string) error {
+	// a leading @ denotes a socket in the abstract namespace, which Go handles
+	// in the same way
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: n.socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("could not connect to systemd notification socket %s: %w", n.socket, err)
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+func (n *Notifier) SetProtocol(proto *workerproto.Protocol) {
+	n.proto = proto
+	if !n.Enabled() {
+		return
+	}
+	proto.AddCapability("graceful-termination")
+	if n.watchdog == 0 {
+		return
+	}
+	proto.Register("heartbeat", func(msg workerproto.Message) {
+		n.lastHeartbeatLock.Lock()
+		defer n.lastHeartbeatLock.Unlock()
+		n.lastHeartbeat = time.Now()
+	})
+	proto.AddCapability("heartbeat")
+}
+
+// Tell systemd that the service is ready and start the watchdog.  This must be
+// called before the protocol is started.
+func (n *Notifier) WorkerStarted() error {
+	if !n.Enabled() {
+		return nil
+	}
+
+	if err := n.notify("READY=1"); err != nil {
+		log.Printf("Could not notify systemd of readiness: %v", err)
+	}
+
+	if n.watchdog != 0 {
+		n.lastHeartbeatLock.Lock()
+		n.lastHeartbeat = time.Now()
+		n.lastHeartbeatLock.Unlock()
+		go n.runWatchdog()
+	}
+
+	return nil
+}
+
+func (n *Notifier) WorkerFinished() error {
+	if !n.Enabled() {
+		return nil
+	}
+	close(n.stop)
+	if err := n.notify("STOPPING=1"); err != nil {
+		log.Printf("Could not notify systemd of stopping: %v", err)
+	}
+	return nil
+}
+
+// Ask the worker to shut down, when systemd stops the unit by sending SIGTERM
+func (n *Notifier) HandleTerminationSignal() {
+	if !n.Enabled() {
+		return
+	}
+	log.Println("Stop requested by systemd; requesting graceful termination of the worker")
+	if err := n.notify("STOPPING=1"); err != nil {
+		log.Printf("Could not notify systemd of stopping: %v", err)
+	}
+	if n.proto != nil && n.proto.Capable("graceful-termination") {
+		n.proto.Send(workerproto.Message{
+			Type: "graceful-termination",
+			Properties: map[string]interface{}{
+				// systemd kills the unit after TimeoutStopSec, which is
+				// generally too short to finish tasks
+				"finish-tasks": false,
+			},
+		})
+	}
+}
+
+// Send watchdog notifications at half of the watchdog interval, as
+// recommended by sd_watchdog_enabled(3).  If the worker sends heartbeats,
+// notifications are only sent while those heartbeats continue, so that a hung
+// worker causes the watchdog to fire.
+func (n *Notifier) runWatchdog() {
+	ticker := time.NewTicker(n.watchdog / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+			if !n.healthy() {
+				log.Printf("No heartbeat from worker in %s; not notifying systemd watchdog", n.watchdog)
+				continue
+			}
+			if err := n.notify("WATCHDOG=1"); err != nil {
+				log.Printf("Could not notify systemd watchdog: %v", err)
+			}
+		}
+	}
+}
+
+// Determine whether the worker is healthy, based on its heartbeats
+func (n *Notifier) healthy() bool {
+	if n.proto == nil || !n.proto.Capable("heartbeat") {
+		// the protocol may not be initialized yet, or the worker does not
+		// send heartbeats; in either case there's nothing to judge by
+		return true
+	}
+	n.lastHeartbeatLock.Lock()
+	defer n.lastHeartbeatLock.Unlock()
+	return time.Since(n.lastHeartbeat) < n.watchdog
+}
@@ -0,0 +1,177 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+	ptesting "github.com/taskcluster/taskcluster/v60/tools/workerproto/testing"
+)
+
+// listen on a notification socket, returning it and a channel of the
+// notifications received on it
+func fakeSystemd(t *testing.T) (string, chan string) {
+	t.Helper()
+	// socket paths are limited in length, so avoid the long t.TempDir()
+	dir, err := os.MkdirTemp("", "sd")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "notify")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	notifications := make(chan string, 100)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			notifications <- string(buf[:n])
+		}
+	}()
+	return socket, notifications
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string {
+		return vars[name]
+	}
+}
+
+func expectNotification(t *testing.T, notifications chan string, state string) {
+	t.Helper()
+	select {
+	case got := <-notifications:
+		require.Equal(t, state, got)
+	case <-time.After(time.Second):
+		t.Fatalf("did not get notification %s", state)
+	}
+}
+
+func countWatchdogs(notifications chan string, d time.Duration) int {
+	count := 0
+	timeout := time.After(d)
+	for {
+		select {
+		case got := <-notifications:
+			if got == "WATCHDOG=1" {
+				count++
+			}
+		case <-timeout:
+			return count
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	n := new(env(nil))
+	require.False(t, n.Enabled())
+
+	wkr := ptesting.NewFakeWorkerWithCapabilities()
+	defer wkr.Close()
+	n.SetProtocol(wkr.RunnerProtocol)
+	require.NoError(t, n.WorkerStarted())
+	require.NoError(t, n.WorkerFinished())
+}
+
+func TestWatchdogEnv(t *testing.T) {
+	n := new(env(map[string]string{"NOTIFY_SOCKET": "/sock", "WATCHDOG_USEC": "2000000"}))
+	require.Equal(t, 2*time.Second, n.watchdog)
+
+	n = new(env(map[string]string{
+		"NOTIFY_SOCKET": "/sock",
+		"WATCHDOG_USEC": "2000000",
+		"WATCHDOG_PID":  strconv.Itoa(os.Getpid() + 1),
+	}))
+	require.Equal(t, time.Duration(0), n.watchdog)
+}
+
+func TestReadyAndStop(t *testing.T) {
+	socket, notifications := fakeSystemd(t)
+	n := new(env(map[string]string{"NOTIFY_SOCKET": socket}))
+
+	wkr := ptesting.NewFakeWorkerWithCapabilities("graceful-termination")
+	defer wkr.Close()
+	gotTerm := wkr.MessageReceivedFunc("graceful-termination", func(msg workerproto.Message) bool {
+		return msg.Properties["finish-tasks"] == false
+	})
+
+	n.SetProtocol(wkr.RunnerProtocol)
+	require.NoError(t, n.WorkerStarted())
+	wkr.RunnerProtocol.Start(false)
+	wkr.RunnerProtocol.WaitUntilInitialized()
+
+	expectNotification(t, notifications, "READY=1")
+
+	n.HandleTerminationSignal()
+	expectNotification(t, notifications, "STOPPING=1")
+	// give the worker time to receive the message
+	time.Sleep(100 * time.Millisecond)
+	require.True(t, gotTerm())
+
+	require.NoError(t, n.WorkerFinished())
+	expectNotification(t, notifications, "STOPPING=1")
+}
+
+func TestWatchdog(t *testing.T) {
+	test := func(t *testing.T, wkr *ptesting.FakeWorker) (*Notifier, chan string) {
+		t.Helper()
+		socket, notifications := fakeSystemd(t)
+		n := new(env(map[string]string{
+			"NOTIFY_SOCKET": socket,
+			"WATCHDOG_USEC": "200000",
+		}))
+
+		n.SetProtocol(wkr.RunnerProtocol)
+		require.NoError(t, n.WorkerStarted())
+		wkr.RunnerProtocol.Start(false)
+		wkr.RunnerProtocol.WaitUntilInitialized()
+		expectNotification(t, notifications, "READY=1")
+		return n, notifications
+	}
+
+	t.Run("worker without heartbeats", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities()
+		defer wkr.Close()
+
+		n, notifications := test(t, wkr)
+		defer n.WorkerFinished()
+
+		require.Greater(t, countWatchdogs(notifications, 500*time.Millisecond), 2)
+	})
+
+	t.Run("worker with heartbeats", func(t *testing.T) {
+		wkr := ptesting.NewFakeWorkerWithCapabilities("heartbeat")
+		defer wkr.Close()
+
+		n, notifications := test(t, wkr)
+		defer n.WorkerFinished()
+
+		stopHeartbeats := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-stopHeartbeats:
+					return
+				case <-time.After(50 * time.Millisecond):
+					wkr.WorkerProtocol.Send(workerproto.Message{Type: "heartbeat"})
+				}
+			}
+		}()
+		require.Greater(t, countWatchdogs(notifications, 500*time.Millisecond), 2)
+
+		// once heartbeats stop, so do watchdog notifications
+		close(stopHeartbeats)
+		countWatchdogs(notifications, 300*time.Millisecond)
+		require.Equal(t, 0, countWatchdogs(notifications, 500*time.Millisecond))
+	})
+}
@@ -50,6 +50,9 @@ func (wm *FakeWorkerManager) RegisterWorker(payload *tcworkermanager.RegisterWor
 			"files": [
 			    {"description": "a file."}
 			]
+		},
+		"workerRunner": {
+			"from-register-worker": true
 		}
 	}`)
 
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/util"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/worker/worker"
@@ -128,7 +129,7 @@ func (d *dockerworker) StartWorker(state *run.State) (workerproto.Transport, err
 	}
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, "DOCKER_WORKER_CONFIG="+d.wicfg.ConfigPath)
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = logging.NewOutputWriter()
 	d.cmd = cmd
 
 	cmdStdout, err := cmd.StdoutPipe()
@@ -0,0 +1,129 @@
+package genericworker
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+// versions must be safe to use in a filename and URL
+var versionPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// binaryProvisioner downloads and caches signed generic-worker binaries.
+type binaryProvisioner struct {
+	// URL of the binary, with `{{version}}` standing for the version; the
+	// signature is at the same URL with `.sig` appended
+	urlTemplate string
+	publicKey   ed25519.PublicKey
+	cacheDir    string
+}
+
+func newBinaryProvisioner(wicfg *genericworkerConfig) (*binaryProvisioner, error) {
+	if wicfg.BinaryURL == "" || wicfg.BinaryPublicKey == "" || wicfg.BinaryCacheDir == "" {
+		return nil, fmt.Errorf("worker.binaryUrl, worker.binaryPublicKey and worker.binaryCacheDir must be set to use a pinned generic-worker version")
+	}
+	key, err := base64.StdEncoding.DecodeString(wicfg.BinaryPublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("worker.binaryPublicKey is not a base64-encoded ed25519 public key")
+	}
+	return &binaryProvisioner{
+		urlTemplate: wicfg.BinaryURL,
+		publicKey:   ed25519.PublicKey(key),
+		cacheDir:    wicfg.BinaryCacheDir,
+	}, nil
+}
+
+func download(url string) ([]byte, error) {
+	resp, _, err := httpbackoff.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Return the path of the given version of the generic-worker binary,
+// downloading it if it is not already cached.  The binary's signature is
+// verified whether or not it was cached.
+func (bp *binaryProvisioner) provision(version string) (string, error) {
+	if !versionPattern.MatchString(version) {
+		return "", fmt.Errorf("invalid generic-worker version %q", version)
+	}
+
+	dir := filepath.Join(bp.cacheDir, version)
+	filename := "generic-worker"
+	if runtime.GOOS == "windows" {
+		filename += ".exe"
+	}
+	path := filepath.Join(dir, filename)
+	sigPath := path + ".sig"
+
+	binary, err := os.ReadFile(path)
+	if err == nil {
+		sig, err := os.ReadFile(sigPath)
+		if err == nil && ed25519.Verify(bp.publicKey, binary, sig) {
+			log.Printf("Using cached generic-worker %s at %s", version, path)
+			return path, nil
+		}
+		log.Printf("Cached generic-worker %s failed verification; downloading it again", version)
+	}
+
+	url := strings.ReplaceAll(bp.urlTemplate, "{{version}}", version)
+	log.Printf("Downloading generic-worker %s from %s", version, url)
+	binary, err = download(url)
+	if err != nil {
+		return "", err
+	}
+	encodedSig, err := download(url + ".sig")
+	if err != nil {
+		return "", err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedSig)))
+	if err != nil {
+		return "", fmt.Errorf("signature at %s.sig is not base64-encoded", url)
+	}
+	if !ed25519.Verify(bp.publicKey, binary, sig) {
+		return "", fmt.Errorf("generic-worker %s downloaded from %s does not have a valid signature", version, url)
+	}
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	// write to a temporary file and rename it into place, so that an
+	// interrupted download is never mistaken for a cached binary
+	tmp, err := os.CreateTemp(dir, filename+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(binary)
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return "", err
+	}
+	err = os.Chmod(tmp.Name(), 0755)
+	if err != nil {
+		return "", err
+	}
+	err = os.WriteFile(sigPath, sig, 0644)
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,107 @@
+package genericworker
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// serve the given binary and signature for every version, counting
+// downloads of the binary
+func fakeBinaryServer(t *testing.T, binary []byte, sig []byte) (*httptest.Server, *int) {
+	t.Helper()
+	downloads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sig") {
+			_, _ = w.Write([]byte(base64.StdEncoding.EncodeToString(sig) + "\n"))
+			return
+		}
+		downloads++
+		_, _ = w.Write(binary)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &downloads
+}
+
+func makeProvisioner(t *testing.T, url string, pub ed25519.PublicKey) *binaryProvisioner {
+	t.Helper()
+	bp, err := newBinaryProvisioner(&genericworkerConfig{
+		BinaryURL:       url + "/{{version}}/generic-worker",
+		BinaryPublicKey: base64.StdEncoding.EncodeToString(pub),
+		BinaryCacheDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	return bp
+}
+
+func TestProvisionBinary(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	binary := []byte("#!/bin/sh\necho hello\n")
+	srv, downloads := fakeBinaryServer(t, binary, ed25519.Sign(priv, binary))
+	bp := makeProvisioner(t, srv.URL, pub)
+
+	path, err := bp.provision("v60.0.0")
+	require.NoError(t, err)
+	require.Equal(t, 1, *downloads)
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, binary, got)
+
+	t.Run("cached", func(t *testing.T) {
+		path2, err := bp.provision("v60.0.0")
+		require.NoError(t, err)
+		require.Equal(t, path, path2)
+		require.Equal(t, 1, *downloads)
+	})
+
+	t.Run("tampered cache", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("evil"), 0755))
+		_, err := bp.provision("v60.0.0")
+		require.NoError(t, err)
+		require.Equal(t, 2, *downloads)
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, binary, got)
+	})
+}
+
+func TestProvisionBinaryBadSignature(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	binary := []byte("binary")
+	srv, _ := fakeBinaryServer(t, binary, ed25519.Sign(otherPriv, binary))
+	bp := makeProvisioner(t, srv.URL, pub)
+
+	_, err = bp.provision("v60.0.0")
+	require.ErrorContains(t, err, "does not have a valid signature")
+
+	entries, err := os.ReadDir(bp.cacheDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestProvisionBinaryInvalidVersion(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	bp := makeProvisioner(t, "http://127.0.0.1:1", pub)
+
+	_, err = bp.provision("../../etc")
+	require.ErrorContains(t, err, "invalid generic-worker version")
+}
+
+func TestNewBinaryProvisionerMissingConfig(t *testing.T) {
+	_, err := newBinaryProvisioner(&genericworkerConfig{BinaryURL: "https://example.com/{{version}}"})
+	require.ErrorContains(t, err, "must be set")
+}
@@ -14,10 +14,15 @@ import (
 )
 
 type genericworkerConfig struct {
-	Path         string `workerimpl:",optional"`
-	Service      string `workerimpl:",optional"`
-	ProtocolPipe string `workerimpl:",optional"`
-	ConfigPath   string
+	Path                   string `workerimpl:",optional"`
+	Service                string `workerimpl:",optional"`
+	ProtocolPipe           string `workerimpl:",optional"`
+	WorkDir                string `workerimpl:",optional"`
+	BinaryURL              string `workerimpl:"binaryUrl,optional"`
+	BinaryPublicKey        string `workerimpl:",optional"`
+	BinaryCacheDir         string `workerimpl:",optional"`
+	ServiceRestartAttempts int    `workerimpl:",optional"`
+	ConfigPath             string
 }
 
 type genericworker struct {
@@ -124,6 +129,28 @@ func (d *genericworker) StartWorker(state *run.State) (workerproto.Transport, er
 		return nil, fmt.Errorf("error writing worker config to %s: %v", d.wicfg.ConfigPath, err)
 	}
 
+	// use the generic-worker version pinned by the worker pool, if any, in
+	// place of worker.path
+	var version string
+	if state.RunnerOptions != nil {
+		if v, err := state.RunnerOptions.Get("genericWorkerVersion"); err == nil {
+			version, _ = v.(string)
+		}
+	}
+	if version != "" {
+		if d.wicfg.Service != "" {
+			return nil, fmt.Errorf("a pinned generic-worker version cannot be used with worker.service")
+		}
+		bp, err := newBinaryProvisioner(&d.wicfg)
+		if err != nil {
+			return nil, err
+		}
+		d.wicfg.Path, err = bp.provision(version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if (d.wicfg.Path != "" && d.wicfg.Service != "") || (d.wicfg.Path == "" && d.wicfg.Service == "") {
 		return nil, fmt.Errorf("specify exactly one of worker.path and worker.windowsService")
 	}
@@ -140,6 +167,7 @@ func (d *genericworker) StartWorker(state *run.State) (workerproto.Transport, er
 }
 
 func (d *genericworker) SetProtocol(proto *workerproto.Protocol) {
+	d.runMethod.setProtocol(proto)
 }
 
 func (d *genericworker) Wait() error {
@@ -170,15 +198,26 @@ worker:
 	path: /usr/local/bin/generic-worker
 	# (Windows only) service name to start
 	service: "Generic Worker"
+	# (Windows only) number of times to restart the service, with increasing
+	# delays, if it crashes; default is 0
+	serviceRestartAttempts: 3
 	# (Windows only) named pipe (\\.\pipe\<something>) with which generic-worker
 	# will communicate with worker-runner; default value is as shown here:
 	protocolPipe: \\.\pipe\generic-worker
 	# path where worker-runner should write the generated
 	# generic-worker configuration.
 	configPath: /etc/taskcluster/generic-worker/config.yaml
+	# directory in which to run generic-worker, where it keeps its state
+	# between tasks; defaults to the working directory of worker-runner
+	workDir: /var/lib/generic-worker
+	# (optional) where to download generic-worker binaries from, when the
+	# worker pool pins a version; see below
+	binaryUrl: https://example.com/generic-worker/{{version}}/generic-worker-multiuser-linux-amd64
+	binaryPublicKey: <base64-encoded ed25519 public key>
+	binaryCacheDir: /var/cache/generic-worker
 `+"```"+`
 
-On Linux, specify only |implementation|, |path|, and |configPath|.
+On Linux, specify only |implementation|, |path|, |configPath|, and optionally |workDir|.
 
 On Windows, worker-runner can start generic-worker in two ways: as a service, or as a child process.
 
@@ -186,8 +225,29 @@ To run generic-worker as a service, specify |implementation|, |service| and |con
 See [Deployment](/docs/reference/workers/worker-runner/deployment).
 In most cases, |protocolPipe| can be omitted to use the default value.
 This would only need to be overridden if multiple copies of generic-worker are running on the same host.
+If |serviceRestartAttempts| is set, worker-runner restarts the service when it crashes (exits with a code other than 0 or one of generic-worker's own exit codes), waiting 5 seconds before the first restart and doubling the delay for each subsequent restart, up to 5 minutes.
+A service that runs for at least 10 minutes before crashing starts again from the first attempt.
+The restarted worker reconnects to worker-runner over |protocolPipe|.
+When the host shuts down, worker-runner asks the worker to terminate gracefully, so that its tasks are resolved as |worker-shutdown|, and does not restart it.
 
-To run generic-worker as a child process, specify |implementation|, |path| and |configPath|.
+To run generic-worker as a child process, specify |implementation|, |path| and |configPath|, and optionally |workDir|.
 In this case, |protocolPipe| is not used.
+
+A worker pool can pin the version of generic-worker to run, so that one machine image can serve pools running different versions, by setting |genericWorkerVersion| in the |workerRunner| section of its worker configuration:
+
+`+"```yaml"+`
+workerConfig:
+	workerRunner:
+		genericWorkerVersion: v60.0.0
+	genericWorker:
+		config: ...
+`+"```"+`
+
+Worker-runner then downloads that version from |binaryUrl|, with |{{version}}| replaced by the version, and runs it in place of |path|.
+The binary must be signed with the ed25519 private key corresponding to |binaryPublicKey|, with the base64-encoded signature available at the same URL with |.sig| appended.
+Verified binaries are cached in |binaryCacheDir|, one directory per version, and their signatures are checked again before each use.
+A pinned version cannot be used when running generic-worker as a Windows service.
+
+When running multiple generic-worker processes on the same host (see |multipleWorkers| in the runner configuration), run them as child processes, and include both |configPath| and |workDir| in |multipleWorkers.workerPaths| so that each worker has its own.
 `, "|", "`")
 }
@@ -0,0 +1,152 @@
+package genericworker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+// pipeBridge connects a protocol transport to a sequence of connections from
+// the worker, one at a time, so that the worker can be restarted without
+// restarting the protocol.
+type pipeBridge struct {
+	// messages from the worker are written to inputWriter, and messages to
+	// the worker are read from outputReader
+	inputWriter  *io.PipeWriter
+	outputReader *io.PipeReader
+
+	lock sync.Mutex
+	cond *sync.Cond
+
+	// the current connection, if any
+	conn io.ReadWriteCloser
+
+	// the first message sent to the worker, which is the protocol's welcome
+	// message; this is replayed to each subsequent connection so that a
+	// restarted worker can negotiate capabilities
+	welcome []byte
+
+	// the network-config message sent to the worker, if any, which is also
+	// replayed, as it is only sent once, on startup
+	networkConfig []byte
+
+	closed bool
+}
+
+// Create a new pipeBridge, returning it and the transport for the protocol
+func newPipeBridge() (*pipeBridge, workerproto.Transport) {
+	inputReader, inputWriter := io.Pipe()
+	outputReader, outputWriter := io.Pipe()
+	b := &pipeBridge{
+		inputWriter:  inputWriter,
+		outputReader: outputReader,
+	}
+	b.cond = sync.NewCond(&b.lock)
+	go b.sendLoop()
+	return b, workerproto.NewPipeTransport(inputReader, outputWriter)
+}
+
+// Copy messages from the given connection to the protocol until it is
+// closed, meanwhile sending messages from the protocol to it.  A partial
+// message at the end of the connection is discarded.
+func (b *pipeBridge) serve(conn io.ReadWriteCloser) {
+	// replay messages and start sending to the connection in the background,
+	// so that the worker's replies can be read meanwhile
+	go func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		for _, msg := range [][]byte{b.welcome, b.networkConfig} {
+			if msg == nil {
+				continue
+			}
+			if _, err := conn.Write(msg); err != nil {
+				log.Printf("Error replaying message to worker: %s", err)
+				return
+			}
+		}
+		b.conn = conn
+		b.cond.Broadcast()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		if _, err = b.inputWriter.Write(line); err != nil {
+			break
+		}
+	}
+
+	b.lock.Lock()
+	if b.conn == conn {
+		b.conn = nil
+	}
+	b.lock.Unlock()
+	conn.Close()
+}
+
+// Send messages from the protocol to the current connection, waiting for a
+// connection if there is none.
+func (b *pipeBridge) sendLoop() {
+	reader := bufio.NewReader(b.outputReader)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		b.lock.Lock()
+		for {
+			for b.conn == nil && !b.closed {
+				b.cond.Wait()
+			}
+			if b.closed {
+				b.lock.Unlock()
+				return
+			}
+			if _, err = b.conn.Write(line); err == nil {
+				// record messages to be replayed once they have been sent,
+				// so that later connections do not receive them twice
+				if b.welcome == nil {
+					b.welcome = line
+				} else if messageType(line) == "network-config" {
+					b.networkConfig = line
+				}
+				break
+			}
+			// the worker has gone away; try again with the next connection
+			b.conn = nil
+		}
+		b.lock.Unlock()
+	}
+}
+
+// Get the type of an encoded protocol message, or "" if it cannot be
+// determined
+func messageType(line []byte) string {
+	var msg struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(bytes.TrimPrefix(line, []byte{'~'}), &msg); err != nil {
+		return ""
+	}
+	return msg.Type
+}
+
+// Close the bridge, signalling EOF to the protocol.
+func (b *pipeBridge) close() {
+	b.lock.Lock()
+	b.closed = true
+	b.cond.Broadcast()
+	b.lock.Unlock()
+
+	b.inputWriter.Close()
+	b.outputReader.Close()
+}
@@ -0,0 +1,95 @@
+package genericworker
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+// connect a worker-side protocol to the bridge over a new connection
+func connectWorker(t *testing.T, bridge *pipeBridge) (*workerproto.Protocol, net.Conn) {
+	t.Helper()
+	runnerEnd, workerEnd := net.Pipe()
+	go bridge.serve(runnerEnd)
+
+	wproto := workerproto.NewProtocol(workerproto.NewPipeTransport(workerEnd, workerEnd))
+	wproto.AddCapability("test")
+	return wproto, workerEnd
+}
+
+func TestPipeBridgeReconnect(t *testing.T) {
+	bridge, transp := newPipeBridge()
+	rproto := workerproto.NewProtocol(transp)
+	rproto.AddCapability("test")
+
+	received := make(chan string, 10)
+	rproto.Register("from-worker", func(msg workerproto.Message) {
+		received <- msg.Properties["worker"].(string)
+	})
+	rproto.Start(false)
+
+	for _, name := range []string{"first", "restarted"} {
+		wproto, conn := connectWorker(t, bridge)
+		wproto.Start(true)
+		wproto.WaitUntilInitialized()
+		require.True(t, wproto.Capable("test"), "%s worker negotiated capabilities", name)
+
+		wproto.Send(workerproto.Message{
+			Type:       "from-worker",
+			Properties: map[string]interface{}{"worker": name},
+		})
+		select {
+		case got := <-received:
+			require.Equal(t, name, got)
+		case <-time.After(time.Second):
+			t.Fatalf("did not get message from %s worker", name)
+		}
+
+		// the worker goes away
+		conn.Close()
+	}
+
+	bridge.close()
+	rproto.WaitForEOF()
+}
+
+func TestPipeBridgeReplaysNetworkConfig(t *testing.T) {
+	bridge, transp := newPipeBridge()
+	rproto := workerproto.NewProtocol(transp)
+	rproto.AddCapability("network-config")
+	rproto.Start(false)
+
+	for i, name := range []string{"first", "restarted"} {
+		runnerEnd, workerEnd := net.Pipe()
+		go bridge.serve(runnerEnd)
+		wproto := workerproto.NewProtocol(workerproto.NewPipeTransport(workerEnd, workerEnd))
+		wproto.AddCapability("network-config")
+		received := make(chan string, 1)
+		wproto.Register("network-config", func(msg workerproto.Message) {
+			received <- msg.Properties["config"].(map[string]interface{})["httpProxy"].(string)
+		})
+		wproto.Start(true)
+
+		if i == 0 {
+			rproto.WaitUntilInitialized()
+			rproto.Send(workerproto.Message{
+				Type:       "network-config",
+				Properties: map[string]interface{}{"config": map[string]interface{}{"httpProxy": "http://proxy"}},
+			})
+		}
+		select {
+		case got := <-received:
+			require.Equal(t, "http://proxy", got)
+		case <-time.After(time.Second):
+			t.Fatalf("%s worker did not get network-config", name)
+		}
+
+		workerEnd.Close()
+	}
+
+	bridge.close()
+	rproto.WaitForEOF()
+}
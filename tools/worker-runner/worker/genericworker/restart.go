@@ -0,0 +1,40 @@
+package genericworker
+
+import "time"
+
+const (
+	// delay before the first restart of a crashed worker, doubling with each
+	// subsequent restart up to maxRestartDelay
+	minRestartDelay = 5 * time.Second
+	maxRestartDelay = 5 * time.Minute
+
+	// a worker that runs for at least this long before crashing is considered
+	// to have recovered, and the restart attempts and delay are reset
+	restartResetAfter = 10 * time.Minute
+)
+
+// restartPolicy decides whether and when to restart a crashed worker.
+type restartPolicy struct {
+	maxAttempts int
+	attempts    int
+	delay       time.Duration
+}
+
+// Return the delay before the next restart of a worker that crashed after
+// running for the given duration, or false if it should not be restarted.
+func (p *restartPolicy) next(ranFor time.Duration) (time.Duration, bool) {
+	if ranFor >= restartResetAfter {
+		p.attempts = 0
+		p.delay = 0
+	}
+	if p.attempts >= p.maxAttempts {
+		return 0, false
+	}
+	p.attempts++
+	if p.delay == 0 {
+		p.delay = minRestartDelay
+	} else {
+		p.delay = min(p.delay*2, maxRestartDelay)
+	}
+	return p.delay, true
+}
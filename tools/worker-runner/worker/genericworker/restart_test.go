@@ -0,0 +1,42 @@
+package genericworker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	p := restartPolicy{maxAttempts: 3}
+
+	var delays []time.Duration
+	for {
+		delay, ok := p.next(time.Second)
+		if !ok {
+			break
+		}
+		delays = append(delays, delay)
+	}
+	require.Equal(t, []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second}, delays)
+
+	// a worker that ran for a long time starts again from the beginning
+	delay, ok := p.next(time.Hour)
+	require.True(t, ok)
+	require.Equal(t, 5*time.Second, delay)
+
+	t.Run("disabled", func(t *testing.T) {
+		p := restartPolicy{}
+		_, ok := p.next(time.Second)
+		require.False(t, ok)
+	})
+
+	t.Run("maximum delay", func(t *testing.T) {
+		p := restartPolicy{maxAttempts: 20}
+		var delay time.Duration
+		for i := 0; i < 20; i++ {
+			delay, _ = p.next(time.Second)
+		}
+		require.Equal(t, maxRestartDelay, delay)
+	})
+}
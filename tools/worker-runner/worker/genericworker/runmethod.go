@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/logging"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/util"
 	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
@@ -13,6 +14,7 @@ import (
 // runMethod allows supporting both run-as-a-service and run-as-an-executable modes.
 type runMethod interface {
 	start(w *genericworker, state *run.State) (workerproto.Transport, error)
+	setProtocol(proto *workerproto.Protocol)
 	wait() error
 }
 
@@ -30,7 +32,16 @@ func (m *cmdRunMethod) start(w *genericworker, state *run.State) (workerproto.Tr
 	// path to generic-worker binary
 	cmd := exec.Command(w.wicfg.Path)
 	cmd.Env = os.Environ()
-	cmd.Stderr = os.Stderr
+	// send stderr to the log destination, also keeping its end in
+	// logging.Tail, where a crashing worker reports why
+	cmd.Stderr = logging.NewOutputWriter()
+	if w.wicfg.WorkDir != "" {
+		err := os.MkdirAll(w.wicfg.WorkDir, 0700)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Dir = w.wicfg.WorkDir
+	}
 
 	cmdStdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -59,6 +70,9 @@ func (m *cmdRunMethod) start(w *genericworker, state *run.State) (workerproto.Tr
 	return transp, nil
 }
 
+func (m *cmdRunMethod) setProtocol(proto *workerproto.Protocol) {
+}
+
 func (m *cmdRunMethod) wait() error {
 	return m.cmd.Wait()
 }
@@ -1,15 +1,22 @@
 package genericworker
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"log"
+	"net"
+	"os"
+	"os/signal"
 	"os/user"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/Microsoft/go-winio"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/crashloop"
 	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
 	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 )
@@ -19,54 +26,90 @@ func newServiceRunMethod() (runMethod, error) {
 }
 
 type serviceRunMethod struct {
-	serviceName string
-	mgr         *mgr.Mgr
+	serviceName  string
+	protocolPipe string
+	mgr          *mgr.Mgr
+	proto        *workerproto.Protocol
+	bridge       *pipeBridge
+	restart      restartPolicy
+
+	// the listener for the current run of the service, and a channel that is
+	// closed when its connection (if any) is finished
+	listener net.Listener
+	served   chan struct{}
+
+	// set when the host is shutting down, in which case the service is not
+	// restarted
+	shuttingDown atomic.Bool
+
+	// closed, with err set, when the service has stopped for good
+	done chan struct{}
+	err  error
 }
 
 func (m *serviceRunMethod) start(w *genericworker, state *run.State) (workerproto.Transport, error) {
 	var err error
 
 	m.serviceName = w.wicfg.Service
+	m.protocolPipe = w.wicfg.ProtocolPipe
+	if m.protocolPipe == "" {
+		m.protocolPipe = `\\.\pipe\generic-worker`
+	}
+	m.restart.maxAttempts = w.wicfg.ServiceRestartAttempts
 	m.mgr, err = mgr.Connect()
 	if err != nil {
 		return nil, fmt.Errorf("Error opening service manager: %s", err)
 	}
 
-	s, err := m.mgr.OpenService(m.serviceName)
+	var transp workerproto.Transport
+	m.bridge, transp = newPipeBridge()
+
+	err = m.startService()
 	if err != nil {
-		return nil, fmt.Errorf("Error getting service %s: %s", m.serviceName, err)
+		return nil, err
 	}
-	defer s.Close()
 
-	err = s.Start()
+	m.done = make(chan struct{})
+	go m.supervise()
+
+	m.handleShutdown()
+
+	return transp, nil
+}
+
+func (m *serviceRunMethod) setProtocol(proto *workerproto.Protocol) {
+	m.proto = proto
+	proto.AddCapability("graceful-termination")
+}
+
+// Listen on the protocol pipe and start the service
+func (m *serviceRunMethod) startService() error {
+	err := m.listen()
 	if err != nil {
-		return nil, fmt.Errorf("Error starting service %s: %s", m.serviceName, err)
+		return err
 	}
 
-	// connect the transport to the named
-	inputReader, inputWriter := io.Pipe()
-	outputReader, outputWriter := io.Pipe()
-	transp := workerproto.NewPipeTransport(inputReader, outputWriter)
-
-	err = m.connectPipeToProtocol(w.wicfg.ProtocolPipe, inputWriter, outputReader)
+	s, err := m.mgr.OpenService(m.serviceName)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("Error getting service %s: %s", m.serviceName, err)
 	}
+	defer s.Close()
 
-	return transp, nil
+	err = s.Start()
+	if err != nil {
+		return fmt.Errorf("Error starting service %s: %s", m.serviceName, err)
+	}
+	return nil
 }
 
-// Connect the configured named pipe to the worker-runner workerproto.  This opens
-// the named pipe and listens for a single connection, which it considers to be
+// Listen on the configured named pipe, and connect it to the worker-runner
+// protocol.  This listens for a single connection, which it considers to be
 // from the worker, and does not acccept any further connections.  Aside from
 // careful configuration of the security descriptor, this provides an
 // additional layer of assurance that this pipe is not used to manipulate the
-// worker or worker-runner.
-func (m *serviceRunMethod) connectPipeToProtocol(protocolPipe string, inputWriter io.WriteCloser, outputReader io.Reader) error {
-	if protocolPipe == "" {
-		protocolPipe = `\\.\pipe\generic-worker`
-	}
-
+// worker or worker-runner.  When the service is restarted, the pipe is
+// listened on again for the restarted worker.
+func (m *serviceRunMethod) listen() error {
 	// Construct a security-descriptor that allows all access to the current
 	// user and to "Local System" (shorthand SY)
 	cu, err := user.Current()
@@ -81,56 +124,143 @@ func (m *serviceRunMethod) connectPipeToProtocol(protocolPipe string, inputWrite
 		// (A;;GA;;;SY) -- GENERIC_ALL access for "Local System"
 		SecurityDescriptor: fmt.Sprintf("D:P(A;;GA;;;%s)(A;;GA;;;SY)", cu.Uid),
 	}
-	listener, err := winio.ListenPipe(protocolPipe, &c)
+	listener, err := winio.ListenPipe(m.protocolPipe, &c)
 	if err != nil {
 		return fmt.Errorf("Error setting up protocolPipe: %s", err)
 	}
 
+	served := make(chan struct{})
 	go func() {
+		defer close(served)
 		conn, err := listener.Accept()
+		listener.Close()
 		if err != nil {
 			// since this occurs asynchronously, there's not much we can do
 			// here other than log about it
-			log.Printf("Error accepting connection on protocolPipe: %s", err)
-			listener.Close()
+			if !errors.Is(err, winio.ErrPipeListenerClosed) {
+				log.Printf("Error accepting connection on protocolPipe: %s", err)
+			}
 			return
 		}
 
-		log.Printf("Worker connecteed on protocolPipe")
-		listener.Close()
-
-		// copy bidirectionally between this connection and the protocol transport, and do not
-		// accept any further connections.  When the pipe is closed, we close the inputWriter.
-		go io.Copy(conn, outputReader)
-		go func() {
-			io.Copy(inputWriter, conn)
-			inputWriter.Close()
-		}()
+		log.Printf("Worker connected on protocolPipe")
+		m.bridge.serve(conn)
 	}()
 
+	m.listener = listener
+	m.served = served
 	return nil
 }
 
-func (m *serviceRunMethod) wait() error {
-	defer m.mgr.Disconnect()
+// Wait for the service to stop, restarting it if it crashed, until it stops
+// for good.
+func (m *serviceRunMethod) supervise() {
+	defer close(m.done)
+	defer m.bridge.close()
 
-	// poll until the service stops
+	for {
+		started := time.Now()
+		exitCode, err := m.waitForStop()
+
+		// stop listening if the worker never connected, and give the
+		// connection a chance to deliver any final messages
+		m.listener.Close()
+		select {
+		case <-m.served:
+		case <-time.After(5 * time.Second):
+		}
+
+		if err != nil {
+			m.err = err
+			return
+		}
+		if m.shuttingDown.Load() || !crashloop.IsCrashExitCode(int(exitCode)) {
+			return
+		}
+
+		delay, ok := m.restart.next(time.Since(started))
+		if !ok {
+			log.Printf("Service %s crashed with exit code %d; not restarting it after %d attempts", m.serviceName, exitCode, m.restart.maxAttempts)
+			m.err = &serviceExitError{m.serviceName, exitCode}
+			return
+		}
+		log.Printf("Service %s crashed with exit code %d; restarting it in %s (attempt %d of %d)", m.serviceName, exitCode, delay, m.restart.attempts, m.restart.maxAttempts)
+		time.Sleep(delay)
+		if m.shuttingDown.Load() {
+			return
+		}
+
+		err = m.startService()
+		if err != nil {
+			m.err = err
+			return
+		}
+	}
+}
+
+// Poll until the service stops, returning its exit code
+func (m *serviceRunMethod) waitForStop() (uint32, error) {
 	for {
 		s, err := m.mgr.OpenService(m.serviceName)
 		if err != nil {
-			return fmt.Errorf("Error while polling service %s status: %s", m.serviceName, err)
+			return 0, fmt.Errorf("Error while polling service %s status: %s", m.serviceName, err)
 		}
 		status, err := s.Query()
 		s.Close()
 
 		if err != nil {
-			return fmt.Errorf("Error querying service %s status: %s", m.serviceName, err)
+			return 0, fmt.Errorf("Error querying service %s status: %s", m.serviceName, err)
 		}
 		if status.State != svc.StartPending && status.State != svc.Running {
-			break
+			if status.Win32ExitCode == uint32(windows.ERROR_SERVICE_SPECIFIC_ERROR) {
+				return status.ServiceSpecificExitCode, nil
+			}
+			return status.Win32ExitCode, nil
 		}
 
 		time.Sleep(2 * time.Second)
 	}
-	return nil
+}
+
+// When the host is shutting down, Windows signals console processes with
+// CTRL_SHUTDOWN_EVENT, which arrives here as SIGTERM.  Ask the worker to stop
+// and resolve its tasks as worker-shutdown, rather than leaving them to expire
+// when the host goes away, and do not restart it.
+func (m *serviceRunMethod) handleShutdown() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	go func() {
+		for range signals {
+			log.Printf("Host is shutting down; requesting graceful termination of the worker")
+			m.shuttingDown.Store(true)
+			if m.proto != nil && m.proto.Capable("graceful-termination") {
+				m.proto.Send(workerproto.Message{
+					Type: "graceful-termination",
+					Properties: map[string]interface{}{
+						"finish-tasks": false,
+					},
+				})
+			}
+		}
+	}()
+}
+
+// serviceExitError is returned from wait when the service crashed
+type serviceExitError struct {
+	serviceName string
+	exitCode    uint32
+}
+
+func (e *serviceExitError) Error() string {
+	return fmt.Sprintf("service %s crashed with exit code %d", e.serviceName, e.exitCode)
+}
+
+func (e *serviceExitError) ExitCode() int {
+	return int(e.exitCode)
+}
+
+func (m *serviceRunMethod) wait() error {
+	defer m.mgr.Disconnect()
+	<-m.done
+	return m.err
 }
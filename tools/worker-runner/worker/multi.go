@@ -0,0 +1,181 @@
+package worker
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/worker/worker"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+// multiWorker runs several instances of a worker implementation on the same
+// host, as configured in the `multipleWorkers` section of the runner
+// configuration, presenting them to the rest of worker-runner as a single
+// worker.
+type multiWorker struct {
+	config  *cfg.MultipleWorkersConfig
+	workers []worker.Worker
+	// the state for each worker, derived from the worker-runner state
+	states []*run.State
+}
+
+// Append the worker index to the given path, before its extension if any, so
+// that each worker has its own file or directory.
+func partitionPath(path string, index int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), index, ext)
+}
+
+func newMultiWorker(runnercfg *cfg.RunnerConfig, constructor func(*cfg.RunnerConfig) (worker.Worker, error)) (worker.Worker, error) {
+	config := runnercfg.MultipleWorkers
+	if runnercfg.CacheOverRestarts != "" {
+		return nil, fmt.Errorf("multipleWorkers cannot be used with cacheOverRestarts")
+	}
+	if len(config.WorkerConfigs) > config.Count {
+		return nil, fmt.Errorf("multipleWorkers.workerConfigs has more entries than multipleWorkers.count")
+	}
+
+	mw := &multiWorker{config: config}
+	for i := 0; i < config.Count; i++ {
+		wicfg := cfg.WorkerImplementationConfig{
+			Implementation: runnercfg.WorkerImplementation.Implementation,
+			Data:           make(map[string]interface{}),
+		}
+		for k, v := range runnercfg.WorkerImplementation.Data {
+			wicfg.Data[k] = v
+		}
+		for _, key := range config.WorkerPaths {
+			v, ok := wicfg.Data[key]
+			if !ok {
+				continue
+			}
+			path, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("worker.%s must be a string to be partitioned between workers", key)
+			}
+			wicfg.Data[key] = partitionPath(path, i)
+		}
+
+		workerRunnercfg := *runnercfg
+		workerRunnercfg.WorkerImplementation = wicfg
+		w, err := constructor(&workerRunnercfg)
+		if err != nil {
+			return nil, err
+		}
+		mw.workers = append(mw.workers, w)
+	}
+	return mw, nil
+}
+
+// Derive the state for the worker with the given index.  Each worker has a
+// workerId derived from that of the host, and its own configuration.
+func (mw *multiWorker) deriveState(state *run.State, index int) (*run.State, error) {
+	workerConfig := state.WorkerConfig
+	if index < len(mw.config.WorkerConfigs) {
+		workerConfig = workerConfig.Merge(mw.config.WorkerConfigs[index])
+	}
+	for _, key := range mw.config.WorkerConfigPaths {
+		v, err := workerConfig.Get(key)
+		if err != nil {
+			continue
+		}
+		path, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("worker config %s must be a string to be partitioned between workers", key)
+		}
+		workerConfig, err = workerConfig.Set(key, partitionPath(path, index))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &run.State{
+		RootURL:            state.RootURL,
+		Credentials:        state.Credentials,
+		CredentialsExpire:  state.CredentialsExpire,
+		RegistrationSecret: state.RegistrationSecret,
+		WorkerPoolID:       state.WorkerPoolID,
+		WorkerGroup:        state.WorkerGroup,
+		WorkerID:           fmt.Sprintf("%s-%d", state.WorkerID, index),
+		ProviderID:         state.ProviderID,
+		ProviderMetadata:   state.ProviderMetadata,
+		WorkerConfig:       workerConfig,
+		RunnerOptions:      state.RunnerOptions,
+		WorkerLocation:     state.WorkerLocation,
+	}, nil
+}
+
+func (mw *multiWorker) ConfigureRun(state *run.State) error {
+	state.RLock()
+	for i := range mw.workers {
+		workerState, err := mw.deriveState(state, i)
+		if err != nil {
+			state.RUnlock()
+			return err
+		}
+		mw.states = append(mw.states, workerState)
+	}
+	state.RUnlock()
+
+	for i, w := range mw.workers {
+		err := w.ConfigureRun(mw.states[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mw *multiWorker) UseCachedRun(state *run.State) error {
+	// newMultiWorker does not allow cacheOverRestarts
+	return fmt.Errorf("multipleWorkers cannot be used with cacheOverRestarts")
+}
+
+func (mw *multiWorker) StartWorker(state *run.State) (workerproto.Transport, error) {
+	transports := make([]workerproto.Transport, 0, len(mw.workers))
+	for i, w := range mw.workers {
+		log.Printf("Starting worker %s", mw.states[i].WorkerID)
+		transp, err := w.StartWorker(mw.states[i])
+		if err != nil {
+			return nil, err
+		}
+		transports = append(transports, transp)
+	}
+	return workerproto.NewMultiTransport(transports...), nil
+}
+
+func (mw *multiWorker) SetProtocol(proto *workerproto.Protocol) {
+	for _, w := range mw.workers {
+		w.SetProtocol(proto)
+	}
+}
+
+// Wait for all of the workers to terminate, returning the first error.
+func (mw *multiWorker) Wait() error {
+	errs := make([]error, len(mw.workers))
+	var wg sync.WaitGroup
+	for i, w := range mw.workers {
+		wg.Add(1)
+		go func(i int, w worker.Worker) {
+			defer wg.Done()
+			errs[i] = w.Wait()
+			if errs[i] != nil {
+				log.Printf("Worker %s exited with error: %v", mw.states[i].WorkerID, errs[i])
+			} else {
+				log.Printf("Worker %s exited", mw.states[i].WorkerID)
+			}
+		}(i, w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/cfg"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/run"
+	"github.com/taskcluster/taskcluster/v60/tools/worker-runner/worker/worker"
+	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
+)
+
+type fakeWorker struct {
+	runnercfg  *cfg.RunnerConfig
+	configured *run.State
+	started    bool
+	waitErr    error
+}
+
+func (w *fakeWorker) ConfigureRun(state *run.State) error {
+	w.configured = state
+	return nil
+}
+
+func (w *fakeWorker) UseCachedRun(state *run.State) error {
+	return nil
+}
+
+func (w *fakeWorker) StartWorker(state *run.State) (workerproto.Transport, error) {
+	w.started = true
+	return workerproto.NewNullTransport(), nil
+}
+
+func (w *fakeWorker) SetProtocol(proto *workerproto.Protocol) {
+}
+
+func (w *fakeWorker) Wait() error {
+	return w.waitErr
+}
+
+func setupMulti(t *testing.T, config *cfg.MultipleWorkersConfig) (*multiWorker, []*fakeWorker) {
+	t.Helper()
+	runnercfg := &cfg.RunnerConfig{
+		WorkerImplementation: cfg.WorkerImplementationConfig{
+			Implementation: "fake",
+			Data: map[string]interface{}{
+				"configPath": "/etc/worker/config.yaml",
+				"path":       "/usr/bin/worker",
+			},
+		},
+		MultipleWorkers: config,
+	}
+	var fakes []*fakeWorker
+	w, err := newMultiWorker(runnercfg, func(runnercfg *cfg.RunnerConfig) (worker.Worker, error) {
+		fake := &fakeWorker{runnercfg: runnercfg}
+		fakes = append(fakes, fake)
+		return fake, nil
+	})
+	require.NoError(t, err)
+	return w.(*multiWorker), fakes
+}
+
+func TestPartitionPath(t *testing.T) {
+	require.Equal(t, "/etc/worker/config-1.yaml", partitionPath("/etc/worker/config.yaml", 1))
+	require.Equal(t, "/var/lib/tasks-0", partitionPath("/var/lib/tasks", 0))
+}
+
+func TestMultiWorker(t *testing.T) {
+	perWorker, err := cfg.NewWorkerConfig().Set("gpu", "1")
+	require.NoError(t, err)
+	mw, fakes := setupMulti(t, &cfg.MultipleWorkersConfig{
+		Count:             2,
+		WorkerPaths:       []string{"configPath"},
+		WorkerConfigPaths: []string{"tasksDir", "missing"},
+		WorkerConfigs:     []*cfg.WorkerConfig{nil, perWorker},
+	})
+	require.Len(t, fakes, 2)
+	require.Equal(t, "/etc/worker/config-0.yaml", fakes[0].runnercfg.WorkerImplementation.Data["configPath"])
+	require.Equal(t, "/etc/worker/config-1.yaml", fakes[1].runnercfg.WorkerImplementation.Data["configPath"])
+	require.Equal(t, "/usr/bin/worker", fakes[1].runnercfg.WorkerImplementation.Data["path"])
+
+	workerConfig, err := cfg.NewWorkerConfig().Set("tasksDir", "/tasks")
+	require.NoError(t, err)
+	state := &run.State{
+		WorkerID:     "i-123",
+		WorkerConfig: workerConfig,
+	}
+	require.NoError(t, mw.ConfigureRun(state))
+
+	require.Equal(t, "i-123-0", fakes[0].configured.WorkerID)
+	require.Equal(t, "/tasks-0", fakes[0].configured.WorkerConfig.MustGet("tasksDir"))
+	require.False(t, fakes[0].configured.WorkerConfig.Has("gpu"))
+	require.Equal(t, "i-123-1", fakes[1].configured.WorkerID)
+	require.Equal(t, "/tasks-1", fakes[1].configured.WorkerConfig.MustGet("tasksDir"))
+	require.Equal(t, "1", fakes[1].configured.WorkerConfig.MustGet("gpu"))
+
+	// the host's state is not changed
+	require.Equal(t, "i-123", state.WorkerID)
+	require.Equal(t, "/tasks", state.WorkerConfig.MustGet("tasksDir"))
+
+	transp, err := mw.StartWorker(state)
+	require.NoError(t, err)
+	require.True(t, fakes[0].started)
+	require.True(t, fakes[1].started)
+	_, ok := transp.Recv()
+	require.False(t, ok, "combined transport should reach EOF")
+
+	fakes[1].waitErr = fmt.Errorf("uhoh")
+	require.Equal(t, fmt.Errorf("uhoh"), mw.Wait())
+}
+
+func TestMultiWorkerCacheOverRestarts(t *testing.T) {
+	runnercfg := &cfg.RunnerConfig{
+		WorkerImplementation: cfg.WorkerImplementationConfig{Implementation: "dummy"},
+		MultipleWorkers:      &cfg.MultipleWorkersConfig{Count: 2},
+		CacheOverRestarts:    "/var/cache/runner.json",
+	}
+	_, err := New(runnercfg)
+	require.EqualError(t, err, "multipleWorkers cannot be used with cacheOverRestarts")
+}
@@ -32,6 +32,9 @@ func New(runnercfg *cfg.RunnerConfig) (worker.Worker, error) {
 	if !ok {
 		return nil, fmt.Errorf("unrecognized worker implementation %s", runnercfg.WorkerImplementation.Implementation)
 	}
+	if runnercfg.MultipleWorkers != nil && runnercfg.MultipleWorkers.Count > 1 {
+		return newMultiWorker(runnercfg, pi.constructor)
+	}
 	return pi.constructor(runnercfg)
 }
 
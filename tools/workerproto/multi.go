@@ -0,0 +1,171 @@
+package workerproto
+
+import (
+	"sync"
+)
+
+// MultiTransport combines the transports of several workers into one, so that
+// a single Protocol can communicate with all of them.
+//
+// Messages sent on a MultiTransport are sent to every worker.  Messages
+// received from any worker are passed along, with the following exceptions:
+//
+//   - `hello` messages are combined into a single `hello`, giving the
+//     capabilities common to all workers, once every worker has sent `hello` or
+//     exited.
+//   - `shutdown` messages are passed along only once every worker has sent
+//     `shutdown` or exited, so that one idle worker does not shut down the
+//     host while others are still working.
+//
+// Recv returns EOF once all of the workers' transports have done so.
+type MultiTransport struct {
+	transports []Transport
+	input      chan Message
+
+	mu sync.Mutex
+	// per-worker state, indexed like transports
+	eof      []bool
+	hello    []*capabilities
+	shutdown []bool
+	// true once the combined hello or shutdown has been passed along
+	helloSent    bool
+	shutdownSent bool
+}
+
+// Create a new MultiTransport combining the given transports.
+func NewMultiTransport(transports ...Transport) *MultiTransport {
+	transp := &MultiTransport{
+		transports: transports,
+		input:      make(chan Message, 10),
+		eof:        make([]bool, len(transports)),
+		hello:      make([]*capabilities, len(transports)),
+		shutdown:   make([]bool, len(transports)),
+	}
+
+	var wg sync.WaitGroup
+	for i, t := range transports {
+		wg.Add(1)
+		go func(i int, t Transport) {
+			defer wg.Done()
+			for {
+				msg, ok := t.Recv()
+				if !ok {
+					transp.handleEOF(i)
+					return
+				}
+				transp.handle(i, msg)
+			}
+		}(i, t)
+	}
+	go func() {
+		wg.Wait()
+		close(transp.input)
+	}()
+
+	return transp
+}
+
+func (transp *MultiTransport) handle(i int, msg Message) {
+	transp.mu.Lock()
+	defer transp.mu.Unlock()
+
+	switch msg.Type {
+	case "hello":
+		transp.hello[i] = FromCapabilitiesList(listOfStrings(msg.Properties["capabilities"]))
+		transp.maybeSendHello()
+	case "shutdown":
+		transp.shutdown[i] = true
+		transp.maybeSendShutdown()
+	default:
+		transp.input <- msg
+	}
+}
+
+func (transp *MultiTransport) handleEOF(i int) {
+	transp.mu.Lock()
+	defer transp.mu.Unlock()
+
+	transp.eof[i] = true
+	transp.maybeSendHello()
+	transp.maybeSendShutdown()
+}
+
+// Send the combined hello, if every worker has said hello or exited.  Workers
+// that exited without saying hello do not limit the capabilities.  Called with
+// mu held.
+func (transp *MultiTransport) maybeSendHello() {
+	if transp.helloSent {
+		return
+	}
+	var caps *capabilities
+	for i := range transp.transports {
+		if transp.hello[i] == nil {
+			if !transp.eof[i] {
+				return
+			}
+			continue
+		}
+		if caps == nil {
+			caps = FromCapabilitiesList(transp.hello[i].List())
+		} else {
+			caps.LimitTo(transp.hello[i])
+		}
+	}
+	if caps == nil {
+		// no worker said hello
+		return
+	}
+	transp.helloSent = true
+	transp.input <- Message{
+		Type: "hello",
+		Properties: map[string]interface{}{
+			"capabilities": stringsToInterfaces(caps.List()),
+		},
+	}
+}
+
+// Pass along shutdown, if every worker has asked for it or exited, and at
+// least one asked for it.  Called with mu held.
+func (transp *MultiTransport) maybeSendShutdown() {
+	if transp.shutdownSent {
+		return
+	}
+	requested := false
+	for i := range transp.transports {
+		if transp.shutdown[i] {
+			requested = true
+		} else if !transp.eof[i] {
+			return
+		}
+	}
+	if !requested {
+		return
+	}
+	transp.shutdownSent = true
+	transp.input <- Message{
+		Type:       "shutdown",
+		Properties: map[string]interface{}{},
+	}
+}
+
+// convert a list of strings to the form produced by decoding JSON
+func stringsToInterfaces(strs []string) []interface{} {
+	rv := make([]interface{}, len(strs))
+	for i, s := range strs {
+		rv[i] = s
+	}
+	return rv
+}
+
+// workerproto.Transport interface
+
+func (transp *MultiTransport) Send(msg Message) {
+	for _, t := range transp.transports {
+		t.Send(msg)
+	}
+}
+
+func (transp *MultiTransport) Recv() (Message, bool) {
+	msg, ok := <-transp.input
+	return msg, ok
+}
@@ -0,0 +1,122 @@
+package workerproto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// a transport fed from a channel, recording sent messages
+type chanTransport struct {
+	recv chan Message
+	sent chan Message
+}
+
+func newChanTransport() *chanTransport {
+	return &chanTransport{
+		recv: make(chan Message, 10),
+		sent: make(chan Message, 10),
+	}
+}
+
+func (transp *chanTransport) Send(msg Message) {
+	transp.sent <- msg
+}
+
+func (transp *chanTransport) Recv() (Message, bool) {
+	msg, ok := <-transp.recv
+	return msg, ok
+}
+
+func hello(caps ...string) Message {
+	return Message{
+		Type: "hello",
+		Properties: map[string]interface{}{
+			"capabilities": stringsToInterfaces(caps),
+		},
+	}
+}
+
+func requireNoMessage(t *testing.T, transp *MultiTransport) {
+	t.Helper()
+	select {
+	case msg := <-transp.input:
+		t.Fatalf("unexpected message %#v", msg)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestMultiTransportSend(t *testing.T) {
+	t1, t2 := newChanTransport(), newChanTransport()
+	transp := NewMultiTransport(t1, t2)
+
+	transp.Send(Message{Type: "welcome"})
+
+	require.Equal(t, "welcome", (<-t1.sent).Type)
+	require.Equal(t, "welcome", (<-t2.sent).Type)
+}
+
+func TestMultiTransportRecv(t *testing.T) {
+	t1, t2 := newChanTransport(), newChanTransport()
+	transp := NewMultiTransport(t1, t2)
+
+	t2.recv <- Message{Type: "log"}
+	msg, ok := transp.Recv()
+	require.True(t, ok)
+	require.Equal(t, "log", msg.Type)
+
+	close(t1.recv)
+	requireNoMessage(t, transp)
+	close(t2.recv)
+	_, ok = transp.Recv()
+	require.False(t, ok)
+}
+
+func TestMultiTransportHello(t *testing.T) {
+	t1, t2, t3 := newChanTransport(), newChanTransport(), newChanTransport()
+	transp := NewMultiTransport(t1, t2, t3)
+
+	t1.recv <- hello("a", "b", "c")
+	t2.recv <- hello("b", "c")
+	requireNoMessage(t, transp)
+
+	// a worker which exits without saying hello does not limit capabilities
+	close(t3.recv)
+	msg, ok := transp.Recv()
+	require.True(t, ok)
+	require.Equal(t, hello("b", "c"), msg)
+}
+
+func TestMultiTransportShutdown(t *testing.T) {
+	t1, t2, t3 := newChanTransport(), newChanTransport(), newChanTransport()
+	transp := NewMultiTransport(t1, t2, t3)
+
+	t1.recv <- Message{Type: "shutdown"}
+	requireNoMessage(t, transp)
+	close(t2.recv)
+	requireNoMessage(t, transp)
+	t3.recv <- Message{Type: "shutdown"}
+
+	msg, ok := transp.Recv()
+	require.True(t, ok)
+	require.Equal(t, "shutdown", msg.Type)
+}
+
+func TestMultiTransportWithProtocol(t *testing.T) {
+	t1, t2 := newChanTransport(), newChanTransport()
+	prot := NewProtocol(NewMultiTransport(t1, t2))
+	prot.AddCapability("a")
+	prot.AddCapability("b")
+	prot.Start(false)
+
+	require.Equal(t, "welcome", (<-t1.sent).Type)
+	require.Equal(t, "welcome", (<-t2.sent).Type)
+
+	t1.recv <- hello("a", "b")
+	t2.recv <- hello("a")
+	prot.WaitUntilInitialized()
+
+	require.True(t, prot.Capable("a"))
+	require.False(t, prot.Capable("b"))
+}
@@ -19,6 +19,7 @@ import (
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/artifacts"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/fileutil"
 )
 
 var (
@@ -93,6 +94,41 @@ func (task *TaskRun) PayloadArtifacts() []artifacts.TaskArtifact {
 							Path:         subPath,
 						},
 					)
+				// Symbolic links and junctions are not followed when walking
+				// directory artifacts, so that files outside of the task
+				// directory are never uploaded, and cyclic links (common in
+				// node_modules trees) do not cause errors. Links to files
+				// inside the task directory are uploaded as regular files.
+				case fileutil.IsLink(info):
+					fullPath := filepath.Join(taskContext.TaskDir, subPath)
+					inside, err := fileutil.WithinDir(taskContext.TaskDir, fullPath)
+					switch {
+					case err != nil:
+						payloadArtifacts = append(
+							payloadArtifacts,
+							&artifacts.ErrorArtifact{
+								BaseArtifact: b,
+								Message:      fmt.Sprintf("Could not resolve link '%s': %s", fullPath, err),
+								Reason:       "file-missing-on-worker",
+								Path:         subPath,
+							},
+						)
+					case !inside:
+						payloadArtifacts = append(
+							payloadArtifacts,
+							&artifacts.ErrorArtifact{
+								BaseArtifact: b,
+								Message:      fmt.Sprintf("Link '%s' resolves to a location outside of the task directory", fullPath),
+								Reason:       "invalid-resource-on-worker",
+								Path:         subPath,
+							},
+						)
+					default:
+						if target, err := os.Stat(fullPath); err == nil && target.IsDir() {
+							return nil
+						}
+						payloadArtifacts = append(payloadArtifacts, resolve(b, "file", subPath, artifact.ContentType, artifact.ContentEncoding))
+					}
 				case info.IsDir():
 					if errArtifact := resolve(b, "directory", subPath, artifact.ContentType, artifact.ContentEncoding); errArtifact != nil {
 						payloadArtifacts = append(payloadArtifacts, errArtifact)
@@ -0,0 +1,82 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/artifacts"
+)
+
+// Symbolic links inside directory artifacts should only be uploaded if they
+// resolve to files inside the task directory, and links to directories should
+// not be followed.
+func TestDirectoryArtifactWithLinks(t *testing.T) {
+
+	setup(t)
+	dir := filepath.Join(taskContext.TaskDir, t.Name(), "links")
+	outsideFile := filepath.Join(t.TempDir(), "secret.txt")
+	for _, f := range []string{filepath.Join(dir, "inside.txt"), outsideFile} {
+		err := os.MkdirAll(filepath.Dir(f), 0777)
+		if err != nil {
+			t.Fatalf("Could not create directory: %v", err)
+		}
+		err = os.WriteFile(f, []byte("hello"), 0666)
+		if err != nil {
+			t.Fatalf("Could not write file %v: %v", f, err)
+		}
+	}
+	links := map[string]string{
+		"link-inside":  "inside.txt",
+		"link-outside": outsideFile,
+		"loop":         ".",
+	}
+	for link, target := range links {
+		err := os.Symlink(target, filepath.Join(dir, link))
+		if err != nil {
+			t.Fatalf("Could not create symbolic link %v: %v", link, err)
+		}
+	}
+
+	validateArtifacts(t,
+
+		// what appears in task payload
+		[]Artifact{{
+			Expires: inAnHour,
+			Path:    t.Name() + "/links",
+			Type:    "directory",
+		}},
+
+		// what we expect to discover on file system
+		[]artifacts.TaskArtifact{
+			&artifacts.S3Artifact{
+				BaseArtifact: &artifacts.BaseArtifact{
+					Name:    t.Name() + "/links/inside.txt",
+					Expires: inAnHour,
+				},
+				ContentType:     "text/plain; charset=utf-8",
+				ContentEncoding: "gzip",
+				Path:            filepath.Join(dir, "inside.txt"),
+			},
+			&artifacts.S3Artifact{
+				BaseArtifact: &artifacts.BaseArtifact{
+					Name:    t.Name() + "/links/link-inside",
+					Expires: inAnHour,
+				},
+				ContentType:     "application/octet-stream",
+				ContentEncoding: "gzip",
+				Path:            filepath.Join(dir, "link-inside"),
+			},
+			&artifacts.ErrorArtifact{
+				BaseArtifact: &artifacts.BaseArtifact{
+					Name:    t.Name() + "/links/link-outside",
+					Expires: inAnHour,
+				},
+				Path:    filepath.Join(t.Name(), "links", "link-outside"),
+				Message: "Link '" + filepath.Join(dir, "link-outside") + "' resolves to a location outside of the task directory",
+				Reason:  "invalid-resource-on-worker",
+			},
+		})
+}
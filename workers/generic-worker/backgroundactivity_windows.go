@@ -0,0 +1,325 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/fileutil"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// backgroundServices are the Windows services that are disabled while tasks
+// run: Windows Update, the Update Orchestrator, and telemetry.
+var backgroundServices = []string{
+	"wuauserv",
+	"UsoSvc",
+	"DiagTrack",
+	"dmwappushservice",
+}
+
+// backgroundScheduledTasks are the scheduled tasks that are disabled while
+// tasks run: automatic maintenance, Defender scans, update scans, and
+// telemetry collection.
+var backgroundScheduledTasks = []string{
+	`\Microsoft\Windows\TaskScheduler\Idle Maintenance`,
+	`\Microsoft\Windows\TaskScheduler\Maintenance Configurator`,
+	`\Microsoft\Windows\TaskScheduler\Regular Maintenance`,
+	`\Microsoft\Windows\Windows Defender\Windows Defender Cache Maintenance`,
+	`\Microsoft\Windows\Windows Defender\Windows Defender Cleanup`,
+	`\Microsoft\Windows\Windows Defender\Windows Defender Scheduled Scan`,
+	`\Microsoft\Windows\Windows Defender\Windows Defender Verification`,
+	`\Microsoft\Windows\UpdateOrchestrator\Schedule Scan`,
+	`\Microsoft\Windows\Application Experience\Microsoft Compatibility Appraiser`,
+	`\Microsoft\Windows\Customer Experience Improvement Program\Consolidator`,
+}
+
+// backgroundActivityStateFile records the services and scheduled tasks that
+// are paused, so that they are re-enabled if the worker stops before the task
+// completes
+const backgroundActivityStateFile = "background-activity.json"
+
+// BackgroundActivityFeature pauses Windows Update, Defender scans, automatic
+// maintenance and telemetry while each task runs, when config setting
+// suppressBackgroundActivity is true, since they can stall tasks for several
+// minutes. Everything is re-enabled when the task completes, so that the
+// worker can catch up while it is idle.
+type BackgroundActivityFeature struct {
+	controller backgroundActivityController
+	stateFile  string
+	// services and scheduled tasks that are paused, and need re-enabling
+	paused backgroundActivityState
+}
+
+// backgroundActivityController pauses and resumes services and scheduled
+// tasks.
+type backgroundActivityController interface {
+	// PauseService disables and stops the named service, returning its
+	// previous state, or nil if the service does not exist or is already
+	// disabled.
+	PauseService(name string) (*pausedService, error)
+	ResumeService(paused pausedService) error
+	// DisableScheduledTask returns true if the named scheduled task was
+	// enabled, and has been disabled.
+	DisableScheduledTask(name string) (bool, error)
+	EnableScheduledTask(name string) error
+	// CancelDefenderScan cancels any Defender scan in progress.
+	CancelDefenderScan()
+}
+
+type backgroundActivityState struct {
+	Services       []pausedService `json:"services"`
+	ScheduledTasks []string        `json:"scheduledTasks"`
+}
+
+// pausedService records the state of a service before it was disabled
+type pausedService struct {
+	Name       string `json:"name"`
+	StartType  uint32 `json:"startType"`
+	WasRunning bool   `json:"wasRunning"`
+}
+
+func (feature *BackgroundActivityFeature) Name() string {
+	return "Background Activity Suppression"
+}
+
+// Initialise re-enables anything left paused by a previous worker that did
+// not complete its task, whether or not suppressBackgroundActivity is still
+// true.
+func (feature *BackgroundActivityFeature) Initialise() error {
+	if feature.controller == nil {
+		feature.controller = windowsBackgroundActivity{}
+	}
+	if feature.stateFile == "" {
+		feature.stateFile = backgroundActivityStateFile
+	}
+	if _, err := os.Stat(feature.stateFile); err != nil {
+		return nil
+	}
+	err := loadFromJSONFile(&feature.paused, feature.stateFile)
+	if err != nil {
+		return err
+	}
+	log.Printf("Re-enabling %v service(s) and %v scheduled task(s) paused by a previous task", len(feature.paused.Services), len(feature.paused.ScheduledTasks))
+	if failed := feature.resume(); len(failed) > 0 {
+		log.Printf("WARNING: could not re-enable %v", failed)
+	}
+	return feature.PersistState()
+}
+
+// PersistState records what is paused, removing the state file when nothing
+// is.
+func (feature *BackgroundActivityFeature) PersistState() error {
+	if len(feature.paused.Services) == 0 && len(feature.paused.ScheduledTasks) == 0 {
+		err := os.Remove(feature.stateFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	err := fileutil.WriteToFileAsJSON(&feature.paused, feature.stateFile)
+	if err != nil {
+		return err
+	}
+	return fileutil.SecureFiles(feature.stateFile)
+}
+
+func (feature *BackgroundActivityFeature) IsEnabled(task *TaskRun) bool {
+	return config.SuppressBackgroundActivity
+}
+
+func (feature *BackgroundActivityFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &BackgroundActivityTask{
+		task:    task,
+		feature: feature,
+	}
+}
+
+// resume re-enables the paused services and scheduled tasks, returning the
+// names of those that could not be re-enabled, which remain paused.
+func (feature *BackgroundActivityFeature) resume() (failed []string) {
+	c := feature.controller
+	services := feature.paused.Services
+	feature.paused.Services = nil
+	for _, paused := range services {
+		err := c.ResumeService(paused)
+		if err != nil {
+			log.Printf("WARNING: could not re-enable service %v: %v", paused.Name, err)
+			feature.paused.Services = append(feature.paused.Services, paused)
+			failed = append(failed, paused.Name)
+		}
+	}
+	scheduledTasks := feature.paused.ScheduledTasks
+	feature.paused.ScheduledTasks = nil
+	for _, name := range scheduledTasks {
+		err := c.EnableScheduledTask(name)
+		if err != nil {
+			log.Printf("WARNING: could not re-enable scheduled task %v: %v", name, err)
+			feature.paused.ScheduledTasks = append(feature.paused.ScheduledTasks, name)
+			failed = append(failed, name)
+		}
+	}
+	return
+}
+
+type BackgroundActivityTask struct {
+	task    *TaskRun
+	feature *BackgroundActivityFeature
+}
+
+func (bat *BackgroundActivityTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{}
+}
+
+func (bat *BackgroundActivityTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+// Start pauses background activity on a best effort basis: anything that
+// can't be paused is logged, but does not fail the task.
+func (bat *BackgroundActivityTask) Start() *CommandExecutionError {
+	c := bat.feature.controller
+	paused := &bat.feature.paused
+	services, scheduledTasks := len(paused.Services), len(paused.ScheduledTasks)
+	for _, name := range backgroundServices {
+		service, err := c.PauseService(name)
+		if err != nil {
+			log.Printf("WARNING: could not pause service %v: %v", name, err)
+			continue
+		}
+		if service != nil {
+			paused.Services = append(paused.Services, *service)
+		}
+	}
+	for _, name := range backgroundScheduledTasks {
+		disabled, err := c.DisableScheduledTask(name)
+		if err != nil {
+			log.Printf("WARNING: could not disable scheduled task %v: %v", name, err)
+			continue
+		}
+		if disabled {
+			paused.ScheduledTasks = append(paused.ScheduledTasks, name)
+		}
+	}
+	c.CancelDefenderScan()
+	err := bat.feature.PersistState()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[background activity] Could not record paused services and scheduled tasks: %v", err))
+	}
+	bat.task.Infof("[background activity] Paused %v service(s) and %v scheduled task(s) for the duration of the task", len(paused.Services)-services, len(paused.ScheduledTasks)-scheduledTasks)
+	return nil
+}
+
+func (bat *BackgroundActivityTask) Stop(err *ExecutionErrors) {
+	failed := bat.feature.resume()
+	if e := bat.feature.PersistState(); e != nil {
+		err.add(executionError(internalError, errored, fmt.Errorf("[background activity] Could not record paused services and scheduled tasks: %v", e)))
+	}
+	if len(failed) > 0 {
+		err.add(executionError(internalError, errored, fmt.Errorf("[background activity] Could not re-enable %v", failed)))
+	}
+}
+
+// windowsBackgroundActivity pauses and resumes background activity with the
+// Windows service manager and schtasks.
+type windowsBackgroundActivity struct {
+}
+
+func (windowsBackgroundActivity) PauseService(name string) (*pausedService, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(name)
+	if err != nil {
+		// not all services exist on all versions of Windows
+		return nil, nil
+	}
+	defer s.Close()
+	c, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	if c.StartType == mgr.StartDisabled {
+		return nil, nil
+	}
+	status, err := s.Query()
+	if err != nil {
+		return nil, err
+	}
+	paused := &pausedService{
+		Name:       name,
+		StartType:  c.StartType,
+		WasRunning: status.State == svc.Running,
+	}
+	c.StartType = mgr.StartDisabled
+	err = s.UpdateConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	if paused.WasRunning {
+		_, err = s.Control(svc.Stop)
+		if err != nil {
+			log.Printf("WARNING: could not stop service %v: %v", name, err)
+		}
+	}
+	return paused, nil
+}
+
+func (windowsBackgroundActivity) ResumeService(paused pausedService) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(paused.Name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	c, err := s.Config()
+	if err != nil {
+		return err
+	}
+	c.StartType = paused.StartType
+	err = s.UpdateConfig(c)
+	if err != nil {
+		return err
+	}
+	if paused.WasRunning {
+		return s.Start()
+	}
+	return nil
+}
+
+func (windowsBackgroundActivity) DisableScheduledTask(name string) (bool, error) {
+	out, err := host.CombinedOutput("schtasks", "/Query", "/TN", name, "/FO", "CSV", "/NH")
+	if err != nil {
+		// not all scheduled tasks exist on all versions of Windows
+		return false, nil
+	}
+	if strings.Contains(out, `"Disabled"`) {
+		return false, nil
+	}
+	err = host.Run("schtasks", "/Change", "/TN", name, "/Disable")
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (windowsBackgroundActivity) EnableScheduledTask(name string) error {
+	return host.Run("schtasks", "/Change", "/TN", name, "/Enable")
+}
+
+func (windowsBackgroundActivity) CancelDefenderScan() {
+	// this fails if there is no scan in progress, which is fine
+	mpCmdRun := filepath.Join(os.Getenv("ProgramFiles"), "Windows Defender", "MpCmdRun.exe")
+	_, _ = host.CombinedOutput(mpCmdRun, "-Cancel")
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/gwconfig"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// fakeBackgroundActivity simulates the services and scheduled tasks of a
+// worker, without changing the real ones.
+type fakeBackgroundActivity struct {
+	// start type of each service
+	services map[string]uint32
+	// whether each scheduled task is enabled
+	scheduledTasks map[string]bool
+}
+
+func newFakeBackgroundActivity() *fakeBackgroundActivity {
+	return &fakeBackgroundActivity{
+		services: map[string]uint32{
+			"wuauserv":  mgr.StartAutomatic,
+			"UsoSvc":    mgr.StartManual,
+			"DiagTrack": mgr.StartDisabled,
+		},
+		scheduledTasks: map[string]bool{
+			`\Microsoft\Windows\TaskScheduler\Idle Maintenance`:                      true,
+			`\Microsoft\Windows\Windows Defender\Windows Defender Scheduled Scan`:    true,
+			`\Microsoft\Windows\Windows Defender\Windows Defender Cache Maintenance`: false,
+		},
+	}
+}
+
+func (f *fakeBackgroundActivity) PauseService(name string) (*pausedService, error) {
+	startType, exists := f.services[name]
+	if !exists || startType == mgr.StartDisabled {
+		return nil, nil
+	}
+	f.services[name] = mgr.StartDisabled
+	return &pausedService{Name: name, StartType: startType}, nil
+}
+
+func (f *fakeBackgroundActivity) ResumeService(paused pausedService) error {
+	f.services[paused.Name] = paused.StartType
+	return nil
+}
+
+func (f *fakeBackgroundActivity) DisableScheduledTask(name string) (bool, error) {
+	if !f.scheduledTasks[name] {
+		return false, nil
+	}
+	f.scheduledTasks[name] = false
+	return true, nil
+}
+
+func (f *fakeBackgroundActivity) EnableScheduledTask(name string) error {
+	f.scheduledTasks[name] = true
+	return nil
+}
+
+func (f *fakeBackgroundActivity) CancelDefenderScan() {
+}
+
+func (f *fakeBackgroundActivity) assertRestored(t *testing.T) {
+	t.Helper()
+	expected := newFakeBackgroundActivity()
+	for name, startType := range expected.services {
+		if f.services[name] != startType {
+			t.Errorf("Expected service %v to have start type %v, but it has %v", name, startType, f.services[name])
+		}
+	}
+	for name, enabled := range expected.scheduledTasks {
+		if f.scheduledTasks[name] != enabled {
+			t.Errorf("Expected scheduled task %v to be enabled=%v, but it is enabled=%v", name, enabled, f.scheduledTasks[name])
+		}
+	}
+}
+
+func TestBackgroundActivityDisabledByConfig(t *testing.T) {
+	config = &gwconfig.Config{}
+	feature := &BackgroundActivityFeature{}
+	if feature.IsEnabled(&TaskRun{}) {
+		t.Fatal("Expected background activity suppression to be disabled when suppressBackgroundActivity is false")
+	}
+	config.SuppressBackgroundActivity = true
+	if !feature.IsEnabled(&TaskRun{}) {
+		t.Fatal("Expected background activity suppression to be enabled when suppressBackgroundActivity is true")
+	}
+}
+
+func TestBackgroundActivityPauseResume(t *testing.T) {
+	fake := newFakeBackgroundActivity()
+	stateFile := filepath.Join(t.TempDir(), backgroundActivityStateFile)
+	feature := &BackgroundActivityFeature{
+		controller: fake,
+		stateFile:  stateFile,
+	}
+	err := feature.Initialise()
+	if err != nil {
+		t.Fatalf("Could not initialise feature: %v", err)
+	}
+
+	taskFeature := feature.NewTaskFeature(&TaskRun{})
+	cee := taskFeature.Start()
+	if cee != nil {
+		t.Fatalf("Could not start feature: %v", cee)
+	}
+	for name, startType := range fake.services {
+		if startType != mgr.StartDisabled {
+			t.Errorf("Expected service %v to be disabled while the task runs", name)
+		}
+	}
+	for name, enabled := range fake.scheduledTasks {
+		if enabled {
+			t.Errorf("Expected scheduled task %v to be disabled while the task runs", name)
+		}
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		t.Fatalf("Expected paused services and scheduled tasks to be recorded in %v: %v", stateFile, err)
+	}
+
+	execErrors := &ExecutionErrors{}
+	taskFeature.Stop(execErrors)
+	if execErrors.Occurred() {
+		t.Fatalf("Could not stop feature: %v", execErrors.Error())
+	}
+	fake.assertRestored(t)
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Fatalf("Expected %v to be removed once everything is re-enabled, but got: %v", stateFile, err)
+	}
+}
+
+func TestBackgroundActivityResumedAfterRestart(t *testing.T) {
+	fake := newFakeBackgroundActivity()
+	stateFile := filepath.Join(t.TempDir(), backgroundActivityStateFile)
+	feature := &BackgroundActivityFeature{
+		controller: fake,
+		stateFile:  stateFile,
+	}
+	err := feature.Initialise()
+	if err != nil {
+		t.Fatalf("Could not initialise feature: %v", err)
+	}
+	cee := feature.NewTaskFeature(&TaskRun{}).Start()
+	if cee != nil {
+		t.Fatalf("Could not start feature: %v", cee)
+	}
+
+	// the worker stops without stopping the feature, and a new worker
+	// starts, with background activity suppression no longer configured
+	config = &gwconfig.Config{}
+	restarted := &BackgroundActivityFeature{
+		controller: fake,
+		stateFile:  stateFile,
+	}
+	err = restarted.Initialise()
+	if err != nil {
+		t.Fatalf("Could not initialise feature after restart: %v", err)
+	}
+	fake.assertRestored(t)
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Fatalf("Expected %v to be removed once everything is re-enabled, but got: %v", stateFile, err)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"path/filepath"
 
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/artifacts"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/fileutil"
 	"golang.org/x/crypto/ed25519"
@@ -114,9 +114,9 @@ func (feature *ChainOfTrustTaskFeature) ReservedArtifacts() []string {
 	}
 }
 
-func (feature *ChainOfTrustTaskFeature) RequiredScopes() scopes.Required {
+func (feature *ChainOfTrustTaskFeature) RequiredScopes() tcscopes.Required {
 	// let's not require any scopes, as I see no reason to control access to this feature
-	return scopes.Required{}
+	return tcscopes.Required{}
 }
 
 func (feature *ChainOfTrustTaskFeature) Start() *CommandExecutionError {
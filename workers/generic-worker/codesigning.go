@@ -0,0 +1,55 @@
+package main
+
+import "github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+
+type CodeSigningFeature struct {
+}
+
+func (feature *CodeSigningFeature) Name() string {
+	return "Code Signing"
+}
+
+func (feature *CodeSigningFeature) Initialise() error {
+	return nil
+}
+
+func (feature *CodeSigningFeature) PersistState() error {
+	return nil
+}
+
+func (feature *CodeSigningFeature) IsEnabled(task *TaskRun) bool {
+	return task.Payload.Features.CodeSigning
+}
+
+func (feature *CodeSigningFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &CodeSigningTask{
+		task: task,
+	}
+}
+
+type CodeSigningTask struct {
+	task *TaskRun
+	// the platform keystore that certificates have been installed into, if
+	// any
+	store *codeSigningStore
+}
+
+func (cst *CodeSigningTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{
+		{"generic-worker:code-signing:" + config.ProvisionerID + "/" + config.WorkerType},
+	}
+}
+
+func (cst *CodeSigningTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (cst *CodeSigningTask) Start() *CommandExecutionError {
+	return cst.installCertificates()
+}
+
+// Stop removes the certificates, even if Start failed part way through
+// installing them.
+func (cst *CodeSigningTask) Stop(err *ExecutionErrors) {
+	err.add(cst.removeCertificates())
+}
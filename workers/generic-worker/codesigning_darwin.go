@@ -0,0 +1,44 @@
+//go:build darwin
+
+package main
+
+import "fmt"
+
+// codeSigningStore is a temporary keychain holding the code signing
+// certificates
+type codeSigningStore struct {
+	keychain *taskKeychain
+}
+
+func (cst *CodeSigningTask) installCertificates() *CommandExecutionError {
+	keychain, cee := newTaskKeychain(cst.task, "[code signing]", "codesigning.keychain-db")
+	if keychain != nil {
+		cst.store = &codeSigningStore{
+			keychain: keychain,
+		}
+	}
+	if cee != nil {
+		return cee
+	}
+	cee = keychain.importCertificates(cst.task.Payload.CodeSigningCertificates)
+	if cee != nil {
+		return cee
+	}
+	err := cst.task.setVariable("TASKCLUSTER_CODESIGNING_KEYCHAIN", keychain.path)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[code signing] Could not set TASKCLUSTER_CODESIGNING_KEYCHAIN environment variable: %v", err))
+	}
+	cst.task.Infof("[code signing] %v code signing certificate(s) installed in keychain %v", len(cst.task.Payload.CodeSigningCertificates), keychain.path)
+	return nil
+}
+
+func (cst *CodeSigningTask) removeCertificates() *CommandExecutionError {
+	if cst.store == nil {
+		return nil
+	}
+	cee := cst.store.keychain.delete()
+	if cee == nil {
+		cst.task.Info("[code signing] Code signing keychain deleted")
+	}
+	return cee
+}
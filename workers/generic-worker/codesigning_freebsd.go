@@ -0,0 +1,17 @@
+//go:build freebsd
+
+package main
+
+import "fmt"
+
+// codeSigningStore is only implemented on macOS and Windows
+type codeSigningStore struct {
+}
+
+func (cst *CodeSigningTask) installCertificates() *CommandExecutionError {
+	return MalformedPayloadError(fmt.Errorf("Code signing certificates are not supported on FreeBSD"))
+}
+
+func (cst *CodeSigningTask) removeCertificates() *CommandExecutionError {
+	return nil
+}
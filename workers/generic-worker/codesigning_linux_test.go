@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestCodeSigningMissingScopes(t *testing.T) {
+	setup(t)
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			CodeSigning: true,
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "generic-worker:code-signing:"+td.ProvisionerID+"/"+td.WorkerType) {
+		t.Fatalf("Expected log file to mention missing code signing scope, but it didn't\n%s", logtext)
+	}
+}
+
+func TestCodeSigningNotSupported(t *testing.T) {
+	setup(t)
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			CodeSigning: true,
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+	td.Scopes = append(td.Scopes, "generic-worker:code-signing:"+td.ProvisionerID+"/"+td.WorkerType)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "Code signing certificates are not supported on Linux") {
+		t.Fatalf("Expected log file to mention code signing is not supported, but it didn't\n%s", logtext)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/taskcluster/slugid-go/slugid"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/process"
+)
+
+// importPfxScript imports the PKCS #12 bundle in file $env:TASKCLUSTER_PFX_FILE
+// into the personal certificate store of the current user, and writes the
+// thumbprints of the imported certificates to standard out, one per line. The
+// bundle password is passed in an environment variable, rather than on the
+// command line, so that it is not visible to other processes.
+const importPfxScript = `$ErrorActionPreference = 'Stop'
+$params = @{ FilePath = $env:TASKCLUSTER_PFX_FILE; CertStoreLocation = 'Cert:\CurrentUser\My' }
+if ($env:TASKCLUSTER_PFX_PASSWORD) {
+  $params.Password = ConvertTo-SecureString -String $env:TASKCLUSTER_PFX_PASSWORD -AsPlainText -Force
+}
+Import-PfxCertificate @params | ForEach-Object { $_.Thumbprint }`
+
+// removeCertificateScript removes the certificate with thumbprint
+// $env:TASKCLUSTER_CERTIFICATE_THUMBPRINT, and its private key, from the
+// personal certificate store of the current user.
+const removeCertificateScript = `$ErrorActionPreference = 'Stop'
+$path = 'Cert:\CurrentUser\My\' + $env:TASKCLUSTER_CERTIFICATE_THUMBPRINT
+if (Test-Path $path) {
+  Remove-Item -Path $path -DeleteKey
+}`
+
+// codeSigningStore records the certificates that have been imported into the
+// personal certificate store of the task user, so that they can be removed
+// again when the task completes.
+type codeSigningStore struct {
+	thumbprints []string
+}
+
+func (cst *CodeSigningTask) installCertificates() *CommandExecutionError {
+	cst.store = &codeSigningStore{}
+	for _, cert := range cst.task.Payload.CodeSigningCertificates {
+		cee := cst.installCertificate(cert)
+		if cee != nil {
+			return cee
+		}
+	}
+	cst.task.Infof("[code signing] %v code signing certificate(s) installed in Cert:\\CurrentUser\\My", len(cst.store.thumbprints))
+	return nil
+}
+
+func (cst *CodeSigningTask) installCertificate(cert SecretCertificate) *CommandExecutionError {
+	bundle, password, err := cst.task.fetchSecretCertificate(cert)
+	if err != nil {
+		return MalformedPayloadError(fmt.Errorf("[code signing] %v", err))
+	}
+	// the bundle is written to the task directory, since the task user needs
+	// to be able to read it
+	pfxFile := filepath.Join(taskContext.TaskDir, slugid.Nice()+".pfx")
+	file, err := CreateFileAsTaskUser(pfxFile)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[code signing] Could not create file %v: %v", pfxFile, err))
+	}
+	defer os.Remove(pfxFile)
+	_, err = file.Write(bundle)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[code signing] Could not write certificate to file %v: %v", pfxFile, err))
+	}
+	out, err := runPowershellAsTaskUser(
+		importPfxScript,
+		"TASKCLUSTER_PFX_FILE="+pfxFile,
+		"TASKCLUSTER_PFX_PASSWORD="+password,
+	)
+	// record thumbprints even on failure, in case some certificates of the
+	// bundle were imported
+	cst.store.thumbprints = append(cst.store.thumbprints, strings.Fields(out)...)
+	if err != nil {
+		return MalformedPayloadError(fmt.Errorf("[code signing] Could not import certificate from secret %v: %v", cert.Secret, err))
+	}
+	cst.task.Infof("[code signing] Imported certificate from secret %v", cert.Secret)
+	return nil
+}
+
+func (cst *CodeSigningTask) removeCertificates() *CommandExecutionError {
+	if cst.store == nil {
+		return nil
+	}
+	var failed []string
+	for _, thumbprint := range cst.store.thumbprints {
+		_, err := runPowershellAsTaskUser(removeCertificateScript, "TASKCLUSTER_CERTIFICATE_THUMBPRINT="+thumbprint)
+		if err != nil {
+			cst.task.Errorf("[code signing] Could not remove certificate %v: %v", thumbprint, err)
+			failed = append(failed, thumbprint)
+		}
+	}
+	if len(failed) > 0 {
+		return executionError(internalError, errored, fmt.Errorf("[code signing] Could not remove certificate(s) %v", strings.Join(failed, ", ")))
+	}
+	cst.task.Infof("[code signing] Removed %v code signing certificate(s)", len(cst.store.thumbprints))
+	return nil
+}
+
+// runPowershellAsTaskUser runs the given PowerShell script as the task user,
+// with the given additional environment variables, and returns its standard
+// output.
+func runPowershellAsTaskUser(script string, env ...string) (string, error) {
+	cmd, err := process.NewCommandNoOutputStreams(
+		[]string{"powershell.exe", "-NoLogo", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-Command", script},
+		taskContext.TaskDir,
+		env,
+		taskContext.pd,
+	)
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	result := cmd.Execute()
+	if !result.Succeeded() {
+		return stdout.String(), fmt.Errorf("%v\n%v", result, stderr.String())
+	}
+	return stdout.String(), nil
+}
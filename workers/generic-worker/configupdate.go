@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+var (
+	// configuration updates received from worker-runner, waiting to be
+	// applied between tasks
+	pendingConfigUpdate      map[string]interface{}
+	pendingConfigUpdateMutex sync.Mutex
+
+	// config properties that can be changed while the worker is running
+	liveConfigProperties = map[string]bool{
+		"paused":        true,
+		"provisionerId": true,
+		"workerType":    true,
+	}
+)
+
+// Queue configuration changes received in a config-update message, to be
+// applied by the next call to applyConfigUpdates.  Properties that cannot be
+// changed while the worker is running are logged and ignored.
+func queueConfigUpdate(update map[string]interface{}) {
+	pendingConfigUpdateMutex.Lock()
+	defer pendingConfigUpdateMutex.Unlock()
+	if pendingConfigUpdate == nil {
+		pendingConfigUpdate = map[string]interface{}{}
+	}
+	for property, value := range update {
+		if !liveConfigProperties[property] {
+			log.Printf("Ignoring config update for %v, which cannot be changed while the worker is running", property)
+			continue
+		}
+		pendingConfigUpdate[property] = value
+	}
+}
+
+// Apply any queued configuration changes.  This is called from the main loop,
+// between claims, so that changes never affect a running task.
+func applyConfigUpdates() {
+	pendingConfigUpdateMutex.Lock()
+	update := pendingConfigUpdate
+	pendingConfigUpdate = nil
+	pendingConfigUpdateMutex.Unlock()
+	if len(update) == 0 {
+		return
+	}
+
+	// unmarshaling into the existing config only changes the properties
+	// present in the update
+	j, err := json.Marshal(update)
+	if err == nil {
+		err = json.Unmarshal(j, &config.PublicConfig)
+	}
+	if err != nil {
+		log.Printf("Could not apply config update %v: %v", update, err)
+		return
+	}
+	log.Printf("Applied config update %v", update)
+	if _, ok := update["paused"]; ok {
+		if config.Paused {
+			log.Print("Worker is paused, and will not claim tasks until resumed")
+		} else {
+			log.Print("Worker is not paused")
+		}
+	}
+}
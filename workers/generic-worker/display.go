@@ -0,0 +1,60 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"os/exec"
+
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+)
+
+type DisplayFeature struct {
+}
+
+func (feature *DisplayFeature) Name() string {
+	return "Display"
+}
+
+func (feature *DisplayFeature) Initialise() error {
+	return nil
+}
+
+func (feature *DisplayFeature) PersistState() error {
+	return nil
+}
+
+func (feature *DisplayFeature) IsEnabled(task *TaskRun) bool {
+	return task.Payload.Features.Display
+}
+
+func (feature *DisplayFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &DisplayTask{
+		task: task,
+	}
+}
+
+type DisplayTask struct {
+	task *TaskRun
+	// the display server process, once started
+	server *exec.Cmd
+	// closed when the display server process exits
+	exited chan struct{}
+	// XDG_RUNTIME_DIR of the Wayland compositor, if any
+	runtimeDir string
+}
+
+func (dt *DisplayTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{}
+}
+
+func (dt *DisplayTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (dt *DisplayTask) Start() *CommandExecutionError {
+	return dt.startDisplay()
+}
+
+func (dt *DisplayTask) Stop(err *ExecutionErrors) {
+	err.add(dt.stopDisplay())
+}
@@ -0,0 +1,13 @@
+//go:build darwin
+
+package main
+
+import "fmt"
+
+func (dt *DisplayTask) startDisplay() *CommandExecutionError {
+	return MalformedPayloadError(fmt.Errorf("[display] Headless displays are not supported on macOS"))
+}
+
+func (dt *DisplayTask) stopDisplay() *CommandExecutionError {
+	return nil
+}
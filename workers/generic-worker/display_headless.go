@@ -0,0 +1,183 @@
+//go:build linux || freebsd
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// displayStartTimeout is how long to wait for a display server to accept
+// connections
+const displayStartTimeout = 30 * time.Second
+
+func (dt *DisplayTask) startDisplay() *CommandExecutionError {
+	settings := HeadlessDisplay{}
+	if dt.task.Payload.Display != nil {
+		settings = *dt.task.Payload.Display
+	}
+	width := settings.Width
+	if width == 0 {
+		width = 1920
+	}
+	height := settings.Height
+	if height == 0 {
+		height = 1080
+	}
+	switch settings.Server {
+	case "", "xvfb":
+		depth := settings.Depth
+		if depth == 0 {
+			depth = 24
+		}
+		return dt.startXvfb(width, height, depth)
+	case "weston":
+		return dt.startWeston(width, height)
+	}
+	return MalformedPayloadError(fmt.Errorf("[display] Unsupported display server %q", settings.Server))
+}
+
+// startXvfb starts an Xvfb X server, letting it choose a free display number,
+// which it writes to the file descriptor given by -displayfd once it is
+// ready to accept connections.
+func (dt *DisplayTask) startXvfb(width, height, depth int64) *CommandExecutionError {
+	displayfdReader, displayfdWriter, err := os.Pipe()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[display] Could not create pipe: %v", err))
+	}
+	defer displayfdReader.Close()
+	dt.server = exec.Command(
+		config.XvfbExecutable,
+		"-displayfd", "3",
+		"-screen", "0", fmt.Sprintf("%dx%dx%d", width, height, depth),
+		"-nolisten", "tcp",
+	)
+	dt.server.ExtraFiles = []*os.File{displayfdWriter}
+	cee := dt.startServer()
+	displayfdWriter.Close()
+	if cee != nil {
+		return cee
+	}
+	displayNumber := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(displayfdReader).ReadString('\n')
+		displayNumber <- strings.TrimSpace(line)
+	}()
+	var display string
+	select {
+	case number := <-displayNumber:
+		if number == "" {
+			return executionError(internalError, errored, fmt.Errorf("[display] %v exited without reporting a display number", config.XvfbExecutable))
+		}
+		display = ":" + number
+	case <-time.After(displayStartTimeout):
+		return executionError(internalError, errored, fmt.Errorf("[display] %v did not start within %v", config.XvfbExecutable, displayStartTimeout))
+	}
+	err = dt.task.setVariable("DISPLAY", display)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[display] Could not set DISPLAY environment variable: %v", err))
+	}
+	dt.task.Infof("[display] X11 display %v (%vx%vx%v) is available", display, width, height, depth)
+	return nil
+}
+
+// startWeston starts a Weston compositor with its headless backend, and waits
+// for it to create its socket.
+func (dt *DisplayTask) startWeston(width, height int64) *CommandExecutionError {
+	var err error
+	dt.runtimeDir, err = os.MkdirTemp("", "weston-")
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[display] Could not create runtime directory for %v: %v", config.WestonExecutable, err))
+	}
+	socket := filepath.Join(dt.runtimeDir, "wayland-0")
+	dt.server = exec.Command(
+		config.WestonExecutable,
+		"--backend=headless-backend.so",
+		fmt.Sprintf("--width=%d", width),
+		fmt.Sprintf("--height=%d", height),
+		"--socket=wayland-0",
+		"--idle-time=0",
+	)
+	dt.server.Env = append(os.Environ(), "XDG_RUNTIME_DIR="+dt.runtimeDir)
+	cee := dt.startServer()
+	if cee != nil {
+		return cee
+	}
+	deadline := time.Now().Add(displayStartTimeout)
+	for {
+		if _, err = os.Stat(socket); err == nil {
+			break
+		}
+		select {
+		case <-dt.exited:
+			return executionError(internalError, errored, fmt.Errorf("[display] %v exited before creating socket %v", config.WestonExecutable, socket))
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			return executionError(internalError, errored, fmt.Errorf("[display] %v did not create socket %v within %v", config.WestonExecutable, socket, displayStartTimeout))
+		}
+	}
+	// the runtime directory must be private when weston starts, but the task
+	// user needs to be able to reach the socket inside it
+	err = os.Chmod(dt.runtimeDir, 0711)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[display] Could not chmod 711 %v: %v", dt.runtimeDir, err))
+	}
+	err = makeFileOrDirReadWritableForUser(false, socket, taskContext.User)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[display] Could not make %v readwritable for task user: %v", socket, err))
+	}
+	// an absolute path is accepted in WAYLAND_DISPLAY, so XDG_RUNTIME_DIR of
+	// the task does not need to be changed
+	err = dt.task.setVariable("WAYLAND_DISPLAY", socket)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[display] Could not set WAYLAND_DISPLAY environment variable: %v", err))
+	}
+	dt.task.Infof("[display] Wayland display %v (%vx%v) is available", socket, width, height)
+	return nil
+}
+
+func (dt *DisplayTask) startServer() *CommandExecutionError {
+	dt.server.Stdout = log.Writer()
+	dt.server.Stderr = log.Writer()
+	err := dt.server.Start()
+	if err != nil {
+		dt.server = nil
+		return executionError(internalError, errored, fmt.Errorf("[display] Could not start display server: %v", err))
+	}
+	dt.exited = make(chan struct{})
+	go func() {
+		_ = dt.server.Wait()
+		close(dt.exited)
+	}()
+	return nil
+}
+
+func (dt *DisplayTask) stopDisplay() *CommandExecutionError {
+	if dt.server != nil {
+		err := dt.server.Process.Signal(syscall.SIGTERM)
+		if err == nil {
+			select {
+			case <-dt.exited:
+			case <-time.After(10 * time.Second):
+				log.Printf("WARNING: display server did not exit after SIGTERM, killing it")
+				_ = dt.server.Process.Kill()
+				<-dt.exited
+			}
+		}
+	}
+	if dt.runtimeDir != "" {
+		err := os.RemoveAll(dt.runtimeDir)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[display] Could not remove %v: %v", dt.runtimeDir, err))
+		}
+	}
+	return nil
+}
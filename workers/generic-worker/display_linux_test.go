@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestDisplayXvfb(t *testing.T) {
+	setup(t)
+	if _, err := exec.LookPath(config.XvfbExecutable); err != nil {
+		t.Skipf("%v not available: %v", config.XvfbExecutable, err)
+	}
+	payload := GenericWorkerPayload{
+		Command: [][]string{
+			{"/usr/bin/env", "bash", "-c", `echo "Display: ${DISPLAY}"`},
+		},
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			Display: true,
+		},
+		Display: &HeadlessDisplay{
+			Width:  800,
+			Height: 600,
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "completed", "completed")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "Display: :") {
+		t.Fatalf("Expected DISPLAY to be set, but it wasn't\n%s", logtext)
+	}
+}
+
+func TestDisplayServerNotInstalled(t *testing.T) {
+	setup(t)
+	config.XvfbExecutable = "/does/not/exist/Xvfb"
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			Display: true,
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "exception", "internal-error")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "Could not start display server") {
+		t.Fatalf("Expected log file to mention display server could not be started, but it didn't\n%s", logtext)
+	}
+}
@@ -1,6 +1,6 @@
 package main
 
-import "github.com/taskcluster/taskcluster/v60/internal/scopes"
+import "github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 
 type (
 	Feature interface {
@@ -12,7 +12,7 @@ type (
 	}
 
 	TaskFeature interface {
-		RequiredScopes() scopes.Required
+		RequiredScopes() tcscopes.Required
 		ReservedArtifacts() []string
 		Start() *CommandExecutionError
 		Stop(err *ExecutionErrors)
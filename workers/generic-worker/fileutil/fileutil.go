@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/mholt/archiver/v3"
 )
@@ -113,6 +114,30 @@ func CreateDir(dir string) error {
 	return os.MkdirAll(dir, 0700)
 }
 
+// IsLink returns true if info describes a symbolic link, or (on Windows) a
+// junction or other reparse point.
+func IsLink(info os.FileInfo) bool {
+	return info.Mode()&(os.ModeSymlink|os.ModeIrregular) != 0
+}
+
+// WithinDir returns true if path, after resolving all symbolic links and
+// junctions, is dir or lies inside dir.
+func WithinDir(dir, path string) (bool, error) {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false, err
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(realDir, realPath)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
 func Unarchive(source, destination, format string) error {
 	var unarchiver archiver.Unarchiver
 	switch format {
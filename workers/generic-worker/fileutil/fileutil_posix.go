@@ -77,3 +77,9 @@ func setPermissions(path string, permissions fs.FileMode) error {
 		permissions,
 	)
 }
+
+// LongPath returns path unchanged, since only Windows has a maximum path
+// length that requires a special path form to exceed.
+func LongPath(path string) string {
+	return path
+}
@@ -1,6 +1,9 @@
 package fileutil
 
 import (
+	"path/filepath"
+	"strings"
+
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
 )
 
@@ -33,3 +36,21 @@ func GetPermissions(path string) (string, func() error, error) {
 func resetPermissions(path string) error {
 	return host.Run("icacls", path, "/reset", "/t")
 }
+
+// LongPath returns path in the `\\?\` extended-length form, so that it can be
+// passed to Windows commands (such as rmdir) that would otherwise fail for
+// paths longer than MAX_PATH (260 characters), as often found in deeply
+// nested node_modules directories.
+func LongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(absPath, `\\`) {
+		return `\\?\UNC\` + absPath[2:]
+	}
+	return `\\?\` + absPath
+}
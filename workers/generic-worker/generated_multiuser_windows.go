@@ -177,6 +177,24 @@ type (
 		// Since: generic-worker 5.3.0
 		ChainOfTrust bool `json:"chainOfTrust,omitempty"`
 
+		// Installs the certificates listed in `task.payload.codeSigningCertificates`
+		// into the platform keystore for the duration of the task, and removes
+		// them again when the task completes.
+		//
+		// On macOS, the certificates are imported into a temporary keychain whose
+		// path is provided to task commands in environment variable
+		// `TASKCLUSTER_CODESIGNING_KEYCHAIN`. On Windows, they are imported into
+		// the personal certificate store (`Cert:\CurrentUser\My`) of the task
+		// user.
+		//
+		// Requires scope
+		// `generic-worker:code-signing:<provisionerId>/<workerType>`.
+		//
+		// This feature is only available on macOS and Windows.
+		//
+		// Since: generic-worker 60.4.0
+		CodeSigning bool `json:"codeSigning,omitempty"`
+
 		// The live log feature streams the combined stderr and stdout to a task artifact
 		// so that the output is available while the task is running.
 		//
@@ -211,6 +229,21 @@ type (
 		//
 		// Since: generic-worker 10.6.0
 		TaskclusterProxy bool `json:"taskclusterProxy,omitempty"`
+
+		// Runs each task command inside a Windows container created from the
+		// image described in `task.payload.windowsContainer`. The task
+		// directory is mapped into the container at the same path, so mounts,
+		// caches and artifacts behave as they do for commands that run directly
+		// on the worker.
+		//
+		// The worker must have config setting `enableWindowsContainers` set to
+		// `true`.
+		//
+		// Requires scope
+		// `generic-worker:windows-container:<provisionerId>/<workerType>`.
+		//
+		// Since: generic-worker 60.4.0
+		WindowsContainer bool `json:"windowsContainer,omitempty"`
 	}
 
 	FileMount struct {
@@ -250,6 +283,14 @@ type (
 		// Since: generic-worker 1.0.0
 		Artifacts []Artifact `json:"artifacts,omitempty"`
 
+		// Certificates to install when `task.payload.features.codeSigning` is
+		// `true`. Each certificate is read from the secrets service, using the
+		// task credentials, so the task requires scope `secrets:get:<secret>` for
+		// each secret.
+		//
+		// Since: generic-worker 60.4.0
+		CodeSigningCertificates []SecretCertificate `json:"codeSigningCertificates,omitempty"`
+
 		// One entry per command (consider each entry to be interpreted as a full line of
 		// a Windows™ .bat file). For example:
 		// ```
@@ -369,8 +410,35 @@ type (
 		// Since: generic-worker 10.5.0
 		RdpInfo string `json:"rdpInfo,omitempty"`
 
+		// The shell that task commands are executed with.
+		//
+		//   * `cmd`: each command is executed as a batch script by `cmd.exe`.
+		//   * `pwsh`: each command is executed as a script by PowerShell Core
+		//     (`pwsh`), with UTF-8 console input and output encoding. This avoids
+		//     the quoting problems of `cmd.exe`. The exit code of the command is
+		//     the exit code of the last native command that it ran, or `1` if its
+		//     last statement failed, or it raised a terminating error. Environment
+		//     variable and current directory changes carry over to subsequent
+		//     commands, as they do with `cmd`.
+		//
+		// If not specified, the worker config setting `defaultShell` is used, which
+		// defaults to `cmd`.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Possible values:
+		//   * "cmd"
+		//   * "pwsh"
+		Shell string `json:"shell,omitempty"`
+
 		// This property is allowed for backward compatibility, but is unused.
 		SupersederURL string `json:"supersederUrl,omitempty"`
+
+		// Configuration for the Windows container that task commands run in, when
+		// `task.payload.features.windowsContainer` is `true`.
+		//
+		// Since: generic-worker 60.4.0
+		WindowsContainer WindowsContainer `json:"windowsContainer,omitempty"`
 	}
 
 	// Content originating from a task artifact that has been indexed by the Taskcluster Index Service.
@@ -450,6 +518,31 @@ type (
 		Format string `json:"format"`
 	}
 
+	// A PKCS #12 certificate bundle (certificate and private key) stored in
+	// the secrets service.
+	//
+	// Since: generic-worker 60.4.0
+	SecretCertificate struct {
+
+		// The property of the secret value that holds the base64 encoded
+		// PKCS #12 bundle. If not specified, `certificate` is used.
+		//
+		// Since: generic-worker 60.4.0
+		CertificateKey string `json:"certificateKey,omitempty"`
+
+		// The property of the secret value that holds the password of the
+		// PKCS #12 bundle. If not specified, `password` is used. If the
+		// property does not exist, the bundle is assumed to have no password.
+		//
+		// Since: generic-worker 60.4.0
+		PasswordKey string `json:"passwordKey,omitempty"`
+
+		// The name of the secret that holds the certificate.
+		//
+		// Since: generic-worker 60.4.0
+		Secret string `json:"secret"`
+	}
+
 	// URL to download content from.
 	//
 	// Since: generic-worker 5.4.0
@@ -468,6 +561,44 @@ type (
 		URL string `json:"url"`
 	}
 
+	// Configuration for the Windows container that task commands run in, when
+	// `task.payload.features.windowsContainer` is `true`.
+	//
+	// Since: generic-worker 60.4.0
+	WindowsContainer struct {
+
+		// The image to create the container from. If `imageFile` is not
+		// specified, this is an image reference that is pulled from a
+		// registry, such as `mcr.microsoft.com/windows/servercore:ltsc2022`.
+		// If `imageFile` is specified, this is the name of the image contained
+		// in the image file.
+		//
+		// Since: generic-worker 60.4.0
+		Image string `json:"image"`
+
+		// The path, relative to the task directory, of an image archive (as
+		// produced by `docker save`) to load before the first command runs.
+		// Typically this is a file mount, so that the image is downloaded and
+		// cached by the worker.
+		//
+		// Since: generic-worker 60.4.0
+		ImageFile string `json:"imageFile,omitempty"`
+
+		// The isolation technology to use for the container. `process`
+		// isolation shares the host kernel and requires the image OS version
+		// to match the host. `hyperv` isolation runs the container in a
+		// lightweight utility VM.
+		//
+		// Since: generic-worker 60.4.0
+		//
+		// Possible values:
+		//   * "process"
+		//   * "hyperv"
+		//
+		// Default:    "process"
+		Isolation string `json:"isolation" default:"process"`
+	}
+
 	WritableDirectoryCache struct {
 
 		// Implies a read/write cache directory volume. A unique name for the
@@ -715,6 +846,32 @@ func JSONSchema() string {
       "title": "Read Only Directory",
       "type": "object"
     },
+    "secretCertificate": {
+      "additionalProperties": false,
+      "description": "A PKCS #12 certificate bundle (certificate and private key) stored in\nthe secrets service.\n\nSince: generic-worker 60.4.0",
+      "properties": {
+        "certificateKey": {
+          "description": "The property of the secret value that holds the base64 encoded\nPKCS #12 bundle. If not specified, ` + "`" + `certificate` + "`" + ` is used.\n\nSince: generic-worker 60.4.0",
+          "title": "Certificate key",
+          "type": "string"
+        },
+        "passwordKey": {
+          "description": "The property of the secret value that holds the password of the\nPKCS #12 bundle. If not specified, ` + "`" + `password` + "`" + ` is used. If the\nproperty does not exist, the bundle is assumed to have no password.\n\nSince: generic-worker 60.4.0",
+          "title": "Password key",
+          "type": "string"
+        },
+        "secret": {
+          "description": "The name of the secret that holds the certificate.\n\nSince: generic-worker 60.4.0",
+          "title": "Secret name",
+          "type": "string"
+        }
+      },
+      "required": [
+        "secret"
+      ],
+      "title": "Secret certificate",
+      "type": "object"
+    },
     "writableDirectoryCache": {
       "additionalProperties": false,
       "dependencies": {
@@ -822,6 +979,15 @@ func JSONSchema() string {
       "type": "array",
       "uniqueItems": true
     },
+    "codeSigningCertificates": {
+      "description": "Certificates to install when ` + "`" + `task.payload.features.codeSigning` + "`" + ` is\n` + "`" + `true` + "`" + `. Each certificate is read from the secrets service, using the\ntask credentials, so the task requires scope ` + "`" + `secrets:get:\u003csecret\u003e` + "`" + ` for\neach secret.\n\nSince: generic-worker 60.4.0",
+      "items": {
+        "$ref": "#/definitions/secretCertificate"
+      },
+      "title": "Code signing certificates",
+      "type": "array",
+      "uniqueItems": false
+    },
     "command": {
       "description": "One entry per command (consider each entry to be interpreted as a full line of\na Windows™ .bat file). For example:\n` + "`" + `` + "`" + `` + "`" + `\n[\n  \"set\",\n  \"echo hello world \u003e hello_world.txt\",\n  \"set GOPATH=C:\\\\Go\"\n]\n` + "`" + `` + "`" + `` + "`" + `\n\nSince: generic-worker 0.0.1",
       "items": {
@@ -855,6 +1021,11 @@ func JSONSchema() string {
           "title": "Enable generation of signed Chain of Trust artifacts",
           "type": "boolean"
         },
+        "codeSigning": {
+          "description": "Installs the certificates listed in ` + "`" + `task.payload.codeSigningCertificates` + "`" + `\ninto the platform keystore for the duration of the task, and removes\nthem again when the task completes.\n\nOn macOS, the certificates are imported into a temporary keychain whose\npath is provided to task commands in environment variable\n` + "`" + `TASKCLUSTER_CODESIGNING_KEYCHAIN` + "`" + `. On Windows, they are imported into\nthe personal certificate store (` + "`" + `Cert:\\CurrentUser\\My` + "`" + `) of the task\nuser.\n\nRequires scope\n` + "`" + `generic-worker:code-signing:\u003cprovisionerId\u003e/\u003cworkerType\u003e` + "`" + `.\n\nThis feature is only available on macOS and Windows.\n\nSince: generic-worker 60.4.0",
+          "title": "Install code signing certificates",
+          "type": "boolean"
+        },
         "liveLog": {
           "default": true,
           "description": "The live log feature streams the combined stderr and stdout to a task artifact\nso that the output is available while the task is running.\n\nSince: generic-worker 48.2.0",
@@ -870,6 +1041,11 @@ func JSONSchema() string {
           "description": "The taskcluster proxy provides an easy and safe way to make authenticated\ntaskcluster requests within the scope(s) of a particular task. See\n[the github project](https://github.com/taskcluster/taskcluster/tree/main/tools/taskcluster-proxy) for more information.\n\nSince: generic-worker 10.6.0",
           "title": "Run [taskcluster-proxy](https://github.com/taskcluster/taskcluster/tree/main/tools/taskcluster-proxy) to allow tasks to dynamically proxy requests to taskcluster services",
           "type": "boolean"
+        },
+        "windowsContainer": {
+          "description": "Runs each task command inside a Windows container created from the\nimage described in ` + "`" + `task.payload.windowsContainer` + "`" + `. The task\ndirectory is mapped into the container at the same path, so mounts,\ncaches and artifacts behave as they do for commands that run directly\non the worker.\n\nThe worker must have config setting ` + "`" + `enableWindowsContainers` + "`" + ` set to\n` + "`" + `true` + "`" + `.\n\nRequires scope\n` + "`" + `generic-worker:windows-container:\u003cprovisionerId\u003e/\u003cworkerType\u003e` + "`" + `.\n\nSince: generic-worker 60.4.0",
+          "title": "Run commands inside a Windows container",
+          "type": "boolean"
         }
       },
       "required": [],
@@ -958,10 +1134,50 @@ func JSONSchema() string {
       "title": "RDP Info",
       "type": "string"
     },
+    "shell": {
+      "description": "The shell that task commands are executed with.\n\n  * ` + "`" + `cmd` + "`" + `: each command is executed as a batch script by ` + "`" + `cmd.exe` + "`" + `.\n  * ` + "`" + `pwsh` + "`" + `: each command is executed as a script by PowerShell Core\n    (` + "`" + `pwsh` + "`" + `), with UTF-8 console input and output encoding. This avoids\n    the quoting problems of ` + "`" + `cmd.exe` + "`" + `. The exit code of the command is\n    the exit code of the last native command that it ran, or ` + "`" + `1` + "`" + ` if its\n    last statement failed, or it raised a terminating error. Environment\n    variable and current directory changes carry over to subsequent\n    commands, as they do with ` + "`" + `cmd` + "`" + `.\n\nIf not specified, the worker config setting ` + "`" + `defaultShell` + "`" + ` is used, which\ndefaults to ` + "`" + `cmd` + "`" + `.\n\nSince: generic-worker 60.4.0",
+      "enum": [
+        "cmd",
+        "pwsh"
+      ],
+      "title": "Shell",
+      "type": "string"
+    },
     "supersederUrl": {
       "description": "This property is allowed for backward compatibility, but is unused.",
       "title": "unused",
       "type": "string"
+    },
+    "windowsContainer": {
+      "additionalProperties": false,
+      "description": "Configuration for the Windows container that task commands run in, when\n` + "`" + `task.payload.features.windowsContainer` + "`" + ` is ` + "`" + `true` + "`" + `.\n\nSince: generic-worker 60.4.0",
+      "properties": {
+        "image": {
+          "description": "The image to create the container from. If ` + "`" + `imageFile` + "`" + ` is not\nspecified, this is an image reference that is pulled from a\nregistry, such as ` + "`" + `mcr.microsoft.com/windows/servercore:ltsc2022` + "`" + `.\nIf ` + "`" + `imageFile` + "`" + ` is specified, this is the name of the image contained\nin the image file.\n\nSince: generic-worker 60.4.0",
+          "title": "Container image",
+          "type": "string"
+        },
+        "imageFile": {
+          "description": "The path, relative to the task directory, of an image archive (as\nproduced by ` + "`" + `docker save` + "`" + `) to load before the first command runs.\nTypically this is a file mount, so that the image is downloaded and\ncached by the worker.\n\nSince: generic-worker 60.4.0",
+          "title": "Container image file",
+          "type": "string"
+        },
+        "isolation": {
+          "default": "process",
+          "description": "The isolation technology to use for the container. ` + "`" + `process` + "`" + `\nisolation shares the host kernel and requires the image OS version\nto match the host. ` + "`" + `hyperv` + "`" + ` isolation runs the container in a\nlightweight utility VM.\n\nSince: generic-worker 60.4.0",
+          "enum": [
+            "process",
+            "hyperv"
+          ],
+          "title": "Container isolation",
+          "type": "string"
+        }
+      },
+      "required": [
+        "image"
+      ],
+      "title": "Windows container",
+      "type": "object"
     }
   },
   "required": [
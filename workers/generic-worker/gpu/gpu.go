@@ -0,0 +1,163 @@
+// Package gpu inventories the NVIDIA GPUs of the host, and leases them to
+// tasks.
+//
+// Leases are held as exclusive locks on files in a lease directory, so that
+// a GPU is never leased to two tasks at the same time, even when several
+// worker processes share the host. Locks are released by the operating
+// system if the process holding them dies, so leases can not leak.
+package gpu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
+)
+
+// ErrInsufficientDevices is returned by Allocator.Acquire if not enough
+// devices are currently free.
+var ErrInsufficientDevices = errors.New("not enough GPUs are free")
+
+// Device is a unit of GPU capacity that can be leased to a task: either a
+// whole GPU, or a MIG (Multi-Instance GPU) slice of one.
+type Device struct {
+	// UUID is the identifier of the device, as accepted in
+	// CUDA_VISIBLE_DEVICES, e.g. GPU-5d5ba0d6-... or MIG-c6d4f1ef-...
+	UUID string
+	// Name is the product name of the GPU, or the profile of the MIG slice
+	// (e.g. 1g.5gb)
+	Name string
+}
+
+var (
+	gpuLine = regexp.MustCompile(`^GPU (\d+): (.*) \(UUID: (GPU-[^)]+)\)$`)
+	migLine = regexp.MustCompile(`^MIG (\S+)\s+Device\s+(\d+): \(UUID: (MIG-[^)]+)\)$`)
+)
+
+// Inventory returns the devices of the host, as reported by `nvidia-smi -L`.
+func Inventory(nvidiaSMI string) ([]Device, error) {
+	out, err := host.CombinedOutput(nvidiaSMI, "-L")
+	if err != nil {
+		return nil, fmt.Errorf("could not list GPUs with %v: %v", nvidiaSMI, err)
+	}
+	return ParseDeviceList(out), nil
+}
+
+// ParseDeviceList parses the output of `nvidia-smi -L`. GPUs that are
+// partitioned into MIG slices are represented by their slices, since a
+// partitioned GPU can not be used as a whole.
+func ParseDeviceList(out string) []Device {
+	devices := []Device{}
+	var gpu *Device
+	migSlices := 0
+	flush := func() {
+		if gpu != nil && migSlices == 0 {
+			devices = append(devices, *gpu)
+		}
+		gpu = nil
+		migSlices = 0
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if match := gpuLine.FindStringSubmatch(line); match != nil {
+			flush()
+			gpu = &Device{
+				UUID: match[3],
+				Name: match[2],
+			}
+			continue
+		}
+		if match := migLine.FindStringSubmatch(line); match != nil && gpu != nil {
+			migSlices++
+			devices = append(devices, Device{
+				UUID: match[3],
+				Name: match[1],
+			})
+		}
+	}
+	flush()
+	return devices
+}
+
+// Allocator leases devices to tasks.
+type Allocator struct {
+	devices  []Device
+	leaseDir string
+}
+
+// NewAllocator returns an Allocator for devices, which holds leases in
+// leaseDir. Allocators of all worker processes on a host must use the same
+// leaseDir.
+func NewAllocator(devices []Device, leaseDir string) (*Allocator, error) {
+	err := os.MkdirAll(leaseDir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("could not create GPU lease directory %v: %v", leaseDir, err)
+	}
+	return &Allocator{
+		devices:  devices,
+		leaseDir: leaseDir,
+	}, nil
+}
+
+// Devices returns all devices managed by the allocator, whether or not they
+// are currently leased.
+func (a *Allocator) Devices() []Device {
+	return a.devices
+}
+
+// Lease is a set of devices that are exclusively held until Release is
+// called.
+type Lease struct {
+	Devices []Device
+	locks   []*os.File
+}
+
+// Acquire leases n devices. If fewer than n devices are free, no devices are
+// leased, and ErrInsufficientDevices is returned.
+func (a *Allocator) Acquire(n int) (*Lease, error) {
+	lease := &Lease{}
+	for _, device := range a.devices {
+		if len(lease.Devices) == n {
+			break
+		}
+		lock, err := tryLock(filepath.Join(a.leaseDir, device.UUID+".lock"))
+		if err != nil {
+			_ = lease.Release()
+			return nil, err
+		}
+		if lock == nil {
+			continue
+		}
+		lease.Devices = append(lease.Devices, device)
+		lease.locks = append(lease.locks, lock)
+	}
+	if len(lease.Devices) < n {
+		_ = lease.Release()
+		return nil, ErrInsufficientDevices
+	}
+	return lease, nil
+}
+
+// UUIDs returns the comma separated UUIDs of the leased devices, in the form
+// expected by CUDA_VISIBLE_DEVICES.
+func (l *Lease) UUIDs() string {
+	uuids := make([]string, len(l.Devices))
+	for i, device := range l.Devices {
+		uuids[i] = device.UUID
+	}
+	return strings.Join(uuids, ",")
+}
+
+// Release releases the leased devices. It is safe to call more than once.
+func (l *Lease) Release() error {
+	var errs []error
+	for _, lock := range l.locks {
+		errs = append(errs, unlock(lock))
+	}
+	l.locks = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package gpu
+
+import (
+	"errors"
+	"testing"
+)
+
+const nvidiaSMIOutput = `GPU 0: NVIDIA A100-SXM4-40GB (UUID: GPU-5d5ba0d6-d33d-2b2c-524d-9e3d8d2b8a77)
+  MIG 3g.20gb     Device  0: (UUID: MIG-c6d4f1ef-42e4-5de3-91c7-45d71c87eb3f)
+  MIG 3g.20gb     Device  1: (UUID: MIG-cba663e8-9bed-5b25-b243-5985ef7c9beb)
+GPU 1: NVIDIA A100-SXM4-40GB (UUID: GPU-0c2a6a1b-7e8d-4f3a-9c5e-1d2b3c4d5e6f)
+`
+
+func TestParseDeviceList(t *testing.T) {
+	devices := ParseDeviceList(nvidiaSMIOutput)
+	expected := []Device{
+		{UUID: "MIG-c6d4f1ef-42e4-5de3-91c7-45d71c87eb3f", Name: "3g.20gb"},
+		{UUID: "MIG-cba663e8-9bed-5b25-b243-5985ef7c9beb", Name: "3g.20gb"},
+		{UUID: "GPU-0c2a6a1b-7e8d-4f3a-9c5e-1d2b3c4d5e6f", Name: "NVIDIA A100-SXM4-40GB"},
+	}
+	if len(devices) != len(expected) {
+		t.Fatalf("Expected %v devices but got %v: %#v", len(expected), len(devices), devices)
+	}
+	for i := range expected {
+		if devices[i] != expected[i] {
+			t.Fatalf("Expected device %v to be %#v but got %#v", i, expected[i], devices[i])
+		}
+	}
+}
+
+func TestAcquireDoesNotDoubleAllocate(t *testing.T) {
+	devices := ParseDeviceList(nvidiaSMIOutput)
+	leaseDir := t.TempDir()
+	// two allocators sharing a lease directory behave like two worker
+	// processes on the same host
+	a1, err := NewAllocator(devices, leaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := NewAllocator(devices, leaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l1, err := a1.Acquire(2)
+	if err != nil {
+		t.Fatalf("Could not acquire 2 devices: %v", err)
+	}
+	_, err = a2.Acquire(2)
+	if !errors.Is(err, ErrInsufficientDevices) {
+		t.Fatalf("Expected ErrInsufficientDevices but got %v", err)
+	}
+	l2, err := a2.Acquire(1)
+	if err != nil {
+		t.Fatalf("Could not acquire remaining device: %v", err)
+	}
+	if l2.UUIDs() != devices[2].UUID {
+		t.Fatalf("Expected lease of %v but got %v", devices[2].UUID, l2.UUIDs())
+	}
+
+	err = l1.Release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l3, err := a2.Acquire(2)
+	if err != nil {
+		t.Fatalf("Could not acquire released devices: %v", err)
+	}
+	if l3.UUIDs() != devices[0].UUID+","+devices[1].UUID {
+		t.Fatalf("Expected lease of released devices but got %v", l3.UUIDs())
+	}
+	_ = l2.Release()
+	_ = l3.Release()
+}
@@ -0,0 +1,128 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/gpu"
+)
+
+// gpuLeasePollInterval is how often a task that is waiting for GPUs checks
+// whether enough have become free
+const gpuLeasePollInterval = 5 * time.Second
+
+// GPUFeature leases GPUs to tasks that request them with payload.gpus, when
+// config setting enableGPUs is true.
+type GPUFeature struct {
+	allocator *gpu.Allocator
+}
+
+func (feature *GPUFeature) Name() string {
+	return "GPUs"
+}
+
+func (feature *GPUFeature) Initialise() error {
+	if !config.EnableGPUs {
+		return nil
+	}
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("worker config setting enableGPUs is only supported on Linux")
+	}
+	devices, err := gpu.Inventory(config.NvidiaSMIExecutable)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		log.Printf("Found GPU %v (%v)", device.UUID, device.Name)
+	}
+	feature.allocator, err = gpu.NewAllocator(devices, config.GPULeaseDir)
+	return err
+}
+
+func (feature *GPUFeature) PersistState() error {
+	return nil
+}
+
+func (feature *GPUFeature) IsEnabled(task *TaskRun) bool {
+	return task.Payload.Gpus > 0
+}
+
+func (feature *GPUFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &GPUTask{
+		task:      task,
+		allocator: feature.allocator,
+	}
+}
+
+type GPUTask struct {
+	task      *TaskRun
+	allocator *gpu.Allocator
+	lease     *gpu.Lease
+}
+
+func (gt *GPUTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{
+		{"generic-worker:gpu:" + config.ProvisionerID + "/" + config.WorkerType},
+	}
+}
+
+func (gt *GPUTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (gt *GPUTask) Start() *CommandExecutionError {
+	requested := int(gt.task.Payload.Gpus)
+	if gt.allocator == nil {
+		return MalformedPayloadError(fmt.Errorf("Task requests %v GPU(s) but GPUs are not enabled on this worker (worker config setting enableGPUs is false)", requested))
+	}
+	if available := len(gt.allocator.Devices()); requested > available {
+		return MalformedPayloadError(fmt.Errorf("Task requests %v GPU(s) but this worker only has %v", requested, available))
+	}
+	deadline := time.Now().Add(time.Duration(gt.task.Payload.MaxRunTime) * time.Second)
+	waiting := false
+	for {
+		lease, err := gt.allocator.Acquire(requested)
+		if err == nil {
+			gt.lease = lease
+			break
+		}
+		if !errors.Is(err, gpu.ErrInsufficientDevices) {
+			return executionError(internalError, errored, fmt.Errorf("Could not lease GPUs: %v", err))
+		}
+		if time.Now().After(deadline) {
+			return ResourceUnavailable(fmt.Errorf("%v GPU(s) did not become free within %v seconds", requested, gt.task.Payload.MaxRunTime))
+		}
+		if !waiting {
+			gt.task.Infof("Waiting for %v GPU(s) to become free...", requested)
+			waiting = true
+		}
+		time.Sleep(gpuLeasePollInterval)
+	}
+	uuids := gt.lease.UUIDs()
+	for _, variable := range []string{"CUDA_VISIBLE_DEVICES", "NVIDIA_VISIBLE_DEVICES"} {
+		err := gt.task.setVariable(variable, uuids)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could not set %v environment variable: %v", variable, err))
+		}
+	}
+	gt.task.Infof("Leased GPU(s) %v", uuids)
+	return nil
+}
+
+func (gt *GPUTask) Stop(err *ExecutionErrors) {
+	if gt.lease == nil {
+		return
+	}
+	releaseErr := gt.lease.Release()
+	if releaseErr != nil {
+		err.add(executionError(internalError, errored, fmt.Errorf("Could not release GPU(s) %v: %v", gt.lease.UUIDs(), releaseErr)))
+		return
+	}
+	gt.task.Infof("Released GPU(s) %v", gt.lease.UUIDs())
+}
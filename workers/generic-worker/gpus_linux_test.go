@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestGPUsNotEnabled(t *testing.T) {
+	setup(t)
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Gpus:       1,
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+	td.Scopes = append(td.Scopes, "generic-worker:gpu:"+td.ProvisionerID+"/"+td.WorkerType)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "GPUs are not enabled on this worker") {
+		t.Fatalf("Expected log file to mention GPUs are not enabled, but it didn't\n%s", logtext)
+	}
+}
@@ -1,8 +1,14 @@
 package graceful
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
-type GracefulTerminationFunc func(bool)
+// GracefulTerminationFunc is called with whether running tasks may be
+// finished, and the time at which the worker will be forcibly terminated,
+// which is the zero time if not known.
+type GracefulTerminationFunc func(finishTasks bool, deadline time.Time)
 
 var (
 	// Mutex for access to other vars
@@ -44,14 +50,21 @@ func OnTerminationRequest(f GracefulTerminationFunc) func() {
 }
 
 // A graceful termination has been requested.  Set a flag so that no further
-// tasks are claimed, and interrupt any running task if `finishTasks` is true
+// tasks are claimed, and interrupt any running task if `finishTasks` is false
 func Terminate(finishTasks bool) {
+	TerminateBy(finishTasks, time.Time{})
+}
+
+// Like Terminate, but the worker will be forcibly terminated at the given
+// deadline, so that a running task may be given until shortly before then to
+// complete, rather than being interrupted immediately.
+func TerminateBy(finishTasks bool, deadline time.Time) {
 	m.Lock()
 	defer m.Unlock()
 
 	terminationRequested = true
 	if callback != nil {
-		callback(finishTasks)
+		callback(finishTasks, deadline)
 	}
 }
 
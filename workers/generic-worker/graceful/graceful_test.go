@@ -2,6 +2,7 @@ package graceful
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -21,7 +22,7 @@ func TestGracefulTermination(t *testing.T) {
 	cleanup()
 	t.Run("WithCallback", func(t *testing.T) {
 		var res *bool // pointer is to distinguish nil from false
-		OnTerminationRequest(func(finishTasks bool) { res = &finishTasks })
+		OnTerminationRequest(func(finishTasks bool, deadline time.Time) { res = &finishTasks })
 		Terminate(false)
 		require.Equal(t, false, *res)
 		require.Equal(t, true, TerminationRequested())
@@ -30,11 +31,11 @@ func TestGracefulTermination(t *testing.T) {
 	cleanup()
 	t.Run("WithRemovedCallback", func(t *testing.T) {
 		var cb1 *bool
-		remove1 := OnTerminationRequest(func(finishTasks bool) { cb1 = &finishTasks })
+		remove1 := OnTerminationRequest(func(finishTasks bool, deadline time.Time) { cb1 = &finishTasks })
 		remove1()
 
 		var cb2 *bool
-		OnTerminationRequest(func(finishTasks bool) { cb2 = &finishTasks })
+		OnTerminationRequest(func(finishTasks bool, deadline time.Time) { cb2 = &finishTasks })
 
 		Terminate(true)
 
@@ -42,4 +43,14 @@ func TestGracefulTermination(t *testing.T) {
 		require.Equal(t, true, *cb2)
 		require.Equal(t, true, TerminationRequested())
 	})
+
+	cleanup()
+	t.Run("WithDeadline", func(t *testing.T) {
+		var got time.Time
+		OnTerminationRequest(func(finishTasks bool, deadline time.Time) { got = deadline })
+		deadline := time.Now().Add(30 * time.Second)
+		TerminateBy(false, deadline)
+		require.Equal(t, deadline, got)
+		require.Equal(t, true, TerminationRequested())
+	})
 }
@@ -26,17 +26,26 @@ type (
 
 	PublicConfig struct {
 		PublicEngineConfig
+		AllowedUSBDevices              []string               `json:"allowedUSBDevices"`
 		AvailabilityZone               string                 `json:"availabilityZone"`
+		BubblewrapExecutable           string                 `json:"bubblewrapExecutable"`
 		CachesDir                      string                 `json:"cachesDir"`
 		CheckForNewDeploymentEverySecs uint                   `json:"checkForNewDeploymentEverySecs"`
 		CleanUpTaskDirs                bool                   `json:"cleanUpTaskDirs"`
 		ClientID                       string                 `json:"clientId"`
 		CreateObjectArtifacts          bool                   `json:"createObjectArtifacts"`
+		DefaultShell                   string                 `json:"defaultShell"`
 		DeploymentID                   string                 `json:"deploymentId"`
 		DisableReboots                 bool                   `json:"disableReboots"`
+		DockerExecutable               string                 `json:"dockerExecutable"`
 		DownloadsDir                   string                 `json:"downloadsDir"`
 		Ed25519SigningKeyLocation      string                 `json:"ed25519SigningKeyLocation"`
+		EnableGPUs                     bool                   `json:"enableGPUs"`
 		EnableInteractive              bool                   `json:"enableInteractive"`
+		EnableSandbox                  bool                   `json:"enableSandbox"`
+		EnableVirtualMachines          bool                   `json:"enableVirtualMachines"`
+		EnableWindowsContainers        bool                   `json:"enableWindowsContainers"`
+		GPULeaseDir                    string                 `json:"gpuLeaseDir"`
 		IdleTimeoutSecs                uint                   `json:"idleTimeoutSecs"`
 		InstanceID                     string                 `json:"instanceId"`
 		InstanceType                   string                 `json:"instanceType"`
@@ -48,19 +57,35 @@ type (
 		LoopbackVideoDeviceNumber      uint8                  `json:"loopbackVideoDeviceNumber"`
 		MaxTaskRunTime                 uint32                 `json:"maxTaskRunTime"`
 		NumberOfTasksToRun             uint                   `json:"numberOfTasksToRun"`
+		NvidiaSMIExecutable            string                 `json:"nvidiaSmiExecutable"`
+		OverlayRootImage               string                 `json:"overlayRootImage"`
+		Paused                         bool                   `json:"paused"`
 		PrivateIP                      net.IP                 `json:"privateIP"`
 		ProvisionerID                  string                 `json:"provisionerId"`
 		PublicIP                       net.IP                 `json:"publicIP"`
+		PwshExecutable                 string                 `json:"pwshExecutable"`
+		QEMUExecutable                 string                 `json:"qemuExecutable"`
+		QEMUImgExecutable              string                 `json:"qemuImgExecutable"`
 		Region                         string                 `json:"region"`
 		RequiredDiskSpaceMegabytes     uint                   `json:"requiredDiskSpaceMegabytes"`
 		RootURL                        string                 `json:"rootURL"`
 		RunAfterUserCreation           string                 `json:"runAfterUserCreation"`
+		SandboxSeccompProfile          string                 `json:"sandboxSeccompProfile"`
 		SentryProject                  string                 `json:"sentryProject"`
 		ShutdownMachineOnIdle          bool                   `json:"shutdownMachineOnIdle"`
 		ShutdownMachineOnInternalError bool                   `json:"shutdownMachineOnInternalError"`
+		SuppressBackgroundActivity     bool                   `json:"suppressBackgroundActivity"`
 		TaskclusterProxyExecutable     string                 `json:"taskclusterProxyExecutable"`
 		TaskclusterProxyPort           uint16                 `json:"taskclusterProxyPort"`
+		TartExecutable                 string                 `json:"tartExecutable"`
 		TasksDir                       string                 `json:"tasksDir"`
+		TaskUserPoolSize               uint                   `json:"taskUserPoolSize"`
+		TerminationAbortMarginSecs     uint                   `json:"terminationAbortMarginSecs"`
+		VirtiofsdExecutable            string                 `json:"virtiofsdExecutable"`
+		VirtualMachineBootTimeoutSecs  uint                   `json:"virtualMachineBootTimeoutSecs"`
+		VirtualMachineSSHPrivateKey    string                 `json:"virtualMachineSSHPrivateKey"`
+		VirtualMachineSSHUser          string                 `json:"virtualMachineSSHUser"`
+		WestonExecutable               string                 `json:"westonExecutable"`
 		WorkerGroup                    string                 `json:"workerGroup"`
 		WorkerID                       string                 `json:"workerId"`
 		WorkerLocation                 string                 `json:"workerLocation,omitempty"`
@@ -68,6 +93,7 @@ type (
 		WorkerTypeMetadata             map[string]interface{} `json:"workerTypeMetadata"`
 		WSTAudience                    string                 `json:"wstAudience"`
 		WSTServerURL                   string                 `json:"wstServerURL"`
+		XvfbExecutable                 string                 `json:"xvfbExecutable"`
 	}
 
 	PrivateConfig struct {
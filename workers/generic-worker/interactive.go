@@ -12,7 +12,7 @@ import (
 	"time"
 
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/artifacts"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/expose"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/interactive"
@@ -52,8 +52,8 @@ func (feature *InteractiveFeature) NewTaskFeature(task *TaskRun) TaskFeature {
 	}
 }
 
-func (it *InteractiveTask) RequiredScopes() scopes.Required {
-	return scopes.Required{}
+func (it *InteractiveTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{}
 }
 
 func (it *InteractiveTask) ReservedArtifacts() []string {
@@ -0,0 +1,54 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import "github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+
+type KeychainFeature struct {
+}
+
+func (feature *KeychainFeature) Name() string {
+	return "Keychain"
+}
+
+func (feature *KeychainFeature) Initialise() error {
+	return nil
+}
+
+func (feature *KeychainFeature) PersistState() error {
+	return nil
+}
+
+func (feature *KeychainFeature) IsEnabled(task *TaskRun) bool {
+	return task.Payload.Features.Keychain
+}
+
+func (feature *KeychainFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &KeychainTask{
+		task: task,
+	}
+}
+
+type KeychainTask struct {
+	task *TaskRun
+	// the keychain, once created
+	keychain *taskKeychain
+}
+
+func (kt *KeychainTask) RequiredScopes() tcscopes.Required {
+	// secrets are fetched with the task credentials, so the secrets service
+	// enforces that the task has the secrets:get:<secret> scopes
+	return tcscopes.Required{}
+}
+
+func (kt *KeychainTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (kt *KeychainTask) Start() *CommandExecutionError {
+	return kt.createKeychain()
+}
+
+func (kt *KeychainTask) Stop(err *ExecutionErrors) {
+	err.add(kt.deleteKeychain())
+}
@@ -0,0 +1,160 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/taskcluster/slugid-go/slugid"
+)
+
+const securityExecutable = "/usr/bin/security"
+
+// taskKeychain is an unlocked keychain in the task directory, that belongs to
+// the task user, and that is deleted when the task completes. All security(1)
+// commands for the keychain run as the task user (see securityCommand), since
+// a keychain unlocked by the worker user would still be locked for the task
+// user.
+type taskKeychain struct {
+	task *TaskRun
+	// prefix of messages logged to the task log, and of errors
+	prefix   string
+	path     string
+	password string
+}
+
+func newTaskKeychain(task *TaskRun, prefix, fileName string) (*taskKeychain, *CommandExecutionError) {
+	path := filepath.Join(taskContext.TaskDir, fileName)
+	password := slugid.Nice()
+	err := runSecurity("create-keychain", "-p", password, path)
+	if err != nil {
+		return nil, executionError(internalError, errored, fmt.Errorf("%v Could not create keychain %v: %v", prefix, path, err))
+	}
+	k := &taskKeychain{
+		task:     task,
+		prefix:   prefix,
+		path:     path,
+		password: password,
+	}
+	// without a timeout, the keychain is never locked automatically
+	err = runSecurity("set-keychain-settings", k.path)
+	if err != nil {
+		return k, executionError(internalError, errored, fmt.Errorf("%v Could not configure keychain %v: %v", prefix, k.path, err))
+	}
+	err = runSecurity("unlock-keychain", "-p", password, k.path)
+	if err != nil {
+		return k, executionError(internalError, errored, fmt.Errorf("%v Could not unlock keychain %v: %v", prefix, k.path, err))
+	}
+	return k, nil
+}
+
+// importCertificates imports the given certificates into the keychain.
+func (k *taskKeychain) importCertificates(certs []SecretCertificate) *CommandExecutionError {
+	for _, cert := range certs {
+		cee := k.importCertificate(cert)
+		if cee != nil {
+			return cee
+		}
+	}
+	if len(certs) > 0 {
+		// allow codesign and other Apple tools to use the private keys without
+		// showing a confirmation dialog
+		err := runSecurity("set-key-partition-list", "-S", "apple-tool:,apple:,codesign:", "-s", "-k", k.password, k.path)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("%v Could not set key partition list of keychain %v: %v", k.prefix, k.path, err))
+		}
+	}
+	return nil
+}
+
+func (k *taskKeychain) importCertificate(cert SecretCertificate) *CommandExecutionError {
+	bundle, bundlePassword, err := k.task.fetchSecretCertificate(cert)
+	if err != nil {
+		return MalformedPayloadError(fmt.Errorf("%v %v", k.prefix, err))
+	}
+	// the task directory is accessible to the task user, unlike the worker
+	// user's temp directory, and the bundle is removed before the task runs
+	bundleFile, err := os.CreateTemp(taskContext.TaskDir, "certificate-*.p12")
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("%v Could not create temporary file for certificate: %v", k.prefix, err))
+	}
+	defer os.Remove(bundleFile.Name())
+	_, err = bundleFile.Write(bundle)
+	if closeErr := bundleFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("%v Could not write certificate to temporary file %v: %v", k.prefix, bundleFile.Name(), err))
+	}
+	err = makeFileOrDirReadWritableForUser(false, bundleFile.Name(), taskContext.User)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("%v Could not make temporary file %v readable for task user: %v", k.prefix, bundleFile.Name(), err))
+	}
+	err = runSecurity(
+		"import", bundleFile.Name(),
+		"-k", k.path,
+		"-f", "pkcs12",
+		"-P", bundlePassword,
+		"-T", "/usr/bin/codesign",
+		"-T", "/usr/bin/productsign",
+		"-T", securityExecutable,
+	)
+	if err != nil {
+		return MalformedPayloadError(fmt.Errorf("%v Could not import certificate from secret %v: %v", k.prefix, cert.Secret, err))
+	}
+	k.task.Infof("%v Imported certificate from secret %v", k.prefix, cert.Secret)
+	return nil
+}
+
+// delete deletes the keychain. It is safe to call on a nil *taskKeychain.
+func (k *taskKeychain) delete() *CommandExecutionError {
+	if k == nil {
+		return nil
+	}
+	err := runSecurity("delete-keychain", k.path)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("%v Could not delete keychain %v: %v", k.prefix, k.path, err))
+	}
+	return nil
+}
+
+// runSecurity runs security(1) with the given arguments, as the task user.
+// Unlike host.Run, it does not log the arguments, since they may include
+// passwords.
+func runSecurity(args ...string) error {
+	log.Printf("Running command: %v %v ...", securityExecutable, args[0])
+	cmd, err := securityCommand(args...)
+	if err != nil {
+		return err
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v (output: %s)", err, out)
+	}
+	return nil
+}
+
+func (kt *KeychainTask) createKeychain() *CommandExecutionError {
+	var cee *CommandExecutionError
+	kt.keychain, cee = newTaskKeychain(kt.task, "[keychain]", "task.keychain-db")
+	if cee != nil {
+		return cee
+	}
+	cee = kt.keychain.importCertificates(kt.task.Payload.KeychainCertificates)
+	if cee != nil {
+		return cee
+	}
+	err := kt.task.setVariable("TASKCLUSTER_KEYCHAIN", kt.keychain.path)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[keychain] Could not set TASKCLUSTER_KEYCHAIN environment variable: %v", err))
+	}
+	kt.task.Infof("[keychain] Unlocked keychain with %v certificate(s) is available at %v", len(kt.task.Payload.KeychainCertificates), kt.keychain.path)
+	return nil
+}
+
+func (kt *KeychainTask) deleteKeychain() *CommandExecutionError {
+	return kt.keychain.delete()
+}
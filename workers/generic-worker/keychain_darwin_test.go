@@ -0,0 +1,43 @@
+//go:build darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestKeychainUnlockedForTaskUser(t *testing.T) {
+	setup(t)
+	payload := GenericWorkerPayload{
+		Command: [][]string{
+			{"/bin/bash", "-c", `test -n "${TASKCLUSTER_KEYCHAIN}" && test -O "${TASKCLUSTER_KEYCHAIN}" && echo 'keychain owned by task user'`},
+			// adding an item fails, rather than prompting for the password,
+			// if the keychain is locked
+			{"/usr/bin/security", "add-generic-password", "-a", "generic-worker", "-s", "keychain-test", "-w", "not-a-secret", "task.keychain-db"},
+			{"/bin/bash", "-c", `security find-generic-password -s keychain-test "${TASKCLUSTER_KEYCHAIN}" && echo 'keychain unlocked for task user'`},
+		},
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			Keychain: true,
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "completed", "completed")
+
+	logtext := LogText(t)
+	for _, expected := range []string{"keychain owned by task user", "keychain unlocked for task user"} {
+		if !strings.Contains(logtext, expected) {
+			t.Fatalf("Expected log file to contain %q, but it didn't\n%s", expected, logtext)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(taskContext.TaskDir, "task.keychain-db")); !os.IsNotExist(err) {
+		t.Fatalf("Expected keychain to be deleted when the task completed, but got: %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package main
+
+import "fmt"
+
+// taskKeychain is only implemented on macOS
+type taskKeychain struct {
+}
+
+func (kt *KeychainTask) createKeychain() *CommandExecutionError {
+	return MalformedPayloadError(fmt.Errorf("Keychains are not supported on Linux"))
+}
+
+func (kt *KeychainTask) deleteKeychain() *CommandExecutionError {
+	return nil
+}
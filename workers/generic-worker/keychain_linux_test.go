@@ -0,0 +1,30 @@
+//go:build linux
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestKeychainNotSupported(t *testing.T) {
+	setup(t)
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			Keychain: true,
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "Keychains are not supported on Linux") {
+		t.Fatalf("Expected log file to mention keychains are not supported, but it didn't\n%s", logtext)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build multiuser && darwin
+
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/process"
+	gwruntime "github.com/taskcluster/taskcluster/v60/workers/generic-worker/runtime"
+)
+
+// securityCommand returns a command that runs security(1) with the given
+// arguments as the task user, so that task keychains belong to, and are
+// unlocked in the security session of, the task user that uses them.
+func securityCommand(args ...string) (*exec.Cmd, error) {
+	if config.RunTasksAsCurrentUser {
+		return exec.Command(securityExecutable, args...), nil
+	}
+	env := []string{
+		"HOME=" + filepath.Join(gwruntime.UserHomeDirectoriesParent(), taskContext.User.Name),
+		"USER=" + taskContext.User.Name,
+	}
+	cmd, err := process.NewCommandNoOutputStreams(append([]string{securityExecutable}, args...), taskContext.TaskDir, env, taskContext.pd)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Cmd, nil
+}
@@ -0,0 +1,12 @@
+//go:build simple && darwin
+
+package main
+
+import "os/exec"
+
+// securityCommand returns a command that runs security(1) with the given
+// arguments. The simple engine runs tasks as the worker user, so security is
+// run as the worker user too.
+func securityCommand(args ...string) (*exec.Cmd, error) {
+	return exec.Command(securityExecutable, args...), nil
+}
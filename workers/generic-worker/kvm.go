@@ -0,0 +1,63 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"os"
+	"os/user"
+
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+)
+
+type KVMFeature struct {
+}
+
+func (feature *KVMFeature) Name() string {
+	return "KVM"
+}
+
+func (feature *KVMFeature) Initialise() error {
+	return nil
+}
+
+func (feature *KVMFeature) PersistState() error {
+	return nil
+}
+
+func (feature *KVMFeature) IsEnabled(task *TaskRun) bool {
+	return task.Payload.Features.KVM
+}
+
+func (feature *KVMFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &KVMTask{
+		task: task,
+	}
+}
+
+type KVMTask struct {
+	task *TaskRun
+	// group owning the kvm device that the task user was added to, if any
+	group *user.Group
+	// whether the permissions of the kvm device were changed, and if so,
+	// what they were before the task started
+	modeChanged  bool
+	originalMode os.FileMode
+}
+
+func (kt *KVMTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{
+		{"generic-worker:kvm:" + config.ProvisionerID + "/" + config.WorkerType},
+	}
+}
+
+func (kt *KVMTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (kt *KVMTask) Start() *CommandExecutionError {
+	return kt.setupKVM()
+}
+
+func (kt *KVMTask) Stop(err *ExecutionErrors) {
+	err.add(kt.resetKVM())
+}
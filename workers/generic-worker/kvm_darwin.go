@@ -0,0 +1,13 @@
+//go:build darwin
+
+package main
+
+import "fmt"
+
+func (kt *KVMTask) setupKVM() *CommandExecutionError {
+	return MalformedPayloadError(fmt.Errorf("[kvm] KVM is not supported on macOS"))
+}
+
+func (kt *KVMTask) resetKVM() *CommandExecutionError {
+	return nil
+}
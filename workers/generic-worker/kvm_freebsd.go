@@ -0,0 +1,13 @@
+//go:build freebsd
+
+package main
+
+import "fmt"
+
+func (kt *KVMTask) setupKVM() *CommandExecutionError {
+	return MalformedPayloadError(fmt.Errorf("[kvm] KVM is not supported on FreeBSD"))
+}
+
+func (kt *KVMTask) resetKVM() *CommandExecutionError {
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const kvmDevice = "/dev/kvm"
+
+func (kt *KVMTask) setupKVM() *CommandExecutionError {
+	if _, err := os.Stat(kvmDevice); err != nil {
+		return MalformedPayloadError(fmt.Errorf("[kvm] Device %v is not available on this worker: %v", kvmDevice, err))
+	}
+	cee := kt.grantKVMAccess()
+	if cee != nil {
+		return cee
+	}
+	kt.task.Infof("[kvm] Task user has read/write access to %v", kvmDevice)
+	return nil
+}
+
+func (kt *KVMTask) resetKVM() *CommandExecutionError {
+	return kt.revokeKVMAccess()
+}
@@ -0,0 +1,73 @@
+//go:build multiuser && linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// grantKVMAccess adds the task user to the group that owns the kvm device,
+// making the device group readable and writable if it isn't already.
+func (kt *KVMTask) grantKVMAccess() *CommandExecutionError {
+	if config.RunTasksAsCurrentUser {
+		err := unix.Access(kvmDevice, unix.R_OK|unix.W_OK)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[kvm] Worker user does not have read/write access to %v: %v", kvmDevice, err))
+		}
+		return nil
+	}
+	info, err := os.Stat(kvmDevice)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[kvm] Could not stat %v: %v", kvmDevice, err))
+	}
+	gid := info.Sys().(*syscall.Stat_t).Gid
+	if gid == 0 {
+		return executionError(internalError, errored, fmt.Errorf("[kvm] Device %v is owned by group root; refusing to add task user to it. Configure the worker so that %v is owned by a dedicated group (e.g. kvm).", kvmDevice, kvmDevice))
+	}
+	group, err := user.LookupGroupId(strconv.Itoa(int(gid)))
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[kvm] Could not look up group %v owning %v: %v", gid, kvmDevice, err))
+	}
+	err = addUserToGroup(taskContext.User.Name, group.Name)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[kvm] Could not add task user to group %v: %v", group.Name, err))
+	}
+	kt.group = group
+	// commands are created before features start, so their supplementary
+	// groups need updating too
+	for _, command := range kt.task.Commands {
+		command.SysProcAttr.Credential.Groups = append(command.SysProcAttr.Credential.Groups, gid)
+	}
+	mode := info.Mode().Perm()
+	if mode&0060 != 0060 {
+		err = os.Chmod(kvmDevice, mode|0060)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[kvm] Could not make %v group readable and writable: %v", kvmDevice, err))
+		}
+		kt.modeChanged = true
+		kt.originalMode = mode
+	}
+	return nil
+}
+
+func (kt *KVMTask) revokeKVMAccess() *CommandExecutionError {
+	if kt.modeChanged {
+		err := os.Chmod(kvmDevice, kt.originalMode)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[kvm] Could not restore permissions %v of %v: %v", kt.originalMode, kvmDevice, err))
+		}
+	}
+	if kt.group != nil {
+		err := removeUserFromGroup(taskContext.User.Name, kt.group.Name)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[kvm] Could not remove task user from group %v: %v", kt.group.Name, err))
+		}
+	}
+	return nil
+}
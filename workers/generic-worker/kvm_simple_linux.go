@@ -0,0 +1,24 @@
+//go:build simple && linux
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// grantKVMAccess checks that the worker user, which the simple engine runs
+// tasks as, can use the kvm device. The simple engine does not manage
+// device permissions, since tasks run as the worker user.
+func (kt *KVMTask) grantKVMAccess() *CommandExecutionError {
+	err := unix.Access(kvmDevice, unix.R_OK|unix.W_OK)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[kvm] Worker user does not have read/write access to %v: %v", kvmDevice, err))
+	}
+	return nil
+}
+
+func (kt *KVMTask) revokeKVMAccess() *CommandExecutionError {
+	return nil
+}
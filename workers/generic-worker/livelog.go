@@ -8,7 +8,7 @@ import (
 	"time"
 
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/artifacts"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/expose"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/livelog"
@@ -55,9 +55,9 @@ func (feature *LiveLogFeature) NewTaskFeature(task *TaskRun) TaskFeature {
 	}
 }
 
-func (l *LiveLogTask) RequiredScopes() scopes.Required {
+func (l *LiveLogTask) RequiredScopes() tcscopes.Required {
 	// let's not require any scopes, as I see no reason to control access to this feature
-	return scopes.Required{}
+	return tcscopes.Required{}
 }
 
 func (l *LiveLogTask) Start() *CommandExecutionError {
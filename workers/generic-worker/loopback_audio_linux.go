@@ -4,17 +4,28 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
 )
 
 func (lat *LoopbackAudioTask) setupAudioDevice() *CommandExecutionError {
-	opts := fmt.Sprintf("options snd-aloop enable=1 index=%d", config.LoopbackAudioDeviceNumber)
-	out, err := host.CombinedOutput("/usr/bin/env", "bash", "-c", fmt.Sprintf("/usr/bin/echo %s > /etc/modprobe.d/snd-aloop.conf", opts))
+	out, err := host.CombinedOutput("/usr/bin/env", "bash", "-c", fmt.Sprintf("/usr/bin/echo %s > /etc/modprobe.d/snd-aloop.conf", lat.moduleOptions()))
 	if err != nil {
 		return executionError(internalError, errored, fmt.Errorf("Could not set snd-aloop kernel module options. Output: %s. Error: %v.", out, err))
 	}
 
+	if len(lat.task.Payload.LoopbackAudioDevices) > 0 {
+		// module options only take effect when the module is loaded, so
+		// unload it in case it was loaded by a previous task with different
+		// options
+		out, err = host.CombinedOutput("/usr/sbin/modprobe", "-r", "snd-aloop")
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could not unload the snd-aloop kernel module in order to apply the requested device parameters. Output: %s. Error: %v.", out, err))
+		}
+	}
+
 	out, err = host.CombinedOutput("/usr/sbin/modprobe", "snd-aloop")
 	if err != nil {
 		return executionError(internalError, errored, fmt.Errorf("Could not load the snd-aloop kernel module. Output: %s. Error: %v.", out, err))
@@ -34,11 +45,43 @@ func (lat *LoopbackAudioTask) setupAudioDevice() *CommandExecutionError {
 		}
 	}
 
+	for i, cardNumber := range lat.cardNumbers {
+		name := fmt.Sprintf("TASKCLUSTER_AUDIO_DEVICE_%d", i)
+		err = lat.task.setVariable(name, fmt.Sprintf("hw:%d", cardNumber))
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could not set %s environment variable: %v", name, err))
+		}
+	}
+	err = lat.task.setVariable("TASKCLUSTER_AUDIO_DEVICE", fmt.Sprintf("hw:%d", lat.cardNumbers[0]))
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("Could not set TASKCLUSTER_AUDIO_DEVICE environment variable: %v", err))
+	}
+
 	lat.task.Infof("Loopback audio devices are available at %v", lat.devicePaths)
 
 	return nil
 }
 
+// moduleOptions returns the modprobe.d options line to configure the
+// snd-aloop kernel module with the devices requested in the task payload.
+func (lat *LoopbackAudioTask) moduleOptions() string {
+	if len(lat.task.Payload.LoopbackAudioDevices) == 0 {
+		return fmt.Sprintf("options snd-aloop enable=1 index=%d", config.LoopbackAudioDeviceNumber)
+	}
+	enable := make([]string, len(lat.devices))
+	index := make([]string, len(lat.devices))
+	substreams := make([]string, len(lat.devices))
+	for i, device := range lat.devices {
+		enable[i] = "1"
+		index[i] = strconv.Itoa(lat.cardNumbers[i])
+		if device.Substreams == 0 {
+			device.Substreams = 8
+		}
+		substreams[i] = strconv.Itoa(int(device.Substreams))
+	}
+	return "options snd-aloop enable=" + strings.Join(enable, ",") + " index=" + strings.Join(index, ",") + " pcm_substreams=" + strings.Join(substreams, ",")
+}
+
 func (lat *LoopbackAudioTask) resetAudioDevice() *CommandExecutionError {
 	for _, devicePath := range lat.devicePaths {
 		chownErr := makeDirUnreadableForUser(devicePath, taskContext.User)
@@ -150,3 +150,28 @@ func TestLoopbackAudioInvalidDeviceNumber(t *testing.T) {
 
 	_ = submitAndAssert(t, td, payload, "exception", "internal-error")
 }
+
+func TestLoopbackAudioModuleOptions(t *testing.T) {
+	setup(t)
+
+	config.LoopbackAudioDeviceNumber = 16
+	task := &TaskRun{
+		Payload: GenericWorkerPayload{
+			LoopbackAudioDevices: []LoopbackAudioDevice{
+				{},
+				{
+					Substreams: 2,
+				},
+			},
+		},
+	}
+	lat := (&LoopbackAudioFeature{}).NewTaskFeature(task).(*LoopbackAudioTask)
+
+	if len(lat.devicePaths) != 10 || lat.devicePaths[5] != "/dev/snd/controlC17" {
+		t.Fatalf("Expected 10 device paths including /dev/snd/controlC17 but got %v", lat.devicePaths)
+	}
+	expected := "options snd-aloop enable=1,1 index=16,17 pcm_substreams=8,2"
+	if options := lat.moduleOptions(); options != expected {
+		t.Fatalf("Expected snd-aloop options %q but got %q", expected, options)
+	}
+}
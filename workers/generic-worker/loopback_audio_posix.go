@@ -5,7 +5,7 @@ package main
 import (
 	"fmt"
 
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 )
 
 type LoopbackAudioFeature struct {
@@ -28,25 +28,41 @@ func (feature *LoopbackAudioFeature) IsEnabled(task *TaskRun) bool {
 }
 
 func (feature *LoopbackAudioFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	devices := task.Payload.LoopbackAudioDevices
+	if len(devices) == 0 {
+		devices = []LoopbackAudioDevice{{}}
+	}
+	cardNumbers := make([]int, len(devices))
+	devicePaths := []string{}
+	for i := range devices {
+		cardNumbers[i] = int(config.LoopbackAudioDeviceNumber) + i
+		devicePaths = append(
+			devicePaths,
+			fmt.Sprintf("/dev/snd/controlC%d", cardNumbers[i]),
+			fmt.Sprintf("/dev/snd/pcmC%dD0c", cardNumbers[i]),
+			fmt.Sprintf("/dev/snd/pcmC%dD0p", cardNumbers[i]),
+			fmt.Sprintf("/dev/snd/pcmC%dD1c", cardNumbers[i]),
+			fmt.Sprintf("/dev/snd/pcmC%dD1p", cardNumbers[i]),
+		)
+	}
 	return &LoopbackAudioTask{
-		task: task,
-		devicePaths: []string{
-			fmt.Sprintf("/dev/snd/controlC%d", config.LoopbackAudioDeviceNumber),
-			fmt.Sprintf("/dev/snd/pcmC%dD0c", config.LoopbackAudioDeviceNumber),
-			fmt.Sprintf("/dev/snd/pcmC%dD0p", config.LoopbackAudioDeviceNumber),
-			fmt.Sprintf("/dev/snd/pcmC%dD1c", config.LoopbackAudioDeviceNumber),
-			fmt.Sprintf("/dev/snd/pcmC%dD1p", config.LoopbackAudioDeviceNumber),
-		},
+		task:        task,
+		devices:     devices,
+		cardNumbers: cardNumbers,
+		devicePaths: devicePaths,
 	}
 }
 
 type LoopbackAudioTask struct {
-	task        *TaskRun
+	task *TaskRun
+	// devices[i] is created as sound card cardNumbers[i]
+	devices     []LoopbackAudioDevice
+	cardNumbers []int
 	devicePaths []string
 }
 
-func (lat *LoopbackAudioTask) RequiredScopes() scopes.Required {
-	return scopes.Required{
+func (lat *LoopbackAudioTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{
 		{"generic-worker:loopback-audio:" + config.ProvisionerID + "/" + config.WorkerType},
 		{"generic-worker:loopback-audio"},
 	}
@@ -60,6 +76,9 @@ func (lat *LoopbackAudioTask) Start() *CommandExecutionError {
 	if config.LoopbackAudioDeviceNumber > 31 {
 		return executionError(internalError, errored, fmt.Errorf("LoopbackAudioDeviceNumber must be between 0 and 31, inclusive."))
 	}
+	if int(config.LoopbackAudioDeviceNumber)+len(lat.devices) > 32 {
+		return executionError(internalError, errored, fmt.Errorf("LoopbackAudioDeviceNumber (%v) is too high to create %v loopback audio devices; sound card numbers must be between 0 and 31, inclusive.", config.LoopbackAudioDeviceNumber, len(lat.devices)))
+	}
 
 	return lat.setupAudioDevice()
 }
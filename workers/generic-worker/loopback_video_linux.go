@@ -4,40 +4,106 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
 )
 
 func (lvt *LoopbackVideoTask) setupVideoDevice() *CommandExecutionError {
-	err := host.Run("/usr/sbin/modprobe", "v4l2loopback", fmt.Sprintf("video_nr=%d", config.LoopbackVideoDeviceNumber))
-	if err != nil {
-		return executionError(internalError, errored, fmt.Errorf("Could not load the v4l2loopback kernel module: %v", err))
+	if len(lvt.task.Payload.LoopbackVideoDevices) > 0 {
+		// module parameters only take effect when the module is loaded, so
+		// unload it in case it was loaded by a previous task with different
+		// parameters
+		err := host.Run("/usr/sbin/modprobe", "-r", "v4l2loopback")
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could not unload the v4l2loopback kernel module in order to apply the requested device parameters: %v", err))
+		}
 	}
 
-	err = host.Run("/bin/chmod", "660", lvt.devicePath)
+	err := host.Run("/usr/sbin/modprobe", lvt.moduleArgs()...)
 	if err != nil {
-		return executionError(internalError, errored, fmt.Errorf("Could not chmod 660 the %s device: %v", lvt.devicePath, err))
+		return executionError(internalError, errored, fmt.Errorf("Could not load the v4l2loopback kernel module: %v", err))
 	}
 
-	err = makeFileOrDirReadWritableForUser(false, lvt.devicePath, taskContext.User)
-	if err != nil {
-		return executionError(internalError, errored, fmt.Errorf("Could make the %s device readwritable for task user: %v", lvt.devicePath, err))
+	for _, devicePath := range lvt.devicePaths {
+		err = host.Run("/bin/chmod", "660", devicePath)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could not chmod 660 the %s device: %v", devicePath, err))
+		}
+
+		err = makeFileOrDirReadWritableForUser(false, devicePath, taskContext.User)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could make the %s device readwritable for task user: %v", devicePath, err))
+		}
 	}
 
-	err = lvt.task.setVariable("TASKCLUSTER_VIDEO_DEVICE", lvt.devicePath)
+	err = lvt.task.setVariable("TASKCLUSTER_VIDEO_DEVICE", lvt.devicePaths[0])
 	if err != nil {
 		return executionError(internalError, errored, fmt.Errorf("Could not set TASKCLUSTER_VIDEO_DEVICE environment variable: %v", err))
 	}
+	for i, devicePath := range lvt.devicePaths {
+		name := fmt.Sprintf("TASKCLUSTER_VIDEO_DEVICE_%d", i)
+		err = lvt.task.setVariable(name, devicePath)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could not set %s environment variable: %v", name, err))
+		}
+	}
 
-	lvt.task.Infof("Loopback video device is available at %s", lvt.devicePath)
+	if len(lvt.devicePaths) == 1 {
+		lvt.task.Infof("Loopback video device is available at %s", lvt.devicePaths[0])
+	} else {
+		lvt.task.Infof("Loopback video devices are available at %v", lvt.devicePaths)
+	}
 
 	return nil
 }
 
+// moduleArgs returns the modprobe arguments to load the v4l2loopback kernel
+// module with the devices requested in the task payload.
+func (lvt *LoopbackVideoTask) moduleArgs() []string {
+	if len(lvt.task.Payload.LoopbackVideoDevices) == 0 {
+		return []string{"v4l2loopback", fmt.Sprintf("video_nr=%d", config.LoopbackVideoDeviceNumber)}
+	}
+	videoNr := make([]string, len(lvt.devices))
+	labels := make([]string, len(lvt.devices))
+	exclusiveCaps := make([]string, len(lvt.devices))
+	var maxWidth, maxHeight int64
+	for i, device := range lvt.devices {
+		videoNr[i] = strconv.Itoa(int(config.LoopbackVideoDeviceNumber) + i)
+		labels[i] = device.Label
+		if labels[i] == "" {
+			labels[i] = fmt.Sprintf("Loopback video device %d", i)
+		}
+		exclusiveCaps[i] = "0"
+		if device.ExclusiveCaps {
+			exclusiveCaps[i] = "1"
+		}
+		maxWidth = max(maxWidth, device.MaxWidth)
+		maxHeight = max(maxHeight, device.MaxHeight)
+	}
+	args := []string{
+		"v4l2loopback",
+		fmt.Sprintf("devices=%d", len(lvt.devices)),
+		"video_nr=" + strings.Join(videoNr, ","),
+		"card_label=" + strings.Join(labels, ","),
+		"exclusive_caps=" + strings.Join(exclusiveCaps, ","),
+	}
+	if maxWidth > 0 {
+		args = append(args, fmt.Sprintf("max_width=%d", maxWidth))
+	}
+	if maxHeight > 0 {
+		args = append(args, fmt.Sprintf("max_height=%d", maxHeight))
+	}
+	return args
+}
+
 func (lvt *LoopbackVideoTask) resetVideoDevice() *CommandExecutionError {
-	chownErr := makeDirUnreadableForUser(lvt.devicePath, taskContext.User)
-	if chownErr != nil {
-		return executionError(internalError, errored, fmt.Errorf("Could not remove %s's access from the %s device: %v", taskContext.User.Name, lvt.devicePath, chownErr))
+	for _, devicePath := range lvt.devicePaths {
+		chownErr := makeDirUnreadableForUser(devicePath, taskContext.User)
+		if chownErr != nil {
+			return executionError(internalError, errored, fmt.Errorf("Could not remove %s's access from the %s device: %v", taskContext.User.Name, devicePath, chownErr))
+		}
 	}
 
 	return nil
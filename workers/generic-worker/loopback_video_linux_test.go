@@ -106,3 +106,42 @@ func TestLoopbackVideoNotOwnedByTaskUser(t *testing.T) {
 		t.Fatalf("Was not expecting `ls` on device %s to be owned by task user, but it was", devicePath)
 	}
 }
+
+func TestLoopbackVideoModuleArgs(t *testing.T) {
+	setup(t)
+
+	config.LoopbackVideoDeviceNumber = 4
+	task := &TaskRun{
+		Payload: GenericWorkerPayload{
+			LoopbackVideoDevices: []LoopbackVideoDevice{
+				{
+					MaxWidth:  1920,
+					MaxHeight: 1080,
+				},
+				{
+					Label:         "Camera",
+					MaxWidth:      3840,
+					ExclusiveCaps: true,
+				},
+			},
+		},
+	}
+	lvt := (&LoopbackVideoFeature{}).NewTaskFeature(task).(*LoopbackVideoTask)
+
+	expectedPaths := []string{"/dev/video4", "/dev/video5"}
+	if strings.Join(lvt.devicePaths, " ") != strings.Join(expectedPaths, " ") {
+		t.Fatalf("Expected device paths %v but got %v", expectedPaths, lvt.devicePaths)
+	}
+	expectedArgs := []string{
+		"v4l2loopback",
+		"devices=2",
+		"video_nr=4,5",
+		"card_label=Loopback video device 0,Camera",
+		"exclusive_caps=0,1",
+		"max_width=3840",
+		"max_height=1080",
+	}
+	if args := lvt.moduleArgs(); strings.Join(args, " ") != strings.Join(expectedArgs, " ") {
+		t.Fatalf("Expected modprobe arguments %q but got %q", expectedArgs, args)
+	}
+}
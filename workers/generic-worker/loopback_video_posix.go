@@ -5,7 +5,7 @@ package main
 import (
 	"fmt"
 
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 )
 
 type LoopbackVideoFeature struct {
@@ -28,19 +28,30 @@ func (feature *LoopbackVideoFeature) IsEnabled(task *TaskRun) bool {
 }
 
 func (feature *LoopbackVideoFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	devices := task.Payload.LoopbackVideoDevices
+	if len(devices) == 0 {
+		devices = []LoopbackVideoDevice{{}}
+	}
+	devicePaths := make([]string, len(devices))
+	for i := range devices {
+		devicePaths[i] = fmt.Sprintf("/dev/video%d", int(config.LoopbackVideoDeviceNumber)+i)
+	}
 	return &LoopbackVideoTask{
-		task:       task,
-		devicePath: fmt.Sprintf("/dev/video%d", config.LoopbackVideoDeviceNumber),
+		task:        task,
+		devices:     devices,
+		devicePaths: devicePaths,
 	}
 }
 
 type LoopbackVideoTask struct {
-	task       *TaskRun
-	devicePath string
+	task *TaskRun
+	// devices[i] is created at devicePaths[i]
+	devices     []LoopbackVideoDevice
+	devicePaths []string
 }
 
-func (lvt *LoopbackVideoTask) RequiredScopes() scopes.Required {
-	return scopes.Required{
+func (lvt *LoopbackVideoTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{
 		{"generic-worker:loopback-video:" + config.ProvisionerID + "/" + config.WorkerType},
 		{"generic-worker:loopback-video"},
 	}
@@ -51,6 +62,10 @@ func (lvt *LoopbackVideoTask) ReservedArtifacts() []string {
 }
 
 func (lvt *LoopbackVideoTask) Start() *CommandExecutionError {
+	if int(config.LoopbackVideoDeviceNumber)+len(lvt.devices) > 256 {
+		return executionError(internalError, errored, fmt.Errorf("LoopbackVideoDeviceNumber (%v) is too high to create %v loopback video devices; device numbers must be between 0 and 255, inclusive.", config.LoopbackVideoDeviceNumber, len(lvt.devices)))
+	}
+
 	return lvt.setupVideoDevice()
 }
 
@@ -21,6 +21,7 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	docopt "github.com/docopt/docopt-go"
@@ -28,9 +29,9 @@ import (
 	"github.com/mcuadros/go-defaults"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/internal"
 	"github.com/taskcluster/taskcluster/v60/internal/mocktc/tc"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/artifacts"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/errorreport"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/expose"
@@ -83,6 +84,7 @@ func initialiseFeatures() (err error) {
 		&TaskclusterProxyFeature{},
 		&OSGroupsFeature{},
 		&MountsFeature{},
+		&CodeSigningFeature{},
 	}
 	Features = append(Features, platformFeatures()...)
 	for _, feature := range Features {
@@ -135,6 +137,7 @@ func main() {
 
 		serviceFactory = &tc.ClientFactory{}
 		initializeWorkerRunnerProtocol(os.Stdin, os.Stdout, withWorkerRunner)
+		waitForNetworkConfig()
 
 		configFileAbs, err := filepath.Abs(arguments["--config"].(string))
 		exitOnError(CANT_LOAD_CONFIG, err, "Cannot determine absolute path location for generic-worker config file '%v'", arguments["--config"])
@@ -214,12 +217,21 @@ func loadConfig(configFile *gwconfig.File) error {
 	// only one place if possible (defaults also declared in `usage`)
 	config = &gwconfig.Config{
 		PublicConfig: gwconfig.PublicConfig{
+			AllowedUSBDevices:              []string{},
+			BubblewrapExecutable:           "bwrap",
 			CachesDir:                      "caches",
 			CheckForNewDeploymentEverySecs: 1800,
 			CleanUpTaskDirs:                true,
+			DefaultShell:                   "cmd",
 			DisableReboots:                 false,
+			DockerExecutable:               "docker",
 			DownloadsDir:                   "downloads",
+			EnableGPUs:                     false,
 			EnableInteractive:              false,
+			EnableSandbox:                  false,
+			EnableVirtualMachines:          false,
+			EnableWindowsContainers:        false,
+			GPULeaseDir:                    "/var/run/generic-worker/gpu-leases",
 			IdleTimeoutSecs:                0,
 			InteractivePort:                53654,
 			LiveLogExecutable:              "livelog",
@@ -228,19 +240,35 @@ func loadConfig(configFile *gwconfig.File) error {
 			LoopbackVideoDeviceNumber:      0,
 			MaxTaskRunTime:                 86400, // 86400s is 24 hours
 			NumberOfTasksToRun:             0,
+			NvidiaSMIExecutable:            "nvidia-smi",
+			OverlayRootImage:               "",
+			Paused:                         false,
 			ProvisionerID:                  "test-provisioner",
+			PwshExecutable:                 "pwsh.exe",
+			QEMUExecutable:                 "qemu-system-x86_64",
+			QEMUImgExecutable:              "qemu-img",
 			RequiredDiskSpaceMegabytes:     10240,
 			RootURL:                        "",
 			RunAfterUserCreation:           "",
+			SandboxSeccompProfile:          "",
 			SentryProject:                  "generic-worker",
 			ShutdownMachineOnIdle:          false,
 			ShutdownMachineOnInternalError: false,
+			SuppressBackgroundActivity:     false,
 			TaskclusterProxyExecutable:     "taskcluster-proxy",
 			TaskclusterProxyPort:           80,
+			TartExecutable:                 "tart",
 			TasksDir:                       defaultTasksDir(),
+			TaskUserPoolSize:               0,
+			TerminationAbortMarginSecs:     15,
+			VirtiofsdExecutable:            "/usr/libexec/virtiofsd",
+			VirtualMachineBootTimeoutSecs:  300,
+			VirtualMachineSSHUser:          "admin",
+			WestonExecutable:               "weston",
 			WorkerGroup:                    "test-worker-group",
 			WorkerLocation:                 "",
 			WorkerTypeMetadata:             map[string]interface{}{},
+			XvfbExecutable:                 "Xvfb",
 		},
 	}
 
@@ -413,7 +441,24 @@ func RunWorker() (exitCode ExitCode) {
 	lastCheckedDeploymentID := time.Time{}
 	lastReportedNoTasks := time.Now()
 	sigInterrupt := make(chan os.Signal, 1)
-	signal.Notify(sigInterrupt, os.Interrupt)
+	signal.Notify(sigInterrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigInterrupt)
+	// The same signals interrupt a running task, so that it is resolved as
+	// worker-shutdown rather than left to expire.  On Windows, this is how
+	// a service is stopped, including when the host shuts down (SIGTERM
+	// stands for CTRL_SHUTDOWN_EVENT and CTRL_CLOSE_EVENT).
+	sigTerminate := make(chan os.Signal, 1)
+	signal.Notify(sigTerminate, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(sigTerminate)
+		close(sigTerminate)
+	}()
+	go func() {
+		for sig := range sigTerminate {
+			log.Printf("Received %v; aborting any running task", sig)
+			graceful.Terminate(false)
+		}
+	}()
 	if RotateTaskEnvironment() {
 		return REBOOT_REQUIRED
 	}
@@ -440,7 +485,15 @@ func RunWorker() (exitCode ExitCode) {
 			panic(err)
 		}
 
-		task := ClaimWork()
+		applyConfigUpdates()
+
+		var task *TaskRun
+		if config.Paused {
+			// a paused worker is not idle, so should not shut down
+			lastActive = time.Now()
+		} else {
+			task = ClaimWork()
+		}
 
 		// make sure at least 5 seconds pass between tcqueue.ClaimWork API calls
 		wait5Seconds := time.NewTimer(time.Second * 5)
@@ -1020,7 +1073,7 @@ func (task *TaskRun) Run() (err *ExecutionErrors) {
 			log.Printf("Creating task feature %v...", feature.Name())
 			taskFeature := feature.NewTaskFeature(task)
 			requiredScopes := taskFeature.RequiredScopes()
-			scopesSatisfied, scopeValidationErr := scopes.Given(task.Definition.Scopes).Satisfies(requiredScopes, serviceFactory.Auth(config.Credentials(), config.RootURL))
+			scopesSatisfied, scopeValidationErr := tcscopes.Given(task.Definition.Scopes).Satisfies(requiredScopes, serviceFactory.Auth(config.Credentials(), config.RootURL))
 			if scopeValidationErr != nil {
 				// presumably we couldn't expand assume:* scopes due to auth
 				// service unavailability
@@ -1028,7 +1081,7 @@ func (task *TaskRun) Run() (err *ExecutionErrors) {
 				continue
 			}
 			if !scopesSatisfied {
-				err.add(MalformedPayloadError(fmt.Errorf("Feature %q requires scopes:\n\n%v\n\nbut task only has scopes:\n\n%v\n\nYou probably should add some scopes to your task definition", feature.Name(), requiredScopes, scopes.Given(task.Definition.Scopes))))
+				err.add(MalformedPayloadError(fmt.Errorf("Feature %q requires scopes:\n\n%v\n\nbut task only has scopes:\n\n%v\n\nYou probably should add some scopes to your task definition", feature.Name(), requiredScopes, tcscopes.Given(task.Definition.Scopes))))
 				continue
 			}
 			reservedArtifacts := taskFeature.ReservedArtifacts()
@@ -1119,8 +1172,24 @@ func (task *TaskRun) Run() (err *ExecutionErrors) {
 	// with the reason `worker-shutdown`. Upon such report the queue will
 	// resolve the run as exception and create a new run, if the task has
 	// additional retries left.
-	stopHandlingGracefulTermination := graceful.OnTerminationRequest(func(finishTasks bool) {
-		if !finishTasks {
+	//
+	// If the time at which the worker will be terminated is known, the task
+	// is given until shortly before then to complete, leaving time to upload
+	// its artifacts and resolve it. The file named by
+	// TASKCLUSTER_TERMINATION_NOTICE_FILE is created, containing the
+	// termination time, so that tasks can checkpoint their work in the
+	// meantime.
+	terminationNoticeFile := filepath.Join(taskContext.TaskDir, ".taskcluster-termination-notice")
+	if e := task.setVariable("TASKCLUSTER_TERMINATION_NOTICE_FILE", terminationNoticeFile); e != nil {
+		err.add(executionError(internalError, errored, fmt.Errorf("could not set TASKCLUSTER_TERMINATION_NOTICE_FILE: %v", e)))
+		return
+	}
+	var abortTimer *time.Timer
+	stopHandlingGracefulTermination := graceful.OnTerminationRequest(func(finishTasks bool, deadline time.Time) {
+		if finishTasks || abortTimer != nil {
+			return
+		}
+		abort := func() {
 			_ = task.StatusManager.Abort(
 				&CommandExecutionError{
 					Cause:      fmt.Errorf("graceful termination requested, without time to finish tasks"),
@@ -1129,8 +1198,26 @@ func (task *TaskRun) Run() (err *ExecutionErrors) {
 				},
 			)
 		}
+		budget := time.Until(deadline) - time.Duration(config.TerminationAbortMarginSecs)*time.Second
+		if deadline.IsZero() || budget <= 0 {
+			abort()
+			return
+		}
+		task.Warnf("Worker will be terminated at %v; task will be aborted in %v unless it completes first", deadline.UTC().Format(time.RFC3339), budget.Round(time.Second))
+		e := os.WriteFile(terminationNoticeFile, []byte(deadline.UTC().Format(time.RFC3339)+"\n"), 0644)
+		if e != nil {
+			task.Warnf("Could not write termination notice file %v: %v", terminationNoticeFile, e)
+		}
+		abortTimer = time.AfterFunc(budget, abort)
 	})
-	defer stopHandlingGracefulTermination()
+	defer func() {
+		stopHandlingGracefulTermination()
+		// the callback is not called after it has been removed, so there
+		// is no race on abortTimer here
+		if abortTimer != nil {
+			abortTimer.Stop()
+		}
+	}()
 
 	started := time.Now()
 	defer func() {
@@ -16,8 +16,9 @@ import (
 	"github.com/taskcluster/httpbackoff/v3"
 	"github.com/taskcluster/slugid-go/slugid"
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcindex"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/internal/mocktc/tc"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/fileutil"
 )
 
@@ -191,9 +192,12 @@ type TaskMount struct {
 	// payload errors are detected when creating feature but only reported when
 	// feature starts, so need to keep hold of any error raised...
 	payloadError      error
-	requiredScopes    scopes.Required
+	requiredScopes    tcscopes.Required
 	referencedTaskIDs map[string]bool // simple implementation of set of strings
 	index             tc.Index
+	// caches index lookups, so that the cache key and the downloaded content
+	// of an IndexedContent mount refer to the same task
+	indexCache *tcindex.TaskCache
 }
 
 // Represents an individual Mount listed in task payload - there
@@ -210,8 +214,8 @@ type MountEntry interface {
 // IndexedContent, URLContent, RawContent or Base64Content concrete types. This
 // is the interface which represents these underlying concrete types.
 type FSContent interface {
-	// Keep it simple and just return a []string, rather than scopes.Required
-	// since currently no easy way to "AND" scopes.Required types.
+	// Keep it simple and just return a []string, rather than tcscopes.Required
+	// since currently no easy way to "AND" tcscopes.Required types.
 	RequiredScopes() []string
 	// Download the content, and return the absolute location of the file. No
 	// archive extraction is performed.
@@ -308,7 +312,7 @@ func (taskMount *TaskMount) Unmarshal(rm json.RawMessage, m MountEntry) {
 // we do this in advance in case there is an error, we can report it upfront
 // when we initialise, rather than later when we go to check what scopes are
 // needed.
-func (taskMount *TaskMount) RequiredScopes() scopes.Required {
+func (taskMount *TaskMount) RequiredScopes() tcscopes.Required {
 	return taskMount.requiredScopes
 }
 
@@ -328,7 +332,7 @@ func (taskMount *TaskMount) initRequiredScopes() {
 			requiredScopes = append(requiredScopes, fsContent.RequiredScopes()...)
 		}
 	}
-	taskMount.requiredScopes = scopes.Required{requiredScopes}
+	taskMount.requiredScopes = tcscopes.Required{requiredScopes}
 }
 
 // loops through all referenced mounts and keeps a list of referenced TaskIDs
@@ -372,6 +376,7 @@ func (taskMount *TaskMount) initIndexClient() {
 		},
 		config.RootURL,
 	)
+	taskMount.indexCache = tcindex.NewTaskCache(10 * time.Minute)
 }
 
 // Here the order is important. We want to delete file caches before we delete
@@ -889,7 +894,7 @@ func (ac *ArtifactContent) Download(taskMount *TaskMount) (file string, sha256 s
 }
 
 func (ic *IndexedContent) Download(taskMount *TaskMount) (file string, sha256 string, err error) {
-	itr, err := taskMount.index.FindTask(ic.Namespace)
+	itr, err := taskMount.indexCache.FindTask(taskMount.index, ic.Namespace)
 	if err != nil {
 		return "", "", err
 	}
@@ -914,8 +919,11 @@ func (ac *ArtifactContent) UniqueKey(taskMount *TaskMount) (string, error) {
 }
 
 func (ic *IndexedContent) UniqueKey(taskMount *TaskMount) (string, error) {
-	itr, err := taskMount.index.FindTask(ic.Namespace)
-	return "artifact:" + itr.TaskID + ":" + ic.Artifact, err
+	itr, err := taskMount.indexCache.FindTask(taskMount.index, ic.Namespace)
+	if err != nil {
+		return "", err
+	}
+	return "artifact:" + itr.TaskID + ":" + ic.Artifact, nil
 }
 
 func (ac *ArtifactContent) RequiredSHA256() string {
@@ -150,11 +150,16 @@ func PlatformTaskEnvironmentSetup(taskDirName string) (reboot bool) {
 	// account. Username can only be 20 chars, uuids are too long, therefore
 	// use prefix (5 chars) plus seconds since epoch (10 chars).
 
-	nextTaskUser := &gwruntime.OSUser{
-		Name:     taskDirName,
-		Password: gwruntime.GeneratePassword(),
+	var nextTaskUser *gwruntime.OSUser
+	if config.TaskUserPoolSize > 0 {
+		nextTaskUser, err = recycleTaskUser()
+	} else {
+		nextTaskUser = &gwruntime.OSUser{
+			Name:     taskDirName,
+			Password: gwruntime.GeneratePassword(),
+		}
+		err = nextTaskUser.CreateNew(false)
 	}
-	err = nextTaskUser.CreateNew(false)
 	if err != nil {
 		panic(err)
 	}
@@ -199,7 +204,7 @@ func deleteExistingOSUsers() (err error) {
 	}
 	allErrors := []string{}
 	for _, username := range userAccounts {
-		if strings.HasPrefix(username, "task_") && username != taskContext.User.Name && username != gwruntime.AutoLogonUser() {
+		if strings.HasPrefix(username, "task_") && !isPoolTaskUser(username) && username != taskContext.User.Name && username != gwruntime.AutoLogonUser() {
 			log.Print("Attempting to remove user " + username + "...")
 			err2 := gwruntime.DeleteUser(username)
 			if err2 != nil {
@@ -213,6 +218,40 @@ func deleteExistingOSUsers() (err error) {
 	return
 }
 
+// isPoolTaskUser returns true if username is the name of a task user from
+// the task user pool (see config setting taskUserPoolSize). Pool users are
+// recycled rather than deleted, so that they do not need to be created for
+// each task.
+func isPoolTaskUser(username string) bool {
+	if config.TaskUserPoolSize == 0 {
+		return false
+	}
+	for i := uint(0); i < config.TaskUserPoolSize; i++ {
+		if username == poolTaskUserName(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func poolTaskUserName(index uint) string {
+	return fmt.Sprintf("task_pool_%d", index)
+}
+
+// nextPoolTaskUserName returns the name of the task user from the task user
+// pool that should run the task after one run by currentUser. Pool users are
+// used in turn, so that the next task user is never currentUser, which is
+// still logged in while the next task user is prepared. If currentUser is not
+// a pool user, the first pool user is next.
+func nextPoolTaskUserName(currentUser string) string {
+	for i := uint(0); i < config.TaskUserPoolSize; i++ {
+		if currentUser == poolTaskUserName(i) {
+			return poolTaskUserName((i + 1) % config.TaskUserPoolSize)
+		}
+	}
+	return poolTaskUserName(0)
+}
+
 func StoredUserCredentials() (*gwruntime.OSUser, error) {
 	credsFile, err := os.Open(ctuPath)
 	if err != nil {
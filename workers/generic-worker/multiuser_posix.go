@@ -27,6 +27,13 @@ func platformFeatures() []Feature {
 		&InteractiveFeature{},
 		&LoopbackAudioFeature{},
 		&LoopbackVideoFeature{},
+		&KeychainFeature{},
+		&DisplayFeature{},
+		&GPUFeature{},
+		&USBDevicesFeature{},
+		&KVMFeature{},            // depends on (must appear later in list than) OSGroups feature
+		&VirtualMachineFeature{}, // wraps commands, so should be late in the list
+		&SandboxFeature{},        // wraps commands, so should be late in the list
 		// keep chain of trust as low down as possible, as it checks permissions
 		// of signing key file, and a feature could change them, so we want these
 		// checks as late as possible
@@ -34,6 +41,10 @@ func platformFeatures() []Feature {
 	}
 }
 
+func recycleTaskUser() (*gwruntime.OSUser, error) {
+	return nil, fmt.Errorf("config setting taskUserPoolSize is only supported on Windows")
+}
+
 func deleteDir(path string) error {
 	log.Print("Removing directory '" + path + "'...")
 	err := host.Run("/usr/bin/sudo", "/bin/chmod", "-R", "u+w", path)
@@ -39,10 +39,10 @@ func grantingDenying(t *testing.T, filetype string, cacheFile bool, taskPath ...
 		pathRegExp = strings.Replace(regexp.QuoteMeta(filepath.Join(testdataDir, t.Name(), "tasks", slug, filepath.Join(taskPath...))), slug, "task_[0-9]*", -1)
 	}
 	return []string{
-			`Granting task_[0-9]* full control of ` + filetype + ` '` + pathRegExp + `'`,
-		}, []string{
-			`Denying task_[0-9]* access to '.*'`,
-		}
+		`Granting task_[0-9]* full control of ` + filetype + ` '` + pathRegExp + `'`,
+	}, []string{
+		`Denying task_[0-9]* access to '.*'`,
+	}
 }
 
 func setConfigRunTasksAsCurrentUser(conf *gwconfig.Config) {
@@ -107,3 +107,45 @@ func TestPrivilegedGenericWorkerBinaryFailsWorker(t *testing.T) {
 
 	execute(t, INTERNAL_ERROR)
 }
+
+func TestIsPoolTaskUser(t *testing.T) {
+	config = &gwconfig.Config{}
+	for _, username := range []string{"task_pool_0", "task_1700000000", "worker"} {
+		if isPoolTaskUser(username) {
+			t.Errorf("Expected %v not to be a pool task user without a task user pool", username)
+		}
+	}
+
+	config.TaskUserPoolSize = 2
+	for username, expected := range map[string]bool{
+		"task_pool_0":     true,
+		"task_pool_1":     true,
+		"task_pool_2":     false,
+		"task_pool_":      false,
+		"task_1700000000": false,
+		"worker":          false,
+	} {
+		if actual := isPoolTaskUser(username); actual != expected {
+			t.Errorf("Expected isPoolTaskUser(%q) to be %v, but was %v", username, expected, actual)
+		}
+	}
+}
+
+func TestNextPoolTaskUserName(t *testing.T) {
+	config = &gwconfig.Config{}
+	config.TaskUserPoolSize = 3
+	user := "task_1700000000"
+	for _, expected := range []string{"task_pool_0", "task_pool_1", "task_pool_2", "task_pool_0", "task_pool_1"} {
+		next := nextPoolTaskUserName(user)
+		if next != expected {
+			t.Fatalf("Expected task user after %v to be %v, but was %v", user, expected, next)
+		}
+		user = next
+	}
+
+	// the pool may have shrunk since the current task user was recycled
+	config.TaskUserPoolSize = 2
+	if next := nextPoolTaskUserName("task_pool_2"); next != "task_pool_0" {
+		t.Fatalf("Expected task user after task_pool_2 to be task_pool_0, but was %v", next)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/fileutil"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/process"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/runtime"
@@ -30,6 +31,8 @@ func platformFeatures() []Feature {
 	return []Feature{
 		&RDPFeature{},
 		&RunAsAdministratorFeature{}, // depends on (must appear later in list than) OSGroups feature
+		&WindowsContainerFeature{},   // wraps commands, so must appear later in list than RunAsAdministrator feature
+		&BackgroundActivityFeature{},
 		// keep chain of trust as low down as possible, as it checks permissions
 		// of signing key file, and a feature could change them, so we want these
 		// checks as late as possible
@@ -45,12 +48,12 @@ func deleteDir(path string) error {
 	}
 	log.Print("WARNING: could not delete directory '" + path + "' with os.RemoveAll(path) method")
 	log.Printf("%v", err)
-	log.Print("Trying to remove directory '" + path + "' via del and rmdir commands...")
-	err = host.Run("cmd", "/c", "del", "/s", "/q", "/f", path)
-	if err != nil {
-		log.Printf("%#v", err)
-	}
-	err = host.Run("cmd", "/c", "rmdir", "/s", "/q", path)
+	// Note, `del /s` is not used, since it follows junctions, and could
+	// therefore delete files outside of path. `rmdir /s` removes junctions
+	// without deleting their targets. The extended-length path form allows
+	// rmdir to delete trees that are deeper than MAX_PATH.
+	log.Print("Trying to remove directory '" + path + "' via rmdir command...")
+	err = host.Run("cmd", "/c", "rmdir", "/s", "/q", fileutil.LongPath(path))
 	if err != nil {
 		log.Printf("%#v", err)
 	}
@@ -79,9 +82,23 @@ func (task *TaskRun) prepareCommand(index int) *CommandExecutionError {
 	dir := filepath.Join(taskContext.TaskDir, "dir.txt")
 	commandName := fmt.Sprintf("command_%06d", index)
 	wrapper := filepath.Join(taskContext.TaskDir, commandName+"_wrapper.bat")
-	script := filepath.Join(taskContext.TaskDir, commandName+".bat")
+	shell := task.shell()
+	var script string
+	switch shell {
+	case "cmd":
+		script = filepath.Join(taskContext.TaskDir, commandName+".bat")
+	case "pwsh":
+		script = filepath.Join(taskContext.TaskDir, commandName+".ps1")
+	default:
+		return executionError(internalError, errored, fmt.Errorf("Unsupported shell %q configured in worker config setting defaultShell; supported shells are cmd and pwsh", shell))
+	}
 	contents := ":: This script runs command " + strconv.Itoa(index) + " defined in TaskId " + task.TaskID + "..." + "\r\n"
 	contents += "@echo off\r\n"
+	if shell == "pwsh" {
+		// switch to the UTF-8 code page before any environment variables are
+		// set, since the remainder of this script is read using it
+		contents += "chcp 65001 > nul\r\n"
+	}
 
 	setEnvVarCommand := func(name, value string) string {
 		return "set " + name + "=" + win32.CMDExeEscape(value) + "\r\n"
@@ -158,14 +175,20 @@ func (task *TaskRun) prepareCommand(index int) *CommandExecutionError {
 	// old version that WROTE TO A FILE:
 	//      contents += "call " + script + " > " + absLogFile + " 2>&1" + "\r\n"
 	// ******************************
-	contents += "call " + script + " 2>&1" + "\r\n"
+	lastCommand := index == len(task.Payload.Command)-1
+	if shell == "pwsh" {
+		contents += "\"" + config.PwshExecutable + "\" -NoLogo -NoProfile -NonInteractive -ExecutionPolicy Bypass -File \"" + script + "\" 2>&1" + "\r\n"
+	} else {
+		contents += "call " + script + " 2>&1" + "\r\n"
+	}
 	contents += "@echo off" + "\r\n"
 
 	// store exit code
 	contents += "set tcexitcode=%errorlevel%\r\n"
 
-	// now store env for next command, unless this is the last command
-	if index != len(task.Payload.Command)-1 {
+	// now store env for next command, unless this is the last command (the
+	// pwsh script stores its own env, since it runs in a separate process)
+	if !lastCommand && shell == "cmd" {
 		contents += "set > " + env + "\r\n"
 		contents += "cd > " + dir + "\r\n"
 	}
@@ -183,11 +206,16 @@ func (task *TaskRun) prepareCommand(index int) *CommandExecutionError {
 		panic(err)
 	}
 
-	// Now make the actual task a .bat script
-	fileContents := []byte(strings.Join([]string{
-		"@echo on",
-		task.Payload.Command[index],
-	}, "\r\n"))
+	// Now make the actual task a .bat or .ps1 script
+	var fileContents []byte
+	if shell == "pwsh" {
+		fileContents = []byte(pwshScript(task.Payload.Command[index], lastCommand, env, dir))
+	} else {
+		fileContents = []byte(strings.Join([]string{
+			"@echo on",
+			task.Payload.Command[index],
+		}, "\r\n"))
+	}
 
 	err = os.WriteFile(
 		script,
@@ -209,6 +237,53 @@ func (task *TaskRun) prepareCommand(index int) *CommandExecutionError {
 	return nil
 }
 
+// shell returns the shell that task commands are executed with, which is set
+// in the task payload, or otherwise by worker config setting defaultShell.
+func (task *TaskRun) shell() string {
+	if task.Payload.Shell != "" {
+		return task.Payload.Shell
+	}
+	return config.DefaultShell
+}
+
+// pwshScript returns a PowerShell script that runs command with UTF-8 console
+// encoding, and exits with the exit code of the last native command that the
+// command ran, or 1 if the last statement of the command failed or the
+// command raised a terminating error. Unless lastCommand is true, the
+// environment and current directory are stored in files env and dir
+// afterwards, for the wrapper script of the next command to restore.
+func pwshScript(command string, lastCommand bool, env, dir string) string {
+	lines := []string{
+		"[Console]::InputEncoding = [System.Text.Encoding]::UTF8",
+		"[Console]::OutputEncoding = [System.Text.Encoding]::UTF8",
+		"$OutputEncoding = [System.Text.Encoding]::UTF8",
+		"$global:LASTEXITCODE = 0",
+		"$tcsuccess = $true",
+		"try {",
+		command,
+		"$tcsuccess = $?",
+		"} catch {",
+		"Write-Error -ErrorRecord $_ -ErrorAction Continue",
+		"$tcsuccess = $false",
+		"} finally {",
+	}
+	if !lastCommand {
+		lines = append(
+			lines,
+			"Get-ChildItem env: | ForEach-Object { \"$($_.Name)=$($_.Value)\" } | Out-File -Encoding utf8 -FilePath '"+strings.ReplaceAll(env, "'", "''")+"'",
+			"(Get-Location).ProviderPath | Out-File -Encoding utf8 -FilePath '"+strings.ReplaceAll(dir, "'", "''")+"'",
+		)
+	}
+	lines = append(
+		lines,
+		"}",
+		"if ($global:LASTEXITCODE -ne 0) { exit $global:LASTEXITCODE }",
+		"if (-not $tcsuccess) { exit 1 }",
+		"exit 0",
+	)
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
 // Set an environment variable in each command.  This can be called from a feature's
 // NewTaskFeature method to set variables for the task.
 func (task *TaskRun) setVariable(variable string, value string) error {
@@ -248,12 +323,12 @@ func install(arguments map[string]interface{}) (err error) {
 
 func makeFileOrDirReadWritableForUser(recurse bool, dir string, user *gwruntime.OSUser) error {
 	// see http://ss64.com/nt/icacls.html
-	return host.Run("icacls", dir, "/grant:r", user.Name+":(OI)(CI)F")
+	return host.Run("icacls", fileutil.LongPath(dir), "/grant:r", user.Name+":(OI)(CI)F")
 }
 
 func makeDirUnreadableForUser(dir string, user *gwruntime.OSUser) error {
 	// see http://ss64.com/nt/icacls.html
-	return host.Run("icacls", dir, "/remove:g", user.Name)
+	return host.Run("icacls", fileutil.LongPath(dir), "/remove:g", user.Name)
 }
 
 // The windows implementation of os.Rename(...) doesn't allow renaming files
@@ -536,6 +611,59 @@ func PreRebootSetup(nextTaskUser *runtime.OSUser) {
 	}
 }
 
+// recycleTaskUser prepares the next user of the task user pool (see config
+// setting taskUserPoolSize) for running a task, so that nothing is left over
+// from the last task it ran: its processes are killed, its password is
+// rotated, and its profile (including its temp directory) and redirected app
+// data folders are deleted. The pool user account is created if it does not
+// exist yet.
+func recycleTaskUser() (*gwruntime.OSUser, error) {
+	if config.TaskUserPoolSize < 2 {
+		return nil, fmt.Errorf("config setting taskUserPoolSize must be 0 (no pool) or at least 2, but is %v", config.TaskUserPoolSize)
+	}
+	currentUser := ""
+	if taskContext != nil && taskContext.User != nil {
+		currentUser = taskContext.User.Name
+	}
+	nextTaskUser := &gwruntime.OSUser{
+		Name:     nextPoolTaskUserName(currentUser),
+		Password: gwruntime.GeneratePassword(),
+	}
+	log.Printf("Recycling task user %v from task user pool", nextTaskUser.Name)
+	err := nextTaskUser.CreateNew(true)
+	if err != nil {
+		return nil, err
+	}
+	// processes left running by the last task would otherwise survive into
+	// the next one, and keep the profile loaded so that it can't be deleted
+	err = gwruntime.KillUserProcesses(nextTaskUser.Name)
+	if err != nil {
+		return nil, fmt.Errorf("Could not kill processes of task user %v: %v", nextTaskUser.Name, err)
+	}
+	err = nextTaskUser.SetPassword()
+	if err != nil {
+		return nil, fmt.Errorf("Could not rotate password of task user %v: %v", nextTaskUser.Name, err)
+	}
+	// there is no profile to delete if the pool user has not logged in yet,
+	// so a failure here is not an error, but the profile directory must not
+	// remain
+	_ = gwruntime.DeleteProfile(nextTaskUser.Name)
+	for _, dir := range []string{
+		// profile directory, including the user temp directory
+		filepath.Join(gwruntime.UserHomeDirectoriesParent(), nextTaskUser.Name),
+		// app data folders are redirected here by PreRebootSetup
+		filepath.Join(config.TasksDir, nextTaskUser.Name),
+	} {
+		if _, err := os.Stat(dir); err == nil {
+			err = deleteDir(dir)
+			if err != nil {
+				return nil, fmt.Errorf("Could not wipe directory %v of task user %v: %v", dir, nextTaskUser.Name, err)
+			}
+		}
+	}
+	return nextTaskUser, nil
+}
+
 func convertNilToEmptyString(val interface{}) string {
 	if val == nil {
 		return ""
@@ -4,6 +4,8 @@ package main
 
 import (
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/mcuadros/go-defaults"
@@ -126,3 +128,33 @@ func TestDesktopResizeAndMovePointer(t *testing.T) {
 
 	_ = submitAndAssert(t, td, payload, "completed", "completed")
 }
+
+func TestPwshShell(t *testing.T) {
+	if _, err := exec.LookPath("pwsh.exe"); err != nil {
+		t.Skip("PowerShell Core not installed")
+	}
+	setup(t)
+	payload := GenericWorkerPayload{
+		Command: []string{
+			`$env:GREETING = "grüße"`,
+			`New-Item -ItemType Directory -Name subdir | Out-Null`,
+			`Set-Location subdir`,
+			`Write-Output "$env:GREETING from $((Get-Item .).Name) with ""quotes"""`,
+			`cmd.exe /c exit 7`,
+		},
+		MaxRunTime: 30,
+		Shell:      "pwsh",
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "failed", "failed")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, `grüße from subdir with "quotes"`) {
+		t.Fatalf("Expected log file to contain UTF-8 greeting, but it didn't\n%s", logtext)
+	}
+	if !strings.Contains(logtext, "Exit Code: 7") {
+		t.Fatalf("Expected log file to contain exit status 7 of final command, but it didn't\n%s", logtext)
+	}
+}
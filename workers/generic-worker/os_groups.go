@@ -1,7 +1,7 @@
 package main
 
 import (
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 )
 
 // one instance overall - represents feature
@@ -36,10 +36,10 @@ func (osGroups *OSGroups) ReservedArtifacts() []string {
 	return []string{}
 }
 
-func (osGroups *OSGroups) RequiredScopes() scopes.Required {
+func (osGroups *OSGroups) RequiredScopes() tcscopes.Required {
 	requiredScopes := make([]string, len(osGroups.Task.Payload.OSGroups))
 	for i, osGroup := range osGroups.Task.Payload.OSGroups {
 		requiredScopes[i] = "generic-worker:os-group:" + config.ProvisionerID + "/" + config.WorkerType + "/" + osGroup
 	}
-	return scopes.Required{requiredScopes}
+	return tcscopes.Required{requiredScopes}
 }
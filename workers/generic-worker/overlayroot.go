@@ -0,0 +1,63 @@
+//go:build simple
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+)
+
+// OverlayRootFeature runs task commands chrooted into an overlayfs mounted
+// over the root filesystem image given by config setting overlayRootImage.
+// Tasks can modify any file of the image, but changes are written to an
+// upper layer that is discarded when the task completes, so every task sees
+// a pristine copy of the image. Like the sandbox feature, it is enabled by
+// the worker configuration rather than by the task.
+type OverlayRootFeature struct {
+}
+
+func (feature *OverlayRootFeature) Name() string {
+	return "Overlay Root"
+}
+
+func (feature *OverlayRootFeature) Initialise() error {
+	if config.OverlayRootImage == "" {
+		return nil
+	}
+	if config.EnableSandbox {
+		return fmt.Errorf("config settings overlayRootImage and enableSandbox cannot be used together")
+	}
+	return initialiseOverlayRoot()
+}
+
+func (feature *OverlayRootFeature) PersistState() error {
+	return nil
+}
+
+func (feature *OverlayRootFeature) IsEnabled(task *TaskRun) bool {
+	return config.OverlayRootImage != ""
+}
+
+func (feature *OverlayRootFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &OverlayRootTask{
+		task: task,
+	}
+}
+
+type OverlayRootTask struct {
+	task *TaskRun
+	// temporary directory holding the upper and work layers of the overlay,
+	// and the directory it is mounted at
+	baseDir string
+	// mount points, in the order they were mounted
+	mounts []string
+}
+
+func (ort *OverlayRootTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{}
+}
+
+func (ort *OverlayRootTask) ReservedArtifacts() []string {
+	return []string{}
+}
@@ -0,0 +1,16 @@
+//go:build simple && freebsd
+
+package main
+
+import "fmt"
+
+func initialiseOverlayRoot() error {
+	return fmt.Errorf("config setting overlayRootImage is not supported on FreeBSD")
+}
+
+func (ort *OverlayRootTask) Start() *CommandExecutionError {
+	return nil
+}
+
+func (ort *OverlayRootTask) Stop(err *ExecutionErrors) {
+}
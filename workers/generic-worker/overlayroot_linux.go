@@ -0,0 +1,172 @@
+//go:build simple && linux
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func initialiseOverlayRoot() error {
+	info, err := os.Stat(config.OverlayRootImage)
+	if err != nil {
+		return fmt.Errorf("could not read root image %v specified in config setting overlayRootImage: %v", config.OverlayRootImage, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("root image %v specified in config setting overlayRootImage is not a directory", config.OverlayRootImage)
+	}
+	return nil
+}
+
+func (ort *OverlayRootTask) Start() *CommandExecutionError {
+	var err error
+	ort.baseDir, err = os.MkdirTemp("", "overlay-root-")
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[overlay root] Could not create overlay directory: %v", err))
+	}
+	upper := filepath.Join(ort.baseDir, "upper")
+	work := filepath.Join(ort.baseDir, "work")
+	root := filepath.Join(ort.baseDir, "root")
+	for _, dir := range []string{upper, work, root} {
+		err = os.Mkdir(dir, 0755)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[overlay root] Could not create directory %v: %v", dir, err))
+		}
+	}
+	err = ort.mount("overlay", root, "overlay", 0, "lowerdir="+config.OverlayRootImage+",upperdir="+upper+",workdir="+work)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[overlay root] Could not mount overlay of %v: %v", config.OverlayRootImage, err))
+	}
+	// keep the mounts below out of the host mount namespace's peer groups,
+	// so that they don't propagate elsewhere
+	err = unix.Mount("", root, "", unix.MS_PRIVATE|unix.MS_REC, "")
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[overlay root] Could not make %v a private mount: %v", root, err))
+	}
+	binds := []struct {
+		source  string
+		fstype  string
+		flags   uintptr
+		purpose string
+	}{
+		{"proc", "proc", 0, "/proc"},
+		{"/dev", "", unix.MS_BIND | unix.MS_REC, "/dev"},
+		{"/sys", "", unix.MS_BIND | unix.MS_REC, "/sys"},
+		{taskContext.TaskDir, "", unix.MS_BIND, taskContext.TaskDir},
+	}
+	for _, b := range binds {
+		var target string
+		target, err = mkdirUnder(root, b.purpose)
+		if err == nil {
+			err = ort.mount(b.source, target, b.fstype, b.flags, "")
+		}
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[overlay root] Could not mount %v at %v: %v", b.source, target, err))
+		}
+	}
+	// use the name servers of the host, since the image can't know them;
+	// this only modifies the upper layer
+	if resolvConf, err := os.ReadFile("/etc/resolv.conf"); err == nil {
+		err = copyResolvConf(root, resolvConf)
+		if err != nil {
+			log.Printf("WARNING: could not copy /etc/resolv.conf into overlay root: %v", err)
+		}
+	}
+	for _, c := range ort.task.Commands {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.Chroot = root
+	}
+	ort.task.Infof("[overlay root] Commands run in overlay of root image %v", config.OverlayRootImage)
+	return nil
+}
+
+func (ort *OverlayRootTask) mount(source, target, fstype string, flags uintptr, data string) error {
+	err := unix.Mount(source, target, fstype, flags, data)
+	if err != nil {
+		return err
+	}
+	ort.mounts = append(ort.mounts, target)
+	return nil
+}
+
+func (ort *OverlayRootTask) Stop(err *ExecutionErrors) {
+	for i := len(ort.mounts) - 1; i >= 0; i-- {
+		e := unix.Unmount(ort.mounts[i], unix.MNT_DETACH)
+		if e != nil {
+			// don't remove the base directory, since it may still contain
+			// mounts of the task directory or host directories
+			err.add(executionError(internalError, errored, fmt.Errorf("[overlay root] Could not unmount %v: %v", ort.mounts[i], e)))
+			return
+		}
+	}
+	if ort.baseDir != "" {
+		e := os.RemoveAll(ort.baseDir)
+		if e != nil {
+			err.add(executionError(internalError, errored, fmt.Errorf("[overlay root] Could not remove overlay directory %v: %v", ort.baseDir, e)))
+		}
+	}
+}
+
+// mkdirUnder creates directory dir under root, like os.MkdirAll, and returns
+// its path. Since the image controls the contents of root, any existing
+// component of the path must be a directory and not a symlink, which would be
+// followed outside of root when the directory is created or mounted onto.
+func mkdirUnder(root, dir string) (string, error) {
+	path := root
+	for _, name := range strings.Split(filepath.Clean("/"+dir), "/") {
+		if name == "" {
+			continue
+		}
+		path = filepath.Join(path, name)
+		info, err := os.Lstat(path)
+		switch {
+		case os.IsNotExist(err):
+			err = os.Mkdir(path, 0755)
+			if err != nil {
+				return "", err
+			}
+		case err != nil:
+			return "", err
+		case !info.IsDir():
+			return "", fmt.Errorf("%v is not a directory", path)
+		}
+	}
+	return path, nil
+}
+
+// copyResolvConf writes resolvConf to etc/resolv.conf under root. The image
+// may ship either as a symlink, which would be followed outside of root, so
+// etc must be a directory, and any existing resolv.conf is replaced with a
+// regular file.
+func copyResolvConf(root string, resolvConf []byte) error {
+	etc := filepath.Join(root, "etc")
+	info, err := os.Lstat(etc)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%v is not a directory", etc)
+	}
+	file := filepath.Join(etc, "resolv.conf")
+	err = os.Remove(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL|syscall.O_NOFOLLOW, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(resolvConf)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+//go:build simple
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestOverlayRootDiscardsChanges(t *testing.T) {
+	setup(t)
+	if os.Geteuid() != 0 {
+		t.Skip("overlay root requires the worker to run as root")
+	}
+	// the host root filesystem serves as the root image
+	config.OverlayRootImage = "/"
+	marker := filepath.Join("/etc", "overlay-root-test-"+t.Name())
+	payload := GenericWorkerPayload{
+		Command: [][]string{
+			{"/usr/bin/env", "bash", "-c", "echo hello > " + marker + " && cat " + marker + " && echo persisted > result.txt"},
+		},
+		MaxRunTime: 30,
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "completed", "completed")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "Commands run in overlay of root image /") {
+		t.Fatalf("Expected log file to mention overlay root, but it didn't\n%s", logtext)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		_ = os.Remove(marker)
+		t.Fatalf("Expected %v written inside overlay root not to exist on host, but got %v", marker, err)
+	}
+	if _, err := os.Stat(filepath.Join(taskContext.TaskDir, "result.txt")); err != nil {
+		t.Fatalf("Expected file written to task directory inside overlay root to exist on host: %v", err)
+	}
+}
+
+func TestCopyResolvConfReplacesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside")
+	err := os.WriteFile(outside, []byte("original"), 0644)
+	if err != nil {
+		t.Fatalf("Could not write %v: %v", outside, err)
+	}
+	err = os.Mkdir(filepath.Join(root, "etc"), 0755)
+	if err != nil {
+		t.Fatalf("Could not create etc: %v", err)
+	}
+	resolvConf := filepath.Join(root, "etc", "resolv.conf")
+	err = os.Symlink(outside, resolvConf)
+	if err != nil {
+		t.Fatalf("Could not create symlink: %v", err)
+	}
+
+	err = copyResolvConf(root, []byte("nameserver 192.0.2.1\n"))
+	if err != nil {
+		t.Fatalf("Could not copy resolv.conf: %v", err)
+	}
+	if content, _ := os.ReadFile(outside); string(content) != "original" {
+		t.Fatalf("Expected %v to be left alone, but it contains %q", outside, content)
+	}
+	info, err := os.Lstat(resolvConf)
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("Expected %v to be a regular file: %v %v", resolvConf, info, err)
+	}
+	if content, _ := os.ReadFile(resolvConf); string(content) != "nameserver 192.0.2.1\n" {
+		t.Fatalf("Expected %v to contain the name servers of the host, but it contains %q", resolvConf, content)
+	}
+}
+
+func TestCopyResolvConfEtcSymlink(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	err := os.Symlink(outside, filepath.Join(root, "etc"))
+	if err != nil {
+		t.Fatalf("Could not create symlink: %v", err)
+	}
+
+	err = copyResolvConf(root, []byte("nameserver 192.0.2.1\n"))
+	if err == nil {
+		t.Fatal("Expected copying resolv.conf to fail when etc is a symlink")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "resolv.conf")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no resolv.conf to be written outside of the root: %v", err)
+	}
+}
+
+func TestMkdirUnder(t *testing.T) {
+	root := t.TempDir()
+	target, err := mkdirUnder(root, "/home/task_1")
+	if err != nil {
+		t.Fatalf("Could not create directory: %v", err)
+	}
+	if target != filepath.Join(root, "home", "task_1") {
+		t.Fatalf("Expected directory to be created under %v, but got %v", root, target)
+	}
+	if info, err := os.Lstat(target); err != nil || !info.IsDir() {
+		t.Fatalf("Expected %v to be a directory: %v %v", target, info, err)
+	}
+	// existing directories are fine
+	_, err = mkdirUnder(root, "/home/task_1")
+	if err != nil {
+		t.Fatalf("Could not create existing directory: %v", err)
+	}
+}
+
+func TestMkdirUnderSymlink(t *testing.T) {
+	for _, dir := range []string{"/proc", "/home/task_1"} {
+		t.Run(dir, func(t *testing.T) {
+			root := t.TempDir()
+			outside := t.TempDir()
+			link := filepath.Join(root, strings.Split(dir, "/")[1])
+			err := os.Symlink(outside, link)
+			if err != nil {
+				t.Fatalf("Could not create symlink: %v", err)
+			}
+
+			_, err = mkdirUnder(root, dir)
+			if err == nil {
+				t.Fatalf("Expected creating %v to fail when %v is a symlink", dir, link)
+			}
+			entries, err := os.ReadDir(outside)
+			if err != nil || len(entries) > 0 {
+				t.Fatalf("Expected nothing to be created outside of the root: %v %v", entries, err)
+			}
+		})
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/artifacts"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/fileutil"
 )
@@ -53,8 +53,8 @@ func (feature *RDPFeature) NewTaskFeature(task *TaskRun) TaskFeature {
 	}
 }
 
-func (l *RDPTask) RequiredScopes() scopes.Required {
-	return scopes.Required{
+func (l *RDPTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{
 		{
 			"generic-worker:allow-rdp:" + l.task.Definition.ProvisionerID + "/" + l.task.Definition.WorkerType,
 		},
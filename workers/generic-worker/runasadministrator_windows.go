@@ -3,7 +3,7 @@ package main
 import (
 	"fmt"
 
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/win32"
 )
 
@@ -40,8 +40,8 @@ func (feature *RunAsAdministratorFeature) NewTaskFeature(task *TaskRun) TaskFeat
 	}
 }
 
-func (l *RunAsAdministratorTask) RequiredScopes() scopes.Required {
-	return scopes.Required{{
+func (l *RunAsAdministratorTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{{
 		"generic-worker:run-as-administrator:" + config.ProvisionerID + "/" + config.WorkerType,
 	}}
 }
@@ -52,7 +52,15 @@ func (user *OSUser) MakeAdmin() error {
 	return err
 }
 
-func DeleteUser(username string) (err error) {
+// SetPassword changes the password of the existing user account to
+// user.Password.
+func (user *OSUser) SetPassword() error {
+	return host.Run("net", "user", user.Name, user.Password)
+}
+
+// DeleteProfile deletes the user profile (profile directory and registry
+// hive) of the given user, but not the user account itself.
+func DeleteProfile(username string) (err error) {
 	var u *user.User
 	u, err = user.Lookup(username)
 	if err == nil {
@@ -71,6 +79,18 @@ func DeleteUser(username string) (err error) {
 	} else {
 		log.Printf("WARNING: not able to look up SID for user %v: %v", username, err)
 	}
+	return
+}
+
+// KillUserProcesses forcefully terminates all processes running as the given
+// user.
+func KillUserProcesses(username string) error {
+	_, err := host.RunIgnoreError("No tasks running", "taskkill.exe", "/f", "/fi", "USERNAME eq "+username)
+	return err
+}
+
+func DeleteUser(username string) (err error) {
+	err = DeleteProfile(username)
 	err2 := host.Run("net", "user", username, "/delete")
 	if err2 != nil {
 		log.Printf("WARNING: not able to delete user account %v: %v", username, err2)
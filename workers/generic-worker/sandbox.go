@@ -0,0 +1,47 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+)
+
+// SandboxFeature isolates task commands from the rest of the worker
+// environment, when config setting enableSandbox is true. Unlike most
+// features, it is enabled by the worker configuration rather than by the
+// task, so that tasks cannot opt out of it.
+type SandboxFeature struct {
+}
+
+func (feature *SandboxFeature) Name() string {
+	return "Sandbox"
+}
+
+func (feature *SandboxFeature) Initialise() error {
+	if !config.EnableSandbox {
+		return nil
+	}
+	return initialiseSandbox()
+}
+
+func (feature *SandboxFeature) PersistState() error {
+	return nil
+}
+
+func (feature *SandboxFeature) IsEnabled(task *TaskRun) bool {
+	return config.EnableSandbox
+}
+
+func (feature *SandboxFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &SandboxTask{
+		task: task,
+	}
+}
+
+func (st *SandboxTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{}
+}
+
+func (st *SandboxTask) ReservedArtifacts() []string {
+	return []string{}
+}
@@ -0,0 +1,20 @@
+//go:build darwin
+
+package main
+
+import "fmt"
+
+type SandboxTask struct {
+	task *TaskRun
+}
+
+func initialiseSandbox() error {
+	return fmt.Errorf("enableSandbox is not supported on macOS")
+}
+
+func (st *SandboxTask) Start() *CommandExecutionError {
+	return nil
+}
+
+func (st *SandboxTask) Stop(err *ExecutionErrors) {
+}
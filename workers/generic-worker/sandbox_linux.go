@@ -0,0 +1,144 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// SandboxTask wraps each task command with bubblewrap (see
+// https://github.com/containers/bubblewrap). Commands see a read-only view of
+// the host filesystem, in which the task directory is the only writable
+// location, and from which the directories of other tasks, the caches and
+// downloads directories, and the worker config file are hidden. Commands run
+// in new namespaces (other than the network namespace), with no capabilities,
+// and bubblewrap prevents them from gaining new privileges (e.g. via setuid
+// binaries). If config setting sandboxSeccompProfile is set, the given
+// seccomp filter is also applied.
+//
+// With the multiuser engine, bubblewrap runs as the task user, and each task
+// gets its own user namespace in addition to its own mount namespace, so that
+// task users cannot see the files or processes of other tasks on a shared
+// worker.
+type SandboxTask struct {
+	task *TaskRun
+	// open seccomp profiles, one per command, since bubblewrap consumes the
+	// file descriptor it reads the profile from
+	seccompProfiles []*os.File
+}
+
+func initialiseSandbox() error {
+	_, err := exec.LookPath(config.BubblewrapExecutable)
+	if err != nil {
+		return fmt.Errorf("config setting enableSandbox is true, but bubblewrap executable %v could not be found: %v", config.BubblewrapExecutable, err)
+	}
+	if config.SandboxSeccompProfile != "" {
+		_, err = os.Stat(config.SandboxSeccompProfile)
+		if err != nil {
+			return fmt.Errorf("could not read seccomp profile %v specified in config setting sandboxSeccompProfile: %v", config.SandboxSeccompProfile, err)
+		}
+	}
+	return nil
+}
+
+func (st *SandboxTask) Start() *CommandExecutionError {
+	bwrapPath, err := exec.LookPath(config.BubblewrapExecutable)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[sandbox] Could not find bubblewrap executable %v: %v", config.BubblewrapExecutable, err))
+	}
+	args, err := st.bubblewrapArgs()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[sandbox] Could not determine sandbox configuration: %v", err))
+	}
+	for _, c := range st.task.Commands {
+		cmdArgs := append([]string{config.BubblewrapExecutable}, args...)
+		if config.SandboxSeccompProfile != "" {
+			profile, err := os.Open(config.SandboxSeccompProfile)
+			if err != nil {
+				return executionError(internalError, errored, fmt.Errorf("[sandbox] Could not open seccomp profile %v: %v", config.SandboxSeccompProfile, err))
+			}
+			st.seccompProfiles = append(st.seccompProfiles, profile)
+			// ExtraFiles[i] becomes file descriptor 3+i in the child process
+			cmdArgs = append(cmdArgs, "--seccomp", strconv.Itoa(3+len(c.Cmd.ExtraFiles)))
+			c.Cmd.ExtraFiles = append(c.Cmd.ExtraFiles, profile)
+		}
+		cmdArgs = append(cmdArgs, "--")
+		c.Cmd.Args = append(cmdArgs, c.Cmd.Args...)
+		c.Cmd.Path = bwrapPath
+	}
+	st.task.Info("[sandbox] Task commands will run in a bubblewrap sandbox; only the task directory is writable")
+	return nil
+}
+
+func (st *SandboxTask) Stop(err *ExecutionErrors) {
+	for _, profile := range st.seccompProfiles {
+		_ = profile.Close()
+	}
+}
+
+// bubblewrapArgs returns the bubblewrap options that set up the sandbox for
+// the current task.
+func (st *SandboxTask) bubblewrapArgs() ([]string, error) {
+	args := []string{
+		"--die-with-parent",
+		"--unshare-all",
+		"--share-net",
+	}
+	if engine == "multiuser" {
+		// --unshare-all only creates a user namespace if it can, but with
+		// the multiuser engine it is required for isolation between task users
+		args = append(args, "--unshare-user")
+	} else {
+		// with the simple engine, task commands run as the worker user, which
+		// may be root
+		args = append(args, "--cap-drop", "ALL")
+	}
+	args = append(
+		args,
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	)
+	// hide directories that hold data from other tasks
+	for _, dir := range []string{config.TasksDir, config.CachesDir, config.DownloadsDir} {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--tmpfs", absDir)
+	}
+	// the worker config contains the worker credentials, and the chain of
+	// trust signing key would allow tasks to forge chain of trust signatures
+	secrets := []string{config.Ed25519SigningKeyLocation}
+	if configFile != nil {
+		secrets = append(secrets, configFile.Path)
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		absSecret, err := filepath.Abs(secret)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(absSecret); err != nil {
+			continue
+		}
+		args = append(args, "--ro-bind", "/dev/null", absSecret)
+	}
+	taskDir, err := filepath.Abs(taskContext.TaskDir)
+	if err != nil {
+		return nil, err
+	}
+	args = append(
+		args,
+		"--bind", taskDir, taskDir,
+		"--chdir", taskDir,
+	)
+	return args, nil
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestSandboxOnlyTaskDirectoryWritable(t *testing.T) {
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		t.Skip("bubblewrap not installed")
+	}
+	setup(t)
+	config.EnableSandbox = true
+	payload := GenericWorkerPayload{
+		Command: [][]string{
+			{"touch", "task-dir-file"},
+			{"/bin/bash", "-c", "touch /etc/sandbox-test-file || echo 'could not write outside task directory'"},
+		},
+		MaxRunTime: 30,
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "completed", "completed")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "could not write outside task directory") {
+		t.Fatalf("Expected task to be unable to write outside of task directory, but it could\n%s", logtext)
+	}
+}
+
+func TestSandboxOtherTaskDirectoriesHidden(t *testing.T) {
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		t.Skip("bubblewrap not installed")
+	}
+	setup(t)
+	config.EnableSandbox = true
+	otherTaskDir := filepath.Join(config.TasksDir, "task_other")
+	err := os.MkdirAll(otherTaskDir, 0777)
+	if err != nil {
+		t.Fatalf("Could not create directory %v: %v", otherTaskDir, err)
+	}
+	payload := GenericWorkerPayload{
+		Command: [][]string{
+			{"/bin/bash", "-c", "test -e '" + otherTaskDir + "' || echo 'other task directory hidden'"},
+		},
+		MaxRunTime: 30,
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "completed", "completed")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "other task directory hidden") {
+		t.Fatalf("Expected directory of other task to be hidden from task, but it wasn't\n%s", logtext)
+	}
+}
+
+func TestSandboxHidesWorkerSecrets(t *testing.T) {
+	setup(t)
+	st := &SandboxTask{}
+	args, err := st.bubblewrapArgs()
+	if err != nil {
+		t.Fatalf("Could not determine sandbox configuration: %v", err)
+	}
+	for _, secret := range []string{config.Ed25519SigningKeyLocation, configFile.Path} {
+		absSecret, err := filepath.Abs(secret)
+		if err != nil {
+			t.Fatalf("Could not determine absolute path of %v: %v", secret, err)
+		}
+		if !strings.Contains(strings.Join(args, " "), "--ro-bind /dev/null "+absSecret) {
+			t.Errorf("Expected %v to be hidden in the sandbox, but bubblewrap arguments were %q", absSecret, args)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+)
+
+// fetchSecretCertificate fetches the PKCS #12 bundle described by cert from
+// the secrets service, using the task credentials, and returns it together
+// with its password.
+func (task *TaskRun) fetchSecretCertificate(cert SecretCertificate) (bundle []byte, password string, err error) {
+	certificateKey := cert.CertificateKey
+	if certificateKey == "" {
+		certificateKey = "certificate"
+	}
+	passwordKey := cert.PasswordKey
+	if passwordKey == "" {
+		passwordKey = "password"
+	}
+	secrets := serviceFactory.Secrets(
+		&tcclient.Credentials{
+			AccessToken: task.TaskClaimResponse.Credentials.AccessToken,
+			ClientID:    task.TaskClaimResponse.Credentials.ClientID,
+			Certificate: task.TaskClaimResponse.Credentials.Certificate,
+		},
+		config.RootURL,
+	)
+	secret, err := secrets.Get(cert.Secret)
+	if err != nil {
+		return nil, "", fmt.Errorf("Could not fetch secret %v: %v", cert.Secret, err)
+	}
+	var values map[string]interface{}
+	err = json.Unmarshal(secret.Secret, &values)
+	if err != nil {
+		return nil, "", fmt.Errorf("Secret %v is not a JSON object: %v", cert.Secret, err)
+	}
+	encoded, ok := values[certificateKey].(string)
+	if !ok {
+		return nil, "", fmt.Errorf("Secret %v does not have string property %v", cert.Secret, certificateKey)
+	}
+	bundle, err = base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", fmt.Errorf("Property %v of secret %v is not base64 encoded: %v", certificateKey, cert.Secret, err)
+	}
+	if values[passwordKey] != nil {
+		password, ok = values[passwordKey].(string)
+		if !ok {
+			return nil, "", fmt.Errorf("Property %v of secret %v is not a string", passwordKey, cert.Secret)
+		}
+	}
+	return bundle, password, nil
+}
@@ -26,6 +26,14 @@ func platformFeatures() []Feature {
 		&InteractiveFeature{},
 		&LoopbackAudioFeature{},
 		&LoopbackVideoFeature{},
+		&KeychainFeature{},
+		&DisplayFeature{},
+		&GPUFeature{},
+		&USBDevicesFeature{},
+		&KVMFeature{},
+		&OverlayRootFeature{},
+		&VirtualMachineFeature{}, // wraps commands, so should be late in the list
+		&SandboxFeature{},        // wraps commands, so should be late in the list
 	}
 }
 
@@ -8,7 +8,7 @@ import (
 	"net/http"
 
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/tcproxy"
 )
 
@@ -47,9 +47,9 @@ func (feature *TaskclusterProxyFeature) NewTaskFeature(task *TaskRun) TaskFeatur
 	}
 }
 
-func (l *TaskclusterProxyTask) RequiredScopes() scopes.Required {
+func (l *TaskclusterProxyTask) RequiredScopes() tcscopes.Required {
 	// let's not require any scopes, to be consistent with docker-worker
-	return scopes.Required{}
+	return tcscopes.Required{}
 }
 
 func (l *TaskclusterProxyTask) Start() *CommandExecutionError {
@@ -74,6 +74,7 @@ func (l *TaskclusterProxyTask) Start() *CommandExecutionError {
 			ClientID:         l.task.TaskClaimResponse.Credentials.ClientID,
 			AuthorizedScopes: scopes,
 		},
+		networkCAFile,
 	)
 	if err != nil {
 		return executionError(internalError, errored, fmt.Errorf("Could not start taskcluster proxy: %s", err))
@@ -25,8 +25,9 @@ type TaskclusterProxy struct {
 }
 
 // New starts a tcproxy OS process using the executable specified, and returns
-// a *TaskclusterProxy.
-func New(taskclusterProxyExecutable string, httpPort uint16, rootURL string, creds *tcclient.Credentials) (*TaskclusterProxy, error) {
+// a *TaskclusterProxy.  If caFile is not empty, the proxy trusts the CA
+// certificates in that file in addition to the system's.
+func New(taskclusterProxyExecutable string, httpPort uint16, rootURL string, creds *tcclient.Credentials, caFile string) (*TaskclusterProxy, error) {
 	args := []string{
 		"--port", strconv.Itoa(int(httpPort)),
 		"--root-url", rootURL,
@@ -37,6 +38,9 @@ func New(taskclusterProxyExecutable string, httpPort uint16, rootURL string, cre
 	if creds.Certificate != "" {
 		args = append(args, "--certificate", creds.Certificate)
 	}
+	if caFile != "" {
+		args = append(args, "--ca-file", caFile)
+	}
 	args = append(args, creds.AuthorizedScopes...)
 	l := &TaskclusterProxy{
 		command:  exec.Command(taskclusterProxyExecutable, args...),
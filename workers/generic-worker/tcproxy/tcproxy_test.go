@@ -9,7 +9,7 @@ import (
 
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
 	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcauth"
-	"github.com/taskcluster/taskcluster/v60/internal/scopes"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
 	"github.com/taskcluster/taskcluster/v60/internal/testrooturl"
 )
 
@@ -28,7 +28,7 @@ func TestTcProxy(t *testing.T) {
 		Certificate:      certificate,
 		AuthorizedScopes: []string{"queue:get-artifact:SampleArtifacts/_/X.txt"},
 	}
-	ll, err := New(executable, 34569, rootURL, creds)
+	ll, err := New(executable, 34569, rootURL, creds, "")
 	// Do defer before checking err since err could be a different error and
 	// process may have already started up.
 	defer func() {
@@ -56,8 +56,8 @@ func TestTcProxy(t *testing.T) {
 	}
 
 	// check that the current scopes satisfy the authorized scopes
-	given := scopes.Given(scopeset.Scopes)
-	required := scopes.Required([][]string{[]string{"queue:get-artifact:SampleArtifacts/_/X.txt"}})
+	given := tcscopes.Given(scopeset.Scopes)
+	required := tcscopes.Required([][]string{[]string{"queue:get-artifact:SampleArtifacts/_/X.txt"}})
 	if ok, err := given.Satisfies(required, tcauth.New(nil, rootURL)); !ok || err != nil {
 		t.Fatalf("Got current scopes %s that do not satisfy authorized scopes %s: %v", string(data), required, err)
 	}
@@ -121,7 +121,16 @@ and reports back results to the queue.
         ** OPTIONAL ** properties
         =========================
 
+          allowedUSBDevices                 USB devices that tasks may be granted access to
+                                            with payload.usbDevices, as a list of
+                                            "<vendorId>:<productId>" strings of lowercase
+                                            hexadecimal IDs (e.g. "18d1:4ee7"). A product ID
+                                            of "*" allows all products of the vendor. Linux
+                                            only. [default: []]
           availabilityZone                  The EC2 availability zone of the worker.
+          bubblewrapExecutable              Filepath of the bubblewrap executable used to
+                                            sandbox task commands (see enableSandbox).
+                                            [default: "bwrap"]
           cachesDir                         The directory where task caches should be stored on
                                             the worker. The directory will be created if it does
                                             not exist. This may be a relative path to the
@@ -144,6 +153,10 @@ and reports back results to the queue.
                                             containing data.  If false, use artifact type 's3'.
                                             The 'object' type will become the default when the
                                             's3' type is deprecated.
+          defaultShell                      The shell that task commands are executed with, if
+                                            not specified in the task payload (see
+                                            task.payload.shell). Either "cmd" or "pwsh".
+                                            Windows only. [default: "cmd"]
           deploymentId                      If running with --configure-for-aws, then between
                                             tasks, at a chosen maximum frequency (see
                                             checkForNewDeploymentEverySecs property), the
@@ -162,14 +175,50 @@ and reports back results to the queue.
                                             (such as formatting a hard drive) and then
                                             rebooting in the run-generic-worker.bat script.
                                             [default: false]
+          dockerExecutable                  Filepath of the docker client executable used to
+                                            run task commands inside Windows containers (see
+                                            enableWindowsContainers). Windows only.
+                                            [default: "docker"]
           downloadsDir                      The directory to cache downloaded files for
                                             populating preloaded caches and readonly mounts. The
                                             directory will be created if it does not exist. This
                                             may be a relative path to the current directory, or
                                             an absolute path. [default: "downloads"]
+          enableGPUs                        Allows tasks to lease NVIDIA GPUs (or MIG slices of
+                                            them) by setting payload.gpus. GPUs are
+                                            discovered with nvidia-smi at startup. Linux
+                                            only. [default: false]
           enableInteractive                 Enables interactive mode. This allows an
                                             interactive shell session to run on the worker.
                                             [default: false]
+          enableSandbox                     If true, task commands are run inside a bubblewrap
+                                            sandbox, with a read-only view of the host
+                                            filesystem in which the task directory is the only
+                                            writable location, and from which other task
+                                            directories, caches, downloads, the worker config
+                                            file and the chain of trust signing key are
+                                            hidden. With the multiuser engine, each task
+                                            additionally runs in its own user namespace, so
+                                            that task users cannot see the files or processes
+                                            of other tasks. Tasks cannot opt out of the
+                                            sandbox. Requires unprivileged user namespaces to
+                                            be enabled for the multiuser engine. Linux only.
+                                            [default: false]
+          enableVirtualMachines             Allows tasks to run their commands inside an
+                                            ephemeral virtual machine, by setting
+                                            payload.features.virtualMachine to true. macOS
+                                            and Linux only. [default: false]
+          enableWindowsContainers           Allows tasks to run their commands inside a
+                                            Windows container, by setting
+                                            payload.features.windowsContainer to true. The
+                                            task user requires access to the docker engine
+                                            (e.g. via membership of the docker-users group).
+                                            Windows only. [default: false]
+          gpuLeaseDir                       Directory holding the lock files that record which
+                                            GPUs are leased to tasks (see enableGPUs). All
+                                            workers on a host must use the same directory, so
+                                            that a GPU is never leased to two tasks at once.
+                                            [default: "/var/run/generic-worker/gpu-leases"]
           idleTimeoutSecs                   How many seconds to wait without getting a new
                                             task to perform, before the worker process exits.
                                             An integer, >= 0. A value of 0 means "never reach
@@ -204,12 +253,37 @@ and reports back results to the queue.
                                             [default: 86400]
           numberOfTasksToRun                If zero, run tasks indefinitely. Otherwise, after
                                             this many tasks, exit. [default: 0]
+          nvidiaSmiExecutable               Filepath of the nvidia-smi executable used to
+                                            discover GPUs (see enableGPUs).
+                                            [default: "nvidia-smi"]
+          overlayRootImage                  If set, the directory of a root filesystem image
+                                            (e.g. a mounted disk image). Task commands are
+                                            then run chrooted into an overlayfs mount of it,
+                                            with /proc, /dev, /sys and the task directory
+                                            mounted inside. Changes to the image are discarded
+                                            when the task completes. The worker must run as
+                                            root. Simple engine on Linux only. [default: ""]
+          paused                            If true, do not claim tasks. This can be changed
+                                            while the worker is running, using worker-runner's
+                                            config-update message, to pause and resume a
+                                            worker. A paused worker does not shut down when
+                                            idle. [default: false]
           privateIP                         The private IP of the worker, used by chain of trust.
           provisionerId                     The taskcluster provisioner which is taking care
                                             of provisioning environments with generic-worker
                                             running on them. [default: "test-provisioner"]
           publicIP                          The IP address for VNC access.  Also used by chain of
                                             trust when present.
+          pwshExecutable                    Filepath of the PowerShell Core executable used to
+                                            execute task commands with shell pwsh (see
+                                            defaultShell). Windows only. [default: "pwsh.exe"]
+          qemuExecutable                    Filepath of the QEMU system emulator used to run
+                                            virtual machines on Linux (see
+                                            enableVirtualMachines).
+                                            [default: "qemu-system-x86_64"]
+          qemuImgExecutable                 Filepath of the qemu-img executable used to create
+                                            virtual machine disk overlays on Linux (see
+                                            enableVirtualMachines). [default: "qemu-img"]
           region                            The EC2 region of the worker. Used by chain of trust.
           requiredDiskSpaceMegabytes        The garbage collector will ensure at least this
                                             number of megabytes of disk space are available
@@ -229,6 +303,11 @@ and reports back results to the queue.
                                             runTasksAsCurrentUser is true, the script will still
                                             be executed as the task user, rather than the
                                             current user (that runs the generic-worker process).` + runTasksAsCurrentUserUsage() + `
+          sandboxSeccompProfile             Filepath of a compiled seccomp BPF filter to apply
+                                            to sandboxed task commands (see enableSandbox),
+                                            as accepted by the --seccomp option of bubblewrap.
+                                            If empty, no seccomp filter is applied.
+                                            [default: ""]
           sentryProject                     The project name used in https://sentry.io for
                                             reporting worker crashes. Permission to publish
                                             crash reports is granted via the scope
@@ -250,14 +329,53 @@ and reports back results to the queue.
                                             for machines running in production, such as on AWS
                                             EC2 spot instances. Use with caution!
                                             [default: false]
+          suppressBackgroundActivity        If true, Windows Update, Defender scans, automatic
+                                            maintenance and telemetry are paused while each
+                                            task runs, and re-enabled when it completes, so
+                                            that they do not stall tasks. Windows only.
+                                            [default: false]
           taskclusterProxyExecutable        Filepath of taskcluster-proxy executable to use; see
                                             https://github.com/taskcluster/taskcluster/tree/main/tools/taskcluster-proxy
                                             [default: "taskcluster-proxy"]
           taskclusterProxyPort              Port number for taskcluster-proxy HTTP requests.
                                             [default: 80]
+          tartExecutable                    Filepath of the tart executable used to run virtual
+                                            machines on macOS (see enableVirtualMachines); see
+                                            https://tart.run/ [default: "tart"]
           tasksDir                          The location where task directories should be
                                             created on the worker.
                                             [default (varies by platform): ` + fmt.Sprintf("%q", defaultTasksDir()) + `]
+          taskUserPoolSize                  If greater than 0, task users are taken in turn from
+                                            a pool of this many pre-created task users (named
+                                            task_pool_0, task_pool_1, ...) which are recycled
+                                            between tasks (processes killed, profile wiped,
+                                            password rotated), rather than a new task user being
+                                            created for every task. This reduces the time
+                                            between tasks. Must be 0 or at least 2. Windows
+                                            only. [default: 0]
+          terminationAbortMarginSecs        When the worker is asked to terminate without
+                                            finishing its current task, and the time at which
+                                            it will be terminated is known (for example, on
+                                            cloud instance preemption), the task is given until
+                                            this many seconds before that time to complete,
+                                            leaving time to upload its artifacts, before it is
+                                            aborted. [default: 15]
+          virtiofsdExecutable               Filepath of the virtiofsd executable used to share
+                                            the task directory with virtual machines on Linux
+                                            (see enableVirtualMachines).
+                                            [default: "/usr/libexec/virtiofsd"]
+          virtualMachineBootTimeoutSecs     The maximum number of seconds to wait for a task
+                                            virtual machine to boot and accept ssh connections.
+                                            [default: 300]
+          virtualMachineSSHPrivateKey       Filepath of the ssh private key used to connect to
+                                            task virtual machines. If not set, the default
+                                            ssh identities of the worker user are used.
+          virtualMachineSSHUser             The user to connect to task virtual machines as,
+                                            over ssh. [default: "admin"]
+          westonExecutable                  Filepath of the Weston compositor used to provide
+                                            headless Wayland displays to tasks with
+                                            payload.display.server weston.
+                                            [default: "weston"]
           workerGroup                       Typically this would be an aws region - an
                                             identifier to uniquely identify which pool of
                                             workers this worker logically belongs to.
@@ -282,6 +400,9 @@ and reports back results to the queue.
           wstServerURL                      The URL of the websocktunnel server with which to expose
                                             live logs.  Optional if not using websocktunnel to expose
                                             live logs.
+          xvfbExecutable                    Filepath of the Xvfb X server used to provide
+                                            headless X11 displays to tasks with
+                                            payload.features.display. [default: "Xvfb"]
 
     If an optional config setting is not provided in the json configuration file, the
     default will be taken (defaults documented above).
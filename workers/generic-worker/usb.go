@@ -0,0 +1,70 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+)
+
+type USBDevicesFeature struct {
+}
+
+func (feature *USBDevicesFeature) Name() string {
+	return "USB Devices"
+}
+
+func (feature *USBDevicesFeature) Initialise() error {
+	return nil
+}
+
+func (feature *USBDevicesFeature) PersistState() error {
+	return nil
+}
+
+func (feature *USBDevicesFeature) IsEnabled(task *TaskRun) bool {
+	return len(task.Payload.UsbDevices) > 0
+}
+
+func (feature *USBDevicesFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &USBDevicesTask{
+		task: task,
+	}
+}
+
+type USBDevicesTask struct {
+	task *TaskRun
+	// devices that the task user has been granted access to
+	granted []*usbDevice
+}
+
+func (ut *USBDevicesTask) RequiredScopes() tcscopes.Required {
+	requiredScopes := []string{}
+	for _, device := range ut.task.Payload.UsbDevices {
+		requiredScopes = append(requiredScopes, "generic-worker:usb-device:"+config.ProvisionerID+"/"+config.WorkerType+"/"+device.VendorID+":"+device.ProductID)
+	}
+	return tcscopes.Required{requiredScopes}
+}
+
+func (ut *USBDevicesTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (ut *USBDevicesTask) Start() *CommandExecutionError {
+	return ut.grantUSBDevices()
+}
+
+func (ut *USBDevicesTask) Stop(err *ExecutionErrors) {
+	err.add(ut.releaseUSBDevices())
+}
+
+// usbDeviceAllowed returns whether the worker config setting
+// allowedUSBDevices permits tasks to access devices with the given vendor and
+// product IDs.
+func usbDeviceAllowed(vendorID, productID string) bool {
+	for _, allowed := range config.AllowedUSBDevices {
+		if allowed == vendorID+":"+productID || allowed == vendorID+":*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+//go:build freebsd
+
+package main
+
+import "fmt"
+
+// usbDevice is only implemented on Linux
+type usbDevice struct {
+}
+
+func (ut *USBDevicesTask) grantUSBDevices() *CommandExecutionError {
+	return MalformedPayloadError(fmt.Errorf("[usb] USB devices are not supported on FreeBSD"))
+}
+
+func (ut *USBDevicesTask) releaseUSBDevices() *CommandExecutionError {
+	return nil
+}
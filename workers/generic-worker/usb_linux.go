@@ -0,0 +1,180 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	usbSysfsDevices = "/sys/bus/usb/devices"
+	// USBDEVFS_RESET ioctl request, i.e. _IO('U', 20)
+	usbdevfsReset = 0x5514
+)
+
+// usbDevice is a USB device attached to the worker
+type usbDevice struct {
+	vendorID     string
+	productID    string
+	serialNumber string
+	// device node, e.g. /dev/bus/usb/001/004
+	node string
+	// ownership and permissions of node before access was granted to the
+	// task user, so that they can be restored afterwards
+	uid  uint32
+	gid  uint32
+	mode os.FileMode
+}
+
+func (ut *USBDevicesTask) grantUSBDevices() *CommandExecutionError {
+	for _, requested := range ut.task.Payload.UsbDevices {
+		if !usbDeviceAllowed(requested.VendorID, requested.ProductID) {
+			return MalformedPayloadError(fmt.Errorf("[usb] USB device %v:%v is not in the worker config setting allowedUSBDevices %v", requested.VendorID, requested.ProductID, config.AllowedUSBDevices))
+		}
+	}
+	devices, err := listUSBDevices()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Could not list USB devices: %v", err))
+	}
+	for i, requested := range ut.task.Payload.UsbDevices {
+		device := ut.findUSBDevice(devices, requested)
+		if device == nil {
+			return ResourceUnavailable(fmt.Errorf("[usb] No USB device %v:%v%v is available on this worker", requested.VendorID, requested.ProductID, serialNumberSuffix(requested.SerialNumber)))
+		}
+		cee := grantUSBAccess(device)
+		if cee != nil {
+			return cee
+		}
+		ut.granted = append(ut.granted, device)
+		name := fmt.Sprintf("TASKCLUSTER_USB_DEVICE_%d", i)
+		err = ut.task.setVariable(name, device.node)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[usb] Could not set %v environment variable: %v", name, err))
+		}
+		ut.task.Infof("[usb] USB device %v:%v%v is available at %v", device.vendorID, device.productID, serialNumberSuffix(device.serialNumber), device.node)
+	}
+	return nil
+}
+
+// findUSBDevice returns the first device in devices that matches requested
+// and has not already been granted to the task, or nil if there is none.
+func (ut *USBDevicesTask) findUSBDevice(devices []*usbDevice, requested USBDevice) *usbDevice {
+	for _, device := range devices {
+		if device.vendorID != requested.VendorID || device.productID != requested.ProductID {
+			continue
+		}
+		if requested.SerialNumber != "" && device.serialNumber != requested.SerialNumber {
+			continue
+		}
+		alreadyGranted := false
+		for _, granted := range ut.granted {
+			if granted.node == device.node {
+				alreadyGranted = true
+				break
+			}
+		}
+		if !alreadyGranted {
+			return device
+		}
+	}
+	return nil
+}
+
+func (ut *USBDevicesTask) releaseUSBDevices() *CommandExecutionError {
+	failed := []string{}
+	for _, device := range ut.granted {
+		cee := revokeUSBAccess(device)
+		if cee != nil {
+			ut.task.Errorf("%v", cee)
+			failed = append(failed, device.node)
+			continue
+		}
+		// reset the device, so that the next task finds it in a clean state
+		err := resetUSBDevice(device.node)
+		if err != nil {
+			ut.task.Errorf("[usb] Could not reset USB device %v: %v", device.node, err)
+			failed = append(failed, device.node)
+		}
+	}
+	if len(failed) > 0 {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Could not release USB device(s) %v", failed))
+	}
+	return nil
+}
+
+// listUSBDevices returns the USB devices attached to the worker, as reported
+// by sysfs.
+func listUSBDevices() ([]*usbDevice, error) {
+	entries, err := os.ReadDir(usbSysfsDevices)
+	if err != nil {
+		return nil, err
+	}
+	devices := []*usbDevice{}
+	for _, entry := range entries {
+		dir := filepath.Join(usbSysfsDevices, entry.Name())
+		// interfaces (e.g. 1-1:1.0) are also listed, but have no idVendor
+		vendorID, err := readSysfsAttribute(dir, "idVendor")
+		if err != nil {
+			continue
+		}
+		productID, err := readSysfsAttribute(dir, "idProduct")
+		if err != nil {
+			return nil, err
+		}
+		busnum, err := readSysfsAttribute(dir, "busnum")
+		if err != nil {
+			return nil, err
+		}
+		devnum, err := readSysfsAttribute(dir, "devnum")
+		if err != nil {
+			return nil, err
+		}
+		bus, err := strconv.Atoi(busnum)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bus number %q of USB device %v: %v", busnum, dir, err)
+		}
+		dev, err := strconv.Atoi(devnum)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device number %q of USB device %v: %v", devnum, dir, err)
+		}
+		// not all devices have a serial number
+		serialNumber, _ := readSysfsAttribute(dir, "serial")
+		devices = append(devices, &usbDevice{
+			vendorID:     vendorID,
+			productID:    productID,
+			serialNumber: serialNumber,
+			node:         fmt.Sprintf("/dev/bus/usb/%03d/%03d", bus, dev),
+		})
+	}
+	return devices, nil
+}
+
+func readSysfsAttribute(dir, attribute string) (string, error) {
+	value, err := os.ReadFile(filepath.Join(dir, attribute))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+func resetUSBDevice(node string) error {
+	f, err := os.OpenFile(node, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return unix.IoctlSetInt(int(f.Fd()), usbdevfsReset, 0)
+}
+
+func serialNumberSuffix(serialNumber string) string {
+	if serialNumber == "" {
+		return ""
+	}
+	return " with serial number " + serialNumber
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestUSBDeviceAllowed(t *testing.T) {
+	setup(t)
+	config.AllowedUSBDevices = []string{"18d1:4ee7", "0483:*"}
+
+	for _, test := range []struct {
+		vendorID  string
+		productID string
+		allowed   bool
+	}{
+		{"18d1", "4ee7", true},
+		{"18d1", "4ee8", false},
+		{"0483", "3748", true},
+		{"1d6b", "0002", false},
+	} {
+		if allowed := usbDeviceAllowed(test.vendorID, test.productID); allowed != test.allowed {
+			t.Errorf("Expected usbDeviceAllowed(%q, %q) to be %v but was %v", test.vendorID, test.productID, test.allowed, allowed)
+		}
+	}
+}
+
+func TestUSBDeviceNotAllowed(t *testing.T) {
+	setup(t)
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		UsbDevices: []USBDevice{
+			{
+				VendorID:  "18d1",
+				ProductID: "4ee7",
+			},
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+	td.Scopes = append(td.Scopes, "generic-worker:usb-device:"+td.ProvisionerID+"/"+td.WorkerType+"/18d1:4ee7")
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "USB device 18d1:4ee7 is not in the worker config setting allowedUSBDevices") {
+		t.Fatalf("Expected log file to mention USB device is not allowed, but it didn't\n%s", logtext)
+	}
+}
@@ -0,0 +1,56 @@
+//go:build multiuser && linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// grantUSBAccess makes the device node owned by the task user, recording its
+// original ownership and permissions so that revokeUSBAccess can restore
+// them.
+func grantUSBAccess(device *usbDevice) *CommandExecutionError {
+	if config.RunTasksAsCurrentUser {
+		err := unix.Access(device.node, unix.R_OK|unix.W_OK)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[usb] Worker user does not have read/write access to %v: %v", device.node, err))
+		}
+		return nil
+	}
+	info, err := os.Stat(device.node)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Could not stat %v: %v", device.node, err))
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	device.uid = stat.Uid
+	device.gid = stat.Gid
+	device.mode = info.Mode().Perm()
+	err = makeFileOrDirReadWritableForUser(false, device.node, taskContext.User)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Could not make %v readwritable for task user: %v", device.node, err))
+	}
+	err = os.Chmod(device.node, 0660)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Could not chmod 660 %v: %v", device.node, err))
+	}
+	return nil
+}
+
+func revokeUSBAccess(device *usbDevice) *CommandExecutionError {
+	if config.RunTasksAsCurrentUser {
+		return nil
+	}
+	err := os.Chown(device.node, int(device.uid), int(device.gid))
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Could not restore ownership of %v: %v", device.node, err))
+	}
+	err = os.Chmod(device.node, device.mode)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Could not restore permissions of %v: %v", device.node, err))
+	}
+	return nil
+}
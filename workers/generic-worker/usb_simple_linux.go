@@ -0,0 +1,24 @@
+//go:build simple && linux
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// grantUSBAccess checks that the worker user, which the simple engine runs
+// tasks as, can use the device. The simple engine does not manage device
+// permissions, since tasks run as the worker user.
+func grantUSBAccess(device *usbDevice) *CommandExecutionError {
+	err := unix.Access(device.node, unix.R_OK|unix.W_OK)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[usb] Worker user does not have read/write access to %v: %v", device.node, err))
+	}
+	return nil
+}
+
+func revokeUSBAccess(device *usbDevice) *CommandExecutionError {
+	return nil
+}
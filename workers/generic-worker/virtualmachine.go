@@ -0,0 +1,200 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/taskcluster/shell"
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
+)
+
+type VirtualMachineFeature struct {
+}
+
+func (feature *VirtualMachineFeature) Name() string {
+	return "Virtual Machine"
+}
+
+func (feature *VirtualMachineFeature) Initialise() error {
+	return nil
+}
+
+func (feature *VirtualMachineFeature) PersistState() error {
+	return nil
+}
+
+func (feature *VirtualMachineFeature) IsEnabled(task *TaskRun) bool {
+	return task.Payload.Features.VirtualMachine
+}
+
+// virtualMachine is implemented by the platform specific hypervisor backends
+type virtualMachine interface {
+	// Boot starts the virtual machine, with the task directory shared with
+	// the guest.
+	Boot() *CommandExecutionError
+	// SSHAddress returns the host and port that the ssh server of the
+	// booted guest can be reached on.
+	SSHAddress() (host string, port uint16, err *CommandExecutionError)
+	// GuestTaskDir returns the location of the shared task directory inside
+	// the guest.
+	GuestTaskDir() string
+	// Destroy stops the virtual machine and discards all of its state.
+	Destroy() *CommandExecutionError
+}
+
+type VirtualMachineTask struct {
+	task      *TaskRun
+	vm        virtualMachine
+	sshArgs   []string
+	sshBinary string
+}
+
+func (feature *VirtualMachineFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &VirtualMachineTask{
+		task: task,
+	}
+}
+
+func (vmt *VirtualMachineTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{{
+		"generic-worker:virtual-machine:" + config.ProvisionerID + "/" + config.WorkerType,
+	}}
+}
+
+func (vmt *VirtualMachineTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (vmt *VirtualMachineTask) Start() *CommandExecutionError {
+	if !config.EnableVirtualMachines {
+		return MalformedPayloadError(fmt.Errorf("This task has payload.features.virtualMachine set to true, but enableVirtualMachines is not enabled on this worker pool (%s/%s)", config.ProvisionerID, config.WorkerType))
+	}
+	if vmt.task.Payload.VirtualMachine == nil || vmt.task.Payload.VirtualMachine.Image == "" {
+		return MalformedPayloadError(fmt.Errorf("task.payload.virtualMachine.image must be specified when task.payload.features.virtualMachine is true"))
+	}
+	var err error
+	vmt.sshBinary, err = exec.LookPath("ssh")
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not find ssh client: %v", err))
+	}
+	var cee *CommandExecutionError
+	vmt.vm, cee = newVirtualMachine(vmt.task)
+	if cee != nil {
+		return cee
+	}
+	vmt.task.Infof("[virtual machine] Booting virtual machine from image %v", vmt.task.Payload.VirtualMachine.Image)
+	cee = vmt.vm.Boot()
+	if cee != nil {
+		return cee
+	}
+	sshHost, sshPort, cee := vmt.vm.SSHAddress()
+	if cee != nil {
+		return cee
+	}
+	vmt.sshArgs = sshArgs(vmt.sshBinary, sshHost, sshPort)
+	cee = vmt.waitForSSH()
+	if cee != nil {
+		return cee
+	}
+	for i, c := range vmt.task.Commands {
+		c.Cmd.Path = vmt.sshBinary
+		env := guestEnv(c.Cmd.Env, vmt.task.Payload.Env)
+		c.Cmd.Args = append(append([]string{}, vmt.sshArgs...), guestCommand(vmt.vm.GuestTaskDir(), env, vmt.task.Payload.Command[i]))
+		// The ssh client runs as the worker user, since the task user does
+		// not have access to the ssh private key. Isolation is provided by
+		// the virtual machine.
+		c.Cmd.SysProcAttr = nil
+	}
+	vmt.task.Infof("[virtual machine] Task commands will run inside the virtual machine, in directory %v", vmt.vm.GuestTaskDir())
+	return nil
+}
+
+func (vmt *VirtualMachineTask) Stop(err *ExecutionErrors) {
+	if vmt.vm == nil {
+		return
+	}
+	err.add(vmt.vm.Destroy())
+}
+
+// waitForSSH polls the ssh server of the guest until it accepts connections,
+// or config.VirtualMachineBootTimeoutSecs have elapsed.
+func (vmt *VirtualMachineTask) waitForSSH() *CommandExecutionError {
+	deadline := time.Now().Add(time.Duration(config.VirtualMachineBootTimeoutSecs) * time.Second)
+	args := append(append([]string{}, vmt.sshArgs[1:]...), "true")
+	for {
+		err := host.Run(vmt.sshBinary, args...)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return executionError(internalError, errored, fmt.Errorf("[virtual machine] Guest ssh server not reachable after %v seconds: %v", config.VirtualMachineBootTimeoutSecs, err))
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// sshArgs returns the ssh client command line, without the remote command,
+// for connecting to the guest ssh server at the given host and port.
+func sshArgs(sshBinary, sshHost string, sshPort uint16) []string {
+	args := []string{
+		sshBinary,
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "LogLevel=ERROR",
+		"-p", strconv.Itoa(int(sshPort)),
+	}
+	if config.VirtualMachineSSHPrivateKey != "" {
+		args = append(args, "-i", config.VirtualMachineSSHPrivateKey)
+	}
+	return append(args, config.VirtualMachineSSHUser+"@"+sshHost)
+}
+
+// hostEnvVars are the variables in the environment of task commands that
+// describe the host rather than the task, so are not passed to the guest,
+// unless the task payload sets them.
+var hostEnvVars = map[string]bool{
+	"DISPLAY":         true,
+	"HOME":            true,
+	"LOGNAME":         true,
+	"PATH":            true,
+	"PWD":             true,
+	"SHELL":           true,
+	"TMPDIR":          true,
+	"USER":            true,
+	"XDG_RUNTIME_DIR": true,
+}
+
+// guestEnv returns the environment for a task command inside the guest, from
+// the environment that the command would have on the host, which includes the
+// variables set by other features (such as TASKCLUSTER_PROXY_URL). The
+// variables are sorted, and later values of repeated variables win.
+func guestEnv(cmdEnv []string, payloadEnv map[string]string) []string {
+	vars := map[string]string{}
+	for _, envVar := range cmdEnv {
+		name, value, _ := strings.Cut(envVar, "=")
+		if _, inPayload := payloadEnv[name]; hostEnvVars[name] && !inPayload {
+			continue
+		}
+		vars[name] = value
+	}
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// guestCommand returns the shell command line that runs a task command inside
+// the guest, from the shared task directory, with the given environment.
+func guestCommand(guestTaskDir string, env []string, command []string) string {
+	return "cd " + shell.Escape(guestTaskDir) + " && exec env " + shell.Escape(env...) + " " + shell.Escape(command...)
+}
@@ -0,0 +1,87 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
+)
+
+// tartVM is a virtual machine run by Apple's Virtualization.framework, using
+// the tart command line tool. See https://tart.run/.
+type tartVM struct {
+	task *TaskRun
+	name string
+	run  *exec.Cmd
+}
+
+func newVirtualMachine(task *TaskRun) (virtualMachine, *CommandExecutionError) {
+	return &tartVM{
+		task: task,
+		name: "task-" + task.TaskID + "-" + strconv.Itoa(int(task.RunID)),
+	}, nil
+}
+
+func (vm *tartVM) Boot() *CommandExecutionError {
+	vmConfig := vm.task.Payload.VirtualMachine
+	err := host.Run(config.TartExecutable, "clone", vmConfig.Image, vm.name)
+	if err != nil {
+		return MalformedPayloadError(fmt.Errorf("[virtual machine] Could not clone virtual machine image %v: %v", vmConfig.Image, err))
+	}
+	setArgs := []string{"set", vm.name}
+	if vmConfig.Cpus > 0 {
+		setArgs = append(setArgs, "--cpu", strconv.Itoa(int(vmConfig.Cpus)))
+	}
+	if vmConfig.MemoryMB > 0 {
+		setArgs = append(setArgs, "--memory", strconv.Itoa(int(vmConfig.MemoryMB)))
+	}
+	if len(setArgs) > 2 {
+		err = host.Run(config.TartExecutable, setArgs...)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not configure virtual machine %v: %v", vm.name, err))
+		}
+	}
+	vm.run = exec.Command(config.TartExecutable, "run", "--no-graphics", "--dir=task:"+taskContext.TaskDir, vm.name)
+	vm.run.Stdout = log.Writer()
+	vm.run.Stderr = log.Writer()
+	err = vm.run.Start()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not start virtual machine %v: %v", vm.name, err))
+	}
+	return nil
+}
+
+func (vm *tartVM) SSHAddress() (string, uint16, *CommandExecutionError) {
+	out, err := host.CombinedOutput(config.TartExecutable, "ip", "--wait", strconv.Itoa(int(config.VirtualMachineBootTimeoutSecs)), vm.name)
+	if err != nil {
+		return "", 0, executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not determine IP address of virtual machine %v: %v", vm.name, err))
+	}
+	return strings.TrimSpace(out), 22, nil
+}
+
+// GuestTaskDir returns the location that tart mounts the shared task
+// directory at inside a macOS guest.
+func (vm *tartVM) GuestTaskDir() string {
+	return "/Volumes/My Shared Files/task"
+}
+
+func (vm *tartVM) Destroy() *CommandExecutionError {
+	if vm.run != nil && vm.run.Process != nil {
+		err := host.Run(config.TartExecutable, "stop", vm.name)
+		if err != nil {
+			log.Printf("WARNING: could not stop virtual machine %v, killing it: %v", vm.name, err)
+			_ = vm.run.Process.Kill()
+		}
+		_ = vm.run.Wait()
+	}
+	err := host.Run(config.TartExecutable, "delete", vm.name)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not delete virtual machine %v: %v", vm.name, err))
+	}
+	return nil
+}
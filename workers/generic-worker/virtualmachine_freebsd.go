@@ -0,0 +1,11 @@
+//go:build freebsd
+
+package main
+
+import (
+	"fmt"
+)
+
+func newVirtualMachine(task *TaskRun) (virtualMachine, *CommandExecutionError) {
+	return nil, MalformedPayloadError(fmt.Errorf("Virtual machines are not supported on FreeBSD"))
+}
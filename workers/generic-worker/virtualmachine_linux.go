@@ -0,0 +1,229 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/fileutil"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
+)
+
+// qemuVM is a KVM accelerated virtual machine run by QEMU. The task directory
+// is shared with the guest using virtio-fs (served by virtiofsd), and the
+// guest serial console is copied into the task log.
+type qemuVM struct {
+	task *TaskRun
+	name string
+	// directory outside of the task directory holding the disk overlay and
+	// the virtiofsd socket
+	workDir     string
+	sshPort     uint16
+	virtiofsd   *exec.Cmd
+	qemu        *exec.Cmd
+	consoleDone chan struct{}
+}
+
+func newVirtualMachine(task *TaskRun) (virtualMachine, *CommandExecutionError) {
+	return &qemuVM{
+		task: task,
+		name: "task-" + task.TaskID + "-" + strconv.Itoa(int(task.RunID)),
+	}, nil
+}
+
+func (vm *qemuVM) Boot() *CommandExecutionError {
+	vmConfig := vm.task.Payload.VirtualMachine
+	image, cee := virtualMachineImage(vmConfig.Image)
+	if cee != nil {
+		return cee
+	}
+	var err error
+	vm.workDir, err = os.MkdirTemp("", vm.name)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not create virtual machine working directory: %v", err))
+	}
+	overlay, cee := vm.createOverlay(image)
+	if cee != nil {
+		return cee
+	}
+	socket, cee := vm.startVirtiofsd()
+	if cee != nil {
+		return cee
+	}
+	vm.sshPort, err = freeLocalPort()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not allocate port for guest ssh server: %v", err))
+	}
+	cpus := vmConfig.Cpus
+	if cpus == 0 {
+		cpus = 1
+	}
+	memoryMB := vmConfig.MemoryMB
+	if memoryMB == 0 {
+		memoryMB = 2048
+	}
+	vm.qemu = exec.Command(
+		config.QEMUExecutable,
+		"-name", vm.name,
+		"-machine", "q35,accel=kvm",
+		"-cpu", "host",
+		"-smp", strconv.Itoa(int(cpus)),
+		"-m", strconv.Itoa(int(memoryMB)),
+		// vhost-user-fs requires guest memory to be shared with virtiofsd
+		"-object", "memory-backend-memfd,id=mem,size="+strconv.Itoa(int(memoryMB))+"M,share=on",
+		"-numa", "node,memdev=mem",
+		"-chardev", "socket,id=taskfs,path="+socket,
+		"-device", "vhost-user-fs-pci,chardev=taskfs,tag=task",
+		"-drive", "file="+overlay+",if=virtio,format=qcow2",
+		"-netdev", "user,id=net0,hostfwd=tcp:127.0.0.1:"+strconv.Itoa(int(vm.sshPort))+"-:22",
+		"-device", "virtio-net-pci,netdev=net0",
+		"-display", "none",
+		"-monitor", "none",
+		"-serial", "stdio",
+	)
+	vm.qemu.Stderr = log.Writer()
+	console, err := vm.qemu.StdoutPipe()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not capture serial console of virtual machine %v: %v", vm.name, err))
+	}
+	err = vm.qemu.Start()
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not start virtual machine %v: %v", vm.name, err))
+	}
+	vm.consoleDone = make(chan struct{})
+	go func() {
+		defer close(vm.consoleDone)
+		scanner := bufio.NewScanner(console)
+		for scanner.Scan() {
+			vm.task.Log("[virtual machine console] ", scanner.Text())
+		}
+	}()
+	return nil
+}
+
+// virtualMachineImage returns the path of the task's virtual machine image,
+// with symbolic links resolved. qemu-img and QEMU run as the worker user, so
+// the image must be a regular file inside the task directory, otherwise a task
+// could have them open any file or block device on the host.
+func virtualMachineImage(image string) (string, *CommandExecutionError) {
+	path, err := filepath.EvalSymlinks(filepath.Join(taskContext.TaskDir, image))
+	if err != nil {
+		return "", MalformedPayloadError(fmt.Errorf("[virtual machine] Could not find virtual machine image %v in task directory: %v", image, err))
+	}
+	inside, err := fileutil.WithinDir(taskContext.TaskDir, path)
+	if err != nil || !inside {
+		return "", MalformedPayloadError(fmt.Errorf("[virtual machine] Virtual machine image %v is not inside the task directory", image))
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", MalformedPayloadError(fmt.Errorf("[virtual machine] Virtual machine image %v is not a regular file", image))
+	}
+	return path, nil
+}
+
+// createOverlay creates a copy-on-write qcow2 overlay of image in the
+// virtual machine working directory, so that the image itself is not
+// modified by the guest, and returns its path.
+func (vm *qemuVM) createOverlay(image string) (string, *CommandExecutionError) {
+	out, err := host.CombinedOutput(config.QEMUImgExecutable, "info", "--output=json", image)
+	if err != nil {
+		return "", MalformedPayloadError(fmt.Errorf("[virtual machine] Could not read virtual machine image %v: %v", image, err))
+	}
+	var info struct {
+		Format          string `json:"format"`
+		BackingFilename string `json:"backing-filename"`
+	}
+	err = json.Unmarshal([]byte(out), &info)
+	if err != nil {
+		return "", executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not parse output of %v info: %v", config.QEMUImgExecutable, err))
+	}
+	// a backing file could be anywhere on the host, like a symbolic link
+	if info.BackingFilename != "" {
+		return "", MalformedPayloadError(fmt.Errorf("[virtual machine] Virtual machine image %v has a backing file, which is not supported", image))
+	}
+	overlay := filepath.Join(vm.workDir, "disk.qcow2")
+	err = host.Run(config.QEMUImgExecutable, "create", "-f", "qcow2", "-F", info.Format, "-b", image, overlay)
+	if err != nil {
+		return "", executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not create disk overlay for virtual machine image %v: %v", image, err))
+	}
+	return overlay, nil
+}
+
+// startVirtiofsd starts a virtiofsd process serving the task directory, and
+// returns the path of its vhost-user socket, once it has been created.
+func (vm *qemuVM) startVirtiofsd() (string, *CommandExecutionError) {
+	socket := filepath.Join(vm.workDir, "virtiofsd.sock")
+	vm.virtiofsd = exec.Command(
+		config.VirtiofsdExecutable,
+		"--socket-path="+socket,
+		"--shared-dir="+taskContext.TaskDir,
+		"--cache=auto",
+	)
+	vm.virtiofsd.Stdout = log.Writer()
+	vm.virtiofsd.Stderr = log.Writer()
+	err := vm.virtiofsd.Start()
+	if err != nil {
+		return "", executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not start %v: %v", config.VirtiofsdExecutable, err))
+	}
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(socket); err == nil {
+			return socket, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return "", executionError(internalError, errored, fmt.Errorf("[virtual machine] %v did not create socket %v: %v", config.VirtiofsdExecutable, socket, err))
+}
+
+// freeLocalPort returns a TCP port on the loopback interface that is not
+// currently in use.
+func freeLocalPort() (uint16, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return uint16(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+func (vm *qemuVM) SSHAddress() (string, uint16, *CommandExecutionError) {
+	return "127.0.0.1", vm.sshPort, nil
+}
+
+// GuestTaskDir returns the location that the guest is required to mount the
+// virtio-fs tag "task" at.
+func (vm *qemuVM) GuestTaskDir() string {
+	return "/mnt/task"
+}
+
+func (vm *qemuVM) Destroy() *CommandExecutionError {
+	if vm.qemu != nil && vm.qemu.Process != nil {
+		err := vm.qemu.Process.Signal(syscall.SIGTERM)
+		if err != nil {
+			log.Printf("WARNING: could not stop virtual machine %v, killing it: %v", vm.name, err)
+			_ = vm.qemu.Process.Kill()
+		}
+		<-vm.consoleDone
+		_ = vm.qemu.Wait()
+	}
+	if vm.virtiofsd != nil && vm.virtiofsd.Process != nil {
+		_ = vm.virtiofsd.Process.Signal(syscall.SIGTERM)
+		_ = vm.virtiofsd.Wait()
+	}
+	if vm.workDir != "" {
+		err := os.RemoveAll(vm.workDir)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[virtual machine] Could not remove virtual machine working directory %v: %v", vm.workDir, err))
+		}
+	}
+	return nil
+}
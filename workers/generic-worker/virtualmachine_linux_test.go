@@ -0,0 +1,92 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestVirtualMachineImageNotInTaskDirectory(t *testing.T) {
+	setup(t)
+	config.EnableVirtualMachines = true
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			VirtualMachine: true,
+		},
+		VirtualMachine: &VirtualMachine{
+			Image: "images/does-not-exist.qcow2",
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+	td.Scopes = append(td.Scopes, "generic-worker:virtual-machine:"+td.ProvisionerID+"/"+td.WorkerType)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "Could not find virtual machine image images/does-not-exist.qcow2 in task directory") {
+		t.Fatalf("Expected log file to mention missing virtual machine image, but it didn't\n%s", logtext)
+	}
+}
+
+func TestVirtualMachineImageOutsideTaskDirectory(t *testing.T) {
+	parent := t.TempDir()
+	oldTaskContext := taskContext
+	t.Cleanup(func() { taskContext = oldTaskContext })
+	taskContext = &TaskContext{TaskDir: filepath.Join(parent, "task_1")}
+
+	outside := filepath.Join(parent, "outside.qcow2")
+	inside := filepath.Join(taskContext.TaskDir, "images", "inside.qcow2")
+	for _, file := range []string{outside, inside} {
+		err := os.MkdirAll(filepath.Dir(file), 0777)
+		if err != nil {
+			t.Fatalf("Could not create directory %v: %v", filepath.Dir(file), err)
+		}
+		err = os.WriteFile(file, []byte("image"), 0666)
+		if err != nil {
+			t.Fatalf("Could not write file %v: %v", file, err)
+		}
+	}
+	for link, target := range map[string]string{
+		"outside-link.qcow2": outside,
+		"inside-link.qcow2":  inside,
+		"images-link":        filepath.Join(taskContext.TaskDir, "images"),
+		"root-link":          "/",
+	} {
+		err := os.Symlink(target, filepath.Join(taskContext.TaskDir, link))
+		if err != nil {
+			t.Fatalf("Could not create symbolic link %v: %v", link, err)
+		}
+	}
+
+	for _, image := range []string{
+		"../outside.qcow2",
+		"images/../../outside.qcow2",
+		"outside-link.qcow2",
+		"root-link/dev/null",
+		"images",
+	} {
+		if _, cee := virtualMachineImage(image); cee == nil {
+			t.Errorf("Expected virtual machine image %v to be rejected, but it wasn't", image)
+		}
+	}
+	for _, image := range []string{
+		"images/inside.qcow2",
+		"inside-link.qcow2",
+		"images-link/inside.qcow2",
+	} {
+		path, cee := virtualMachineImage(image)
+		if cee != nil {
+			t.Errorf("Expected virtual machine image %v to be accepted, but got: %v", image, cee)
+		} else if path != inside {
+			t.Errorf("Expected virtual machine image %v to resolve to %v, but got %v", image, inside, path)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+//go:build darwin || linux || freebsd
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestVirtualMachineNotEnabled(t *testing.T) {
+	setup(t)
+	config.EnableVirtualMachines = false
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			VirtualMachine: true,
+		},
+		VirtualMachine: &VirtualMachine{
+			Image: "ghcr.io/cirruslabs/macos-sonoma-base:latest",
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+	td.Scopes = append(td.Scopes, "generic-worker:virtual-machine:"+td.ProvisionerID+"/"+td.WorkerType)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "enableVirtualMachines is not enabled") {
+		t.Fatalf("Expected log file to mention enableVirtualMachines, but it didn't\n%s", logtext)
+	}
+}
+
+func TestVirtualMachineMissingScopes(t *testing.T) {
+	setup(t)
+	config.EnableVirtualMachines = true
+	payload := GenericWorkerPayload{
+		Command:    helloGoodbye(),
+		MaxRunTime: 30,
+		Features: FeatureFlags{
+			VirtualMachine: true,
+		},
+		VirtualMachine: &VirtualMachine{
+			Image: "ghcr.io/cirruslabs/macos-sonoma-base:latest",
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+
+	logtext := LogText(t)
+	if !strings.Contains(logtext, "generic-worker:virtual-machine:"+td.ProvisionerID+"/"+td.WorkerType) {
+		t.Fatalf("Expected log file to contain missing scopes, but it didn't\n%s", logtext)
+	}
+}
+
+func TestVirtualMachineSSHArgs(t *testing.T) {
+	setup(t)
+	config.VirtualMachineSSHUser = "admin"
+	config.VirtualMachineSSHPrivateKey = "/etc/generic-worker/vm_key"
+	args := sshArgs("/usr/bin/ssh", "192.168.64.5", 2222)
+	expected := []string{
+		"/usr/bin/ssh",
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "LogLevel=ERROR",
+		"-p", "2222",
+		"-i", "/etc/generic-worker/vm_key",
+		"admin@192.168.64.5",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected ssh arguments\n%q\nbut got\n%q", expected, args)
+	}
+
+	config.VirtualMachineSSHPrivateKey = ""
+	args = sshArgs("/usr/bin/ssh", "localhost", 22)
+	if args[len(args)-2] == "/etc/generic-worker/vm_key" {
+		t.Fatalf("Expected no private key to be passed to ssh, but got %q", args)
+	}
+}
+
+func TestVirtualMachineGuestEnv(t *testing.T) {
+	cmdEnv := []string{
+		"HOME=/home/task_1",
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"USER=task_1",
+		"LANG=en_US.UTF-8",
+		"TASK_ID=abc",
+		"TASKCLUSTER_PROXY_URL=http://localhost:8080",
+		"TASKCLUSTER_WORKER_LOCATION={\"cloud\":\"aws\"}",
+		"TASKCLUSTER_VIDEO_DEVICE=/dev/video0",
+		"TASKCLUSTER_VIDEO_DEVICE=/dev/video1",
+	}
+	payloadEnv := map[string]string{
+		"LANG": "en_US.UTF-8",
+		"PATH": "/usr/local/bin:/usr/bin:/bin",
+	}
+	env := guestEnv(cmdEnv, payloadEnv)
+	expected := []string{
+		"LANG=en_US.UTF-8",
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"TASKCLUSTER_PROXY_URL=http://localhost:8080",
+		"TASKCLUSTER_VIDEO_DEVICE=/dev/video1",
+		"TASKCLUSTER_WORKER_LOCATION={\"cloud\":\"aws\"}",
+		"TASK_ID=abc",
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Fatalf("Expected guest environment\n%q\nbut got\n%q", expected, env)
+	}
+}
+
+func TestVirtualMachineGuestCommand(t *testing.T) {
+	command := guestCommand(
+		"/Volumes/My Shared Files/task",
+		[]string{"TASK_ID=abc", "GREETING=hello world"},
+		[]string{"/bin/bash", "-c", "echo $GREETING"},
+	)
+	expected := `cd '/Volumes/My Shared Files/task' && exec env TASK_ID=abc 'GREETING=hello world' /bin/bash -c 'echo $GREETING'`
+	if command != expected {
+		t.Fatalf("Expected guest command\n%v\nbut got\n%v", expected, command)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v60/clients/client-go/tcscopes"
+	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/host"
+)
+
+type WindowsContainerFeature struct {
+}
+
+func (feature *WindowsContainerFeature) Name() string {
+	return "Windows Container"
+}
+
+func (feature *WindowsContainerFeature) Initialise() error {
+	return nil
+}
+
+func (feature *WindowsContainerFeature) PersistState() error {
+	return nil
+}
+
+func (feature *WindowsContainerFeature) IsEnabled(task *TaskRun) bool {
+	return task.Payload.Features.WindowsContainer
+}
+
+type WindowsContainerTask struct {
+	task *TaskRun
+	// names of containers that may have been created by this task, so that
+	// they can be removed when the task completes, even if the docker client
+	// process was killed (e.g. due to maxRunTime being exceeded)
+	containerNames []string
+}
+
+func (feature *WindowsContainerFeature) NewTaskFeature(task *TaskRun) TaskFeature {
+	return &WindowsContainerTask{
+		task: task,
+	}
+}
+
+func (wct *WindowsContainerTask) RequiredScopes() tcscopes.Required {
+	return tcscopes.Required{{
+		"generic-worker:windows-container:" + config.ProvisionerID + "/" + config.WorkerType,
+	}}
+}
+
+func (wct *WindowsContainerTask) ReservedArtifacts() []string {
+	return []string{}
+}
+
+func (wct *WindowsContainerTask) Start() *CommandExecutionError {
+	if !config.EnableWindowsContainers {
+		return MalformedPayloadError(fmt.Errorf("This task has payload.features.windowsContainer set to true, but enableWindowsContainers is not enabled on this worker pool (%s/%s)", config.ProvisionerID, config.WorkerType))
+	}
+	container := wct.task.Payload.WindowsContainer
+	if container.Image == "" {
+		return MalformedPayloadError(fmt.Errorf("task.payload.windowsContainer.image must be specified when task.payload.features.windowsContainer is true"))
+	}
+	if container.ImageFile != "" {
+		imageFile := filepath.Join(taskContext.TaskDir, container.ImageFile)
+		wct.task.Infof("[windows container] Loading container image from %v", imageFile)
+		err := host.Run(config.DockerExecutable, "load", "--input", imageFile)
+		if err != nil {
+			return executionError(internalError, errored, fmt.Errorf("[windows container] Could not load container image from %v: %v", imageFile, err))
+		}
+	}
+	dockerPath, err := exec.LookPath(config.DockerExecutable)
+	if err != nil {
+		return executionError(internalError, errored, fmt.Errorf("[windows container] Could not find container engine client %v: %v", config.DockerExecutable, err))
+	}
+	for i, c := range wct.task.Commands {
+		name := fmt.Sprintf("%v_%v_%v", wct.task.TaskID, wct.task.RunID, i)
+		c.Cmd.Args = windowsContainerRunArgs(name, container, taskContext.TaskDir, c.Cmd.Env, c.Cmd.Args)
+		c.Cmd.Path = dockerPath
+		wct.containerNames = append(wct.containerNames, name)
+	}
+	wct.task.Infof("[windows container] Task commands will run in a container created from image %v with %v isolation", container.Image, container.Isolation)
+	return nil
+}
+
+// windowsContainerRunArgs returns the docker client command line for running
+// a task command, with the given environment and arguments, in a container
+// with the given name.
+func windowsContainerRunArgs(name string, container WindowsContainer, taskDir string, env []string, command []string) []string {
+	args := []string{
+		config.DockerExecutable,
+		"run",
+		"--rm",
+		"--name", name,
+		"--isolation", container.Isolation,
+		// mount the task directory at the same location inside the
+		// container, so that the wrapper scripts, mounts and artifacts can
+		// all be referenced by their host paths
+		"--volume", taskDir + ":" + taskDir,
+		"--workdir", taskDir,
+	}
+	// environment variables set by other features (such as
+	// TASKCLUSTER_PROXY_URL) are set on the docker client process, so need to
+	// be explicitly passed through to the container. Payload env vars are set
+	// by the command wrapper script, so are not included here.
+	for _, envVar := range env {
+		if strings.HasPrefix(strings.ToUpper(envVar), "TASKCLUSTER_") {
+			args = append(args, "--env", envVar)
+		}
+	}
+	args = append(args, container.Image, "cmd.exe", "/c")
+	return append(args, command...)
+}
+
+func (wct *WindowsContainerTask) Stop(err *ExecutionErrors) {
+	for _, name := range wct.containerNames {
+		// containers are normally removed automatically (--rm) when their
+		// command exits, so not finding them is expected
+		_, _ = host.RunIgnoreError("No such container", config.DockerExecutable, "rm", "--force", name)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mcuadros/go-defaults"
+)
+
+func TestWindowsContainerNotEnabled(t *testing.T) {
+	setup(t)
+	config.EnableWindowsContainers = false
+	payload := GenericWorkerPayload{
+		Command:    []string{`echo hello`},
+		MaxRunTime: 10,
+		Features: FeatureFlags{
+			WindowsContainer: true,
+		},
+		WindowsContainer: WindowsContainer{
+			Image: "mcr.microsoft.com/windows/nanoserver:ltsc2022",
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+	td.Scopes = []string{
+		"generic-worker:windows-container:" + td.ProvisionerID + "/" + td.WorkerType,
+	}
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+}
+
+func TestWindowsContainerMissingScopes(t *testing.T) {
+	setup(t)
+	config.EnableWindowsContainers = true
+	payload := GenericWorkerPayload{
+		Command:    []string{`echo hello`},
+		MaxRunTime: 10,
+		Features: FeatureFlags{
+			WindowsContainer: true,
+		},
+		WindowsContainer: WindowsContainer{
+			Image: "mcr.microsoft.com/windows/nanoserver:ltsc2022",
+		},
+	}
+	defaults.SetDefaults(&payload)
+	td := testTask(t)
+
+	_ = submitAndAssert(t, td, payload, "exception", "malformed-payload")
+}
+
+func TestWindowsContainerRunArgs(t *testing.T) {
+	setup(t)
+	config.DockerExecutable = "docker"
+	container := WindowsContainer{
+		Image:     "mcr.microsoft.com/windows/nanoserver:ltsc2022",
+		Isolation: "hyperv",
+	}
+	env := []string{
+		"PATH=C:\\Windows\\system32",
+		"TASKCLUSTER_PROXY_URL=http://taskcluster",
+		"Taskcluster_Worker_Location={}",
+		"TASK_ID=abc",
+	}
+	args := windowsContainerRunArgs("abc_0_0", container, `C:\tasks\task_1`, env, []string{`C:\tasks\task_1\command_000000_wrapper.bat`})
+	expected := []string{
+		"docker",
+		"run",
+		"--rm",
+		"--name", "abc_0_0",
+		"--isolation", "hyperv",
+		"--volume", `C:\tasks\task_1:C:\tasks\task_1`,
+		"--workdir", `C:\tasks\task_1`,
+		"--env", "TASKCLUSTER_PROXY_URL=http://taskcluster",
+		"--env", "Taskcluster_Worker_Location={}",
+		"mcr.microsoft.com/windows/nanoserver:ltsc2022",
+		"cmd.exe", "/c",
+		`C:\tasks\task_1\command_000000_wrapper.bat`,
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected docker run arguments\n%q\nbut got\n%q", expected, args)
+	}
+}
@@ -2,15 +2,27 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"log"
 	"os"
+	"sync"
+	"time"
 
 	tcclient "github.com/taskcluster/taskcluster/v60/clients/client-go"
+	"github.com/taskcluster/taskcluster/v60/internal/netconfig"
 	"github.com/taskcluster/taskcluster/v60/tools/workerproto"
 	"github.com/taskcluster/taskcluster/v60/workers/generic-worker/graceful"
 )
 
+const (
+	// How often to send heartbeat messages to worker-runner
+	heartbeatInterval = 10 * time.Second
+
+	// How long to wait for worker-runner to send the network configuration
+	networkConfigTimeout = 30 * time.Second
+)
+
 var (
 	// Support for communication betweeen this process and worker-runner.  This
 	// is initialized early in the `generic-worker run` process and can be used
@@ -19,6 +31,15 @@ var (
 
 	// The transport behind WorkerRunnerProtocol
 	workerRunnerTransport workerproto.Transport
+
+	// Closed once a network-config message from worker-runner has been
+	// applied
+	networkConfigured     chan struct{}
+	networkConfiguredOnce *sync.Once
+
+	// File containing the additional CA certificates given by worker-runner,
+	// for taskcluster-proxy, or "" if there are none
+	networkCAFile string
 )
 
 // A loggingWriter implements io.Writer and should be passed to a `log` instance
@@ -82,8 +103,16 @@ func startProtocol() {
 	WorkerRunnerProtocol.AddCapability("graceful-termination")
 	WorkerRunnerProtocol.Register("graceful-termination", func(msg workerproto.Message) {
 		finishTasks := msg.Properties["finish-tasks"].(bool)
-		log.Printf("Got graceful-termination request with finish-tasks=%v", finishTasks)
-		graceful.Terminate(finishTasks)
+		var deadline time.Time
+		if d, ok := msg.Properties["deadline"].(string); ok {
+			var err error
+			deadline, err = time.Parse(time.RFC3339, d)
+			if err != nil {
+				log.Printf("Ignoring invalid graceful-termination deadline %q: %v", d, err)
+			}
+		}
+		log.Printf("Got graceful-termination request with finish-tasks=%v deadline=%v", finishTasks, deadline)
+		graceful.TerminateBy(finishTasks, deadline)
 	})
 
 	WorkerRunnerProtocol.AddCapability("new-credentials")
@@ -96,10 +125,91 @@ func startProtocol() {
 		config.UpdateCredentials(&creds)
 	})
 
+	WorkerRunnerProtocol.AddCapability("config-update")
+	WorkerRunnerProtocol.Register("config-update", func(msg workerproto.Message) {
+		update, ok := msg.Properties["config"].(map[string]interface{})
+		if !ok {
+			log.Printf("Ignoring invalid config-update message")
+			return
+		}
+		queueConfigUpdate(update)
+	})
+
+	networkConfigured = make(chan struct{})
+	networkConfiguredOnce = &sync.Once{}
+	WorkerRunnerProtocol.AddCapability("network-config")
+	WorkerRunnerProtocol.Register("network-config", func(msg workerproto.Message) {
+		err := applyNetworkConfig(msg.Properties["config"])
+		if err != nil {
+			log.Printf("Error applying network-config: %v", err)
+		}
+		networkConfiguredOnce.Do(func() { close(networkConfigured) })
+	})
+
 	WorkerRunnerProtocol.AddCapability("error-report")
 	WorkerRunnerProtocol.AddCapability("log")
+	WorkerRunnerProtocol.AddCapability("heartbeat")
 
 	WorkerRunnerProtocol.Start(true)
+
+	go sendHeartbeats(WorkerRunnerProtocol)
+}
+
+// Apply the HTTP proxy and CA certificates from worker-runner to this process,
+// and so to its children such as taskcluster-proxy.
+func applyNetworkConfig(value interface{}) error {
+	j, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var network netconfig.Config
+	err = json.Unmarshal(j, &network)
+	if err != nil {
+		return err
+	}
+	err = network.Apply()
+	if err != nil {
+		return err
+	}
+	if network.CACertificates != "" {
+		f, err := os.CreateTemp("", "generic-worker-ca-*.pem")
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.WriteString(network.CACertificates)
+		if err != nil {
+			return err
+		}
+		networkCAFile = f.Name()
+	}
+	return nil
+}
+
+// Wait for worker-runner to send the network configuration, if it has any,
+// so that it applies to all requests this process makes.
+func waitForNetworkConfig() {
+	WorkerRunnerProtocol.WaitUntilInitialized()
+	if !WorkerRunnerProtocol.Capable("network-config") {
+		return
+	}
+	select {
+	case <-networkConfigured:
+	case <-time.After(networkConfigTimeout):
+		log.Printf("Did not receive network-config from worker-runner after %v; continuing without it", networkConfigTimeout)
+	}
+}
+
+// Send periodic heartbeats to worker-runner, if it supports them, so that it
+// can tell that this process has not hung.
+func sendHeartbeats(proto *workerproto.Protocol) {
+	proto.WaitUntilInitialized()
+	if !proto.Capable("heartbeat") {
+		return
+	}
+	for range time.Tick(heartbeatInterval) {
+		proto.Send(workerproto.Message{Type: "heartbeat"})
+	}
 }
 
 func teardownWorkerRunnerProtocol() {
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
@@ -45,7 +49,7 @@ func TestGracefulTermination(t *testing.T) {
 
 	done := make(chan bool, 1)
 
-	graceful.OnTerminationRequest(func(finishTasks bool) {
+	graceful.OnTerminationRequest(func(finishTasks bool, deadline time.Time) {
 		done <- finishTasks
 	})
 
@@ -61,6 +65,27 @@ func TestGracefulTermination(t *testing.T) {
 	require.True(t, graceful.TerminationRequested())
 }
 
+func TestGracefulTerminationWithDeadline(t *testing.T) {
+	runnerProto := setupWorkerRunnerTest(t, "graceful-termination")
+
+	done := make(chan time.Time, 1)
+
+	graceful.OnTerminationRequest(func(finishTasks bool, deadline time.Time) {
+		done <- deadline
+	})
+
+	runnerProto.Send(workerproto.Message{
+		Type: "graceful-termination",
+		Properties: map[string]interface{}{
+			"finish-tasks": false,
+			"deadline":     "2024-03-01T12:34:56Z",
+		},
+	})
+
+	deadline := <-done
+	require.Equal(t, time.Date(2024, 3, 1, 12, 34, 56, 0, time.UTC), deadline.UTC())
+}
+
 func TestNewCredentials(t *testing.T) {
 	runnerProto := setupWorkerRunnerTest(t, "new-credentials")
 
@@ -108,3 +133,90 @@ func TestNewCredentials(t *testing.T) {
 	t.Run("WithCertificate", test(true))
 	t.Run("WithoutCertificate", test(false))
 }
+
+func TestConfigUpdate(t *testing.T) {
+	runnerProto := setupWorkerRunnerTest(t, "config-update")
+	config = &gwconfig.Config{}
+	config.WorkerType = "old-worker-type"
+	config.ProvisionerID = "old-provisioner"
+	config.RootURL = "https://tc.example.com"
+
+	runnerProto.Send(workerproto.Message{
+		Type: "config-update",
+		Properties: map[string]interface{}{
+			"config": map[string]interface{}{
+				"paused":     true,
+				"workerType": "new-worker-type",
+				"rootURL":    "https://other.example.com",
+			},
+		},
+	})
+
+	// messages are handled asynchronously, so poll until seeing the queued
+	// update
+	for i := 0; i < 200; i++ {
+		pendingConfigUpdateMutex.Lock()
+		queued := len(pendingConfigUpdate)
+		pendingConfigUpdateMutex.Unlock()
+		if queued > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the update is not applied until the main loop applies it
+	require.False(t, config.Paused)
+
+	applyConfigUpdates()
+	require.True(t, config.Paused)
+	require.Equal(t, "new-worker-type", config.WorkerType)
+	require.Equal(t, "old-provisioner", config.ProvisionerID)
+	// properties which cannot be changed while running are ignored
+	require.Equal(t, "https://tc.example.com", config.RootURL)
+}
+
+func TestNetworkConfig(t *testing.T) {
+	runnerProto := setupWorkerRunnerTest(t, "network-config")
+	require.True(t, WorkerRunnerProtocol.Capable("network-config"))
+
+	transport := http.DefaultTransport.(*http.Transport)
+	oldProxy, oldTLSConfig := transport.Proxy, transport.TLSClientConfig
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+	defer func() {
+		transport.Proxy, transport.TLSClientConfig = oldProxy, oldTLSConfig
+		networkCAFile = ""
+	}()
+
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	runnerProto.Send(workerproto.Message{
+		Type: "network-config",
+		Properties: map[string]interface{}{
+			"config": map[string]interface{}{
+				"httpsProxy":     "http://proxy.example.com:3128",
+				"caCertificates": caCert,
+			},
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		waitForNetworkConfig()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("network-config was not applied")
+	}
+
+	require.Equal(t, "http://proxy.example.com:3128", os.Getenv("HTTPS_PROXY"))
+	require.NotEmpty(t, networkCAFile)
+	defer os.Remove(networkCAFile)
+	content, err := os.ReadFile(networkCAFile)
+	require.NoError(t, err)
+	require.Equal(t, caCert, string(content))
+}